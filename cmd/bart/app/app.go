@@ -6,6 +6,7 @@ package app
 
 import (
 	"github.com/urfave/cli/v2"
+	"time"
 )
 
 const (
@@ -29,6 +30,19 @@ type BartApp struct {
 	multiClass            bool
 	serverTimeoutSeconds  int
 	serverMaxRequestBytes int
+	memoryMap             bool
+	threads               int
+	admin                 bool
+	adminAddress          string
+	cacheSize             int
+	cacheTTL              time.Duration
+	jobsDB                string
+	jobWorkers            int
+	jobTTL                time.Duration
+	jobShutdownTimeout    time.Duration
+	nliEntailment         string
+	nliContradiction      string
+	nliNeutral            string
 }
 
 // NewBartApp returns a new BartApp object, which can be used as either client or server.
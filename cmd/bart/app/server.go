@@ -5,7 +5,10 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/calibration"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/bpetokenizer"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/sentencepiece"
@@ -14,13 +17,21 @@ import (
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/loader"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/server"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/huggingface"
+	"github.com/nlpodyssey/spago/pkg/plugins"
+	"github.com/nlpodyssey/spago/pkg/utils/asyncjobs"
 	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"github.com/nlpodyssey/spago/pkg/utils/kvdb"
+	"github.com/nlpodyssey/spago/pkg/utils/lrucache"
 	"github.com/urfave/cli/v2"
 	"log"
 	"os"
+	"os/signal"
 	"os/user"
 	"path"
 	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
 )
 
 func newServerCommandFor(app *BartApp) *cli.Command {
@@ -93,6 +104,84 @@ func newServerCommandFlagsFor(app *BartApp) []cli.Flag {
 			Value:       httputils.DefaultMaxRequestBytes,
 			Destination: &app.serverMaxRequestBytes,
 		},
+		&cli.IntFlag{
+			Name:        "threads",
+			Usage:       "Maximum number of computations running concurrently across all the requests.",
+			Value:       runtime.NumCPU(),
+			Destination: &app.threads,
+		},
+		&cli.BoolFlag{
+			Name:        "mmap",
+			Usage:       "Memory-maps the model weights read-only, so that the server processes of the host share them.",
+			Destination: &app.memoryMap,
+		},
+		&cli.BoolFlag{
+			Name:        "admin",
+			Usage:       "Enables the debug endpoints (pprof, goroutines dump, memory stats) under /debug/, served on the admin address.",
+			Destination: &app.admin,
+		},
+		&cli.StringFlag{
+			Name:        "admin-address",
+			Usage:       "Changes the bind address of the debug endpoints, which only accept local connections by default.",
+			Value:       httputils.DefaultAdminAddress,
+			Destination: &app.adminAddress,
+		},
+		&cli.IntFlag{
+			Name:        "cache-size",
+			Usage:       "Caches the responses of up to the given number of classify/answer/encode requests (0 disables the cache).",
+			Destination: &app.cacheSize,
+		},
+		&cli.DurationFlag{
+			Name:        "cache-ttl",
+			Usage:       "Expires the cached responses after the given duration (e.g. 10m; 0 for no expiration).",
+			Destination: &app.cacheTTL,
+		},
+		&cli.StringFlag{
+			Name:        "jobs-db",
+			Usage:       "Enables the asynchronous jobs API (/jobs/), persisting the jobs into the database at the given path.",
+			Destination: &app.jobsDB,
+		},
+		&cli.IntFlag{
+			Name:        "job-workers",
+			Usage:       "Number of asynchronous jobs running concurrently.",
+			Value:       1,
+			Destination: &app.jobWorkers,
+		},
+		&cli.DurationFlag{
+			Name:        "job-ttl",
+			Usage:       "Removes the completed jobs after the given duration (e.g. 24h; 0 keeps them for ever).",
+			Value:       24 * time.Hour,
+			Destination: &app.jobTTL,
+		},
+		&cli.StringSliceFlag{
+			Name:  "job-callback-host",
+			Usage: "Allows the callbacks of the jobs to the given host, with or without port (repeatable); callbacks are disabled by default.",
+		},
+		&cli.DurationFlag{
+			Name:        "job-shutdown-timeout",
+			Usage:       "On SIGINT or SIGTERM, waits up to the given duration for the submitted jobs to be completed.",
+			Value:       30 * time.Second,
+			Destination: &app.jobShutdownTimeout,
+		},
+		&cli.StringFlag{
+			Name:        "nli-entailment-label",
+			Usage:       "Label of the entailment class of the zero-shot classification model (default \"entailment\").",
+			Destination: &app.nliEntailment,
+		},
+		&cli.StringFlag{
+			Name:        "nli-contradiction-label",
+			Usage:       "Label of the contradiction class of the zero-shot classification model (default \"contradiction\").",
+			Destination: &app.nliContradiction,
+		},
+		&cli.StringFlag{
+			Name:        "nli-neutral-label",
+			Usage:       "Label of the neutral class, scored along with the contradiction in the multi-class zero-shot classification.",
+			Destination: &app.nliNeutral,
+		},
+		&cli.StringSliceFlag{
+			Name:  "plugin",
+			Usage: "Loads the Go plugin at the given path, whose routes are added to the server (repeatable).",
+		},
 	}
 }
 
@@ -104,7 +193,10 @@ func newServerCommandActionFor(app *BartApp) func(c *cli.Context) error {
 
 		modelPath := filepath.Join(app.repo, app.model)
 
-		model, err := loader.Load(modelPath)
+		if app.threads > 0 {
+			ag.SetGlobalThreadBudget(ag.NewThreadBudget(app.threads))
+		}
+		model, err := loader.Load(modelPath, loadOptions(app.memoryMap)...)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -155,13 +247,59 @@ func newServerCommandActionFor(app *BartApp) func(c *cli.Context) error {
 		s := server.NewServer(model, bpeTokenizer, spTokenizer)
 		s.TimeoutSeconds = app.serverTimeoutSeconds
 		s.MaxRequestBytes = app.serverMaxRequestBytes
+		if app.admin {
+			s.AdminAddress = app.adminAddress
+		}
+		s.NLILabels = server.NLILabels{
+			Entailment:    app.nliEntailment,
+			Contradiction: app.nliContradiction,
+			Neutral:       app.nliNeutral,
+		}
+		s.Calibrator, err = calibration.LoadFromModelPath(modelPath)
+		if err != nil {
+			return err
+		}
+		if app.cacheSize > 0 {
+			s.Cache = lrucache.New(app.cacheSize, app.cacheTTL)
+		}
+		if app.jobsDB != "" {
+			db := kvdb.NewDefaultKeyValueDB(kvdb.Config{Path: app.jobsDB})
+			defer db.Close()
+			s.Jobs, err = asyncjobs.NewManager(db, app.jobWorkers,
+				asyncjobs.TTL(app.jobTTL),
+				asyncjobs.CallbackHosts(c.StringSlice("job-callback-host")...))
+			if err != nil {
+				return err
+			}
+		}
+		s.Routes, err = loadPlugins(c.StringSlice("plugin"), plugins.Context{Model: model, ModelPath: modelPath})
+		if err != nil {
+			return err
+		}
 		s.StartDefaultHTTPServer(app.address, app.tlsCert, app.tlsKey, app.tlsDisable)
-		s.StartDefaultServer(app.grpcAddress, app.tlsCert, app.tlsKey, app.tlsDisable)
+		go s.StartDefaultServer(app.grpcAddress, app.tlsCert, app.tlsKey, app.tlsDisable)
 
+		waitForTermination()
+		if s.Jobs != nil {
+			fmt.Println("Shutting down the jobs...")
+			ctx, cancel := context.WithTimeout(context.Background(), app.jobShutdownTimeout)
+			defer cancel()
+			if err := s.Jobs.Shutdown(ctx); err != nil {
+				log.Printf("jobs left pending or running: %v", err)
+			}
+		}
 		return nil
 	}
 }
 
+// waitForTermination blocks until the process receives SIGINT or SIGTERM.
+func waitForTermination() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	<-ch
+	signal.Stop(ch)
+}
+
 const defaultModelFile = "spago_model.bin"
 
 func pullModel(app *BartApp) error {
@@ -199,3 +337,29 @@ func pullModel(app *BartApp) error {
 
 	return nil
 }
+
+// loadPlugins opens the Go plugins at the given paths, and returns the routes of all the registered
+// plugins that support the model.
+func loadPlugins(paths []string, ctx plugins.Context) ([]plugins.Route, error) {
+	for _, file := range paths {
+		if err := plugins.Open(file); err != nil {
+			return nil, err
+		}
+	}
+	routes, err := plugins.Routes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		fmt.Printf("Plugin route: %s\n", route.Pattern)
+	}
+	return routes, nil
+}
+
+// loadOptions returns the options for loading the model weights.
+func loadOptions(memoryMap bool) []nn.LoadOption {
+	if memoryMap {
+		return []nn.LoadOption{nn.MemoryMap()}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ package app
 
 import (
 	"github.com/urfave/cli/v2"
+	"time"
 )
 
 const (
@@ -27,8 +28,24 @@ type BertApp struct {
 	requestText2          string
 	passage               string
 	question              string
+	poolingStrategy       string
+	normalize             bool
 	serverTimeoutSeconds  int
 	serverMaxRequestBytes int
+	memoryMap             bool
+	threads               int
+	admin                 bool
+	adminAddress          string
+	cacheSize             int
+	cacheTTL              time.Duration
+	qaTemperature         float64
+	qaCalibrationSet      string
+	calibrationSet        string
+	calibrationMethod     string
+	squadDataset          string
+	squadURL              string
+	squadMinConfidence    float64
+	squadPredictions      string
 }
 
 // NewBertApp returns BertApp objects. The app can be used as both a client and a server.
@@ -42,6 +59,7 @@ func NewBertApp() *BertApp {
 	app.Commands = []*cli.Command{
 		newClientCommandFor(app),
 		newServerCommandFor(app),
+		newSquadCommandFor(app),
 	}
 	return app
 }
@@ -30,6 +30,17 @@ func newClientEncodeCommandFlagsFor(app *BertApp) []cli.Flag {
 			Destination: &app.requestText,
 			Required:    true,
 		},
+		&cli.StringFlag{
+			Name:        "pooling",
+			Usage:       "CLS_TOKEN, REDUCE_MEAN, REDUCE_MAX, REDUCE_MEAN_MAX or REDUCE_MEAN_LAST_4_LAYERS",
+			Value:       grpcapi.EncodeRequest_CLS_TOKEN.String(),
+			Destination: &app.poolingStrategy,
+		},
+		&cli.BoolFlag{
+			Name:        "normalize",
+			Usage:       "normalize the vector to unit length (L2 norm)",
+			Destination: &app.normalize,
+		},
 	})
 }
 
@@ -37,11 +48,18 @@ func newClientEncodeCommandActionFor(app *BertApp) func(c *cli.Context) error {
 	return func(c *cli.Context) error {
 		clientutils.VerifyFlags(app.output)
 
+		poolingStrategy, ok := grpcapi.EncodeRequest_PoolingStrategy_value[app.poolingStrategy]
+		if !ok {
+			log.Fatalf("invalid pooling strategy %q", app.poolingStrategy)
+		}
+
 		conn := clientutils.OpenConnection(app.address, app.tlsDisable)
 		client := grpcapi.NewBERTClient(conn)
 
 		resp, err := client.Encode(context.Background(), &grpcapi.EncodeRequest{
-			Text: app.requestText,
+			Text:            app.requestText,
+			PoolingStrategy: grpcapi.EncodeRequest_PoolingStrategy(poolingStrategy),
+			Normalize:       app.normalize,
 		})
 
 		if err != nil {
@@ -5,16 +5,25 @@
 package app
 
 import (
+	"encoding/json"
 	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/calibration"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/huggingface"
+	"github.com/nlpodyssey/spago/pkg/plugins"
 	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"github.com/nlpodyssey/spago/pkg/utils/lrucache"
 	"github.com/urfave/cli/v2"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/user"
 	"path"
 	"path/filepath"
+	"runtime"
 )
 
 func newServerCommandFor(app *BertApp) *cli.Command {
@@ -87,6 +96,64 @@ func newServerCommandFlagsFor(app *BertApp) []cli.Flag {
 			Value:       httputils.DefaultMaxRequestBytes,
 			Destination: &app.serverMaxRequestBytes,
 		},
+		&cli.IntFlag{
+			Name:        "threads",
+			Usage:       "Maximum number of computations running concurrently across all the requests.",
+			Value:       runtime.NumCPU(),
+			Destination: &app.threads,
+		},
+		&cli.BoolFlag{
+			Name:        "mmap",
+			Usage:       "Memory-maps the model weights read-only, so that the server processes of the host share them.",
+			Destination: &app.memoryMap,
+		},
+		&cli.BoolFlag{
+			Name:        "admin",
+			Usage:       "Enables the debug endpoints (pprof, goroutines dump, memory stats) under /debug/, served on the admin address.",
+			Destination: &app.admin,
+		},
+		&cli.StringFlag{
+			Name:        "admin-address",
+			Usage:       "Changes the bind address of the debug endpoints, which only accept local connections by default.",
+			Value:       httputils.DefaultAdminAddress,
+			Destination: &app.adminAddress,
+		},
+		&cli.IntFlag{
+			Name:        "cache-size",
+			Usage:       "Caches the responses of up to the given number of classify/answer/encode requests (0 disables the cache).",
+			Destination: &app.cacheSize,
+		},
+		&cli.DurationFlag{
+			Name:        "cache-ttl",
+			Usage:       "Expires the cached responses after the given duration (e.g. 10m; 0 for no expiration).",
+			Destination: &app.cacheTTL,
+		},
+		&cli.Float64Flag{
+			Name:        "qa-temperature",
+			Usage:       "Calibrates the confidence of the answers with the given temperature (temperature scaling).",
+			Value:       1,
+			Destination: &app.qaTemperature,
+		},
+		&cli.StringFlag{
+			Name:        "qa-calibration-set",
+			Usage:       "Learns the temperature of the answers on the dev set at the given path (a JSON array of question, passage and answer).",
+			Destination: &app.qaCalibrationSet,
+		},
+		&cli.StringFlag{
+			Name:        "calibration-set",
+			Usage:       "Learns the calibration of the classification on the dev set at the given path (a JSON array of text, text2 and label), and saves it with the model.",
+			Destination: &app.calibrationSet,
+		},
+		&cli.StringFlag{
+			Name:        "calibration-method",
+			Usage:       "Specifies the method of the calibration learned on the calibration set (temperature or isotonic).",
+			Value:       calibration.TemperatureMethod,
+			Destination: &app.calibrationMethod,
+		},
+		&cli.StringSliceFlag{
+			Name:  "plugin",
+			Usage: "Loads the Go plugin at the given path, whose routes are added to the server (repeatable).",
+		},
 	}
 }
 
@@ -123,7 +190,10 @@ func newServerCommandActionFor(app *BertApp) func(c *cli.Context) error {
 			}
 		}
 
-		model, err := bert.LoadModel(modelPath)
+		if app.threads > 0 {
+			ag.SetGlobalThreadBudget(ag.NewThreadBudget(app.threads))
+		}
+		model, err := bert.LoadModel(modelPath, loadOptions(app.memoryMap)...)
 		if err != nil {
 			log.Fatalf("error during model loading (%v)\n", err)
 		}
@@ -149,8 +219,97 @@ func newServerCommandActionFor(app *BertApp) func(c *cli.Context) error {
 		server := bert.NewServer(model)
 		server.TimeoutSeconds = app.serverTimeoutSeconds
 		server.MaxRequestBytes = app.serverMaxRequestBytes
+		if app.admin {
+			server.AdminAddress = app.adminAddress
+		}
+		server.AnswerTemperature = mat.Float(app.qaTemperature)
+		if app.qaCalibrationSet != "" {
+			examples, err := readQAExamples(app.qaCalibrationSet)
+			if err != nil {
+				return err
+			}
+			server.AnswerTemperature = server.FitAnswerTemperature(examples)
+			fmt.Printf("QA temperature: %v\n", server.AnswerTemperature)
+		}
+		server.Calibrator, err = loadCalibrator(server, modelPath, app.calibrationSet, app.calibrationMethod)
+		if err != nil {
+			return err
+		}
+		if app.cacheSize > 0 {
+			server.Cache = lrucache.New(app.cacheSize, app.cacheTTL)
+		}
+		server.Routes, err = loadPlugins(c.StringSlice("plugin"), plugins.Context{Model: model, ModelPath: modelPath})
+		if err != nil {
+			return err
+		}
 		server.StartDefaultServer(app.address, app.grpcAddress, app.tlsCert, app.tlsKey, app.tlsDisable)
 
 		return nil
 	}
 }
+
+// loadPlugins opens the Go plugins at the given paths, and returns the routes of all the registered
+// plugins that support the model.
+func loadPlugins(paths []string, ctx plugins.Context) ([]plugins.Route, error) {
+	for _, file := range paths {
+		if err := plugins.Open(file); err != nil {
+			return nil, err
+		}
+	}
+	routes, err := plugins.Routes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		fmt.Printf("Plugin route: %s\n", route.Pattern)
+	}
+	return routes, nil
+}
+
+// readQAExamples reads a JSON array of question-answering examples.
+func readQAExamples(filename string) ([]bert.QAExample, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var examples []bert.QAExample
+	if err := json.Unmarshal(data, &examples); err != nil {
+		return nil, err
+	}
+	return examples, nil
+}
+
+// loadCalibrator returns the calibrator of the classification learned on the calibration set, if
+// any, saving it in the directory of the model; otherwise, the one previously saved, if present.
+func loadCalibrator(server *bert.Server, modelPath, calibrationSet, method string) (calibration.Calibrator, error) {
+	if calibrationSet == "" {
+		calibrator, err := calibration.LoadFromModelPath(modelPath)
+		if calibrator != nil {
+			fmt.Printf("Calibration: %s\n", filepath.Join(modelPath, calibration.DefaultFilename))
+		}
+		return calibrator, err
+	}
+	data, err := ioutil.ReadFile(calibrationSet)
+	if err != nil {
+		return nil, err
+	}
+	var examples []bert.ClassificationExample
+	if err := json.Unmarshal(data, &examples); err != nil {
+		return nil, err
+	}
+	calibrator, err := server.FitCalibrator(examples, method)
+	if err != nil {
+		return nil, err
+	}
+	filename := filepath.Join(modelPath, calibration.DefaultFilename)
+	fmt.Printf("Calibration (%s): %s\n", method, filename)
+	return calibrator, calibration.Save(filename, calibrator)
+}
+
+// loadOptions returns the options for loading the model weights.
+func loadOptions(memoryMap bool) []nn.LoadOption {
+	if memoryMap {
+		return []nn.LoadOption{nn.MemoryMap()}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/nlp/evaluation/squad"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
+	"github.com/urfave/cli/v2"
+	"io/ioutil"
+	"log"
+	"os/user"
+	"path"
+	"path/filepath"
+)
+
+func newSquadCommandFor(app *BertApp) *cli.Command {
+	return &cli.Command{
+		Name:        "squad",
+		Usage:       "Evaluate the question-answering on a SQuAD dataset.",
+		Description: "Compute the exact match and F1 scores of the answers of a local model, or of a running " + programName + ", on a SQuAD v1.1 or v2.0 JSON dataset.",
+		Flags:       newSquadCommandFlagsFor(app),
+		Action:      newSquadCommandActionFor(app),
+	}
+}
+
+func newSquadCommandFlagsFor(app *BertApp) []cli.Flag {
+	usr, err := user.Current()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "dataset",
+			Required:    true,
+			Usage:       "Specifies the path of the SQuAD JSON dataset.",
+			Destination: &app.squadDataset,
+		},
+		&cli.StringFlag{
+			Name:        "url",
+			Usage:       "Specifies the URL of the answer endpoint of a running server, instead of a local model (e.g. http://localhost:1987/answer).",
+			Destination: &app.squadURL,
+		},
+		&cli.StringFlag{
+			Name:        "repo",
+			Usage:       "Specifies the path to the models.",
+			Value:       path.Join(usr.HomeDir, ".spago"),
+			Destination: &app.repo,
+		},
+		&cli.StringFlag{
+			Name:        "model, m",
+			Usage:       "Specifies the name of the local model.",
+			Destination: &app.model,
+		},
+		&cli.Float64Flag{
+			Name:        "qa-temperature",
+			Usage:       "Calibrates the confidence of the answers of the local model with the given temperature.",
+			Value:       1,
+			Destination: &app.qaTemperature,
+		},
+		&cli.Float64Flag{
+			Name:        "min-confidence",
+			Usage:       "Considers the question impossible if the confidence of the best answer is lower (SQuAD v2.0).",
+			Destination: &app.squadMinConfidence,
+		},
+		&cli.StringFlag{
+			Name:        "predictions",
+			Usage:       "Writes the answers, by question ID, to the given JSON file.",
+			Destination: &app.squadPredictions,
+		},
+	}
+}
+
+func newSquadCommandActionFor(app *BertApp) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		questions, err := squad.LoadFile(app.squadDataset)
+		if err != nil {
+			return err
+		}
+
+		var model squad.Model
+		switch {
+		case app.squadURL != "":
+			model = &squad.HTTPModel{URL: app.squadURL, MinConfidence: app.squadMinConfidence}
+		case app.model != "":
+			bertModel, err := bert.LoadModel(filepath.Join(app.repo, app.model))
+			if err != nil {
+				return err
+			}
+			server := bert.NewServer(bertModel)
+			server.AnswerTemperature = mat.Float(app.qaTemperature)
+			model = &squad.BERTModel{Server: server, MinConfidence: app.squadMinConfidence}
+		default:
+			return fmt.Errorf("either the url of a server or a local model is required")
+		}
+
+		result, predictions, err := squad.Evaluate(questions, model)
+		if err != nil {
+			return err
+		}
+		if app.squadPredictions != "" {
+			data, err := json.MarshalIndent(predictions, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(app.squadPredictions, data, 0644); err != nil {
+				return err
+			}
+		}
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+}
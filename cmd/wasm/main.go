@@ -0,0 +1,16 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build js,wasm
+
+// This program exposes lightweight spaGO models to JavaScript as the global "spago" object, when
+// compiled to WebAssembly. See the README for the build instructions.
+package main
+
+import "github.com/nlpodyssey/spago/pkg/wasm"
+
+func main() {
+	wasm.Register("spago")
+	select {} // keep the functions available to JavaScript
+}
@@ -0,0 +1,60 @@
+#!/usr/bin/env bash
+
+# Copyright 2021 spaGO Authors. All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+set -e
+
+script_name=$(basename "$0")
+usage="
+This is a small utility script to build the mobile library of spaGO (package
+\"pkg/mobile\") with gomobile, for on-device text classification and named
+entities recognition.
+
+The build is tuned to reduce the size of the library: the symbol tables and
+the debug information are stripped (-ldflags \"-s -w\"), the file system paths
+are removed (-trimpath), and only the 64-bit architectures are targeted, which
+are the ones of the current devices.
+
+Usage: $script_name [OPTION]
+
+Options:
+  -h, -help, --help    Show usage and exit
+  android              Build build/spago.aar (requires the Android NDK)
+  ios                  Build build/Spago.xcframework (requires Xcode)
+
+Requirements:
+  go install golang.org/x/mobile/cmd/gomobile@latest
+  gomobile init
+  go get -d golang.org/x/mobile/bind
+"
+
+if [[ $# -ne 1 ]]; then
+  echo "Wrong number of parameters."
+  echo "$usage"
+  exit 1
+fi
+
+flags=(-ldflags="-s -w" -trimpath)
+
+case "$1" in
+  -h | -help | --help)
+    echo "$usage"
+    exit 0
+    ;;
+  android)
+    mkdir -p build
+    gomobile bind "${flags[@]}" -target=android/arm64,android/amd64 -javapkg=com.nlpodyssey.spago \
+      -o build/spago.aar ./pkg/mobile
+    ;;
+  ios)
+    mkdir -p build
+    gomobile bind "${flags[@]}" -target=ios -prefix=Spago -o build/Spago.xcframework ./pkg/mobile
+    ;;
+  *)
+    echo "Invalid option."
+    echo "$usage"
+    exit 1
+    ;;
+esac
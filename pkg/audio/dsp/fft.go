@@ -0,0 +1,70 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"math"
+	"math/cmplx"
+)
+
+// FFT returns the discrete Fourier transform of x, computed by the iterative radix-2 Cooley-Tukey
+// algorithm. The length of x must be a power of two.
+func FFT(x []complex128) []complex128 {
+	n := len(x)
+	if !isPowerOfTwo(n) {
+		panic("dsp: the length of the FFT must be a power of two")
+	}
+	out := make([]complex128, n)
+	bits := 0
+	for 1<<bits < n {
+		bits++
+	}
+	for i, v := range x {
+		out[reverseBits(i, bits)] = v
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := out[start+k], w*out[start+k+size/2]
+				out[start+k], out[start+k+size/2] = even+odd, even-odd
+				w *= step
+			}
+		}
+	}
+	return out
+}
+
+// PowerSpectrum returns the squared magnitudes of the first nFFT/2+1 bins of the FFT of the frame,
+// from the zero frequency to the Nyquist frequency. The frame is padded with zeros (or truncated)
+// to nFFT samples, which must be a power of two.
+func PowerSpectrum(frame []mat.Float, nFFT int) []mat.Float {
+	x := make([]complex128, nFFT)
+	for i := 0; i < len(frame) && i < nFFT; i++ {
+		x[i] = complex(float64(frame[i]), 0)
+	}
+	spectrum := FFT(x)
+	power := make([]mat.Float, nFFT/2+1)
+	for i := range power {
+		re, im := real(spectrum[i]), imag(spectrum[i])
+		power[i] = mat.Float(re*re + im*im)
+	}
+	return power
+}
+
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
+
+// reverseBits returns i with its lowest bits in reverse order.
+func reverseBits(i, bits int) int {
+	r := 0
+	for b := 0; b < bits; b++ {
+		r = r<<1 | (i>>b)&1
+	}
+	return r
+}
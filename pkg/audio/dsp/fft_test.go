@@ -0,0 +1,48 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestFFT(t *testing.T) {
+	x := []complex128{1, 2, 3, 4, 0, -1, 2, 0.5}
+	out := FFT(x)
+	// the naive discrete Fourier transform
+	for k := range x {
+		var expected complex128
+		for n, v := range x {
+			expected += v * cmplx.Exp(complex(0, -2*math.Pi*float64(k*n)/float64(len(x))))
+		}
+		assert.InDelta(t, real(expected), real(out[k]), 1.0e-9)
+		assert.InDelta(t, imag(expected), imag(out[k]), 1.0e-9)
+	}
+	assert.Equal(t, []complex128{5}, FFT([]complex128{5}))
+	assert.Panics(t, func() { FFT(make([]complex128, 6)) })
+}
+
+func TestPowerSpectrum(t *testing.T) {
+	// a cosine with 2 cycles in 16 samples has all its power in the bin 2
+	frame := make([]mat.Float, 16)
+	for n := range frame {
+		frame[n] = mat.Cos(2 * mat.Pi * 2 * mat.Float(n) / 16)
+	}
+	power := PowerSpectrum(frame, 16)
+	assert.Len(t, power, 9)
+	for k, p := range power {
+		if k == 2 {
+			assert.InDelta(t, 64, p, 1.0e-3)
+		} else {
+			assert.InDelta(t, 0, p, 1.0e-3)
+		}
+	}
+	// zero-padding
+	assert.Len(t, PowerSpectrum(frame[:10], 32), 17)
+}
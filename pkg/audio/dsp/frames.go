@@ -0,0 +1,79 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dsp provides the digital signal processing of the audio frontends: the signal is split
+// into overlapping frames, which are windowed and transformed into power spectra by the FFT, then
+// mapped on the mel scale by a filterbank. The resulting log-mel spectrogram is a matrix with a
+// row for each frame, consumable by the convolution and recurrent layers.
+package dsp
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/utils"
+)
+
+// Frame splits the signal into frames of the given length, starting every hopLength samples.
+// The last frame is padded with zeros, so that all the samples are included; a signal shorter than
+// a frame has a single padded frame, and an empty signal none.
+func Frame(signal []mat.Float, frameLength, hopLength int) [][]mat.Float {
+	if frameLength < 1 || hopLength < 1 {
+		panic("dsp: the frame and the hop lengths must be positive")
+	}
+	if len(signal) == 0 {
+		return nil
+	}
+	numFrames := 1
+	if len(signal) > frameLength {
+		numFrames += (len(signal) - frameLength + hopLength - 1) / hopLength
+	}
+	frames := make([][]mat.Float, numFrames)
+	for i := range frames {
+		frames[i] = make([]mat.Float, frameLength)
+		start := i * hopLength
+		copy(frames[i], signal[start:utils.MinInt(start+frameLength, len(signal))])
+	}
+	return frames
+}
+
+// PreEmphasis returns the signal filtered by y[t] = x[t] - coefficient * x[t-1], which boosts the
+// high frequencies (the coefficient is usually 0.97).
+func PreEmphasis(signal []mat.Float, coefficient mat.Float) []mat.Float {
+	out := make([]mat.Float, len(signal))
+	for t, x := range signal {
+		out[t] = x
+		if t > 0 {
+			out[t] -= coefficient * signal[t-1]
+		}
+	}
+	return out
+}
+
+// HannWindow returns the periodic Hann window of the given length.
+func HannWindow(length int) []mat.Float {
+	return cosineWindow(length, 0.5)
+}
+
+// HammingWindow returns the periodic Hamming window of the given length.
+func HammingWindow(length int) []mat.Float {
+	return cosineWindow(length, 0.54)
+}
+
+// cosineWindow returns the periodic window w[n] = a - (1 - a) * cos(2πn / length).
+func cosineWindow(length int, a mat.Float) []mat.Float {
+	w := make([]mat.Float, length)
+	for n := range w {
+		w[n] = a - (1-a)*mat.Cos(2*mat.Pi*mat.Float(n)/mat.Float(length))
+	}
+	return w
+}
+
+// ApplyWindow multiplies the frame by the window, in place.
+func ApplyWindow(frame, window []mat.Float) {
+	if len(frame) != len(window) {
+		panic("dsp: the frame and the window must have the same length")
+	}
+	for i, w := range window {
+		frame[i] *= w
+	}
+}
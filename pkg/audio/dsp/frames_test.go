@@ -0,0 +1,34 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFrame(t *testing.T) {
+	signal := []mat.Float{1, 2, 3, 4, 5, 6, 7}
+	assert.Equal(t, [][]mat.Float{{1, 2, 3, 4}, {3, 4, 5, 6}, {5, 6, 7, 0}}, Frame(signal, 4, 2))
+	assert.Equal(t, [][]mat.Float{{1, 2, 3, 4, 5, 6, 7, 0}}, Frame(signal, 8, 2))
+	assert.Equal(t, [][]mat.Float{{1, 2, 3, 4, 5, 6, 7}}, Frame(signal, 7, 3))
+	assert.Nil(t, Frame(nil, 4, 2))
+	assert.Panics(t, func() { Frame(signal, 4, 0) })
+}
+
+func TestPreEmphasis(t *testing.T) {
+	assert.InDeltaSlice(t, []mat.Float{1, 1.5, 2}, PreEmphasis([]mat.Float{1, 2, 3}, 0.5), 1.0e-6)
+}
+
+func TestWindows(t *testing.T) {
+	assert.InDeltaSlice(t, []mat.Float{0, 0.5, 1, 0.5}, HannWindow(4), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.08, 0.54, 1, 0.54}, HammingWindow(4), 1.0e-6)
+
+	frame := []mat.Float{2, 2, 2, 2}
+	ApplyWindow(frame, HannWindow(4))
+	assert.InDeltaSlice(t, []mat.Float{0, 1, 2, 1}, frame, 1.0e-6)
+	assert.Panics(t, func() { ApplyWindow(frame, HannWindow(3)) })
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+// logFloor is added to the mel energies before the logarithm, so that the silence is finite.
+const logFloor mat.Float = 1e-10
+
+// Config provides configuration settings for a LogMelSpectrogram.
+type Config struct {
+	// SampleRate is the number of samples per second of the signal.
+	SampleRate int
+	// FrameLength and HopLength are the number of samples of a frame, and between the start of two
+	// consecutive frames.
+	FrameLength int
+	HopLength   int
+	// NFFT is the size of the FFT, a power of two not less than FrameLength (the smallest one if zero).
+	NFFT int
+	// NumMels is the number of mel filters, that is the number of features of a frame.
+	NumMels int
+	// FMin and FMax are the frequency range of the filters, in Hz (FMax is SampleRate/2 if zero).
+	FMin mat.Float
+	FMax mat.Float
+	// PreEmphasis is the coefficient of the pre-emphasis filter (see PreEmphasis); zero disables it.
+	PreEmphasis mat.Float
+	// Window returns the window applied to the frames (HannWindow if nil).
+	Window func(length int) []mat.Float
+}
+
+// DefaultConfig returns the usual configuration of the speech frontends for the given sample rate:
+// frames of 25ms every 10ms, 40 mel filters and a pre-emphasis of 0.97.
+func DefaultConfig(sampleRate int) Config {
+	return Config{
+		SampleRate:  sampleRate,
+		FrameLength: sampleRate * 25 / 1000,
+		HopLength:   sampleRate * 10 / 1000,
+		NumMels:     40,
+		PreEmphasis: 0.97,
+	}
+}
+
+// withDefaults returns the configuration with the zero values replaced by their defaults.
+func (c Config) withDefaults() Config {
+	if c.NFFT == 0 {
+		c.NFFT = 1
+		for c.NFFT < c.FrameLength {
+			c.NFFT <<= 1
+		}
+	}
+	if c.FMax == 0 {
+		c.FMax = mat.Float(c.SampleRate) / 2
+	}
+	if c.Window == nil {
+		c.Window = HannWindow
+	}
+	return c
+}
+
+// LogMelSpectrogram returns the log-mel spectrogram of the signal: a matrix with a row for each
+// frame (see Frame) and a column for each mel filter, holding the natural logarithm of the energy
+// of the filter. It panics if the configuration is invalid.
+func LogMelSpectrogram(signal []mat.Float, config Config) *mat.Dense {
+	c := config.withDefaults()
+	if c.SampleRate < 1 || c.NumMels < 1 || c.NFFT < c.FrameLength || !isPowerOfTwo(c.NFFT) {
+		panic("dsp: invalid log-mel spectrogram configuration")
+	}
+	if c.PreEmphasis != 0 {
+		signal = PreEmphasis(signal, c.PreEmphasis)
+	}
+	filters := MelFilterBank(c.NumMels, c.NFFT, c.SampleRate, c.FMin, c.FMax)
+	window := c.Window(c.FrameLength)
+	frames := Frame(signal, c.FrameLength, c.HopLength)
+
+	out := mat.NewEmptyDense(len(frames), c.NumMels)
+	for i, frame := range frames {
+		ApplyWindow(frame, window)
+		power := PowerSpectrum(frame, c.NFFT)
+		for j, filter := range filters {
+			var energy mat.Float
+			for k, w := range filter {
+				energy += w * power[k]
+			}
+			out.Set(i, j, mat.Log(energy+logFloor))
+		}
+	}
+	return out
+}
+
+// MelFilterBank returns numMels triangular filters, equally spaced on the mel scale between fMin
+// and fMax, each one with a weight for each of the nFFT/2+1 bins of a power spectrum (see
+// PowerSpectrum). A filter peaks with weight one at its center, and reaches zero at the centers of
+// the neighboring filters.
+func MelFilterBank(numMels, nFFT, sampleRate int, fMin, fMax mat.Float) [][]mat.Float {
+	if fMin < 0 || fMax <= fMin || fMax > mat.Float(sampleRate)/2 {
+		panic("dsp: invalid frequency range of the mel filters")
+	}
+	// the edges of the filters: the i-th filter spans from the i-th to the (i+2)-th
+	minMel, maxMel := HzToMel(fMin), HzToMel(fMax)
+	edges := make([]mat.Float, numMels+2)
+	for i := range edges {
+		edges[i] = MelToHz(minMel + (maxMel-minMel)*mat.Float(i)/mat.Float(numMels+1))
+	}
+	filters := make([][]mat.Float, numMels)
+	for i := range filters {
+		filters[i] = make([]mat.Float, nFFT/2+1)
+		left, center, right := edges[i], edges[i+1], edges[i+2]
+		for k := range filters[i] {
+			f := mat.Float(k) * mat.Float(sampleRate) / mat.Float(nFFT)
+			if rising := (f - left) / (center - left); f <= center {
+				filters[i][k] = mat.Max(0, rising)
+			} else {
+				filters[i][k] = mat.Max(0, (right-f)/(right-center))
+			}
+		}
+	}
+	return filters
+}
+
+// HzToMel converts a frequency in Hz to the mel scale (HTK formula).
+func HzToMel(hz mat.Float) mat.Float {
+	return 2595 * mat.Log(1+hz/700) / mat.Log(10)
+}
+
+// MelToHz converts a frequency on the mel scale to Hz (HTK formula).
+func MelToHz(mel mat.Float) mat.Float {
+	return 700 * (mat.Pow(10, mel/2595) - 1)
+}
+
+// Normalize standardizes each column (feature) of the spectrogram to zero mean and unit variance
+// over the frames, in place, which removes the constant gain of the recording channel.
+func Normalize(spectrogram *mat.Dense) {
+	rows, cols := spectrogram.Dims()
+	if rows == 0 {
+		return
+	}
+	for j := 0; j < cols; j++ {
+		var mean, variance mat.Float
+		for i := 0; i < rows; i++ {
+			mean += spectrogram.At(i, j)
+		}
+		mean /= mat.Float(rows)
+		for i := 0; i < rows; i++ {
+			d := spectrogram.At(i, j) - mean
+			variance += d * d
+		}
+		std := mat.Sqrt(variance / mat.Float(rows))
+		for i := 0; i < rows; i++ {
+			v := spectrogram.At(i, j) - mean
+			if std > 0 {
+				v /= std
+			}
+			spectrogram.Set(i, j, v)
+		}
+	}
+}
+
+// Sequence returns the rows of the spectrogram as a sequence of vectors, one for each frame, as
+// the input of the recurrent and one-dimensional convolution layers. The spectrogram itself is the
+// single-channel input of the two-dimensional convolution layers.
+func Sequence(spectrogram *mat.Dense) []mat.Matrix {
+	out := make([]mat.Matrix, spectrogram.Rows())
+	for i := range out {
+		out[i] = spectrogram.ExtractRow(i)
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMelScale(t *testing.T) {
+	assert.InDelta(t, 0, HzToMel(0), 1.0e-4)
+	assert.InDelta(t, 1000, HzToMel(1000), 0.1)
+	assert.InDelta(t, 440, MelToHz(HzToMel(440)), 1.0e-2)
+}
+
+func TestMelFilterBank(t *testing.T) {
+	filters := MelFilterBank(10, 512, 16000, 0, 8000)
+	assert.Len(t, filters, 10)
+	for i, filter := range filters {
+		assert.Len(t, filter, 257)
+		peak := 0
+		for k, w := range filter {
+			assert.True(t, w >= 0 && w <= 1)
+			if w > filter[peak] {
+				peak = k
+			}
+		}
+		assert.True(t, filter[peak] > 0.5)
+		if i > 0 {
+			assert.True(t, peak > 0) // the centers increase
+		}
+	}
+	assert.Panics(t, func() { MelFilterBank(10, 512, 16000, 0, 9000) })
+}
+
+func TestLogMelSpectrogram(t *testing.T) {
+	// one second of a 1kHz tone, and one of a 4kHz tone
+	config := DefaultConfig(16000)
+	signal := make([]mat.Float, 32000)
+	for n := range signal {
+		freq := mat.Float(1000)
+		if n >= 16000 {
+			freq = 4000
+		}
+		signal[n] = mat.Sin(2 * mat.Pi * freq * mat.Float(n) / 16000)
+	}
+	spectrogram := LogMelSpectrogram(signal, config)
+	rows, cols := spectrogram.Dims()
+	assert.Equal(t, 40, cols)
+	assert.Equal(t, 1+(32000-400+159)/160, rows)
+
+	first, last := argMax(spectrogram.ExtractRow(10).Data()), argMax(spectrogram.ExtractRow(rows-10).Data())
+	assert.True(t, last > first, "the higher tone must peak in a higher mel filter")
+	assert.InDelta(t, 1000, MelToHz(HzToMel(8000)*mat.Float(first+1)/41), 150)
+
+	Normalize(spectrogram)
+	var mean mat.Float
+	for i := 0; i < rows; i++ {
+		mean += spectrogram.At(i, first)
+	}
+	assert.InDelta(t, 0, mean/mat.Float(rows), 1.0e-3)
+
+	sequence := Sequence(spectrogram)
+	assert.Len(t, sequence, rows)
+	assert.Equal(t, 40, sequence[0].Size())
+
+	assert.Equal(t, 0, LogMelSpectrogram(nil, config).Rows())
+	assert.Panics(t, func() { LogMelSpectrogram(signal, Config{SampleRate: 16000, FrameLength: 400, NFFT: 300, NumMels: 40}) })
+}
+
+func argMax(xs []mat.Float) int {
+	best := 0
+	for i, x := range xs {
+		if x > xs[best] {
+			best = i
+		}
+	}
+	return best
+}
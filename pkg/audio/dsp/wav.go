@@ -0,0 +1,120 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+)
+
+const (
+	wavFormatPCM   = 1
+	wavFormatFloat = 3
+)
+
+// ReadWAVFile reads the WAV file at the given path (see ReadWAV).
+func ReadWAVFile(filename string) (samples []mat.Float, sampleRate int, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	return ReadWAV(f)
+}
+
+// ReadWAV reads a WAV stream, with samples in linear PCM of 8, 16, 24 or 32 bits or in 32-bit
+// floating point, and returns its samples in [-1, 1] and its sample rate. The channels of a
+// multi-channel stream are averaged.
+func ReadWAV(r io.Reader) (samples []mat.Float, sampleRate int, err error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, 0, err
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, 0, errors.New("dsp: not a WAV stream")
+	}
+
+	var format, channels, bitsPerSample int
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			if err == io.EOF {
+				return nil, 0, errors.New("dsp: WAV stream without data")
+			}
+			return nil, 0, err
+		}
+		id, size := string(chunk[0:4]), int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		switch id {
+		case "fmt ":
+			data := make([]byte, size+size%2) // the chunks are padded to an even size
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, 0, err
+			}
+			if size < 16 {
+				return nil, 0, errors.New("dsp: invalid WAV format chunk")
+			}
+			format = int(binary.LittleEndian.Uint16(data[0:2]))
+			channels = int(binary.LittleEndian.Uint16(data[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(data[4:8]))
+			bitsPerSample = int(binary.LittleEndian.Uint16(data[14:16]))
+			if format == 0xFFFE && size >= 26 { // WAVE_FORMAT_EXTENSIBLE: the format is in the sub-format
+				format = int(binary.LittleEndian.Uint16(data[24:26]))
+			}
+		case "data":
+			if channels == 0 {
+				return nil, 0, errors.New("dsp: WAV data before the format chunk")
+			}
+			data := make([]byte, size)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, 0, err
+			}
+			samples, err := decodeWAVSamples(data, format, channels, bitsPerSample)
+			return samples, sampleRate, err
+		default:
+			if _, err := io.CopyN(ioutil.Discard, r, size+size%2); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+}
+
+// decodeWAVSamples decodes the interleaved samples of the data chunk, averaging the channels.
+func decodeWAVSamples(data []byte, format, channels, bitsPerSample int) ([]mat.Float, error) {
+	var decode func(b []byte) float64
+	switch {
+	case format == wavFormatPCM && bitsPerSample == 8:
+		decode = func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case format == wavFormatPCM && bitsPerSample == 16:
+		decode = func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15) }
+	case format == wavFormatPCM && bitsPerSample == 24:
+		decode = func(b []byte) float64 {
+			return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / (1 << 23)
+		}
+	case format == wavFormatPCM && bitsPerSample == 32:
+		decode = func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }
+	case format == wavFormatFloat && bitsPerSample == 32:
+		decode = func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	default:
+		return nil, fmt.Errorf("dsp: unsupported WAV format %d with %d bits per sample", format, bitsPerSample)
+	}
+	bytesPerSample := bitsPerSample / 8
+	frameSize := bytesPerSample * channels
+	samples := make([]mat.Float, len(data)/frameSize)
+	for i := range samples {
+		var sum float64
+		for c := 0; c < channels; c++ {
+			offset := i*frameSize + c*bytesPerSample
+			sum += decode(data[offset : offset+bytesPerSample])
+		}
+		samples[i] = mat.Float(sum / float64(channels))
+	}
+	return samples, nil
+}
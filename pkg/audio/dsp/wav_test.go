@@ -0,0 +1,67 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// wav returns a WAV stream with the given format and data, and an unknown chunk before the data.
+func wav(format, channels, sampleRate, bitsPerSample int, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+24+10+8+len(data)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(format))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*channels*bitsPerSample/8))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels*bitsPerSample/8))
+	binary.Write(&buf, binary.LittleEndian, uint16(bitsPerSample))
+	buf.WriteString("LIST")
+	binary.Write(&buf, binary.LittleEndian, uint32(1)) // odd size: padded
+	buf.Write([]byte{0, 0})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestReadWAV(t *testing.T) {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, []int16{16384, -16384, 32767, 32767})
+	samples, sampleRate, err := ReadWAV(bytes.NewReader(wav(wavFormatPCM, 2, 8000, 16, data.Bytes())))
+	assert.NoError(t, err)
+	assert.Equal(t, 8000, sampleRate)
+	assert.InDeltaSlice(t, []mat.Float{0, 1}, samples, 1.0e-4)
+
+	data.Reset()
+	binary.Write(&data, binary.LittleEndian, []float32{0.25, -0.5})
+	samples, _, err = ReadWAV(bytes.NewReader(wav(wavFormatFloat, 1, 16000, 32, data.Bytes())))
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []mat.Float{0.25, -0.5}, samples, 1.0e-6)
+
+	samples, _, err = ReadWAV(bytes.NewReader(wav(wavFormatPCM, 1, 16000, 24, []byte{0, 0, 0x40, 0, 0, 0xC0})))
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []mat.Float{0.5, -0.5}, samples, 1.0e-6)
+
+	samples, _, err = ReadWAV(bytes.NewReader(wav(wavFormatPCM, 1, 16000, 8, []byte{128, 192})))
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []mat.Float{0, 0.5}, samples, 1.0e-6)
+}
+
+func TestReadWAVErrors(t *testing.T) {
+	_, _, err := ReadWAV(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00AVI ")))
+	assert.Error(t, err)
+	_, _, err = ReadWAV(bytes.NewReader(wav(wavFormatPCM, 1, 16000, 12, []byte{0, 0})))
+	assert.Error(t, err)
+	_, _, err = ReadWAV(bytes.NewReader([]byte("RIFF\x04\x00\x00\x00WAVE")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mat32
+
+// IsBroadcastable reports whether the other matrix can be broadcast against m in the
+// element-wise operations (Add, Sub, Prod), that is if it is a row vector with the same
+// number of columns of m, or a column vector with the same number of rows of m.
+// The row vector is repeated for each row of m, and the column vector for each column.
+func IsBroadcastable(m, other Matrix) bool {
+	rows, cols := m.Dims()
+	oRows, oCols := other.Dims()
+	return (oRows == 1 && oCols == cols && rows > 1) || (oCols == 1 && oRows == rows && cols > 1)
+}
+
+// broadcastTo writes to out the result of op applied to each element of the receiver and
+// the corresponding element of the other matrix, which is broadcast against the receiver.
+// out can be the receiver data itself.
+func (d *Dense) broadcastTo(out []Float, other Matrix, op func(a, b Float) Float) {
+	oData := other.Data()
+	if other.Rows() == 1 {
+		for i := 0; i < d.rows; i++ {
+			offset := i * d.cols
+			for j, b := range oData {
+				out[offset+j] = op(d.data[offset+j], b)
+			}
+		}
+		return
+	}
+	for i, b := range oData {
+		offset := i * d.cols
+		for j := 0; j < d.cols; j++ {
+			out[offset+j] = op(d.data[offset+j], b)
+		}
+	}
+}
+
+func addOp(a, b Float) Float  { return a + b }
+func subOp(a, b Float) Float  { return a - b }
+func prodOp(a, b Float) Float { return a * b }
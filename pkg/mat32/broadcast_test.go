@@ -0,0 +1,59 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mat32
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIsBroadcastable(t *testing.T) {
+	m := NewEmptyDense(2, 3)
+	assert.True(t, IsBroadcastable(m, NewEmptyDense(1, 3)))
+	assert.True(t, IsBroadcastable(m, NewEmptyDense(2, 1)))
+	assert.False(t, IsBroadcastable(m, NewEmptyDense(2, 3)))
+	assert.False(t, IsBroadcastable(m, NewEmptyDense(1, 2)))
+	assert.False(t, IsBroadcastable(m, NewEmptyDense(3, 1)))
+	assert.False(t, IsBroadcastable(NewEmptyVecDense(3), NewEmptyDense(1, 3)))
+}
+
+func TestDense_Broadcasting(t *testing.T) {
+	newMatrix := func() *Dense {
+		return NewDense(2, 3, []Float{
+			1, 2, 3,
+			4, 5, 6,
+		})
+	}
+	row := NewDense(1, 3, []Float{10, 20, 30})
+	col := NewVecDense([]Float{10, 20})
+
+	t.Run("Add", func(t *testing.T) {
+		assertSliceEqualApprox(t, []Float{11, 22, 33, 14, 25, 36}, newMatrix().Add(row).Data())
+		assertSliceEqualApprox(t, []Float{11, 12, 13, 24, 25, 26}, newMatrix().Add(col).Data())
+	})
+
+	t.Run("Sub", func(t *testing.T) {
+		assertSliceEqualApprox(t, []Float{-9, -18, -27, -6, -15, -24}, newMatrix().Sub(row).Data())
+		assertSliceEqualApprox(t, []Float{-9, -8, -7, -16, -15, -14}, newMatrix().Sub(col).Data())
+	})
+
+	t.Run("Prod", func(t *testing.T) {
+		assertSliceEqualApprox(t, []Float{10, 40, 90, 40, 100, 180}, newMatrix().Prod(row).Data())
+		assertSliceEqualApprox(t, []Float{10, 20, 30, 80, 100, 120}, newMatrix().Prod(col).Data())
+	})
+
+	t.Run("in place", func(t *testing.T) {
+		m := newMatrix()
+		m.AddInPlace(row)
+		m.SubInPlace(col)
+		m.ProdInPlace(row)
+		assertSliceEqualApprox(t, []Float{10, 240, 690, -60, 100, 480}, m.Data())
+	})
+
+	t.Run("it panics with incompatible vectors", func(t *testing.T) {
+		assert.Panics(t, func() { newMatrix().Add(NewDense(1, 2, []Float{1, 2})) })
+		assert.Panics(t, func() { newMatrix().Prod(NewVecDense([]Float{1, 2, 3})) })
+	})
+}
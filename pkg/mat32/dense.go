@@ -45,6 +45,23 @@ func NewDense(rows, cols int, elements []Float) *Dense {
 	return d
 }
 
+// NewDenseNoCopy returns a new rows x cols dense matrix backed by the elements, which are not copied,
+// e.g. to use the memory of a memory-mapped file. The elements cannot be nil, panic otherwise.
+func NewDenseNoCopy(rows, cols int, elements []Float) *Dense {
+	if elements == nil {
+		panic("mat32: elements cannot be nil. Use NewEmptyDense() instead.")
+	}
+	if len(elements) != rows*cols {
+		panic(fmt.Sprintf("mat32: wrong matrix dimensions. Elements size must be: %d", rows*cols))
+	}
+	return &Dense{
+		rows: rows,
+		cols: cols,
+		size: rows * cols,
+		data: elements,
+	}
+}
+
 // NewVecDense returns a new column vector populated with a copy of the elements.
 // The elements cannot be nil, panic otherwise. Use NewEmptyVecDense to initialize an empty matrix.
 func NewVecDense(elements []Float) *Dense {
@@ -158,6 +175,79 @@ func (d *Dense) View(rows, cols int) *Dense {
 	}
 }
 
+// IsView reports whether the matrix shares the underlying data with another matrix.
+func (d *Dense) IsView() bool {
+	return d.viewOf != nil
+}
+
+// RowsRange returns a new Matrix with the rows of the receiver from the index from
+// (inclusive) to the index to (exclusive), sharing the same underlying data.
+func (d *Dense) RowsRange(from, to int) *Dense {
+	if from < 0 || to > d.rows || from > to {
+		panic("mat32: rows range out of bounds")
+	}
+	return d.viewRange(to-from, d.cols, from*d.cols)
+}
+
+// ColsRange returns a new Matrix with the columns of the receiver from the index from
+// (inclusive) to the index to (exclusive). The data is copied (see CopySlice).
+func (d *Dense) ColsRange(from, to int) *Dense {
+	return d.CopySlice(0, d.rows, from, to)
+}
+
+// Slice returns a new Matrix with the portion of the receiver delimited by the rows fromRow
+// (inclusive) to toRow (exclusive), and by the columns fromCol (inclusive) to toCol (exclusive),
+// sharing the same underlying data.
+//
+// Since the data of a Dense matrix is stored contiguously in row-major order, the portion must be
+// contiguous too, that is it must span all the columns, or a single row (see IsContiguous);
+// otherwise it panics. Use CopySlice to get a copy of any portion.
+func (d *Dense) Slice(fromRow, toRow, fromCol, toCol int) *Dense {
+	d.checkSliceBounds(fromRow, toRow, fromCol, toCol)
+	if !d.IsContiguous(fromRow, toRow, fromCol, toCol) {
+		panic("mat32: the portion of the matrix is not contiguous")
+	}
+	return d.viewRange(toRow-fromRow, toCol-fromCol, fromRow*d.cols+fromCol)
+}
+
+// CopySlice returns a new Matrix with a copy of the portion of the receiver delimited by the rows
+// fromRow (inclusive) to toRow (exclusive), and by the columns fromCol (inclusive) to toCol (exclusive).
+func (d *Dense) CopySlice(fromRow, toRow, fromCol, toCol int) *Dense {
+	d.checkSliceBounds(fromRow, toRow, fromCol, toCol)
+	rows, cols := toRow-fromRow, toCol-fromCol
+	out := GetDenseWorkspace(rows, cols)
+	for i := 0; i < rows; i++ {
+		offset := (fromRow+i)*d.cols + fromCol
+		copy(out.data[i*cols:(i+1)*cols], d.data[offset:offset+cols])
+	}
+	return out
+}
+
+// IsContiguous reports whether the portion of the receiver delimited by the given rows and columns
+// is stored contiguously, so that Slice can return a view of it.
+func (d *Dense) IsContiguous(fromRow, toRow, fromCol, toCol int) bool {
+	return toCol-fromCol == d.cols || toRow-fromRow <= 1
+}
+
+func (d *Dense) checkSliceBounds(fromRow, toRow, fromCol, toCol int) {
+	if fromRow < 0 || toRow > d.rows || fromRow > toRow || fromCol < 0 || toCol > d.cols || fromCol > toCol {
+		panic("mat32: slice out of bounds")
+	}
+}
+
+// viewRange returns a view of rows x cols elements of the receiver, starting from the given offset.
+func (d *Dense) viewRange(rows, cols, offset int) *Dense {
+	size := rows * cols
+	return &Dense{
+		rows:     rows,
+		cols:     cols,
+		size:     size,
+		data:     d.data[offset : offset+size : offset+size],
+		viewOf:   d,
+		fromPool: false,
+	}
+}
+
 // Zeros sets all the values of the matrix to zero.
 func (d *Dense) Zeros() {
 	data := d.data // avoid bounds check
@@ -408,9 +498,14 @@ func (d *Dense) ProdScalar(n Float) Matrix {
 }
 
 // Add returns the addition between the receiver and another matrix.
+// The other matrix can also be a vector broadcast against the receiver (see IsBroadcastable).
 func (d *Dense) Add(other Matrix) Matrix {
+	if IsBroadcastable(d, other) {
+		out := d.ZerosLike().(*Dense)
+		d.broadcastTo(out.data, other, addOp)
+		return out
+	}
 	if !(SameDims(d, other) ||
-		(other.Columns() == 1 && other.Rows() == d.Rows()) ||
 		(other.IsVector() && d.IsVector() && other.Size() == d.Size())) {
 		panic("mat32: matrices with not compatible size")
 	}
@@ -422,8 +517,11 @@ func (d *Dense) Add(other Matrix) Matrix {
 
 // AddInPlace performs the in-place addition with the other matrix.
 func (d *Dense) AddInPlace(other Matrix) Matrix {
+	if IsBroadcastable(d, other) {
+		d.broadcastTo(d.data, other, addOp)
+		return d
+	}
 	if !(SameDims(d, other) ||
-		(other.Columns() == 1 && other.Rows() == d.Rows()) ||
 		(other.IsVector() && d.IsVector() && other.Size() == d.Size())) {
 		panic("mat32: matrices with not compatible size")
 	}
@@ -433,9 +531,14 @@ func (d *Dense) AddInPlace(other Matrix) Matrix {
 }
 
 // Sub returns the subtraction of the other matrix from the receiver.
+// The other matrix can also be a vector broadcast against the receiver (see IsBroadcastable).
 func (d *Dense) Sub(other Matrix) Matrix {
+	if IsBroadcastable(d, other) {
+		out := d.ZerosLike().(*Dense)
+		d.broadcastTo(out.data, other, subOp)
+		return out
+	}
 	if !(SameDims(d, other) ||
-		(other.Columns() == 1 && other.Rows() == d.Rows()) ||
 		(other.IsVector() && d.IsVector() && other.Size() == d.Size())) {
 		panic("mat32: matrices with not compatible size")
 	}
@@ -447,8 +550,11 @@ func (d *Dense) Sub(other Matrix) Matrix {
 
 // SubInPlace performs the in-place subtraction with the other matrix.
 func (d *Dense) SubInPlace(other Matrix) Matrix {
+	if IsBroadcastable(d, other) {
+		d.broadcastTo(d.data, other, subOp)
+		return d
+	}
 	if !(SameDims(d, other) ||
-		(other.Columns() == 1 && other.Rows() == d.Rows()) ||
 		(other.IsVector() && d.IsVector() && other.Size() == d.Size())) {
 		panic("mat32: matrices with not compatible size")
 	}
@@ -464,9 +570,14 @@ func (d *Dense) SubInPlace(other Matrix) Matrix {
 }
 
 // Prod performs the element-wise product between the receiver and the other matrix.
+// The other matrix can also be a vector broadcast against the receiver (see IsBroadcastable).
 func (d *Dense) Prod(other Matrix) Matrix {
+	if IsBroadcastable(d, other) {
+		out := GetDenseWorkspace(d.Dims())
+		d.broadcastTo(out.data, other, prodOp)
+		return out
+	}
 	if !(SameDims(d, other) ||
-		(other.Columns() == 1 && other.Rows() == d.Rows()) ||
 		(other.IsVector() && d.IsVector() && other.Size() == d.Size())) {
 		panic("mat32: matrices with not compatible size")
 	}
@@ -492,8 +603,11 @@ func (d *Dense) Prod(other Matrix) Matrix {
 
 // ProdInPlace performs the in-place element-wise product with the other matrix.
 func (d *Dense) ProdInPlace(other Matrix) Matrix {
+	if IsBroadcastable(d, other) {
+		d.broadcastTo(d.data, other, prodOp)
+		return d
+	}
 	if !(SameDims(d, other) ||
-		(other.Columns() == 1 && other.Rows() == d.Rows()) ||
 		(other.IsVector() && d.IsVector() && other.Size() == d.Size())) {
 		panic("mat32: matrices with not compatible size")
 	}
@@ -338,6 +338,18 @@ func TestNewDense(t *testing.T) {
 	})
 }
 
+func TestNewDenseNoCopy(t *testing.T) {
+	data := []Float{1, 2, 3, 4, 5, 6}
+	a := NewDenseNoCopy(2, 3, data)
+	assert.Equal(t, 2, a.Rows())
+	assert.Equal(t, 3, a.Columns())
+	data[0] = 7
+	assert.Equal(t, Float(7), a.At(0, 0))
+
+	assert.Panics(t, func() { NewDenseNoCopy(2, 2, data) })
+	assert.Panics(t, func() { NewDenseNoCopy(0, 0, nil) })
+}
+
 func TestNewVecDense(t *testing.T) {
 	t.Run("simple case", func(t *testing.T) {
 		a := NewVecDense([]Float{0.1, 0.2, 0.3, 0.0})
@@ -1199,6 +1211,87 @@ func TestDense_View(t *testing.T) {
 	})
 }
 
+func TestDense_RowsRange(t *testing.T) {
+	t.Run("simple case", func(t *testing.T) {
+		d := NewDense(3, 2, []Float{
+			1, 2,
+			3, 4,
+			5, 6,
+		})
+		view := d.RowsRange(1, 3)
+		assert.Equal(t, 2, view.Rows())
+		assert.Equal(t, 2, view.Columns())
+		assert.Equal(t, []Float{3, 4, 5, 6}, view.Data())
+		assert.True(t, view.IsView())
+
+		view.Set(0, 0, 42)
+		assert.Equal(t, Float(42), d.At(1, 0), "the data is shared")
+	})
+
+	t.Run("it panics with an invalid range", func(t *testing.T) {
+		d := NewEmptyDense(2, 3)
+		assert.Panics(t, func() { d.RowsRange(1, 3) })
+		assert.Panics(t, func() { d.RowsRange(2, 1) })
+	})
+}
+
+func TestDense_Slice(t *testing.T) {
+	newMatrix := func() *Dense {
+		return NewDense(3, 3, []Float{
+			1, 2, 3,
+			4, 5, 6,
+			7, 8, 9,
+		})
+	}
+
+	t.Run("single row", func(t *testing.T) {
+		d := newMatrix()
+		view := d.Slice(1, 2, 1, 3)
+		assert.Equal(t, []Float{5, 6}, view.Data())
+		assert.True(t, view.IsView())
+	})
+
+	t.Run("all the columns", func(t *testing.T) {
+		d := newMatrix()
+		view := d.Slice(1, 3, 0, 3)
+		assert.Equal(t, []Float{4, 5, 6, 7, 8, 9}, view.Data())
+		assert.True(t, view.IsView())
+
+		view.Set(0, 0, 42)
+		assert.Equal(t, Float(42), d.At(1, 0), "the data is shared")
+	})
+
+	t.Run("it panics with a non-contiguous portion", func(t *testing.T) {
+		d := newMatrix()
+		assert.False(t, d.IsContiguous(1, 3, 0, 2))
+		assert.Panics(t, func() { d.Slice(1, 3, 0, 2) })
+	})
+
+	t.Run("copy of a non-contiguous portion", func(t *testing.T) {
+		d := newMatrix()
+		s := d.CopySlice(1, 3, 0, 2)
+		assert.Equal(t, 2, s.Rows())
+		assert.Equal(t, 2, s.Columns())
+		assert.Equal(t, []Float{4, 5, 7, 8}, s.Data())
+		assert.False(t, s.IsView())
+
+		s.Set(0, 0, 42)
+		assert.Equal(t, Float(4), d.At(1, 0), "the data is copied")
+	})
+
+	t.Run("columns range", func(t *testing.T) {
+		d := newMatrix()
+		assert.Equal(t, []Float{3, 6, 9}, d.ColsRange(2, 3).Data())
+	})
+
+	t.Run("it panics out of bounds", func(t *testing.T) {
+		d := newMatrix()
+		assert.Panics(t, func() { d.Slice(0, 4, 0, 1) })
+		assert.Panics(t, func() { d.Slice(0, 1, 2, 1) })
+		assert.Panics(t, func() { d.CopySlice(0, 4, 0, 1) })
+	})
+}
+
 func TestDense_Scalar(t *testing.T) {
 	t.Run("simple case", func(t *testing.T) {
 		s := NewScalar(42)
@@ -75,9 +75,10 @@ func GetEmptyDenseWorkspace(r, c int) *Dense {
 
 // ReleaseMatrix checks whether m is a Dense matrix, and, if so, it
 // releases is, otherwise no operation is performed.
+// Views are not released, since their data belongs to another matrix.
 func ReleaseMatrix(m Matrix) {
 	d, isDense := m.(*Dense)
-	if !isDense {
+	if !isDense || d.viewOf != nil {
 		return
 	}
 	ReleaseDense(d)
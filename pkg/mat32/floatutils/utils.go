@@ -7,6 +7,7 @@ package floatutils
 import (
 	"github.com/nlpodyssey/spago/pkg/mat32/internal"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -107,6 +108,22 @@ func ArgMin(v []float32) int {
 	return imin
 }
 
+// ArgSort returns the indices that sort v in ascending order, or in descending order
+// if descending is true. Equal elements keep their original order.
+func ArgSort(v []float32, descending bool) []int {
+	indices := make([]int, len(v))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		if descending {
+			return v[indices[i]] > v[indices[j]]
+		}
+		return v[indices[i]] < v[indices[j]]
+	})
+	return indices
+}
+
 // MakeFloatMatrix returns a new 2-dimensional slice.
 func MakeFloatMatrix(rows, cols int) [][]float32 {
 	matrix := make([][]float32, rows)
@@ -167,3 +167,10 @@ func (lr *LockedRand) Shuffle(i int, swap func(i int, j int)) {
 	lr.r.Shuffle(i, swap)
 	lr.lk.Unlock()
 }
+
+// Spawn returns a new LockedRand seeded with a value drawn from lr.
+// It allows concurrent workers to use their own generator, without contending on
+// the lock of a shared one, while keeping the sequences reproducible from the seed of lr.
+func (lr *LockedRand) Spawn() *LockedRand {
+	return NewLockedRand(lr.Uint64())
+}
@@ -14,3 +14,9 @@ import (
 func Float() mat32.Float {
 	return rand.Float32()
 }
+
+// Seed uses the provided seed value to initialize the default Source to a
+// deterministic state.
+func Seed(seed uint64) {
+	rand.Seed(seed)
+}
@@ -44,6 +44,23 @@ func NewDense(rows, cols int, elements []Float) *Dense {
 	return d
 }
 
+// NewDenseNoCopy returns a new rows x cols dense matrix backed by the elements, which are not copied,
+// e.g. to use the memory of a memory-mapped file. The elements cannot be nil, panic otherwise.
+func NewDenseNoCopy(rows, cols int, elements []Float) *Dense {
+	if elements == nil {
+		panic("mat64: elements cannot be nil. Use NewEmptyDense() instead.")
+	}
+	if len(elements) != rows*cols {
+		panic(fmt.Sprintf("mat64: wrong matrix dimensions. Elements size must be: %d", rows*cols))
+	}
+	return &Dense{
+		rows: rows,
+		cols: cols,
+		size: rows * cols,
+		data: elements,
+	}
+}
+
 // NewVecDense returns a new column vector populated with a copy of the elements.
 // The elements cannot be nil, panic otherwise. Use NewEmptyVecDense to initialize an empty matrix.
 func NewVecDense(elements []Float) *Dense {
@@ -361,6 +361,18 @@ func TestNewDense(t *testing.T) {
 	})
 }
 
+func TestNewDenseNoCopy(t *testing.T) {
+	data := []Float{1, 2, 3, 4, 5, 6}
+	a := NewDenseNoCopy(2, 3, data)
+	assert.Equal(t, 2, a.Rows())
+	assert.Equal(t, 3, a.Columns())
+	data[0] = 7
+	assert.Equal(t, Float(7), a.At(0, 0))
+
+	assert.Panics(t, func() { NewDenseNoCopy(2, 2, data) })
+	assert.Panics(t, func() { NewDenseNoCopy(0, 0, nil) })
+}
+
 func TestNewVecDense(t *testing.T) {
 	t.Run("simple case", func(t *testing.T) {
 		a := NewVecDense([]Float{0.1, 0.2, 0.3, 0.0})
@@ -0,0 +1,131 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package activelearning selects the items of an unlabeled pool which are worth annotating,
+// scoring them with a served model by the uncertainty of its predictions (entropy, margin or
+// least confidence), and optionally spreading the selection over the clusters of their
+// embeddings, so that the selected items are diverse as well.
+package activelearning
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"sort"
+)
+
+// Model is a served model which predicts the items of the pool.
+type Model interface {
+	// Probabilities returns the probabilities of the classes for the text, in any order.
+	Probabilities(text string) ([]mat.Float, error)
+}
+
+// Embedder is a served model which encodes the items of the pool.
+type Embedder interface {
+	// Embedding returns the sentence embedding of the text.
+	Embedding(text string) (mat.Matrix, error)
+}
+
+// Item is an item of the pool, scored for the selection.
+type Item struct {
+	// Index is the position of the item in the pool.
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+	// Uncertainty is the uncertainty of the prediction of the item.
+	Uncertainty mat.Float `json:"uncertainty"`
+	// Cluster is the cluster of the embedding of the item, or -1 without an embedder.
+	Cluster int `json:"cluster"`
+}
+
+// Config provides the configuration of Select.
+type Config struct {
+	// Uncertainty is the measure of the uncertainty of the predictions (Entropy if nil).
+	Uncertainty Uncertainty
+	// Embedder, if not nil, encodes the items, so that the selection is spread over their clusters.
+	Embedder Embedder
+	// Seed is the seed of the clustering.
+	Seed uint64
+}
+
+// Rank returns the items of the pool, scored by the uncertainty of the predictions of the model
+// (Entropy if nil), by decreasing uncertainty.
+func Rank(pool []string, model Model, uncertainty Uncertainty) ([]Item, error) {
+	if uncertainty == nil {
+		uncertainty = Entropy
+	}
+	items := make([]Item, len(pool))
+	for i, text := range pool {
+		probs, err := model.Probabilities(text)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = Item{
+			Index:       i,
+			Text:        text,
+			Uncertainty: uncertainty(probs),
+			Cluster:     -1,
+		}
+	}
+	sortByUncertainty(items)
+	return items, nil
+}
+
+// Select returns the n items of the pool to annotate, by decreasing uncertainty.
+// Without an embedder, they are the n most uncertain items. With an embedder, the pool is
+// clustered into n clusters by the embeddings of the items, and the most uncertain item of each
+// cluster is selected, so that the items are not only uncertain but also different from each other.
+func Select(pool []string, n int, model Model, config Config) ([]Item, error) {
+	items, err := Rank(pool, model, config.Uncertainty)
+	if err != nil {
+		return nil, err
+	}
+	if n > len(items) {
+		n = len(items)
+	}
+	if config.Embedder == nil || n < 1 {
+		return items[:n], nil
+	}
+
+	embeddings := make([]mat.Matrix, len(items))
+	for i, item := range items {
+		embeddings[i], err = config.Embedder.Embedding(item.Text)
+		if err != nil {
+			return nil, err
+		}
+	}
+	assignments, _ := KMeans(embeddings, n, rand.NewLockedRand(config.Seed))
+	selected := make([]Item, 0, n)
+	taken := make(map[int]bool, n)
+	for i := range items {
+		items[i].Cluster = assignments[i]
+		// the items are sorted by decreasing uncertainty, so the first of a cluster is the most uncertain
+		if !taken[assignments[i]] {
+			taken[assignments[i]] = true
+			selected = append(selected, items[i])
+		}
+	}
+	// the clusters can be fewer than n if some embeddings are identical: the most uncertain
+	// items left complete the selection
+	for i := 0; len(selected) < n && i < len(items); i++ {
+		if !containsIndex(selected, items[i].Index) {
+			selected = append(selected, items[i])
+		}
+	}
+	sortByUncertainty(selected)
+	return selected, nil
+}
+
+func sortByUncertainty(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Uncertainty > items[j].Uncertainty
+	})
+}
+
+func containsIndex(items []Item, index int) bool {
+	for _, item := range items {
+		if item.Index == index {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package activelearning
+
+import (
+	"encoding/json"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeModel struct {
+	probs      map[string][]mat.Float
+	embeddings map[string][]mat.Float
+}
+
+func (m fakeModel) Probabilities(text string) ([]mat.Float, error) {
+	return m.probs[text], nil
+}
+
+func (m fakeModel) Embedding(text string) (mat.Matrix, error) {
+	return mat.NewVecDense(m.embeddings[text]), nil
+}
+
+func TestUncertainty(t *testing.T) {
+	assert.InDelta(t, 1.0, Entropy([]mat.Float{0.5, 0.5}), 1.0e-6)
+	assert.InDelta(t, 0.0, Entropy([]mat.Float{1, 0}), 1.0e-6)
+	assert.InDelta(t, 0.9, Margin([]mat.Float{0.1, 0.4, 0.5}), 1.0e-6)
+	assert.InDelta(t, 0.5, LeastConfidence([]mat.Float{0.1, 0.4, 0.5}), 1.0e-6)
+	assert.Equal(t, mat.Float(0), Entropy([]mat.Float{1}))
+	assert.Equal(t, mat.Float(0), Margin(nil))
+}
+
+func TestKMeans(t *testing.T) {
+	embeddings := []mat.Matrix{
+		mat.NewVecDense([]mat.Float{0, 0}),
+		mat.NewVecDense([]mat.Float{10, 10}),
+		mat.NewVecDense([]mat.Float{0, 1}),
+		mat.NewVecDense([]mat.Float{10, 11}),
+	}
+	assignments, centroids := KMeans(embeddings, 2, rand.NewLockedRand(42))
+	assert.Equal(t, assignments[0], assignments[2])
+	assert.Equal(t, assignments[1], assignments[3])
+	assert.NotEqual(t, assignments[0], assignments[1])
+	assert.Equal(t, []mat.Float{0, 0.5}, centroids[assignments[0]])
+	assert.Equal(t, []mat.Float{10, 10.5}, centroids[assignments[1]])
+}
+
+func TestSelect(t *testing.T) {
+	model := fakeModel{
+		probs: map[string][]mat.Float{
+			"a": {0.5, 0.5},
+			"b": {0.55, 0.45},
+			"c": {0.9, 0.1},
+			"d": {1, 0},
+		},
+		embeddings: map[string][]mat.Float{
+			"a": {0, 0},
+			"b": {0, 1}, // similar to "a"
+			"c": {10, 10},
+			"d": {10, 11},
+		},
+	}
+	pool := []string{"d", "c", "b", "a"}
+
+	items, err := Select(pool, 2, model, Config{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, texts(items))
+	assert.Equal(t, 3, items[0].Index)
+	assert.Equal(t, -1, items[0].Cluster)
+
+	items, err = Select(pool, 2, model, Config{Uncertainty: Margin, Embedder: model})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, texts(items))
+	assert.NotEqual(t, items[0].Cluster, items[1].Cluster)
+
+	items, err = Select(pool, 10, model, Config{Embedder: model})
+	require.NoError(t, err)
+	assert.Len(t, items, 4)
+}
+
+func TestHTTPModel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, "text", body["text"])
+		switch req.URL.Path {
+		case "/classify":
+			w.Write([]byte(`{"class": "a", "distribution": [{"class": "a", "confidence": 0.7}, {"class": "b", "confidence": 0.3}]}`))
+		case "/encode":
+			assert.Equal(t, true, body["normalize"])
+			w.Write([]byte(`{"data": [0.6, 0.8]}`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	m := &HTTPModel{ClassifyURL: srv.URL + "/classify", EncodeURL: srv.URL + "/encode"}
+	probs, err := m.Probabilities("text")
+	require.NoError(t, err)
+	assert.Equal(t, []mat.Float{0.7, 0.3}, probs)
+	embedding, err := m.Embedding("text")
+	require.NoError(t, err)
+	assert.Equal(t, []mat.Float{0.6, 0.8}, embedding.Data())
+
+	m.ClassifyURL = srv.URL + "/unknown"
+	_, err = m.Probabilities("text")
+	assert.Error(t, err)
+}
+
+func texts(items []Item) []string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = item.Text
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package activelearning
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"net/http"
+)
+
+var (
+	_ Model    = &HTTPModel{}
+	_ Embedder = &HTTPModel{}
+)
+
+// HTTPModel is a Model and an Embedder served over HTTP by a spaGO server, e.g. the "classify"
+// and "encode" endpoints of the BERT server, or the "classify" endpoint of the BART server.
+type HTTPModel struct {
+	// ClassifyURL is the URL of the classification endpoint, whose responses have a "distribution"
+	// of classes with their "confidence".
+	ClassifyURL string
+	// EncodeURL is the URL of the encoding endpoint, whose responses have the "data" of the vector.
+	EncodeURL string
+	// Client is the HTTP client of the requests (http.DefaultClient if nil).
+	Client *http.Client
+}
+
+// Probabilities returns the confidences of the distribution classifying the text.
+func (m *HTTPModel) Probabilities(text string) ([]mat.Float, error) {
+	var response struct {
+		Distribution []struct {
+			Confidence mat.Float `json:"confidence"`
+		} `json:"distribution"`
+	}
+	if err := m.post(m.ClassifyURL, map[string]interface{}{"text": text}, &response); err != nil {
+		return nil, err
+	}
+	probs := make([]mat.Float, len(response.Distribution))
+	for i, pair := range response.Distribution {
+		probs[i] = pair.Confidence
+	}
+	return probs, nil
+}
+
+// Embedding returns the normalized encoding of the text.
+func (m *HTTPModel) Embedding(text string) (mat.Matrix, error) {
+	var response struct {
+		Data []mat.Float `json:"data"`
+	}
+	if err := m.post(m.EncodeURL, map[string]interface{}{"text": text, "normalize": true}, &response); err != nil {
+		return nil, err
+	}
+	return mat.NewVecDense(response.Data), nil
+}
+
+func (m *HTTPModel) post(url string, body interface{}, response interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	client := m.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("activelearning: %s: status code %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package activelearning
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+)
+
+// defaultKMeansIterations is the maximum number of iterations of KMeans.
+const defaultKMeansIterations = 100
+
+// KMeans clusters the embeddings into k clusters (or fewer, if there are fewer embeddings), with
+// the k-means++ initialization. It returns the cluster of each embedding and the centroids.
+func KMeans(embeddings []mat.Matrix, k int, rndGen *rand.LockedRand) ([]int, [][]mat.Float) {
+	if k > len(embeddings) {
+		k = len(embeddings)
+	}
+	points := make([][]mat.Float, len(embeddings))
+	for i, e := range embeddings {
+		points[i] = e.Data()
+	}
+	assignments := make([]int, len(points))
+	if k < 1 {
+		return assignments, nil
+	}
+	centroids := initCentroids(points, k, rndGen)
+	for iter := 0; iter < defaultKMeansIterations; iter++ {
+		changed := false
+		for i, p := range points {
+			if nearest, _ := nearestCentroid(p, centroids); nearest != assignments[i] {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+		if iter > 0 && !changed {
+			break
+		}
+		updateCentroids(points, assignments, centroids)
+	}
+	return assignments, centroids
+}
+
+// initCentroids chooses k points as the initial centroids with the k-means++ method: each new
+// centroid is chosen with a probability proportional to its squared distance from the nearest
+// centroid already chosen.
+func initCentroids(points [][]mat.Float, k int, rndGen *rand.LockedRand) [][]mat.Float {
+	centroids := make([][]mat.Float, 0, k)
+	centroids = append(centroids, clone(points[rndGen.Intn(len(points))]))
+	distances := make([]mat.Float, len(points))
+	for len(centroids) < k {
+		var sum mat.Float
+		for i, p := range points {
+			_, distances[i] = nearestCentroid(p, centroids)
+			sum += distances[i]
+		}
+		next := 0
+		if sum > 0 {
+			r := mat.Float(rndGen.Float()) * sum
+			for next = 0; next < len(points)-1; next++ {
+				r -= distances[next]
+				if r < 0 && distances[next] > 0 {
+					break
+				}
+			}
+		}
+		centroids = append(centroids, clone(points[next]))
+	}
+	return centroids
+}
+
+// updateCentroids sets each centroid to the mean of the points assigned to it. The centroids
+// without points are left unchanged.
+func updateCentroids(points [][]mat.Float, assignments []int, centroids [][]mat.Float) {
+	counts := make([]int, len(centroids))
+	sums := make([][]mat.Float, len(centroids))
+	for i := range sums {
+		sums[i] = make([]mat.Float, len(centroids[i]))
+	}
+	for i, p := range points {
+		c := assignments[i]
+		counts[c]++
+		for j, v := range p {
+			sums[c][j] += v
+		}
+	}
+	for c, sum := range sums {
+		if counts[c] == 0 {
+			continue
+		}
+		for j := range sum {
+			centroids[c][j] = sum[j] / mat.Float(counts[c])
+		}
+	}
+}
+
+// nearestCentroid returns the index of the centroid nearest to the point, and its squared distance.
+func nearestCentroid(point []mat.Float, centroids [][]mat.Float) (int, mat.Float) {
+	nearest, min := 0, squaredDistance(point, centroids[0])
+	for i, c := range centroids[1:] {
+		if d := squaredDistance(point, c); d < min {
+			nearest, min = i+1, d
+		}
+	}
+	return nearest, min
+}
+
+func squaredDistance(x, y []mat.Float) mat.Float {
+	var sum mat.Float
+	for i, v := range x {
+		d := v - y[i]
+		sum += d * d
+	}
+	return sum
+}
+
+func clone(xs []mat.Float) []mat.Float {
+	return append([]mat.Float(nil), xs...)
+}
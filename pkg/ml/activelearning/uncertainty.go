@@ -0,0 +1,63 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package activelearning
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"sort"
+)
+
+// Uncertainty is a measure of the uncertainty of a prediction, given the probabilities of the
+// classes: the higher, the more informative the item is expected to be once annotated.
+// The measures do not depend on the order of the classes.
+type Uncertainty func(probs []mat.Float) mat.Float
+
+var (
+	_ Uncertainty = Entropy
+	_ Uncertainty = Margin
+	_ Uncertainty = LeastConfidence
+)
+
+// Entropy returns the entropy of the probabilities, normalized to [0, 1] by the entropy of the
+// uniform distribution.
+func Entropy(probs []mat.Float) mat.Float {
+	if len(probs) < 2 {
+		return 0
+	}
+	var entropy mat.Float
+	for _, p := range probs {
+		if p > 0 {
+			entropy -= p * mat.Log(p)
+		}
+	}
+	return entropy / mat.Log(mat.Float(len(probs)))
+}
+
+// Margin returns one minus the difference between the two highest probabilities, so that
+// the items whose two best classes are close are the most uncertain.
+func Margin(probs []mat.Float) mat.Float {
+	if len(probs) < 2 {
+		return 0
+	}
+	sorted := append([]mat.Float(nil), probs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] > sorted[j]
+	})
+	return 1 - (sorted[0] - sorted[1])
+}
+
+// LeastConfidence returns one minus the highest probability.
+func LeastConfidence(probs []mat.Float) mat.Float {
+	if len(probs) == 0 {
+		return 0
+	}
+	max := probs[0]
+	for _, p := range probs[1:] {
+		if p > max {
+			max = p
+		}
+	}
+	return 1 - max
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ArenaStats reports the memory held by the free-lists of the arenas of all the graphs (see the Arena option).
+type ArenaStats struct {
+	// Arenas is the number of live arenas.
+	Arenas int64 `json:"arenas"`
+	// Operators and Variables are the numbers of recycled nodes ready to be reused.
+	Operators int64 `json:"operators"`
+	Variables int64 `json:"variables"`
+	// Buffers is the number of recycled values and gradient buffers, which take BufferBytes.
+	Buffers     int64 `json:"buffers"`
+	BufferBytes int64 `json:"buffer_bytes"`
+}
+
+// arenaStats is updated atomically by the arenas.
+var arenaStats ArenaStats
+
+// GetArenaStats returns the memory currently held by the arenas of all the graphs.
+func GetArenaStats() ArenaStats {
+	return ArenaStats{
+		Arenas:      atomic.LoadInt64(&arenaStats.Arenas),
+		Operators:   atomic.LoadInt64(&arenaStats.Operators),
+		Variables:   atomic.LoadInt64(&arenaStats.Variables),
+		Buffers:     atomic.LoadInt64(&arenaStats.Buffers),
+		BufferBytes: atomic.LoadInt64(&arenaStats.BufferBytes),
+	}
+}
+
+func bufferBytes(rows, cols int) int64 {
+	return int64(rows*cols) * int64(unsafe.Sizeof(mat.Float(0)))
+}
+
+// maxBuffersPerShape is the maximum number of matrices kept in the free-list of each shape.
+// The exceeding ones are put into the global pool, so that the memory held by an arena is bounded
+// even if the values of the nodes are more than the gradient buffers that reuse them.
+const maxBuffersPerShape = 256
+
+// arena recycles the memory of the nodes of a graph (see the Arena option).
+// The nodes, their values and the gradient buffers released by the graph are kept in free-lists,
+// so that the next computations on the same graph reuse them instead of allocating new ones.
+// Unlike the global pools, the free-lists are not emptied by the garbage collector.
+type arena struct {
+	mu        sync.Mutex
+	operators []*operator
+	variables []*variable
+	// buffers are keyed by shape, so that a buffer is always reused as it is.
+	buffers map[shape][]*mat.Dense
+}
+
+type shape struct {
+	rows, cols int
+}
+
+func newArena() *arena {
+	a := &arena{
+		buffers: map[shape][]*mat.Dense{},
+	}
+	atomic.AddInt64(&arenaStats.Arenas, 1)
+	runtime.SetFinalizer(a, (*arena).discard)
+	return a
+}
+
+// discard removes the free-lists of a garbage collected arena from the stats.
+func (a *arena) discard() {
+	atomic.AddInt64(&arenaStats.Arenas, -1)
+	atomic.AddInt64(&arenaStats.Operators, -int64(len(a.operators)))
+	atomic.AddInt64(&arenaStats.Variables, -int64(len(a.variables)))
+	for key, list := range a.buffers {
+		atomic.AddInt64(&arenaStats.Buffers, -int64(len(list)))
+		atomic.AddInt64(&arenaStats.BufferBytes, -int64(len(list))*bufferBytes(key.rows, key.cols))
+	}
+}
+
+// newOperator returns a recycled operator, or a new one if the free-list is empty.
+func (a *arena) newOperator() *operator {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if n := len(a.operators); n > 0 {
+		op := a.operators[n-1]
+		a.operators[n-1] = nil
+		a.operators = a.operators[:n-1]
+		atomic.AddInt64(&arenaStats.Operators, -1)
+		return op
+	}
+	return new(operator)
+}
+
+// releaseOperator resets the operator and puts it into the free-list.
+func (a *arena) releaseOperator(op *operator) {
+	*op = operator{}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.operators = append(a.operators, op)
+	atomic.AddInt64(&arenaStats.Operators, 1)
+}
+
+// newVariable returns a recycled variable, or a new one if the free-list is empty.
+func (a *arena) newVariable() *variable {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if n := len(a.variables); n > 0 {
+		v := a.variables[n-1]
+		a.variables[n-1] = nil
+		a.variables = a.variables[:n-1]
+		atomic.AddInt64(&arenaStats.Variables, -1)
+		return v
+	}
+	return new(variable)
+}
+
+// releaseVariable resets the variable and puts it into the free-list.
+func (a *arena) releaseVariable(v *variable) {
+	*v = variable{}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.variables = append(a.variables, v)
+	atomic.AddInt64(&arenaStats.Variables, 1)
+}
+
+// newBuffer returns a matrix of the given shape with all the values set to zeros,
+// recycling a released one if possible.
+func (a *arena) newBuffer(rows, cols int) *mat.Dense {
+	key := shape{rows: rows, cols: cols}
+	a.mu.Lock()
+	if list := a.buffers[key]; len(list) > 0 {
+		n := len(list)
+		m := list[n-1]
+		list[n-1] = nil
+		a.buffers[key] = list[:n-1]
+		a.mu.Unlock()
+		atomic.AddInt64(&arenaStats.Buffers, -1)
+		atomic.AddInt64(&arenaStats.BufferBytes, -bufferBytes(rows, cols))
+		m.Zeros()
+		return m
+	}
+	a.mu.Unlock()
+	return mat.GetEmptyDenseWorkspace(rows, cols)
+}
+
+// releaseBuffer puts the matrix into the free-list of its shape, or into the global pool if the
+// free-list is full. Views and matrices other than Dense are not recycled.
+func (a *arena) releaseBuffer(m mat.Matrix) {
+	d, isDense := m.(*mat.Dense)
+	if !isDense || d.IsView() {
+		return
+	}
+	key := shape{rows: d.Rows(), cols: d.Columns()}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.buffers[key]) >= maxBuffersPerShape {
+		mat.ReleaseMatrix(d)
+		return
+	}
+	a.buffers[key] = append(a.buffers[key], d)
+	atomic.AddInt64(&arenaStats.Buffers, 1)
+	atomic.AddInt64(&arenaStats.BufferBytes, bufferBytes(key.rows, key.cols))
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestGraph_Arena(t *testing.T) {
+	g := NewGraph(Arena(true))
+
+	run := func() (x, y Node) {
+		x = g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), true)
+		y = g.Dot(x, x)
+		g.Backward(y)
+		return x, y
+	}
+
+	x, y := run()
+	assert.Equal(t, mat.Float(5), y.ScalarValue())
+	assert.Equal(t, []mat.Float{2, 4}, x.Grad().Data())
+	grad := x.Grad()
+	g.Clear()
+
+	x2, y2 := run()
+	assert.Same(t, x, x2)
+	assert.Same(t, y, y2)
+	assert.Same(t, grad, x2.Grad())
+	assert.Equal(t, mat.Float(5), y2.ScalarValue())
+	assert.Equal(t, []mat.Float{2, 4}, x2.Grad().Data()) // the recycled buffer is zeroed
+
+	g.Truncate(0) // keeps x
+	y3 := g.Dot(x, x)
+	assert.Same(t, y, y3)
+	assert.Equal(t, mat.Float(5), y3.ScalarValue())
+}
+
+func TestGraph_ArenaValues(t *testing.T) {
+	g := NewGraph(Arena(true))
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), false)
+	value := g.Square(x).Value()
+	g.Clear()
+
+	// the value is recycled as a gradient buffer of the same shape
+	buffers := g.arena.buffers[shape{rows: 2, cols: 1}]
+	assert.Same(t, value, buffers[len(buffers)-1])
+	x = g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), true)
+	g.Backward(g.ReduceSum(x))
+	assert.Same(t, value, x.Grad())
+}
+
+func TestArena_MaxBuffersPerShape(t *testing.T) {
+	a := newArena()
+	defer func() {
+		runtime.SetFinalizer(a, nil)
+		a.discard()
+	}()
+	for i := 0; i < maxBuffersPerShape+10; i++ {
+		a.releaseBuffer(mat.GetEmptyDenseWorkspace(3, 2))
+	}
+	assert.Len(t, a.buffers[shape{rows: 3, cols: 2}], maxBuffersPerShape)
+}
+
+func TestGetArenaStats(t *testing.T) {
+	defer debug.SetGCPercent(debug.SetGCPercent(-1)) // no arena is finalized meanwhile
+
+	before := GetArenaStats()
+	g := NewGraph(Arena(true))
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), true)
+	g.Backward(g.Dot(x, x))
+	g.Clear()
+
+	var buffers, bytes int64
+	for key, list := range g.arena.buffers {
+		buffers += int64(len(list))
+		bytes += int64(len(list)) * bufferBytes(key.rows, key.cols)
+	}
+	stats := GetArenaStats()
+	assert.Equal(t, before.Arenas+1, stats.Arenas)
+	assert.Equal(t, before.Operators+1, stats.Operators)
+	assert.Equal(t, before.Variables+1, stats.Variables)
+	assert.Equal(t, before.Buffers+buffers, stats.Buffers)
+	assert.Equal(t, before.BufferBytes+bytes, stats.BufferBytes)
+	assert.True(t, buffers > 0)
+
+	g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), true) // reuses the variable
+	assert.Equal(t, before.Variables, GetArenaStats().Variables)
+
+	runtime.SetFinalizer(g.arena, nil)
+	g.arena.discard()
+	assert.Equal(t, before, GetArenaStats())
+}
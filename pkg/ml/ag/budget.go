@@ -0,0 +1,70 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	"github.com/nlpodyssey/spago/pkg/utils/processingqueue"
+	"sync/atomic"
+)
+
+// ThreadBudget limits the number of computations running concurrently across several graphs, e.g.
+// the per-request graphs of a server, so that together they do not oversubscribe the CPUs.
+// The limit of each graph (see ConcurrentComputations) still applies.
+type ThreadBudget struct {
+	queue processingqueue.ProcessingQueue
+}
+
+// NewThreadBudget returns a new ThreadBudget allowing up to size concurrent computations.
+func NewThreadBudget(size int) *ThreadBudget {
+	if size < 1 {
+		panic("ag: ThreadBudget size must be greater than zero")
+	}
+	return &ThreadBudget{queue: processingqueue.New(size)}
+}
+
+// Size returns the maximum number of concurrent computations allowed by the budget.
+func (b *ThreadBudget) Size() int {
+	return b.queue.Size()
+}
+
+// globalThreadBudget holds the *ThreadBudget shared by the graphs without their own budget.
+var globalThreadBudget atomic.Value
+
+func init() {
+	globalThreadBudget.Store((*ThreadBudget)(nil))
+}
+
+// SetGlobalThreadBudget sets the budget shared by all the graphs without their own budget
+// (see SharedThreadBudget). The nil budget, which is the default, removes the global limit.
+func SetGlobalThreadBudget(budget *ThreadBudget) {
+	globalThreadBudget.Store(budget)
+}
+
+// GlobalThreadBudget returns the budget shared by all the graphs without their own budget, or nil.
+func GlobalThreadBudget() *ThreadBudget {
+	return globalThreadBudget.Load().(*ThreadBudget)
+}
+
+// SharedThreadBudget sets the budget shared by the graph with other graphs, in place of the global one.
+func SharedThreadBudget(budget *ThreadBudget) GraphOption {
+	return func(g *Graph) {
+		g.threadBudget = budget
+	}
+}
+
+// run runs the heavy computation f, once both the graph and the thread budget allow it.
+func (g *Graph) run(f func()) {
+	g.processingQueue.Run(func() {
+		budget := g.threadBudget
+		if budget == nil {
+			budget = GlobalThreadBudget()
+		}
+		if budget == nil {
+			f()
+			return
+		}
+		budget.queue.Run(f)
+	})
+}
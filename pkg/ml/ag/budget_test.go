@@ -0,0 +1,73 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// budgetTestFunction counts the forward computations running concurrently.
+type budgetTestFunction struct {
+	running, maxRunning *int32
+}
+
+func (f *budgetTestFunction) Forward() mat.Matrix {
+	n := atomic.AddInt32(f.running, 1)
+	defer atomic.AddInt32(f.running, -1)
+	for {
+		max := atomic.LoadInt32(f.maxRunning)
+		if n <= max || atomic.CompareAndSwapInt32(f.maxRunning, max, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return mat.NewScalar(1)
+}
+
+func (f *budgetTestFunction) Backward(_ mat.Matrix) {}
+
+func TestNewThreadBudget(t *testing.T) {
+	assert.Equal(t, 3, NewThreadBudget(3).Size())
+	assert.Panics(t, func() { NewThreadBudget(0) })
+}
+
+func TestSharedThreadBudget(t *testing.T) {
+	assert.LessOrEqual(t, maxConcurrentForwards(nil), int32(4))
+	assert.Equal(t, int32(1), maxConcurrentForwards(SharedThreadBudget(NewThreadBudget(1))))
+
+	SetGlobalThreadBudget(NewThreadBudget(1))
+	defer SetGlobalThreadBudget(nil)
+	assert.Equal(t, 1, GlobalThreadBudget().Size())
+	assert.Equal(t, int32(1), maxConcurrentForwards(nil))
+	assert.LessOrEqual(t, maxConcurrentForwards(SharedThreadBudget(NewThreadBudget(2))), int32(2))
+}
+
+// maxConcurrentForwards computes 4 operators on each of 2 graphs concurrently, with at most 2
+// concurrent computations per graph, and returns the maximum number of concurrent computations.
+func maxConcurrentForwards(opt GraphOption) int32 {
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		opts := []GraphOption{ConcurrentComputations(2)}
+		if opt != nil {
+			opts = append(opts, opt)
+		}
+		g := NewGraph(opts...)
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				g.NewOperator(&budgetTestFunction{running: &running, maxRunning: &maxRunning})
+			}()
+		}
+	}
+	wg.Wait()
+	return maxRunning
+}
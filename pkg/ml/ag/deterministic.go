@@ -0,0 +1,36 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+)
+
+// DeterministicSeed is the seed of the default random generator of a new Graph (i.e. without the
+// Rand or RandSeed options). It is also used to re-seed the global random generator when the
+// deterministic mode is enabled.
+const DeterministicSeed uint64 = 1
+
+// SetDeterministic enables or disables the deterministic execution mode, so that training runs
+// and predictions are bit-reproducible across runs.
+//
+// When enabled, the global random generator is re-seeded with DeterministicSeed, and each new Graph
+// performs its computations sequentially regardless of the ConcurrentComputations option, so that the
+// gradients are always accumulated in the same order. The functions also stop running concurrent
+// computations whose outcome depends on the goroutines scheduling.
+//
+// It is meant to be called once at startup, before creating any Graph.
+func SetDeterministic(enabled bool) {
+	fn.SetDeterministic(enabled)
+	if enabled {
+		rand.Seed(DeterministicSeed)
+	}
+}
+
+// DeterministicEnabled reports whether the deterministic execution mode is enabled.
+func DeterministicEnabled() bool {
+	return fn.Deterministic()
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSetDeterministic(t *testing.T) {
+	SetDeterministic(true)
+	defer SetDeterministic(false)
+
+	assert.True(t, DeterministicEnabled())
+	assert.True(t, fn.Deterministic())
+
+	g := NewGraph(ConcurrentComputations(4))
+	assert.Equal(t, 1, g.ConcurrentComputations())
+
+	first := rand.Float()
+	SetDeterministic(true)
+	assert.Equal(t, first, rand.Float())
+
+	SetDeterministic(false)
+	assert.False(t, DeterministicEnabled())
+	assert.Equal(t, 4, NewGraph(ConcurrentComputations(4)).ConcurrentComputations())
+}
+
+func TestDeterministicBackward(t *testing.T) {
+	SetDeterministic(true)
+	defer SetDeterministic(false)
+
+	run := func() []mat.Float {
+		g := NewGraph(ConcurrentComputations(4))
+		w := g.NewVariable(mat.NewDense(2, 3, []mat.Float{0.1, -0.2, 0.3, 0.4, 0.5, -0.6}), true)
+		var loss Node
+		for i := 0; i < 10; i++ {
+			x := g.NewVariable(mat.NewVecDense([]mat.Float{mat.Float(i) * 0.3, 0.7, -0.1}), false)
+			y := g.Dropout(g.Tanh(g.Mul(w, x)), 0.5)
+			loss = g.Add(loss, g.ReduceSum(g.Square(y)))
+		}
+		g.Backward(loss)
+		return w.Grad().Data()
+	}
+
+	expected := run()
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, expected, run())
+	}
+}
@@ -10,6 +10,7 @@ var _ Function = &Add{}
 
 // Add is an operator to perform element-wise sum over two values.
 // y = x1 + x2
+// x2 can also be a row or column vector, broadcast against the matrix x1.
 type Add struct {
 	x1 Operand
 	x2 Operand
@@ -28,7 +29,7 @@ func (r *Add) Forward() mat.Matrix {
 		x1v = x2v.ZerosLike()
 		defer mat.ReleaseMatrix(x1v)
 	}
-	if !(mat.SameDims(x1v, x2v) || mat.VectorsOfSameSize(x1v, x2v)) {
+	if !elementwiseCompatible(x1v, x2v) {
 		panic("fn: matrices with not compatible size")
 	}
 	return x1v.Add(x2v)
@@ -45,6 +46,11 @@ func (r *Add) Backward(gy mat.Matrix) {
 	}
 	if r.x2.RequiresGrad() {
 		x2v := r.x2.Value()
+		if gx := reduceBroadcast(gy, x2v); gx != nil {
+			defer mat.ReleaseMatrix(gx)
+			r.x2.PropagateGrad(gx)
+			return
+		}
 		if !(mat.SameDims(x2v, gy) || mat.VectorsOfSameSize(x2v, gy)) {
 			panic("fn: matrices with not compatible size")
 		}
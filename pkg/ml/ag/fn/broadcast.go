@@ -0,0 +1,38 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+// elementwiseCompatible reports whether x1 and x2 can be operands of an element-wise
+// function, either because they have the same size, or because x2 can be broadcast against x1.
+func elementwiseCompatible(x1, x2 mat.Matrix) bool {
+	return mat.SameDims(x1, x2) || mat.VectorsOfSameSize(x1, x2) || mat.IsBroadcastable(x1, x2)
+}
+
+// reduceBroadcast returns the gradient gy summed along the dimension over which x
+// was broadcast, so that it has the same shape of x. It returns nil if x was not broadcast.
+func reduceBroadcast(gy, x mat.Matrix) mat.Matrix {
+	if !mat.IsBroadcastable(gy, x) {
+		return nil
+	}
+	rows, cols := gy.Dims()
+	gx := x.ZerosLike()
+	gxData := gx.Data()
+	gyData := gy.Data()
+	rowVector := x.Rows() == 1
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if rowVector {
+				gxData[j] += gyData[i*cols+j]
+			} else {
+				gxData[i] += gyData[i*cols+j]
+			}
+		}
+	}
+	return gx
+}
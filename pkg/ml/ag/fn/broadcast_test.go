@@ -0,0 +1,74 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newBroadcastTestOperands() (*variable, *variable, *variable) {
+	x := &variable{
+		value: mat.NewDense(2, 3, []mat.Float{
+			0.1, 0.2, 0.3,
+			0.4, 0.5, 0.6,
+		}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	row := &variable{
+		value:        mat.NewDense(1, 3, []mat.Float{1.0, 2.0, 3.0}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	col := &variable{
+		value:        mat.NewVecDense([]mat.Float{-1.0, 2.0}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	return x, row, col
+}
+
+var broadcastTestGrad = mat.NewDense(2, 3, []mat.Float{
+	1.0, 2.0, 3.0,
+	4.0, 5.0, 6.0,
+})
+
+func TestAdd_Broadcasting(t *testing.T) {
+	x, row, col := newBroadcastTestOperands()
+
+	f := NewAdd(x, row)
+	assert.InDeltaSlice(t, []mat.Float{1.1, 2.2, 3.3, 1.4, 2.5, 3.6}, f.Forward().Data(), 1.0e-6)
+	f.Backward(broadcastTestGrad)
+	assert.InDeltaSlice(t, broadcastTestGrad.Data(), x.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{5.0, 7.0, 9.0}, row.grad.Data(), 1.0e-6)
+
+	f = NewAdd(x, col)
+	assert.InDeltaSlice(t, []mat.Float{-0.9, -0.8, -0.7, 2.4, 2.5, 2.6}, f.Forward().Data(), 1.0e-6)
+	f.Backward(broadcastTestGrad)
+	assert.InDeltaSlice(t, []mat.Float{6.0, 15.0}, col.grad.Data(), 1.0e-6)
+}
+
+func TestSub_Broadcasting(t *testing.T) {
+	x, row, _ := newBroadcastTestOperands()
+
+	f := NewSub(x, row)
+	assert.InDeltaSlice(t, []mat.Float{-0.9, -1.8, -2.7, -0.6, -1.5, -2.4}, f.Forward().Data(), 1.0e-6)
+	f.Backward(broadcastTestGrad)
+	assert.InDeltaSlice(t, broadcastTestGrad.Data(), x.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-5.0, -7.0, -9.0}, row.grad.Data(), 1.0e-6)
+}
+
+func TestProd_Broadcasting(t *testing.T) {
+	x, _, col := newBroadcastTestOperands()
+
+	f := NewProd(x, col)
+	assert.InDeltaSlice(t, []mat.Float{-0.1, -0.2, -0.3, 0.8, 1.0, 1.2}, f.Forward().Data(), 1.0e-6)
+	f.Backward(broadcastTestGrad)
+	assert.InDeltaSlice(t, []mat.Float{-1.0, -2.0, -3.0, 8.0, 10.0, 12.0}, x.grad.Data(), 1.0e-6)
+	// 0.1*1 + 0.2*2 + 0.3*3; 0.4*4 + 0.5*5 + 0.6*6
+	assert.InDeltaSlice(t, []mat.Float{1.4, 7.7}, col.grad.Data(), 1.0e-6)
+}
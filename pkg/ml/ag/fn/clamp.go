@@ -0,0 +1,59 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &Clamp{}
+
+// Clamp is a function that limits each element of the input to the range [min, max].
+// The gradient is zero for the elements outside the range.
+type Clamp struct {
+	x   Operand
+	min mat.Float
+	max mat.Float
+}
+
+// NewClamp returns a new Clamp Function.
+func NewClamp(x Operand, min, max mat.Float) *Clamp {
+	if min > max {
+		panic("fn: the minimum must be less than or equal to the maximum")
+	}
+	return &Clamp{x: x, min: min, max: max}
+}
+
+// Forward computes the output of the function.
+func (r *Clamp) Forward() mat.Matrix {
+	y := r.x.Value().Clone()
+	yData := y.Data()
+	for i, v := range yData {
+		if v < r.min {
+			yData[i] = r.min
+		} else if v > r.max {
+			yData[i] = r.max
+		}
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *Clamp) Backward(gy mat.Matrix) {
+	if !(mat.SameDims(r.x.Value(), gy) || mat.VectorsOfSameSize(r.x.Value(), gy)) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := gy.Clone()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		for i, v := range r.x.Value().Data() {
+			if v < r.min || v > r.max {
+				gxData[i] = 0.0
+			}
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
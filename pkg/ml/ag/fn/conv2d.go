@@ -0,0 +1,199 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &Conv2D{}
+
+// Conv2DConfig provides the configuration of a Conv2D function.
+type Conv2DConfig struct {
+	KernelRows   int
+	KernelCols   int
+	StrideRows   int
+	StrideCols   int
+	PaddingRows  int
+	PaddingCols  int
+	DilationRows int
+	DilationCols int
+}
+
+// OutputDims returns the dimensions of each output channel, given the dimensions of the input channels.
+func (c Conv2DConfig) OutputDims(rows, cols int) (int, int) {
+	outRows := (rows+2*c.PaddingRows-c.DilationRows*(c.KernelRows-1)-1)/c.StrideRows + 1
+	outCols := (cols+2*c.PaddingCols-c.DilationCols*(c.KernelCols-1)-1)/c.StrideCols + 1
+	return outRows, outCols
+}
+
+// Conv2D is a multi-channel two-dimensional convolution, with support for stride, zero-padding
+// and dilation. It is computed as a single matrix multiplication between the kernels and the
+// patches of the input extracted with the "im2col" transformation.
+//
+// The input is a list of channels of the same dimensions.
+// The kernels are a matrix with a row for each output channel; each row contains the
+// kernels of all the input channels, in row-major order.
+// The optional bias is a vector with an element for each output channel.
+// The output is a matrix with a row for each output channel, containing the flattened
+// (row-major) result of the convolution, whose dimensions are given by Conv2DConfig.OutputDims.
+type Conv2D struct {
+	w      Operand
+	b      Operand // it can be nil
+	xs     []Operand
+	config Conv2DConfig
+}
+
+// NewConv2D returns a new Conv2D Function. The bias can be nil.
+func NewConv2D(w, b Operand, xs []Operand, config Conv2DConfig) *Conv2D {
+	if config.StrideRows <= 0 || config.StrideCols <= 0 || config.DilationRows <= 0 || config.DilationCols <= 0 {
+		panic("fn: invalid convolution stride or dilation")
+	}
+	return &Conv2D{
+		w:      w,
+		b:      b,
+		xs:     xs,
+		config: config,
+	}
+}
+
+// Forward computes the output of the function.
+func (r *Conv2D) Forward() mat.Matrix {
+	w := r.w.Value()
+	if w.Columns() != len(r.xs)*r.config.KernelRows*r.config.KernelCols {
+		panic("fn: matrices with not compatible size")
+	}
+	cols := r.im2col()
+	defer mat.ReleaseDense(cols)
+	y := w.Mul(cols)
+	if r.b != nil {
+		b := r.b.Value()
+		if b.Size() != y.Rows() {
+			panic("fn: matrices with not compatible size")
+		}
+		data := y.Data()
+		n := y.Columns()
+		for i, v := range b.Data() {
+			for j := i * n; j < (i+1)*n; j++ {
+				data[j] += v
+			}
+		}
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *Conv2D) Backward(gy mat.Matrix) {
+	w := r.w.Value()
+	rows, cols := r.xs[0].Value().Dims()
+	outRows, outCols := r.config.OutputDims(rows, cols)
+	if gy.Rows() != w.Rows() || gy.Columns() != outRows*outCols {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.w.RequiresGrad() {
+		patches := r.im2col()
+		patchesT := patches.T()
+		mat.ReleaseDense(patches)
+		gw := gy.Mul(patchesT)
+		mat.ReleaseMatrix(patchesT)
+		r.w.PropagateGrad(gw)
+		mat.ReleaseMatrix(gw)
+	}
+	if r.b != nil && r.b.RequiresGrad() {
+		gb := mat.NewEmptyVecDense(gy.Rows())
+		data := gy.Data()
+		n := gy.Columns()
+		for i := range gb.Data() {
+			var sum mat.Float = 0.0
+			for _, v := range data[i*n : (i+1)*n] {
+				sum += v
+			}
+			gb.SetVec(i, sum)
+		}
+		r.b.PropagateGrad(gb)
+		mat.ReleaseDense(gb)
+	}
+	if r.xsRequireGrad() {
+		wt := w.T()
+		gPatches := wt.Mul(gy)
+		mat.ReleaseMatrix(wt)
+		r.col2im(gPatches)
+		mat.ReleaseMatrix(gPatches)
+	}
+}
+
+func (r *Conv2D) xsRequireGrad() bool {
+	for _, x := range r.xs {
+		if x.RequiresGrad() {
+			return true
+		}
+	}
+	return false
+}
+
+// forEachPatchElement calls fn for each element of the im2col matrix, passing its
+// position in the im2col matrix and in the input channel. Padding elements are skipped.
+func (r *Conv2D) forEachPatchElement(fn func(patchRow, patchCol, channel, i, j int)) {
+	c := r.config
+	rows, cols := r.xs[0].Value().Dims()
+	outRows, outCols := c.OutputDims(rows, cols)
+	for ch := range r.xs {
+		for ki := 0; ki < c.KernelRows; ki++ {
+			for kj := 0; kj < c.KernelCols; kj++ {
+				patchRow := (ch*c.KernelRows+ki)*c.KernelCols + kj
+				for oi := 0; oi < outRows; oi++ {
+					i := oi*c.StrideRows - c.PaddingRows + ki*c.DilationRows
+					if i < 0 || i >= rows {
+						continue
+					}
+					for oj := 0; oj < outCols; oj++ {
+						j := oj*c.StrideCols - c.PaddingCols + kj*c.DilationCols
+						if j < 0 || j >= cols {
+							continue
+						}
+						fn(patchRow, oi*outCols+oj, ch, i, j)
+					}
+				}
+			}
+		}
+	}
+}
+
+// im2col returns a matrix with a column for each output position, containing the
+// elements of the input patch (of all the channels) the kernels are applied to.
+func (r *Conv2D) im2col() *mat.Dense {
+	rows, cols := r.xs[0].Value().Dims()
+	outRows, outCols := r.config.OutputDims(rows, cols)
+	patches := mat.NewEmptyDense(len(r.xs)*r.config.KernelRows*r.config.KernelCols, outRows*outCols)
+	xs := make([]mat.Matrix, len(r.xs))
+	for i, x := range r.xs {
+		xs[i] = x.Value()
+		if !mat.SameDims(xs[i], xs[0]) {
+			panic("fn: input channels with different size")
+		}
+	}
+	r.forEachPatchElement(func(patchRow, patchCol, channel, i, j int) {
+		patches.Set(patchRow, patchCol, xs[channel].At(i, j))
+	})
+	return patches
+}
+
+// col2im accumulates the gradients of the im2col matrix into the input channels.
+func (r *Conv2D) col2im(gPatches mat.Matrix) {
+	rows, cols := r.xs[0].Value().Dims()
+	gxs := make([]*mat.Dense, len(r.xs))
+	for i := range gxs {
+		gxs[i] = mat.NewEmptyDense(rows, cols)
+	}
+	r.forEachPatchElement(func(patchRow, patchCol, channel, i, j int) {
+		gxs[channel].Set(i, j, gxs[channel].At(i, j)+gPatches.At(patchRow, patchCol))
+	})
+	for i, x := range r.xs {
+		if x.RequiresGrad() {
+			x.PropagateGrad(gxs[i])
+		}
+		mat.ReleaseDense(gxs[i])
+	}
+}
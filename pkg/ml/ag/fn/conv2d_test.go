@@ -0,0 +1,112 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConv2D_Forward(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(3, 3, []mat.Float{1, 2, 3, 4, 5, 6, 7, 8, 9}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	w := &variable{
+		value:        mat.NewDense(1, 4, []mat.Float{1, 0, 0, 1}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	b := &variable{
+		value:        mat.NewVecDense([]mat.Float{1}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewConv2D(w, b, []Operand{x}, Conv2DConfig{
+		KernelRows:   2,
+		KernelCols:   2,
+		StrideRows:   1,
+		StrideCols:   1,
+		DilationRows: 1,
+		DilationCols: 1,
+	})
+	y := f.Forward()
+
+	assert.Equal(t, 1, y.Rows())
+	assert.InDeltaSlice(t, []mat.Float{7, 9, 13, 15}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(1, 4, []mat.Float{1, 1, 1, 1}))
+
+	assert.InDeltaSlice(t, []mat.Float{12, 16, 24, 28}, w.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{4}, b.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{1, 1, 0, 1, 2, 1, 0, 1, 1}, x.grad.Data(), 1.0e-6)
+}
+
+func TestConv2D_ForwardWithPadding(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	w := &variable{
+		value:        mat.NewInitDense(2, 9, 1),
+		grad:         nil,
+		requiresGrad: false,
+	}
+	f := NewConv2D(w, nil, []Operand{x}, Conv2DConfig{
+		KernelRows:   3,
+		KernelCols:   3,
+		StrideRows:   1,
+		StrideCols:   1,
+		PaddingRows:  1,
+		PaddingCols:  1,
+		DilationRows: 1,
+		DilationCols: 1,
+	})
+	y := f.Forward()
+
+	assert.Equal(t, 2, y.Rows())
+	assert.InDeltaSlice(t, []mat.Float{10, 10, 10, 10, 10, 10, 10, 10}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewInitDense(2, 4, 1))
+
+	assert.Nil(t, w.grad)
+	assert.InDeltaSlice(t, []mat.Float{8, 8, 8, 8}, x.grad.Data(), 1.0e-6)
+}
+
+func TestConv2D_ForwardWithDilationAndStride(t *testing.T) {
+	x1 := &variable{
+		value:        mat.NewDense(3, 3, []mat.Float{1, 2, 3, 4, 5, 6, 7, 8, 9}),
+		grad:         nil,
+		requiresGrad: false,
+	}
+	x2 := &variable{
+		value:        mat.NewDense(3, 3, []mat.Float{9, 8, 7, 6, 5, 4, 3, 2, 1}),
+		grad:         nil,
+		requiresGrad: false,
+	}
+	w := &variable{
+		value:        mat.NewDense(1, 8, []mat.Float{1, 1, 1, 1, 0, 0, 0, 1}),
+		grad:         nil,
+		requiresGrad: false,
+	}
+	config := Conv2DConfig{
+		KernelRows:   2,
+		KernelCols:   2,
+		StrideRows:   2,
+		StrideCols:   2,
+		DilationRows: 2,
+		DilationCols: 2,
+	}
+	rows, cols := config.OutputDims(3, 3)
+	assert.Equal(t, 1, rows)
+	assert.Equal(t, 1, cols)
+
+	y := NewConv2D(w, nil, []Operand{x1, x2}, config).Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{21}, y.Data(), 1.0e-6)
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var (
+	_ Function = &CumSum{}
+	_ Function = &CumProd{}
+)
+
+// CumSum is a function to compute the cumulative sum of the input along the given axis:
+// 0 to accumulate the elements of each column (e.g. of a vector), 1 of each row.
+type CumSum struct {
+	x    Operand
+	axis int
+}
+
+// NewCumSum returns a new CumSum Function.
+func NewCumSum(x Operand, axis int) *CumSum {
+	validateAxis(axis)
+	return &CumSum{x: x, axis: axis}
+}
+
+// Forward computes the output of the function.
+func (r *CumSum) Forward() mat.Matrix {
+	x := r.x.Value()
+	y := x.Clone()
+	yData := y.Data()
+	forEachLine(x.Rows(), x.Columns(), r.axis, func(offset, stride, length int) {
+		for k := 1; k < length; k++ {
+			i := offset + k*stride
+			yData[i] += yData[i-stride]
+		}
+	})
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *CumSum) Backward(gy mat.Matrix) {
+	x := r.x.Value()
+	if !mat.SameDims(x, gy) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := gy.Clone()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		// the gradient is the reverse cumulative sum of gy
+		forEachLine(x.Rows(), x.Columns(), r.axis, func(offset, stride, length int) {
+			for k := length - 2; k >= 0; k-- {
+				i := offset + k*stride
+				gxData[i] += gxData[i+stride]
+			}
+		})
+		r.x.PropagateGrad(gx)
+	}
+}
+
+// CumProd is a function to compute the cumulative product of the input along the given axis:
+// 0 to accumulate the elements of each column (e.g. of a vector), 1 of each row.
+type CumProd struct {
+	x    Operand
+	axis int
+}
+
+// NewCumProd returns a new CumProd Function.
+func NewCumProd(x Operand, axis int) *CumProd {
+	validateAxis(axis)
+	return &CumProd{x: x, axis: axis}
+}
+
+// Forward computes the output of the function.
+func (r *CumProd) Forward() mat.Matrix {
+	x := r.x.Value()
+	y := x.Clone()
+	yData := y.Data()
+	forEachLine(x.Rows(), x.Columns(), r.axis, func(offset, stride, length int) {
+		for k := 1; k < length; k++ {
+			i := offset + k*stride
+			yData[i] *= yData[i-stride]
+		}
+	})
+	return y
+}
+
+// Backward computes the backward pass.
+// The gradient is computed without divisions, so that it is correct when the input contains zeros.
+func (r *CumProd) Backward(gy mat.Matrix) {
+	x := r.x.Value()
+	if !mat.SameDims(x, gy) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := x.ZerosLike()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		gyData := gy.Data()
+		xData := x.Data()
+		forEachLine(x.Rows(), x.Columns(), r.axis, func(offset, stride, length int) {
+			var prefix mat.Float = 1.0 // product of the elements before the k-th
+			for k := 0; k < length; k++ {
+				i := offset + k*stride
+				// sum over j >= k of gy[j] times the product of x[0..j] except x[k]
+				p := prefix
+				sum := gyData[i] * p
+				for j := k + 1; j < length; j++ {
+					p *= xData[offset+j*stride]
+					sum += gyData[offset+j*stride] * p
+				}
+				gxData[i] = sum
+				prefix *= xData[i]
+			}
+		})
+		r.x.PropagateGrad(gx)
+	}
+}
+
+func validateAxis(axis int) {
+	if axis != 0 && axis != 1 {
+		panic("fn: invalid axis, it must be 0 or 1")
+	}
+}
+
+// forEachLine calls fn for each column (axis 0) or row (axis 1) of a matrix with the given
+// dimensions, passing the flat index of its first element, the distance between two
+// consecutive elements, and the number of elements.
+func forEachLine(rows, cols, axis int, fn func(offset, stride, length int)) {
+	if axis == 0 {
+		for c := 0; c < cols; c++ {
+			fn(c, cols, rows)
+		}
+		return
+	}
+	for r := 0; r < rows; r++ {
+		fn(r*cols, 1, cols)
+	}
+}
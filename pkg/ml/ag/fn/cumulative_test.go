@@ -0,0 +1,93 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCumSum_Forward(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{0.1, 0.2, 0.3, 0.4}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewCumSum(x, 0)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{0.1, 0.3, 0.6, 1.0}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{1.0, 2.0, 3.0, 4.0}))
+
+	assert.InDeltaSlice(t, []mat.Float{10.0, 9.0, 7.0, 4.0}, x.grad.Data(), 1.0e-6)
+}
+
+func TestCumSum_ForwardRows(t *testing.T) {
+	x := &variable{
+		value: mat.NewDense(2, 3, []mat.Float{
+			1.0, 2.0, 3.0,
+			4.0, 5.0, 6.0,
+		}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewCumSum(x, 1)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{1.0, 3.0, 6.0, 4.0, 9.0, 15.0}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 3, []mat.Float{
+		1.0, 1.0, 1.0,
+		1.0, 0.0, 2.0,
+	}))
+
+	assert.InDeltaSlice(t, []mat.Float{3.0, 2.0, 1.0, 3.0, 2.0, 2.0}, x.grad.Data(), 1.0e-6)
+}
+
+func TestCumProd_Forward(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{2.0, 3.0, 0.5, 4.0}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewCumProd(x, 0)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{2.0, 6.0, 3.0, 12.0}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{1.0, 1.0, 1.0, 1.0}))
+
+	// d/dx0 = 1 + 3 + 1.5 + 6; d/dx1 = 2 + 1 + 4; d/dx2 = 6 + 24; d/dx3 = 3
+	assert.InDeltaSlice(t, []mat.Float{11.5, 7.0, 30.0, 3.0}, x.grad.Data(), 1.0e-6)
+}
+
+func TestCumProd_BackwardWithZeros(t *testing.T) {
+	x := &variable{
+		value: mat.NewDense(2, 3, []mat.Float{
+			2.0, 0.0, 3.0,
+			1.0, 2.0, 3.0,
+		}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewCumProd(x, 1)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{2.0, 0.0, 0.0, 1.0, 2.0, 6.0}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 3, []mat.Float{
+		1.0, 1.0, 1.0,
+		0.0, 0.0, 1.0,
+	}))
+
+	// d/dx1 of the first row = 2 + 2*3
+	assert.InDeltaSlice(t, []mat.Float{1.0, 8.0, 0.0, 6.0, 3.0, 2.0}, x.grad.Data(), 1.0e-6)
+}
+
+func TestNewCumSum_InvalidAxis(t *testing.T) {
+	assert.Panics(t, func() { NewCumSum(nil, 2) })
+}
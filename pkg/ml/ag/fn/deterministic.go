@@ -0,0 +1,55 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// deterministic is set to 1 when the functions must run without concurrency.
+var deterministic int32
+
+// SetDeterministic sets whether the functions must avoid any concurrent computation whose
+// outcome depends on the scheduling of the goroutines, such as the propagation of gradients
+// from concurrent tasks to the same operand.
+// You usually don't need to call it directly: see ag.SetDeterministic.
+func SetDeterministic(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&deterministic, value)
+}
+
+// Deterministic reports whether the deterministic mode is enabled.
+func Deterministic() bool {
+	return atomic.LoadInt32(&deterministic) == 1
+}
+
+// runTasks runs the given tasks concurrently, or sequentially in the deterministic mode.
+// Nil tasks are ignored.
+func runTasks(tasks ...func()) {
+	if Deterministic() {
+		for _, task := range tasks {
+			if task != nil {
+				task()
+			}
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		if task == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(task func()) {
+			defer wg.Done()
+			task()
+		}(task)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,238 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import mat "github.com/nlpodyssey/spago/pkg/mat32"
+
+var _ Function = &FlashAttention{}
+
+// FlashAttention is a memory-efficient scaled dot-product attention function.
+// The keys and the values are processed in blocks, keeping a running maximum and
+// a running sum of the scores of each query (online softmax), so that the full
+// attention matrix is never materialized, neither during the forward nor during
+// the backward pass. The extra memory is linear in the length of the sequence.
+//
+// Reference: "FlashAttention: Fast and Memory-Efficient Exact Attention with IO-Awareness"
+// by Dao et al., 2022 (https://arxiv.org/abs/2205.14135).
+type FlashAttention struct {
+	q         Operand // queries, one for each row
+	k         Operand // keys, one for each row
+	v         Operand // values, one for each row
+	scale     mat.Float
+	causal    bool
+	blockSize int
+	y         mat.Matrix  // initialized during the forward pass (required by the backward pass)
+	lse       []mat.Float // log-sum-exp of the scores of each query, initialized during the forward pass
+}
+
+// NewFlashAttention returns a new FlashAttention Function.
+// The queries, the keys and the values are matrices with one element for each row.
+// If causal is true, the i-th query attends only to the keys up to the i-th. If there are more keys than
+// queries (e.g. the keys of the past inputs of an incremental decoding), the first keys in excess are
+// attended by all the queries.
+func NewFlashAttention(q, k, v Operand, scale mat.Float, causal bool, blockSize int) *FlashAttention {
+	if blockSize <= 0 {
+		panic("fn: invalid block size")
+	}
+	return &FlashAttention{
+		q:         q,
+		k:         k,
+		v:         v,
+		scale:     scale,
+		causal:    causal,
+		blockSize: blockSize,
+	}
+}
+
+// Forward computes the output of the function.
+func (r *FlashAttention) Forward() mat.Matrix {
+	q, k, v := r.q.Value(), r.k.Value(), r.v.Value()
+	if q.Columns() != k.Columns() || k.Rows() != v.Rows() {
+		panic("fn: matrices with not compatible size")
+	}
+	nq, dv := q.Rows(), v.Columns()
+	y := mat.NewEmptyDense(nq, dv)
+	yData := y.Data()
+	r.lse = make([]mat.Float, nq)
+	r.forEachBlock(nq, func(start, end int) {
+		scores := make([]mat.Float, r.blockSize)
+		for i := start; i < end; i++ {
+			r.lse[i] = r.forwardRow(i, scores, yData[i*dv:(i+1)*dv])
+		}
+	})
+	r.y = y
+	return y
+}
+
+// forwardRow computes the attention of the i-th query, writing the result into out.
+// It returns the log-sum-exp of the scores.
+func (r *FlashAttention) forwardRow(i int, scores, out []mat.Float) mat.Float {
+	qi := row(r.q.Value(), i)
+	k, v := r.k.Value(), r.v.Value()
+	nk := r.keysLength(i)
+	m := mat.Inf(-1)
+	var l mat.Float = 0.0
+	for start := 0; start < nk; start += r.blockSize {
+		end := minInt(start+r.blockSize, nk)
+		blockMax := mat.Inf(-1)
+		for j := start; j < end; j++ {
+			s := r.scale * dotSlices(qi, row(k, j))
+			scores[j-start] = s
+			if s > blockMax {
+				blockMax = s
+			}
+		}
+		if blockMax > m {
+			c := mat.Exp(m - blockMax)
+			l *= c
+			for h := range out {
+				out[h] *= c
+			}
+			m = blockMax
+		}
+		for j := start; j < end; j++ {
+			p := mat.Exp(scores[j-start] - m)
+			l += p
+			axpy(p, row(v, j), out)
+		}
+	}
+	for h := range out {
+		out[h] /= l
+	}
+	return m + mat.Log(l)
+}
+
+// Backward computes the backward pass.
+// The attention probabilities are recomputed from the cached log-sum-exp values.
+func (r *FlashAttention) Backward(gy mat.Matrix) {
+	if !mat.SameDims(r.y, gy) {
+		panic("fn: matrices with not compatible size")
+	}
+	q, k, v := r.q.Value(), r.k.Value(), r.v.Value()
+	nq, nk := q.Rows(), k.Rows()
+	gyData := gy.Data()
+	dv := v.Columns()
+
+	// delta[i] is the dot product between the i-th output and its gradient
+	delta := make([]mat.Float, nq)
+	yData := r.y.Data()
+	for i := range delta {
+		delta[i] = dotSlices(yData[i*dv:(i+1)*dv], gyData[i*dv:(i+1)*dv])
+	}
+
+	// prob returns the attention probability of the j-th key for the i-th query,
+	// and the gradient of the corresponding score.
+	prob := func(i, j int) (p, ds mat.Float) {
+		p = mat.Exp(r.scale*dotSlices(row(q, i), row(k, j)) - r.lse[i])
+		dp := dotSlices(gyData[i*dv:(i+1)*dv], row(v, j))
+		return p, p * (dp - delta[i])
+	}
+
+	var gqTask, gkvTask func()
+	if r.q.RequiresGrad() {
+		gqTask = func() {
+			gq := mat.NewEmptyDense(q.Dims())
+			defer mat.ReleaseDense(gq)
+			r.forEachBlock(nq, func(start, end int) {
+				for i := start; i < end; i++ {
+					gqi := row(gq, i)
+					for j, n := 0, r.keysLength(i); j < n; j++ {
+						_, ds := prob(i, j)
+						axpy(r.scale*ds, row(k, j), gqi)
+					}
+				}
+			})
+			r.q.PropagateGrad(gq)
+		}
+	}
+	if r.k.RequiresGrad() || r.v.RequiresGrad() {
+		gkvTask = func() {
+			gk := mat.NewEmptyDense(k.Dims())
+			defer mat.ReleaseDense(gk)
+			gv := mat.NewEmptyDense(v.Dims())
+			defer mat.ReleaseDense(gv)
+			r.forEachBlock(nk, func(start, end int) {
+				for j := start; j < end; j++ {
+					gkj, gvj := row(gk, j), row(gv, j)
+					for i := r.firstQuery(j); i < nq; i++ {
+						p, ds := prob(i, j)
+						axpy(p, gyData[i*dv:(i+1)*dv], gvj)
+						axpy(r.scale*ds, row(q, i), gkj)
+					}
+				}
+			})
+			if r.k.RequiresGrad() {
+				r.k.PropagateGrad(gk)
+			}
+			if r.v.RequiresGrad() {
+				r.v.PropagateGrad(gv)
+			}
+		}
+	}
+	runTasks(gqTask, gkvTask)
+}
+
+// keysLength returns the number of keys the i-th query attends to.
+func (r *FlashAttention) keysLength(i int) int {
+	nk := r.k.Value().Rows()
+	if n := r.pastLength() + i + 1; r.causal && n < nk {
+		return n
+	}
+	return nk
+}
+
+// firstQuery returns the index of the first query attending to the j-th key.
+func (r *FlashAttention) firstQuery(j int) int {
+	if first := j - r.pastLength(); r.causal && first > 0 {
+		return first
+	}
+	return 0
+}
+
+// pastLength returns the number of keys in excess with respect to the queries.
+func (r *FlashAttention) pastLength() int {
+	if n := r.k.Value().Rows() - r.q.Value().Rows(); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// forEachBlock splits the range [0, n) in blocks and calls fn for each of them, in order.
+// The blocks are not processed concurrently: the graph already runs the operators concurrently,
+// within its limits (see ag.ConcurrentComputations and ag.ThreadBudget), which an operator
+// starting its own goroutines would exceed.
+func (r *FlashAttention) forEachBlock(n int, fn func(start, end int)) {
+	for start := 0; start < n; start += r.blockSize {
+		fn(start, minInt(start+r.blockSize, n))
+	}
+}
+
+// row returns the i-th row of the matrix, sharing the underlying data.
+func row(m mat.Matrix, i int) []mat.Float {
+	cols := m.Columns()
+	return m.Data()[i*cols : (i+1)*cols]
+}
+
+func dotSlices(a, b []mat.Float) mat.Float {
+	var sum mat.Float = 0.0
+	for i, x := range a {
+		sum += x * b[i]
+	}
+	return sum
+}
+
+// axpy computes y += alpha * x.
+func axpy(alpha mat.Float, x, y []mat.Float) {
+	for i, v := range x {
+		y[i] += alpha * v
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFlashAttention_Forward(t *testing.T) {
+	q := &variable{
+		value:        mat.NewDense(1, 2, []mat.Float{1, 0}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	k := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{1, 0, 0, 1}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	v := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewFlashAttention(q, k, v, 1.0, false, 1)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{1.5378828, 2.5378828}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(1, 2, []mat.Float{1, 0}))
+
+	assert.InDeltaSlice(t, []mat.Float{-0.3932238, 0.3932238}, q.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-0.3932238, 0, 0.3932238, 0}, k.grad.Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.7310586, 0, 0.2689414, 0}, v.grad.Data(), 1.0e-6)
+}
+
+func TestFlashAttention_ForwardCausal(t *testing.T) {
+	q := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{1, 0, 1, 0}),
+		grad:         nil,
+		requiresGrad: false,
+	}
+	k := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{1, 0, 0, 1}),
+		grad:         nil,
+		requiresGrad: false,
+	}
+	v := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4}),
+		grad:         nil,
+		requiresGrad: false,
+	}
+	f := NewFlashAttention(q, k, v, 1.0, true, 2)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{1, 2, 1.5378828, 2.5378828}, y.Data(), 1.0e-6)
+}
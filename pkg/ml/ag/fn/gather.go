@@ -0,0 +1,52 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &Gather{}
+
+// Gather is a function to extract the elements at the given indices from the input,
+// which is treated as a flat vector. The same index can appear more than once.
+type Gather struct {
+	x       Operand
+	indices []int
+}
+
+// NewGather returns a new Gather Function.
+func NewGather(x Operand, indices []int) *Gather {
+	return &Gather{x: x, indices: indices}
+}
+
+// Forward computes the output of the function.
+func (r *Gather) Forward() mat.Matrix {
+	data := r.x.Value().Data()
+	y := mat.NewEmptyVecDense(len(r.indices))
+	for i, index := range r.indices {
+		if index < 0 || index >= len(data) {
+			panic("fn: index out of range")
+		}
+		y.SetVec(i, data[index])
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *Gather) Backward(gy mat.Matrix) {
+	if gy.Size() != len(r.indices) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := r.x.Value().ZerosLike()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		for i, v := range gy.Data() {
+			gxData[r.indices[i]] += v
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
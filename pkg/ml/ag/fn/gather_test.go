@@ -0,0 +1,36 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGather_Forward(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{0.1, 0.2, 0.3, 0.4}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewGather(x, []int{3, 0, 3})
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{0.4, 0.1, 0.4}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{1.0, 2.0, 3.0}))
+
+	assert.InDeltaSlice(t, []mat.Float{2.0, 0.0, 0.0, 4.0}, x.grad.Data(), 1.0e-6)
+}
+
+func TestGather_ForwardOutOfRange(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{0.1, 0.2}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	assert.Panics(t, func() { NewGather(x, []int{2}).Forward() })
+}
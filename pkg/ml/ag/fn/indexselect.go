@@ -0,0 +1,60 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &IndexSelect{}
+
+// IndexSelect is a function to extract the rows at the given indices from the input matrix,
+// returning a new matrix with a row for each index. The same index can appear more than once.
+type IndexSelect struct {
+	x       Operand
+	indices []int
+}
+
+// NewIndexSelect returns a new IndexSelect Function.
+func NewIndexSelect(x Operand, indices []int) *IndexSelect {
+	return &IndexSelect{x: x, indices: indices}
+}
+
+// Forward computes the output of the function.
+func (r *IndexSelect) Forward() mat.Matrix {
+	x := r.x.Value()
+	rows, cols := x.Dims()
+	data := x.Data()
+	y := mat.NewEmptyDense(len(r.indices), cols)
+	yData := y.Data()
+	for i, index := range r.indices {
+		if index < 0 || index >= rows {
+			panic("fn: index out of range")
+		}
+		copy(yData[i*cols:(i+1)*cols], data[index*cols:(index+1)*cols])
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *IndexSelect) Backward(gy mat.Matrix) {
+	cols := r.x.Value().Columns()
+	if gy.Rows() != len(r.indices) || gy.Columns() != cols {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := r.x.Value().ZerosLike()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		gyData := gy.Data()
+		for i, index := range r.indices {
+			dst := gxData[index*cols : (index+1)*cols]
+			for j, v := range gyData[i*cols : (i+1)*cols] {
+				dst[j] += v
+			}
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
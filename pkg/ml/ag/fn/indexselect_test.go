@@ -0,0 +1,37 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIndexSelect_Forward(t *testing.T) {
+	x := &variable{
+		value: mat.NewDense(3, 2, []mat.Float{
+			0.1, 0.2,
+			0.3, 0.4,
+			0.5, 0.6,
+		}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewIndexSelect(x, []int{2, 0, 2})
+	y := f.Forward()
+
+	assert.Equal(t, 3, y.Rows())
+	assert.Equal(t, 2, y.Columns())
+	assert.InDeltaSlice(t, []mat.Float{0.5, 0.6, 0.1, 0.2, 0.5, 0.6}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(3, 2, []mat.Float{
+		1.0, 2.0,
+		3.0, 4.0,
+		5.0, 6.0,
+	}))
+
+	assert.InDeltaSlice(t, []mat.Float{3.0, 4.0, 0.0, 0.0, 6.0, 8.0}, x.grad.Data(), 1.0e-6)
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &LayerNorm{}
+
+// LayerNorm is a fused layer normalization function, computing
+// y = (x - E[x]) / sqrt(VAR[x] + eps) * w + b
+// in a single step, instead of a chain of elementary operators.
+type LayerNorm struct {
+	x   Operand
+	w   Operand
+	b   Operand
+	eps mat.Float
+	// initialized during the forward pass (required by the backward pass)
+	xHat   mat.Matrix
+	stdDev mat.Float
+}
+
+// NewLayerNorm returns a new LayerNorm Function.
+func NewLayerNorm(x, w, b Operand, eps mat.Float) *LayerNorm {
+	return &LayerNorm{x: x, w: w, b: b, eps: eps}
+}
+
+// Forward computes the output of the function.
+func (r *LayerNorm) Forward() mat.Matrix {
+	x := r.x.Value()
+	if x.Size() != r.w.Value().Size() || x.Size() != r.b.Value().Size() {
+		panic("fn: matrices with not compatible size")
+	}
+	data := x.Data()
+	n := mat.Float(len(data))
+	var mean mat.Float = 0.0
+	for _, v := range data {
+		mean += v
+	}
+	mean /= n
+	var variance mat.Float = 0.0
+	for _, v := range data {
+		d := v - mean
+		variance += d * d
+	}
+	variance /= n
+	r.stdDev = mat.Sqrt(variance + r.eps)
+
+	r.xHat = x.ZerosLike()
+	xHatData := r.xHat.Data()
+	y := x.ZerosLike()
+	yData := y.Data()
+	wData := r.w.Value().Data()
+	bData := r.b.Value().Data()
+	for i, v := range data {
+		xHatData[i] = (v - mean) / r.stdDev
+		yData[i] = xHatData[i]*wData[i] + bData[i]
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *LayerNorm) Backward(gy mat.Matrix) {
+	if gy.Size() != r.xHat.Size() {
+		panic("fn: matrices with not compatible size")
+	}
+	gyData := gy.Data()
+	xHatData := r.xHat.Data()
+	if r.x.RequiresGrad() {
+		wData := r.w.Value().Data()
+		n := mat.Float(len(gyData))
+		// means of the gradient w.r.t. xHat, and of its product with xHat
+		var meanG, meanGXHat mat.Float = 0.0, 0.0
+		for i, v := range gyData {
+			g := v * wData[i]
+			meanG += g
+			meanGXHat += g * xHatData[i]
+		}
+		meanG /= n
+		meanGXHat /= n
+		gx := r.x.Value().ZerosLike()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		for i, v := range gyData {
+			gxData[i] = (v*wData[i] - meanG - xHatData[i]*meanGXHat) / r.stdDev
+		}
+		r.x.PropagateGrad(gx)
+	}
+	if r.w.RequiresGrad() {
+		gw := r.w.Value().ZerosLike()
+		defer mat.ReleaseMatrix(gw)
+		gwData := gw.Data()
+		for i, v := range gyData {
+			gwData[i] = v * xHatData[i]
+		}
+		r.w.PropagateGrad(gw)
+	}
+	if r.b.RequiresGrad() {
+		gb := r.b.Value().ZerosLike()
+		defer mat.ReleaseMatrix(gb)
+		copy(gb.Data(), gyData)
+		r.b.PropagateGrad(gb)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &LogSoftmax{}
+
+// LogSoftmax is a single-input log-softmax function, computed as x - log(sum(exp(x))).
+// Unlike Log(Softmax(x)), it does not underflow for very negative log-probabilities.
+type LogSoftmax struct {
+	x Operand
+	y mat.Matrix // initialized during the forward pass (required by the backward pass)
+}
+
+// NewLogSoftmax returns a new LogSoftmax Function.
+func NewLogSoftmax(x Operand) *LogSoftmax {
+	return &LogSoftmax{x: x}
+}
+
+// Forward computes the output of this function.
+func (r *LogSoftmax) Forward() mat.Matrix {
+	data := r.x.Value().Data()
+	lse := logSumExp(data)
+	out := make([]mat.Float, len(data))
+	for i, x := range data {
+		out[i] = x - lse
+	}
+	r.y = mat.NewVecDense(out)
+	return r.y
+}
+
+// Backward computes the backward pass.
+func (r *LogSoftmax) Backward(gy mat.Matrix) {
+	if !(mat.SameDims(r.x.Value(), gy) || mat.VectorsOfSameSize(r.x.Value(), gy)) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := mat.NewEmptyVecDense(r.y.Size())
+		defer mat.ReleaseDense(gx)
+		gyData := gy.Data()
+		sum := gy.Sum()
+		for i, y := range r.y.Data() {
+			gx.SetVec(i, gyData[i]-mat.Exp(y)*sum)
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLogSoftmax_Forward(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{-0.41, -1.08, 0, 0.87, -0.19, -0.75}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewLogSoftmax(x)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{-2.1486193, -2.8186193, -1.7386193, -0.8686193, -1.9286193, -2.4886193}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{0.5, 0.0, -1.0, 0.0, 0.0, 0.2}))
+
+	assert.InDeltaSlice(t, []mat.Float{0.5349935, 0.0179065, -0.9472711, 0.1258591, 0.0436046, 0.2249074}, x.grad.Data(), 1.0e-6)
+}
+
+func TestLogSoftmax_ForwardLargeRange(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{1000, 0, -1000}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewLogSoftmax(x)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{0, -1000, -2000}, y.Data(), 1.0e-6)
+}
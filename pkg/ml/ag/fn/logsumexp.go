@@ -0,0 +1,56 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &LogSumExp{}
+
+// LogSumExp is a single-input function computing log(sum(exp(x))).
+// The maximum of x is subtracted before the exponentiation to avoid overflows.
+type LogSumExp struct {
+	x Operand
+	y mat.Float // initialized during the forward pass (required by the backward pass)
+}
+
+// NewLogSumExp returns a new LogSumExp Function.
+func NewLogSumExp(x Operand) *LogSumExp {
+	return &LogSumExp{x: x}
+}
+
+// Forward computes the output of this function.
+func (r *LogSumExp) Forward() mat.Matrix {
+	r.y = logSumExp(r.x.Value().Data())
+	return mat.NewScalar(r.y)
+}
+
+// Backward computes the backward pass.
+func (r *LogSumExp) Backward(gy mat.Matrix) {
+	if !gy.IsScalar() {
+		panic("fn: the gradient had to be a scalar")
+	}
+	if r.x.RequiresGrad() {
+		x := r.x.Value()
+		gx := x.ZerosLike()
+		defer mat.ReleaseMatrix(gx)
+		g := gy.Scalar()
+		gxData := gx.Data()
+		for i, v := range x.Data() {
+			gxData[i] = g * mat.Exp(v-r.y) // the gradient is softmax(x)
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
+
+func logSumExp(v []mat.Float) mat.Float {
+	maximum := max(v)
+	var sum mat.Float = 0.0
+	for _, x := range v {
+		sum += mat.Exp(x - maximum)
+	}
+	return maximum + mat.Log(sum)
+}
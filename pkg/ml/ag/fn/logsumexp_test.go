@@ -0,0 +1,39 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLogSumExp_Forward(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{-0.41, -1.08, 0, 0.87, -0.19, -0.75}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewLogSumExp(x)
+	y := f.Forward()
+
+	assert.InDelta(t, 1.7386193, y.Scalar(), 1.0e-6)
+
+	f.Backward(mat.NewScalar(2.0))
+
+	assert.InDeltaSlice(t, []mat.Float{0.2332902, 0.1193766, 0.3515258, 0.8390608, 0.2906975, 0.166049}, x.grad.Data(), 1.0e-6)
+}
+
+func TestLogSumExp_ForwardLargeRange(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{1000, 1000}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewLogSumExp(x)
+	y := f.Forward()
+
+	assert.InDelta(t, 1000.6931472, y.Scalar(), 1.0e-3)
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &MaskedFill{}
+
+// MaskedFill is a function that replaces the elements of the input with the given value
+// where the mask is non-zero (e.g. with -inf to mask the attention scores).
+// The gradient of the replaced elements is zero.
+type MaskedFill struct {
+	x     Operand
+	mask  mat.Matrix
+	value mat.Float
+}
+
+// NewMaskedFill returns a new MaskedFill Function.
+func NewMaskedFill(x Operand, mask mat.Matrix, value mat.Float) *MaskedFill {
+	return &MaskedFill{x: x, mask: mask, value: value}
+}
+
+// Forward computes the output of the function.
+func (r *MaskedFill) Forward() mat.Matrix {
+	x := r.x.Value()
+	if x.Size() != r.mask.Size() {
+		panic("fn: matrices with not compatible size")
+	}
+	y := x.Clone()
+	yData := y.Data()
+	for i, m := range r.mask.Data() {
+		if m != 0.0 {
+			yData[i] = r.value
+		}
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *MaskedFill) Backward(gy mat.Matrix) {
+	if !(mat.SameDims(r.x.Value(), gy) || mat.VectorsOfSameSize(r.x.Value(), gy)) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := gy.Clone()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		for i, m := range r.mask.Data() {
+			if m != 0.0 {
+				gxData[i] = 0.0
+			}
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
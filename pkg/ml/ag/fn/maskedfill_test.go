@@ -0,0 +1,28 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMaskedFill_Forward(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{0.1, 0.2, 0.3, 0.4}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	mask := mat.NewVecDense([]mat.Float{0, 1, 0, 1})
+	f := NewMaskedFill(x, mask, mat.Inf(-1))
+	y := f.Forward()
+
+	assert.Equal(t, []mat.Float{0.1, mat.Inf(-1), 0.3, mat.Inf(-1)}, y.Data())
+
+	f.Backward(mat.NewVecDense([]mat.Float{1.0, 2.0, 3.0, 4.0}))
+
+	assert.InDeltaSlice(t, []mat.Float{1.0, 0.0, 3.0, 0.0}, x.grad.Data(), 1.0e-6)
+}
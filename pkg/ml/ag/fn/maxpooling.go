@@ -46,9 +46,9 @@ func (r *MaxPooling) Forward() mat.Matrix {
 
 	for row := 0; row < r.y.Rows(); row++ {
 		for col := 0; col < r.y.Columns(); col++ {
-			maximum := mat.SmallestNonzeroFloat
+			maximum := mat.Inf(-1)
 			for i := row * r.rows; i < (row*r.rows)+r.rows; i++ {
-				for j := col * r.cols; j < (col*r.cols)+r.rows; j++ {
+				for j := col * r.cols; j < (col*r.cols)+r.cols; j++ {
 					val := r.x.Value().At(i, j)
 					if val > maximum {
 						maximum = val
@@ -49,3 +49,20 @@ func TestMaxPool_Forward(t *testing.T) {
 		t.Error("The rows and columns of the resulting x-gradients matrix are not correct")
 	}
 }
+
+func TestMaxPool_ForwardWithRectangularWindowAndNegativeValues(t *testing.T) {
+	x := &variable{
+		value: mat.NewDense(2, 6, []mat.Float{
+			-0.4, -0.1, -0.9, -0.5, -0.2, -0.3,
+			-0.4, -0.3, -0.7, -0.3, -0.8, -0.6,
+		}),
+		grad:         nil,
+		requiresGrad: false,
+	}
+	y := NewMaxPooling(x, 1, 3).Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{
+		-0.1, -0.2,
+		-0.3, -0.3,
+	}, y.Data(), 1.0e-6)
+}
@@ -6,7 +6,6 @@ package fn
 
 import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
-	"sync"
 )
 
 var _ Function = &Mul{}
@@ -36,22 +35,18 @@ func (r *Mul) Backward(gy mat.Matrix) {
 	if !(r.x1.Value().Rows() == gy.Rows() && r.x2.Value().Columns() == gy.Columns()) {
 		panic("fn: matrices with not compatible size")
 	}
-	var wg sync.WaitGroup
+	var gx1, gx2 func()
 	if r.x1.RequiresGrad() {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+		gx1 = func() {
 			x2t := r.x2.Value().T()
 			defer mat.ReleaseMatrix(x2t)
 			gx := gy.Mul(x2t)
 			defer mat.ReleaseMatrix(gx)
 			r.x1.PropagateGrad(gx)
-		}()
+		}
 	}
 	if r.x2.RequiresGrad() {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+		gx2 = func() {
 			//r.x2.PropagateGrad(gy.T().Mul(r.x1).T()) // alternative method
 			if gy.Columns() == 1 {
 				gx := r.x1.Value().MulT(gy)
@@ -64,7 +59,7 @@ func (r *Mul) Backward(gy mat.Matrix) {
 				defer mat.ReleaseMatrix(gx)
 				r.x2.PropagateGrad(gx)
 			}
-		}()
+		}
 	}
-	wg.Wait()
+	runTasks(gx1, gx2)
 }
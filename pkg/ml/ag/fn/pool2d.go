@@ -0,0 +1,179 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var (
+	_ Function = &MaxPool2D{}
+	_ Function = &AvgPool2D{}
+)
+
+// Pool2DConfig provides the configuration of the two-dimensional pooling functions.
+type Pool2DConfig struct {
+	KernelRows  int
+	KernelCols  int
+	StrideRows  int
+	StrideCols  int
+	PaddingRows int
+	PaddingCols int
+}
+
+// OutputDims returns the dimensions of the output, given the dimensions of the input.
+func (c Pool2DConfig) OutputDims(rows, cols int) (int, int) {
+	outRows := (rows+2*c.PaddingRows-c.KernelRows)/c.StrideRows + 1
+	outCols := (cols+2*c.PaddingCols-c.KernelCols)/c.StrideCols + 1
+	return outRows, outCols
+}
+
+func (c Pool2DConfig) validate() {
+	if c.KernelRows <= 0 || c.KernelCols <= 0 || c.StrideRows <= 0 || c.StrideCols <= 0 {
+		panic("fn: invalid pooling kernel size or stride")
+	}
+	if c.PaddingRows < 0 || c.PaddingCols < 0 || 2*c.PaddingRows > c.KernelRows || 2*c.PaddingCols > c.KernelCols {
+		panic("fn: pooling padding must be at most half of the kernel size")
+	}
+}
+
+// forEachWindow calls fn for each output position, passing the boundaries
+// of the corresponding input window, clipped to the input (i.e. excluding the padding).
+func (c Pool2DConfig) forEachWindow(rows, cols int, fn func(oi, oj, fromRow, toRow, fromCol, toCol int)) {
+	outRows, outCols := c.OutputDims(rows, cols)
+	for oi := 0; oi < outRows; oi++ {
+		fromRow := oi*c.StrideRows - c.PaddingRows
+		toRow := minInt(fromRow+c.KernelRows, rows)
+		if fromRow < 0 {
+			fromRow = 0
+		}
+		for oj := 0; oj < outCols; oj++ {
+			fromCol := oj*c.StrideCols - c.PaddingCols
+			toCol := minInt(fromCol+c.KernelCols, cols)
+			if fromCol < 0 {
+				fromCol = 0
+			}
+			fn(oi, oj, fromRow, toRow, fromCol, toCol)
+		}
+	}
+}
+
+// MaxPool2D is a two-dimensional max pooling, with support for stride and padding.
+// The padding elements are ignored when computing the maximum.
+type MaxPool2D struct {
+	x      Operand
+	config Pool2DConfig
+	// initialized during the forward pass
+	argmax []int // flat index of the maximum of each window
+}
+
+// NewMaxPool2D returns a new MaxPool2D Function.
+func NewMaxPool2D(x Operand, config Pool2DConfig) *MaxPool2D {
+	config.validate()
+	return &MaxPool2D{
+		x:      x,
+		config: config,
+		argmax: nil,
+	}
+}
+
+// Forward computes the output of the function.
+func (r *MaxPool2D) Forward() mat.Matrix {
+	x := r.x.Value()
+	rows, cols := x.Dims()
+	outRows, outCols := r.config.OutputDims(rows, cols)
+	y := mat.NewEmptyDense(outRows, outCols)
+	r.argmax = make([]int, outRows*outCols)
+	data := x.Data()
+	r.config.forEachWindow(rows, cols, func(oi, oj, fromRow, toRow, fromCol, toCol int) {
+		argmax := fromRow*cols + fromCol
+		for i := fromRow; i < toRow; i++ {
+			for j := fromCol; j < toCol; j++ {
+				if data[i*cols+j] > data[argmax] {
+					argmax = i*cols + j
+				}
+			}
+		}
+		r.argmax[oi*outCols+oj] = argmax
+		y.Set(oi, oj, data[argmax])
+	})
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *MaxPool2D) Backward(gy mat.Matrix) {
+	if gy.Size() != len(r.argmax) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := r.x.Value().ZerosLike()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		for i, v := range gy.Data() {
+			gxData[r.argmax[i]] += v
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
+
+// AvgPool2D is a two-dimensional average pooling, with support for stride and padding.
+// The padding elements are counted as zeros when computing the average.
+type AvgPool2D struct {
+	x      Operand
+	config Pool2DConfig
+}
+
+// NewAvgPool2D returns a new AvgPool2D Function.
+func NewAvgPool2D(x Operand, config Pool2DConfig) *AvgPool2D {
+	config.validate()
+	return &AvgPool2D{
+		x:      x,
+		config: config,
+	}
+}
+
+// Forward computes the output of the function.
+func (r *AvgPool2D) Forward() mat.Matrix {
+	x := r.x.Value()
+	rows, cols := x.Dims()
+	y := mat.NewEmptyDense(r.config.OutputDims(rows, cols))
+	data := x.Data()
+	n := mat.Float(r.config.KernelRows * r.config.KernelCols)
+	r.config.forEachWindow(rows, cols, func(oi, oj, fromRow, toRow, fromCol, toCol int) {
+		var sum mat.Float = 0.0
+		for i := fromRow; i < toRow; i++ {
+			for _, v := range data[i*cols+fromCol : i*cols+toCol] {
+				sum += v
+			}
+		}
+		y.Set(oi, oj, sum/n)
+	})
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *AvgPool2D) Backward(gy mat.Matrix) {
+	x := r.x.Value()
+	rows, cols := x.Dims()
+	outRows, outCols := r.config.OutputDims(rows, cols)
+	if gy.Rows() != outRows || gy.Columns() != outCols {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := x.ZerosLike()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		n := mat.Float(r.config.KernelRows * r.config.KernelCols)
+		r.config.forEachWindow(rows, cols, func(oi, oj, fromRow, toRow, fromCol, toCol int) {
+			g := gy.At(oi, oj) / n
+			for i := fromRow; i < toRow; i++ {
+				for j := fromCol; j < toCol; j++ {
+					gxData[i*cols+j] += g
+				}
+			}
+		})
+		r.x.PropagateGrad(gx)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMaxPool2D_Forward(t *testing.T) {
+	x := &variable{
+		value: mat.NewDense(4, 4, []mat.Float{
+			0.4, 0.1, -0.9, -0.5,
+			-0.4, 0.3, 0.7, -0.3,
+			0.8, 0.2, 0.6, 0.7,
+			0.2, -0.1, 0.6, -0.2,
+		}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewMaxPool2D(x, Pool2DConfig{KernelRows: 2, KernelCols: 2, StrideRows: 2, StrideCols: 2})
+	y := f.Forward()
+
+	assert.Equal(t, 2, y.Rows())
+	assert.Equal(t, 2, y.Columns())
+	assert.InDeltaSlice(t, []mat.Float{0.4, 0.7, 0.8, 0.7}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(2, 2, []mat.Float{0.5, -0.5, 1.0, 2.0}))
+
+	assert.InDeltaSlice(t, []mat.Float{
+		0.5, 0.0, 0.0, 0.0,
+		0.0, 0.0, -0.5, 0.0,
+		1.0, 0.0, 0.0, 2.0,
+		0.0, 0.0, 0.0, 0.0,
+	}, x.grad.Data(), 1.0e-6)
+}
+
+func TestMaxPool2D_ForwardWithOverlappingWindowsAndPadding(t *testing.T) {
+	x := &variable{
+		value: mat.NewDense(3, 3, []mat.Float{
+			-0.1, -0.2, -0.3,
+			-0.4, -0.5, -0.6,
+			-0.7, -0.8, -0.9,
+		}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewMaxPool2D(x, Pool2DConfig{
+		KernelRows:  3,
+		KernelCols:  3,
+		StrideRows:  2,
+		StrideCols:  2,
+		PaddingRows: 1,
+		PaddingCols: 1,
+	})
+	y := f.Forward()
+
+	assert.Equal(t, 2, y.Rows())
+	assert.Equal(t, 2, y.Columns())
+	assert.InDeltaSlice(t, []mat.Float{-0.1, -0.2, -0.4, -0.5}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewInitDense(2, 2, 1.0))
+
+	assert.InDeltaSlice(t, []mat.Float{1, 1, 0, 1, 1, 0, 0, 0, 0}, x.grad.Data(), 1.0e-6)
+}
+
+func TestAvgPool2D_Forward(t *testing.T) {
+	x := &variable{
+		value: mat.NewDense(2, 4, []mat.Float{
+			0.4, 0.1, -0.9, -0.5,
+			-0.4, 0.3, 0.7, -0.3,
+		}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewAvgPool2D(x, Pool2DConfig{KernelRows: 2, KernelCols: 2, StrideRows: 1, StrideCols: 2})
+	y := f.Forward()
+
+	assert.Equal(t, 1, y.Rows())
+	assert.Equal(t, 2, y.Columns())
+	assert.InDeltaSlice(t, []mat.Float{0.1, -0.25}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewDense(1, 2, []mat.Float{0.4, -0.8}))
+
+	assert.InDeltaSlice(t, []mat.Float{
+		0.1, 0.1, -0.2, -0.2,
+		0.1, 0.1, -0.2, -0.2,
+	}, x.grad.Data(), 1.0e-6)
+}
+
+func TestAvgPool2D_ForwardWithPadding(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewAvgPool2D(x, Pool2DConfig{
+		KernelRows:  2,
+		KernelCols:  2,
+		StrideRows:  2,
+		StrideCols:  2,
+		PaddingRows: 1,
+		PaddingCols: 1,
+	})
+	y := f.Forward()
+
+	assert.Equal(t, 2, y.Rows())
+	assert.Equal(t, 2, y.Columns())
+	assert.InDeltaSlice(t, []mat.Float{0.25, 0.5, 0.75, 1.0}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewInitDense(2, 2, 1.0))
+
+	assert.InDeltaSlice(t, []mat.Float{0.25, 0.25, 0.25, 0.25}, x.grad.Data(), 1.0e-6)
+}
+
+func TestPool2DConfig_InvalidPadding(t *testing.T) {
+	x := &variable{value: mat.NewEmptyDense(2, 2)}
+	assert.Panics(t, func() {
+		NewAvgPool2D(x, Pool2DConfig{KernelRows: 2, KernelCols: 2, StrideRows: 1, StrideCols: 1, PaddingRows: 2})
+	})
+}
@@ -11,6 +11,7 @@ import (
 var _ Function = &Prod{}
 
 // Prod is an operator to perform element-wise product over two values.
+// x2 can also be a row or column vector, broadcast against the matrix x1.
 type Prod struct {
 	x1 Operand
 	x2 Operand
@@ -30,7 +31,7 @@ func NewSquare(x Operand) *Prod {
 func (r *Prod) Forward() mat.Matrix {
 	x1v := r.x1.Value()
 	x2v := r.x2.Value()
-	if !(mat.SameDims(x1v, x2v) || mat.VectorsOfSameSize(x1v, x2v)) {
+	if !elementwiseCompatible(x1v, x2v) {
 		panic("fn: matrices with not compatible size")
 	}
 	return x1v.Prod(x2v)
@@ -45,13 +46,22 @@ func (r *Prod) Backward(gy mat.Matrix) {
 		panic("fn: matrices with not compatible size")
 	}
 	if r.x1.RequiresGrad() {
-		gx := r.x2.Value().Prod(gy)
+		var gx mat.Matrix
+		if mat.IsBroadcastable(gy, x2v) {
+			gx = gy.Prod(x2v)
+		} else {
+			gx = x2v.Prod(gy)
+		}
 		defer mat.ReleaseMatrix(gx)
 		r.x1.PropagateGrad(gx)
 	}
 	if r.x2.RequiresGrad() {
-		gx := r.x1.Value().Prod(gy)
+		gx := x1v.Prod(gy)
 		defer mat.ReleaseMatrix(gx)
+		if reduced := reduceBroadcast(gx, x2v); reduced != nil {
+			defer mat.ReleaseMatrix(reduced)
+			gx = reduced
+		}
 		r.x2.PropagateGrad(gx)
 	}
 }
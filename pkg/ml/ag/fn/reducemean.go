@@ -31,7 +31,7 @@ func (r *ReduceMean) Backward(gy mat.Matrix) {
 		panic("fn: the gradient had to be a scalar")
 	}
 	if r.x.RequiresGrad() {
-		gx := mat.NewInitVecDense(r.x.Value().Size(), gy.Scalar()/mat.Float(r.x.Value().Size()))
+		gx := mat.NewInitDense(r.x.Value().Rows(), r.x.Value().Columns(), gy.Scalar()/mat.Float(r.x.Value().Size()))
 		defer mat.ReleaseDense(gx)
 		r.x.PropagateGrad(gx)
 	}
@@ -26,3 +26,22 @@ func TestReduceSum_Forward(t *testing.T) {
 
 	assert.InDeltaSlice(t, []mat.Float{0.5, 0.5, 0.5, 0.5}, x.grad.Data(), 1.0e-6)
 }
+
+func TestReduceSum_ForwardMatrix(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{0.1, 0.2, 0.3, 0.0}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+
+	f := NewReduceSum(x)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{0.6}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{0.5}))
+
+	assert.Equal(t, 2, x.grad.Rows())
+	assert.Equal(t, 2, x.grad.Columns())
+	assert.InDeltaSlice(t, []mat.Float{0.5, 0.5, 0.5, 0.5}, x.grad.Data(), 1.0e-6)
+}
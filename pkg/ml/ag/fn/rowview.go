@@ -23,12 +23,16 @@ func NewRowView(x Operand, i int) *RowView {
 }
 
 // Forward computes the output of the function.
+// If the input is Dense, the output shares its underlying data.
 func (r *RowView) Forward() mat.Matrix {
 	xv := r.x.Value()
 	rows, cols := xv.Dims()
 	if r.i >= rows {
 		panic("fn: matrix with not compatible size")
 	}
+	if x, ok := xv.(*mat.Dense); ok {
+		return x.RowsRange(r.i, r.i+1)
+	}
 	y := mat.GetDenseWorkspace(1, cols)
 	for j := 0; j < cols; j++ {
 		y.Set(0, j, xv.At(r.i, j))
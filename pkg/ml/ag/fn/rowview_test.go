@@ -42,3 +42,18 @@ func TestRow_Forward(t *testing.T) {
 		0.1, 0.2, -0.8, -0.1,
 	}, x.grad.Data(), 1.0e-6)
 }
+
+func TestRowView_ForwardSharesData(t *testing.T) {
+	x := &variable{
+		value:        mat.NewDense(2, 2, []mat.Float{0.1, 0.2, 0.3, 0.4}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	y := NewRowView(x, 1).Forward()
+
+	assert.True(t, y.(*mat.Dense).IsView())
+	assert.InDeltaSlice(t, []mat.Float{0.3, 0.4}, y.Data(), 1.0e-6)
+
+	mat.ReleaseMatrix(y) // no-op on views
+	assert.InDeltaSlice(t, []mat.Float{0.1, 0.2, 0.3, 0.4}, x.value.Data(), 1.0e-6)
+}
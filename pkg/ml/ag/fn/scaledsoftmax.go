@@ -0,0 +1,76 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &ScaledSoftmax{}
+
+// ScaledSoftmax is a fused function computing softmax(x * scale + mask), as used
+// to obtain the attention probabilities from the attention scores.
+// The mask is added to the scaled input (e.g. -inf for the masked positions); it can be nil.
+type ScaledSoftmax struct {
+	x     Operand
+	scale mat.Float
+	mask  mat.Matrix
+	y     mat.Matrix // initialized during the forward pass (required by the backward pass)
+}
+
+// NewScaledSoftmax returns a new ScaledSoftmax Function.
+func NewScaledSoftmax(x Operand, scale mat.Float, mask mat.Matrix) *ScaledSoftmax {
+	return &ScaledSoftmax{x: x, scale: scale, mask: mask}
+}
+
+// Forward computes the output of the function.
+func (r *ScaledSoftmax) Forward() mat.Matrix {
+	r.y = mat.NewVecDense(scaledSoftmax(r.x.Value(), r.scale, r.mask))
+	return r.y
+}
+
+// Backward computes the backward pass.
+func (r *ScaledSoftmax) Backward(gy mat.Matrix) {
+	if !(mat.SameDims(r.x.Value(), gy) || mat.VectorsOfSameSize(r.x.Value(), gy)) {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := scaledSoftmaxGrad(r.y.Data(), gy.Data(), r.scale)
+		defer mat.ReleaseDense(gx)
+		r.x.PropagateGrad(gx)
+	}
+}
+
+func scaledSoftmax(x mat.Matrix, scale mat.Float, mask mat.Matrix) []mat.Float {
+	data := x.Data()
+	v := make([]mat.Float, len(data))
+	for i, e := range data {
+		v[i] = e * scale
+	}
+	if mask != nil {
+		if mask.Size() != len(v) {
+			panic("fn: matrices with not compatible size")
+		}
+		for i, m := range mask.Data() {
+			v[i] += m
+		}
+	}
+	return softmax(v)
+}
+
+// scaledSoftmaxGrad returns the gradient of softmax(x * scale) w.r.t. x,
+// given the softmax output y and the output gradients gy.
+func scaledSoftmaxGrad(y, gy []mat.Float, scale mat.Float) *mat.Dense {
+	var dot mat.Float = 0.0
+	for i, v := range y {
+		dot += v * gy[i]
+	}
+	gx := mat.NewEmptyVecDense(len(y))
+	gxData := gx.Data()
+	for i, v := range y {
+		gxData[i] = scale * v * (gy[i] - dot)
+	}
+	return gx
+}
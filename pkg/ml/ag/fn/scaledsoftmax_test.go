@@ -0,0 +1,40 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScaledSoftmax_Forward(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{1.0, 2.0, 3.0}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	mask := mat.NewVecDense([]mat.Float{0, 0, mat.Inf(-1)})
+	f := NewScaledSoftmax(x, 0.5, mask)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{0.377541, 0.622459, 0.0}, y.Data(), 1.0e-6)
+
+	f.Backward(mat.NewVecDense([]mat.Float{1.0, 0.0, 0.0}))
+
+	assert.InDeltaSlice(t, []mat.Float{0.117502, -0.117502, 0.0}, x.grad.Data(), 1.0e-6)
+}
+
+func TestScaledSoftmax_ForwardWithoutMask(t *testing.T) {
+	x := &variable{
+		value:        mat.NewVecDense([]mat.Float{-0.82, -2.16, 0, 1.74, -0.38, -1.5}),
+		grad:         nil,
+		requiresGrad: true,
+	}
+	f := NewScaledSoftmax(x, 0.5, nil)
+	y := f.Forward()
+
+	assert.InDeltaSlice(t, []mat.Float{0.1166451, 0.0596882, 0.1757629, 0.4195304, 0.1453487, 0.083024}, y.Data(), 1.0e-6)
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &Scatter{}
+
+// Scatter is a function to place the elements of the input vector into a new vector
+// of the given size, at the given indices. Values scattered to the same index are summed.
+// It is the inverse of Gather.
+type Scatter struct {
+	x       Operand
+	indices []int
+	size    int
+}
+
+// NewScatter returns a new Scatter Function.
+func NewScatter(x Operand, indices []int, size int) *Scatter {
+	return &Scatter{x: x, indices: indices, size: size}
+}
+
+// Forward computes the output of the function.
+func (r *Scatter) Forward() mat.Matrix {
+	data := r.x.Value().Data()
+	if len(data) != len(r.indices) {
+		panic("fn: the number of indices must be equal to the size of the input")
+	}
+	y := mat.NewEmptyVecDense(r.size)
+	yData := y.Data()
+	for i, index := range r.indices {
+		if index < 0 || index >= r.size {
+			panic("fn: index out of range")
+		}
+		yData[index] += data[i]
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *Scatter) Backward(gy mat.Matrix) {
+	if gy.Size() != r.size {
+		panic("fn: matrices with not compatible size")
+	}
+	if r.x.RequiresGrad() {
+		gx := r.x.Value().ZerosLike()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		gyData := gy.Data()
+		for i, index := range r.indices {
+			gxData[i] = gyData[index]
+		}
+		r.x.PropagateGrad(gx)
+	}
+}
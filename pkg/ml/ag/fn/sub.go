@@ -11,6 +11,7 @@ import (
 var _ Function = &Sub{}
 
 // Sub is an element-wise subtraction function over two values.
+// x2 can also be a row or column vector, broadcast against the matrix x1.
 type Sub struct {
 	x1 Operand
 	x2 Operand
@@ -25,7 +26,7 @@ func NewSub(x1, x2 Operand) *Sub {
 func (r *Sub) Forward() mat.Matrix {
 	x1v := r.x1.Value()
 	x2v := r.x2.Value()
-	if !(mat.SameDims(x1v, x2v) || mat.VectorsOfSameSize(x1v, x2v)) {
+	if !elementwiseCompatible(x1v, x2v) {
 		panic("fn: matrices with not compatible size")
 	}
 	return x1v.Sub(x2v)
@@ -45,6 +46,10 @@ func (r *Sub) Backward(gy mat.Matrix) {
 	if r.x2.RequiresGrad() {
 		gx := gy.ProdScalar(-1.0)
 		defer mat.ReleaseMatrix(gx)
+		if reduced := reduceBroadcast(gx, x2v); reduced != nil {
+			defer mat.ReleaseMatrix(reduced)
+			gx = reduced
+		}
 		r.x2.PropagateGrad(gx)
 	}
 }
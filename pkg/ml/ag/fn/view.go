@@ -25,7 +25,15 @@ func NewView(x Operand, sx, sy, lx, ly int) *View {
 }
 
 // Forward computes the output of the function.
+// If the input is Dense, the output shares its underlying data whenever the portion is contiguous
+// (see mat.Dense.Slice).
 func (r *View) Forward() mat.Matrix {
+	if x, ok := r.x.Value().(*mat.Dense); ok {
+		if x.IsContiguous(r.sx, r.sx+r.lx, r.sy, r.sy+r.ly) {
+			return x.Slice(r.sx, r.sx+r.lx, r.sy, r.sy+r.ly)
+		}
+		return x.CopySlice(r.sx, r.sx+r.lx, r.sy, r.sy+r.ly)
+	}
 	y := mat.NewEmptyDense(r.lx, r.ly)
 	for i := 0; i < r.lx; i++ {
 		for j := 0; j < r.ly; j++ {
@@ -0,0 +1,66 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+var _ Function = &Where{}
+
+// Where is a function that selects, element-wise, the value of x1 where the
+// condition is non-zero, and the value of x2 otherwise.
+type Where struct {
+	condition mat.Matrix
+	x1        Operand
+	x2        Operand
+}
+
+// NewWhere returns a new Where Function.
+func NewWhere(condition mat.Matrix, x1, x2 Operand) *Where {
+	return &Where{condition: condition, x1: x1, x2: x2}
+}
+
+// Forward computes the output of the function.
+func (r *Where) Forward() mat.Matrix {
+	x1v, x2v := r.x1.Value(), r.x2.Value()
+	if !(mat.SameDims(x1v, x2v) || mat.VectorsOfSameSize(x1v, x2v)) || x1v.Size() != r.condition.Size() {
+		panic("fn: matrices with not compatible size")
+	}
+	y := x1v.Clone()
+	yData := y.Data()
+	x2Data := x2v.Data()
+	for i, c := range r.condition.Data() {
+		if c == 0.0 {
+			yData[i] = x2Data[i]
+		}
+	}
+	return y
+}
+
+// Backward computes the backward pass.
+func (r *Where) Backward(gy mat.Matrix) {
+	if gy.Size() != r.condition.Size() {
+		panic("fn: matrices with not compatible size")
+	}
+	propagate := func(x Operand, selected bool) {
+		gx := x.Value().ZerosLike()
+		defer mat.ReleaseMatrix(gx)
+		gxData := gx.Data()
+		gyData := gy.Data()
+		for i, c := range r.condition.Data() {
+			if (c != 0.0) == selected {
+				gxData[i] = gyData[i]
+			}
+		}
+		x.PropagateGrad(gx)
+	}
+	if r.x1.RequiresGrad() {
+		propagate(r.x1, true)
+	}
+	if r.x2.RequiresGrad() {
+		propagate(r.x2, false)
+	}
+}
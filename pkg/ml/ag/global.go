@@ -219,6 +219,21 @@ func ColView(x Node, column int) Node {
 	return globalGraph.ColView(x, column)
 }
 
+// Gather returns a new operator node as a result of the fn.Gather function.
+func Gather(x Node, indices []int) Node {
+	return globalGraph.Gather(x, indices)
+}
+
+// Scatter returns a new operator node as a result of the fn.Scatter function.
+func Scatter(x Node, indices []int, size int) Node {
+	return globalGraph.Scatter(x, indices, size)
+}
+
+// IndexSelect returns a new operator node as a result of the fn.IndexSelect function.
+func IndexSelect(x Node, indices []int) Node {
+	return globalGraph.IndexSelect(x, indices)
+}
+
 // RotateR performs the right circular shift.
 // `i` is the number of places by which the elements are shifted.
 func RotateR(x Node, i int) Node {
@@ -245,6 +260,21 @@ func Pow(x Node, power mat.Float) Node {
 	return globalGraph.Pow(x, power)
 }
 
+// Clamp returns a new operator node as a result of the fn.Clamp function.
+func Clamp(x Node, min, max mat.Float) Node {
+	return globalGraph.Clamp(x, min, max)
+}
+
+// MaskedFill returns a new operator node as a result of the fn.MaskedFill function.
+func MaskedFill(x Node, mask mat.Matrix, value mat.Float) Node {
+	return globalGraph.MaskedFill(x, mask, value)
+}
+
+// Where returns a new operator node as a result of the fn.Where function.
+func Where(condition mat.Matrix, x1 Node, x2 Node) Node {
+	return globalGraph.Where(condition, x1, x2)
+}
+
 // Sqrt returns a new operator node as a result of the `Sqrt` function.
 func Sqrt(x Node) Node {
 	return globalGraph.Sqrt(x)
@@ -355,11 +385,26 @@ func Softmax(x Node) Node {
 	return globalGraph.Softmax(x)
 }
 
-// LogSoftmax returns a new operator node as a result of Log(Softmax(x)).
+// ScaledSoftmax returns a new operator node as a result of the fn.ScaledSoftmax function.
+func ScaledSoftmax(x Node, scale mat.Float, mask mat.Matrix) Node {
+	return globalGraph.ScaledSoftmax(x, scale, mask)
+}
+
+// LogSoftmax returns a new operator node as a result of the fn.LogSoftmax function.
 func LogSoftmax(x Node) Node {
 	return globalGraph.LogSoftmax(x)
 }
 
+// LogSumExp returns a new operator node as a result of the fn.LogSumExp function.
+func LogSumExp(x Node) Node {
+	return globalGraph.LogSumExp(x)
+}
+
+// LayerNorm returns a new operator node as a result of the fn.LayerNorm function.
+func LayerNorm(x, w, b Node, eps mat.Float) Node {
+	return globalGraph.LayerNorm(x, w, b, eps)
+}
+
 // SparseMax returns a new operator node as a result of the fn.SparseMax function.
 func SparseMax(x Node) Node {
 	return globalGraph.SparseMax(x)
@@ -425,6 +470,28 @@ func Mean(xs []Node) Node {
 	return globalGraph.Mean(xs)
 }
 
+// Sort returns a new operator node with the elements of x sorted in ascending order,
+// or in descending order if descending is true, along with their original indices.
+func Sort(x Node, descending bool) (Node, []int) {
+	return globalGraph.Sort(x, descending)
+}
+
+// TopK returns a new operator node with the k largest elements of x in descending order,
+// along with their original indices.
+func TopK(x Node, k int) (Node, []int) {
+	return globalGraph.TopK(x, k)
+}
+
+// CumSum returns a new operator node as a result of the fn.CumSum function.
+func CumSum(x Node, axis int) Node {
+	return globalGraph.CumSum(x, axis)
+}
+
+// CumProd returns a new operator node as a result of the fn.CumProd function.
+func CumProd(x Node, axis int) Node {
+	return globalGraph.CumProd(x, axis)
+}
+
 // Concat returns a new operator node as a result of the fn.Concat function.
 func Concat(xs ...Node) Node {
 	return globalGraph.Concat(xs...)
@@ -434,3 +501,23 @@ func Concat(xs ...Node) Node {
 func Stack(xs ...Node) Node {
 	return globalGraph.Stack(xs...)
 }
+
+// FlashAttention returns a new operator node as a result of the fn.FlashAttention function.
+func FlashAttention(q, k, v Node, scale mat.Float, causal bool, blockSize int) Node {
+	return globalGraph.FlashAttention(q, k, v, scale, causal, blockSize)
+}
+
+// Conv2D returns a new operator node as a result of the fn.Conv2D function.
+func Conv2D(w, b Node, xs []Node, config fn.Conv2DConfig) Node {
+	return globalGraph.Conv2D(w, b, xs, config)
+}
+
+// MaxPool2D returns a new operator node as a result of the fn.MaxPool2D function.
+func MaxPool2D(x Node, config fn.Pool2DConfig) Node {
+	return globalGraph.MaxPool2D(x, config)
+}
+
+// AvgPool2D returns a new operator node as a result of the fn.AvgPool2D function.
+func AvgPool2D(x Node, config fn.Pool2DConfig) Node {
+	return globalGraph.AvgPool2D(x, config)
+}
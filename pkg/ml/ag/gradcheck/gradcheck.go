@@ -0,0 +1,196 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gradcheck provides a numerical gradient-checking utility, which compares the gradients
+// computed by the backward pass with the ones estimated by central finite differences.
+//
+// It is meant to validate the implementation of new functions (see package ag/fn) and models.
+// The computations are performed with the precision of mat.Float, so the tolerance must be
+// relaxed accordingly when the checked functions are far from linear.
+package gradcheck
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+// Function builds the computation to check on the graph g, given the input nodes.
+type Function func(g *ag.Graph, xs []ag.Node) ag.Node
+
+// ModelFunction builds the computation to check on the graph g, given the model reified on it.
+type ModelFunction func(g *ag.Graph, proc nn.Model) ag.Node
+
+// Config provides configuration settings for the gradient checking.
+type Config struct {
+	// Epsilon is the perturbation applied to each element to estimate its gradient.
+	Epsilon mat.Float
+	// Tolerance is the maximum accepted difference between the analytical and the numerical
+	// gradients, relative to their magnitude (if greater than one).
+	Tolerance mat.Float
+	// Seed is used to initialize the random generator of the graphs and the random weights
+	// used to reduce a non-scalar output to a scalar.
+	Seed uint64
+}
+
+// DefaultConfig is a configuration suitable for most functions in single precision.
+var DefaultConfig = Config{
+	Epsilon:   1e-3,
+	Tolerance: 1e-2,
+	Seed:      1,
+}
+
+// MismatchError reports an element whose analytical gradient doesn't match the numerical one.
+type MismatchError struct {
+	// Name identifies the input, or the model parameter, containing the element.
+	Name string
+	// Index is the index of the element in the flattened input.
+	Index      int
+	Analytical mat.Float
+	Numerical  mat.Float
+}
+
+// Error satisfies the error interface.
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("gradcheck: %s[%d]: analytical gradient %g, numerical gradient %g",
+		e.Name, e.Index, e.Analytical, e.Numerical)
+}
+
+// Check compares the gradients of the output of f with respect to each of the inputs with the ones
+// estimated by central finite differences. The inputs are not modified.
+// A non-scalar output is reduced to a scalar as a weighted sum of its elements, with random weights.
+// It returns a *MismatchError for the first element whose gradients don't match, or nil.
+func Check(f Function, inputs []mat.Matrix, config Config) error {
+	c := newChecker(config)
+	values := make([]mat.Matrix, len(inputs))
+	for i, in := range inputs {
+		values[i] = in.Clone()
+	}
+
+	g := ag.NewGraph(ag.RandSeed(config.Seed))
+	xs := make([]ag.Node, len(values))
+	for i, v := range values {
+		xs[i] = g.NewVariable(v, true)
+	}
+	g.Backward(c.reduce(g, f(g, xs)))
+
+	eval := func() mat.Float {
+		g := ag.NewGraph(ag.RandSeed(config.Seed))
+		xs := make([]ag.Node, len(values))
+		for i, v := range values {
+			xs[i] = g.NewVariable(v, false)
+		}
+		return c.reduce(g, f(g, xs)).ScalarValue()
+	}
+
+	for i, x := range xs {
+		if err := c.compare(fmt.Sprintf("input %d", i), values[i], x.Grad(), eval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckModel compares the gradients of the output of f with respect to each parameter of the model
+// with the ones estimated by central finite differences. The model is reified for training on a new
+// graph at each evaluation; the values of the parameters are restored at the end, and their
+// gradients are zeroed.
+// It returns a *MismatchError for the first element whose gradients don't match, or nil.
+func CheckModel(m nn.Model, f ModelFunction, config Config) error {
+	c := newChecker(config)
+	eval := func(backward bool) mat.Float {
+		g := ag.NewGraph(ag.RandSeed(config.Seed))
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m)
+		y := c.reduce(g, f(g, proc))
+		if backward {
+			g.Backward(y)
+		}
+		return y.ScalarValue()
+	}
+
+	nn.ZeroGrad(m)
+	defer nn.ZeroGrad(m)
+	eval(true)
+
+	var names []string
+	var params []nn.Param
+	var grads []mat.Matrix
+	nn.ForEachParamWithPath(m, func(path string, param nn.Param) {
+		if !param.RequiresGrad() {
+			return
+		}
+		names = append(names, path)
+		params = append(params, param)
+		if param.HasGrad() {
+			grads = append(grads, param.Grad().Clone())
+		} else {
+			grads = append(grads, nil)
+		}
+	})
+
+	for i, param := range params {
+		err := c.compare(names[i], param.Value(), grads[i], func() mat.Float { return eval(false) })
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type checker struct {
+	Config
+	// weights is used to reduce a non-scalar output; it is initialized at the first evaluation.
+	weights mat.Matrix
+}
+
+func newChecker(config Config) *checker {
+	return &checker{Config: config}
+}
+
+// reduce returns y if it is a scalar, otherwise the weighted sum of its elements.
+func (c *checker) reduce(g *ag.Graph, y ag.Node) ag.Node {
+	if y.Value().Size() == 1 {
+		return y
+	}
+	if c.weights == nil {
+		generator := rand.NewLockedRand(c.Seed)
+		c.weights = y.Value().ZerosLike()
+		data := c.weights.Data()
+		for i := range data {
+			data[i] = generator.Float()*2 - 1
+		}
+	}
+	return g.ReduceSum(g.Prod(y, g.NewVariable(c.weights, false)))
+}
+
+// compare perturbs in place each element of value, estimating its gradient from the results
+// of eval, and compares it with the analytical gradient grad (nil means zeros).
+func (c *checker) compare(name string, value, grad mat.Matrix, eval func() mat.Float) error {
+	data := value.Data()
+	for i, v := range data {
+		data[i] = v + c.Epsilon
+		plus := eval()
+		data[i] = v - c.Epsilon
+		minus := eval()
+		data[i] = v
+
+		numerical := mat.Float((float64(plus) - float64(minus)) / (2 * float64(c.Epsilon)))
+		var analytical mat.Float
+		if grad != nil {
+			analytical = grad.Data()[i]
+		}
+		scale := mat.Max(1, mat.Max(mat.Abs(analytical), mat.Abs(numerical)))
+		if mat.Abs(analytical-numerical)/scale > c.Tolerance {
+			return &MismatchError{
+				Name:       name,
+				Index:      i,
+				Analytical: analytical,
+				Numerical:  numerical,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gradcheck
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	x := mat.NewDense(2, 3, []mat.Float{0.1, -0.2, 0.3, 0.4, 0.5, -0.6})
+	w := mat.NewDense(3, 2, []mat.Float{0.5, -0.1, 0.2, 0.3, -0.4, 0.6})
+	v := mat.NewVecDense([]mat.Float{0.3, -0.5, 0.8})
+	b := mat.NewVecDense([]mat.Float{0.1, 0.2, -0.3})
+
+	tests := []struct {
+		name   string
+		f      Function
+		inputs []mat.Matrix
+	}{
+		{"Mul", func(g *ag.Graph, xs []ag.Node) ag.Node { return g.Mul(xs[0], xs[1]) }, []mat.Matrix{x, w}},
+		{"Softmax", func(g *ag.Graph, xs []ag.Node) ag.Node { return g.Softmax(xs[0]) }, []mat.Matrix{v}},
+		{"LogSumExp", func(g *ag.Graph, xs []ag.Node) ag.Node { return g.LogSumExp(xs[0]) }, []mat.Matrix{v}},
+		{"ScaledSoftmax", func(g *ag.Graph, xs []ag.Node) ag.Node { return g.ScaledSoftmax(xs[0], 0.5, nil) }, []mat.Matrix{v}},
+		{"LayerNorm", func(g *ag.Graph, xs []ag.Node) ag.Node {
+			return g.LayerNorm(xs[0], xs[1], xs[2], 1e-5)
+		}, []mat.Matrix{v, b, b}},
+		{"CumProd", func(g *ag.Graph, xs []ag.Node) ag.Node { return g.CumProd(xs[0], 1) }, []mat.Matrix{x}},
+		{"Tanh", func(g *ag.Graph, xs []ag.Node) ag.Node { return g.Tanh(xs[0]) }, []mat.Matrix{x}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, Check(tt.f, tt.inputs, DefaultConfig))
+		})
+	}
+
+	assert.Equal(t, []mat.Float{0.3, -0.5, 0.8}, v.Data(), "the inputs must not be modified")
+}
+
+func TestCheck_Mismatch(t *testing.T) {
+	x := mat.NewVecDense([]mat.Float{0.5, -1.0, 2.0})
+	// the second operand doesn't propagate the gradients, so the analytical gradient is half the numerical one
+	f := func(g *ag.Graph, xs []ag.Node) ag.Node {
+		return g.ReduceSum(g.Prod(xs[0], g.NewWrapNoGrad(xs[0])))
+	}
+	err := Check(f, []mat.Matrix{x}, DefaultConfig)
+	if assert.Error(t, err) {
+		mismatch, ok := err.(*MismatchError)
+		assert.True(t, ok)
+		assert.Equal(t, "input 0", mismatch.Name)
+		assert.Equal(t, 0, mismatch.Index)
+		assert.InDelta(t, 0.5, mismatch.Analytical, 1e-6)
+		assert.InDelta(t, 1.0, mismatch.Numerical, 1e-2)
+	}
+}
+
+func TestCheckModel(t *testing.T) {
+	model := linear.New(3, 2)
+	r := rand.NewLockedRand(42)
+	initializers.XavierUniform(model.W.Value(), 1.0, r)
+	initializers.Uniform(model.B.Value(), -0.1, 0.1, r)
+	w := model.W.Value().Clone()
+
+	x := mat.NewVecDense([]mat.Float{0.3, -0.5, 0.8})
+	f := func(g *ag.Graph, proc nn.Model) ag.Node {
+		y := proc.(*linear.Model).Forward(g.NewVariable(x, false))[0]
+		return g.ReduceSum(g.Square(g.Sigmoid(y)))
+	}
+	assert.NoError(t, CheckModel(model, f, DefaultConfig))
+	assert.Equal(t, w.Data(), model.W.Value().Data())
+	assert.False(t, model.W.HasGrad())
+}
@@ -5,6 +5,7 @@
 package ag
 
 import (
+	"context"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/mat32/rand"
 	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
@@ -46,6 +47,11 @@ type Graph struct {
 	// such as forward and backward steps.
 	// The default size is defaultProcessingQueueSize.
 	processingQueue processingqueue.ProcessingQueue
+	// threadBudget is shared with other graphs, to limit their concurrent computations as a whole.
+	// If nil, the global one is used (see SetGlobalThreadBudget).
+	threadBudget *ThreadBudget
+	// arena recycles the memory of the nodes (nil if disabled).
+	arena *arena
 }
 
 // defaultProcessingQueueSize is the default size of Graph.processingQueue on a new Graph.
@@ -78,6 +84,22 @@ func IncrementalForward(value bool) GraphOption {
 	}
 }
 
+// Arena sets whether the graph recycles the memory of its nodes (default false).
+// When enabled, the nodes, their values and the gradients released by Clear and Truncate are kept in
+// free-lists (the matrices are keyed by shape, up to a maximum number for each shape), so that the
+// next computations on the same graph reuse them instead of allocating new ones. It is useful when the same graph serves many computations, as the
+// graphs of the processors of a nn.ProcessorPool do.
+// Since the released nodes are reused, they must not be accessed after Clear or Truncate.
+func Arena(value bool) GraphOption {
+	return func(g *Graph) {
+		if value {
+			g.arena = newArena()
+		} else {
+			g.arena = nil
+		}
+	}
+}
+
 // ConcurrentComputations sets the maximum number of concurrent computations handled by the Graph
 // for heavy tasks such as forward and backward steps.
 // The value 1 corresponds to sequential execution.
@@ -92,6 +114,7 @@ func ConcurrentComputations(value int) GraphOption {
 
 // NewGraph returns a new initialized graph.
 // It can take an optional random generator of type rand.Rand.
+// In the deterministic mode (see SetDeterministic) the computations are always sequential.
 func NewGraph(opts ...GraphOption) *Graph {
 	g := &Graph{
 		maxID:              -1,
@@ -106,7 +129,10 @@ func NewGraph(opts ...GraphOption) *Graph {
 		opt(g)
 	}
 	if g.randGen == nil {
-		g.randGen = rand.NewLockedRand(1) // set default random generator
+		g.randGen = rand.NewLockedRand(DeterministicSeed) // set default random generator
+	}
+	if DeterministicEnabled() {
+		g.processingQueue = processingqueue.New(1)
 	}
 	return g
 }
@@ -137,13 +163,11 @@ func (g *Graph) Clear() {
 	g.releaseMemory()
 
 	for _, node := range g.nodes {
-		if node, ok := node.(*operator); ok {
-			*node = operator{}
-			operatorPool.Put(node)
-		}
+		g.recycleNode(node)
 	}
 
 	g.nodes = nil
+	g.constants = map[mat.Float]Node{}
 }
 
 // clearCache cleans the cache.
@@ -166,6 +190,104 @@ func (g *Graph) ClearForReuse() {
 	g.releaseMemory()
 }
 
+// Truncate removes all the nodes created after the node with the given ID, releasing their memory
+// as Clear does. The time step is restored to the one of the last remaining node.
+// It allows to reuse a graph whose first nodes are still valid, such as the ones created during the
+// reification of a model (see nn.ProcessorPool). A negative ID removes all the nodes.
+func (g *Graph) Truncate(maxID int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if maxID >= g.maxID {
+		return
+	}
+	if maxID < 0 {
+		maxID = -1
+	}
+	g.clearCache()
+	for i, node := range g.nodes[maxID+1:] {
+		if node, ok := node.(*operator); ok {
+			g.releaseValue(node)
+			g.releaseGrad(node)
+		}
+		g.recycleNode(node)
+		g.nodes[maxID+1+i] = nil
+	}
+	for value, node := range g.constants {
+		if node.ID() > maxID {
+			delete(g.constants, value)
+		}
+	}
+	g.maxID = maxID
+	if maxID == -1 {
+		g.nodes = nil
+		g.curTimeStep = 0
+		return
+	}
+	g.nodes = g.nodes[:maxID+1]
+	g.curTimeStep = g.nodes[maxID].TimeStep()
+}
+
+// DetachHistory removes all the nodes created after the node with the given ID, as Truncate does,
+// keeping only the values of the given nodes: they are returned as new variables that don't require
+// gradients, in the same order (nil nodes stay nil). The time step is not changed.
+// It allows the truncated back-propagation through time with a bounded graph: the hidden states of a
+// recurrent network are carried over, while the history that produced them is removed.
+func (g *Graph) DetachHistory(maxID int, nodes ...Node) []Node {
+	values := make([]mat.Matrix, len(nodes))
+	for i, node := range nodes {
+		if node != nil {
+			values[i] = g.GetCopiedValue(node)
+		}
+	}
+	timeStep := g.TimeStep()
+	g.Truncate(maxID)
+	g.curTimeStep = timeStep
+	detached := make([]Node, len(nodes))
+	for i, value := range values {
+		if value != nil {
+			detached[i] = g.NewVariable(value, false)
+		}
+	}
+	return detached
+}
+
+// recycleNode resets the node and puts it into the arena, if enabled.
+// Otherwise, the operators are put into the global pool, and the variables are left to the garbage collector.
+func (g *Graph) recycleNode(node Node) {
+	switch node := node.(type) {
+	case *operator:
+		if g.arena != nil {
+			g.arena.releaseOperator(node)
+			return
+		}
+		*node = operator{}
+		operatorPool.Put(node)
+	case *variable:
+		if g.arena != nil {
+			node.ZeroGrad()
+			g.arena.releaseVariable(node)
+		}
+	}
+}
+
+// newGradBuffer returns a matrix of the given shape with all the values set to zeros, to accumulate
+// the gradients of a node. It is taken from the arena, if enabled.
+func (g *Graph) newGradBuffer(rows, cols int) mat.Matrix {
+	if g.arena != nil {
+		return g.arena.newBuffer(rows, cols)
+	}
+	return mat.GetEmptyDenseWorkspace(rows, cols)
+}
+
+// releaseGradBuffer releases a matrix obtained with newGradBuffer.
+func (g *Graph) releaseGradBuffer(grad mat.Matrix) {
+	if g.arena != nil {
+		g.arena.releaseBuffer(grad)
+		return
+	}
+	mat.ReleaseMatrix(grad)
+}
+
 // releaseMemory clears the values and the gradients of operator nodes.
 // Since the values and the gradients within the nodes are handled through a pool of dense matrices,
 // releasing them allows the memory to be reused without being reallocated, improving performance.
@@ -179,11 +301,16 @@ func (g *Graph) releaseMemory() {
 }
 
 // releaseValue set the node value to nil release the memory.
+// The value is put into the arena, if enabled, to be reused as a gradient buffer.
 func (g *Graph) releaseValue(node *operator) {
 	if node.value == nil {
 		return
 	}
-	mat.ReleaseMatrix(node.value)
+	if g.arena != nil {
+		g.arena.releaseBuffer(node.value)
+	} else {
+		mat.ReleaseMatrix(node.value)
+	}
 	node.value = nil
 }
 
@@ -201,9 +328,10 @@ func (g *Graph) ZeroGrad() {
 
 // NewVariable creates and returns a new node.
 func (g *Graph) NewVariable(value mat.Matrix, requiresGrad bool) Node {
+	newNode := g.newVariableNode()
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	newNode := &variable{
+	*newNode = variable{
 		graph:        g,
 		timeStep:     g.curTimeStep,
 		id:           g.newID(),
@@ -217,6 +345,22 @@ func (g *Graph) NewVariable(value mat.Matrix, requiresGrad bool) Node {
 	return newNode
 }
 
+// newVariableNode returns an empty variable, taken from the arena if enabled.
+func (g *Graph) newVariableNode() *variable {
+	if g.arena != nil {
+		return g.arena.newVariable()
+	}
+	return new(variable)
+}
+
+// newOperatorNode returns an empty operator, taken from the arena if enabled.
+func (g *Graph) newOperatorNode() *operator {
+	if g.arena != nil {
+		return g.arena.newOperator()
+	}
+	return operatorPool.Get().(*operator)
+}
+
 // NewScalar creates a variable node that doesn't require gradients.
 // TODO: Why shouldn't gradient be required by default?
 func (g *Graph) NewScalar(value mat.Float) Node {
@@ -249,7 +393,7 @@ func (g *Graph) NewOperator(f fn.Function, operands ...Node) Node {
 	var value mat.Matrix = nil
 	if g.incrementalForward {
 		// the calculation is out of the lock so it can run concurrently with other operators
-		g.processingQueue.Run(func() {
+		g.run(func() {
 			value = f.Forward()
 		})
 	}
@@ -261,7 +405,7 @@ func (g *Graph) NewOperator(f fn.Function, operands ...Node) Node {
 		}
 	}
 
-	newNode := operatorPool.Get().(*operator)
+	newNode := g.newOperatorNode()
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -341,8 +485,17 @@ func Range(fromTimeStep, toTimeStep int) ForwardOption {
 // If you do, all values will be recalculated. You can also choose through the Range option to recalculate only a portion of nodes.
 // Instead, it is required to obtain the value of the nodes in case the Graph has been created with IncrementalForward(false).
 func (g *Graph) Forward(opts ...ForwardOption) {
+	_ = g.ForwardContext(context.Background(), opts...)
+}
+
+// ForwardContext is like Forward, but it stops computing the nodes as soon as the context is done,
+// e.g. because the client of a request went away, and returns the context error.
+// In that case the values of the nodes that have not been computed are nil, and the Graph
+// should only be cleared.
+func (g *Graph) ForwardContext(ctx context.Context, opts ...ForwardOption) error {
 	handler := &forwardHandler{
 		g:            g,
+		ctx:          ctx,
 		fromTimeStep: 0,
 		toTimeStep:   -1, // unlimited
 	}
@@ -360,10 +513,9 @@ func (g *Graph) Forward(opts ...ForwardOption) {
 	}
 
 	if g.processingQueue.Size() > 1 {
-		handler.runConcurrent()
-	} else {
-		handler.runSerial()
+		return handler.runConcurrent()
 	}
+	return handler.runSerial()
 }
 
 // BackwardOption allows to adapt the Backward() to your specific needs.
@@ -385,6 +537,17 @@ func OutputGrad(grad mat.Matrix) BackwardOption {
 	}
 }
 
+// EarlyRelease is an option that releases the value of each node as soon as its gradients have been
+// propagated to its operands, instead of keeping it until the graph is cleared. It reduces the peak memory
+// of the back-propagation, e.g. when training deep stacks of layers.
+// The value of the node from which the back-propagation starts is kept, while the values of the other
+// nodes involved are nil afterwards: they can be computed again with Forward.
+func EarlyRelease() BackwardOption {
+	return func(f *backwardHandler) {
+		f.earlyRelease = true
+	}
+}
+
 // Backward performs the back-propagation.
 // It visits each node in reverse topological order, to propagate the gradients from the given node all the way
 // back to the leaf. Note that the gradients are summed to the existing ones. Unless that's what you want, make sure
@@ -479,6 +642,20 @@ func (g *Graph) TimeStep() int {
 	return int(g.curTimeStep)
 }
 
+// MaxID returns the ID of the last node created in the graph, or -1 if the graph has no nodes.
+func (g *Graph) MaxID() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.maxID
+}
+
+// Rand returns the generator of random numbers of the Graph.
+// Models and workers can use it (or a generator spawned from it) instead of the global random,
+// so that their results are reproducible from the seed of the Graph.
+func (g *Graph) Rand() *rand.LockedRand {
+	return g.randGen
+}
+
 // ConcurrentComputations returns the maximum number of concurrent computations handled by the Graph
 // for heavy tasks such as forward and backward steps.
 func (g *Graph) ConcurrentComputations() int {
@@ -5,6 +5,7 @@
 package ag
 
 import (
+	"context"
 	"fmt"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/mat32/rand"
@@ -81,6 +82,27 @@ func TestConcurrentComputations(t *testing.T) {
 	})
 }
 
+func TestGraph_Rand(t *testing.T) {
+	r := rand.NewLockedRand(42)
+	g := NewGraph(Rand(r))
+	assert.Same(t, r, g.Rand())
+}
+
+func TestGraph_DropoutReproducible(t *testing.T) {
+	run := func(forwardOrder []int) []mat.Float {
+		g := NewGraph(RandSeed(7), IncrementalForward(false))
+		x := g.NewVariable(mat.NewInitVecDense(100, 1.0), false)
+		ys := []Node{g.Dropout(x, 0.5), g.Dropout(x, 0.5)}
+		for _, i := range forwardOrder {
+			ys[i].(*operator).value = ys[i].(*operator).function.Forward()
+		}
+		return append(ys[0].Value().Data(), ys[1].Value().Data()...)
+	}
+	expected := run([]int{0, 1})
+	assert.Equal(t, expected, run([]int{1, 0}))
+	assert.NotEqual(t, expected[:100], expected[100:])
+}
+
 func TestGraph_NewVariable(t *testing.T) {
 	t.Run("with requiresGrad true", func(t *testing.T) {
 		g := NewGraph()
@@ -221,6 +243,14 @@ func TestGraph_Clear(t *testing.T) {
 		assert.Nil(t, op.Grad())
 	})
 
+	t.Run("it resets the constants", func(t *testing.T) {
+		g := NewGraph()
+		g.Constant(42)
+		g.Clear()
+		assert.Empty(t, g.constants)
+		assert.Equal(t, 0, g.Constant(42).ID())
+	})
+
 	t.Run("it works on a graph without nodes", func(t *testing.T) {
 		g := NewGraph()
 		g.Clear()
@@ -254,6 +284,26 @@ func TestGraph_ClearForReuse(t *testing.T) {
 	})
 }
 
+func TestGraph_Truncate(t *testing.T) {
+	g := NewGraph()
+	a := g.NewVariable(mat.NewScalar(1.0), true)
+	c := g.Constant(2.0)
+	mark := g.MaxID()
+	g.IncTimeStep()
+	b := g.Add(a, c)
+	g.Constant(3.0)
+	assert.Equal(t, mat.Float(3.0), b.ScalarValue())
+
+	g.Truncate(mark)
+	assert.Equal(t, mark, g.MaxID())
+	assert.Equal(t, 0, g.TimeStep())
+	assert.Same(t, c, g.Constant(2.0))
+	assert.Equal(t, mark+1, g.Constant(3.0).ID())
+
+	g.Truncate(-1)
+	assert.Equal(t, -1, g.MaxID())
+}
+
 func TestGraph_ZeroGrad(t *testing.T) {
 	g := NewGraph()
 	v1 := g.NewVariable(mat.NewScalar(1), true)
@@ -321,3 +371,74 @@ func TestGraph_Forward(t *testing.T) {
 	assert.NotNil(t, op.Value())
 	assert.Equal(t, mat.Float(42.0), op.Value().Scalar())
 }
+
+func TestGraph_ForwardContext(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			g := NewGraph(IncrementalForward(false), ConcurrentComputations(concurrency))
+			op := g.Mul(g.Add(g.NewScalar(40), g.NewScalar(2)), g.NewScalar(2))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			assert.Equal(t, context.Canceled, g.ForwardContext(ctx))
+			assert.Nil(t, op.Value())
+
+			assert.NoError(t, g.ForwardContext(context.Background()))
+			assert.Equal(t, mat.Float(84.0), op.Value().Scalar())
+		})
+	}
+}
+
+func TestGraph_BackwardEarlyRelease(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		build := func() (g *Graph, x, h, y, loss Node) {
+			g = NewGraph(ConcurrentComputations(concurrency))
+			x = g.NewVariable(mat.NewVecDense([]mat.Float{0.5, -1.0}), true)
+			w := g.NewVariable(mat.NewDense(2, 2, []mat.Float{0.1, 0.2, 0.3, 0.4}), true)
+			h = g.Tanh(g.Mul(w, x))
+			y = g.Sigmoid(g.Mul(w, h))
+			loss = g.ReduceSum(g.Square(y))
+			return
+		}
+
+		g1, x1, _, _, loss1 := build()
+		g1.Backward(loss1)
+
+		g2, x2, h2, y2, loss2 := build()
+		g2.Backward(loss2, EarlyRelease())
+
+		assert.InDeltaSlice(t, x1.Grad().Data(), x2.Grad().Data(), 1.0e-6)
+		assert.Equal(t, loss1.ScalarValue(), loss2.ScalarValue())
+		assert.NotNil(t, x2.Value())
+		assert.Nil(t, h2.Value())
+		assert.Nil(t, y2.Value())
+
+		g2.Forward()
+		assert.NotNil(t, y2.Value())
+	}
+}
+
+func TestGraph_DetachHistory(t *testing.T) {
+	g := NewGraph()
+	w := g.NewVariable(mat.NewScalar(2.0), true)
+	mark := g.MaxID()
+
+	h := g.NewScalar(1.0)
+	for i := 0; i < 3; i++ {
+		g.IncTimeStep()
+		h = g.Prod(w, h)
+	}
+	assert.Equal(t, mat.Float(8.0), h.ScalarValue())
+
+	detached := g.DetachHistory(mark, h, nil)
+	assert.Len(t, detached, 2)
+	assert.Nil(t, detached[1])
+	assert.Equal(t, mark+1, g.MaxID())
+	assert.Equal(t, 3, g.TimeStep())
+	assert.Equal(t, mat.Float(8.0), detached[0].ScalarValue())
+	assert.False(t, detached[0].RequiresGrad())
+
+	y := g.Prod(w, detached[0])
+	g.Backward(y)
+	assert.Equal(t, mat.Float(8.0), w.Grad().Scalar())
+}
@@ -5,17 +5,19 @@
 package ag
 
 import (
+	"context"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"sync"
 )
 
 type forwardHandler struct {
 	g            *Graph
+	ctx          context.Context
 	fromTimeStep int // default 0
 	toTimeStep   int // default -1 (no limit)
 }
 
-func (h *forwardHandler) runSerial() {
+func (h *forwardHandler) runSerial() error {
 	for _, node := range h.g.nodes {
 		if op, ok := node.(*operator); ok {
 			if op.timeStep < h.fromTimeStep {
@@ -24,37 +26,49 @@ func (h *forwardHandler) runSerial() {
 			if h.toTimeStep != -1 && op.timeStep > h.toTimeStep {
 				continue
 			}
+			if err := h.ctx.Err(); err != nil {
+				return err
+			}
 			op.value = op.function.Forward()
 		}
 	}
+	return nil
 }
 
-func (h *forwardHandler) runConcurrent() {
+func (h *forwardHandler) runConcurrent() error {
 	fromTS, toTS := h.fromTimeStep, h.toTimeStep
 	groups := h.g.groupNodesByHeight()
 
 	var wg sync.WaitGroup
 	for _, group := range groups {
+		if err := h.ctx.Err(); err != nil {
+			return err
+		}
 		for _, node := range group {
 			op, isOperator := node.(*operator)
 			if !isOperator || (op.timeStep < fromTS || (toTS != -1 && op.timeStep > toTS)) {
 				continue
 			}
 			wg.Add(1)
-			h.g.processingQueue.Go(func() {
+			go h.g.run(func() {
 				defer wg.Done()
+				if h.ctx.Err() != nil {
+					return // the operators still waiting for a thread are skipped
+				}
 				op.value = op.function.Forward()
 			})
 		}
 		wg.Wait()
 	}
+	return h.ctx.Err()
 }
 
 type backwardHandler struct {
 	g              *Graph
 	node           Node
 	outputGrad     mat.Matrix
-	stopAtTimeStep int // default -1 (full backward)
+	stopAtTimeStep int  // default -1 (full backward)
+	earlyRelease   bool // default false
 }
 
 func (h *backwardHandler) propagateOutputGrad() {
@@ -78,6 +92,7 @@ func (h *backwardHandler) runSerial() {
 		}
 		if node, ok := nodes[i].(*operator); ok {
 			node.backward()
+			h.releaseValue(node)
 		}
 	}
 }
@@ -102,11 +117,27 @@ func (h *backwardHandler) runConcurrent() {
 				continue
 			}
 			wg.Add(1)
-			h.g.processingQueue.Go(func() {
+			go h.g.run(func() {
 				defer wg.Done()
 				op.backward()
 			})
 		}
 		wg.Wait()
+		for _, node := range groups[i] {
+			if op, isOperator := node.(*operator); isOperator && op.id <= lastNodeIndex {
+				h.releaseValue(op)
+			}
+		}
+	}
+}
+
+// releaseValue releases the value of an operator whose gradients have been propagated, if the early
+// release is enabled. The operators that come before in the back-propagation order (i.e. the ones
+// using this node as operand) have already been visited, so the value is no longer needed.
+// The value of the node from which the back-propagation starts is always kept.
+func (h *backwardHandler) releaseValue(op *operator) {
+	if !h.earlyRelease || !op.hasGrad || op == h.node {
+		return
 	}
+	h.g.releaseValue(op)
 }
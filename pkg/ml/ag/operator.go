@@ -70,7 +70,7 @@ func (r *operator) PropagateGrad(grad mat.Matrix) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if r.grad == nil {
-		r.grad = mat.GetEmptyDenseWorkspace(r.value.Dims()) // this could reduce the number of allocations
+		r.grad = r.graph.newGradBuffer(r.value.Dims()) // this could reduce the number of allocations
 	}
 	r.grad.AddInPlace(grad)
 	r.hasGrad = true
@@ -91,7 +91,7 @@ func (r *operator) ZeroGrad() {
 	if r.grad == nil {
 		return
 	}
-	defer mat.ReleaseMatrix(r.grad) // release memory
+	defer r.graph.releaseGradBuffer(r.grad) // release memory
 	r.grad = nil
 	r.hasGrad = false
 }
@@ -147,74 +147,119 @@ const (
 	OpConcat
 	// OpStack identifies the Graph.Stack operator.
 	OpStack
+	// OpFlashAttention identifies the Graph.FlashAttention operator.
+	OpFlashAttention
+	// OpConv2D identifies the Graph.Conv2D operator.
+	OpConv2D
+	// OpMaxPool2D identifies the Graph.MaxPool2D operator.
+	OpMaxPool2D
+	// OpAvgPool2D identifies the Graph.AvgPool2D operator.
+	OpAvgPool2D
+	// OpLogSumExp identifies the Graph.LogSumExp operator.
+	OpLogSumExp
+	// OpGather identifies the Graph.Gather operator.
+	OpGather
+	// OpScatter identifies the Graph.Scatter operator.
+	OpScatter
+	// OpIndexSelect identifies the Graph.IndexSelect operator.
+	OpIndexSelect
+	// OpMaskedFill identifies the Graph.MaskedFill operator.
+	OpMaskedFill
+	// OpWhere identifies the Graph.Where operator.
+	OpWhere
+	// OpClamp identifies the Graph.Clamp operator.
+	OpClamp
+	// OpCumSum identifies the Graph.CumSum operator.
+	OpCumSum
+	// OpCumProd identifies the Graph.CumProd operator.
+	OpCumProd
+	// OpLayerNorm identifies the Graph.LayerNorm operator.
+	OpLayerNorm
+	// OpScaledSoftmax identifies the Graph.ScaledSoftmax operator.
+	OpScaledSoftmax
 )
 
 var opNameToMethodName = map[OpName]string{
-	OpIdentity:      "Identity",
-	OpDropout:       "Dropout",
-	OpAtVec:         "AtVec",
-	OpAt:            "At",
-	OpAdd:           "Add",
-	OpSub:           "Sub",
-	OpSubScalar:     "SubScalar",
-	OpAddScalar:     "AddScalar",
-	OpReverseSub:    "ReverseSub",
-	OpProd:          "Prod",
-	OpDiv:           "Div",
-	OpProdScalar:    "ProdScalar",
-	OpDivScalar:     "DivScalar",
-	OpMul:           "Mul",
-	OpDot:           "Dot",
-	OpReshape:       "Reshape",
-	OpMaxPooling:    "MaxPooling",
-	OpView:          "View",
-	OpRowView:       "RowView",
-	OpColView:       "ColView",
-	OpVec:           "Vec",
-	OpRotateR:       "RotateR",
-	OpT:             "T",
-	OpSquare:        "Square",
-	OpPow:           "Pow",
-	OpSqrt:          "Sqrt",
-	OpTan:           "Tan",
-	OpTanh:          "Tanh",
-	OpSigmoid:       "Sigmoid",
-	OpHardSigmoid:   "HardSigmoid",
-	OpHardTanh:      "HardTanh",
-	OpSoftsign:      "Softsign",
-	OpReLU:          "ReLU",
-	OpCELU:          "CELU",
-	OpGELU:          "GELU",
-	OpELU:           "ELU",
-	OpPositiveELU:   "PositiveELU",
-	OpSwishB:        "SwishB",
-	OpSwish:         "Swish",
-	OpSiLU:          "SiLU",
-	OpMish:          "Mish",
-	OpLeakyReLU:     "LeakyReLU",
-	OpSELU:          "SELU",
-	OpSoftPlus:      "SoftPlus",
-	OpSoftShrink:    "SoftShrink",
-	OpThreshold:     "Threshold",
-	OpSoftmax:       "Softmax",
-	OpLogSoftmax:    "LogSoftmax",
-	OpSparseMax:     "SparseMax",
-	OpSparseMaxLoss: "SparseMaxLoss",
-	OpSin:           "Sin",
-	OpCos:           "Cos",
-	OpExp:           "Exp",
-	OpLog:           "Log",
-	OpAbs:           "Abs",
-	OpNeg:           "Neg",
-	OpReciprocal:    "Reciprocal",
-	OpMax:           "Max",
-	OpMin:           "Min",
-	OpReduceSum:     "ReduceSum",
-	OpReduceMean:    "ReduceMean",
-	OpMean:          "Mean",
-	OpSum:           "Sum",
-	OpConcat:        "Concat",
-	OpStack:         "Stack",
+	OpIdentity:       "Identity",
+	OpDropout:        "Dropout",
+	OpAtVec:          "AtVec",
+	OpAt:             "At",
+	OpAdd:            "Add",
+	OpSub:            "Sub",
+	OpSubScalar:      "SubScalar",
+	OpAddScalar:      "AddScalar",
+	OpReverseSub:     "ReverseSub",
+	OpProd:           "Prod",
+	OpDiv:            "Div",
+	OpProdScalar:     "ProdScalar",
+	OpDivScalar:      "DivScalar",
+	OpMul:            "Mul",
+	OpDot:            "Dot",
+	OpReshape:        "Reshape",
+	OpMaxPooling:     "MaxPooling",
+	OpView:           "View",
+	OpRowView:        "RowView",
+	OpColView:        "ColView",
+	OpVec:            "Vec",
+	OpRotateR:        "RotateR",
+	OpT:              "T",
+	OpSquare:         "Square",
+	OpPow:            "Pow",
+	OpSqrt:           "Sqrt",
+	OpTan:            "Tan",
+	OpTanh:           "Tanh",
+	OpSigmoid:        "Sigmoid",
+	OpHardSigmoid:    "HardSigmoid",
+	OpHardTanh:       "HardTanh",
+	OpSoftsign:       "Softsign",
+	OpReLU:           "ReLU",
+	OpCELU:           "CELU",
+	OpGELU:           "GELU",
+	OpELU:            "ELU",
+	OpPositiveELU:    "PositiveELU",
+	OpSwishB:         "SwishB",
+	OpSwish:          "Swish",
+	OpSiLU:           "SiLU",
+	OpMish:           "Mish",
+	OpLeakyReLU:      "LeakyReLU",
+	OpSELU:           "SELU",
+	OpSoftPlus:       "SoftPlus",
+	OpSoftShrink:     "SoftShrink",
+	OpThreshold:      "Threshold",
+	OpSoftmax:        "Softmax",
+	OpLogSoftmax:     "LogSoftmax",
+	OpSparseMax:      "SparseMax",
+	OpSparseMaxLoss:  "SparseMaxLoss",
+	OpSin:            "Sin",
+	OpCos:            "Cos",
+	OpExp:            "Exp",
+	OpLog:            "Log",
+	OpAbs:            "Abs",
+	OpNeg:            "Neg",
+	OpReciprocal:     "Reciprocal",
+	OpMax:            "Max",
+	OpMin:            "Min",
+	OpReduceSum:      "ReduceSum",
+	OpReduceMean:     "ReduceMean",
+	OpMean:           "Mean",
+	OpSum:            "Sum",
+	OpConcat:         "Concat",
+	OpStack:          "Stack",
+	OpFlashAttention: "FlashAttention",
+	OpConv2D:         "Conv2D",
+	OpMaxPool2D:      "MaxPool2D",
+	OpAvgPool2D:      "AvgPool2D",
+	OpLogSumExp:      "LogSumExp",
+	OpGather:         "Gather",
+	OpScatter:        "Scatter",
+	OpIndexSelect:    "IndexSelect",
+	OpMaskedFill:     "MaskedFill",
+	OpWhere:          "Where",
+	OpClamp:          "Clamp",
+	OpCumSum:         "CumSum",
+	OpCumProd:        "CumProd",
+	OpLayerNorm:      "LayerNorm",
+	OpScaledSoftmax:  "ScaledSoftmax",
 }
 
 // strToOpName is the inverse map of opNameToMethodName.
@@ -253,8 +298,10 @@ func (g *Graph) Identity(x Node) Node {
 }
 
 // Dropout returns a new operator node as a result of the fn.Dropout function.
+// The mask is drawn from a generator spawned from the one of the graph when the operator is created,
+// so that it doesn't depend on the order in which the operators are computed.
 func (g *Graph) Dropout(x Node, p mat.Float) Node {
-	return g.NewOperator(fn.NewDropout(x, p, g.randGen), x)
+	return g.NewOperator(fn.NewDropout(x, p, g.randGen.Spawn()), x)
 }
 
 // AtVec returns a new operator node as a result of the fn.AtVec function.
@@ -357,6 +404,21 @@ func (g *Graph) RowView(x Node, row int) Node {
 	return g.NewOperator(fn.NewRowView(x, row), x)
 }
 
+// Gather returns a new operator node as a result of the fn.Gather function.
+func (g *Graph) Gather(x Node, indices []int) Node {
+	return g.NewOperator(fn.NewGather(x, indices), x)
+}
+
+// Scatter returns a new operator node as a result of the fn.Scatter function.
+func (g *Graph) Scatter(x Node, indices []int, size int) Node {
+	return g.NewOperator(fn.NewScatter(x, indices, size), x)
+}
+
+// IndexSelect returns a new operator node as a result of the fn.IndexSelect function.
+func (g *Graph) IndexSelect(x Node, indices []int) Node {
+	return g.NewOperator(fn.NewIndexSelect(x, indices), x)
+}
+
 // RotateR performs the right circular shift.
 // `i` is the number of places by which the elements are shifted.
 func (g *Graph) RotateR(x Node, i int) Node {
@@ -388,6 +450,21 @@ func (g *Graph) Pow(x Node, power mat.Float) Node {
 	return g.NewOperator(fn.NewPow(x, power), x)
 }
 
+// Clamp returns a new operator node as a result of the fn.Clamp function.
+func (g *Graph) Clamp(x Node, min, max mat.Float) Node {
+	return g.NewOperator(fn.NewClamp(x, min, max), x)
+}
+
+// MaskedFill returns a new operator node as a result of the fn.MaskedFill function.
+func (g *Graph) MaskedFill(x Node, mask mat.Matrix, value mat.Float) Node {
+	return g.NewOperator(fn.NewMaskedFill(x, mask, value), x)
+}
+
+// Where returns a new operator node as a result of the fn.Where function.
+func (g *Graph) Where(condition mat.Matrix, x1 Node, x2 Node) Node {
+	return g.NewOperator(fn.NewWhere(condition, x1, x2), x1, x2)
+}
+
 // Sqrt returns a new operator node as a result of the `Sqrt` function.
 func (g *Graph) Sqrt(x Node) Node {
 	return g.NewOperator(fn.NewSqrt(x), x)
@@ -493,6 +570,27 @@ func (g *Graph) Softmax(x Node) Node {
 	return g.NewOperator(fn.NewSoftmax(x), x)
 }
 
+// ScaledSoftmax returns a new operator node as a result of the fn.ScaledSoftmax function.
+// The mask is added to the scaled input; it can be nil.
+func (g *Graph) ScaledSoftmax(x Node, scale mat.Float, mask mat.Matrix) Node {
+	return g.NewOperator(fn.NewScaledSoftmax(x, scale, mask), x)
+}
+
+// LogSoftmax returns a new operator node as a result of the fn.LogSoftmax function.
+func (g *Graph) LogSoftmax(x Node) Node {
+	return g.NewOperator(fn.NewLogSoftmax(x), x)
+}
+
+// LogSumExp returns a new operator node as a result of the fn.LogSumExp function.
+func (g *Graph) LogSumExp(x Node) Node {
+	return g.NewOperator(fn.NewLogSumExp(x), x)
+}
+
+// LayerNorm returns a new operator node as a result of the fn.LayerNorm function.
+func (g *Graph) LayerNorm(x, w, b Node, eps mat.Float) Node {
+	return g.NewOperator(fn.NewLayerNorm(x, w, b, eps), x, w, b)
+}
+
 // SparseMax returns a new operator node as a result of the fn.SparseMax function.
 func (g *Graph) SparseMax(x Node) Node {
 	return g.NewOperator(fn.NewSparseMax(x), x)
@@ -548,6 +646,18 @@ func (g *Graph) ReduceMean(x Node) Node {
 	return g.NewOperator(fn.NewReduceMean(x), x)
 }
 
+// CumSum returns a new operator node as a result of the fn.CumSum function.
+// The axis is 0 to accumulate the elements of each column (e.g. of a vector), 1 of each row.
+func (g *Graph) CumSum(x Node, axis int) Node {
+	return g.NewOperator(fn.NewCumSum(x, axis), x)
+}
+
+// CumProd returns a new operator node as a result of the fn.CumProd function.
+// The axis is 0 to accumulate the elements of each column (e.g. of a vector), 1 of each row.
+func (g *Graph) CumProd(x Node, axis int) Node {
+	return g.NewOperator(fn.NewCumProd(x, axis), x)
+}
+
 // Concat returns a new operator node as a result of the fn.Concat function.
 func (g *Graph) Concat(xs ...Node) Node {
 	return g.NewOperator(fn.NewConcat(Operands(xs)), xs...)
@@ -557,3 +667,31 @@ func (g *Graph) Concat(xs ...Node) Node {
 func (g *Graph) Stack(xs ...Node) Node {
 	return g.NewOperator(fn.NewStack(Operands(xs)), xs...)
 }
+
+// FlashAttention returns a new operator node as a result of the fn.FlashAttention function.
+// The queries, keys and values are matrices with one element for each row.
+func (g *Graph) FlashAttention(q, k, v Node, scale mat.Float, causal bool, blockSize int) Node {
+	return g.NewOperator(fn.NewFlashAttention(q, k, v, scale, causal, blockSize), q, k, v)
+}
+
+// Conv2D returns a new operator node as a result of the fn.Conv2D function.
+// The xs are the input channels; the bias b can be nil.
+func (g *Graph) Conv2D(w, b Node, xs []Node, config fn.Conv2DConfig) Node {
+	operands := append([]Node{w}, xs...)
+	var bias fn.Operand
+	if b != nil {
+		bias = b
+		operands = append(operands, b)
+	}
+	return g.NewOperator(fn.NewConv2D(w, bias, Operands(xs), config), operands...)
+}
+
+// MaxPool2D returns a new operator node as a result of the fn.MaxPool2D function.
+func (g *Graph) MaxPool2D(x Node, config fn.Pool2DConfig) Node {
+	return g.NewOperator(fn.NewMaxPool2D(x, config), x)
+}
+
+// AvgPool2D returns a new operator node as a result of the fn.AvgPool2D function.
+func (g *Graph) AvgPool2D(x Node, config fn.Pool2DConfig) Node {
+	return g.NewOperator(fn.NewAvgPool2D(x, config), x)
+}
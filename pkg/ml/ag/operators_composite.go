@@ -4,16 +4,35 @@
 
 package ag
 
-import mat "github.com/nlpodyssey/spago/pkg/mat32"
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+)
 
 // PositiveELU returns a new operator node as a result of ELU(x) + 1.
 func (g *Graph) PositiveELU(x Node) Node {
 	return g.AddScalar(g.ELU(x, g.Constant(1.0)), g.Constant(1.0))
 }
 
-// LogSoftmax returns a new operator node as a result of Log(Softmax(x)).
-func (g *Graph) LogSoftmax(x Node) Node {
-	return g.Log(g.Softmax(x))
+// Sort returns a new operator node with the elements of x sorted in ascending order,
+// or in descending order if descending is true, along with their original indices.
+// The gradients flow back to the original positions of the elements.
+// The value of x must be already computed (i.e. the graph uses the incremental forward).
+func (g *Graph) Sort(x Node, descending bool) (Node, []int) {
+	indices := floatutils.ArgSort(x.Value().Data(), descending)
+	return g.Gather(x, indices), indices
+}
+
+// TopK returns a new operator node with the k largest elements of x in descending order,
+// along with their original indices. If k is greater than the size of x, all the elements are returned.
+// The gradients flow back to the original positions of the elements.
+// The value of x must be already computed (i.e. the graph uses the incremental forward).
+func (g *Graph) TopK(x Node, k int) (Node, []int) {
+	indices := floatutils.ArgSort(x.Value().Data(), true)
+	if k < len(indices) {
+		indices = indices[:k]
+	}
+	return g.Gather(x, indices), indices
 }
 
 // Sum returns the value that describes the sum of the sample.
@@ -0,0 +1,40 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ag
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGraph_TopK(t *testing.T) {
+	g := NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{0.3, 0.9, -0.1, 0.5}), true)
+	y, indices := g.TopK(x, 2)
+
+	assert.Equal(t, []int{1, 3}, indices)
+	assert.InDeltaSlice(t, []mat.Float{0.9, 0.5}, y.Value().Data(), 1.0e-6)
+
+	g.Backward(y, OutputGrad(mat.NewVecDense([]mat.Float{1.0, 2.0})))
+
+	assert.InDeltaSlice(t, []mat.Float{0.0, 1.0, 0.0, 2.0}, x.Grad().Data(), 1.0e-6)
+
+	_, all := g.TopK(x, 10)
+	assert.Equal(t, []int{1, 3, 0, 2}, all)
+}
+
+func TestGraph_Sort(t *testing.T) {
+	g := NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{0.3, 0.9, -0.1, 0.3}), true)
+
+	asc, ascIndices := g.Sort(x, false)
+	assert.Equal(t, []int{2, 0, 3, 1}, ascIndices)
+	assert.InDeltaSlice(t, []mat.Float{-0.1, 0.3, 0.3, 0.9}, asc.Value().Data(), 1.0e-6)
+
+	desc, descIndices := g.Sort(x, true)
+	assert.Equal(t, []int{1, 0, 3, 2}, descIndices)
+	assert.InDeltaSlice(t, []mat.Float{0.9, 0.3, 0.3, -0.1}, desc.Value().Data(), 1.0e-6)
+}
@@ -0,0 +1,212 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package calibration
+
+import (
+	"encoding/json"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultFilename is the name of the file of the calibrator, stored in the directory of the model.
+const DefaultFilename = "calibration.json"
+
+const (
+	// TemperatureMethod is the name of the temperature scaling method.
+	TemperatureMethod = "temperature"
+	// IsotonicMethod is the name of the isotonic regression method.
+	IsotonicMethod = "isotonic"
+)
+
+// Calibrator turns the logits of a prediction into calibrated probabilities.
+type Calibrator interface {
+	// Calibrate returns the calibrated probabilities of the logits.
+	Calibrate(logits []mat.Float) []mat.Float
+}
+
+var (
+	_ Calibrator = &TemperatureScaling{}
+	_ Calibrator = &Isotonic{}
+)
+
+// Fit returns the calibrator of the given method (TemperatureMethod or IsotonicMethod) which
+// best fits the targets on held-out data (see FitTemperature and FitIsotonic).
+func Fit(method string, logits [][]mat.Float, targets []int) (Calibrator, error) {
+	switch method {
+	case TemperatureMethod:
+		return &TemperatureScaling{Temperature: FitTemperature(logits, targets)}, nil
+	case IsotonicMethod:
+		return FitIsotonic(logits, targets), nil
+	default:
+		return nil, fmt.Errorf("calibration: unknown method %q", method)
+	}
+}
+
+// TemperatureScaling is a Calibrator dividing the logits by the temperature before the softmax.
+type TemperatureScaling struct {
+	Temperature mat.Float `json:"temperature"`
+}
+
+// Calibrate returns the softmax of the logits divided by the temperature.
+func (c *TemperatureScaling) Calibrate(logits []mat.Float) []mat.Float {
+	return SoftMaxWithTemperature(logits, c.Temperature)
+}
+
+// Isotonic is a Calibrator mapping the softmax probabilities with a non-decreasing function learned
+// by isotonic regression, then normalizing them. The function is piecewise linear, through the
+// points (X[i], Y[i]), and constant outside them.
+type Isotonic struct {
+	X []mat.Float `json:"x"`
+	Y []mat.Float `json:"y"`
+}
+
+// FitIsotonic returns the Isotonic calibrator which best fits the targets on held-out data.
+// Each example has its own logits (the number of classes may vary), and the target is the index
+// of the correct one. The regression is fit on the pooled probabilities of all the classes
+// (one-vs-rest), so that it is shared by the classes.
+func FitIsotonic(logits [][]mat.Float, targets []int) *Isotonic {
+	if len(logits) != len(targets) {
+		panic("calibration: the logits and the targets must have the same length")
+	}
+	type point struct{ x, y mat.Float }
+	var points []point
+	for i, xs := range logits {
+		for k, p := range floatutils.SoftMax(xs) {
+			y := mat.Float(0)
+			if k == targets[i] {
+				y = 1
+			}
+			points = append(points, point{x: p, y: y})
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].x < points[j].x })
+
+	// pool adjacent violators: the blocks have non-decreasing means; the points with the same
+	// probability are pooled in advance, so that their order does not matter
+	type block struct{ sumX, sumY, n mat.Float }
+	var blocks []block
+	for i, p := range points {
+		if i > 0 && p.x == points[i-1].x {
+			last := &blocks[len(blocks)-1]
+			last.sumX, last.sumY, last.n = last.sumX+p.x, last.sumY+p.y, last.n+1
+		} else {
+			blocks = append(blocks, block{sumX: p.x, sumY: p.y, n: 1})
+		}
+		for len(blocks) > 1 {
+			last, prev := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if prev.sumY/prev.n < last.sumY/last.n {
+				break
+			}
+			blocks = append(blocks[:len(blocks)-2], block{sumX: prev.sumX + last.sumX, sumY: prev.sumY + last.sumY, n: prev.n + last.n})
+		}
+	}
+	c := &Isotonic{X: make([]mat.Float, len(blocks)), Y: make([]mat.Float, len(blocks))}
+	for i, b := range blocks {
+		c.X[i], c.Y[i] = b.sumX/b.n, b.sumY/b.n
+	}
+	return c
+}
+
+// Calibrate returns the mapped softmax probabilities of the logits, normalized. If all of them
+// are mapped to zero, the plain softmax is returned.
+func (c *Isotonic) Calibrate(logits []mat.Float) []mat.Float {
+	probs := floatutils.SoftMax(logits)
+	if len(c.X) == 0 {
+		return probs
+	}
+	calibrated := make([]mat.Float, len(probs))
+	var sum mat.Float
+	for i, p := range probs {
+		calibrated[i] = c.predict(p)
+		sum += calibrated[i]
+	}
+	if sum == 0 {
+		return probs
+	}
+	for i := range calibrated {
+		calibrated[i] /= sum
+	}
+	return calibrated
+}
+
+// predict returns the value of the isotonic function at x.
+func (c *Isotonic) predict(x mat.Float) mat.Float {
+	i := sort.Search(len(c.X), func(i int) bool { return c.X[i] >= x })
+	switch {
+	case i == 0:
+		return c.Y[0]
+	case i == len(c.X):
+		return c.Y[len(c.Y)-1]
+	}
+	t := (x - c.X[i-1]) / (c.X[i] - c.X[i-1])
+	return c.Y[i-1] + t*(c.Y[i]-c.Y[i-1])
+}
+
+// file is the JSON format of a saved calibrator.
+type file struct {
+	Method      string      `json:"method"`
+	Temperature *mat.Float  `json:"temperature,omitempty"`
+	X           []mat.Float `json:"x,omitempty"`
+	Y           []mat.Float `json:"y,omitempty"`
+}
+
+// Save writes the calibrator to a JSON file.
+func Save(filename string, c Calibrator) error {
+	var f file
+	switch c := c.(type) {
+	case *TemperatureScaling:
+		f = file{Method: TemperatureMethod, Temperature: &c.Temperature}
+	case *Isotonic:
+		f = file{Method: IsotonicMethod, X: c.X, Y: c.Y}
+	default:
+		return fmt.Errorf("calibration: cannot save a calibrator of type %T", c)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// Load reads a calibrator from a JSON file written by Save.
+func Load(filename string) (Calibrator, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	switch f.Method {
+	case TemperatureMethod:
+		if f.Temperature == nil {
+			return nil, fmt.Errorf("calibration: %s: missing temperature", filename)
+		}
+		return &TemperatureScaling{Temperature: *f.Temperature}, nil
+	case IsotonicMethod:
+		if len(f.X) != len(f.Y) {
+			return nil, fmt.Errorf("calibration: %s: x and y must have the same length", filename)
+		}
+		return &Isotonic{X: f.X, Y: f.Y}, nil
+	default:
+		return nil, fmt.Errorf("calibration: %s: unknown method %q", filename, f.Method)
+	}
+}
+
+// LoadFromModelPath reads the calibrator stored with the DefaultFilename in the directory of a
+// model, if present; otherwise, it returns nil without error.
+func LoadFromModelPath(modelPath string) (Calibrator, error) {
+	filename := filepath.Join(modelPath, DefaultFilename)
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return Load(filename)
+}
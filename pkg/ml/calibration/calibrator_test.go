@@ -0,0 +1,80 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package calibration
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFitIsotonic(t *testing.T) {
+	// The first class has softmax probability 0.982 with logits [4, 0], but is right 75% of the times.
+	var logits [][]mat.Float
+	var targets []int
+	for i := 0; i < 100; i++ {
+		logits = append(logits, []mat.Float{4, 0})
+		targets = append(targets, map[bool]int{true: 0, false: 1}[i < 75])
+	}
+	c := FitIsotonic(logits, targets)
+	assert.InDeltaSlice(t, []mat.Float{0.75, 0.25}, c.Calibrate([]mat.Float{4, 0}), 1.0e-6)
+
+	// the function is non-decreasing
+	for i := 1; i < len(c.Y); i++ {
+		assert.True(t, c.Y[i] >= c.Y[i-1])
+	}
+	assert.Panics(t, func() { FitIsotonic([][]mat.Float{{1, 2}}, nil) })
+}
+
+func TestIsotonicPredict(t *testing.T) {
+	c := &Isotonic{X: []mat.Float{0.2, 0.6}, Y: []mat.Float{0.1, 0.5}}
+	assert.InDelta(t, 0.1, c.predict(0), 1.0e-6)
+	assert.InDelta(t, 0.3, c.predict(0.4), 1.0e-6)
+	assert.InDelta(t, 0.5, c.predict(1), 1.0e-6)
+
+	empty := &Isotonic{}
+	assert.InDeltaSlice(t, []mat.Float{0.5, 0.5}, empty.Calibrate([]mat.Float{1, 1}), 1.0e-6)
+}
+
+func TestFit(t *testing.T) {
+	logits := [][]mat.Float{{2, 0}, {0, 2}}
+	c, err := Fit(TemperatureMethod, logits, []int{0, 1})
+	assert.NoError(t, err)
+	assert.IsType(t, &TemperatureScaling{}, c)
+	c, err = Fit(IsotonicMethod, logits, []int{0, 1})
+	assert.NoError(t, err)
+	assert.IsType(t, &Isotonic{}, c)
+	_, err = Fit("platt", logits, []int{0, 1})
+	assert.Error(t, err)
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "calibration")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := LoadFromModelPath(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	filename := filepath.Join(dir, DefaultFilename)
+	assert.NoError(t, Save(filename, &TemperatureScaling{Temperature: 1.5}))
+	c, err = LoadFromModelPath(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, &TemperatureScaling{Temperature: 1.5}, c)
+
+	isotonic := &Isotonic{X: []mat.Float{0.1, 0.9}, Y: []mat.Float{0.2, 0.8}}
+	assert.NoError(t, Save(filename, isotonic))
+	c, err = Load(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, isotonic, c)
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(`{"method": "temperature"}`), 0644))
+	_, err = Load(filename)
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package calibration provides methods to calibrate the confidence of the predictions of a model,
+// so that the confidences can be compared with thresholds sensibly.
+package calibration
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"math"
+)
+
+const (
+	// the search range of the logarithm of the inverse temperature
+	minLogInvTemperature = -5.0
+	maxLogInvTemperature = 5.0
+	searchIterations     = 100
+)
+
+// SoftMaxWithTemperature returns the softmax of the logits divided by the temperature.
+// A temperature greater than 1 softens the distribution, a lower one sharpens it.
+// A non-positive temperature is treated as 1.
+func SoftMaxWithTemperature(logits []mat.Float, temperature mat.Float) []mat.Float {
+	if temperature <= 0 || temperature == 1 {
+		return floatutils.SoftMax(logits)
+	}
+	scaled := make([]mat.Float, len(logits))
+	for i, v := range logits {
+		scaled[i] = v / temperature
+	}
+	return floatutils.SoftMax(scaled)
+}
+
+// FitTemperature returns the temperature which minimizes the negative log-likelihood of the
+// targets on held-out data (temperature scaling). Each example has its own logits (the number of
+// classes or candidates may vary), and the target is the index of the correct one.
+// It returns 1 if there are no examples.
+func FitTemperature(logits [][]mat.Float, targets []int) mat.Float {
+	if len(logits) != len(targets) {
+		panic("calibration: the logits and the targets must have the same length")
+	}
+	if len(logits) == 0 {
+		return 1
+	}
+	// The negative log-likelihood is convex in the inverse temperature, so it is unimodal in its
+	// logarithm, where a ternary search finds the minimum.
+	lo, hi := minLogInvTemperature, maxLogInvTemperature
+	for i := 0; i < searchIterations; i++ {
+		m1 := lo + (hi-lo)/3
+		m2 := hi - (hi-lo)/3
+		if nll(logits, targets, math.Exp(m1)) < nll(logits, targets, math.Exp(m2)) {
+			hi = m2
+		} else {
+			lo = m1
+		}
+	}
+	return mat.Float(1 / math.Exp((lo+hi)/2))
+}
+
+// nll returns the negative log-likelihood of the targets with the given inverse temperature.
+func nll(logits [][]mat.Float, targets []int, invTemperature float64) float64 {
+	sum := 0.0
+	for i, xs := range logits {
+		max := math.Inf(-1)
+		for _, x := range xs {
+			max = math.Max(max, float64(x)*invTemperature)
+		}
+		z := 0.0
+		for _, x := range xs {
+			z += math.Exp(float64(x)*invTemperature - max)
+		}
+		sum += max + math.Log(z) - float64(xs[targets[i]])*invTemperature
+	}
+	return sum
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package calibration
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFitTemperature(t *testing.T) {
+	// With logits [4, 0], the first class is right 88% of the times, which is the
+	// probability given by the softmax with temperature 2.
+	var logits [][]mat.Float
+	var targets []int
+	for i := 0; i < 1000; i++ {
+		logits = append(logits, []mat.Float{4, 0})
+		if i < 881 {
+			targets = append(targets, 0)
+		} else {
+			targets = append(targets, 1)
+		}
+	}
+	temperature := FitTemperature(logits, targets)
+	assert.InDelta(t, 2.0, temperature, 0.01)
+	assert.InDelta(t, 0.881, SoftMaxWithTemperature(logits[0], temperature)[0], 0.001)
+}
+
+func TestFitTemperature_Empty(t *testing.T) {
+	assert.Equal(t, mat.Float(1), FitTemperature(nil, nil))
+	assert.Panics(t, func() { FitTemperature([][]mat.Float{{1, 2}}, nil) })
+}
+
+func TestSoftMaxWithTemperature(t *testing.T) {
+	logits := []mat.Float{1, 2, 3}
+	assert.Equal(t, SoftMaxWithTemperature(logits, 0), SoftMaxWithTemperature(logits, 1))
+	assert.InDeltaSlice(t, SoftMaxWithTemperature([]mat.Float{0.5, 1, 1.5}, 1), SoftMaxWithTemperature(logits, 2), 1.0e-6)
+}
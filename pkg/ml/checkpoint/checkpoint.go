@@ -0,0 +1,346 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package checkpoint makes long trainings survive the loss of the machine, e.g. the preemption of
+// a spot instance, by uploading periodic checkpoints of the model and of the optimizer to a remote
+// Sink (an S3 or GCS bucket), and by resuming the training from the latest one.
+//
+// A training loop calls MaybeSave after each optimization step, and Resume before the first one:
+//
+//	sink, _ := checkpoint.NewS3SinkFromEnv("my-bucket", "runs/ner/")
+//	c := checkpoint.New(sink, model, optimizer, checkpoint.Config{Interval: 10 * time.Minute, Keep: 3})
+//	defer c.NotifyPreemption(syscall.SIGTERM)()
+//	progress, _, err := c.Resume(ctx)
+//	for step := progress.Step + 1; step <= steps && !c.Preempted(); step++ {
+//	    // ... optimization step ...
+//	    if _, err := c.MaybeSave(ctx, checkpoint.Progress{Step: step, Epoch: epoch}); err != nil { ... }
+//	}
+//	err = c.Wait()
+//
+// A checkpoint is a single tar object, named after its step, with the metadata ("meta.json"), the
+// model in the model file format ("model.bin") and the state of the optimizer ("optimizer.bin").
+package checkpoint
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	namePrefix = "checkpoint-"
+	nameSuffix = ".tar"
+
+	metaEntry      = "meta.json"
+	modelEntry     = "model.bin"
+	optimizerEntry = "optimizer.bin"
+)
+
+// Config provides configuration settings for a Checkpointer.
+type Config struct {
+	// Steps is the number of steps between two periodic checkpoints; 0 disables the trigger.
+	Steps int
+	// Interval is the time between two periodic checkpoints; 0 disables the trigger.
+	Interval time.Duration
+	// Keep is the number of most recent checkpoints kept in the sink; 0 keeps all of them.
+	Keep int
+}
+
+// Progress is the position of the training saved with a checkpoint, to resume from.
+type Progress struct {
+	Step  int       `json:"step"`
+	Epoch int       `json:"epoch"`
+	Time  time.Time `json:"time"`
+}
+
+// Checkpointer saves the checkpoints of a training to a Sink, and restores the latest one.
+type Checkpointer struct {
+	Config
+	sink      Sink
+	model     nn.Model
+	optimizer *gd.GradientDescent
+	lastStep  int
+	lastTime  time.Time
+	uploading sync.WaitGroup
+	mu        sync.Mutex
+	uploadErr error
+	preempted int32
+}
+
+// New returns a new Checkpointer of the model, and of the optimizer if not nil.
+func New(sink Sink, model nn.Model, optimizer *gd.GradientDescent, config Config) *Checkpointer {
+	if config.Steps < 0 || config.Interval < 0 || config.Keep < 0 {
+		panic("checkpoint: the steps, the interval and the number of checkpoints to keep cannot be negative")
+	}
+	return &Checkpointer{
+		Config:    config,
+		sink:      sink,
+		model:     model,
+		optimizer: optimizer,
+		lastTime:  time.Now(),
+	}
+}
+
+// Name returns the name of the object of the checkpoint of the step.
+func Name(step int) string {
+	return fmt.Sprintf("%s%012d%s", namePrefix, step, nameSuffix)
+}
+
+// MaybeSave saves a checkpoint if one of the periodic triggers is due, or if the training has been
+// preempted (see NotifyPreemption). It reports whether a checkpoint has been saved.
+//
+// The periodic checkpoints are uploaded in background, so that the training goes on; an error of
+// the upload is returned by the next call to MaybeSave, Save or Wait. After the preemption, the
+// checkpoint is uploaded before returning, since the machine is going away.
+func (c *Checkpointer) MaybeSave(ctx context.Context, progress Progress) (bool, error) {
+	if c.Preempted() {
+		if err := c.Save(ctx, progress); err != nil {
+			return false, err
+		}
+		return true, c.Wait()
+	}
+	due := (c.Steps > 0 && progress.Step-c.lastStep >= c.Steps) ||
+		(c.Interval > 0 && time.Since(c.lastTime) >= c.Interval)
+	if !due {
+		return false, c.takeUploadErr()
+	}
+	return true, c.Save(ctx, progress)
+}
+
+// Save takes a checkpoint of the model and of the optimizer, and uploads it in background, after
+// the previous upload. The model and the optimizer can be modified as soon as Save returns.
+func (c *Checkpointer) Save(ctx context.Context, progress Progress) error {
+	if progress.Time.IsZero() {
+		progress.Time = time.Now()
+	}
+	data, err := c.snapshot(progress)
+	if err != nil {
+		return err
+	}
+	c.lastStep, c.lastTime = progress.Step, time.Now()
+
+	c.uploading.Wait()
+	if err := c.takeUploadErr(); err != nil {
+		return err
+	}
+	c.uploading.Add(1)
+	go func() {
+		defer c.uploading.Done()
+		err := c.sink.Put(ctx, Name(progress.Step), bytes.NewReader(data))
+		if err == nil {
+			err = c.prune(ctx)
+		}
+		if err != nil {
+			c.mu.Lock()
+			c.uploadErr = err
+			c.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// Wait waits for the upload of the last checkpoint, and returns its error, if any.
+func (c *Checkpointer) Wait() error {
+	c.uploading.Wait()
+	return c.takeUploadErr()
+}
+
+func (c *Checkpointer) takeUploadErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.uploadErr
+	c.uploadErr = nil
+	return err
+}
+
+// snapshot returns the checkpoint object of the current state of the training.
+func (c *Checkpointer) snapshot(progress Progress) ([]byte, error) {
+	meta, err := json.Marshal(progress)
+	if err != nil {
+		return nil, err
+	}
+	var model bytes.Buffer
+	if err := nn.WriteModel(&model, c.model); err != nil {
+		return nil, err
+	}
+	names := []string{metaEntry, modelEntry}
+	entries := [][]byte{meta, model.Bytes()}
+	if c.optimizer != nil {
+		var state bytes.Buffer
+		if err := gob.NewEncoder(&state).Encode(c.optimizer.GetState(c.model)); err != nil {
+			return nil, err
+		}
+		names = append(names, optimizerEntry)
+		entries = append(entries, state.Bytes())
+	}
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for i, data := range entries {
+		header := &tar.Header{Name: names[i], Mode: 0644, Size: int64(len(data)), ModTime: progress.Time}
+		if err := w.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// prune deletes the oldest checkpoints exceeding the number to keep.
+func (c *Checkpointer) prune(ctx context.Context) error {
+	if c.Keep == 0 {
+		return nil
+	}
+	names, err := c.list(ctx)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(names)-c.Keep; i++ {
+		if err := c.sink.Delete(ctx, names[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list returns the names of the checkpoints in the sink, from the oldest to the most recent.
+func (c *Checkpointer) list(ctx context.Context) ([]string, error) {
+	names, err := c.sink.List(ctx, namePrefix)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := names[:0]
+	for _, name := range names {
+		if strings.HasSuffix(name, nameSuffix) {
+			checkpoints = append(checkpoints, name)
+		}
+	}
+	return checkpoints, nil
+}
+
+// Resume restores the model, and the optimizer if not nil, from the most recent checkpoint in the
+// sink, and returns the progress of the training to resume from. If there are no checkpoints, the
+// model is not modified, and found is false.
+func (c *Checkpointer) Resume(ctx context.Context) (progress Progress, found bool, err error) {
+	names, err := c.list(ctx)
+	if err != nil || len(names) == 0 {
+		return progress, false, err
+	}
+	data, err := c.sink.Get(ctx, names[len(names)-1])
+	if err != nil {
+		return progress, false, err
+	}
+	progress, err = c.restore(data)
+	if err != nil {
+		return progress, false, fmt.Errorf("checkpoint: %s: %w", names[len(names)-1], err)
+	}
+	c.lastStep, c.lastTime = progress.Step, time.Now()
+	return progress, true, nil
+}
+
+// restore restores the model and the optimizer from a checkpoint object.
+func (c *Checkpointer) restore(data []byte) (progress Progress, err error) {
+	entries := make(map[string][]byte)
+	r := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return progress, err
+		}
+		if entries[header.Name], err = ioutil.ReadAll(r); err != nil {
+			return progress, err
+		}
+	}
+	meta, ok := entries[metaEntry]
+	if !ok {
+		return progress, fmt.Errorf("missing %s", metaEntry)
+	}
+	if err := json.Unmarshal(meta, &progress); err != nil {
+		return progress, err
+	}
+	model, ok := entries[modelEntry]
+	if !ok {
+		return progress, fmt.Errorf("missing %s", modelEntry)
+	}
+	// the decoded params do not require gradients, so the flags of the model in training are kept
+	requiresGrad := make(map[string]bool)
+	nn.ForEachParamWithPath(c.model, func(path string, param nn.Param) {
+		requiresGrad[path] = param.RequiresGrad()
+	})
+	if _, err := nn.ReadModel(bytes.NewReader(model), int64(len(model)), c.model); err != nil {
+		return progress, err
+	}
+	nn.ForEachParamWithPath(c.model, func(path string, param nn.Param) {
+		if value, ok := requiresGrad[path]; ok {
+			param.SetRequiresGrad(value)
+		}
+	})
+	if c.optimizer == nil {
+		return progress, nil
+	}
+	optimizer, ok := entries[optimizerEntry]
+	if !ok {
+		return progress, fmt.Errorf("missing %s", optimizerEntry)
+	}
+	var state gd.State
+	if err := gob.NewDecoder(bytes.NewReader(optimizer)).Decode(&state); err != nil {
+		return progress, err
+	}
+	return progress, c.optimizer.LoadState(c.model, state)
+}
+
+// NotifyPreemption makes the Checkpointer preempted when the process receives one of the signals
+// (e.g. SIGTERM, which precedes the termination of a spot instance), so that the next MaybeSave
+// saves a final checkpoint. It returns a function to stop the notification.
+func (c *Checkpointer) NotifyPreemption(signals ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+	go func() {
+		select {
+		case <-ch:
+			c.Preempt()
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Preempt makes the Checkpointer preempted, e.g. when the cloud provider announces the termination
+// of the instance through its metadata service.
+func (c *Checkpointer) Preempt() {
+	atomic.StoreInt32(&c.preempted, 1)
+}
+
+// Preempted reports whether the training has been preempted, and it should stop after the final
+// checkpoint.
+func (c *Checkpointer) Preempted() bool {
+	return atomic.LoadInt32(&c.preempted) == 1
+}
@@ -0,0 +1,194 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newTestTraining(seed uint64) (*linear.Model, *gd.GradientDescent) {
+	model := linear.New(2, 1)
+	randGen := rand.NewLockedRand(seed)
+	nn.ForEachParam(model, func(param nn.Param) {
+		initializers.Uniform(param.Value(), -0.5, 0.5, randGen)
+	})
+	optimizer := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(model))
+	return model, optimizer
+}
+
+func trainStep(model *linear.Model, optimizer *gd.GradientDescent) {
+	optimizer.IncBatch()
+	optimizer.IncExample()
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*linear.Model)
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, -1}), false)
+	loss := g.Square(g.SubScalar(nn.ToNode(proc.Forward(x)), g.Constant(3)))
+	g.Backward(loss)
+	optimizer.Optimize()
+}
+
+func newTestDirSink(t *testing.T) (*DirSink, func()) {
+	dir, err := ioutil.TempDir("", "spago-checkpoint-test-")
+	require.NoError(t, err)
+	return &DirSink{Dir: dir}, func() { os.RemoveAll(dir) }
+}
+
+func TestDirSink(t *testing.T) {
+	sink, cleanup := newTestDirSink(t)
+	defer cleanup()
+	testSink(t, sink)
+}
+
+// testSink tests the operations of an empty sink.
+func testSink(t *testing.T, sink Sink) {
+	ctx := context.Background()
+	names, err := sink.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, names)
+	_, err = sink.Get(ctx, "a")
+	assert.Equal(t, ErrNotExist, err)
+
+	require.NoError(t, sink.Put(ctx, "b/1", strings.NewReader("one")))
+	require.NoError(t, sink.Put(ctx, "a", strings.NewReader("two")))
+	require.NoError(t, sink.Put(ctx, "b+2", strings.NewReader("")))
+	require.NoError(t, sink.Put(ctx, "a", strings.NewReader("three")))
+	data, err := sink.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "three", string(data))
+	data, err = sink.Get(ctx, "b/1")
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(data))
+
+	names, err = sink.List(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b+2", "b/1"}, names)
+
+	require.NoError(t, sink.Delete(ctx, "b/1"))
+	require.NoError(t, sink.Delete(ctx, "b/1"))
+	names, err = sink.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b+2"}, names)
+}
+
+func TestCheckpointer(t *testing.T) {
+	sink, cleanup := newTestDirSink(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	model, optimizer := newTestTraining(1)
+	c := New(sink, model, optimizer, Config{Steps: 3, Keep: 2})
+	_, found, err := c.Resume(ctx)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	for step := 1; step <= 10; step++ {
+		trainStep(model, optimizer)
+		saved, err := c.MaybeSave(ctx, Progress{Step: step, Epoch: step / 4})
+		require.NoError(t, err)
+		assert.Equal(t, step%3 == 0, saved, step)
+	}
+	require.NoError(t, c.Wait())
+	names, err := sink.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{Name(6), Name(9)}, names)
+
+	// a new machine resumes from the last checkpoint
+	resumed, resumedOptimizer := newTestTraining(2)
+	c2 := New(sink, resumed, resumedOptimizer, Config{Steps: 3, Keep: 2})
+	progress, found, err := c2.Resume(ctx)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, 9, progress.Step)
+	assert.Equal(t, 2, progress.Epoch)
+	assert.False(t, progress.Time.IsZero())
+
+	// the training goes on as it would have without the preemption
+	model, optimizer = newTestTraining(1)
+	for step := 1; step <= 10; step++ {
+		trainStep(model, optimizer)
+		if step == 9 {
+			assert.Equal(t, model.W.Value().Data(), resumed.W.Value().Data())
+			assert.Equal(t, model.B.Value().Data(), resumed.B.Value().Data())
+		}
+	}
+	trainStep(resumed, resumedOptimizer)
+	assert.Equal(t, model.W.Value().Data(), resumed.W.Value().Data())
+
+	saved, err := c2.MaybeSave(ctx, Progress{Step: 10})
+	require.NoError(t, err)
+	assert.False(t, saved)
+
+	// the preemption saves a checkpoint at once
+	c2.Preempt()
+	assert.True(t, c2.Preempted())
+	saved, err = c2.MaybeSave(ctx, Progress{Step: 10})
+	require.NoError(t, err)
+	assert.True(t, saved)
+	names, err = sink.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{Name(9), Name(10)}, names)
+}
+
+// failingSink is a Sink whose uploads fail.
+type failingSink struct {
+	DirSink
+}
+
+var errUpload = errors.New("upload failed")
+
+func (s *failingSink) Put(context.Context, string, io.Reader) error {
+	return errUpload
+}
+
+func TestCheckpointer_UploadError(t *testing.T) {
+	model, optimizer := newTestTraining(1)
+	c := New(&failingSink{}, model, optimizer, Config{Steps: 1})
+	// the error of the background upload is returned later
+	saved, err := c.MaybeSave(context.Background(), Progress{Step: 1})
+	assert.True(t, saved)
+	assert.NoError(t, err)
+	assert.Equal(t, errUpload, c.Wait())
+	assert.NoError(t, c.Wait())
+
+	// a new checkpoint waits for the previous upload
+	require.NoError(t, c.Save(context.Background(), Progress{Step: 2}))
+	assert.Equal(t, errUpload, c.Save(context.Background(), Progress{Step: 3}))
+}
+
+type otherModel struct {
+	nn.BaseModel
+	Linear *linear.Model
+}
+
+func TestCheckpointer_ModelMismatch(t *testing.T) {
+	sink, cleanup := newTestDirSink(t)
+	defer cleanup()
+	ctx := context.Background()
+	model, optimizer := newTestTraining(1)
+	c := New(sink, model, optimizer, Config{})
+	require.NoError(t, c.Save(ctx, Progress{Step: 1}))
+	require.NoError(t, c.Wait())
+
+	_, found, err := New(sink, &otherModel{Linear: linear.New(2, 1)}, nil, Config{}).Resume(ctx)
+	assert.False(t, found)
+	assert.Error(t, err)
+}
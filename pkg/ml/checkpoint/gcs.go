@@ -0,0 +1,348 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gcsEndpoint      = "https://storage.googleapis.com"
+	gcsMetadataToken = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	// gcsChunkGranularity is the granularity of the size of the chunks of the resumable uploads.
+	gcsChunkGranularity = 256 << 10
+	// defaultGCSChunkSize is the default size of the chunks of the resumable uploads.
+	defaultGCSChunkSize = 8 << 20
+	// statusResumeIncomplete is the status of the responses to the chunks of a resumable upload
+	// but the last one.
+	statusResumeIncomplete = 308
+)
+
+// metadataClient is the HTTP client of the requests to the metadata server, which answers at once
+// on Google Compute Engine, so that elsewhere the requests fail instead of hanging.
+var metadataClient = &http.Client{Timeout: 10 * time.Second}
+
+// TokenSource returns an OAuth2 access token for the requests to Google Cloud Storage.
+type TokenSource func(ctx context.Context) (string, error)
+
+// StaticToken returns a TokenSource always returning the given token, e.g. the output of
+// "gcloud auth print-access-token".
+func StaticToken(token string) TokenSource {
+	return func(context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// MetadataToken returns a TokenSource getting the tokens of the service account of the instance
+// from the metadata server of Google Compute Engine, renewing them before they expire.
+func MetadataToken() TokenSource {
+	var mu sync.Mutex
+	var token string
+	var expiry time.Time
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" && time.Now().Before(expiry) {
+			return token, nil
+		}
+		req, err := http.NewRequest(http.MethodGet, gcsMetadataToken, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		resp, err := metadataClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("checkpoint: metadata server: %s", resp.Status)
+		}
+		var result struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return "", err
+		}
+		token = result.AccessToken
+		expiry = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+		return token, nil
+	}
+}
+
+// GCSConfig provides configuration settings for a GCSSink.
+type GCSConfig struct {
+	Bucket string
+	// Prefix is prepended to the names of the objects, e.g. "runs/bert-ner/".
+	Prefix string
+	// Endpoint is the base URL of the service; "https://storage.googleapis.com" if empty.
+	Endpoint string
+	// Token is the source of the access tokens; MetadataToken() if nil.
+	Token TokenSource
+	// ChunkSize is the size in bytes of the chunks of the resumable uploads (8 MiB if 0), rounded
+	// up to a multiple of 256 KiB, as required by GCS. It bounds the memory used by an upload.
+	ChunkSize int
+}
+
+// GCSSink is a Sink storing the objects in a Google Cloud Storage bucket, through its JSON API. The
+// objects are uploaded in chunks, with a resumable upload.
+type GCSSink struct {
+	GCSConfig
+	// Client is the HTTP client used for the requests; http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// NewGCSSink returns a new GCSSink.
+func NewGCSSink(config GCSConfig) *GCSSink {
+	if config.Bucket == "" {
+		panic("checkpoint: the bucket of GCS is required")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = gcsEndpoint
+	}
+	if config.Token == nil {
+		config.Token = MetadataToken()
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = defaultGCSChunkSize
+	}
+	if rem := config.ChunkSize % gcsChunkGranularity; rem != 0 {
+		config.ChunkSize += gcsChunkGranularity - rem
+	}
+	return &GCSSink{GCSConfig: config}
+}
+
+// Put satisfies the Sink interface. The data is read one chunk at a time, and uploaded in a
+// resumable upload session, which is cancelled on failure.
+func (s *GCSSink) Put(ctx context.Context, name string, r io.Reader) error {
+	session, err := s.startResumableUpload(ctx, s.Prefix+name)
+	if err != nil {
+		return err
+	}
+	err = s.uploadChunks(ctx, session, r)
+	if err != nil {
+		s.cancelResumableUpload(ctx, session)
+	}
+	return err
+}
+
+// startResumableUpload starts a resumable upload of the object, and returns the URL of its session.
+func (s *GCSSink) startResumableUpload(ctx context.Context, name string) (string, error) {
+	query := url.Values{"uploadType": {"resumable"}, "name": {name}}
+	resp, err := s.do(ctx, http.MethodPost, s.url("/upload/storage/v1/b/"+url.PathEscape(s.Bucket)+"/o?"+query.Encode()), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := gcsError(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return "", fmt.Errorf("checkpoint: GCS %s %s: missing session URL", resp.Request.Method, resp.Request.URL.Path)
+	}
+	return session, nil
+}
+
+// uploadChunks uploads the data read from r to the session of a resumable upload. The size of the
+// object is declared by the last chunk, the first one shorter than the chunk size (possibly empty).
+func (s *GCSSink) uploadChunks(ctx context.Context, session string, r io.Reader) error {
+	chunk := make([]byte, s.ChunkSize)
+	offset := 0
+	for {
+		n, err := io.ReadFull(r, chunk)
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return err
+		}
+		var contentRange string
+		switch {
+		case !last:
+			contentRange = fmt.Sprintf("bytes %d-%d/*", offset, offset+n-1)
+		case n > 0:
+			contentRange = fmt.Sprintf("bytes %d-%d/%d", offset, offset+n-1, offset+n)
+		default:
+			contentRange = fmt.Sprintf("bytes */%d", offset)
+		}
+		resp, err := s.do(ctx, http.MethodPut, session, http.Header{"Content-Range": {contentRange}}, chunk[:n])
+		if err != nil {
+			return err
+		}
+		if last {
+			err = gcsError(resp, http.StatusOK, http.StatusCreated)
+		} else {
+			err = gcsError(resp, statusResumeIncomplete)
+		}
+		if err == nil && !last {
+			err = checkPersisted(resp, offset+n)
+		}
+		resp.Body.Close()
+		if err != nil || last {
+			return err
+		}
+		offset += n
+	}
+}
+
+// checkPersisted returns an error if the service did not persist the given number of bytes of a
+// resumable upload, according to the Range header of the response to a chunk.
+func checkPersisted(resp *http.Response, expected int) error {
+	persisted := 0
+	if r := resp.Header.Get("Range"); r != "" {
+		end, err := strconv.Atoi(r[strings.LastIndex(r, "-")+1:])
+		if err != nil {
+			return fmt.Errorf("checkpoint: GCS %s: invalid range %q", resp.Request.Method, r)
+		}
+		persisted = end + 1
+	}
+	if persisted != expected {
+		return fmt.Errorf("checkpoint: GCS %s: persisted %d bytes of %d", resp.Request.Method, persisted, expected)
+	}
+	return nil
+}
+
+// cancelResumableUpload discards the session of a resumable upload. Its error is ignored, as the
+// one of the upload is more relevant; an abandoned session expires in a week.
+func (s *GCSSink) cancelResumableUpload(ctx context.Context, session string) {
+	resp, err := s.do(ctx, http.MethodDelete, session, nil, nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// Get satisfies the Sink interface.
+func (s *GCSSink) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.url(s.objectPath(name)+"?alt=media"), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotExist
+	}
+	if err := gcsError(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// List satisfies the Sink interface.
+func (s *GCSSink) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	token := ""
+	for {
+		query := url.Values{"prefix": {s.Prefix + prefix}, "fields": {"items(name),nextPageToken"}}
+		if token != "" {
+			query.Set("pageToken", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, s.url("/storage/v1/b/"+url.PathEscape(s.Bucket)+"/o?"+query.Encode()), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err = gcsError(resp, http.StatusOK)
+		if err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&result)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range result.Items {
+			names = append(names, strings.TrimPrefix(item.Name, s.Prefix))
+		}
+		if result.NextPageToken == "" {
+			break
+		}
+		token = result.NextPageToken
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete satisfies the Sink interface.
+func (s *GCSSink) Delete(ctx context.Context, name string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.url(s.objectPath(name)), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return gcsError(resp, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+// objectPath returns the path of the metadata of the object in the JSON API, where the name of the
+// object is a single escaped segment.
+func (s *GCSSink) objectPath(name string) string {
+	return "/storage/v1/b/" + url.PathEscape(s.Bucket) + "/o/" + url.PathEscape(s.Prefix+name)
+}
+
+// url returns the URL of the path of the API.
+func (s *GCSSink) url(path string) string {
+	return strings.TrimSuffix(s.Endpoint, "/") + path
+}
+
+// do sends an authorized request to the URL, with the additional headers.
+func (s *GCSSink) do(ctx context.Context, method, rawURL string, header http.Header, body []byte) (*http.Response, error) {
+	token, err := s.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = int64(len(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// gcsError returns an error with the message of the service if the status of the response is not
+// one of the expected ones.
+func gcsError(resp *http.Response, expected ...int) error {
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	var result struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if json.Unmarshal(body, &result) == nil && result.Error.Message != "" {
+		return fmt.Errorf("checkpoint: GCS %s %s: %s (%s)", resp.Request.Method, resp.Request.URL.Path, resp.Status, result.Error.Message)
+	}
+	return fmt.Errorf("checkpoint: GCS %s %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status)
+}
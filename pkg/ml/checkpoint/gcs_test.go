@@ -0,0 +1,194 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGCS is an in-memory GCS bucket serving the JSON API, with a name per page of the listings.
+type fakeGCS struct {
+	bucket  string
+	mu      sync.Mutex
+	objects map[string][]byte
+	// sessions are the resumable uploads in progress, by session ID.
+	sessions map[string]*fakeGCSSession
+	nextID   int
+}
+
+type fakeGCSSession struct {
+	name string
+	data []byte
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": {"code": 401, "message": "Invalid Credentials"}}`)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	objects := "/storage/v1/b/" + f.bucket + "/o"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload"+objects:
+		if r.URL.Query().Get("uploadType") != "resumable" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.nextID++
+		id := strconv.Itoa(f.nextID)
+		f.sessions[id] = &fakeGCSSession{name: r.URL.Query().Get("name")}
+		w.Header().Set("Location", "http://"+r.Host+"/upload/sessions/"+id)
+	case strings.HasPrefix(r.URL.Path, "/upload/sessions/"):
+		f.upload(w, r, strings.TrimPrefix(r.URL.Path, "/upload/sessions/"))
+	case r.Method == http.MethodGet && r.URL.Path == objects:
+		f.list(w, r)
+	case strings.HasPrefix(r.URL.Path, objects+"/"):
+		name := strings.TrimPrefix(r.URL.Path, objects+"/")
+		data, ok := f.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.objects, name)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.URL.Query().Get("alt") != "media" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write(data)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// upload receives a chunk of a resumable upload, with a Content-Range of the form "bytes 0-3/*",
+// "bytes 4-5/6" or "bytes */6", or cancels the upload.
+func (f *fakeGCS) upload(w http.ResponseWriter, r *http.Request, id string) {
+	session, ok := f.sessions[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		delete(f.sessions, id)
+		w.WriteHeader(499)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	var start, end int
+	var total string
+	contentRange := r.Header.Get("Content-Range")
+	if strings.HasPrefix(contentRange, "bytes */") {
+		start, end, total = len(session.data), len(session.data)-1, strings.TrimPrefix(contentRange, "bytes */")
+	} else if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%s", &start, &end, &total); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if start != len(session.data) || end-start+1 != len(body) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	session.data = append(session.data, body...)
+	if total == strconv.Itoa(len(session.data)) {
+		f.objects[session.name] = session.data
+		delete(f.sessions, id)
+		fmt.Fprint(w, "{}")
+		return
+	}
+	if len(session.data) > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(session.data)-1))
+	}
+	w.WriteHeader(statusResumeIncomplete)
+}
+
+func (f *fakeGCS) list(w http.ResponseWriter, r *http.Request) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	var result struct {
+		Items         []item `json:"items,omitempty"`
+		NextPageToken string `json:"nextPageToken,omitempty"`
+	}
+	var names []string
+	for name := range f.objects {
+		if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	if start < len(names) {
+		result.Items = []item{{Name: names[start]}}
+	}
+	if start+1 < len(names) {
+		result.NextPageToken = strconv.Itoa(start + 1)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+func TestGCSSink(t *testing.T) {
+	fake := &fakeGCS{bucket: "bucket", objects: map[string][]byte{"other/x": {}}, sessions: map[string]*fakeGCSSession{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := NewGCSSink(GCSConfig{
+		Bucket:   "bucket",
+		Prefix:   "runs/",
+		Endpoint: server.URL,
+		Token:    StaticToken("token"),
+	})
+	testSink(t, s)
+	assert.Contains(t, fake.objects, "runs/a")
+	assert.Contains(t, fake.objects, "runs/b+2")
+
+	s.Prefix, s.ChunkSize = "chunks/", 3
+	testSink(t, s) // "three" is uploaded in two chunks, "one" in a chunk and an empty one
+	assert.Equal(t, "three", string(fake.objects["chunks/a"]))
+
+	ctx := context.Background()
+	require.NoError(t, s.Put(ctx, "big", strings.NewReader("0123456789")))
+	data, err := s.Get(ctx, "big")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	// a failed upload is cancelled
+	err = s.Put(ctx, "broken", io.MultiReader(strings.NewReader("0123"), errReader{}))
+	assert.Equal(t, errRead, err)
+	assert.Empty(t, fake.sessions)
+	assert.NotContains(t, fake.objects, "chunks/broken")
+
+	s.Token = StaticToken("expired")
+	_, err = s.List(ctx, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid Credentials")
+}
+
+func TestNewGCSSink(t *testing.T) {
+	s := NewGCSSink(GCSConfig{Bucket: "bucket"})
+	assert.Equal(t, "https://storage.googleapis.com", s.Endpoint)
+	assert.NotNil(t, s.Token)
+	assert.Equal(t, 8<<20, s.ChunkSize)
+	assert.Equal(t, 512<<10, NewGCSSink(GCSConfig{Bucket: "bucket", ChunkSize: 300 << 10}).ChunkSize)
+	assert.Equal(t, "/storage/v1/b/bucket/o/runs%2Fa", (&GCSSink{GCSConfig: GCSConfig{Bucket: "bucket", Prefix: "runs/"}}).objectPath("a"))
+	assert.Panics(t, func() { NewGCSSink(GCSConfig{}) })
+}
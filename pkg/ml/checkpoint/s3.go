@@ -0,0 +1,448 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultS3PartSize is the default size of the parts of the multipart uploads.
+	defaultS3PartSize = 8 << 20
+	// maxS3Parts is the maximum number of parts of a multipart upload.
+	maxS3Parts = 10000
+)
+
+// S3Config provides configuration settings for an S3Sink.
+type S3Config struct {
+	Bucket string
+	Region string
+	// Prefix is prepended to the names of the objects, e.g. "runs/bert-ner/".
+	Prefix string
+	// Endpoint is the base URL of an S3-compatible service (e.g. "http://localhost:9000" for MinIO),
+	// addressing the bucket in the path. If empty, the AWS endpoint of the region is used,
+	// addressing the bucket in the host.
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is required by temporary credentials only.
+	SessionToken string
+	// PartSize is the size in bytes of the parts of the multipart uploads (8 MiB if 0); S3 requires
+	// at least 5 MiB. It bounds the memory used by an upload, and the size of the objects to
+	// 10,000 parts.
+	PartSize int
+}
+
+// S3Sink is a Sink storing the objects in an Amazon S3 (or S3-compatible) bucket, through its REST
+// API with the Signature Version 4 authentication. The objects larger than a part are uploaded in
+// parts, with a multipart upload.
+type S3Sink struct {
+	S3Config
+	// Client is the HTTP client used for the requests; http.DefaultClient if nil.
+	Client *http.Client
+	// now returns the time of the signatures; time.Now if nil.
+	now func() time.Time
+}
+
+// NewS3Sink returns a new S3Sink.
+func NewS3Sink(config S3Config) *S3Sink {
+	if config.Bucket == "" || config.Region == "" {
+		panic("checkpoint: the bucket and the region of S3 are required")
+	}
+	return &S3Sink{S3Config: config}
+}
+
+// NewS3SinkFromEnv returns a new S3Sink of the bucket, with the region and the credentials from the
+// standard environment variables of AWS (AWS_REGION or AWS_DEFAULT_REGION, AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN).
+func NewS3SinkFromEnv(bucket, prefix string) (*S3Sink, error) {
+	config := S3Config{
+		Bucket:          bucket,
+		Region:          os.Getenv("AWS_REGION"),
+		Prefix:          prefix,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if config.Region == "" {
+		return nil, fmt.Errorf("checkpoint: missing AWS_REGION")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("checkpoint: missing AWS_ACCESS_KEY_ID or AWS_SECRET_ACCESS_KEY")
+	}
+	return NewS3Sink(config), nil
+}
+
+// Put satisfies the Sink interface. The data is read one part at a time: an object not larger than
+// a part is uploaded with a single request, the others with a multipart upload, which is aborted
+// on failure.
+func (s *S3Sink) Put(ctx context.Context, name string, r io.Reader) error {
+	part := make([]byte, s.partSize())
+	n, err := io.ReadFull(r, part)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return s.putObject(ctx, s.Prefix+name, part[:n])
+	}
+	if err != nil {
+		return err
+	}
+	uploadID, err := s.createMultipartUpload(ctx, s.Prefix+name)
+	if err != nil {
+		return err
+	}
+	err = s.uploadParts(ctx, s.Prefix+name, uploadID, part, r)
+	if err != nil {
+		s.abortMultipartUpload(ctx, s.Prefix+name, uploadID)
+	}
+	return err
+}
+
+func (s *S3Sink) partSize() int {
+	if s.PartSize > 0 {
+		return s.PartSize
+	}
+	return defaultS3PartSize
+}
+
+// putObject uploads the object with a single request.
+func (s *S3Sink) putObject(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s3Error(resp, http.StatusOK)
+}
+
+// createMultipartUpload starts a multipart upload of the object, and returns its ID.
+func (s *S3Sink) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := s3Error(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.UploadID, nil
+}
+
+// s3Part is a part of a completed multipart upload.
+type s3Part struct {
+	PartNumber int
+	ETag       string
+}
+
+// uploadParts uploads the first part, already read, and the ones read from r, then completes the
+// multipart upload.
+func (s *S3Sink) uploadParts(ctx context.Context, key, uploadID string, part []byte, r io.Reader) error {
+	var parts []s3Part
+	for number := 1; len(part) > 0; number++ {
+		if number > maxS3Parts {
+			return fmt.Errorf("checkpoint: S3 object larger than %d parts", maxS3Parts)
+		}
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		resp, err := s.do(ctx, http.MethodPut, key, query, part)
+		if err != nil {
+			return err
+		}
+		err = s3Error(resp, http.StatusOK)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		parts = append(parts, s3Part{PartNumber: number, ETag: resp.Header.Get("ETag")})
+
+		n, err := io.ReadFull(r, part)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		part = part[:n]
+	}
+	return s.completeMultipartUpload(ctx, key, uploadID, parts)
+}
+
+// completeMultipartUpload assembles the uploaded parts into the object.
+func (s *S3Sink) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []s3Part) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := s3Error(resp, http.StatusOK); err != nil {
+		return err
+	}
+	// the request can fail after the response status has been sent, with an error in the body
+	var result struct {
+		XMLName xml.Name
+		Code    string
+		Message string
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.XMLName.Local == "Error" {
+		return fmt.Errorf("checkpoint: S3 %s %s: %s (%s)", resp.Request.Method, resp.Request.URL.Path, result.Code, result.Message)
+	}
+	return nil
+}
+
+// abortMultipartUpload discards the uploaded parts. Its error is ignored, as the one of the upload
+// is more relevant; the parts left by a failed abort can be expired by a lifecycle rule.
+func (s *S3Sink) abortMultipartUpload(ctx context.Context, key, uploadID string) {
+	resp, err := s.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// Get satisfies the Sink interface.
+func (s *S3Sink) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.Prefix+name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotExist
+	}
+	if err := s3Error(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// List satisfies the Sink interface.
+func (s *S3Sink) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.Prefix + prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = s3Error(resp, http.StatusOK)
+		if err == nil {
+			err = xml.NewDecoder(resp.Body).Decode(&result)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range result.Contents {
+			names = append(names, strings.TrimPrefix(c.Key, s.Prefix))
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete satisfies the Sink interface.
+func (s *S3Sink) Delete(ctx context.Context, name string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.Prefix+name, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s3Error(resp, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+// do sends a signed request for the object with the given key, or for the bucket if the key is empty.
+func (s *S3Sink) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = canonicalQuery(query)
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = int64(len(body))
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	s.sign(req, body, now())
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// objectURL returns the URL of the object with the given key, or of the bucket if the key is empty.
+func (s *S3Sink) objectURL(key string) (*url.URL, error) {
+	if s.Endpoint == "" {
+		return url.Parse(fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.Bucket, s.Region, uriEncode(key, false)))
+	}
+	path := "/" + s.Bucket
+	if key != "" {
+		path += "/" + uriEncode(key, false)
+	}
+	return url.Parse(strings.TrimSuffix(s.Endpoint, "/") + path)
+}
+
+// sign adds the Signature Version 4 authorization to the request.
+func (s *S3Sink) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if s.SessionToken != "" {
+		headers["x-amz-security-token"] = s.SessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery returns the query string sorted by key and encoded as required by the signature.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes all the bytes but the unreserved characters (and the slashes, unless
+// encodeSlash is true), as required by the signature.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Error returns an error with the message of the service if the status of the response is not
+// one of the expected ones.
+func s3Error(resp *http.Response, expected ...int) error {
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	var result struct {
+		Code    string
+		Message string
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if xml.Unmarshal(body, &result) == nil && result.Code != "" {
+		return fmt.Errorf("checkpoint: S3 %s %s: %s (%s)", resp.Request.Method, resp.Request.URL.Path, result.Code, result.Message)
+	}
+	return fmt.Errorf("checkpoint: S3 %s %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status)
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNotExist is returned by a Sink getting an object which does not exist.
+var ErrNotExist = errors.New("checkpoint: object does not exist")
+
+// Sink is a storage of the checkpoints, e.g. a cloud bucket, holding objects by name.
+type Sink interface {
+	// Put stores the data read from r with the given name, replacing the existing object, if any.
+	Put(ctx context.Context, name string, r io.Reader) error
+	// Get returns the data of the object with the given name, or ErrNotExist.
+	Get(ctx context.Context, name string) ([]byte, error)
+	// List returns the names of the objects with the given prefix, in lexicographic order.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes the object with the given name; removing a missing object is not an error.
+	Delete(ctx context.Context, name string) error
+}
+
+var (
+	_ Sink = &DirSink{}
+	_ Sink = &S3Sink{}
+	_ Sink = &GCSSink{}
+)
+
+// DirSink is a Sink storing the objects as files of a local directory, e.g. a network volume
+// surviving the machine. The slashes in the names of the objects separate subdirectories.
+type DirSink struct {
+	Dir string
+}
+
+// Put satisfies the Sink interface. The file is written to a temporary file first, then renamed,
+// so that a partial write never replaces a checkpoint.
+func (s *DirSink) Put(_ context.Context, name string, r io.Reader) error {
+	filename := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), ".tmp-"+filepath.Base(filename)+"-")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Get satisfies the Sink interface.
+func (s *DirSink) Get(_ context.Context, name string) ([]byte, error) {
+	data, err := ioutil.ReadFile(s.path(name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	return data, err
+}
+
+// List satisfies the Sink interface.
+func (s *DirSink) List(_ context.Context, prefix string) ([]string, error) {
+	var names []string
+	err := filepath.Walk(s.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == s.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Dir, path)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+// Delete satisfies the Sink interface.
+func (s *DirSink) Delete(_ context.Context, name string) error {
+	err := os.Remove(s.path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *DirSink) path(name string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(name))
+}
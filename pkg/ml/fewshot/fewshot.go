@@ -0,0 +1,102 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fewshot implements a nearest-class-mean classifier of embeddings, to classify texts
+// among classes known from a handful of examples, without any training loop.
+// Each class is represented by the centroid of the embeddings of its examples, which is updated
+// online as new examples are added, and the embeddings are classified by their cosine similarity
+// with the centroids.
+package fewshot
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"sort"
+	"sync"
+)
+
+// Classifier is a nearest-class-mean classifier. It is safe for concurrent use.
+type Classifier struct {
+	mu        sync.RWMutex
+	centroids map[string]*centroid
+}
+
+type centroid struct {
+	sum   mat.Matrix
+	count int
+}
+
+// Class is the label of a class with its number of examples.
+type Class struct {
+	Label    string `json:"label"`
+	Examples int    `json:"examples"`
+}
+
+// Prediction is the cosine similarity of an embedding with the centroid of a class.
+type Prediction struct {
+	Label      string    `json:"label"`
+	Similarity mat.Float `json:"similarity"`
+}
+
+// New returns a new Classifier without classes.
+func New() *Classifier {
+	return &Classifier{centroids: make(map[string]*centroid)}
+}
+
+// Add adds an example of the class with the given label, creating the class if new.
+// All the embeddings must have the same size.
+func (c *Classifier) Add(label string, embedding mat.Matrix) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cn, ok := c.centroids[label]; ok {
+		cn.sum.AddInPlace(embedding)
+		cn.count++
+		return
+	}
+	c.centroids[label] = &centroid{sum: embedding.Clone(), count: 1}
+}
+
+// Remove removes the class with the given label, and reports whether it existed.
+func (c *Classifier) Remove(label string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.centroids[label]
+	delete(c.centroids, label)
+	return ok
+}
+
+// Classes returns the classes, sorted by label.
+func (c *Classifier) Classes() []Class {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	classes := make([]Class, 0, len(c.centroids))
+	for label, cn := range c.centroids {
+		classes = append(classes, Class{Label: label, Examples: cn.count})
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		return classes[i].Label < classes[j].Label
+	})
+	return classes
+}
+
+// Predict returns the similarity of the embedding with each class, by decreasing similarity.
+// It returns an empty slice if there are no classes.
+func (c *Classifier) Predict(embedding mat.Matrix) []Prediction {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	predictions := make([]Prediction, 0, len(c.centroids))
+	for label, cn := range c.centroids {
+		// the cosine similarity does not depend on the scale of the centroid, so the sum is as good
+		predictions = append(predictions, Prediction{
+			Label:      label,
+			Similarity: mat.Cosine(embedding, cn.sum),
+		})
+	}
+	sort.Slice(predictions, func(i, j int) bool {
+		if predictions[i].Similarity == predictions[j].Similarity {
+			return predictions[i].Label < predictions[j].Label
+		}
+		return predictions[i].Similarity > predictions[j].Similarity
+	})
+	return predictions
+}
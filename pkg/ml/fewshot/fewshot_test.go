@@ -0,0 +1,40 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fewshot
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestClassifier(t *testing.T) {
+	c := New()
+	assert.Empty(t, c.Predict(mat.NewVecDense([]mat.Float{1, 0})))
+
+	c.Add("sport", mat.NewVecDense([]mat.Float{1, 0}))
+	c.Add("sport", mat.NewVecDense([]mat.Float{0.8, 0.2}))
+	c.Add("tech", mat.NewVecDense([]mat.Float{0, 1}))
+	assert.Equal(t, []Class{{Label: "sport", Examples: 2}, {Label: "tech", Examples: 1}}, c.Classes())
+
+	predictions := c.Predict(mat.NewVecDense([]mat.Float{0.9, 0.1}))
+	assert.Len(t, predictions, 2)
+	assert.Equal(t, "sport", predictions[0].Label)
+	assert.InDelta(t, 1.0, predictions[0].Similarity, 1.0e-6)
+	assert.Equal(t, "tech", predictions[1].Label)
+	assert.InDelta(t, 0.110432, predictions[1].Similarity, 1.0e-6)
+
+	assert.True(t, c.Remove("sport"))
+	assert.False(t, c.Remove("sport"))
+	assert.Equal(t, []Class{{Label: "tech", Examples: 1}}, c.Classes())
+}
+
+func TestClassifier_AddDoesNotRetainTheEmbedding(t *testing.T) {
+	c := New()
+	embedding := mat.NewVecDense([]mat.Float{1, 0})
+	c.Add("sport", embedding)
+	c.Add("sport", mat.NewVecDense([]mat.Float{1, 0}))
+	assert.Equal(t, []mat.Float{1, 0}, embedding.Data())
+}
@@ -118,3 +118,120 @@ func Achlioptas(m mat.Matrix, generator *rand.LockedRand) {
 		}
 	}
 }
+
+// FanMode specifies whether the number of input or output units (fan-in or fan-out)
+// is used to scale the Kaiming initializations.
+type FanMode int
+
+const (
+	// FanIn preserves the magnitude of the variance of the weights in the forward pass.
+	FanIn FanMode = iota
+	// FanOut preserves the magnitude of the variance of the weights in the backward pass.
+	FanOut
+)
+
+// fans returns the fan-in and fan-out of a weight matrix, which has a row for
+// each output unit and a column for each input unit (e.g. linear.Model.W).
+func fans(m mat.Matrix) (fanIn, fanOut int) {
+	return m.Columns(), m.Rows()
+}
+
+func (mode FanMode) fan(m mat.Matrix) int {
+	fanIn, fanOut := fans(m)
+	if mode == FanOut {
+		return fanOut
+	}
+	return fanIn
+}
+
+// TruncatedNormal fills the input matrix with random samples from a normal (Gaussian)
+// distribution with the given mean and standard deviation, truncated to the interval [a, b].
+// The values outside the interval are redrawn until they are within the bounds.
+func TruncatedNormal(m mat.Matrix, mean, std, a, b mat.Float, generator *rand.LockedRand) {
+	dist := normal.New(std, mean, generator)
+	for i := 0; i < m.Rows(); i++ {
+		for j := 0; j < m.Columns(); j++ {
+			v := dist.Next()
+			for v < a || v > b {
+				v = dist.Next()
+			}
+			m.Set(i, j, v)
+		}
+	}
+}
+
+// KaimingUniform fills the input matrix with values according to the method described in
+// "Delving deep into rectifiers: Surpassing human-level performance on ImageNet classification"
+// by He, K. et al. (2015), using a uniform distribution in [-bound, bound], where
+// bound = gain * sqrt(3 / fan).
+func KaimingUniform(m mat.Matrix, gain mat.Float, mode FanMode, generator *rand.LockedRand) {
+	bound := gain * mat.Sqrt(3.0/mat.Float(mode.fan(m)))
+	Uniform(m, -bound, bound, generator)
+}
+
+// KaimingNormal fills the input matrix with values according to the method described in
+// "Delving deep into rectifiers: Surpassing human-level performance on ImageNet classification"
+// by He, K. et al. (2015), using a normal distribution with mean 0 and std = gain / sqrt(fan).
+func KaimingNormal(m mat.Matrix, gain mat.Float, mode FanMode, generator *rand.LockedRand) {
+	std := gain / mat.Sqrt(mat.Float(mode.fan(m)))
+	Normal(m, 0, std, generator)
+}
+
+// LeCunUniform fills the input matrix with values according to the method described in
+// "Efficient BackProp" by LeCun, Y. et al. (1998), using a uniform distribution in
+// [-bound, bound], where bound = sqrt(3 / fan-in).
+func LeCunUniform(m mat.Matrix, generator *rand.LockedRand) {
+	KaimingUniform(m, 1.0, FanIn, generator)
+}
+
+// LeCunNormal fills the input matrix with values according to the method described in
+// "Efficient BackProp" by LeCun, Y. et al. (1998), using a normal distribution with
+// mean 0 and std = sqrt(1 / fan-in).
+func LeCunNormal(m mat.Matrix, generator *rand.LockedRand) {
+	KaimingNormal(m, 1.0, FanIn, generator)
+}
+
+// Orthogonal fills the input matrix with a (semi) orthogonal matrix, multiplied by gain,
+// as described in "Exact solutions to the nonlinear dynamics of learning in deep linear
+// neural networks" by Saxe, A. et al. (2013).
+// If the matrix has more rows than columns, the columns are orthonormal; otherwise the rows are.
+func Orthogonal(m mat.Matrix, gain mat.Float, generator *rand.LockedRand) {
+	rows, cols := m.Dims()
+	transposed := rows < cols
+	if transposed {
+		rows, cols = cols, rows
+	}
+	// the orthonormalization of the columns of a random normal matrix is
+	// equivalent to the Q factor of its QR decomposition, with positive diagonal R
+	q := mat.NewEmptyDense(rows, cols)
+	defer mat.ReleaseDense(q)
+	Normal(q, 0, 1, generator)
+	for j := 0; j < cols; j++ {
+		for k := 0; k < j; k++ {
+			var dot mat.Float = 0.0
+			for i := 0; i < rows; i++ {
+				dot += q.At(i, j) * q.At(i, k)
+			}
+			for i := 0; i < rows; i++ {
+				q.Set(i, j, q.At(i, j)-dot*q.At(i, k))
+			}
+		}
+		var norm mat.Float = 0.0
+		for i := 0; i < rows; i++ {
+			norm += q.At(i, j) * q.At(i, j)
+		}
+		norm = mat.Sqrt(norm)
+		for i := 0; i < rows; i++ {
+			q.Set(i, j, q.At(i, j)/norm)
+		}
+	}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if transposed {
+				m.Set(j, i, gain*q.At(i, j))
+			} else {
+				m.Set(i, j, gain*q.At(i, j))
+			}
+		}
+	}
+}
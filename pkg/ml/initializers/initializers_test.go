@@ -0,0 +1,80 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package initializers
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTruncatedNormal(t *testing.T) {
+	m := mat.NewEmptyDense(50, 50)
+	TruncatedNormal(m, 0.0, 1.0, -0.5, 0.5, rand.NewLockedRand(42))
+	for _, v := range m.Data() {
+		assert.True(t, v >= -0.5 && v <= 0.5)
+	}
+	assert.InDelta(t, 0.0, m.Sum()/mat.Float(m.Size()), 0.05)
+}
+
+func TestKaimingUniform(t *testing.T) {
+	m := mat.NewEmptyDense(10, 6) // fan-in 6, fan-out 10
+	KaimingUniform(m, mat.Sqrt(2.0), FanIn, rand.NewLockedRand(42))
+	for _, v := range m.Data() {
+		assert.True(t, mat.Abs(v) <= 1.0)
+	}
+	KaimingUniform(m, mat.Sqrt(2.0), FanOut, rand.NewLockedRand(42))
+	for _, v := range m.Data() {
+		assert.True(t, mat.Abs(v) <= mat.Sqrt(0.6))
+	}
+}
+
+func TestKaimingNormal(t *testing.T) {
+	m := mat.NewEmptyDense(100, 200)
+	KaimingNormal(m, mat.Sqrt(2.0), FanIn, rand.NewLockedRand(42))
+	mean := m.Sum() / mat.Float(m.Size())
+	variance := m.SubScalar(mean).Pow(2).Sum() / mat.Float(m.Size())
+	assert.InDelta(t, 2.0/200.0, variance, 0.001)
+}
+
+func TestOrthogonal(t *testing.T) {
+	for _, dims := range [][2]int{{5, 3}, {3, 5}, {4, 4}} {
+		m := mat.NewEmptyDense(dims[0], dims[1])
+		Orthogonal(m, 2.0, rand.NewLockedRand(42))
+
+		var product mat.Matrix
+		if dims[0] >= dims[1] {
+			product = m.T().Mul(m)
+		} else {
+			product = m.Mul(m.T())
+		}
+		n := product.Rows()
+		expected := mat.NewEmptyDense(n, n)
+		for i := 0; i < n; i++ {
+			expected.Set(i, i, 4.0)
+		}
+		assert.InDeltaSlice(t, expected.Data(), product.Data(), 1.0e-5)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	assert.Contains(t, Names(), "kaiming_normal")
+	assert.Contains(t, Names(), "orthogonal")
+
+	_, err := Get("foo")
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustGet("foo") })
+
+	Register("twos", func(m mat.Matrix, _ *rand.LockedRand) { Constant(m, 2.0) })
+	m := mat.NewEmptyDense(2, 2)
+	MustGet("twos")(m, nil)
+	assert.Equal(t, []mat.Float{2, 2, 2, 2}, m.Data())
+
+	initializer, err := Get("ones")
+	assert.NoError(t, err)
+	initializer(m, nil)
+	assert.Equal(t, []mat.Float{1, 1, 1, 1}, m.Data())
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package initializers
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"sort"
+	"sync"
+)
+
+// Initializer fills a matrix with initial values.
+type Initializer func(m mat.Matrix, generator *rand.LockedRand)
+
+var registry = struct {
+	sync.RWMutex
+	initializers map[string]Initializer
+}{
+	initializers: map[string]Initializer{
+		"zeros": func(m mat.Matrix, _ *rand.LockedRand) { Zeros(m) },
+		"ones":  func(m mat.Matrix, _ *rand.LockedRand) { Ones(m) },
+		"xavier_uniform": func(m mat.Matrix, g *rand.LockedRand) {
+			XavierUniform(m, 1.0, g)
+		},
+		"xavier_normal": func(m mat.Matrix, g *rand.LockedRand) {
+			XavierNormal(m, 1.0, g)
+		},
+		"kaiming_uniform": func(m mat.Matrix, g *rand.LockedRand) {
+			KaimingUniform(m, mat.Sqrt(2.0), FanIn, g)
+		},
+		"kaiming_normal": func(m mat.Matrix, g *rand.LockedRand) {
+			KaimingNormal(m, mat.Sqrt(2.0), FanIn, g)
+		},
+		"kaiming_uniform_fan_out": func(m mat.Matrix, g *rand.LockedRand) {
+			KaimingUniform(m, mat.Sqrt(2.0), FanOut, g)
+		},
+		"kaiming_normal_fan_out": func(m mat.Matrix, g *rand.LockedRand) {
+			KaimingNormal(m, mat.Sqrt(2.0), FanOut, g)
+		},
+		"lecun_uniform": LeCunUniform,
+		"lecun_normal":  LeCunNormal,
+		"orthogonal": func(m mat.Matrix, g *rand.LockedRand) {
+			Orthogonal(m, 1.0, g)
+		},
+		// the initialization of BERT-like models
+		"trunc_normal": func(m mat.Matrix, g *rand.LockedRand) {
+			TruncatedNormal(m, 0.0, 0.02, -0.04, 0.04, g)
+		},
+		"achlioptas": Achlioptas,
+	},
+}
+
+// Register adds a named initializer to the registry, replacing any existing one with the same name.
+func Register(name string, initializer Initializer) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.initializers[name] = initializer
+}
+
+// Get returns the initializer registered with the given name.
+//
+// The predefined initializers use the default parameters of the reference implementations:
+// "zeros", "ones", "xavier_uniform", "xavier_normal", "kaiming_uniform", "kaiming_normal"
+// (fan-in, ReLU gain), "kaiming_uniform_fan_out", "kaiming_normal_fan_out", "lecun_uniform",
+// "lecun_normal", "orthogonal", "trunc_normal" (std 0.02, truncated at two standard
+// deviations) and "achlioptas".
+func Get(name string) (Initializer, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+	initializer, ok := registry.initializers[name]
+	if !ok {
+		return nil, fmt.Errorf("initializers: unknown initializer %#v", name)
+	}
+	return initializer, nil
+}
+
+// MustGet is like Get, but panics if the initializer is not registered.
+func MustGet(name string) Initializer {
+	initializer, err := Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return initializer
+}
+
+// Names returns the names of all the registered initializers, in lexicographic order.
+func Names() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.initializers))
+	for name := range registry.initializers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package losses
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+)
+
+// InfoNCE implements the InfoNCE contrastive loss function, as described in
+// "Representation Learning with Contrastive Predictive Coding" by van den Oord et al., 2018
+// (https://arxiv.org/abs/1807.03748), using in-batch negatives.
+//
+// The i-th key is the positive example of the i-th query, while all the other keys of the
+// batch are used as negatives. The similarity is the cosine similarity scaled by 1/temperature.
+// It returns the loss averaged over the queries.
+func InfoNCE(g *ag.Graph, queries []ag.Node, keys []ag.Node, temperature mat.Float) ag.Node {
+	if len(queries) != len(keys) {
+		panic("losses: the number of queries and keys must be the same")
+	}
+	k := g.Stack(l2Normalize(g, keys)...)
+	invTemperature := g.NewScalar(1.0 / temperature)
+	var loss ag.Node
+	for i, q := range l2Normalize(g, queries) {
+		logits := g.ProdScalar(g.Mul(k, q), invTemperature)
+		loss = g.Add(loss, CrossEntropy(g, logits, i))
+	}
+	return g.DivScalar(loss, g.NewScalar(mat.Float(len(queries))))
+}
+
+// NTXent implements the normalized temperature-scaled cross-entropy loss function, as described in
+// "A Simple Framework for Contrastive Learning of Visual Representations" by Chen et al., 2020
+// (https://arxiv.org/abs/2002.05709).
+//
+// xs1[i] and xs2[i] are two views of the same example (a positive pair); each of the 2N
+// representations is contrasted with the other 2(N-1) representations of the batch.
+// It returns the loss averaged over all the 2N representations.
+func NTXent(g *ag.Graph, xs1 []ag.Node, xs2 []ag.Node, temperature mat.Float) ag.Node {
+	n := len(xs1)
+	if n != len(xs2) {
+		panic("losses: the number of views must be the same")
+	}
+	zs := l2Normalize(g, append(append([]ag.Node{}, xs1...), xs2...))
+	invTemperature := g.NewScalar(1.0 / temperature)
+	var loss ag.Node
+	for i, z := range zs {
+		positive := (i + n) % (2 * n)
+		others := make([]ag.Node, 0, 2*n-1)
+		target := 0
+		for j, other := range zs {
+			if j == i {
+				continue
+			}
+			if j == positive {
+				target = len(others)
+			}
+			others = append(others, other)
+		}
+		logits := g.ProdScalar(g.Mul(g.Stack(others...), z), invTemperature)
+		loss = g.Add(loss, CrossEntropy(g, logits, target))
+	}
+	return g.DivScalar(loss, g.NewScalar(mat.Float(2*n)))
+}
+
+// Triplet implements the triplet margin loss function, which is minimized when the Euclidean
+// distance between the anchor and the positive is smaller than the distance between the anchor
+// and the negative by at least the given margin.
+func Triplet(g *ag.Graph, anchor, positive, negative ag.Node, margin mat.Float) ag.Node {
+	dPos := euclideanDistance(g, anchor, positive)
+	dNeg := euclideanDistance(g, anchor, negative)
+	return g.ReLU(g.AddScalar(g.Sub(dPos, dNeg), g.NewScalar(margin)))
+}
+
+// CosineEmbedding implements the cosine embedding loss function.
+// y is 1 if x1 and x2 are expected to be similar, and -1 otherwise; in the latter case
+// the loss is zero when the cosine similarity is below the given margin.
+func CosineEmbedding(g *ag.Graph, x1, x2 ag.Node, y int, margin mat.Float) ag.Node {
+	cos := cosineSimilarity(g, x1, x2)
+	switch y {
+	case 1:
+		return g.ReverseSub(cos, g.NewScalar(1.0))
+	case -1:
+		return g.ReLU(g.SubScalar(cos, g.NewScalar(margin)))
+	default:
+		panic("losses: the cosine embedding target must be 1 or -1")
+	}
+}
+
+// InBatchNegatives returns, for each of the batchSize examples, the indices of n other examples
+// of the same batch to be used as negatives. If n is greater than or equal to batchSize-1, all
+// the other examples are returned; otherwise they are sampled without replacement using the
+// given generator (or the global random if nil).
+func InBatchNegatives(batchSize, n int, generator *rand.LockedRand) [][]int {
+	negatives := make([][]int, batchSize)
+	for i := range negatives {
+		others := make([]int, 0, batchSize-1)
+		for j := 0; j < batchSize; j++ {
+			if j != i {
+				others = append(others, j)
+			}
+		}
+		if n < len(others) {
+			others = rand.ShuffleInPlace(others, generator)[:n]
+		}
+		negatives[i] = others
+	}
+	return negatives
+}
+
+func l2Normalize(g *ag.Graph, xs []ag.Node) []ag.Node {
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		ys[i] = g.DivScalar(x, g.Sqrt(g.ReduceSum(g.Square(x))))
+	}
+	return ys
+}
+
+func euclideanDistance(g *ag.Graph, x1, x2 ag.Node) ag.Node {
+	return g.Sqrt(g.ReduceSum(g.Square(g.Sub(x1, x2))))
+}
+
+func cosineSimilarity(g *ag.Graph, x1, x2 ag.Node) ag.Node {
+	n1 := g.Sqrt(g.ReduceSum(g.Square(x1)))
+	n2 := g.Sqrt(g.ReduceSum(g.Square(x2)))
+	return g.Div(g.Dot(x1, x2), g.Prod(n1, n2))
+}
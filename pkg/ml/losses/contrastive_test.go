@@ -0,0 +1,93 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package losses
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInfoNCE(t *testing.T) {
+	g := ag.NewGraph()
+	queries := []ag.Node{
+		g.NewVariable(mat.NewVecDense([]mat.Float{2.0, 0.0}), true),
+		g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 1.0}), true),
+	}
+	keys := []ag.Node{
+		g.NewVariable(mat.NewVecDense([]mat.Float{1.0, 0.0}), true),
+		g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 3.0}), true),
+	}
+	loss := InfoNCE(g, queries, keys, 1.0)
+
+	assertEqualApprox(t, 0.313262, loss.Value().Scalar())
+
+	g.Backward(loss)
+
+	assert.NotNil(t, queries[0].Grad())
+	assert.NotNil(t, keys[1].Grad())
+}
+
+func TestNTXent(t *testing.T) {
+	g := ag.NewGraph()
+	xs1 := []ag.Node{
+		g.NewVariable(mat.NewVecDense([]mat.Float{1.0, 0.0}), true),
+		g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 1.0}), true),
+	}
+	xs2 := []ag.Node{
+		g.NewVariable(mat.NewVecDense([]mat.Float{1.0, 0.0}), true),
+		g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 1.0}), true),
+	}
+	loss := NTXent(g, xs1, xs2, 1.0)
+
+	assertEqualApprox(t, 0.551445, loss.Value().Scalar())
+}
+
+func TestTriplet(t *testing.T) {
+	g := ag.NewGraph()
+	a := g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 0.0}), true)
+	p := g.NewVariable(mat.NewVecDense([]mat.Float{3.0, 4.0}), true)
+	n := g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 1.0}), true)
+	loss := Triplet(g, a, p, n, 1.0)
+
+	assertEqualApprox(t, 5.0, loss.Value().Scalar())
+
+	g.Backward(loss)
+
+	assert.InDeltaSlice(t, []mat.Float{-0.6, 0.2}, a.Grad().Data(), 1.0e-6)
+
+	far := g.NewVariable(mat.NewVecDense([]mat.Float{6.0, 8.0}), true)
+	assertEqualApprox(t, 0.0, Triplet(g, a, p, far, 1.0).Value().Scalar())
+}
+
+func TestCosineEmbedding(t *testing.T) {
+	g := ag.NewGraph()
+	x1 := g.NewVariable(mat.NewVecDense([]mat.Float{1.0, 0.0}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]mat.Float{1.0, 1.0}), true)
+
+	assertEqualApprox(t, 0.292893, CosineEmbedding(g, x1, x2, 1, 0.0).Value().Scalar())
+	assertEqualApprox(t, 0.207107, CosineEmbedding(g, x1, x2, -1, 0.5).Value().Scalar())
+	assertEqualApprox(t, 0.0, CosineEmbedding(g, x1, x2, -1, 0.8).Value().Scalar())
+	assert.Panics(t, func() { CosineEmbedding(g, x1, x2, 0, 0.0) })
+}
+
+func TestInBatchNegatives(t *testing.T) {
+	all := InBatchNegatives(3, 5, nil)
+	assert.Equal(t, [][]int{{1, 2}, {0, 2}, {0, 1}}, all)
+
+	sampled := InBatchNegatives(10, 3, rand.NewLockedRand(42))
+	assert.Len(t, sampled, 10)
+	for i, negatives := range sampled {
+		assert.Len(t, negatives, 3)
+		assert.NotContains(t, negatives, i)
+		seen := map[int]bool{}
+		for _, j := range negatives {
+			assert.False(t, seen[j])
+			seen[j] = true
+		}
+	}
+}
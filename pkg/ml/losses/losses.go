@@ -36,7 +36,35 @@ func NLL(g *ag.Graph, x ag.Node, y ag.Node) ag.Node {
 // CrossEntropy implements a cross-entropy loss function.
 // c is the index of the gold class
 func CrossEntropy(g *ag.Graph, x ag.Node, c int) ag.Node {
-	return g.Add(g.Neg(g.AtVec(x, c)), g.Log(g.ReduceSum(g.Exp(x))))
+	return g.Add(g.Neg(g.AtVec(x, c)), g.LogSumExp(x))
+}
+
+// WeightedCrossEntropy implements a cross-entropy loss function scaled by the weight of the gold class c,
+// so that the errors on the rare classes of an imbalanced dataset can be penalized more.
+// weights contains the weight of each class.
+func WeightedCrossEntropy(g *ag.Graph, x ag.Node, c int, weights []mat.Float) ag.Node {
+	return g.ProdScalar(CrossEntropy(g, x, c), g.NewScalar(weights[c]))
+}
+
+// LabelSmoothingCrossEntropy implements a cross-entropy loss function with label smoothing,
+// where the one-hot target distribution of the gold class c is mixed with a uniform distribution
+// over all classes. epsilon is the smoothing factor in [0, 1] (a value of 0 gives the plain CrossEntropy).
+func LabelSmoothingCrossEntropy(g *ag.Graph, x ag.Node, c int, epsilon mat.Float) ag.Node {
+	logSumExp := g.LogSumExp(x)
+	nll := g.Add(g.Neg(g.AtVec(x, c)), logSumExp)
+	smooth := g.Sub(logSumExp, g.ReduceMean(x)) // -mean(log(softmax(x)))
+	return g.Add(g.ProdScalar(nll, g.NewScalar(1.0-epsilon)), g.ProdScalar(smooth, g.NewScalar(epsilon)))
+}
+
+// FocalLoss implements the focal loss function as described in "Focal Loss for Dense Object Detection"
+// by Lin et al., 2017 (https://arxiv.org/abs/1708.02002).
+// It is a cross-entropy scaled by (1 - p)^gamma, where p is the probability of the gold class c,
+// so that the contribution of the well-classified examples is down-weighted.
+// gamma is the focusing parameter (a value of 0 gives the plain CrossEntropy).
+func FocalLoss(g *ag.Graph, x ag.Node, c int, gamma mat.Float) ag.Node {
+	ce := CrossEntropy(g, x, c)
+	p := g.Exp(g.Neg(ce))
+	return g.Prod(g.Pow(g.ReverseSub(p, g.NewScalar(1.0)), gamma), ce)
 }
 
 // Perplexity computes the perplexity, implemented as exp over the cross-entropy.
@@ -102,6 +130,46 @@ func CrossEntropySeq(g *ag.Graph, predicted []ag.Node, target []int, reduceMean
 	return loss
 }
 
+// WeightedCrossEntropySeq calculates the WeightedCrossEntropy loss on the given sequence.
+// If reduceMean is true, the loss is divided by the sum of the weights of the targets,
+// i.e. it is the weighted mean of the losses.
+func WeightedCrossEntropySeq(g *ag.Graph, predicted []ag.Node, target []int, weights []mat.Float, reduceMean bool) ag.Node {
+	loss := WeightedCrossEntropy(g, predicted[0], target[0], weights)
+	sum := weights[target[0]]
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, WeightedCrossEntropy(g, predicted[i], target[i], weights))
+		sum += weights[target[i]]
+	}
+	if reduceMean && sum != 0 {
+		return g.DivScalar(loss, g.NewScalar(sum))
+	}
+	return loss
+}
+
+// LabelSmoothingCrossEntropySeq calculates the LabelSmoothingCrossEntropy loss on the given sequence.
+func LabelSmoothingCrossEntropySeq(g *ag.Graph, predicted []ag.Node, target []int, epsilon mat.Float, reduceMean bool) ag.Node {
+	loss := LabelSmoothingCrossEntropy(g, predicted[0], target[0], epsilon)
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, LabelSmoothingCrossEntropy(g, predicted[i], target[i], epsilon))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(mat.Float(len(predicted))))
+	}
+	return loss
+}
+
+// FocalLossSeq calculates the FocalLoss on the given sequence.
+func FocalLossSeq(g *ag.Graph, predicted []ag.Node, target []int, gamma mat.Float, reduceMean bool) ag.Node {
+	loss := FocalLoss(g, predicted[0], target[0], gamma)
+	for i := 1; i < len(predicted); i++ {
+		loss = g.Add(loss, FocalLoss(g, predicted[i], target[i], gamma))
+	}
+	if reduceMean {
+		return g.DivScalar(loss, g.NewScalar(mat.Float(len(predicted))))
+	}
+	return loss
+}
+
 // SPG (Softmax Policy Gradient) is a Gradient Policy used in Reinforcement Learning.
 // logPropActions are the log-probability of the chosen action by the Agent at each time;
 // logProbTargets are results of the reward function i.e. the predicted log-likelihood of the ground truth at each time;
@@ -49,6 +49,83 @@ func TestCrossEntropyLoss(t *testing.T) {
 	assert.InDeltaSlice(t, []mat.Float{0.0, 0.1, -0.8, 0.7}, x.Grad().Data(), 1.0e-6)
 }
 
+func TestCrossEntropyLossLargeLogits(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1000, 0}), true)
+	loss := CrossEntropy(g, x, 1)
+
+	assertEqualApprox(t, 1000, loss.Value().Scalar())
+
+	g.Backward(loss)
+
+	assert.InDeltaSlice(t, []mat.Float{1.0, -1.0}, x.Grad().Data(), 1.0e-6)
+}
+
+func TestWeightedCrossEntropyLoss(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{-500, 0, 0.693147, 1.94591}), true)
+	loss := WeightedCrossEntropy(g, x, 2, []mat.Float{1.0, 0.5, 2.0, 1.0})
+
+	assertEqualApprox(t, 3.218876, loss.Value().Scalar())
+
+	g.Backward(loss)
+
+	assert.InDeltaSlice(t, []mat.Float{0.0, 0.2, -1.6, 1.4}, x.Grad().Data(), 1.0e-6)
+}
+
+func TestWeightedCrossEntropySeqLoss(t *testing.T) {
+	g := ag.NewGraph()
+	x1 := g.NewVariable(mat.NewVecDense([]mat.Float{-500, 0, 0.693147, 1.94591}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]mat.Float{0, 0, 0, 0}), true)
+	weights := []mat.Float{1.0, 0.5, 2.0, 1.0}
+
+	loss := WeightedCrossEntropySeq(g, []ag.Node{x1, x2}, []int{2, 1}, weights, false)
+	assertEqualApprox(t, 3.912023, loss.Value().Scalar())
+
+	loss = WeightedCrossEntropySeq(g, []ag.Node{x1, x2}, []int{2, 1}, weights, true)
+	assertEqualApprox(t, 1.564809, loss.Value().Scalar())
+}
+
+func TestLabelSmoothingCrossEntropyLoss(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 0.0, 0.693147, 1.791759}), true)
+	loss := LabelSmoothingCrossEntropy(g, x, 2, 0.1)
+
+	assertEqualApprox(t, 1.616630, loss.Value().Scalar())
+
+	g.Backward(loss)
+
+	assert.InDeltaSlice(t, []mat.Float{0.075, 0.075, -0.725, 0.575}, x.Grad().Data(), 1.0e-6)
+}
+
+func TestLabelSmoothingCrossEntropyWithoutSmoothing(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{-500, 0, 0.693147, 1.94591}), true)
+	loss := LabelSmoothingCrossEntropy(g, x, 2, 0.0)
+
+	assertEqualApprox(t, 1.609438, loss.Value().Scalar())
+}
+
+func TestFocalLoss(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 0.0, 0.693147, 1.791759}), true)
+	loss := FocalLoss(g, x, 2, 2.0)
+
+	assertEqualApprox(t, 1.030040, loss.Value().Scalar())
+
+	g.Backward(loss)
+
+	assert.InDeltaSlice(t, []mat.Float{0.115502, 0.115502, -0.924016, 0.693012}, x.Grad().Data(), 1.0e-5)
+}
+
+func TestFocalLossWithoutFocusing(t *testing.T) {
+	g := ag.NewGraph()
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{-500, 0, 0.693147, 1.94591}), true)
+	loss := FocalLoss(g, x, 2, 0.0)
+
+	assertEqualApprox(t, 1.609438, loss.Value().Scalar())
+}
+
 func TestZeroOneQuantization(t *testing.T) {
 	g := ag.NewGraph()
 	x := g.NewVariable(mat.NewVecDense([]mat.Float{0.1, 0.2, 1.0, 0.4, -0.8, 0.3}), true)
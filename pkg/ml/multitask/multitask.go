@@ -0,0 +1,62 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package multitask implements the joint training of several tasks sharing a model, usually a
+// backbone with a head for each task (e.g. BERT with a NER head and a classification head).
+//
+// At each optimization step, a task is sampled according to the weights of the tasks, and the
+// model is optimized on a batch of its examples, so that the backbone learns from all the tasks
+// while each head learns from its own. The tasks are visited in proportion to their weights,
+// regardless of their sizes: the examples of each task are shuffled, and reshuffled whenever all
+// of them have been used.
+package multitask
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+// Task is a task of the multi-task training.
+type Task struct {
+	// Name identifies the task in the metrics.
+	Name string
+	// Size is the number of examples of the task, identified by their index.
+	Size int
+	// Weight is the sampling weight of the task (see TrainingConfig.Temperature). If the weights of
+	// all the tasks are zero, the sizes of the tasks are used instead.
+	Weight mat.Float
+	// Loss returns the loss of an example of the task, computed by the model reified for the
+	// training (e.g. by the backbone and the head of the task).
+	Loss func(model nn.Model, example int) ag.Node
+	// Evaluate, if not nil, returns a metric of the task (e.g. the accuracy on its validation set),
+	// computed by the model reified for the inference.
+	Evaluate func(model nn.Model) mat.Float
+}
+
+// TaskMetrics reports the progress of the training of a task.
+type TaskMetrics struct {
+	Name string
+	// Steps is the number of optimization steps on batches of the task.
+	Steps int
+	// Examples is the number of examples of the task used so far.
+	Examples int
+	// Epochs is the number of complete passes over the examples of the task.
+	Epochs int
+	// Loss is the average loss of the examples since the last reset of the metrics.
+	Loss mat.Float
+	// lossSum and lossCount are the sum and the number of the losses since the last reset.
+	lossSum   mat.Float
+	lossCount int
+}
+
+func (m *TaskMetrics) addLoss(loss mat.Float, n int) {
+	m.lossSum += loss * mat.Float(n)
+	m.lossCount += n
+	m.Loss = m.lossSum / mat.Float(m.lossCount)
+}
+
+func (m *TaskMetrics) resetLoss() {
+	m.Loss, m.lossSum, m.lossCount = 0, 0, 0
+}
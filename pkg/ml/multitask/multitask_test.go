@@ -0,0 +1,137 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package multitask
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// testModel is a shared linear backbone with a regression head for each task.
+type testModel struct {
+	nn.BaseModel
+	Backbone *linear.Model
+	Sum      *linear.Model
+	Diff     *linear.Model
+}
+
+func newTestModel() *testModel {
+	m := &testModel{
+		Backbone: linear.New(2, 2),
+		Sum:      linear.New(2, 1),
+		Diff:     linear.New(2, 1),
+	}
+	randGen := rand.NewLockedRand(1)
+	nn.ForEachParam(m, func(param nn.Param) {
+		initializers.Uniform(param.Value(), -0.5, 0.5, randGen)
+	})
+	return m
+}
+
+// testTask returns a task learning the target of random inputs through the head.
+func testTask(name string, size int, head func(m *testModel) *linear.Model, target func(x []mat.Float) mat.Float) *Task {
+	randGen := rand.NewLockedRand(uint64(size))
+	inputs := make([][]mat.Float, size)
+	for i := range inputs {
+		inputs[i] = []mat.Float{randGen.Float()*2 - 1, randGen.Float()*2 - 1}
+	}
+	loss := func(model nn.Model, i int) ag.Node {
+		m := model.(*testModel)
+		g := m.Graph()
+		x := g.NewVariable(mat.NewVecDense(inputs[i]), false)
+		y := nn.ToNode(head(m).Forward(nn.ToNode(m.Backbone.Forward(x))))
+		return g.Square(g.SubScalar(y, g.Constant(target(inputs[i]))))
+	}
+	return &Task{
+		Name: name,
+		Size: size,
+		Loss: loss,
+		Evaluate: func(model nn.Model) mat.Float {
+			var sum mat.Float
+			for i := range inputs {
+				sum += loss(model, i).ScalarValue()
+			}
+			return sum / mat.Float(size)
+		},
+	}
+}
+
+func newTestTasks() []*Task {
+	return []*Task{
+		testTask("sum", 60, func(m *testModel) *linear.Model { return m.Sum }, func(x []mat.Float) mat.Float {
+			return x[0] + x[1]
+		}),
+		testTask("diff", 20, func(m *testModel) *linear.Model { return m.Diff }, func(x []mat.Float) mat.Float {
+			return x[0] - x[1]
+		}),
+	}
+}
+
+func TestTrainer_Probabilities(t *testing.T) {
+	tasks := newTestTasks()
+	config := TrainingConfig{BatchSize: 4, UpdateMethod: sgd.NewConfig(0.1, 0, false)}
+
+	// proportional to the sizes
+	assert.InDeltaSlice(t, []mat.Float{0.75, 0.25}, NewTrainer(newTestModel(), tasks, config).Probabilities(), 1e-6)
+
+	config.Temperature = 2
+	assert.InDeltaSlice(t, []mat.Float{0.6340, 0.3660}, NewTrainer(newTestModel(), tasks, config).Probabilities(), 1e-4)
+
+	config.Temperature = 0
+	tasks[0].Weight, tasks[1].Weight = 1, 3
+	assert.InDeltaSlice(t, []mat.Float{0.25, 0.75}, NewTrainer(newTestModel(), tasks, config).Probabilities(), 1e-6)
+}
+
+func TestTrainer_Train(t *testing.T) {
+	model := newTestModel()
+	tasks := newTestTasks()
+	trainer := NewTrainer(model, tasks, TrainingConfig{
+		Seed:         42,
+		BatchSize:    4,
+		UpdateMethod: sgd.NewConfig(0.1, 0, false),
+	})
+
+	before := trainer.Evaluate()
+	trainer.Train(400)
+	after := trainer.Evaluate()
+	for _, task := range tasks {
+		assert.Less(t, float64(after[task.Name]), float64(before[task.Name])/10, task.Name)
+	}
+
+	metrics := trainer.Metrics()
+	assert.Equal(t, "sum", metrics[0].Name)
+	assert.Equal(t, 400, metrics[0].Steps+metrics[1].Steps)
+	// the tasks are sampled according to their sizes
+	assert.InDelta(t, 0.75, float64(metrics[0].Steps)/400, 0.06)
+	for i, m := range metrics {
+		assert.Equal(t, m.Steps*4, m.Examples)
+		assert.Equal(t, (m.Examples-1)/tasks[i].Size, m.Epochs)
+		assert.True(t, m.Loss > 0)
+	}
+
+	trainer.ResetLosses()
+	assert.Equal(t, mat.Float(0), trainer.Metrics()[0].Loss)
+	task, loss := trainer.Step()
+	assert.Equal(t, loss, trainer.Metrics()[task].Loss)
+}
+
+func TestNewTrainer(t *testing.T) {
+	config := TrainingConfig{BatchSize: 4, UpdateMethod: sgd.NewConfig(0.1, 0, false)}
+	assert.Panics(t, func() { NewTrainer(newTestModel(), nil, config) })
+	assert.Panics(t, func() { NewTrainer(newTestModel(), newTestTasks(), TrainingConfig{}) })
+	tasks := newTestTasks()
+	tasks[1].Loss = nil
+	assert.Panics(t, func() { NewTrainer(newTestModel(), tasks, config) })
+	tasks = newTestTasks()
+	tasks[0].Weight = -1
+	assert.Panics(t, func() { NewTrainer(newTestModel(), tasks, config) })
+}
@@ -0,0 +1,197 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package multitask
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/gdmbuilder"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"runtime"
+)
+
+// TrainingConfig provides configuration settings for a multi-task Trainer.
+type TrainingConfig struct {
+	Seed uint64
+	// BatchSize is the number of examples of a batch, all of the same task.
+	BatchSize int
+	// Temperature flattens the distribution of the tasks: the probability of sampling a task is
+	// proportional to its weight raised to 1/Temperature. 0 and 1 keep the weights unchanged;
+	// higher values give more room to the tasks with smaller weights (e.g. the smaller datasets,
+	// when the weights are the sizes).
+	Temperature      mat.Float
+	GradientClipping mat.Float
+	UpdateMethod     gd.MethodConfig
+}
+
+// Trainer implements the joint training of several tasks sharing a model.
+type Trainer struct {
+	TrainingConfig
+	randGen   *rand.LockedRand
+	optimizer *gd.GradientDescent
+	model     nn.Model
+	tasks     []*Task
+	probs     []mat.Float
+	// queues are the shuffled indices of the examples of each task not used yet in its epoch.
+	queues  [][]int
+	metrics []TaskMetrics
+}
+
+// NewTrainer returns a new multi-task Trainer of the model, which must include the parameters of
+// all the tasks (e.g. a struct with the backbone and the heads).
+func NewTrainer(model nn.Model, tasks []*Task, config TrainingConfig) *Trainer {
+	if len(tasks) == 0 {
+		panic("multitask: no tasks")
+	}
+	if config.BatchSize < 1 {
+		panic("multitask: the batch size must be positive")
+	}
+	for _, task := range tasks {
+		if task.Size < 1 || task.Loss == nil || task.Weight < 0 {
+			panic(fmt.Sprintf("multitask: invalid task %q: it must have examples, a loss and a non-negative weight", task.Name))
+		}
+	}
+	optimizer := gd.NewOptimizer(gdmbuilder.NewMethod(config.UpdateMethod), nn.NewDefaultParamsIterator(model))
+	if config.GradientClipping != 0.0 {
+		gd.ClipGradByNorm(config.GradientClipping, 2.0)(optimizer)
+	}
+	metrics := make([]TaskMetrics, len(tasks))
+	for i, task := range tasks {
+		metrics[i].Name = task.Name
+	}
+	return &Trainer{
+		TrainingConfig: config,
+		randGen:        rand.NewLockedRand(config.Seed),
+		optimizer:      optimizer,
+		model:          model,
+		tasks:          tasks,
+		probs:          samplingProbs(tasks, config.Temperature),
+		queues:         make([][]int, len(tasks)),
+		metrics:        metrics,
+	}
+}
+
+// samplingProbs returns the probabilities of sampling each task.
+func samplingProbs(tasks []*Task, temperature mat.Float) []mat.Float {
+	weights := make([]mat.Float, len(tasks))
+	useSizes := true
+	for i, task := range tasks {
+		weights[i] = task.Weight
+		useSizes = useSizes && task.Weight == 0
+	}
+	if useSizes {
+		for i, task := range tasks {
+			weights[i] = mat.Float(task.Size)
+		}
+	}
+	var sum mat.Float
+	for i, w := range weights {
+		if temperature != 0 && temperature != 1 && w > 0 {
+			weights[i] = mat.Pow(w, 1/temperature)
+		}
+		sum += weights[i]
+	}
+	for i := range weights {
+		weights[i] /= sum
+	}
+	return weights
+}
+
+// Probabilities returns the probabilities of sampling each task at an optimization step.
+func (t *Trainer) Probabilities() []mat.Float {
+	return append([]mat.Float(nil), t.probs...)
+}
+
+// Train performs the given number of optimization steps.
+func (t *Trainer) Train(steps int) {
+	for i := 0; i < steps; i++ {
+		t.Step()
+	}
+}
+
+// Step performs an optimization step on a batch of examples of a sampled task, and returns the
+// index of the task and the average loss of the batch.
+func (t *Trainer) Step() (task int, loss mat.Float) {
+	task = t.sampleTask()
+	batch := t.nextBatch(task)
+
+	t.optimizer.IncBatch()
+	g := ag.NewGraph(ag.Rand(t.randGen), ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, t.model)
+	losses := make([]ag.Node, len(batch))
+	for i, example := range batch {
+		t.optimizer.IncExample()
+		losses[i] = t.tasks[task].Loss(proc, example)
+	}
+	batchLoss := g.DivScalar(g.Sum(losses...), g.Constant(mat.Float(len(batch))))
+	g.Backward(batchLoss)
+	t.optimizer.Optimize()
+
+	loss = batchLoss.ScalarValue()
+	t.metrics[task].Steps++
+	t.metrics[task].addLoss(loss, len(batch))
+	return task, loss
+}
+
+// sampleTask samples the index of a task according to the probabilities.
+func (t *Trainer) sampleTask() int {
+	u := t.randGen.Float()
+	var cumulative mat.Float
+	for i, p := range t.probs {
+		cumulative += p
+		if u < cumulative {
+			return i
+		}
+	}
+	return len(t.probs) - 1 // rounding errors
+}
+
+// nextBatch returns the indices of the next examples of the task, starting a new epoch of the
+// task when all its examples have been used. A batch never spans two epochs, so the last one of
+// an epoch can be smaller.
+func (t *Trainer) nextBatch(task int) []int {
+	if len(t.queues[task]) == 0 {
+		if t.metrics[task].Examples > 0 {
+			t.metrics[task].Epochs++
+		}
+		t.queues[task] = rand.ShuffleInPlace(utils.MakeIndices(t.tasks[task].Size), t.randGen)
+	}
+	n := utils.MinInt(t.BatchSize, len(t.queues[task]))
+	batch := t.queues[task][:n]
+	t.queues[task] = t.queues[task][n:]
+	t.metrics[task].Examples += n
+	return batch
+}
+
+// Metrics returns the metrics of the training of each task, in the order of the tasks.
+func (t *Trainer) Metrics() []TaskMetrics {
+	return append([]TaskMetrics(nil), t.metrics...)
+}
+
+// ResetLosses resets the average losses of the metrics, e.g. at each report.
+func (t *Trainer) ResetLosses() {
+	for i := range t.metrics {
+		t.metrics[i].resetLoss()
+	}
+}
+
+// Evaluate returns the metric of each task having the Evaluate function, by name.
+func (t *Trainer) Evaluate() map[string]mat.Float {
+	results := make(map[string]mat.Float)
+	for _, task := range t.tasks {
+		if task.Evaluate == nil {
+			continue
+		}
+		g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
+		results[task.Name] = task.Evaluate(nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, t.model))
+		g.Clear()
+	}
+	return results
+}
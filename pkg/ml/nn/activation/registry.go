@@ -0,0 +1,114 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package activation
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"strings"
+	"sync"
+)
+
+// registryEntry associates an activation name to an operator and the default
+// values of its parameters (e.g. the alpha of the ELU).
+type registryEntry struct {
+	op     ag.OpName
+	params []mat.Float
+}
+
+var registry = struct {
+	sync.RWMutex
+	entries map[string]registryEntry
+}{
+	entries: map[string]registryEntry{
+		// the GELU operator uses the tanh approximation, close to the exact one up to ~1e-3
+		"gelu":              {op: ag.OpGELU},
+		"gelu_new":          {op: ag.OpGELU},
+		"gelu_fast":         {op: ag.OpGELU},
+		"gelu_python":       {op: ag.OpGELU},
+		"gelu_accurate":     {op: ag.OpGELU},
+		"gelu_pytorch_tanh": {op: ag.OpGELU},
+		"relu":              {op: ag.OpReLU},
+		"silu":              {op: ag.OpSiLU},
+		"swish":             {op: ag.OpSiLU},
+		"mish":              {op: ag.OpMish},
+		"tanh":              {op: ag.OpTanh},
+		"sigmoid":           {op: ag.OpSigmoid},
+		"hard_sigmoid":      {op: ag.OpHardSigmoid},
+		"hardtanh":          {op: ag.OpHardTanh},
+		"softsign":          {op: ag.OpSoftsign},
+		"linear":            {op: ag.OpIdentity},
+		"identity":          {op: ag.OpIdentity},
+		"elu":               {op: ag.OpELU, params: []mat.Float{1.0}},
+		"celu":              {op: ag.OpCELU, params: []mat.Float{1.0}},
+		"leaky_relu":        {op: ag.OpLeakyReLU, params: []mat.Float{0.01}},
+		"selu":              {op: ag.OpSELU, params: []mat.Float{1.6732632423543772, 1.0507009873554805}},
+	},
+}
+
+// Register associates an activation name (case-insensitive) to an operator and the default values
+// of its parameters, replacing any existing association.
+func Register(name string, op ag.OpName, params ...mat.Float) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.entries[strings.ToLower(name)] = registryEntry{op: op, params: params}
+}
+
+func lookup(name string) (registryEntry, error) {
+	registry.RLock()
+	entry, ok := registry.entries[strings.ToLower(name)]
+	registry.RUnlock()
+	if ok {
+		return entry, nil
+	}
+	// fallback to the names of the operators (e.g. "GELU")
+	if op, err := ag.GetOpName(name); err == nil {
+		return registryEntry{op: op}, nil
+	}
+	return registryEntry{}, fmt.Errorf("activation: unknown activation function %#v", name)
+}
+
+// GetOpName returns the operator associated with the given activation name, as used
+// in the configuration of pre-trained models (e.g. the "hidden_act" of Hugging Face
+// configurations: "gelu", "gelu_new", "silu", "mish", ...).
+// Names are case-insensitive; the names of the operators are also accepted.
+func GetOpName(name string) (ag.OpName, error) {
+	entry, err := lookup(name)
+	return entry.op, err
+}
+
+// MustGetOpName is like GetOpName, but panics if the activation is unknown.
+func MustGetOpName(name string) ag.OpName {
+	op, err := GetOpName(name)
+	if err != nil {
+		panic(err)
+	}
+	return op
+}
+
+// NewFromName returns a new activation Model for the given activation name (see GetOpName),
+// with its parameters, if any, set to the default (non-trainable) values.
+func NewFromName(name string) (*Model, error) {
+	entry, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]nn.Param, len(entry.params))
+	for i, v := range entry.params {
+		params[i] = nn.NewParam(mat.NewScalar(v), nn.RequiresGrad(false))
+	}
+	return New(entry.op, params...), nil
+}
+
+// MustNewFromName is like NewFromName, but panics if the activation is unknown.
+func MustNewFromName(name string) *Model {
+	m, err := NewFromName(name)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
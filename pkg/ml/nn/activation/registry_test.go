@@ -0,0 +1,62 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package activation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetOpName(t *testing.T) {
+	cases := map[string]ag.OpName{
+		"gelu":     ag.OpGELU,
+		"gelu_new": ag.OpGELU,
+		"silu":     ag.OpSiLU,
+		"swish":    ag.OpSiLU,
+		"mish":     ag.OpMish,
+		"ReLU":     ag.OpReLU,
+		"linear":   ag.OpIdentity,
+		"Softmax":  ag.OpSoftmax, // operator name
+	}
+	for name, expected := range cases {
+		op, err := GetOpName(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, expected, op, name)
+	}
+
+	_, err := GetOpName("foo")
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustGetOpName("foo") })
+}
+
+func TestRegister(t *testing.T) {
+	Register("Quick_Swish", ag.OpSwishB, 1.702)
+	assert.Equal(t, ag.OpSwishB, MustGetOpName("quick_swish"))
+
+	m := MustNewFromName("quick_swish")
+	assert.Len(t, m.Params, 1)
+	assert.Equal(t, mat.Float(1.702), m.Params[0].ScalarValue())
+}
+
+func TestNewFromName(t *testing.T) {
+	m, err := NewFromName("leaky_relu")
+	assert.NoError(t, err)
+	assert.Equal(t, ag.OpLeakyReLU, m.Activation)
+	assert.Len(t, m.Params, 1)
+	assert.False(t, m.Params[0].RequiresGrad())
+
+	g := ag.NewGraph()
+	p := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*Model)
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1.0, -2.0}), true)
+	y := nn.ToNode(p.Forward(x))
+	assert.InDeltaSlice(t, []mat.Float{1.0, -0.02}, y.Value().Data(), 1.0e-6)
+
+	_, err = NewFromName("foo")
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustNewFromName("foo") })
+}
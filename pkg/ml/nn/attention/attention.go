@@ -53,17 +53,18 @@ func ScaledDotProductAttention(g *ag.Graph, qkv QKV, scaleFactor mat.Float, useC
 	prob = make([]mat.Matrix, len(qkv.Queries))
 	keys := g.Stack(qkv.Keys...)
 	values := g.T(g.Stack(qkv.Values...))
-	factor := g.NewScalar(scaleFactor)
 
+	pastLength := len(qkv.Keys) - len(qkv.Queries) // the keys of the past inputs are attended by all the queries
+	if pastLength < 0 {
+		pastLength = 0
+	}
 	for i, q := range qkv.Queries {
-		attScores := g.ProdScalar(g.Mul(keys, q), factor)
-
+		var mask mat.Matrix
 		if useCausalMask && len(qkv.Queries) > 1 {
-			causalMask := MakeCausalMask(i, len(qkv.Keys)) // TODO: use external cache for causal mask?
-			attScores = g.Add(attScores, g.NewVariable(mat.NewVecDense(causalMask), false))
+			mask = mat.NewVecDense(MakeCausalMask(pastLength+i, len(qkv.Keys))) // TODO: use external cache for causal mask?
 		}
 
-		attProb := g.Softmax(attScores)
+		attProb := g.ScaledSoftmax(g.Mul(keys, q), scaleFactor, mask)
 		context[i] = g.Mul(values, attProb)
 		prob[i] = attProb.Value()
 	}
@@ -80,19 +81,21 @@ func MakeCausalMask(curIndex, seqLength int) []mat.Float {
 }
 
 // ScaledDotProductAttentionConcurrent does the same thing as ScaledDotProductAttention but processes input concurrently.
+// It falls back to ScaledDotProductAttention if the graph doesn't allow concurrent computations.
 func ScaledDotProductAttentionConcurrent(g *ag.Graph, qkv QKV, scaleFactor mat.Float) (context []ag.Node, prob []mat.Matrix) {
+	if g.ConcurrentComputations() == 1 {
+		return ScaledDotProductAttention(g, qkv, scaleFactor, false)
+	}
 	context = make([]ag.Node, len(qkv.Queries))
 	prob = make([]mat.Matrix, len(qkv.Queries))
 	keys := g.Stack(qkv.Keys...)
 	values := g.T(g.Stack(qkv.Values...))
-	factor := g.NewScalar(scaleFactor)
 	var wg sync.WaitGroup
 	wg.Add(len(qkv.Queries))
 	for i, q := range qkv.Queries {
 		go func(i int, q ag.Node) {
 			defer wg.Done()
-			attScores := g.ProdScalar(g.Mul(keys, q), factor)
-			attProb := g.Softmax(attScores)
+			attProb := g.ScaledSoftmax(g.Mul(keys, q), scaleFactor, nil)
 			context[i] = g.Mul(values, attProb)
 			prob[i] = attProb.Value()
 		}(i, q)
@@ -101,6 +104,28 @@ func ScaledDotProductAttentionConcurrent(g *ag.Graph, qkv QKV, scaleFactor mat.F
 	return
 }
 
+// DefaultFlashAttentionBlockSize is the default number of keys processed at once by FlashAttention.
+const DefaultFlashAttentionBlockSize = 64
+
+// FlashAttention does the same thing as ScaledDotProductAttention, but it processes the keys and the values
+// in blocks of the given size, never materializing the full attention matrix. This reduces the memory
+// from quadratic to linear in the sequence length, at the cost of not returning the attention weights.
+func FlashAttention(g *ag.Graph, qkv QKV, scaleFactor mat.Float, useCausalMask bool, blockSize int) []ag.Node {
+	y := g.FlashAttention(
+		g.Stack(qkv.Queries...),
+		g.Stack(qkv.Keys...),
+		g.Stack(qkv.Values...),
+		scaleFactor,
+		useCausalMask && len(qkv.Queries) > 1,
+		blockSize,
+	)
+	context := make([]ag.Node, len(qkv.Queries))
+	for i := range context {
+		context[i] = g.T(g.RowView(y, i))
+	}
+	return context
+}
+
 // MappingFunc is a mapping function used by LinearAttention.
 type MappingFunc func(g *ag.Graph, x ag.Node) ag.Node
 
@@ -99,6 +99,89 @@ func TestScaledDotProductAttention2(t *testing.T) {
 	assert.InDeltaSlice(t, []mat.Float{-0.31458, -0.432022, -0.289395, -0.410987}, attIn.Values[2].Grad().Data(), 1.0e-6)
 }
 
+//gocyclo:ignore
+func TestFlashAttention(t *testing.T) {
+	g := ag.NewGraph()
+
+	attIn := QKV{
+		Queries: []ag.Node{
+			g.NewVariable(mat.NewVecDense([]mat.Float{0.22, 0.3}), true),
+			g.NewVariable(mat.NewVecDense([]mat.Float{-0.17, 0.24}), true),
+			g.NewVariable(mat.NewVecDense([]mat.Float{-0.15, 0.23}), true),
+		},
+		Keys: []ag.Node{
+			g.NewVariable(mat.NewVecDense([]mat.Float{1.66, 0.12}), true),
+			g.NewVariable(mat.NewVecDense([]mat.Float{0.88, -0.02}), true),
+			g.NewVariable(mat.NewVecDense([]mat.Float{-0.3, -0.46}), true),
+		},
+		Values: []ag.Node{
+			g.NewVariable(mat.NewVecDense([]mat.Float{0.83, 0.7, -0.25, -0.58}), true),
+			g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 0.2, 0.57, -2.08}), true),
+			g.NewVariable(mat.NewVecDense([]mat.Float{-0.07, 0.0, 0.29, 0.5}), true),
+		},
+	}
+
+	// == Forward
+	context := FlashAttention(g, attIn, 1.0/mat.Sqrt(2), false, 2)
+
+	if len(context) != 3 {
+		t.Error("The context doesn't have the expected length")
+	}
+	assert.InDeltaSlice(t, []mat.Float{0.312291, 0.347165, 0.170855, -0.813202}, context[0].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.232861, 0.284047, 0.21555, -0.694914}, context[1].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.236194, 0.28672, 0.21373, -0.700304}, context[2].Value().Data(), 1.0e-6)
+
+	// == Backward
+	context[0].PropagateGrad(mat.NewVecDense([]mat.Float{0.7, -0.3, -0.7, -0.5}))
+	context[1].PropagateGrad(mat.NewVecDense([]mat.Float{-0.8, -0.5, -0.5, 0.1}))
+	context[2].PropagateGrad(mat.NewVecDense([]mat.Float{-0.6, -0.5, 0.2, -0.9}))
+	g.BackwardAll()
+
+	assert.InDeltaSlice(t, []mat.Float{0.291064, 0.090078}, attIn.Queries[0].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-0.214319, -0.065291}, attIn.Queries[1].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.084357, 0.057063}, attIn.Queries[2].Grad().Data(), 1.0e-6)
+
+	assert.InDeltaSlice(t, []mat.Float{0.06886, -0.025612}, attIn.Keys[0].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-0.039958, 0.089393}, attIn.Keys[1].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-0.028902, -0.063781}, attIn.Keys[2].Grad().Data(), 1.0e-6)
+
+	assert.InDeltaSlice(t, []mat.Float{-0.15834, -0.431875, -0.371149, -0.450847}, attIn.Values[0].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-0.22708, -0.436103, -0.339456, -0.438166}, attIn.Values[1].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-0.31458, -0.432022, -0.289395, -0.410987}, attIn.Values[2].Grad().Data(), 1.0e-6)
+}
+
+func TestFlashAttention_CausalMask(t *testing.T) {
+	newQKV := func(g *ag.Graph) QKV {
+		return QKV{
+			Queries: []ag.Node{
+				g.NewVariable(mat.NewVecDense([]mat.Float{1.1, 0.0, 2.3}), true),
+				g.NewVariable(mat.NewVecDense([]mat.Float{2.2, -0.5, 0.3}), true),
+				g.NewVariable(mat.NewVecDense([]mat.Float{3.2, 0.5, 0.4}), true),
+			},
+			Keys: []ag.Node{
+				g.NewVariable(mat.NewVecDense([]mat.Float{0.0, 1.2, 1.3}), true),
+				g.NewVariable(mat.NewVecDense([]mat.Float{4.5, 4.3, 0.2}), true),
+				g.NewVariable(mat.NewVecDense([]mat.Float{2.7, 3.6, 2.1}), true),
+			},
+			Values: []ag.Node{
+				g.NewVariable(mat.NewVecDense([]mat.Float{1.2, 2.3, 3.4}), true),
+				g.NewVariable(mat.NewVecDense([]mat.Float{2.2, 8.5, 0.0}), true),
+				g.NewVariable(mat.NewVecDense([]mat.Float{2.3, 6.5, 3.5}), true),
+			},
+		}
+	}
+
+	g1 := ag.NewGraph()
+	expected, _ := ScaledDotProductAttention(g1, newQKV(g1), 1.0/mat.Sqrt(3), true)
+
+	g2 := ag.NewGraph()
+	actual := FlashAttention(g2, newQKV(g2), 1.0/mat.Sqrt(3), true, 1)
+
+	for i := range expected {
+		assert.InDeltaSlice(t, expected[i].Value().Data(), actual[i].Value().Data(), 1.0e-5)
+	}
+}
+
 func TestLinearAttention(t *testing.T) {
 	g := ag.NewGraph()
 
@@ -0,0 +1,216 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package groupedqueryattention implements the grouped-query attention (GQA), a variant of the
+// multi-head attention where groups of query heads share a single key/value head.
+// With a single key/value head it corresponds to the multi-query attention (MQA).
+//
+// Sharing the key/value heads reduces the size of the keys/values cache by a factor of
+// NumOfHeads / NumOfKeyValueHeads, speeding up the autoregressive decoding.
+//
+// References:
+// "Fast Transformer Decoding: One Write-Head is All You Need" by Noam Shazeer, 2019 (https://arxiv.org/abs/1911.02150)
+// "GQA: Training Generalized Multi-Query Transformer Models from Multi-Head Checkpoints" by Ainslie et al., 2023
+// (https://arxiv.org/abs/2305.13245)
+package groupedqueryattention
+
+import (
+	"encoding/gob"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/multiheadattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+)
+
+var (
+	_ nn.Model = &Model{}
+)
+
+// Model contains the serializable parameters.
+type Model struct {
+	nn.BaseModel
+	Config
+	Query       []*linear.Model // one for each query head
+	Key         []*linear.Model // one for each key/value head
+	Value       []*linear.Model // one for each key/value head
+	OutputMerge *linear.Model
+}
+
+// Config provides configuration settings for a grouped-query attention Model.
+type Config struct {
+	Size               int // input and output vectors dimension
+	NumOfHeads         int // number of query heads
+	NumOfKeyValueHeads int // number of key/value heads; it must divide NumOfHeads
+	UseCausalMask      bool
+	// FlashAttentionBlockSize, if greater than zero, enables the memory-efficient attention.FlashAttention.
+	// In that case, the attention weights are not returned.
+	FlashAttentionBlockSize int
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new model with parameters initialized to zeros.
+func New(config Config) *Model {
+	if config.NumOfKeyValueHeads <= 0 || config.NumOfHeads%config.NumOfKeyValueHeads != 0 {
+		panic(fmt.Sprintf("groupedqueryattention: %d key/value heads are incompatible with %d heads",
+			config.NumOfKeyValueHeads, config.NumOfHeads))
+	}
+	dk := config.headSize()
+	return &Model{
+		Config:      config,
+		Query:       makeLinears(config.NumOfHeads, config.Size, dk),
+		Key:         makeLinears(config.NumOfKeyValueHeads, config.Size, dk),
+		Value:       makeLinears(config.NumOfKeyValueHeads, config.Size, dk),
+		OutputMerge: linear.New(dk*config.NumOfHeads, config.Size),
+	}
+}
+
+func makeLinears(n, in, out int) []*linear.Model {
+	ms := make([]*linear.Model, n)
+	for i := range ms {
+		ms[i] = linear.New(in, out)
+	}
+	return ms
+}
+
+// headSize returns the hidden vectors dimension (Size / NumOfHeads).
+func (c Config) headSize() int {
+	return c.Size / c.NumOfHeads
+}
+
+// groupSize returns the number of query heads sharing the same key/value head.
+func (c Config) groupSize() int {
+	return c.NumOfHeads / c.NumOfKeyValueHeads
+}
+
+// FromMultiHeadAttention converts a multi-head attention Model into a grouped-query attention Model
+// with the given number of key/value heads. The projections of each key/value head are initialized
+// with the mean of the projections of the heads of the original group, as described in the GQA paper.
+// The queries and the output projections are copied.
+func FromMultiHeadAttention(m *multiheadattention.Model, numOfKeyValueHeads int, useCausalMask bool) *Model {
+	gqa := New(Config{
+		Size:               m.Dm,
+		NumOfHeads:         m.NumOfHeads,
+		NumOfKeyValueHeads: numOfKeyValueHeads,
+		UseCausalMask:      useCausalMask,
+	})
+	for h, att := range m.Attention {
+		copyLinear(gqa.Query[h], att.Query)
+	}
+	groupSize := gqa.groupSize()
+	for g := range gqa.Key {
+		keys := make([]*linear.Model, groupSize)
+		values := make([]*linear.Model, groupSize)
+		for i := range keys {
+			keys[i] = m.Attention[g*groupSize+i].Key
+			values[i] = m.Attention[g*groupSize+i].Value
+		}
+		meanLinear(gqa.Key[g], keys)
+		meanLinear(gqa.Value[g], values)
+	}
+	copyLinear(gqa.OutputMerge, m.OutputMerge)
+	return gqa
+}
+
+func copyLinear(dst, src *linear.Model) {
+	dst.W.Value().SetData(src.W.Value().Data())
+	dst.B.Value().SetData(src.B.Value().Data())
+}
+
+func meanLinear(dst *linear.Model, srcs []*linear.Model) {
+	n := mat.Float(len(srcs))
+	for _, src := range srcs {
+		dst.W.Value().AddInPlace(src.W.Value())
+		dst.B.Value().AddInPlace(src.B.Value())
+	}
+	dst.W.Value().ProdScalarInPlace(1 / n)
+	dst.B.Value().ProdScalarInPlace(1 / n)
+}
+
+// KeysValuesPairs contains the attention.KeysValuesPair for each key/value head.
+type KeysValuesPairs = []attention.KeysValuesPair
+
+// Output aggregates the multiple output of the grouped-query attention,
+// incl. attention scores and last projected keys and values for each key/value head.
+type Output struct {
+	// Result of the grouped-query attention.
+	AttOutput []ag.Node
+	// AttWeights attention scores for each query head.
+	AttWeights [][]mat.Matrix
+	// ProjKeysValues contains the attention.KeysValuesPair for each key/value head.
+	ProjKeysValues KeysValuesPairs
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *Model) Forward(qkv attention.QKV) Output {
+	return m.forward(qkv, nil)
+}
+
+// ForwardWithPastKeysValues performs the forward step for each input node and returns the result.
+// The past keys and values are expected to be the Output.ProjKeysValues of a previous step.
+func (m *Model) ForwardWithPastKeysValues(qkv attention.QKV, pastProjKeysValues KeysValuesPairs) Output {
+	return m.forward(qkv, pastProjKeysValues)
+}
+
+func (m *Model) forward(qkv attention.QKV, pastProjKeysValues KeysValuesPairs) Output {
+	projKeysValues := make(KeysValuesPairs, m.NumOfKeyValueHeads)
+	for g := range projKeysValues {
+		projKeysValues[g] = m.projectKeysValues(g, qkv, pastProjKeysValues)
+	}
+
+	scaleFactor := 1.0 / mat.Sqrt(mat.Float(m.headSize()))
+	groupSize := m.groupSize()
+	headsAttNodes := make([][]ag.Node, m.NumOfHeads)
+	headsAttWeights := make([][]mat.Matrix, m.NumOfHeads)
+	for h, query := range m.Query {
+		kv := projKeysValues[h/groupSize]
+		projAtt := attention.QKV{
+			Queries: query.Forward(qkv.Queries...),
+			Keys:    kv.Keys,
+			Values:  kv.Values,
+		}
+		if m.FlashAttentionBlockSize > 0 {
+			headsAttNodes[h] = attention.FlashAttention(
+				m.Graph(), projAtt, scaleFactor, m.UseCausalMask, m.FlashAttentionBlockSize)
+			continue
+		}
+		headsAttNodes[h], headsAttWeights[h] = attention.ScaledDotProductAttention(
+			m.Graph(), projAtt, scaleFactor, m.UseCausalMask)
+	}
+
+	concatHeads := make([]ag.Node, len(qkv.Queries))
+	for i := range concatHeads {
+		buf := make([]ag.Node, m.NumOfHeads)
+		for j := range buf {
+			buf[j] = headsAttNodes[j][i]
+		}
+		concatHeads[i] = m.Graph().Concat(buf...)
+	}
+
+	return Output{
+		AttOutput:      m.OutputMerge.Forward(concatHeads...),
+		AttWeights:     headsAttWeights,
+		ProjKeysValues: projKeysValues,
+	}
+}
+
+// projectKeysValues returns the projected keys and values of the g-th key/value head,
+// preceded by the past ones, if any.
+func (m *Model) projectKeysValues(g int, qkv attention.QKV, past KeysValuesPairs) attention.KeysValuesPair {
+	var kv attention.KeysValuesPair
+	if past != nil {
+		kv.Keys = append([]ag.Node{}, past[g].Keys...)     // this append is important
+		kv.Values = append([]ag.Node{}, past[g].Values...) // this append is important
+	}
+	if qkv.Keys != nil { // the qkv.Values shall not be null as well
+		kv.Keys = append(kv.Keys, m.Key[g].Forward(qkv.Keys...)...)
+		kv.Values = append(kv.Values, m.Value[g].Forward(qkv.Values...)...)
+	}
+	return kv
+}
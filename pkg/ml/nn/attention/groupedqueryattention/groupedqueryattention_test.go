@@ -0,0 +1,95 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package groupedqueryattention
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/multiheadattention"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	model := New(Config{Size: 8, NumOfHeads: 4, NumOfKeyValueHeads: 2})
+	assert.Len(t, model.Query, 4)
+	assert.Len(t, model.Key, 2)
+	assert.Len(t, model.Value, 2)
+
+	assert.Panics(t, func() {
+		New(Config{Size: 8, NumOfHeads: 4, NumOfKeyValueHeads: 3})
+	})
+}
+
+func TestModel_FromMultiHeadAttention(t *testing.T) {
+	mha := newTestMultiHeadAttention()
+	gqa := FromMultiHeadAttention(mha, mha.NumOfHeads, false)
+
+	xs := [][]mat.Float{
+		{-0.8, -0.9, -0.9, 1.0},
+		{0.8, -0.3, 0.5, 0.3},
+		{-0.2, 0.7, 0.2, 0.4},
+	}
+
+	g1 := ag.NewGraph()
+	expected := nn.Reify(nn.Context{Graph: g1, Mode: nn.Inference}, mha).(*multiheadattention.Model).
+		Forward(attention.ToQKV(newInput(g1, xs))).AttOutput
+
+	g2 := ag.NewGraph()
+	actual := nn.Reify(nn.Context{Graph: g2, Mode: nn.Inference}, gqa).(*Model).
+		Forward(attention.ToQKV(newInput(g2, xs))).AttOutput
+
+	for i := range expected {
+		assert.InDeltaSlice(t, expected[i].Value().Data(), actual[i].Value().Data(), 1.0e-6)
+	}
+}
+
+func TestModel_MultiQueryAttention(t *testing.T) {
+	mha := newTestMultiHeadAttention()
+	mqa := FromMultiHeadAttention(mha, 1, true)
+
+	for h, att := range mha.Attention {
+		assert.Equal(t, att.Query.W.Value().Data(), mqa.Query[h].W.Value().Data())
+	}
+	expectedKeyW := mha.Attention[0].Key.W.Value().Add(mha.Attention[1].Key.W.Value()).ProdScalar(0.5)
+	assert.InDeltaSlice(t, expectedKeyW.Data(), mqa.Key[0].W.Value().Data(), 1.0e-6)
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, mqa).(*Model)
+
+	xs := newInput(g, [][]mat.Float{{-0.8, -0.9, -0.9, 1.0}, {0.8, -0.3, 0.5, 0.3}})
+	out := proc.Forward(attention.ToQKV(xs))
+	assert.Len(t, out.AttOutput, 2)
+	assert.Len(t, out.ProjKeysValues, 1)
+	assert.Len(t, out.ProjKeysValues[0].Keys, 2)
+
+	next := newInput(g, [][]mat.Float{{-0.2, 0.7, 0.2, 0.4}})
+	out = proc.ForwardWithPastKeysValues(attention.ToQKV(next), out.ProjKeysValues)
+	assert.Len(t, out.AttOutput, 1)
+	assert.Len(t, out.ProjKeysValues, 1)
+	assert.Len(t, out.ProjKeysValues[0].Keys, 3)
+	assert.Len(t, out.ProjKeysValues[0].Values, 3)
+}
+
+func newInput(g *ag.Graph, xs [][]mat.Float) []ag.Node {
+	nodes := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		nodes[i] = g.NewVariable(mat.NewVecDense(x), false)
+	}
+	return nodes
+}
+
+func newTestMultiHeadAttention() *multiheadattention.Model {
+	model := multiheadattention.New(4, 2, false)
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		initializers.XavierUniform(param.Value(), 1.0, rndGen)
+	})
+	return model
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package groupedqueryattention
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+)
+
+// HuggingFaceConfig contains the attention settings of the Hugging Face configuration (config.json)
+// of a model with grouped-query attention, such as Llama or Mistral.
+//
+// The BERT and BART converters do not handle these models: their architectures only have the
+// multi-head attention, so they refuse the checkpoints with fewer key/value heads than heads.
+type HuggingFaceConfig struct {
+	HiddenSize        int `json:"hidden_size"`
+	NumAttentionHeads int `json:"num_attention_heads"`
+	NumKeyValueHeads  int `json:"num_key_value_heads"`
+}
+
+// Config returns the Config of the attention. Without num_key_value_heads, as in the checkpoints
+// of the models with the multi-head attention, each head has its own key/value head.
+func (c HuggingFaceConfig) Config(useCausalMask bool) Config {
+	numOfKeyValueHeads := c.NumKeyValueHeads
+	if numOfKeyValueHeads == 0 {
+		numOfKeyValueHeads = c.NumAttentionHeads
+	}
+	return Config{
+		Size:               c.HiddenSize,
+		NumOfHeads:         c.NumAttentionHeads,
+		NumOfKeyValueHeads: numOfKeyValueHeads,
+		UseCausalMask:      useCausalMask,
+	}
+}
+
+// LoadHuggingFaceParams sets the params of the model from the Hugging Face params of an attention
+// layer, by name: <prefix>.q_proj, <prefix>.k_proj, <prefix>.v_proj and <prefix>.o_proj, each with
+// its weight and optional bias (zero if missing).
+// The query, key and value projections are those of all the heads stacked by rows, which are split
+// among the heads; the key and value ones have NumOfKeyValueHeads heads.
+func (m *Model) LoadHuggingFaceParams(params map[string][]mat.Float, prefix string) error {
+	projections := []struct {
+		name  string
+		heads []*linear.Model
+	}{
+		{name: "q_proj", heads: m.Query},
+		{name: "k_proj", heads: m.Key},
+		{name: "v_proj", heads: m.Value},
+		{name: "o_proj", heads: []*linear.Model{m.OutputMerge}},
+	}
+	for _, p := range projections {
+		name := fmt.Sprintf("%s.%s", prefix, p.name)
+		if err := loadStacked(p.heads, params, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadStacked splits the weight and the bias of a projection, by rows, among the linear models.
+func loadStacked(heads []*linear.Model, params map[string][]mat.Float, name string) error {
+	rows, cols := heads[0].W.Value().Dims()
+	weight, ok := params[name+".weight"]
+	if !ok {
+		return fmt.Errorf("groupedqueryattention: param %#v not found", name+".weight")
+	}
+	if len(weight) != len(heads)*rows*cols {
+		return fmt.Errorf("groupedqueryattention: param %#v has size %d, expected %d (%d heads of %dx%d)",
+			name+".weight", len(weight), len(heads)*rows*cols, len(heads), rows, cols)
+	}
+	bias, hasBias := params[name+".bias"]
+	if hasBias && len(bias) != len(heads)*rows {
+		return fmt.Errorf("groupedqueryattention: param %#v has size %d, expected %d",
+			name+".bias", len(bias), len(heads)*rows)
+	}
+	for h, head := range heads {
+		head.W.Value().SetData(weight[h*rows*cols : (h+1)*rows*cols])
+		if hasBias {
+			head.B.Value().SetData(bias[h*rows : (h+1)*rows])
+		} else {
+			head.B.Value().Zeros()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package groupedqueryattention
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHuggingFaceConfig_Config(t *testing.T) {
+	config := HuggingFaceConfig{HiddenSize: 8, NumAttentionHeads: 4, NumKeyValueHeads: 2}.Config(true)
+	assert.Equal(t, Config{Size: 8, NumOfHeads: 4, NumOfKeyValueHeads: 2, UseCausalMask: true}, config)
+
+	config = HuggingFaceConfig{HiddenSize: 8, NumAttentionHeads: 4}.Config(false)
+	assert.Equal(t, 4, config.NumOfKeyValueHeads)
+}
+
+func TestModel_LoadHuggingFaceParams(t *testing.T) {
+	// 4 query heads and 2 key/value heads of size 1
+	model := New(Config{Size: 4, NumOfHeads: 4, NumOfKeyValueHeads: 2})
+	params := map[string][]mat.Float{
+		"attn.q_proj.weight": seq(16),
+		"attn.q_proj.bias":   seq(4),
+		"attn.k_proj.weight": seq(8),
+		"attn.v_proj.weight": seq(8),
+		"attn.v_proj.bias":   seq(2),
+		"attn.o_proj.weight": seq(16),
+	}
+	assert.NoError(t, model.LoadHuggingFaceParams(params, "attn"))
+
+	assert.Equal(t, []mat.Float{4, 5, 6, 7}, model.Query[1].W.Value().Data())
+	assert.Equal(t, []mat.Float{3}, model.Query[3].B.Value().Data())
+	assert.Equal(t, []mat.Float{4, 5, 6, 7}, model.Key[1].W.Value().Data())
+	assert.Equal(t, []mat.Float{0}, model.Key[1].B.Value().Data())
+	assert.Equal(t, []mat.Float{1}, model.Value[1].B.Value().Data())
+	assert.Equal(t, seq(16), model.OutputMerge.W.Value().Data())
+
+	params["attn.k_proj.weight"] = seq(16) // the key/value heads of the multi-head attention
+	assert.Error(t, model.LoadHuggingFaceParams(params, "attn"))
+	delete(params, "attn.k_proj.weight")
+	assert.Error(t, model.LoadHuggingFaceParams(params, "attn"))
+}
+
+func seq(n int) []mat.Float {
+	xs := make([]mat.Float, n)
+	for i := range xs {
+		xs[i] = mat.Float(i)
+	}
+	return xs
+}
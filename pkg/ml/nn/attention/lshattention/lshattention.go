@@ -88,10 +88,8 @@ func (m *Model) lshScaledDotProductAttention(
 	prob = mat.NewEmptyVecDense(length)
 	keys := g.Stack(ks.node...)
 	values := g.T(g.Stack(vs.node...))
-	factor := g.NewScalar(scaleFactor)
 
-	attScores := g.ProdScalar(g.Mul(keys, q), factor)
-	attProb := g.Softmax(attScores)
+	attProb := g.ScaledSoftmax(g.Mul(keys, q), scaleFactor, nil)
 	context = g.Mul(values, attProb)
 
 	probData := prob.Data()
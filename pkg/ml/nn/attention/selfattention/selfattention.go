@@ -14,7 +14,8 @@ import (
 )
 
 var (
-	_ nn.Model = &Model{}
+	_ nn.Model    = &Model{}
+	_ nn.Stateful = &Model{}
 )
 
 // Model contains the serializable parameters.
@@ -24,6 +25,8 @@ type Model struct {
 	Query *linear.Model
 	Key   *linear.Model
 	Value *linear.Model
+	// Cache contains the projected keys and values of the past inputs of ForwardIncremental.
+	Cache attention.KeysValuesPair `spago:"scope:processor"`
 }
 
 // Config provides configuration settings for a Self-Attention Model.
@@ -34,6 +37,10 @@ type Config struct {
 	ValueSize     int
 	ScaleFactor   mat.Float
 	UseCausalMask bool
+	// FlashAttentionBlockSize, if greater than zero, enables the memory-efficient
+	// attention.FlashAttention processing keys and values in blocks of this size.
+	// In that case, the attention weights are not returned.
+	FlashAttentionBlockSize int
 }
 
 func init() {
@@ -58,7 +65,7 @@ func (m *Model) Forward(qkv attention.QKV) attention.Output {
 		Keys:    m.Key.Forward(qkv.Keys...),
 		Values:  m.Value.Forward(qkv.Values...),
 	}
-	attOutput, attWeights := attention.ScaledDotProductAttention(m.Graph(), projAtt, m.ScaleFactor, m.UseCausalMask)
+	attOutput, attWeights := m.attention(projAtt)
 
 	return attention.Output{
 		AttOutput:  attOutput,
@@ -84,7 +91,7 @@ func (m *Model) ForwardWithPastKeysValues(qkv attention.QKV, past attention.Keys
 		projAtt.Values = append(projAtt.Values, m.Value.Forward(qkv.Values...)...)
 	}
 
-	attOutput, attWeights := attention.ScaledDotProductAttention(m.Graph(), projAtt, m.ScaleFactor, m.UseCausalMask)
+	attOutput, attWeights := m.attention(projAtt)
 
 	return attention.Output{
 		AttOutput:  attOutput,
@@ -95,3 +102,37 @@ func (m *Model) ForwardWithPastKeysValues(qkv attention.QKV, past attention.Keys
 		},
 	}
 }
+
+// ForwardIncremental performs the forward step for the next input nodes of a stream, attending to the
+// keys and values of the past inputs as well, which are kept in the cache.
+// It generates the queries, keys and values from the same input xs.
+func (m *Model) ForwardIncremental(xs ...ag.Node) attention.Output {
+	output := m.ForwardWithPastKeysValues(attention.ToQKV(xs), m.Cache)
+	m.Cache = output.ProjKeysValues
+	return output
+}
+
+// State returns the cached keys followed by the cached values (see nn.Stateful).
+func (m *Model) State() []ag.Node {
+	state := make([]ag.Node, 0, len(m.Cache.Keys)+len(m.Cache.Values))
+	state = append(state, m.Cache.Keys...)
+	return append(state, m.Cache.Values...)
+}
+
+// SetState replaces the cache with the given keys and values, as returned by State (see nn.Stateful).
+func (m *Model) SetState(state []ag.Node) {
+	n := len(state) / 2
+	m.Cache = attention.KeysValuesPair{
+		Keys:   state[:n:n],
+		Values: state[n:],
+	}
+}
+
+// attention computes the scaled dot-product attention, using the memory-efficient
+// attention.FlashAttention if a block size is configured.
+func (m *Model) attention(qkv attention.QKV) ([]ag.Node, []mat.Matrix) {
+	if m.FlashAttentionBlockSize > 0 {
+		return attention.FlashAttention(m.Graph(), qkv, m.ScaleFactor, m.UseCausalMask, m.FlashAttentionBlockSize), nil
+	}
+	return attention.ScaledDotProductAttention(m.Graph(), qkv, m.ScaleFactor, m.UseCausalMask)
+}
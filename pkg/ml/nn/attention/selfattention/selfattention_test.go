@@ -64,6 +64,34 @@ func TestModel_SelfAttention(t *testing.T) {
 	}, model.Query.B.Grad().Data(), 1.0e-05)
 }
 
+func TestModel_SelfAttentionWithFlashAttention(t *testing.T) {
+	model := newTestModel()
+	model.FlashAttentionBlockSize = 2
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+
+	x1 := g.NewVariable(mat.NewVecDense([]mat.Float{-0.8, -0.9, -0.9, 1.0}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]mat.Float{0.8, -0.3, 0.5, 0.3}), true)
+	x3 := g.NewVariable(mat.NewVecDense([]mat.Float{-0.2, 0.7, 0.2, 0.4}), true)
+
+	output := proc.Forward(attention.ToQKV([]ag.Node{x1, x2, x3}))
+
+	assert.Nil(t, output.AttWeights)
+	assert.InDeltaSlice(t, []mat.Float{0.789110, -0.755551, -0.431247}, output.AttOutput[0].Value().Data(), 1.0e-05)
+	assert.InDeltaSlice(t, []mat.Float{0.780654, -0.6212001, -0.380214}, output.AttOutput[1].Value().Data(), 1.0e-05)
+	assert.InDeltaSlice(t, []mat.Float{0.7586521, -0.569575, -0.390976}, output.AttOutput[2].Value().Data(), 1.0e-05)
+
+	output.AttOutput[0].PropagateGrad(mat.NewVecDense([]mat.Float{-0.04, 0.36, 0.32}))
+	output.AttOutput[1].PropagateGrad(mat.NewVecDense([]mat.Float{-0.08, -0.2, -0.1}))
+	output.AttOutput[2].PropagateGrad(mat.NewVecDense([]mat.Float{0.1, 0.3, 0.8}))
+
+	g.BackwardAll()
+
+	assert.InDeltaSlice(t, []mat.Float{0.01654154, 0.48942297, -0.1587743, -0.2387454}, x1.Grad().Data(), 1.0e-05)
+	assert.InDeltaSlice(t, []mat.Float{0.04408108, 0.18716132, -0.15425818, -0.040870}, x2.Grad().Data(), 1.0e-05)
+	assert.InDeltaSlice(t, []mat.Float{0.05800791, 0.205784865, -0.2431444, -0.1281430}, x3.Grad().Data(), 1.0e-05)
+}
+
 func newTestModel() *Model {
 	model := New(Config{
 		InputSize:   4,
@@ -92,3 +120,45 @@ func newTestModel() *Model {
 	model.Query.B.Value().SetData([]mat.Float{0.3, 0.5, -0.7})
 	return model
 }
+
+func TestModel_ForwardIncremental(t *testing.T) {
+	inputs := [][]mat.Float{
+		{-0.8, -0.9, -0.9, 1.0},
+		{0.8, -0.3, 0.5, 0.3},
+		{-0.2, 0.7, 0.2, 0.4},
+	}
+	for _, blockSize := range []int{0, 2} {
+		model := newTestModel()
+		model.UseCausalMask = true
+		model.FlashAttentionBlockSize = blockSize
+
+		g := ag.NewGraph()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+		xs := make([]ag.Node, len(inputs))
+		for i, input := range inputs {
+			xs[i] = g.NewVariable(mat.NewVecDense(input), false)
+		}
+		expected := proc.Forward(attention.ToQKV(xs)).AttOutput
+
+		session := nn.NewSession(model, nn.Inference)
+		var actual []mat.Matrix
+		for _, chunk := range [][][]mat.Float{inputs[:1], inputs[1:]} {
+			session.Step(func(proc nn.Stateful) {
+				g := proc.Graph()
+				xs := make([]ag.Node, len(chunk))
+				for i, input := range chunk {
+					xs[i] = g.NewVariable(mat.NewVecDense(input), false)
+				}
+				for _, y := range proc.(*Model).ForwardIncremental(xs...).AttOutput {
+					actual = append(actual, g.GetCopiedValue(y))
+				}
+			})
+		}
+		assert.Len(t, session.Processor().State(), 6)
+
+		for i := range expected {
+			assert.InDeltaSlice(t, expected[i].Value().Data(), actual[i].Data(), 1.0e-06)
+		}
+		session.Close()
+	}
+}
@@ -43,3 +43,15 @@ func (m *BaseModel) IsProcessor() bool {
 // InitProcessor is used to initialize structures and data useful for the Forward().
 // nn.Reify() automatically invokes InitProcessor() for any sub-models.
 func (m *BaseModel) InitProcessor() {}
+
+// WrapParam returns a node of the param in the graph of the (reified) model. It is meant for the
+// params the reifier does not wrap, like the ones of the fields with scope "model" (e.g. the
+// vectors of a lookup table, wrapped on demand): as for the reified params, their gradients are
+// accumulated in the Context.Gradients buffer, if any, in place of the param.
+func (m *BaseModel) WrapParam(p Param) ag.Node {
+	g := m.Graph()
+	if r, ok := p.(*param); ok && m.Ctx.Mode == Training && m.Ctx.Gradients != nil && r.requiresGrad {
+		return g.NewWrap(m.Ctx.Gradients.get(r))
+	}
+	return g.NewWrap(p)
+}
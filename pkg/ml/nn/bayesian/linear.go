@@ -0,0 +1,156 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bayesian provides the means to quantify the uncertainty of the predictions: a linear
+// layer with a Gaussian posterior over its weights, trained by variational inference with the
+// reparameterization trick ("Bayes by Backprop"), and the Monte Carlo estimate of the predictive
+// distribution of a classifier, which also applies to the models with dropout ("MC dropout").
+//
+// Reference: "Weight Uncertainty in Neural Networks" by Blundell et al., 2015
+// (https://arxiv.org/abs/1505.05424); "Dropout as a Bayesian Approximation: Representing Model
+// Uncertainty in Deep Learning" by Gal and Ghahramani, 2016 (https://arxiv.org/abs/1506.02142).
+package bayesian
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.StandardModel = &Linear{}
+)
+
+// softPlusThreshold is the threshold above which the softplus is linear.
+const softPlusThreshold mat.Float = 20
+
+// LinearConfig provides configuration settings for a Bayesian Linear model.
+type LinearConfig struct {
+	InputSize  int
+	OutputSize int
+	// PriorStd is the standard deviation of the zero-mean Gaussian prior of the weights and biases.
+	PriorStd mat.Float
+	// InitStd is the initial standard deviation of the posterior of the weights and biases.
+	InitStd mat.Float
+}
+
+// DefaultLinearConfig returns a LinearConfig with a standard normal prior and a small initial
+// standard deviation of the posterior.
+func DefaultLinearConfig(inputSize, outputSize int) LinearConfig {
+	return LinearConfig{
+		InputSize:  inputSize,
+		OutputSize: outputSize,
+		PriorStd:   1,
+		InitStd:    0.01,
+	}
+}
+
+// Linear is a linear layer whose weights and biases have a factorized Gaussian posterior: each
+// one has a mean (WMu, BMu) and a standard deviation, parameterized as softplus(WRho) and
+// softplus(BRho) so that it is always positive.
+//
+// In Training mode, the weights are sampled once per processor, that is once for each graph, as
+// the mean plus the standard deviation times a standard normal noise, so that the gradients flow
+// into both. In Inference mode, the means are used.
+type Linear struct {
+	nn.BaseModel
+	Config LinearConfig
+	WMu    nn.Param `spago:"type:weights"`
+	WRho   nn.Param `spago:"type:weights"`
+	BMu    nn.Param `spago:"type:biases"`
+	BRho   nn.Param `spago:"type:biases"`
+	// w and b are the weights and biases sampled at the first forward of the processor.
+	w, b ag.Node
+}
+
+func init() {
+	gob.Register(&Linear{})
+}
+
+// NewLinear returns a new Bayesian Linear model, with the means initialized to zeros and the
+// standard deviations to Config.InitStd.
+func NewLinear(config LinearConfig) *Linear {
+	if config.PriorStd <= 0 || config.InitStd <= 0 {
+		panic("bayesian: the standard deviations must be positive")
+	}
+	rho := inverseSoftPlus(config.InitStd)
+	wRho := mat.NewEmptyDense(config.OutputSize, config.InputSize)
+	bRho := mat.NewEmptyVecDense(config.OutputSize)
+	initializers.Constant(wRho, rho)
+	initializers.Constant(bRho, rho)
+	return &Linear{
+		Config: config,
+		WMu:    nn.NewParam(mat.NewEmptyDense(config.OutputSize, config.InputSize)),
+		WRho:   nn.NewParam(wRho),
+		BMu:    nn.NewParam(mat.NewEmptyVecDense(config.OutputSize)),
+		BRho:   nn.NewParam(bRho),
+	}
+}
+
+// inverseSoftPlus returns the x such that softplus(x) = y.
+func inverseSoftPlus(y mat.Float) mat.Float {
+	return mat.Log(mat.Exp(y) - 1)
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *Linear) Forward(xs ...ag.Node) []ag.Node {
+	w, b := m.weights()
+	g := m.Graph()
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		ys[i] = g.Add(g.Mul(w, x), b)
+	}
+	return ys
+}
+
+// weights returns the weights and the biases to use in the forward: the means in Inference mode,
+// a sample of the posterior in Training mode.
+func (m *Linear) weights() (w, b ag.Node) {
+	if m.Mode() != nn.Training {
+		return m.WMu, m.BMu
+	}
+	if m.w == nil {
+		m.w = m.sample(m.WMu, m.WRho)
+		m.b = m.sample(m.BMu, m.BRho)
+	}
+	return m.w, m.b
+}
+
+// sample returns mu + softplus(rho) * eps, with eps drawn from the standard normal distribution
+// by the random generator of the graph.
+func (m *Linear) sample(mu, rho nn.Param) ag.Node {
+	g := m.Graph()
+	eps := mat.NewEmptyDense(mu.Value().Dims())
+	initializers.Normal(eps, 0, 1, g.Rand())
+	return g.Add(mu, g.Prod(m.std(rho), g.NewVariable(eps, false)))
+}
+
+// std returns softplus(rho).
+func (m *Linear) std(rho ag.Node) ag.Node {
+	g := m.Graph()
+	return g.SoftPlus(rho, g.Constant(1), g.Constant(softPlusThreshold))
+}
+
+// KL returns the Kullback-Leibler divergence of the posterior of the weights and biases from
+// their prior. The variational loss of a dataset is the sum of the negative log-likelihood of
+// the examples and of the KL of all the Bayesian layers; dividing the KL by the number of
+// examples gives the loss of each example.
+func (m *Linear) KL() ag.Node {
+	g := m.Graph()
+	return g.Add(m.kl(m.WMu, m.WRho), m.kl(m.BMu, m.BRho))
+}
+
+// kl returns the sum of log(p/s) + (s^2 + mu^2) / (2p^2) - 1/2, the divergence of N(mu, s^2)
+// from N(0, p^2) for each element.
+func (m *Linear) kl(mu, rho ag.Node) ag.Node {
+	g := m.Graph()
+	prior := m.Config.PriorStd
+	std := m.std(rho)
+	ratio := g.ProdScalar(g.Add(g.Square(std), g.Square(mu)), g.Constant(1/(2*prior*prior)))
+	y := g.Sub(ratio, g.Log(std))
+	n := mat.Float(mu.Value().Size())
+	return g.AddScalar(g.ReduceSum(y), g.Constant(n*(mat.Log(prior)-0.5)))
+}
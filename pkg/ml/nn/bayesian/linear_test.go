@@ -0,0 +1,100 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bayesian
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestLinear() *Linear {
+	m := NewLinear(DefaultLinearConfig(3, 2))
+	initializers.Uniform(m.WMu.Value(), -0.5, 0.5, rand.NewLockedRand(42))
+	m.BMu.Value().SetData([]mat.Float{0.1, -0.1})
+	return m
+}
+
+var testInput = mat.NewVecDense([]mat.Float{0.5, -1, 2})
+
+func TestNewLinear(t *testing.T) {
+	m := NewLinear(DefaultLinearConfig(3, 2))
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, m).(*Linear)
+	std := proc.std(proc.WRho).Value().Data()
+	for _, s := range std {
+		assert.InDelta(t, 0.01, s, 1.0e-6)
+	}
+	assert.Panics(t, func() { NewLinear(LinearConfig{InputSize: 1, OutputSize: 1, InitStd: 0.1}) })
+}
+
+func TestLinear_Forward(t *testing.T) {
+	m := newTestLinear()
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, m).(*Linear)
+	y := proc.Forward(g.NewVariable(testInput, false))[0]
+	expected := m.WMu.Value().Mul(testInput).Add(m.BMu.Value())
+	assert.InDeltaSlice(t, expected.Data(), y.Value().Data(), 1.0e-6)
+
+	// in training mode the weights are sampled once per processor
+	g = ag.NewGraph(ag.Rand(rand.NewLockedRand(1)))
+	proc = nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*Linear)
+	y1 := proc.Forward(g.NewVariable(testInput, false))[0]
+	y2 := proc.Forward(g.NewVariable(testInput, false))[0]
+	assert.Equal(t, y1.Value().Data(), y2.Value().Data())
+	assert.NotEqual(t, expected.Data(), y1.Value().Data())
+	assert.InDeltaSlice(t, expected.Data(), y1.Value().Data(), 0.2)
+
+	g = ag.NewGraph(ag.Rand(rand.NewLockedRand(2)))
+	proc = nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*Linear)
+	y3 := proc.Forward(g.NewVariable(testInput, false))[0]
+	assert.NotEqual(t, y1.Value().Data(), y3.Value().Data())
+}
+
+func TestLinear_KL(t *testing.T) {
+	m := NewLinear(LinearConfig{InputSize: 1, OutputSize: 1, PriorStd: 2, InitStd: 0.5})
+	m.WMu.Value().SetData([]mat.Float{1})
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*Linear)
+	// log(2/0.5) + (0.25 + 1) / 8 - 0.5 for the weight, log(2/0.5) + 0.25 / 8 - 0.5 for the bias
+	expected := 2*mat.Log(4) + 1.25/8 + 0.25/8 - 1
+	assert.InDelta(t, expected, proc.KL().ScalarValue(), 1.0e-5)
+
+	// the posterior equal to the prior has no divergence
+	m = NewLinear(LinearConfig{InputSize: 2, OutputSize: 2, PriorStd: 1, InitStd: 1})
+	proc = nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*Linear)
+	assert.InDelta(t, 0, proc.KL().ScalarValue(), 1.0e-5)
+}
+
+func TestLinear_Training(t *testing.T) {
+	m := newTestLinear()
+	optimizer := gd.NewOptimizer(adam.New(adam.NewConfig(0.05, 0.9, 0.999, 1.0e-8)), nn.NewDefaultParamsIterator(m))
+	generator := rand.NewLockedRand(3)
+	x := mat.NewVecDense([]mat.Float{1, 0, -1})
+
+	var first, last mat.Float
+	for i := 0; i < 100; i++ {
+		g := ag.NewGraph(ag.Rand(generator))
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*Linear)
+		y := proc.Forward(g.NewVariable(x, false))[0]
+		loss := g.Add(losses.CrossEntropy(g, y, 0), g.DivScalar(proc.KL(), g.Constant(100)))
+		g.Backward(loss)
+		if i == 0 {
+			first = loss.ScalarValue()
+			assert.NotNil(t, m.WRho.Grad())
+		}
+		optimizer.Optimize()
+		last = loss.ScalarValue()
+	}
+	assert.Less(t, last, first)
+}
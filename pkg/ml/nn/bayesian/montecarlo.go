@@ -0,0 +1,123 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bayesian
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"runtime"
+	"sort"
+)
+
+// Prediction is the predictive distribution of a classifier, estimated over several stochastic
+// forward passes.
+type Prediction struct {
+	// Mean and Variance are the mean and the variance of the probability of each class.
+	Mean     []mat.Float
+	Variance []mat.Float
+	// Entropy is the entropy of the mean probabilities, that is the total uncertainty of the
+	// prediction.
+	Entropy mat.Float
+	// MutualInformation is the part of the Entropy due to the uncertainty of the model about its
+	// weights (epistemic), rather than to the ambiguity of the input: the Entropy minus the mean
+	// entropy of the samples (BALD).
+	MutualInformation mat.Float
+}
+
+// ClassScore associates the mean probability of a class with its variance.
+type ClassScore struct {
+	Class      string    `json:"class"`
+	Confidence mat.Float `json:"confidence"`
+	Variance   mat.Float `json:"variance"`
+}
+
+// MonteCarlo returns the Prediction of a classifier over the given number of samples. For each
+// sample, the forward function reifies the model with the given context and returns the logits
+// of the classes. Each sample runs on a new graph with the given random generator, in Training
+// mode, so that the dropout layers drop their inputs (MC dropout) and the Bayesian layers sample
+// their weights; no gradient is computed. The layers that update their statistics in Training
+// mode (e.g. batchnorm) are not meant to be used with it.
+func MonteCarlo(samples int, generator *rand.LockedRand, forward func(ctx nn.Context) ag.Node) *Prediction {
+	if samples < 1 {
+		panic("bayesian: the number of samples must be positive")
+	}
+	probs := make([][]mat.Float, samples)
+	for i := range probs {
+		probs[i] = sampleProbabilities(generator, forward)
+	}
+	return NewPrediction(probs)
+}
+
+// sampleProbabilities returns the probabilities of the classes of a stochastic forward pass.
+func sampleProbabilities(generator *rand.LockedRand, forward func(ctx nn.Context) ag.Node) []mat.Float {
+	g := ag.NewGraph(ag.Rand(generator), ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	logits := forward(nn.Context{Graph: g, Mode: nn.Training})
+	return floatutils.SoftMax(logits.Value().Data())
+}
+
+// NewPrediction returns the Prediction of the probabilities of the classes of each sample.
+func NewPrediction(probs [][]mat.Float) *Prediction {
+	n := mat.Float(len(probs))
+	numClasses := len(probs[0])
+	p := &Prediction{
+		Mean:     make([]mat.Float, numClasses),
+		Variance: make([]mat.Float, numClasses),
+	}
+	var sampleEntropy mat.Float
+	for _, sample := range probs {
+		for c, v := range sample {
+			p.Mean[c] += v / n
+		}
+		sampleEntropy += entropy(sample) / n
+	}
+	for _, sample := range probs {
+		for c, v := range sample {
+			p.Variance[c] += (v - p.Mean[c]) * (v - p.Mean[c]) / n
+		}
+	}
+	p.Entropy = entropy(p.Mean)
+	p.MutualInformation = mat.Max(0, p.Entropy-sampleEntropy)
+	return p
+}
+
+// entropy returns the entropy of the probabilities, in nats.
+func entropy(probs []mat.Float) mat.Float {
+	var h mat.Float
+	for _, p := range probs {
+		if p > 0 {
+			h -= p * mat.Log(p)
+		}
+	}
+	return h
+}
+
+// Best returns the index of the class with the highest mean probability.
+func (p *Prediction) Best() int {
+	return floatutils.ArgMax(p.Mean)
+}
+
+// Distribution returns the scores of the classes, with the given labels, by decreasing mean
+// probability.
+func (p *Prediction) Distribution(labels []string) []ClassScore {
+	if len(labels) != len(p.Mean) {
+		panic("bayesian: the number of labels and classes must be the same")
+	}
+	scores := make([]ClassScore, len(labels))
+	for i, label := range labels {
+		scores[i] = ClassScore{
+			Class:      label,
+			Confidence: p.Mean[i],
+			Variance:   p.Variance[i],
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Confidence > scores[j].Confidence
+	})
+	return scores
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bayesian
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/dropout"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMonteCarlo(t *testing.T) {
+	bayesian := newTestLinear()
+	bayesian.Config.InitStd = 0.5
+	initializers.Constant(bayesian.WRho.Value(), inverseSoftPlus(0.5))
+	forward := func(ctx nn.Context) ag.Node {
+		proc := nn.Reify(ctx, bayesian).(*Linear)
+		return proc.Forward(ctx.Graph.NewVariable(testInput, false))[0]
+	}
+	p := MonteCarlo(50, rand.NewLockedRand(1), forward)
+	assert.InDelta(t, 1, p.Mean[0]+p.Mean[1], 1.0e-5)
+	assert.Greater(t, p.Variance[0], mat.Float(0))
+	assert.InDelta(t, p.Variance[0], p.Variance[1], 1.0e-5)
+	assert.Greater(t, p.MutualInformation, mat.Float(0))
+	assert.LessOrEqual(t, p.MutualInformation, p.Entropy)
+
+	// the same generator gives the same prediction
+	assert.Equal(t, p, MonteCarlo(50, rand.NewLockedRand(1), forward))
+
+	assert.Panics(t, func() { MonteCarlo(0, rand.NewLockedRand(1), forward) })
+}
+
+func TestMonteCarlo_Dropout(t *testing.T) {
+	l := linear.New(3, 2)
+	initializers.Uniform(l.W.Value(), -1, 1, rand.NewLockedRand(42))
+	model := stack.New(dropout.New(0.5), l)
+	forward := func(ctx nn.Context) ag.Node {
+		proc := nn.Reify(ctx, model).(*stack.Model)
+		return proc.Forward(ctx.Graph.NewVariable(testInput, false))[0]
+	}
+	p := MonteCarlo(20, rand.NewLockedRand(1), forward)
+	assert.Greater(t, p.Variance[0], mat.Float(0))
+
+	deterministic := MonteCarlo(5, rand.NewLockedRand(1), func(ctx nn.Context) ag.Node {
+		proc := nn.Reify(ctx, l).(*linear.Model)
+		return proc.Forward(ctx.Graph.NewVariable(testInput, false))[0]
+	})
+	assert.Equal(t, []mat.Float{0, 0}, deterministic.Variance)
+	assert.InDelta(t, 0, deterministic.MutualInformation, 1.0e-6)
+}
+
+func TestNewPrediction(t *testing.T) {
+	p := NewPrediction([][]mat.Float{{0.9, 0.1}, {0.5, 0.5}})
+	assert.InDeltaSlice(t, []mat.Float{0.7, 0.3}, p.Mean, 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.04, 0.04}, p.Variance, 1.0e-6)
+	expectedEntropy := -(0.7*mat.Log(0.7) + 0.3*mat.Log(0.3))
+	assert.InDelta(t, expectedEntropy, p.Entropy, 1.0e-6)
+	sampleEntropy := (-(0.9*mat.Log(0.9) + 0.1*mat.Log(0.1)) + mat.Log(2)) / 2
+	assert.InDelta(t, expectedEntropy-sampleEntropy, p.MutualInformation, 1.0e-6)
+	assert.Equal(t, 0, p.Best())
+
+	assert.Equal(t, []ClassScore{
+		{Class: "pos", Confidence: p.Mean[0], Variance: p.Variance[0]},
+		{Class: "neg", Confidence: p.Mean[1], Variance: p.Variance[1]},
+	}, p.Distribution([]string{"pos", "neg"}))
+	assert.Panics(t, func() { p.Distribution([]string{"pos"}) })
+}
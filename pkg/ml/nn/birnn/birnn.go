@@ -55,17 +55,22 @@ func New(positive, negative nn.StandardModel, merge MergeType) *Model {
 func (m *Model) Forward(xs ...ag.Node) []ag.Node {
 	var pos []ag.Node
 	var neg []ag.Node
-	var wg sync.WaitGroup
-	wg.Add(2)
-	go func() {
-		defer wg.Done()
+	if m.Graph().ConcurrentComputations() > 1 {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			pos = m.Positive.Forward(xs...)
+		}()
+		go func() {
+			defer wg.Done()
+			neg = m.Negative.Forward(reversed(xs)...)
+		}()
+		wg.Wait()
+	} else {
 		pos = m.Positive.Forward(xs...)
-	}()
-	go func() {
-		defer wg.Done()
 		neg = m.Negative.Forward(reversed(xs)...)
-	}()
-	wg.Wait()
+	}
 	out := make([]ag.Node, len(pos))
 	for i := range out {
 		out[i] = m.merge(pos[i], neg[len(out)-1-i])
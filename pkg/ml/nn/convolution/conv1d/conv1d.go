@@ -0,0 +1,115 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package conv1d implements a one-dimensional convolution layer over a sequence
+// of vectors, with support for stride, dilation, zero-padding and causal padding.
+package conv1d
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.StandardModel = &Model{}
+)
+
+// Config provides configuration settings for a Conv1D Model.
+// Zero values of stride and dilation are interpreted as 1.
+type Config struct {
+	InputChannels  int
+	OutputChannels int
+	KernelSize     int
+	Stride         int
+	Dilation       int
+	// Padding is the number of zeros added on both sides of the sequence.
+	// It is ignored if Causal is true.
+	Padding int
+	// Causal reports whether the sequence is left-padded so that each output
+	// element depends only on the current and previous input elements, and the
+	// output sequence has the same length as the input (with stride 1).
+	Causal  bool
+	UseBias bool
+}
+
+// Model contains the serializable parameters for a one-dimensional convolution.
+type Model struct {
+	nn.BaseModel
+	Config Config
+	// W contains a row for each output channel, with the kernels of all the input channels.
+	W nn.Param `spago:"type:weights"`
+	// B contains the bias of each output channel. It is used only if Config.UseBias is true.
+	B nn.Param `spago:"type:biases"`
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new convolution Model, initialized according to the given configuration.
+func New(config Config) *Model {
+	if config.Stride == 0 {
+		config.Stride = 1
+	}
+	if config.Dilation == 0 {
+		config.Dilation = 1
+	}
+	return &Model{
+		Config: config,
+		W:      nn.NewParam(mat.NewEmptyDense(config.OutputChannels, config.InputChannels*config.KernelSize)),
+		B:      nn.NewParam(mat.NewEmptyVecDense(config.OutputChannels), nn.RequiresGrad(config.UseBias)),
+	}
+}
+
+// receptiveField returns the number of input elements covered by the dilated kernel, minus one.
+func (c Config) receptiveField() int {
+	return c.Dilation * (c.KernelSize - 1)
+}
+
+// Forward performs the convolution over the sequence xs, where each element is a
+// vector of size InputChannels. It returns a sequence of vectors of size OutputChannels.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	c := m.Config
+	padding := c.Padding
+	if c.Causal {
+		padding = c.receptiveField()
+	}
+	config := fn.Conv2DConfig{
+		KernelRows:   1,
+		KernelCols:   c.KernelSize,
+		StrideRows:   1,
+		StrideCols:   c.Stride,
+		PaddingCols:  padding,
+		DilationRows: 1,
+		DilationCols: c.Dilation,
+	}
+
+	// each input channel is a row vector containing the values of the whole sequence
+	seq := g.Stack(xs...)
+	channels := make([]ag.Node, c.InputChannels)
+	for i := range channels {
+		channels[i] = g.ColView(seq, i)
+	}
+
+	var b ag.Node
+	if c.UseBias {
+		b = m.B
+	}
+	y := g.Conv2D(m.W, b, channels, config)
+
+	_, length := config.OutputDims(1, len(xs))
+	if c.Causal {
+		// the symmetric padding yields additional elements at the end, which depend on future inputs
+		length = (len(xs)-1)/c.Stride + 1
+	}
+	ys := make([]ag.Node, length)
+	for i := range ys {
+		ys[i] = g.T(g.ColView(y, i))
+	}
+	return ys
+}
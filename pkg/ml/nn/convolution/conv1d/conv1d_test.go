@@ -0,0 +1,105 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv1d
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestModel_ForwardCausal(t *testing.T) {
+	model := newTestModel(1)
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+
+	x1 := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]mat.Float{3, 4}), true)
+	x3 := g.NewVariable(mat.NewVecDense([]mat.Float{5, 6}), true)
+
+	// == Forward
+
+	ys := proc.Forward(x1, x2, x3)
+
+	assert.Len(t, ys, 3)
+	assert.Equal(t, 1, ys[0].Value().Rows())
+	assert.Equal(t, 1, ys[0].Value().Columns())
+	assert.InDeltaSlice(t, []mat.Float{10}, ys[0].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{29}, ys[1].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{49}, ys[2].Value().Data(), 1.0e-6)
+
+	// == Backward
+
+	for _, y := range ys {
+		y.PropagateGrad(mat.NewVecDense([]mat.Float{1}))
+	}
+	g.BackwardAll()
+
+	assert.InDeltaSlice(t, []mat.Float{3, 7}, x1.Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{3, 7}, x2.Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{2, 4}, x3.Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{4, 9, 6, 12}, model.W.Grad().Data(), 1.0e-6)
+	assert.Nil(t, model.B.Grad())
+}
+
+func TestModel_ForwardCausalWithDilation(t *testing.T) {
+	model := newTestModel(2)
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+
+	ys := proc.Forward(
+		g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), false),
+		g.NewVariable(mat.NewVecDense([]mat.Float{3, 4}), false),
+		g.NewVariable(mat.NewVecDense([]mat.Float{5, 6}), false),
+	)
+
+	assert.Len(t, ys, 3)
+	assert.InDeltaSlice(t, []mat.Float{10}, ys[0].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{22}, ys[1].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{41}, ys[2].Value().Data(), 1.0e-6)
+}
+
+func TestModel_ForwardWithStrideAndPadding(t *testing.T) {
+	model := New(Config{
+		InputChannels:  1,
+		OutputChannels: 2,
+		KernelSize:     3,
+		Stride:         2,
+		Padding:        1,
+		UseBias:        true,
+	})
+	model.W.Value().(*mat.Dense).SetData([]mat.Float{
+		1, 1, 1,
+		0, 1, 0,
+	})
+	model.B.Value().(*mat.Dense).SetData([]mat.Float{0.5, -0.5})
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+
+	xs := make([]ag.Node, 5)
+	for i := range xs {
+		xs[i] = g.NewScalar(mat.Float(i + 1))
+	}
+	ys := proc.Forward(xs...)
+
+	assert.Len(t, ys, 3)
+	assert.InDeltaSlice(t, []mat.Float{3.5, 0.5}, ys[0].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{9.5, 2.5}, ys[1].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{9.5, 4.5}, ys[2].Value().Data(), 1.0e-6)
+}
+
+func newTestModel(dilation int) *Model {
+	model := New(Config{
+		InputChannels:  2,
+		OutputChannels: 1,
+		KernelSize:     2,
+		Dilation:       dilation,
+		Causal:         true,
+	})
+	model.W.Value().(*mat.Dense).SetData([]mat.Float{1, 2, 3, 4})
+	return model
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package conv2d implements a multi-channel two-dimensional convolution layer,
+// with support for stride, zero-padding, dilation and bias.
+package conv2d
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.StandardModel = &Model{}
+)
+
+// Config provides configuration settings for a Conv2D Model.
+// Zero values of strides and dilations are interpreted as 1.
+type Config struct {
+	InputChannels  int
+	OutputChannels int
+	KernelSizeX    int
+	KernelSizeY    int
+	XStride        int
+	YStride        int
+	XPadding       int
+	YPadding       int
+	XDilation      int
+	YDilation      int
+	UseBias        bool
+}
+
+// Model contains the serializable parameters for a two-dimensional convolution.
+type Model struct {
+	nn.BaseModel
+	Config Config
+	// W contains a row for each output channel, with the kernels of all the input channels (row-major).
+	W nn.Param `spago:"type:weights"`
+	// B contains the bias of each output channel. It is used only if Config.UseBias is true.
+	B nn.Param `spago:"type:biases"`
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new convolution Model, initialized according to the given configuration.
+func New(config Config) *Model {
+	config.XStride = defaultOne(config.XStride)
+	config.YStride = defaultOne(config.YStride)
+	config.XDilation = defaultOne(config.XDilation)
+	config.YDilation = defaultOne(config.YDilation)
+	kernelSize := config.InputChannels * config.KernelSizeX * config.KernelSizeY
+	return &Model{
+		Config: config,
+		W:      nn.NewParam(mat.NewEmptyDense(config.OutputChannels, kernelSize)),
+		B:      nn.NewParam(mat.NewEmptyVecDense(config.OutputChannels), nn.RequiresGrad(config.UseBias)),
+	}
+}
+
+func defaultOne(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// OutputDims returns the dimensions of each output channel, given the dimensions of the input channels.
+func (m *Model) OutputDims(rows, cols int) (int, int) {
+	return m.fnConfig().OutputDims(rows, cols)
+}
+
+func (m *Model) fnConfig() fn.Conv2DConfig {
+	c := m.Config
+	return fn.Conv2DConfig{
+		KernelRows:   c.KernelSizeX,
+		KernelCols:   c.KernelSizeY,
+		StrideRows:   c.XStride,
+		StrideCols:   c.YStride,
+		PaddingRows:  c.XPadding,
+		PaddingCols:  c.YPadding,
+		DilationRows: c.XDilation,
+		DilationCols: c.YDilation,
+	}
+}
+
+// Forward performs the convolution of the input channels xs, returning the output channels.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	if len(xs) != m.Config.InputChannels {
+		panic("conv2d: wrong number of input channels")
+	}
+	g := m.Graph()
+	var b ag.Node
+	if m.Config.UseBias {
+		b = m.B
+	}
+	config := m.fnConfig()
+	rows, cols := config.OutputDims(xs[0].Value().Dims())
+	y := g.Conv2D(m.W, b, xs, config)
+	ys := make([]ag.Node, m.Config.OutputChannels)
+	for i := range ys {
+		ys[i] = g.Reshape(g.RowView(y, i), rows, cols)
+	}
+	return ys
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv2d
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestModel_Forward(t *testing.T) {
+	model := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+
+	x1 := g.NewVariable(mat.NewDense(3, 3, []mat.Float{
+		0.2, 0.1, 0.5,
+		0.4, -0.3, -0.2,
+		0.5, -0.6, -0.4,
+	}), true)
+	x2 := g.NewVariable(mat.NewDense(3, 3, []mat.Float{
+		-0.3, 0.9, 0.5,
+		0.8, -0.3, 0.6,
+		0.1, 0.2, 0.7,
+	}), true)
+
+	// == Forward
+
+	ys := proc.Forward(x1, x2)
+
+	assert.Len(t, ys, 2)
+	assert.Equal(t, 2, ys[0].Value().Rows())
+	assert.Equal(t, 2, ys[0].Value().Columns())
+	assert.InDeltaSlice(t, []mat.Float{0.19, -0.2, 0.39, -0.27}, ys[0].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.6, -0.5, -0.1, 0.0}, ys[1].Value().Data(), 1.0e-6)
+
+	// == Backward
+
+	ys[0].PropagateGrad(mat.NewDense(2, 2, []mat.Float{1, 0, 0, 1}))
+	ys[1].PropagateGrad(mat.NewDense(2, 2, []mat.Float{0, 1, 1, 0}))
+	g.BackwardAll()
+
+	assert.InDeltaSlice(t, []mat.Float{
+		0.5, -0.4, 0.0,
+		0.3, 0.8, -0.4,
+		0.0, 0.3, 0.3,
+	}, x1.Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{
+		0.0, 0.0, 0.0,
+		0.0, 1.0, 0.0,
+		1.0, 0.0, 0.0,
+	}, x2.Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{2, 2}, model.B.Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{
+		-0.1, -0.1, -0.2, -0.7, // x1 kernel of the first output channel
+		-0.6, 1.5, 1.0, 0.4, // x2 kernel of the first output channel
+		0.5, 0.2, 0.2, -0.8, // x1 kernel of the second output channel
+		1.7, 0.2, -0.2, 0.8, // x2 kernel of the second output channel
+	}, model.W.Grad().Data(), 1.0e-6)
+}
+
+func TestModel_ForwardWithPadding(t *testing.T) {
+	model := New(Config{
+		InputChannels:  1,
+		OutputChannels: 1,
+		KernelSizeX:    3,
+		KernelSizeY:    3,
+		XPadding:       1,
+		YPadding:       1,
+	})
+	model.W.Value().(*mat.Dense).SetData([]mat.Float{0, 0, 0, 0, 1, 0, 0, 0, 0})
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+
+	x := g.NewVariable(mat.NewDense(2, 3, []mat.Float{1, 2, 3, 4, 5, 6}), false)
+	ys := proc.Forward(x)
+
+	assert.Equal(t, 2, ys[0].Value().Rows())
+	assert.Equal(t, 3, ys[0].Value().Columns())
+	assert.InDeltaSlice(t, []mat.Float{1, 2, 3, 4, 5, 6}, ys[0].Value().Data(), 1.0e-6)
+	assert.False(t, model.B.RequiresGrad())
+}
+
+func newTestModel() *Model {
+	model := New(Config{
+		InputChannels:  2,
+		OutputChannels: 2,
+		KernelSizeX:    2,
+		KernelSizeY:    2,
+		UseBias:        true,
+	})
+	model.W.Value().(*mat.Dense).SetData([]mat.Float{
+		0.5, -0.4, 0.3, 0.3,
+		0.0, 0.0, 0.0, 0.0,
+		0.0, 0.0, 0.0, 0.0,
+		0.0, 0.0, 0.0, 0.0,
+	})
+	model.W.Value().(*mat.Dense).Set(1, 6, 1.0) // x2 at (1, 0) for the second output channel
+	model.B.Value().(*mat.Dense).SetData([]mat.Float{0.1, -0.2})
+	return model
+}
@@ -0,0 +1,224 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package resnet implements a small Residual Network for image classification, as described in
+// "Deep Residual Learning for Image Recognition" by He et al., 2015 (https://arxiv.org/abs/1512.03385).
+//
+// The network is a convolutional stem followed by stages of basic residual blocks, each made of
+// two 3x3 convolutions; the first block of each stage after the first one halves the resolution
+// and changes the number of channels. The output channels of the last stage are averaged into a
+// vector, which is classified by a linear layer.
+//
+// Since spaGO processes one example per graph, the batch normalization is a per-channel affine
+// transformation (Norm), which holds the batch statistics of the pre-trained models folded into
+// its scale and bias.
+package resnet
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/convolution/conv2d"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/pooling"
+)
+
+var (
+	_ nn.StandardModel = &Model{}
+	_ nn.StandardModel = &Block{}
+	_ nn.StandardModel = &Norm{}
+)
+
+// Config provides configuration settings for a ResNet Model.
+type Config struct {
+	// InputChannels is the number of channels of the images (e.g. 3 for RGB).
+	InputChannels int
+	// Channels contains the number of channels of each stage, and Blocks its number of blocks.
+	Channels []int
+	Blocks   []int
+	// Labels are the classes of the images; the classifier has an output for each of them.
+	Labels []string
+}
+
+// Model contains the serializable parameters for a ResNet.
+type Model struct {
+	nn.BaseModel
+	Config Config
+	// Stem is the first 3x3 convolution, followed by StemNorm.
+	Stem     *conv2d.Model
+	StemNorm *Norm
+	Blocks   []*Block
+	Pooling  *pooling.GlobalAvgPooling
+	// Classifier maps the pooled channels to the logits of the labels.
+	Classifier *linear.Model
+}
+
+// Block is a basic residual block of a ResNet.
+type Block struct {
+	nn.BaseModel
+	Conv1 *conv2d.Model
+	Norm1 *Norm
+	Conv2 *conv2d.Model
+	Norm2 *Norm
+	// Downsample is a strided 1x1 convolution, followed by DownsampleNorm, that matches the
+	// residual connection to the output. It is nil if the input and the output have the same
+	// channels and resolution.
+	Downsample     *conv2d.Model
+	DownsampleNorm *Norm
+}
+
+// Norm is a per-channel affine transformation, y = x * W[c] + B[c] for the channel c.
+type Norm struct {
+	nn.BaseModel
+	W nn.Param `spago:"type:weights"`
+	B nn.Param `spago:"type:biases"`
+}
+
+func init() {
+	gob.Register(&Model{})
+	gob.Register(&Block{})
+	gob.Register(&Norm{})
+}
+
+// SmallConfig returns the configuration of a ResNet-20 for small images (e.g. 32x32 pixels), with
+// three stages of three blocks, of 16, 32 and 64 channels.
+func SmallConfig(inputChannels int, labels []string) Config {
+	return Config{
+		InputChannels: inputChannels,
+		Channels:      []int{16, 32, 64},
+		Blocks:        []int{3, 3, 3},
+		Labels:        labels,
+	}
+}
+
+// New returns a new ResNet Model, with the stages of Config.Channels and Config.Blocks.
+// The convolutions and the classifier are initialized to zeros, the normalizations to the identity.
+func New(config Config) *Model {
+	if len(config.Channels) == 0 || len(config.Channels) != len(config.Blocks) {
+		panic("resnet: the channels and the blocks of the stages must have the same length")
+	}
+	stemChannels := config.Channels[0]
+	var blocks []*Block
+	in := stemChannels
+	for stage, out := range config.Channels {
+		for i := 0; i < config.Blocks[stage]; i++ {
+			stride := 1
+			if stage > 0 && i == 0 {
+				stride = 2
+			}
+			blocks = append(blocks, NewBlock(in, out, stride))
+			in = out
+		}
+	}
+	return &Model{
+		Config:     config,
+		Stem:       newConv(config.InputChannels, stemChannels, 3, 1),
+		StemNorm:   NewNorm(stemChannels),
+		Blocks:     blocks,
+		Pooling:    pooling.NewGlobalAvg(),
+		Classifier: linear.New(in, len(config.Labels)),
+	}
+}
+
+// Init initializes the parameters of the model for the training from scratch: the convolutions
+// by the Kaiming normal initialization, and the classifier by the Xavier uniform one. The last
+// normalization of each block is initialized to zero, so that the blocks start as the identity
+// and the training of the deep networks is stable without the batch statistics.
+func Init(m *Model, generator *rand.LockedRand) {
+	convs := []*conv2d.Model{m.Stem}
+	for _, block := range m.Blocks {
+		convs = append(convs, block.Conv1, block.Conv2)
+		if block.Downsample != nil {
+			convs = append(convs, block.Downsample)
+		}
+		initializers.Zeros(block.Norm2.W.Value())
+	}
+	for _, conv := range convs {
+		initializers.KaimingNormal(conv.W.Value(), initializers.Gain(ag.OpReLU), initializers.FanIn, generator)
+	}
+	initializers.XavierUniform(m.Classifier.W.Value(), 1, generator)
+}
+
+// NewBlock returns a new residual Block, whose first convolution has the given stride.
+func NewBlock(in, out, stride int) *Block {
+	block := &Block{
+		Conv1: newConv(in, out, 3, stride),
+		Norm1: NewNorm(out),
+		Conv2: newConv(out, out, 3, 1),
+		Norm2: NewNorm(out),
+	}
+	if in != out || stride != 1 {
+		block.Downsample = newConv(in, out, 1, stride)
+		block.DownsampleNorm = NewNorm(out)
+	}
+	return block
+}
+
+// newConv returns a new convolution without bias, padded so that the resolution is preserved
+// with stride 1.
+func newConv(in, out, kernelSize, stride int) *conv2d.Model {
+	return conv2d.New(conv2d.Config{
+		InputChannels:  in,
+		OutputChannels: out,
+		KernelSizeX:    kernelSize,
+		KernelSizeY:    kernelSize,
+		XStride:        stride,
+		YStride:        stride,
+		XPadding:       kernelSize / 2,
+		YPadding:       kernelSize / 2,
+	})
+}
+
+// NewNorm returns a new Norm of the given number of channels, initialized to the identity.
+func NewNorm(channels int) *Norm {
+	return &Norm{
+		W: nn.NewParam(mat.NewInitVecDense(channels, 1)),
+		B: nn.NewParam(mat.NewEmptyVecDense(channels)),
+	}
+}
+
+// Forward takes the channels of an image, each one a matrix with the rows and the columns of the
+// image, and returns the logits of the labels.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	return []ag.Node{m.Classifier.Forward(m.Features(xs...))[0]}
+}
+
+// Features returns the vector of the pooled output channels of the last stage, which the
+// classifier maps to the logits; it is an embedding of the image.
+func (m *Model) Features(xs ...ag.Node) ag.Node {
+	g := m.Graph()
+	ys := ag.Map(g.ReLU, m.StemNorm.Forward(m.Stem.Forward(xs...)...))
+	for _, block := range m.Blocks {
+		ys = block.Forward(ys...)
+	}
+	return m.Pooling.Forward(ys...)[0]
+}
+
+// Forward performs the forward step for each input channel and returns the output channels.
+func (m *Block) Forward(xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	ys := ag.Map(g.ReLU, m.Norm1.Forward(m.Conv1.Forward(xs...)...))
+	ys = m.Norm2.Forward(m.Conv2.Forward(ys...)...)
+	residual := xs
+	if m.Downsample != nil {
+		residual = m.DownsampleNorm.Forward(m.Downsample.Forward(xs...)...)
+	}
+	for i := range ys {
+		ys[i] = g.ReLU(g.Add(ys[i], residual[i]))
+	}
+	return ys
+}
+
+// Forward scales and shifts each input channel by its weight and bias.
+func (m *Norm) Forward(xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	ys := make([]ag.Node, len(xs))
+	for c, x := range xs {
+		ys[c] = g.AddScalar(g.ProdScalar(x, g.AtVec(m.W, c)), g.AtVec(m.B, c))
+	}
+	return ys
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package resnet
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	model := New(Config{
+		InputChannels: 3,
+		Channels:      []int{4, 8},
+		Blocks:        []int{2, 1},
+		Labels:        []string{"cat", "dog"},
+	})
+	assert.Len(t, model.Blocks, 3)
+	assert.Nil(t, model.Blocks[0].Downsample)
+	assert.Nil(t, model.Blocks[1].Downsample)
+	assert.NotNil(t, model.Blocks[2].Downsample)
+	assert.Equal(t, 2, model.Blocks[2].Conv1.Config.XStride)
+	assert.Equal(t, 1, model.Blocks[2].Conv2.Config.XStride)
+	assert.Equal(t, 2, model.Classifier.W.Value().Rows())
+	assert.Equal(t, 8, model.Classifier.W.Value().Columns())
+
+	assert.Len(t, New(SmallConfig(3, []string{"a"})).Blocks, 9)
+	assert.Panics(t, func() { New(Config{Channels: []int{4}, Blocks: []int{1, 1}}) })
+}
+
+func TestModel_Forward(t *testing.T) {
+	model := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+
+	xs := newTestImage(g)
+	ys := proc.Forward(xs...)
+	assert.Len(t, ys, 1)
+	assert.Equal(t, 2, ys[0].Value().Size())
+	assert.Equal(t, 8, proc.Features(xs...).Value().Size())
+
+	g.Backward(g.AtVec(ys[0], 0))
+	for _, x := range xs {
+		assert.NotNil(t, x.Grad())
+	}
+	assert.NotNil(t, proc.Blocks[2].Downsample.W.Grad())
+}
+
+func TestNorm_Forward(t *testing.T) {
+	norm := NewNorm(2)
+	norm.W.Value().SetVec(1, 2)
+	norm.B.Value().SetVec(1, -1)
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, norm).(*Norm)
+	ys := proc.Forward(
+		g.NewVariable(mat.NewDense(1, 2, []mat.Float{1, 2}), false),
+		g.NewVariable(mat.NewDense(1, 2, []mat.Float{1, 2}), false),
+	)
+	assert.Equal(t, []mat.Float{1, 2}, ys[0].Value().Data())
+	assert.Equal(t, []mat.Float{1, 3}, ys[1].Value().Data())
+}
+
+func TestInit(t *testing.T) {
+	model := New(Config{InputChannels: 1, Channels: []int{2}, Blocks: []int{1}, Labels: []string{"a", "b"}})
+	Init(model, rand.NewLockedRand(1))
+	assert.NotEqual(t, 0, model.Stem.W.Value().Sum())
+	assert.Equal(t, mat.Float(0), model.Blocks[0].Norm2.W.Value().Sum())
+	assert.Equal(t, mat.Float(2), model.Blocks[0].Norm1.W.Value().Sum())
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resnet")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	model := newTestModel()
+	filename := filepath.Join(dir, "model.bin")
+	assert.NoError(t, nn.SaveModel(filename, model))
+	loaded := New(model.Config)
+	_, err = nn.LoadModel(filename, loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Blocks[2].Downsample.W.Value().Data(), loaded.Blocks[2].Downsample.W.Value().Data())
+}
+
+func newTestModel() *Model {
+	model := New(Config{
+		InputChannels: 3,
+		Channels:      []int{4, 8},
+		Blocks:        []int{2, 1},
+		Labels:        []string{"cat", "dog"},
+	})
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		initializers.Uniform(param.Value(), -0.5, 0.5, rndGen)
+	})
+	return model
+}
+
+// newTestImage returns the three channels of a 6x5 image.
+func newTestImage(g *ag.Graph) []ag.Node {
+	xs := make([]ag.Node, 3)
+	for c := range xs {
+		data := make([]mat.Float, 30)
+		for i := range data {
+			data[i] = mat.Float((i*(c+1))%7) * 0.1
+		}
+		xs[c] = g.NewVariable(mat.NewDense(6, 5, data), true)
+	}
+	return xs
+}
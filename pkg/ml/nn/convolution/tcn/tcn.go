@@ -0,0 +1,127 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tcn implements a Temporal Convolutional Network, as described in
+// "An Empirical Evaluation of Generic Convolutional and Recurrent Networks for Sequence Modeling"
+// by Bai et al., 2018 (https://arxiv.org/abs/1803.01271).
+//
+// The network is a stack of residual blocks, each made of two causal dilated
+// convolutions; the dilation doubles at each block, so that the receptive field
+// grows exponentially with the depth of the network.
+package tcn
+
+import (
+	"encoding/gob"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/convolution/conv1d"
+)
+
+var (
+	_ nn.StandardModel = &Model{}
+	_ nn.StandardModel = &Block{}
+)
+
+// Config provides configuration settings for a TCN Model.
+type Config struct {
+	InputSize int
+	// Channels contains the number of output channels of each block.
+	Channels   []int
+	KernelSize int
+	// Activation is the activation function applied after each convolution (e.g. ag.OpReLU).
+	Activation ag.OpName
+}
+
+// Model contains the serializable parameters for a TCN.
+type Model struct {
+	nn.BaseModel
+	Config Config
+	Blocks []*Block
+}
+
+// Block is a residual block of a TCN.
+type Block struct {
+	nn.BaseModel
+	Conv1       *conv1d.Model
+	Conv2       *conv1d.Model
+	Activation1 *activation.Model
+	Activation2 *activation.Model
+	// Downsample is a 1x1 convolution that matches the size of the residual connection
+	// to the output. It is nil if the input and the output have the same size.
+	Downsample *conv1d.Model
+}
+
+func init() {
+	gob.Register(&Model{})
+	gob.Register(&Block{})
+}
+
+// New returns a new TCN Model, with a block for each element of Config.Channels.
+// The dilation of the i-th block is 2^i.
+func New(config Config) *Model {
+	blocks := make([]*Block, len(config.Channels))
+	in := config.InputSize
+	for i, out := range config.Channels {
+		blocks[i] = NewBlock(in, out, config.KernelSize, 1<<i, config.Activation)
+		in = out
+	}
+	return &Model{
+		Config: config,
+		Blocks: blocks,
+	}
+}
+
+// NewBlock returns a new residual Block.
+func NewBlock(in, out, kernelSize, dilation int, act ag.OpName) *Block {
+	newConv := func(in int) *conv1d.Model {
+		return conv1d.New(conv1d.Config{
+			InputChannels:  in,
+			OutputChannels: out,
+			KernelSize:     kernelSize,
+			Dilation:       dilation,
+			Causal:         true,
+			UseBias:        true,
+		})
+	}
+	var downsample *conv1d.Model
+	if in != out {
+		downsample = conv1d.New(conv1d.Config{
+			InputChannels:  in,
+			OutputChannels: out,
+			KernelSize:     1,
+			UseBias:        true,
+		})
+	}
+	return &Block{
+		Conv1:       newConv(in),
+		Conv2:       newConv(out),
+		Activation1: activation.New(act),
+		Activation2: activation.New(act),
+		Downsample:  downsample,
+	}
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	for _, block := range m.Blocks {
+		xs = block.Forward(xs...)
+	}
+	return xs
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *Block) Forward(xs ...ag.Node) []ag.Node {
+	ys := m.Activation1.Forward(m.Conv1.Forward(xs...)...)
+	ys = m.Activation2.Forward(m.Conv2.Forward(ys...)...)
+	residual := xs
+	if m.Downsample != nil {
+		residual = m.Downsample.Forward(xs...)
+	}
+	g := m.Graph()
+	for i := range ys {
+		ys[i] = g.ReLU(g.Add(ys[i], residual[i]))
+	}
+	return ys
+}
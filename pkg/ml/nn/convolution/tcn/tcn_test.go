@@ -0,0 +1,94 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tcn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	model := New(Config{
+		InputSize:  3,
+		Channels:   []int{4, 4, 2},
+		KernelSize: 3,
+		Activation: ag.OpReLU,
+	})
+
+	assert.Len(t, model.Blocks, 3)
+	assert.NotNil(t, model.Blocks[0].Downsample)
+	assert.Nil(t, model.Blocks[1].Downsample)
+	assert.NotNil(t, model.Blocks[2].Downsample)
+	for i, block := range model.Blocks {
+		assert.Equal(t, 1<<i, block.Conv1.Config.Dilation)
+		assert.Equal(t, 1<<i, block.Conv2.Config.Dilation)
+		assert.True(t, block.Conv1.Config.Causal)
+	}
+}
+
+func TestModel_Forward(t *testing.T) {
+	model := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+
+	xs := newTestSequence(g, 6)
+	ys := proc.Forward(xs...)
+
+	assert.Len(t, ys, len(xs))
+	for _, y := range ys {
+		assert.Equal(t, 2, y.Value().Size())
+	}
+
+	g.Backward(ys[len(ys)-1])
+	for _, x := range xs {
+		assert.NotNil(t, x.Grad())
+	}
+}
+
+func TestModel_ForwardIsCausal(t *testing.T) {
+	model := newTestModel()
+
+	g1 := ag.NewGraph()
+	xs1 := newTestSequence(g1, 6)
+	ys1 := nn.Reify(nn.Context{Graph: g1, Mode: nn.Inference}, model).(*Model).Forward(xs1...)
+
+	g2 := ag.NewGraph()
+	xs2 := newTestSequence(g2, 6)
+	xs2[4] = g2.NewVariable(mat.NewVecDense([]mat.Float{1, -1, 1}), false)
+	ys2 := nn.Reify(nn.Context{Graph: g2, Mode: nn.Inference}, model).(*Model).Forward(xs2...)
+
+	for i := 0; i < 4; i++ {
+		assert.InDeltaSlice(t, ys1[i].Value().Data(), ys2[i].Value().Data(), 1.0e-6)
+	}
+	assert.NotEqual(t, ys1[4].Value().Data(), ys2[4].Value().Data())
+}
+
+func newTestModel() *Model {
+	model := New(Config{
+		InputSize:  3,
+		Channels:   []int{4, 2},
+		KernelSize: 2,
+		Activation: ag.OpReLU,
+	})
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		initializers.Uniform(param.Value(), -0.5, 0.5, rndGen)
+	})
+	return model
+}
+
+func newTestSequence(g *ag.Graph, length int) []ag.Node {
+	xs := make([]ag.Node, length)
+	for i := range xs {
+		v := mat.Float(i) * 0.1
+		xs[i] = g.NewVariable(mat.NewVecDense([]mat.Float{v, -v, 0.5 - v}), true)
+	}
+	return xs
+}
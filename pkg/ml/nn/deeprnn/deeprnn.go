@@ -0,0 +1,158 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package deeprnn implements a deep recurrent network, stacking any number of recurrent layers
+// (e.g. lstm, gru, mogrifierlstm) with optional residual or highway connections between them,
+// and variational dropout on the inputs of the upper layers.
+//
+// Reference: "Google's Neural Machine Translation System: Bridging the Gap between Human and Machine
+// Translation" by Wu et al., 2016 (https://arxiv.org/pdf/1609.08144.pdf), for the residual connections;
+// "A Theoretically Grounded Application of Dropout in Recurrent Neural Networks" by Gal and Ghahramani,
+// 2016 (https://arxiv.org/pdf/1512.05287.pdf), for the variational dropout.
+package deeprnn
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+// ConnectionType is the enumeration-like type used for the set of connections
+// between the layers of a deep recurrent network.
+type ConnectionType int
+
+const (
+	// Plain connection: the output of a layer is the input of the next one (the default)
+	Plain ConnectionType = iota
+	// Residual connection: the input of a layer is added to its output
+	Residual
+	// Highway connection: the output of a layer and its input are mixed by a learned gate
+	Highway
+)
+
+var (
+	_ nn.Model = &Model{}
+)
+
+// Model contains the serializable parameters.
+// The connections are used from the second layer onwards, so the first layer can change the size of
+// the input, while all the other layers must keep the same size.
+type Model struct {
+	nn.BaseModel
+	Layers     []nn.StandardModel
+	Connection ConnectionType
+	// WT and BT are the parameters of the gates of the highway connections, one for each layer but the first.
+	WT []nn.Param `spago:"type:weights"`
+	BT []nn.Param `spago:"type:biases"`
+	// Dropout is the probability of the variational dropout applied to the input of each layer but the first.
+	Dropout mat.Float
+	// masks are the dropout masks, sampled once per processor and shared by all the time steps.
+	masks []ag.Node
+}
+
+// Option allows to configure a new Model with your specific needs.
+type Option func(*Model)
+
+// WithResidual enables the residual connections.
+func WithResidual() Option {
+	return func(m *Model) {
+		m.Connection = Residual
+	}
+}
+
+// WithHighway enables the highway connections, creating the parameters of the gates for the given
+// size of the layers. The parameters are initialized to zeros.
+func WithHighway(size int) Option {
+	return func(m *Model) {
+		m.Connection = Highway
+		m.WT = make([]nn.Param, len(m.Layers)-1)
+		m.BT = make([]nn.Param, len(m.Layers)-1)
+		for i := range m.WT {
+			m.WT[i] = nn.NewParam(mat.NewEmptyDense(size, size))
+			m.BT[i] = nn.NewParam(mat.NewEmptyVecDense(size))
+		}
+	}
+}
+
+// WithDropout sets the probability of the variational dropout between the layers.
+func WithDropout(p mat.Float) Option {
+	return func(m *Model) {
+		m.Dropout = p
+	}
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new model stacking the given recurrent layers, configured with the options.
+func New(layers []nn.StandardModel, options ...Option) *Model {
+	m := &Model{
+		Layers: layers,
+	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// LastLayer returns the last layer from the stack.
+func (m *Model) LastLayer() nn.StandardModel {
+	return m.Layers[len(m.Layers)-1]
+}
+
+// Forward performs the forward step for each input node and returns the result.
+// The layers keep their states across the calls, so a sequence can also be processed incrementally.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	ys := m.Layers[0].Forward(xs...)
+	for i := 1; i < len(m.Layers); i++ {
+		in := m.dropout(i-1, ys)
+		ys = m.connect(i, m.Layers[i].Forward(in...), in)
+	}
+	return ys
+}
+
+// dropout applies the mask of the given layer boundary to each input, in training mode only.
+func (m *Model) dropout(i int, xs []ag.Node) []ag.Node {
+	if m.Mode() != nn.Training || m.Dropout <= 0.0 || len(xs) == 0 {
+		return xs
+	}
+	g := m.Graph()
+	if m.masks == nil {
+		m.masks = make([]ag.Node, len(m.Layers)-1)
+	}
+	if m.masks[i] == nil {
+		ones := mat.NewInitVecDense(xs[0].Value().Size(), 1.0)
+		m.masks[i] = g.Dropout(g.NewVariable(ones, false), m.Dropout)
+	}
+	ys := make([]ag.Node, len(xs))
+	for t, x := range xs {
+		ys[t] = g.Prod(x, m.masks[i])
+	}
+	return ys
+}
+
+// connect combines the outputs hs of the layer i with its inputs xs, according to the connection type:
+// y = h + x    (residual)
+// y = t * h + (1 - t) * x, with t = sigmoid(wT (dot) x + bT)    (highway)
+func (m *Model) connect(i int, hs, xs []ag.Node) []ag.Node {
+	if m.Connection == Plain {
+		return hs
+	}
+	g := m.Graph()
+	ys := make([]ag.Node, len(hs))
+	for t, h := range hs {
+		switch m.Connection {
+		case Residual:
+			ys[t] = g.Add(h, xs[t])
+		case Highway:
+			gate := g.Sigmoid(nn.Affine(g, m.BT[i-1], m.WT[i-1], xs[t]))
+			ys[t] = g.Add(g.Prod(gate, h), g.Prod(g.ReverseSub(gate, g.NewScalar(1.0)), xs[t]))
+		default:
+			panic("deeprnn: invalid connection type")
+		}
+	}
+	return ys
+}
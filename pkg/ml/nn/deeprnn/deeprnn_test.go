@@ -0,0 +1,124 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package deeprnn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/gradcheck"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/recurrent/lstm"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testInputs = [][]mat.Float{
+	{-0.8, -0.9, -0.9, 1.0},
+	{0.8, -0.3, 0.5, 0.3},
+	{-0.2, 0.7, 0.2, 0.4},
+}
+
+func TestModel_Forward(t *testing.T) {
+	for _, connection := range []ConnectionType{Plain, Residual, Highway} {
+		model := newTestModel(connection)
+		g := ag.NewGraph()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+		ys := proc.Forward(newInputs(g)...)
+
+		// the same computation, wiring the layers by hand
+		h1 := proc.Layers[0].(*lstm.Model)
+		h2 := proc.Layers[1].(*lstm.Model)
+		h1.States, h2.States = nil, nil
+		in := h1.Forward(newInputs(g)...)
+		out := h2.Forward(in...)
+		for i := range out {
+			switch connection {
+			case Residual:
+				out[i] = g.Add(out[i], in[i])
+			case Highway:
+				gate := g.Sigmoid(g.Add(g.Mul(proc.WT[0], in[i]), proc.BT[0]))
+				out[i] = g.Add(g.Prod(gate, out[i]), g.Prod(g.ReverseSub(gate, g.NewScalar(1.0)), in[i]))
+			}
+			assert.InDeltaSlice(t, out[i].Value().Data(), ys[i].Value().Data(), 1.0e-06, "connection %d", connection)
+		}
+	}
+}
+
+func TestModel_Gradients(t *testing.T) {
+	model := newTestModel(Highway)
+	f := func(g *ag.Graph, proc nn.Model) ag.Node {
+		return g.Concat(proc.(*Model).Forward(newInputs(g)...)...)
+	}
+	assert.NoError(t, gradcheck.CheckModel(model, f, gradcheck.DefaultConfig))
+}
+
+func TestModel_VariationalDropout(t *testing.T) {
+	model := New([]nn.StandardModel{&identity{}, &identity{}}, WithDropout(0.5))
+	ones := mat.NewInitVecDense(64, 1.0)
+
+	g := ag.NewGraph(ag.RandSeed(42))
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+	ys := proc.Forward(g.NewVariable(ones, false), g.NewVariable(ones, false))
+	ys = append(ys, proc.Forward(g.NewVariable(ones, false))...)
+	mask := ys[0].Value().Data()
+	assert.Contains(t, mask, mat.Float(0.0))
+	assert.Contains(t, mask, mat.Float(2.0))
+	for _, y := range ys[1:] {
+		assert.Equal(t, mask, y.Value().Data()) // the same mask at each time step
+	}
+
+	g = ag.NewGraph()
+	proc = nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	y := proc.Forward(g.NewVariable(ones, false))[0]
+	assert.Equal(t, ones.Data(), y.Value().Data())
+}
+
+func TestNew(t *testing.T) {
+	model := New([]nn.StandardModel{lstm.New(4, 5), lstm.New(5, 5), lstm.New(5, 5)}, WithHighway(5), WithDropout(0.1))
+	assert.Equal(t, Highway, model.Connection)
+	assert.Equal(t, mat.Float(0.1), model.Dropout)
+	assert.Len(t, model.WT, 2)
+	assert.Len(t, model.BT, 2)
+	assert.Equal(t, 5, model.WT[0].Value().Rows())
+	assert.Equal(t, 5, model.WT[0].Value().Columns())
+}
+
+type identity struct {
+	nn.BaseModel
+}
+
+func (m *identity) Forward(xs ...ag.Node) []ag.Node {
+	return xs
+}
+
+func newTestModel(connection ConnectionType) *Model {
+	var options []Option
+	switch connection {
+	case Residual:
+		options = append(options, WithResidual())
+	case Highway:
+		options = append(options, WithHighway(3))
+	}
+	model := New([]nn.StandardModel{lstm.New(4, 3), lstm.New(3, 3)}, options...)
+	r := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, r)
+		} else {
+			initializers.Uniform(param.Value(), -0.5, 0.5, r)
+		}
+	})
+	return model
+}
+
+func newInputs(g *ag.Graph) []ag.Node {
+	xs := make([]ag.Node, len(testInputs))
+	for i, input := range testInputs {
+		xs[i] = g.NewVariable(mat.NewVecDense(input), false)
+	}
+	return xs
+}
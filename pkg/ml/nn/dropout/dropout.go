@@ -0,0 +1,136 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dropout implements parameter-free models that randomly drop elements of their inputs
+// during training. They depend on the processing mode: in Inference mode, the inputs are returned
+// unchanged.
+//
+// Besides the standard dropout, the package provides the locked (a.k.a. variational) dropout, which
+// drops the same elements at each time step of a recurrent network, and the embedding dropout, which
+// drops whole embedding vectors. See linear.DropConnect for the dropout of the weights.
+//
+// Reference: "A Theoretically Grounded Application of Dropout in Recurrent Neural Networks" by Gal and
+// Ghahramani, 2016 (https://arxiv.org/pdf/1512.05287.pdf); "Regularizing and Optimizing LSTM Language
+// Models" by Merity et al., 2017 (https://arxiv.org/pdf/1708.02182.pdf).
+package dropout
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.StandardModel = &Model{}
+	_ nn.StandardModel = &Locked{}
+	_ nn.StandardModel = &Embedding{}
+)
+
+// Model is a parameter-free model applying the standard dropout, with a new mask for each input.
+type Model struct {
+	nn.BaseModel
+	P mat.Float
+}
+
+// Locked is a parameter-free model applying the locked dropout: the mask is sampled once per processor,
+// and applied to all the inputs (e.g. all the time steps of a sequence), even across several calls.
+type Locked struct {
+	nn.BaseModel
+	P mat.Float
+	// mask is sampled at the first forward of the processor.
+	mask ag.Node
+}
+
+// Embedding is a parameter-free model applying the embedding dropout: each input vector is either
+// dropped entirely or scaled, and the same input node is always dropped or kept in the same way
+// by a processor. Since repeated IDs share the same node (see embedding.Model.Encode), all the
+// occurrences of a word are dropped together.
+type Embedding struct {
+	nn.BaseModel
+	P mat.Float
+	// masks are the scalar masks of the input nodes seen by the processor.
+	masks map[ag.Node]ag.Node
+}
+
+func init() {
+	gob.Register(&Model{})
+	gob.Register(&Locked{})
+	gob.Register(&Embedding{})
+}
+
+// New returns a new Model dropping the elements with probability p.
+func New(p mat.Float) *Model {
+	return &Model{P: p}
+}
+
+// NewLocked returns a new Locked model dropping the elements with probability p.
+func NewLocked(p mat.Float) *Locked {
+	return &Locked{P: p}
+}
+
+// NewEmbedding returns a new Embedding model dropping the vectors with probability p.
+func NewEmbedding(p mat.Float) *Embedding {
+	return &Embedding{P: p}
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	if !isEnabled(m, m.P) {
+		return xs
+	}
+	g := m.Graph()
+	dropout := func(x ag.Node) ag.Node {
+		return g.Dropout(x, m.P)
+	}
+	return ag.Map(dropout, xs)
+}
+
+// Forward performs the forward step for each input node and returns the result.
+// All the inputs must have the same size.
+func (m *Locked) Forward(xs ...ag.Node) []ag.Node {
+	if !isEnabled(m, m.P) || len(xs) == 0 {
+		return xs
+	}
+	g := m.Graph()
+	if m.mask == nil {
+		m.mask = newMask(g, xs[0].Value().Rows(), xs[0].Value().Columns(), m.P)
+	}
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		ys[i] = g.Prod(x, m.mask)
+	}
+	return ys
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *Embedding) Forward(xs ...ag.Node) []ag.Node {
+	if !isEnabled(m, m.P) {
+		return xs
+	}
+	g := m.Graph()
+	if m.masks == nil {
+		m.masks = make(map[ag.Node]ag.Node)
+	}
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		mask, ok := m.masks[x]
+		if !ok {
+			mask = newMask(g, 1, 1, m.P)
+			m.masks[x] = mask
+		}
+		ys[i] = g.ProdScalar(x, mask)
+	}
+	return ys
+}
+
+// isEnabled reports whether the dropout must be applied by the processor.
+func isEnabled(m nn.Model, p mat.Float) bool {
+	return m.Mode() == nn.Training && p > 0.0
+}
+
+// newMask returns a node whose values are either zeros, with probability p, or 1 / (1 - p).
+func newMask(g *ag.Graph, rows, cols int, p mat.Float) ag.Node {
+	return g.Dropout(g.NewVariable(mat.NewInitDense(rows, cols, 1.0), false), p)
+}
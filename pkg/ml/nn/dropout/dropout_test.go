@@ -0,0 +1,89 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dropout
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestModel_Forward(t *testing.T) {
+	g := ag.NewGraph(ag.RandSeed(42))
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, New(0.5)).(*Model)
+	ys := proc.Forward(newOnes(g), newOnes(g))
+
+	assertDropped(t, ys[0].Value().Data(), 0.0, 2.0)
+	assertDropped(t, ys[1].Value().Data(), 0.0, 2.0)
+	assert.NotEqual(t, ys[0].Value().Data(), ys[1].Value().Data()) // a new mask for each input
+}
+
+func TestLocked_Forward(t *testing.T) {
+	g := ag.NewGraph(ag.RandSeed(42))
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, NewLocked(0.5)).(*Locked)
+	ys := proc.Forward(newOnes(g), newOnes(g))
+	ys = append(ys, proc.Forward(newOnes(g))...)
+
+	mask := ys[0].Value().Data()
+	assertDropped(t, mask, 0.0, 2.0)
+	for _, y := range ys[1:] {
+		assert.Equal(t, mask, y.Value().Data()) // the same mask for all the inputs
+	}
+
+	// a new processor samples a new mask
+	proc = nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, NewLocked(0.5)).(*Locked)
+	assert.NotEqual(t, mask, proc.Forward(newOnes(g))[0].Value().Data())
+}
+
+func TestEmbedding_Forward(t *testing.T) {
+	g := ag.NewGraph(ag.RandSeed(42))
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, NewEmbedding(0.5)).(*Embedding)
+	xs := make([]ag.Node, 32)
+	for i := range xs {
+		xs[i] = newOnes(g)
+	}
+	ys := proc.Forward(append(xs, xs[0])...)
+
+	var dropped, kept int
+	for _, y := range ys {
+		data := y.Value().Data()
+		switch data[0] {
+		case 0.0:
+			dropped++
+		case 2.0:
+			kept++
+		}
+		assert.Equal(t, mat.NewInitVecDense(len(data), data[0]).Data(), data) // the whole vector
+	}
+	assert.Equal(t, len(ys), dropped+kept)
+	assert.NotZero(t, dropped)
+	assert.NotZero(t, kept)
+	assert.Equal(t, ys[0].Value().Data(), ys[len(ys)-1].Value().Data()) // the same node, the same mask
+}
+
+func TestInference(t *testing.T) {
+	for _, model := range []nn.StandardModel{New(0.5), NewLocked(0.5), NewEmbedding(0.5)} {
+		g := ag.NewGraph()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(nn.StandardModel)
+		x := newOnes(g)
+		assert.Equal(t, []ag.Node{x}, proc.Forward(x))
+	}
+}
+
+func newOnes(g *ag.Graph) ag.Node {
+	return g.NewVariable(mat.NewInitVecDense(64, 1.0), true)
+}
+
+// assertDropped asserts that the data contains both the dropped and the kept value, and nothing else.
+func assertDropped(t *testing.T, data []mat.Float, dropped, kept mat.Float) {
+	t.Helper()
+	assert.Contains(t, data, dropped)
+	assert.Contains(t, data, kept)
+	for _, v := range data {
+		assert.True(t, v == dropped || v == kept)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package embedding implements a lookup table of trainable vectors indexed by integer IDs
+// (e.g. the token embeddings of a vocabulary).
+//
+// Each vector is a distinct parameter, so the backward pass only produces the
+// gradients of the vectors looked up in the graph, and the optimizer only
+// updates those vectors, instead of the whole (possibly very large) table.
+//
+// The same vectors can be used as the weights of the output projection of a
+// language model (weight tying), with Model.Project. Since there is no separate
+// projection layer, the shared vectors are serialized only once, together with
+// the embedding Model.
+package embedding
+
+import (
+	"encoding/gob"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.Model = &Model{}
+)
+
+// Config provides configuration settings for an embedding Model.
+type Config struct {
+	// Size of the embedding vectors.
+	Size int
+	// NumOfEmbeddings is the number of vectors of the lookup table.
+	NumOfEmbeddings int
+	// Trainable reports whether the vectors are updated during training.
+	Trainable bool
+	// UseOutputBias reports whether a bias is added to the output of Project.
+	UseOutputBias bool
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	nn.BaseModel
+	Config Config
+	// Vectors are shared among all the processors of the model, avoiding to
+	// reify the whole lookup table at every forward.
+	Vectors []nn.Param `spago:"type:weights;scope:model"`
+	// OutputBias is the bias of the output projection. It is nil if Config.UseOutputBias is false.
+	OutputBias nn.Param `spago:"type:biases"`
+	// OutputWeights is the matrix of all the vectors, stacked by rows on the first Project.
+	OutputWeights ag.Node `spago:"scope:processor"`
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new embedding model with vectors initialized to zeros.
+func New(config Config) *Model {
+	vectors := make([]nn.Param, config.NumOfEmbeddings)
+	for i := range vectors {
+		vectors[i] = nn.NewParam(mat.NewEmptyVecDense(config.Size), nn.RequiresGrad(config.Trainable))
+	}
+	var outputBias nn.Param
+	if config.UseOutputBias {
+		outputBias = nn.NewParam(mat.NewEmptyVecDense(config.NumOfEmbeddings), nn.RequiresGrad(config.Trainable))
+	}
+	return &Model{
+		Config:     config,
+		Vectors:    vectors,
+		OutputBias: outputBias,
+	}
+}
+
+// Encode returns the vectors associated with the given IDs, as nodes inserted in the graph.
+// Repeated IDs share the same node. It panics if an ID is out of range.
+func (m *Model) Encode(ids []int) []ag.Node {
+	encoding := make([]ag.Node, len(ids))
+	cache := make(map[int]ag.Node) // be smart, don't create two nodes for the same ID!
+	for i, id := range ids {
+		if item, ok := cache[id]; ok {
+			encoding[i] = item
+			continue
+		}
+		if id < 0 || id >= len(m.Vectors) {
+			panic(fmt.Sprintf("embedding: ID %d out of range [0, %d)", id, len(m.Vectors)))
+		}
+		encoding[i] = m.WrapParam(m.Vectors[id])
+		cache[id] = encoding[i]
+	}
+	return encoding
+}
+
+// EncodeMatrix returns a matrix with the vectors associated with the given IDs, by rows.
+// Only the distinct vectors are stacked, and then selected with IndexSelect, so that
+// only those vectors receive the gradients, as with Encode. It panics if an ID is out of range.
+func (m *Model) EncodeMatrix(ids []int) ag.Node {
+	positions := make(map[int]int) // position of each distinct ID among the stacked vectors
+	distinct := make([]int, 0, len(ids))
+	indices := make([]int, len(ids))
+	for i, id := range ids {
+		position, ok := positions[id]
+		if !ok {
+			position = len(distinct)
+			positions[id] = position
+			distinct = append(distinct, id)
+		}
+		indices[i] = position
+	}
+	g := m.Graph()
+	return g.IndexSelect(g.Stack(m.Encode(distinct)...), indices)
+}
+
+// Project returns, for each input vector, the scores (logits) of all the embeddings,
+// computed as the dot product between the input and each embedding vector, plus the
+// optional output bias.
+//
+// Since the projection uses the same parameters of Encode, the gradients coming from
+// both the input and the output of a model are accumulated on the shared vectors.
+// The vectors are stacked once for each processor, on the first call.
+func (m *Model) Project(xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	if m.OutputWeights == nil {
+		m.OutputWeights = g.Stack(m.Encode(m.allIDs())...)
+	}
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		ys[i] = g.Mul(m.OutputWeights, x)
+		if m.OutputBias != nil {
+			ys[i] = g.Add(ys[i], m.OutputBias)
+		}
+	}
+	return ys
+}
+
+func (m *Model) allIDs() []int {
+	ids := make([]int, len(m.Vectors))
+	for i := range ids {
+		ids[i] = i
+	}
+	return ids
+}
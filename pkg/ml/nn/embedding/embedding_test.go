@@ -0,0 +1,145 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package embedding
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestModel_Encode(t *testing.T) {
+	model := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+
+	ys := proc.Encode([]int{1, 3, 1})
+
+	assert.Len(t, ys, 3)
+	assert.Same(t, ys[0], ys[2])
+	assert.InDeltaSlice(t, []mat.Float{0.1, 0.2}, ys[0].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.5, -0.6}, ys[1].Value().Data(), 1.0e-6)
+
+	assert.Panics(t, func() { proc.Encode([]int{4}) })
+	assert.Panics(t, func() { proc.Encode([]int{-1}) })
+}
+
+func TestModel_EncodeMatrix(t *testing.T) {
+	model := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+
+	y := proc.EncodeMatrix([]int{1, 3, 1})
+	assert.Equal(t, 3, y.Value().Rows())
+	assert.Equal(t, 2, y.Value().Columns())
+	assert.InDeltaSlice(t, []mat.Float{0.1, 0.2, 0.5, -0.6, 0.1, 0.2}, y.Value().Data(), 1.0e-6)
+
+	g.Backward(g.ReduceSum(y))
+	assert.False(t, model.Vectors[0].HasGrad())
+	assert.False(t, model.Vectors[2].HasGrad())
+	assert.InDeltaSlice(t, []mat.Float{2, 2}, model.Vectors[1].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{1, 1}, model.Vectors[3].Grad().Data(), 1.0e-6)
+
+	assert.Panics(t, func() { proc.EncodeMatrix([]int{4}) })
+}
+
+func TestModel_SparseUpdate(t *testing.T) {
+	model := newTestModel()
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(1.0, 0.0, false)), nn.NewDefaultParamsIterator(model))
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+	ys := proc.Encode([]int{1, 3, 1})
+	g.Backward(g.ReduceSum(g.Concat(ys...)))
+
+	assert.False(t, model.Vectors[0].HasGrad())
+	assert.False(t, model.Vectors[2].HasGrad())
+	assert.InDeltaSlice(t, []mat.Float{2, 2}, model.Vectors[1].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{1, 1}, model.Vectors[3].Grad().Data(), 1.0e-6)
+
+	optimizer.Optimize()
+
+	assert.InDeltaSlice(t, []mat.Float{0.0, 0.0}, model.Vectors[0].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-1.9, -1.8}, model.Vectors[1].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.3, 0.4}, model.Vectors[2].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-0.5, -1.6}, model.Vectors[3].Value().Data(), 1.0e-6)
+	assert.Nil(t, model.Vectors[0].Payload())
+	assert.Nil(t, model.Vectors[2].Payload())
+}
+
+func TestModel_ProjectWithTiedWeights(t *testing.T) {
+	model := newTestModel()
+	model.Config.UseOutputBias = true
+	model.OutputBias = nn.NewParam(mat.NewVecDense([]mat.Float{0.0, 0.0, 0.0, 1.0}))
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+
+	x := proc.Encode([]int{1})[0]
+	ys := proc.Project(x)
+	weights := proc.OutputWeights
+	proc.Project(x)
+	assert.Same(t, weights, proc.OutputWeights, "the vectors are stacked once")
+
+	assert.Len(t, ys, 1)
+	assert.InDeltaSlice(t, []mat.Float{0.0, 0.05, 0.11, 0.93}, ys[0].Value().Data(), 1.0e-6)
+
+	ys[0].PropagateGrad(mat.NewVecDense([]mat.Float{1, 1, 1, 1}))
+	g.BackwardAll()
+
+	// the gradients of the input and of the output projection are accumulated on the same vector
+	assert.InDeltaSlice(t, []mat.Float{1.0, 0.2}, model.Vectors[1].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.1, 0.2}, model.Vectors[0].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.1, 0.2}, model.Vectors[3].Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{1, 1, 1, 1}, model.OutputBias.Grad().Data(), 1.0e-6)
+}
+
+func TestNew_NotTrainable(t *testing.T) {
+	model := New(Config{Size: 2, NumOfEmbeddings: 3})
+	for _, v := range model.Vectors {
+		assert.False(t, v.RequiresGrad())
+	}
+	assert.Nil(t, model.OutputBias)
+}
+
+func Test_Serialize(t *testing.T) {
+	model := newTestModel()
+	tempFile, err := ioutil.TempFile("", "test_serialize")
+	require.Nil(t, err)
+	tempFile.Close()
+	defer func() {
+		_ = os.Remove(tempFile.Name())
+	}()
+	err = utils.SerializeToFile(tempFile.Name(), model)
+	require.Nil(t, err)
+
+	var model2 *Model
+	err = utils.DeserializeFromFile(tempFile.Name(), &model2)
+	require.NoError(t, err)
+	require.Equal(t, model.Config, model2.Config)
+	require.Len(t, model2.Vectors, 4)
+	for i, v := range model.Vectors {
+		require.Equal(t, v.Value().Data(), model2.Vectors[i].Value().Data())
+	}
+}
+
+func newTestModel() *Model {
+	model := New(Config{
+		Size:            2,
+		NumOfEmbeddings: 4,
+		Trainable:       true,
+	})
+	model.Vectors[1].Value().SetData([]mat.Float{0.1, 0.2})
+	model.Vectors[2].Value().SetData([]mat.Float{0.3, 0.4})
+	model.Vectors[3].Value().SetData([]mat.Float{0.5, -0.6})
+	return model
+}
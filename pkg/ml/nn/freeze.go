@@ -0,0 +1,126 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"path"
+	"strings"
+)
+
+// ParamSelector selects a subset of the parameters of a model, by path, name and type.
+// A new ParamSelector selects all the parameters; each method adds a condition that
+// a parameter must satisfy to be selected, so that conditions can be chained:
+//
+//	nn.Freeze(model, nn.SelectParams().PathPrefix("Encoder.Layers.0").Type(nn.Weights))
+//
+// See ForEachParamWithPath for the format of the paths.
+type ParamSelector struct {
+	conditions []func(path string, param Param) bool
+}
+
+// SelectParams returns a new ParamSelector, selecting all the parameters.
+func SelectParams() *ParamSelector {
+	return &ParamSelector{}
+}
+
+// Where adds a custom condition.
+func (s *ParamSelector) Where(condition func(path string, param Param) bool) *ParamSelector {
+	s.conditions = append(s.conditions, condition)
+	return s
+}
+
+// PathPrefix selects the parameters of the subtree rooted at one of the given paths
+// (e.g. "Encoder.Layers.0" selects "Encoder.Layers.0.FFN.W", but not "Encoder.Layers.01.FFN.W").
+func (s *ParamSelector) PathPrefix(prefixes ...string) *ParamSelector {
+	return s.Where(func(path string, _ Param) bool {
+		for _, prefix := range prefixes {
+			if path == prefix || strings.HasPrefix(path, prefix+".") {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// PathMatch selects the parameters whose path matches the given shell pattern, as
+// defined by path.Match, using dots as separators instead of slashes
+// (e.g. "Encoder.Layers.*.SelfAttention.*").
+func (s *ParamSelector) PathMatch(pattern string) *ParamSelector {
+	pattern = strings.ReplaceAll(pattern, ".", "/")
+	return s.Where(func(p string, _ Param) bool {
+		matched, err := path.Match(pattern, strings.ReplaceAll(p, ".", "/"))
+		if err != nil {
+			panic(err)
+		}
+		return matched
+	})
+}
+
+// Name selects the parameters with one of the given names (i.e. the name of the struct field, lowercase).
+func (s *ParamSelector) Name(names ...string) *ParamSelector {
+	return s.Where(func(_ string, param Param) bool {
+		for _, name := range names {
+			if param.Name() == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Type selects the parameters of the given type.
+func (s *ParamSelector) Type(paramType ParamsType) *ParamSelector {
+	return s.Where(func(_ string, param Param) bool {
+		return param.Type() == paramType
+	})
+}
+
+// Not selects the parameters that are not selected by the given selector.
+func (s *ParamSelector) Not(other *ParamSelector) *ParamSelector {
+	return s.Where(func(path string, param Param) bool {
+		return !other.Selects(path, param)
+	})
+}
+
+// Selects reports whether the parameter with the given path satisfies all the conditions.
+func (s *ParamSelector) Selects(path string, param Param) bool {
+	for _, condition := range s.conditions {
+		if !condition(path, param) {
+			return false
+		}
+	}
+	return true
+}
+
+// Freeze marks the selected parameters of the model (including sub-models) as non-trainable:
+// their gradients are neither computed nor optimized. It returns the number of frozen parameters.
+// A nil selector selects all the parameters.
+func Freeze(m Model, selector *ParamSelector) int {
+	return setRequiresGrad(m, selector, false)
+}
+
+// Unfreeze marks the selected parameters of the model (including sub-models) as trainable.
+// It returns the number of unfrozen parameters. A nil selector selects all the parameters.
+//
+// Freeze and Unfreeze can be combined to implement gradual unfreezing, e.g. freezing
+// the whole model at first, and unfreezing one layer more at each epoch, starting from the top.
+func Unfreeze(m Model, selector *ParamSelector) int {
+	return setRequiresGrad(m, selector, true)
+}
+
+func setRequiresGrad(m Model, selector *ParamSelector, value bool) int {
+	count := 0
+	ForEachParamWithPath(m, func(path string, param Param) {
+		if selector != nil && !selector.Selects(path, param) {
+			return
+		}
+		if !value {
+			param.ZeroGrad()
+		}
+		param.SetRequiresGrad(value)
+		count++
+	})
+	return count
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type freezeTestLayer struct {
+	ParamsTraversalBaseModel
+	W Param `spago:"type:weights"`
+	B Param `spago:"type:biases"`
+}
+
+type freezeTestModel struct {
+	ParamsTraversalBaseModel
+	Embeddings Param `spago:"type:weights"`
+	Layers     []*freezeTestLayer
+	Head       *freezeTestLayer
+}
+
+func newFreezeTestModel() *freezeTestModel {
+	newLayer := func() *freezeTestLayer {
+		return &freezeTestLayer{
+			W: NewParam(mat.NewEmptyDense(2, 2)),
+			B: NewParam(mat.NewEmptyVecDense(2)),
+		}
+	}
+	return &freezeTestModel{
+		Embeddings: NewParam(mat.NewEmptyDense(3, 2)),
+		Layers:     []*freezeTestLayer{newLayer(), newLayer()},
+		Head:       newLayer(),
+	}
+}
+
+func trainablePaths(m Model) []string {
+	var paths []string
+	ForEachParamWithPath(m, func(path string, param Param) {
+		if param.RequiresGrad() {
+			paths = append(paths, path)
+		}
+	})
+	return paths
+}
+
+func TestForEachParamWithPath(t *testing.T) {
+	var paths []string
+	ForEachParamWithPath(newFreezeTestModel(), func(path string, _ Param) {
+		paths = append(paths, path)
+	})
+	assert.Equal(t, []string{
+		"Embeddings",
+		"Layers.0.W",
+		"Layers.0.B",
+		"Layers.1.W",
+		"Layers.1.B",
+		"Head.W",
+		"Head.B",
+	}, paths)
+}
+
+func TestFreeze(t *testing.T) {
+	t.Run("nil selector", func(t *testing.T) {
+		m := newFreezeTestModel()
+		assert.Equal(t, 7, Freeze(m, nil))
+		assert.Empty(t, trainablePaths(m))
+		assert.Equal(t, 7, Unfreeze(m, nil))
+		assert.Len(t, trainablePaths(m), 7)
+	})
+
+	t.Run("path prefix", func(t *testing.T) {
+		m := newFreezeTestModel()
+		assert.Equal(t, 5, Freeze(m, SelectParams().PathPrefix("Embeddings", "Layers")))
+		assert.Equal(t, []string{"Head.W", "Head.B"}, trainablePaths(m))
+	})
+
+	t.Run("path match and type", func(t *testing.T) {
+		m := newFreezeTestModel()
+		assert.Equal(t, 2, Freeze(m, SelectParams().PathMatch("Layers.*.*").Type(Weights)))
+		assert.Equal(t, []string{"Embeddings", "Layers.0.B", "Layers.1.B", "Head.W", "Head.B"}, trainablePaths(m))
+	})
+
+	t.Run("name and not", func(t *testing.T) {
+		m := newFreezeTestModel()
+		assert.Equal(t, 2, Freeze(m, SelectParams().Name("b").Not(SelectParams().PathPrefix("Head"))))
+		assert.Equal(t, []string{"Embeddings", "Layers.0.W", "Layers.1.W", "Head.W", "Head.B"}, trainablePaths(m))
+	})
+
+	t.Run("gradual unfreezing", func(t *testing.T) {
+		m := newFreezeTestModel()
+		Freeze(m, nil)
+		Unfreeze(m, SelectParams().PathPrefix("Head"))
+		assert.Equal(t, []string{"Head.W", "Head.B"}, trainablePaths(m))
+		Unfreeze(m, SelectParams().PathPrefix("Layers.1"))
+		assert.Equal(t, []string{"Layers.1.W", "Layers.1.B", "Head.W", "Head.B"}, trainablePaths(m))
+	})
+
+	t.Run("frozen params discard the gradients", func(t *testing.T) {
+		m := newFreezeTestModel()
+		m.Head.W.PropagateGrad(mat.NewInitDense(2, 2, 1.0))
+		Freeze(m, SelectParams().PathPrefix("Head"))
+		assert.False(t, m.Head.W.HasGrad())
+		m.Head.W.PropagateGrad(mat.NewInitDense(2, 2, 1.0))
+		assert.False(t, m.Head.W.HasGrad())
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package glu implements the feed-forward blocks based on gated linear units,
+// as described in "GLU Variants Improve Transformer" by Noam Shazeer, 2020
+// (https://arxiv.org/abs/2002.05202).
+//
+// The output is computed as Down(act(Gate(x)) ⊙ Up(x)), where the activation
+// function determines the variant:
+//   ag.OpSigmoid  GLU
+//   ag.OpSiLU     SwiGLU (e.g. LLaMA)
+//   ag.OpGELU     GeGLU (e.g. T5 v1.1)
+//   ag.OpReLU     ReGLU
+package glu
+
+import (
+	"encoding/gob"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+)
+
+var (
+	_ nn.StandardModel = &Model{}
+)
+
+// Config provides configuration settings for a gated feed-forward Model.
+type Config struct {
+	InputSize  int
+	HiddenSize int
+	OutputSize int
+	Activation ag.OpName
+	// UseBias reports whether the linear projections use a bias. If false,
+	// the biases are kept to zero, as in T5 v1.1 and LLaMA.
+	UseBias bool
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	nn.BaseModel
+	Config     Config
+	Gate       *linear.Model
+	Up         *linear.Model
+	Down       *linear.Model
+	Activation *activation.Model
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new model with parameters initialized to zeros.
+func New(config Config) *Model {
+	return &Model{
+		Config:     config,
+		Gate:       linear.New(config.InputSize, config.HiddenSize, linear.BiasGrad(config.UseBias)),
+		Up:         linear.New(config.InputSize, config.HiddenSize, linear.BiasGrad(config.UseBias)),
+		Down:       linear.New(config.HiddenSize, config.OutputSize, linear.BiasGrad(config.UseBias)),
+		Activation: activation.New(config.Activation),
+	}
+}
+
+// NewGLU returns a new GLU feed-forward model, with biases.
+func NewGLU(in, hidden, out int) *Model {
+	return New(Config{InputSize: in, HiddenSize: hidden, OutputSize: out, Activation: ag.OpSigmoid, UseBias: true})
+}
+
+// NewSwiGLU returns a new SwiGLU feed-forward model, without biases.
+func NewSwiGLU(in, hidden, out int) *Model {
+	return New(Config{InputSize: in, HiddenSize: hidden, OutputSize: out, Activation: ag.OpSiLU})
+}
+
+// NewGeGLU returns a new GeGLU feed-forward model, without biases.
+func NewGeGLU(in, hidden, out int) *Model {
+	return New(Config{InputSize: in, HiddenSize: hidden, OutputSize: out, Activation: ag.OpGELU})
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	gates := m.Activation.Forward(m.Gate.Forward(xs...)...)
+	ups := m.Up.Forward(xs...)
+	hs := make([]ag.Node, len(xs))
+	for i := range hs {
+		hs[i] = g.Prod(gates[i], ups[i])
+	}
+	return m.Down.Forward(hs...)
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glu
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestModel_Forward(t *testing.T) {
+	model := New(Config{
+		InputSize:  2,
+		HiddenSize: 2,
+		OutputSize: 1,
+		Activation: ag.OpSigmoid,
+	})
+	model.Gate.W.Value().SetData([]mat.Float{1, 0, 0, 1})
+	model.Up.W.Value().SetData([]mat.Float{1, 1, 0, 2})
+	model.Down.W.Value().SetData([]mat.Float{1, -1})
+
+	g := ag.NewGraph()
+	ctx := nn.Context{Graph: g, Mode: nn.Training}
+
+	// == Forward
+
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), true)
+	y := nn.ToNode(nn.Reify(ctx, model).(*Model).Forward(x))
+
+	assert.InDeltaSlice(t, []mat.Float{-1.3300126}, y.Value().Data(), 1.0e-06)
+
+	// == Backward
+
+	g.Backward(y, ag.OutputGrad(mat.NewVecDense([]mat.Float{1})))
+
+	assert.InDeltaSlice(t, []mat.Float{1.3208943, -1.4505099}, x.Grad().Data(), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{2.1931758, 3.5231884}, model.Down.W.Grad().Data(), 1.0e-06)
+	assert.False(t, model.Gate.B.RequiresGrad())
+	assert.False(t, model.Up.B.RequiresGrad())
+	assert.False(t, model.Down.B.RequiresGrad())
+}
+
+func TestNewSwiGLU(t *testing.T) {
+	model := NewSwiGLU(4, 8, 4)
+	assert.Equal(t, ag.OpSiLU, model.Activation.Activation)
+	assert.False(t, model.Config.UseBias)
+
+	model = NewGLU(4, 8, 4)
+	assert.Equal(t, ag.OpSigmoid, model.Activation.Activation)
+	assert.True(t, model.Up.B.RequiresGrad())
+}
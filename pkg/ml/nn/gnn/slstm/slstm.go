@@ -162,49 +162,37 @@ func (m *Model) computeUx(xs []ag.Node) {
 	m.Support.xUo = make([]ag.Node, n)
 	m.Support.xUu = make([]ag.Node, n)
 
-	var wg sync.WaitGroup
-	wg.Add(n)
-	for i := 0; i < n; i++ {
-		go func(i int) {
-			defer wg.Done()
-			m.Support.xUi[i] = g.Mul(m.InputGate.U, xs[i])
-			m.Support.xUl[i] = g.Mul(m.LeftCellGate.U, xs[i])
-			m.Support.xUr[i] = g.Mul(m.RightCellGate.U, xs[i])
-			m.Support.xUf[i] = g.Mul(m.CellGate.U, xs[i])
-			m.Support.xUs[i] = g.Mul(m.SentCellGate.U, xs[i])
-			m.Support.xUo[i] = g.Mul(m.OutputGate.U, xs[i])
-			m.Support.xUu[i] = g.Mul(m.InputActivation.U, xs[i])
-		}(i)
-	}
-	wg.Wait()
+	m.forEach(n, func(i int) {
+		m.Support.xUi[i] = g.Mul(m.InputGate.U, xs[i])
+		m.Support.xUl[i] = g.Mul(m.LeftCellGate.U, xs[i])
+		m.Support.xUr[i] = g.Mul(m.RightCellGate.U, xs[i])
+		m.Support.xUf[i] = g.Mul(m.CellGate.U, xs[i])
+		m.Support.xUs[i] = g.Mul(m.SentCellGate.U, xs[i])
+		m.Support.xUo[i] = g.Mul(m.OutputGate.U, xs[i])
+		m.Support.xUu[i] = g.Mul(m.InputActivation.U, xs[i])
+	})
 }
 
 func (m *Model) computeVg(prevG ag.Node) {
 	g := m.Graph()
-	var wg sync.WaitGroup
-	wg.Add(7)
-	for i := 0; i < 7; i++ {
-		go func(i int) {
-			defer wg.Done()
-			switch i {
-			case 0:
-				m.Support.ViPrevG = g.Mul(m.InputGate.V, prevG)
-			case 1:
-				m.Support.VlPrevG = g.Mul(m.LeftCellGate.V, prevG)
-			case 2:
-				m.Support.VrPrevG = g.Mul(m.RightCellGate.V, prevG)
-			case 3:
-				m.Support.VfPrevG = g.Mul(m.CellGate.V, prevG)
-			case 4:
-				m.Support.VsPrevG = g.Mul(m.SentCellGate.V, prevG)
-			case 5:
-				m.Support.VoPrevG = g.Mul(m.OutputGate.V, prevG)
-			case 6:
-				m.Support.VuPrevG = g.Mul(m.InputActivation.U, prevG)
-			}
-		}(i)
-	}
-	wg.Wait()
+	m.forEach(7, func(i int) {
+		switch i {
+		case 0:
+			m.Support.ViPrevG = g.Mul(m.InputGate.V, prevG)
+		case 1:
+			m.Support.VlPrevG = g.Mul(m.LeftCellGate.V, prevG)
+		case 2:
+			m.Support.VrPrevG = g.Mul(m.RightCellGate.V, prevG)
+		case 3:
+			m.Support.VfPrevG = g.Mul(m.CellGate.V, prevG)
+		case 4:
+			m.Support.VsPrevG = g.Mul(m.SentCellGate.V, prevG)
+		case 5:
+			m.Support.VoPrevG = g.Mul(m.OutputGate.V, prevG)
+		case 6:
+			m.Support.VuPrevG = g.Mul(m.InputActivation.U, prevG)
+		}
+	})
 }
 
 func (m *Model) processNode(i int, prevH []ag.Node, prevC []ag.Node, prevG ag.Node) (h ag.Node, c ag.Node) {
@@ -248,19 +236,32 @@ func (m *Model) processNode(i int, prevH []ag.Node, prevC []ag.Node, prevG ag.No
 }
 
 func (m *Model) updateHiddenNodes(prevH []ag.Node, prevC []ag.Node, prevG ag.Node) ([]ag.Node, []ag.Node) {
-	var wg sync.WaitGroup
 	n := len(prevH)
-	wg.Add(n)
 	h := make([]ag.Node, n)
 	c := make([]ag.Node, n)
+	m.forEach(n, func(i int) {
+		h[i], c[i] = m.processNode(i, prevH, prevC, prevG)
+	})
+	return h, c
+}
+
+// forEach calls fn for each index in [0, n), concurrently if the graph allows concurrent computations.
+func (m *Model) forEach(n int, fn func(i int)) {
+	if m.Graph().ConcurrentComputations() == 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	wg.Add(n)
 	for i := 0; i < n; i++ {
 		go func(i int) {
 			defer wg.Done()
-			h[i], c[i] = m.processNode(i, prevH, prevC, prevG)
+			fn(i)
 		}(i)
 	}
 	wg.Wait()
-	return h, c
 }
 
 func (m *Model) updateSentenceState(prevH []ag.Node, prevC []ag.Node, prevG ag.Node) (ag.Node, ag.Node) {
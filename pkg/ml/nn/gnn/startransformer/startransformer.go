@@ -88,11 +88,16 @@ func (m *Model) copy(xs []ag.Node) []ag.Node {
 func (m *Model) updateSatelliteNodes(prevH []ag.Node, prevS ag.Node, residual []ag.Node) []ag.Node {
 	g := m.Graph()
 	n := len(prevH)
-	var wg sync.WaitGroup
-	wg.Add(n)
 	h := make([]ag.Node, n)
 	first := 0
 	last := n - 1
+	update := func(i, j, k int) {
+		context := []ag.Node{prevH[j], prevH[i], prevH[k], residual[i], prevS}
+		h[i] = m.satelliteAttention(prevH[i], context)
+		h[i] = nn.ToNode(m.SatelliteNorm.Forward(g.ReLU(h[i])))
+	}
+	concurrent := g.ConcurrentComputations() > 1
+	var wg sync.WaitGroup
 	for i := 0; i < n; i++ {
 		j := i - 1
 		k := i + 1
@@ -102,11 +107,14 @@ func (m *Model) updateSatelliteNodes(prevH []ag.Node, prevS ag.Node, residual []
 		if k > last {
 			k = first
 		}
+		if !concurrent {
+			update(i, j, k)
+			continue
+		}
+		wg.Add(1)
 		go func(i, j, k int) {
 			defer wg.Done()
-			context := []ag.Node{prevH[j], prevH[i], prevH[k], residual[i], prevS}
-			h[i] = m.satelliteAttention(prevH[i], context)
-			h[i] = nn.ToNode(m.SatelliteNorm.Forward(g.ReLU(h[i])))
+			update(i, j, k)
 		}(i, j, k)
 	}
 	wg.Wait()
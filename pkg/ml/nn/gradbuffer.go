@@ -0,0 +1,110 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"sync"
+)
+
+// GradientBuffer accumulates the gradients of the params of the processors reified in training mode
+// with it (see Context.Gradients), in place of the params themselves.
+//
+// Each of the workers training the same model concurrently (e.g. Hogwild!) uses its own buffer, so
+// that the backward steps of the workers never contend for the params, nor mix their gradients.
+type GradientBuffer struct {
+	mu    sync.Mutex
+	grads map[*param]*bufferedGrad
+	// ordered are the gradients in the order in which the params have been reified.
+	ordered []*bufferedGrad
+}
+
+// NewGradientBuffer returns a new empty GradientBuffer.
+func NewGradientBuffer() *GradientBuffer {
+	return &GradientBuffer{grads: make(map[*param]*bufferedGrad)}
+}
+
+// get returns the gradients of the param in the buffer, creating them if needed.
+func (b *GradientBuffer) get(p *param) *bufferedGrad {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g, ok := b.grads[p]
+	if !ok {
+		g = &bufferedGrad{param: p}
+		b.grads[p] = g
+		b.ordered = append(b.ordered, g)
+	}
+	return g
+}
+
+// ForEach calls the callback for each param with accumulated gradients, in the order in which the
+// params have been reified.
+func (b *GradientBuffer) ForEach(callback func(param Param, grad mat.Matrix)) {
+	b.mu.Lock()
+	grads := append([]*bufferedGrad(nil), b.ordered...)
+	b.mu.Unlock()
+	for _, g := range grads {
+		if g.HasGrad() {
+			callback(g.param, g.grad)
+		}
+	}
+}
+
+// ZeroGrad clears the accumulated gradients, keeping the buffer usable by the processors reified
+// with it.
+func (b *GradientBuffer) ZeroGrad() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, g := range b.grads {
+		g.ZeroGrad()
+	}
+}
+
+var _ ag.GradValue = &bufferedGrad{}
+
+// bufferedGrad is the value of a param, whose gradients are accumulated apart from the param.
+type bufferedGrad struct {
+	*param
+	mu      sync.Mutex
+	grad    mat.Matrix
+	hasGrad bool
+}
+
+// Grad returns the gradients accumulated in the buffer.
+func (r *bufferedGrad) Grad() mat.Matrix {
+	return r.grad
+}
+
+// PropagateGrad accumulates the gradients in the buffer.
+func (r *bufferedGrad) PropagateGrad(grad mat.Matrix) {
+	if !r.requiresGrad {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.grad == nil {
+		r.grad = mat.GetEmptyDenseWorkspace(r.Value().Dims())
+	}
+	r.grad.AddInPlace(grad)
+	r.hasGrad = true
+}
+
+// HasGrad returns true if there are gradients accumulated in the buffer.
+func (r *bufferedGrad) HasGrad() bool {
+	return r.hasGrad
+}
+
+// ZeroGrad clears the gradients accumulated in the buffer.
+func (r *bufferedGrad) ZeroGrad() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.grad == nil {
+		return
+	}
+	mat.ReleaseMatrix(r.grad)
+	r.grad = nil
+	r.hasGrad = false
+}
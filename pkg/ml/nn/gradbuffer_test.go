@@ -0,0 +1,73 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+type gradBufferTestModel struct {
+	BaseModel
+	W Param `spago:"type:weights"`
+	B Param `spago:"type:biases"`
+}
+
+func TestGradientBuffer(t *testing.T) {
+	m := &gradBufferTestModel{
+		W: NewParam(mat.NewDense(2, 2, []mat.Float{1, 2, 3, 4})),
+		B: NewParam(mat.NewVecDense([]mat.Float{1, 1}), RequiresGrad(false)),
+	}
+	buf := NewGradientBuffer()
+
+	g := ag.NewGraph()
+	proc := Reify(Context{Graph: g, Mode: Training, Gradients: buf}, m).(*gradBufferTestModel)
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1, 2}), false)
+	y := g.Add(g.Mul(proc.W, x), proc.B)
+	g.Backward(g.ReduceSum(y))
+
+	// the gradients are in the buffer, not in the params
+	assert.False(t, m.W.HasGrad())
+	assert.True(t, proc.W.HasGrad())
+	var params []Param
+	buf.ForEach(func(param Param, grad mat.Matrix) {
+		params = append(params, param)
+		assert.Equal(t, []mat.Float{1, 2, 1, 2}, grad.Data())
+	})
+	assert.Equal(t, []Param{m.W}, params)
+
+	buf.ZeroGrad()
+	assert.False(t, proc.W.HasGrad())
+	buf.ForEach(func(Param, mat.Matrix) { t.Fail() })
+}
+
+func TestApplyDeltaAtomic(t *testing.T) {
+	p := NewParam(mat.NewVecDense([]mat.Float{0, 10}))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				ApplyDeltaAtomic(p, mat.NewVecDense([]mat.Float{1, -1}))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []mat.Float{-8000, 8010}, p.Value().Data())
+
+	// a shared value is replaced (copy-on-write)
+	value := p.(*param).share()
+	ApplyDeltaAtomic(p, mat.NewVecDense([]mat.Float{1, 1}))
+	assert.Equal(t, []mat.Float{-8000, 8010}, value.Data())
+	assert.Equal(t, []mat.Float{-8001, 8009}, p.Value().Data())
+
+	assert.Panics(t, func() {
+		ApplyDeltaAtomic(NewParam(mat.NewVecDense([]mat.Float{1})), mat.NewVecDense([]mat.Float{1, 2}))
+	})
+}
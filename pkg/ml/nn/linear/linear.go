@@ -9,7 +9,9 @@ import (
 	"sync"
 
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 )
 
@@ -22,6 +24,10 @@ type Model struct {
 	nn.BaseModel
 	W nn.Param `spago:"type:weights"`
 	B nn.Param `spago:"type:biases"`
+	// DropConnect is the probability of dropping each weight during training (see the DropConnect option).
+	DropConnect mat.Float
+	// droppedW are the weights after the DropConnect, sampled at the first forward of the processor.
+	droppedW ag.Node
 }
 
 // Option allows to configure a new Model with your specific needs.
@@ -34,6 +40,29 @@ func BiasGrad(enable bool) Option {
 	}
 }
 
+// InitWeights initializes the weights with the initializer registered with the given
+// name (e.g. "xavier_uniform", "kaiming_normal"). See initializers.Get.
+// It panics if the initializer is not registered.
+func InitWeights(name string, generator *rand.LockedRand) Option {
+	initializer := initializers.MustGet(name)
+	return func(m *Model) {
+		initializer(m.W.Value(), generator)
+	}
+}
+
+// DropConnect sets the probability of dropping each weight during training, instead of the inputs or the
+// outputs. The same weights are dropped for all the inputs processed by a processor, so that the mask is
+// shared by the time steps when the model is used by a recurrent network. In Inference mode, all the
+// weights are used.
+//
+// Reference: "Regularization of Neural Networks using DropConnect" by Wan et al., 2013
+// (http://proceedings.mlr.press/v28/wan13.pdf).
+func DropConnect(p mat.Float) Option {
+	return func(m *Model) {
+		m.DropConnect = p
+	}
+}
+
 func init() {
 	gob.Register(&Model{})
 }
@@ -52,28 +81,40 @@ func New(in, out int, options ...Option) *Model {
 
 // Forward performs the forward step for each input node and returns the result.
 func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	w := m.weights()
 	if len(xs) > 1 && m.Graph().ConcurrentComputations() > 1 {
-		return m.fwdConcurrent(xs)
+		return m.fwdConcurrent(w, xs)
+	}
+	return m.fwdSerial(w, xs)
+}
+
+// weights returns the weights to use in the forward, after the DropConnect in training mode.
+func (m *Model) weights() ag.Node {
+	if m.Mode() != nn.Training || m.DropConnect <= 0.0 {
+		return m.W
+	}
+	if m.droppedW == nil {
+		m.droppedW = m.Graph().Dropout(m.W, m.DropConnect)
 	}
-	return m.fwdSerial(xs)
+	return m.droppedW
 }
 
-func (m *Model) fwdSerial(xs []ag.Node) []ag.Node {
+func (m *Model) fwdSerial(w ag.Node, xs []ag.Node) []ag.Node {
 	ys := make([]ag.Node, len(xs))
 	for i, x := range xs {
-		ys[i] = m.forward(x)
+		ys[i] = m.forward(w, x)
 	}
 	return ys
 }
 
-func (m *Model) fwdConcurrent(xs []ag.Node) []ag.Node {
+func (m *Model) fwdConcurrent(w ag.Node, xs []ag.Node) []ag.Node {
 	ys := make([]ag.Node, len(xs))
 	var wg sync.WaitGroup
 	wg.Add(len(xs))
 	for i := range xs {
 		go func(i int) {
 			defer wg.Done()
-			ys[i] = m.forward(xs[i])
+			ys[i] = m.forward(w, xs[i])
 		}(i)
 	}
 	wg.Wait()
@@ -81,6 +122,6 @@ func (m *Model) fwdConcurrent(xs []ag.Node) []ag.Node {
 }
 
 // y = w (dot) x + b
-func (m *Model) forward(x ag.Node) ag.Node {
-	return nn.Affine(m.Graph(), m.B, m.W, x)
+func (m *Model) forward(w, x ag.Node) ag.Node {
+	return nn.Affine(m.Graph(), m.B, w, x)
 }
@@ -6,6 +6,7 @@ package linear
 
 import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/losses"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
@@ -51,6 +52,42 @@ func TestModel_Forward(t *testing.T) {
 	}, model.B.Grad().Data(), 1.0e-05)
 }
 
+func TestInitWeights(t *testing.T) {
+	model := New(4, 3, InitWeights("orthogonal", rand.NewLockedRand(42)))
+	product := model.W.Value().Mul(model.W.Value().T())
+	assert.InDeltaSlice(t, []mat.Float{1, 0, 0, 0, 1, 0, 0, 0, 1}, product.Data(), 1.0e-5)
+	assert.Equal(t, []mat.Float{0, 0, 0}, model.B.Value().Data())
+
+	assert.Panics(t, func() { New(4, 3, InitWeights("foo", rand.NewLockedRand(42))) })
+}
+
+func TestDropConnect(t *testing.T) {
+	model := New(4, 16, DropConnect(0.5))
+	model.W.Value().SetData(mat.NewInitVecDense(64, 1.0).Data())
+	x := mat.NewVecDense([]mat.Float{1.0, 0.0, 0.0, 0.0})
+
+	g := ag.NewGraph(ag.RandSeed(42))
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+	ys := proc.Forward(g.NewVariable(x, false), g.NewVariable(x, false))
+	ys = append(ys, proc.Forward(g.NewVariable(x, false))...)
+	dropped := ys[0].Value().Data() // the first column of the weights
+	assert.Contains(t, dropped, mat.Float(0.0))
+	assert.Contains(t, dropped, mat.Float(2.0))
+	for _, y := range ys[1:] {
+		assert.Equal(t, dropped, y.Value().Data()) // the same weights for all the inputs
+	}
+
+	g.Backward(g.ReduceSum(ys[0]))
+	for i, v := range dropped {
+		assert.Equal(t, v, model.W.Grad().At(i, 0)) // no gradients for the dropped weights
+	}
+
+	g = ag.NewGraph()
+	proc = nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	y := proc.Forward(g.NewVariable(x, false))[0]
+	assert.Equal(t, mat.NewInitVecDense(16, 1.0).Data(), y.Value().Data())
+}
+
 func newTestModel() *Model {
 	model := New(4, 5)
 	model.W.Value().SetData([]mat.Float{
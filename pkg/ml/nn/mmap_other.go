@@ -0,0 +1,17 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package nn
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func mapFile(_ *os.File, _ int64) ([]byte, error) {
+	return nil, fmt.Errorf("nn: memory-mapped model files are not supported on %s", runtime.GOOS)
+}
@@ -27,6 +27,9 @@ type Context struct {
 	Graph *ag.Graph
 	// Mode regulates the different usage of some operations whether you're doing training or inference.
 	Mode ProcessingMode
+	// Gradients, if not nil, accumulates the gradients of the params in training mode, in place of
+	// the params themselves (see GradientBuffer).
+	Gradients *GradientBuffer
 }
 
 // MarshalBinary satisfies package pkg/encoding/gob custom marshaling interface
@@ -80,6 +83,11 @@ type StandardModel interface {
 }
 
 // Reify returns a new "reified" model (a.k.a. processor) to execute the forward step.
+//
+// In Inference mode, the params of the processor are read-only views of the params of the model: they are
+// shared without copies, so that any number of processors can safely operate concurrently on the same model,
+// even while it is being trained (the training updates are applied with copy-on-write). Modifying the params
+// of a processor in inference mode panics.
 func Reify(ctx Context, m Model) Model {
 	return reifier{ctx: ctx}.reify(m)
 }
@@ -89,6 +97,13 @@ func ForEachParam(m Model, callback func(param Param)) {
 	newParamsTraversal(callback, true).walk(m)
 }
 
+// ForEachParamWithPath iterate all the parameters of a model also exploring the sub-parameters recursively,
+// passing to the callback the path of each parameter in the model tree. The path is made of the names of
+// the struct fields, slice indices and map keys, joined by dots (e.g. "Encoder.Layers.0.FFN.W").
+func ForEachParamWithPath(m Model, callback func(path string, param Param)) {
+	newPathParamsTraversal(callback, true).walk(m)
+}
+
 // ForEachParamStrict iterate all the parameters of a model without exploring the sub-models.
 func ForEachParamStrict(m Model, callback func(param Param)) {
 	newParamsTraversal(callback, false).walk(m)
@@ -0,0 +1,656 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+// ModelFileVersion is the current version of the format of the model files.
+//
+// A model file is made of:
+//   - the magic bytes "SPAGOMDL" and the format version (big-endian uint32);
+//   - the length (big-endian uint32) and the JSON encoding of a ModelFileHeader;
+//   - the gob encoding of the model (the payload), without the values of the dense parameters;
+//   - the tensors section, with the binary encoding of the values of the dense parameters, each one
+//     aligned to 64 bytes, so that they can be read concurrently or on first access;
+//   - the length of the payload (big-endian uint64) and its SHA-256 checksum.
+//
+// In the files of version 1 the values of all the parameters are in the payload, and there is no
+// tensors section. Files without the magic bytes are raw gob dumps of the models, written by the
+// older versions of spaGO, and they are read as files of version 0.
+const ModelFileVersion = 2
+
+var modelFileMagic = []byte("SPAGOMDL")
+
+const (
+	modelFilePrefixSize  = 16 // magic bytes, version and header length
+	modelFileTrailerSize = 8 + sha256.Size
+	tensorAlignment      = 64
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// ModelFileHeader describes the model stored in a model file.
+type ModelFileHeader struct {
+	// Version is the version of the format of the file.
+	Version int `json:"version"`
+	// Architecture identifies the type of the model, e.g. "*bert.Model".
+	Architecture string `json:"architecture"`
+	// Config is the JSON encoding of the Config field of the model, if any.
+	Config json.RawMessage `json:"config,omitempty"`
+	// Tensors is the index of the parameters of the model, sorted by path.
+	Tensors []TensorInfo `json:"tensors"`
+	// PayloadSize is the size in bytes of the gob payload.
+	PayloadSize int64 `json:"payload_size,omitempty"`
+}
+
+// TensorInfo describes a parameter of the model stored in a model file.
+type TensorInfo struct {
+	Path string `json:"path"`
+	Rows int    `json:"rows"`
+	Cols int    `json:"cols"`
+	// Offset is the position of the value in the tensors section, and Length is its size in bytes.
+	// The Length is zero if the value is in the gob payload.
+	Offset int64 `json:"offset,omitempty"`
+	Length int64 `json:"length,omitempty"`
+	// Checksum is the CRC-32 (Castagnoli) checksum of the value in the tensors section.
+	Checksum uint32 `json:"checksum,omitempty"`
+}
+
+// LoadOption allows to configure the loading of a model file.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	workers   int
+	lazy      bool
+	memoryMap bool
+}
+
+// LoadWorkers sets the number of goroutines reading the values of the parameters concurrently
+// (default runtime.NumCPU()).
+func LoadWorkers(n int) LoadOption {
+	return func(o *loadOptions) {
+		o.workers = n
+	}
+}
+
+// LazyLoad defers the reading of the values of the parameters to their first access, reducing
+// the load time and the memory of the parameters that are never used. A value that cannot be read
+// (e.g. because the file has been corrupted) causes a panic on access. The model file is kept open
+// and must not be modified until all the values have been read.
+func LazyLoad() LoadOption {
+	return func(o *loadOptions) {
+		o.lazy = true
+	}
+}
+
+// MemoryMap makes LoadModel map the model file read-only into memory, and use the values of the
+// parameters in place, without copying them. The server processes of the same host that load the
+// same file share its memory (the page cache), instead of each holding a private copy of the values.
+// The values of the parameters must not be modified in place: ApplyDelta replaces them with updated
+// copies. Memory mapping is supported on Linux, macOS and the BSDs.
+func MemoryMap() LoadOption {
+	return func(o *loadOptions) {
+		o.memoryMap = true
+	}
+}
+
+// mappedFile is a model file mapped into memory.
+type mappedFile struct {
+	*bytes.Reader
+	data []byte
+}
+
+// ModelMigration upgrades a model read from a file of an older format version, e.g. to initialize
+// the parameters introduced by a refactoring.
+type ModelMigration func(header ModelFileHeader, m Model) error
+
+type modelMigration struct {
+	toVersion int
+	migrate   ModelMigration
+}
+
+var (
+	modelFileMu       sync.RWMutex
+	modelMigrations   = make(map[string][]modelMigration)
+	architectureAlias = make(map[string]string)
+)
+
+// RegisterModelMigration registers a migration for the models of the given architecture, which is
+// applied when reading the files with a format version lower than toVersion.
+// The migrations are applied in order of registration.
+func RegisterModelMigration(architecture string, toVersion int, migrate ModelMigration) {
+	modelFileMu.Lock()
+	defer modelFileMu.Unlock()
+	modelMigrations[architecture] = append(modelMigrations[architecture], modelMigration{
+		toVersion: toVersion,
+		migrate:   migrate,
+	})
+}
+
+// RegisterArchitectureAlias allows to read the files of a model whose type has been renamed or
+// moved, from the old architecture identifier to the new one (e.g. "*bert.Model").
+func RegisterArchitectureAlias(oldArchitecture, newArchitecture string) {
+	modelFileMu.Lock()
+	defer modelFileMu.Unlock()
+	architectureAlias[oldArchitecture] = newArchitecture
+}
+
+// SaveModel writes the model to file, in the versioned format of the model files.
+func SaveModel(filename string, m Model) (err error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+	buf := bufio.NewWriter(f) // Buffered writing is essential to avoid memory leaks with large data
+	if err := WriteModel(buf, m); err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// LoadModel reads the model from file into m, which must be of the same type of the stored model.
+// Both the versioned model files and the raw gob dumps of the older versions of spaGO are supported.
+func LoadModel(filename string, m Model, opts ...LoadOption) (header ModelFileHeader, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return header, err
+	}
+	o := newLoadOptions(opts)
+	defer func() {
+		if o.lazy && !o.memoryMap && err == nil {
+			return // the file is closed when the values have been read, and it is no longer referenced
+		}
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return header, err
+	}
+	if o.memoryMap && info.Size() > 0 {
+		data, err := mapFile(f, info.Size())
+		if err != nil {
+			return header, fmt.Errorf("nn: %w", err)
+		}
+		return ReadModel(&mappedFile{Reader: bytes.NewReader(data), data: data}, info.Size(), m, opts...)
+	}
+	return ReadModel(f, info.Size(), m, opts...)
+}
+
+// ReadModelFileHeader reads the header of a model file, without reading the model.
+// The header of a raw gob dump of the older versions of spaGO is empty, with version 0.
+func ReadModelFileHeader(filename string) (ModelFileHeader, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return ModelFileHeader{}, err
+	}
+	defer f.Close()
+	header, _, err := readModelFileHeader(f)
+	return header, err
+}
+
+// WriteModel writes the model to w, in the versioned format of the model files.
+// The model must not be modified, or encoded with gob, while it is written.
+func WriteModel(w io.Writer, m Model) error {
+	header, detached, err := newModelFileHeader(m)
+	if err != nil {
+		return err
+	}
+	for _, p := range detached {
+		atomic.AddInt32(&p.detached, 1)
+	}
+	var payload bytes.Buffer
+	err = gob.NewEncoder(&payload).Encode(m)
+	for _, p := range detached {
+		atomic.AddInt32(&p.detached, -1)
+	}
+	if err != nil {
+		return err
+	}
+	header.PayloadSize = int64(payload.Len())
+	if err := indexTensors(&header, detached); err != nil {
+		return err
+	}
+
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, modelFilePrefixSize)
+	copy(prefix, modelFileMagic)
+	binary.BigEndian.PutUint32(prefix[8:], ModelFileVersion)
+	binary.BigEndian.PutUint32(prefix[12:], uint32(len(headerData)))
+	if _, err := w.Write(prefix); err != nil {
+		return err
+	}
+	if _, err := w.Write(headerData); err != nil {
+		return err
+	}
+	hw := &hashingWriter{w: w, hash: sha256.New()}
+	if _, err := hw.Write(payload.Bytes()); err != nil {
+		return err
+	}
+	payloadEnd := modelFilePrefixSize + int64(len(headerData)) + header.PayloadSize
+	if err := writeTensors(w, header.Tensors, payloadEnd, detached); err != nil {
+		return err
+	}
+	trailer := make([]byte, 8, modelFileTrailerSize)
+	binary.BigEndian.PutUint64(trailer, uint64(hw.n))
+	_, err = w.Write(hw.hash.Sum(trailer))
+	return err
+}
+
+// indexTensors sets the position, the length and the checksum of the values of the detached params
+// in the tensors section of the file.
+func indexTensors(header *ModelFileHeader, detached map[string]*param) error {
+	offset := int64(0)
+	for i, tensor := range header.Tensors {
+		p, ok := detached[tensor.Path]
+		if !ok {
+			continue
+		}
+		data, err := p.Value().(*mat.Dense).MarshalBinary()
+		if err != nil {
+			return err
+		}
+		header.Tensors[i].Offset = offset
+		header.Tensors[i].Length = int64(len(data))
+		header.Tensors[i].Checksum = crc32.Checksum(data, crc32c)
+		offset = alignOffset(offset + int64(len(data)))
+	}
+	return nil
+}
+
+// writeTensors writes the tensors section of the file, which starts at the first aligned position
+// after the payload.
+func writeTensors(w io.Writer, tensors []TensorInfo, payloadEnd int64, detached map[string]*param) error {
+	written := payloadEnd
+	for _, tensor := range tensors {
+		p, ok := detached[tensor.Path]
+		if !ok {
+			continue
+		}
+		start := alignOffset(payloadEnd) + tensor.Offset
+		if _, err := w.Write(make([]byte, start-written)); err != nil {
+			return err
+		}
+		data, err := p.Value().(*mat.Dense).MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		written = start + int64(len(data))
+	}
+	return nil
+}
+
+// ReadModel reads the model of the given size from r into m, which must be of the same type of the
+// stored model. Both the versioned model files and the raw gob dumps of the older versions of spaGO
+// are supported. The checksums and the tensors of the file are verified, and the registered
+// migrations are applied to the models of the older versions.
+//
+// The values of the parameters in the tensors section are read concurrently, or on first access
+// with LazyLoad, in which case r must remain readable until all the values have been read.
+func ReadModel(r io.ReaderAt, size int64, m Model, opts ...LoadOption) (ModelFileHeader, error) {
+	header, offset, err := readModelFileHeader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return header, err
+	}
+	architecture := architectureOf(m)
+	if header.Version == 0 {
+		header.Architecture = architecture
+		err := gob.NewDecoder(bufio.NewReader(io.NewSectionReader(r, 0, size))).Decode(m)
+		if err != nil {
+			return header, err
+		}
+		return header, migrateModel(header, m)
+	}
+
+	if resolveArchitectureAlias(header.Architecture) != architecture {
+		return header, fmt.Errorf("nn: the model file contains a %s, not a %s", header.Architecture, architecture)
+	}
+	payloadSize := size - offset - modelFileTrailerSize
+	tensorsStart := int64(0)
+	if header.Version > 1 {
+		payloadSize = header.PayloadSize
+		tensorsStart = alignOffset(offset + payloadSize)
+		end := offset + payloadSize
+		if n := tensorsEnd(header.Tensors); n > 0 {
+			end = tensorsStart + n
+		}
+		if end+modelFileTrailerSize != size {
+			return header, fmt.Errorf("nn: truncated model file")
+		}
+	}
+	trailer := make([]byte, modelFileTrailerSize)
+	if _, err := r.ReadAt(trailer, size-modelFileTrailerSize); err != nil || payloadSize < 0 {
+		return header, fmt.Errorf("nn: truncated model file")
+	}
+	if binary.BigEndian.Uint64(trailer) != uint64(payloadSize) {
+		return header, fmt.Errorf("nn: truncated model file")
+	}
+
+	h := sha256.New()
+	payload := io.TeeReader(io.NewSectionReader(r, offset, payloadSize), h)
+	decodeErr := gob.NewDecoder(bufio.NewReader(payload)).Decode(m)
+	if _, err := io.Copy(ioutil.Discard, payload); err != nil {
+		return header, err
+	}
+	if !bytes.Equal(h.Sum(nil), trailer[8:]) {
+		return header, fmt.Errorf("nn: checksum mismatch: the model file is corrupted")
+	}
+	if decodeErr != nil {
+		return header, decodeErr
+	}
+	if err := checkTensors(header, m); err != nil {
+		return header, err
+	}
+	if err := readTensors(r, tensorsStart, header, m, newLoadOptions(opts)); err != nil {
+		return header, err
+	}
+	return header, migrateModel(header, m)
+}
+
+// readModelFileHeader returns the header of a model file, and the offset of the payload.
+func readModelFileHeader(r io.Reader) (ModelFileHeader, int64, error) {
+	prefix := make([]byte, modelFilePrefixSize)
+	n, err := io.ReadFull(r, prefix)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return ModelFileHeader{}, 0, err
+	}
+	if n < modelFilePrefixSize || !bytes.Equal(prefix[:8], modelFileMagic) {
+		return ModelFileHeader{Version: 0}, 0, nil // raw gob dump
+	}
+	version := int(binary.BigEndian.Uint32(prefix[8:]))
+	if version > ModelFileVersion {
+		return ModelFileHeader{}, 0, fmt.Errorf("nn: unsupported model file version %d (the latest is %d)",
+			version, ModelFileVersion)
+	}
+	headerSize := int64(binary.BigEndian.Uint32(prefix[12:]))
+	var header ModelFileHeader
+	if err := json.NewDecoder(io.LimitReader(r, headerSize)).Decode(&header); err != nil {
+		return header, 0, fmt.Errorf("nn: invalid model file header: %w", err)
+	}
+	header.Version = version
+	return header, modelFilePrefixSize + headerSize, nil
+}
+
+// newModelFileHeader returns the header of the model file of m, and the params whose values are
+// written in the tensors section, by path.
+func newModelFileHeader(m Model) (ModelFileHeader, map[string]*param, error) {
+	header := ModelFileHeader{
+		Version:      ModelFileVersion,
+		Architecture: architectureOf(m),
+		Tensors:      make([]TensorInfo, 0),
+	}
+	v := reflect.Indirect(reflect.ValueOf(m))
+	if v.Kind() == reflect.Struct {
+		if config := v.FieldByName("Config"); config.IsValid() && config.CanInterface() {
+			data, err := json.Marshal(config.Interface())
+			if err != nil {
+				return header, nil, err
+			}
+			header.Config = data
+		}
+	}
+	detached := make(map[string]*param)
+	ForEachParamWithPath(m, func(path string, p Param) {
+		rows, cols := 0, 0
+		value := p.Value()
+		if value != nil {
+			rows, cols = value.Dims()
+		}
+		header.Tensors = append(header.Tensors, TensorInfo{Path: path, Rows: rows, Cols: cols})
+		if pp, ok := p.(*param); ok && pp.storage == nil && rows*cols > 0 {
+			if _, dense := value.(*mat.Dense); dense {
+				detached[path] = pp
+			}
+		}
+	})
+	sort.Slice(header.Tensors, func(i, j int) bool {
+		return header.Tensors[i].Path < header.Tensors[j].Path
+	})
+	return header, detached, nil
+}
+
+// checkTensors returns an error if a tensor of the index is missing in the model, or the value of
+// the payload has different dimensions.
+func checkTensors(header ModelFileHeader, m Model) error {
+	params := NamedParams(m)
+	for _, tensor := range header.Tensors {
+		p, ok := params[tensor.Path]
+		if !ok {
+			return fmt.Errorf("nn: parameter %#v of the model file not found in the model", tensor.Path)
+		}
+		if tensor.Length > 0 {
+			if _, ok := p.(*param); !ok {
+				return fmt.Errorf("nn: unsupported Param implementation for parameter %#v: %T", tensor.Path, p)
+			}
+			continue // the value is in the tensors section
+		}
+		rows, cols := 0, 0
+		if value := p.Value(); value != nil {
+			rows, cols = value.Dims()
+		}
+		if rows != tensor.Rows || cols != tensor.Cols {
+			return fmt.Errorf("nn: parameter %#v has dimensions %dx%d, the model file has %dx%d",
+				tensor.Path, rows, cols, tensor.Rows, tensor.Cols)
+		}
+	}
+	return nil
+}
+
+// readTensors reads the values of the tensors section into the params of the model, concurrently
+// or lazily. The values of a mapped file are read-only, so they are marked as shared (copy-on-write).
+func readTensors(r io.ReaderAt, tensorsStart int64, header ModelFileHeader, m Model, o loadOptions) error {
+	params := NamedParams(m)
+	if _, mapped := r.(*mappedFile); mapped {
+		for _, tensor := range header.Tensors {
+			if tensor.Length > 0 {
+				atomic.StoreInt32(&params[tensor.Path].(*param).shared, 1)
+			}
+		}
+	}
+	tensors := make(chan TensorInfo)
+	errs := make(chan error, o.workers)
+	var wg sync.WaitGroup
+	for i := 0; i < o.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tensor := range tensors {
+				p := params[tensor.Path].(*param)
+				value, err := readTensor(r, tensorsStart, tensor)
+				if err != nil {
+					errs <- err
+					return
+				}
+				p.value = value
+			}
+		}()
+	}
+	var err error
+	for _, tensor := range header.Tensors {
+		if tensor.Length == 0 {
+			continue
+		}
+		if o.lazy {
+			tensor := tensor
+			params[tensor.Path].(*param).lazy = &lazyValue{
+				load: func() (mat.Matrix, error) {
+					return readTensor(r, tensorsStart, tensor)
+				},
+			}
+			continue
+		}
+		select {
+		case tensors <- tensor:
+			continue
+		case err = <-errs:
+		}
+		break
+	}
+	close(tensors)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	select {
+	case err = <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// readTensor reads and verifies the value of a tensor from the tensors section. The value of a
+// mapped file uses its memory, if possible.
+func readTensor(r io.ReaderAt, tensorsStart int64, tensor TensorInfo) (mat.Matrix, error) {
+	var data []byte
+	mf, mapped := r.(*mappedFile)
+	if mapped {
+		data = mf.data[tensorsStart+tensor.Offset : tensorsStart+tensor.Offset+tensor.Length]
+	} else {
+		data = make([]byte, tensor.Length)
+		if _, err := r.ReadAt(data, tensorsStart+tensor.Offset); err != nil {
+			return nil, err
+		}
+	}
+	if crc32.Checksum(data, crc32c) != tensor.Checksum {
+		return nil, fmt.Errorf("nn: checksum mismatch for parameter %#v: the model file is corrupted", tensor.Path)
+	}
+	// the values are encoded as mat.Dense.MarshalBinary: rows, columns and elements
+	if len(data) < 8 || int(binary.LittleEndian.Uint32(data)) != tensor.Rows ||
+		int(binary.LittleEndian.Uint32(data[4:])) != tensor.Cols ||
+		len(data) != 8+tensor.Rows*tensor.Cols*binary.Size(mat.Float(0)) {
+		return nil, fmt.Errorf("nn: invalid value for parameter %#v", tensor.Path)
+	}
+	if mapped && isLittleEndian {
+		if elements, ok := floatsOf(data[8:], tensor.Rows*tensor.Cols); ok {
+			return mat.NewDenseNoCopy(tensor.Rows, tensor.Cols, elements), nil
+		}
+	}
+	value := new(mat.Dense)
+	if err := value.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// tensorsEnd returns the size of the tensors section.
+func tensorsEnd(tensors []TensorInfo) int64 {
+	end := int64(0)
+	for _, tensor := range tensors {
+		if n := tensor.Offset + tensor.Length; tensor.Length > 0 && n > end {
+			end = n
+		}
+	}
+	return end
+}
+
+// isLittleEndian reports whether the native byte order is little-endian, as the one of the tensors.
+var isLittleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// floatsOf returns the n floats encoded in data, without copying them, if they are aligned.
+func floatsOf(data []byte, n int) ([]mat.Float, bool) {
+	if uintptr(unsafe.Pointer(&data[0]))%unsafe.Alignof(mat.Float(0)) != 0 {
+		return nil, false
+	}
+	var elements []mat.Float
+	header := (*reflect.SliceHeader)(unsafe.Pointer(&elements))
+	header.Data = uintptr(unsafe.Pointer(&data[0]))
+	header.Len = n
+	header.Cap = n
+	return elements, true
+}
+
+func alignOffset(offset int64) int64 {
+	return (offset + tensorAlignment - 1) / tensorAlignment * tensorAlignment
+}
+
+func newLoadOptions(opts []LoadOption) loadOptions {
+	o := loadOptions{workers: runtime.NumCPU()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.workers < 1 {
+		o.workers = 1
+	}
+	return o
+}
+
+func migrateModel(header ModelFileHeader, m Model) error {
+	modelFileMu.RLock()
+	migrations := modelMigrations[architectureOf(m)]
+	modelFileMu.RUnlock()
+	for _, migration := range migrations {
+		if header.Version >= migration.toVersion {
+			continue
+		}
+		if err := migration.migrate(header, m); err != nil {
+			return fmt.Errorf("nn: model migration failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func architectureOf(m Model) string {
+	return fmt.Sprintf("%T", m)
+}
+
+func resolveArchitectureAlias(architecture string) string {
+	modelFileMu.RLock()
+	defer modelFileMu.RUnlock()
+	if alias, ok := architectureAlias[architecture]; ok {
+		return alias
+	}
+	return architecture
+}
+
+// hashingWriter writes to w, computing the hash and the number of bytes written.
+type hashingWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	n    int64
+}
+
+func (hw *hashingWriter) Write(p []byte) (int, error) {
+	n, err := hw.w.Write(p)
+	hw.hash.Write(p[:n])
+	hw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type modelFileTestConfig struct {
+	Size int `json:"size"`
+}
+
+type modelFileTestModel struct {
+	ParamsTraversalBaseModel
+	Config modelFileTestConfig
+	W      Param `spago:"type:weights"`
+	B      Param `spago:"type:biases"`
+}
+
+type modelFileTestModelV2 struct {
+	ParamsTraversalBaseModel
+	Config  modelFileTestConfig
+	Weights Param `spago:"type:weights"`
+	B       Param `spago:"type:biases"`
+}
+
+func newModelFileTestModel() *modelFileTestModel {
+	return &modelFileTestModel{
+		Config: modelFileTestConfig{Size: 2},
+		W:      NewParam(mat.NewVecDense([]mat.Float{1, 2, 3, 4}).View(2, 2)),
+		B:      NewParam(mat.NewVecDense([]mat.Float{5, 6})),
+	}
+}
+
+func TestSaveModelAndLoadModel(t *testing.T) {
+	dir := newModelFileTestDir(t)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "model.bin")
+
+	require.NoError(t, SaveModel(filename, newModelFileTestModel()))
+
+	header, err := ReadModelFileHeader(filename)
+	require.NoError(t, err)
+	assert.Equal(t, ModelFileVersion, header.Version)
+	assert.Equal(t, "*nn.modelFileTestModel", header.Architecture)
+	assert.JSONEq(t, `{"size": 2}`, string(header.Config))
+	require.Len(t, header.Tensors, 2)
+	assert.Equal(t, TensorInfo{Path: "B", Rows: 2, Cols: 1, Offset: 0, Length: 16}, withoutChecksum(header.Tensors[0]))
+	assert.Equal(t, TensorInfo{Path: "W", Rows: 2, Cols: 2, Offset: 64, Length: 24}, withoutChecksum(header.Tensors[1]))
+
+	m := &modelFileTestModel{}
+	header, err = LoadModel(filename, m)
+	require.NoError(t, err)
+	assert.Equal(t, ModelFileVersion, header.Version)
+	assert.Equal(t, 2, m.Config.Size)
+	assert.Equal(t, []mat.Float{1, 2, 3, 4}, m.W.Value().Data())
+	assert.Equal(t, []mat.Float{5, 6}, m.B.Value().Data())
+}
+
+func TestLoadModel_Lazy(t *testing.T) {
+	dir := newModelFileTestDir(t)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "model.bin")
+	require.NoError(t, SaveModel(filename, newModelFileTestModel()))
+
+	m := &modelFileTestModel{}
+	_, err := LoadModel(filename, m, LazyLoad())
+	require.NoError(t, err)
+	assert.Nil(t, m.W.(*param).value)
+	assert.Equal(t, []mat.Float{1, 2, 3, 4}, m.W.Value().Data())
+	assert.Nil(t, m.B.(*param).value)
+
+	m.B.ReplaceValue(mat.NewVecDense([]mat.Float{7, 8}))
+	assert.Equal(t, []mat.Float{7, 8}, m.B.Value().Data())
+}
+
+func TestLoadModel_MemoryMap(t *testing.T) {
+	dir := newModelFileTestDir(t)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "model.bin")
+	require.NoError(t, SaveModel(filename, newModelFileTestModel()))
+
+	for _, opts := range [][]LoadOption{{MemoryMap()}, {MemoryMap(), LazyLoad()}} {
+		m := &modelFileTestModel{}
+		_, err := LoadModel(filename, m, opts...)
+		if runtime.GOOS == "windows" {
+			assert.Error(t, err)
+			return
+		}
+		require.NoError(t, err)
+		assert.Equal(t, []mat.Float{1, 2, 3, 4}, m.W.Value().Data())
+		assert.Equal(t, []mat.Float{5, 6}, m.B.Value().Data())
+
+		// the read-only values are not modified in place
+		m.B.ApplyDelta(mat.NewVecDense([]mat.Float{1, 1}))
+		assert.Equal(t, []mat.Float{4, 5}, m.B.Value().Data())
+	}
+}
+
+func TestReadModel_Workers(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteModel(&buf, newModelFileTestModel()))
+
+	for _, workers := range []int{0, 1, 4} {
+		m := &modelFileTestModel{}
+		_, err := ReadModel(bytes.NewReader(buf.Bytes()), int64(buf.Len()), m, LoadWorkers(workers))
+		require.NoError(t, err)
+		assert.Equal(t, []mat.Float{1, 2, 3, 4}, m.W.Value().Data())
+		assert.Equal(t, []mat.Float{5, 6}, m.B.Value().Data())
+	}
+}
+
+func TestReadModel_Version1(t *testing.T) {
+	var payload bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&payload).Encode(newModelFileTestModel()))
+	headerData := []byte(`{"architecture":"*nn.modelFileTestModel","tensors":[` +
+		`{"path":"B","rows":2,"cols":1},{"path":"W","rows":2,"cols":2}]}`)
+
+	var buf bytes.Buffer
+	buf.Write(modelFileMagic)
+	require.NoError(t, binary.Write(&buf, binary.BigEndian, uint32(1)))
+	require.NoError(t, binary.Write(&buf, binary.BigEndian, uint32(len(headerData))))
+	buf.Write(headerData)
+	buf.Write(payload.Bytes())
+	require.NoError(t, binary.Write(&buf, binary.BigEndian, uint64(payload.Len())))
+	checksum := sha256.Sum256(payload.Bytes())
+	buf.Write(checksum[:])
+
+	m := &modelFileTestModel{}
+	header, err := ReadModel(bytes.NewReader(buf.Bytes()), int64(buf.Len()), m)
+	require.NoError(t, err)
+	assert.Equal(t, 1, header.Version)
+	assert.Equal(t, []mat.Float{1, 2, 3, 4}, m.W.Value().Data())
+	assert.Equal(t, []mat.Float{5, 6}, m.B.Value().Data())
+}
+
+func TestReadModel_Legacy(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(newModelFileTestModel()))
+
+	migrated := 0
+	RegisterModelMigration("*nn.modelFileTestModel", 1, func(header ModelFileHeader, m Model) error {
+		assert.Equal(t, 0, header.Version)
+		migrated++
+		return nil
+	})
+	defer func() {
+		modelFileMu.Lock()
+		delete(modelMigrations, "*nn.modelFileTestModel")
+		modelFileMu.Unlock()
+	}()
+
+	m := &modelFileTestModel{}
+	header, err := ReadModel(bytes.NewReader(buf.Bytes()), int64(buf.Len()), m)
+	require.NoError(t, err)
+	assert.Equal(t, 0, header.Version)
+	assert.Equal(t, []mat.Float{5, 6}, m.B.Value().Data())
+	assert.Equal(t, 1, migrated)
+
+	// the migration is not applied to the files of the current version
+	buf.Reset()
+	require.NoError(t, WriteModel(&buf, newModelFileTestModel()))
+	_, err = ReadModel(bytes.NewReader(buf.Bytes()), int64(buf.Len()), &modelFileTestModel{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+}
+
+func TestReadModel_Corrupted(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteModel(&buf, newModelFileTestModel()))
+	data := buf.Bytes()
+
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-modelFileTrailerSize-10] ^= 0xff
+	_, err := ReadModel(bytes.NewReader(corrupted), int64(len(corrupted)), &modelFileTestModel{})
+	assert.Error(t, err)
+
+	truncated := data[:len(data)-10]
+	_, err = ReadModel(bytes.NewReader(truncated), int64(len(truncated)), &modelFileTestModel{})
+	assert.Error(t, err)
+
+	_, err = ReadModel(bytes.NewReader(corrupted), int64(len(corrupted)), &modelFileTestModel{}, LoadWorkers(1))
+	assert.EqualError(t, err, "nn: checksum mismatch for parameter \"W\": the model file is corrupted")
+
+	lazy := &modelFileTestModel{}
+	_, err = ReadModel(bytes.NewReader(corrupted), int64(len(corrupted)), lazy, LazyLoad())
+	require.NoError(t, err)
+	assert.Equal(t, []mat.Float{5, 6}, lazy.B.Value().Data())
+	assert.Panics(t, func() { lazy.W.Value() })
+
+	future := append([]byte{}, data...)
+	future[11] = ModelFileVersion + 1
+	_, err = ReadModel(bytes.NewReader(future), int64(len(future)), &modelFileTestModel{})
+	assert.Error(t, err)
+}
+
+func TestReadModel_Architecture(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteModel(&buf, newModelFileTestModel()))
+	data := buf.Bytes()
+
+	_, err := ReadModel(bytes.NewReader(data), int64(len(data)), &modelFileTestModelV2{})
+	assert.EqualError(t, err, "nn: the model file contains a *nn.modelFileTestModel, not a *nn.modelFileTestModelV2")
+
+	RegisterArchitectureAlias("*nn.modelFileTestModel", "*nn.modelFileTestModelV2")
+	defer func() {
+		modelFileMu.Lock()
+		delete(architectureAlias, "*nn.modelFileTestModel")
+		modelFileMu.Unlock()
+	}()
+	// the parameter W has been renamed, so the model does not match the tensors of the file
+	_, err = ReadModel(bytes.NewReader(data), int64(len(data)), &modelFileTestModelV2{})
+	assert.EqualError(t, err, "nn: parameter \"W\" of the model file not found in the model")
+}
+
+func withoutChecksum(tensor TensorInfo) TensorInfo {
+	tensor.Checksum = 0
+	return tensor
+}
+
+func newModelFileTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "spago-modelfile-test-")
+	require.NoError(t, err)
+	return dir
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package groupnorm implements the Group Normalization (GroupNorm) method.
+//
+// The features of each input vector are divided into groups of the same size,
+// which are normalized independently; the learned scale and shift are applied
+// to each feature. With one group it is equivalent to LayerNorm.
+//
+// Reference: "Group Normalization" by Yuxin Wu and Kaiming He (2018).
+// (https://arxiv.org/pdf/1803.08494.pdf)
+package groupnorm
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.Model = &Model{}
+)
+
+// Model contains the serializable parameters.
+type Model struct {
+	nn.BaseModel
+	NumOfGroups int
+	W           nn.Param `spago:"type:weights"`
+	B           nn.Param `spago:"type:biases"`
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new model with parameters initialized to zeros.
+// It panics if size is not divisible by the number of groups.
+func New(size, numOfGroups int) *Model {
+	if numOfGroups <= 0 || size%numOfGroups != 0 {
+		panic("groupnorm: size must be divisible by the number of groups")
+	}
+	return &Model{
+		NumOfGroups: numOfGroups,
+		W:           nn.NewParam(mat.NewEmptyVecDense(size)),
+		B:           nn.NewParam(mat.NewEmptyVecDense(size)),
+	}
+}
+
+// Forward performs the forward step for each input node and returns the result.
+// For each group: y = (x - E\[x\]) / sqrt(VAR\[x\] + [EPS]), followed by y * g + b.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	eps := g.Constant(1e-12) // avoid underflow errors
+	groupSize := m.W.Value().Size() / m.NumOfGroups
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		groups := make([]ag.Node, m.NumOfGroups)
+		for k := range groups {
+			group := g.View(x, k*groupSize, 0, groupSize, 1)
+			mean := g.ReduceMean(group)
+			dev := g.SubScalar(group, mean)
+			stdDev := g.Sqrt(g.Add(g.ReduceMean(g.Square(dev)), eps))
+			groups[k] = g.DivScalar(dev, stdDev)
+		}
+		ys[i] = g.Add(g.Prod(g.Concat(groups...), m.W), m.B)
+	}
+	return ys
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package groupnorm
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestModel_ForwardWithOneGroup(t *testing.T) {
+	model := newTestModel(1)
+	g := ag.NewGraph()
+	ctx := nn.Context{Graph: g, Mode: nn.Training}
+
+	// == Forward
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{0.4, 0.8, -0.7, -0.5}), true)
+	y := nn.ToNode(nn.Reify(ctx, model).(*Model).Forward(x))
+
+	// same as LayerNorm
+	assert.InDeltaSlice(t, []mat.Float{1.157863, 0.2, -0.561554, -0.444658}, y.Value().Data(), 1.0e-06)
+
+	// == Backward
+	y.PropagateGrad(mat.NewVecDense([]mat.Float{-1.0, -0.2, 0.4, 0.6}))
+	g.BackwardAll()
+
+	assert.InDeltaSlice(t, []mat.Float{-0.496261, 0.280677, -0.408772, 0.624355}, x.Grad().Data(), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{-0.644658, -0.257863, -0.45126, -0.483493}, model.W.Grad().Data(), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{-1.0, -0.2, 0.4, 0.6}, model.B.Grad().Data(), 1.0e-06)
+}
+
+func TestModel_ForwardWithTwoGroups(t *testing.T) {
+	model := newTestModel(2)
+	g := ag.NewGraph()
+	ctx := nn.Context{Graph: g, Mode: nn.Training}
+
+	x1 := g.NewVariable(mat.NewVecDense([]mat.Float{1.0, 3.0, 20.0, 10.0}), true)
+	x2 := g.NewVariable(mat.NewVecDense([]mat.Float{0.5, 0.5, -2.0, 2.0}), true)
+	ys := nn.Reify(ctx, model).(*Model).Forward(x1, x2)
+
+	assert.Len(t, ys, 2)
+	assert.InDeltaSlice(t, []mat.Float{0.5, 0.2, -1.2, -0.6}, ys[0].Value().Data(), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{0.9, 0.2, -0.6, 1.0}, ys[1].Value().Data(), 1.0e-06)
+}
+
+func TestNew(t *testing.T) {
+	assert.Panics(t, func() { New(5, 2) })
+	assert.Panics(t, func() { New(4, 0) })
+	assert.NotPanics(t, func() { New(6, 3) })
+}
+
+func newTestModel(numOfGroups int) *Model {
+	model := New(4, numOfGroups)
+	model.W.Value().SetData([]mat.Float{0.4, 0.0, -0.3, 0.8})
+	model.B.Value().SetData([]mat.Float{0.9, 0.2, -0.9, 0.2})
+	return model
+}
@@ -42,13 +42,9 @@ func New(size int) *Model {
 // y = (x - E\[x\]) / sqrt(VAR\[x\] + [EPS]) * g + b
 func (m *Model) Forward(xs ...ag.Node) []ag.Node {
 	g := m.Graph()
-	eps := g.Constant(1e-12) // avoid underflow errors
 	ys := make([]ag.Node, len(xs))
 	for i, x := range xs {
-		mean := g.ReduceMean(x)
-		dev := g.SubScalar(x, mean)
-		stdDev := g.Sqrt(g.Add(g.ReduceMean(g.Square(dev)), eps))
-		ys[i] = g.Add(g.Prod(g.DivScalar(dev, stdDev), m.W), m.B)
+		ys[i] = g.LayerNorm(x, m.W, m.B, 1e-12) // eps avoids underflow errors
 	}
 	return ys
 }
@@ -19,11 +19,30 @@ var (
 	_ nn.Model = &Model{}
 )
 
+// DefaultEpsilon is the value added for numerical stability, used when Config.Epsilon is zero.
+const DefaultEpsilon mat.Float = 1e-10
+
+// Config provides configuration settings for a RMSNorm Model.
+type Config struct {
+	Size int
+	// Epsilon is added to the root mean square for numerical stability (DefaultEpsilon if zero).
+	// Recent transformer checkpoints commonly use 1e-5 or 1e-6.
+	Epsilon mat.Float
+	// EpsilonInsideSqrt reports whether Epsilon is added to the mean square, inside the square
+	// root, as in the reference implementation and in most pre-trained models (e.g. T5, LLaMA),
+	// instead of to the root mean square.
+	EpsilonInsideSqrt bool
+	// UseBias reports whether the bias B is trained. If false, B remains zero,
+	// as in most pre-trained models using RMSNorm (e.g. T5, LLaMA).
+	UseBias bool
+}
+
 // Model contains the serializable parameters.
 type Model struct {
 	nn.BaseModel
-	W nn.Param `spago:"type:weights"`
-	B nn.Param `spago:"type:biases"`
+	Config Config
+	W      nn.Param `spago:"type:weights"`
+	B      nn.Param `spago:"type:biases"`
 }
 
 func init() {
@@ -32,20 +51,42 @@ func init() {
 
 // New returns a new model with parameters initialized to zeros.
 func New(size int) *Model {
+	return NewWithConfig(Config{
+		Size:    size,
+		UseBias: true,
+	})
+}
+
+// NewWithConfig returns a new model with parameters initialized to zeros,
+// according to the given configuration.
+func NewWithConfig(config Config) *Model {
 	return &Model{
-		W: nn.NewParam(mat.NewEmptyVecDense(size)),
-		B: nn.NewParam(mat.NewEmptyVecDense(size)),
+		Config: config,
+		W:      nn.NewParam(mat.NewEmptyVecDense(config.Size)),
+		B:      nn.NewParam(mat.NewEmptyVecDense(config.Size), nn.RequiresGrad(config.UseBias)),
 	}
 }
 
 // Forward performs the forward step for each input node and returns the result.
+// y = x / (sqrt(E\[x^2\]) + [EPS]) * g + b
+// or, with EpsilonInsideSqrt:
+// y = x / sqrt(E\[x^2\] + [EPS]) * g + b
 func (m *Model) Forward(xs ...ag.Node) []ag.Node {
 	g := m.Graph()
-	eps := g.Constant(1e-10)
+	epsilon := m.Config.Epsilon
+	if epsilon == 0 {
+		epsilon = DefaultEpsilon
+	}
+	eps := g.Constant(epsilon)
 	ys := make([]ag.Node, len(xs))
 	for i, x := range xs {
-		rms := g.Sqrt(g.ReduceMean(g.Square(x)))
-		ys[i] = g.Add(g.Prod(g.DivScalar(x, g.AddScalar(rms, eps)), m.W), m.B)
+		var rms ag.Node
+		if m.Config.EpsilonInsideSqrt {
+			rms = g.Sqrt(g.AddScalar(g.ReduceMean(g.Square(x)), eps))
+		} else {
+			rms = g.AddScalar(g.Sqrt(g.ReduceMean(g.Square(x))), eps)
+		}
+		ys[i] = g.Add(g.Prod(g.DivScalar(x, rms), m.W), m.B)
 	}
 	return ys
 }
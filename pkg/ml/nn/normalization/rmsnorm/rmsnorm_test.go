@@ -38,6 +38,33 @@ func TestModel_Forward(t *testing.T) {
 	assert.InDeltaSlice(t, []mat.Float{0.0040284488, 0.0087283057, 0.0242825941, -0.1630402749}, x3.Grad().Data(), 1.0e-06)
 }
 
+func TestNewWithConfig(t *testing.T) {
+	model := NewWithConfig(Config{Size: 4, Epsilon: 1e-6, EpsilonInsideSqrt: true})
+	model.W.Value().SetData([]mat.Float{1.0, 1.0, 1.0, 1.0})
+	g := ag.NewGraph()
+	ctx := nn.Context{Graph: g, Mode: nn.Training}
+
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{1.0, 2.0, 0.0, 4.0}), true)
+	y := nn.Reify(ctx, model).(*Model).Forward(x)
+
+	assert.InDeltaSlice(t, []mat.Float{0.4364357, 0.8728715, 0.0, 1.745743}, y[0].Value().Data(), 1.0e-06)
+	assert.False(t, model.B.RequiresGrad())
+}
+
+func TestModel_ForwardEpsilon(t *testing.T) {
+	forward := func(epsilonInsideSqrt bool) []mat.Float {
+		model := NewWithConfig(Config{Size: 4, Epsilon: 0.5, EpsilonInsideSqrt: epsilonInsideSqrt})
+		model.W.Value().SetData([]mat.Float{1.0, 1.0, 1.0, 1.0})
+		g := ag.NewGraph()
+		x := g.NewVariable(mat.NewVecDense([]mat.Float{1.0, 2.0, 0.0, 4.0}), false)
+		y := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model).Forward(x)
+		return y[0].Value().Data()
+	}
+	// by default, the epsilon is added to the root mean square
+	assert.InDeltaSlice(t, []mat.Float{0.3582576, 0.7165151, 0.0, 1.4330303}, forward(false), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{0.4170288, 0.8340577, 0.0, 1.6681153}, forward(true), 1.0e-06)
+}
+
 func newTestModel() *Model {
 	model := New(4)
 	model.W.Value().SetData([]mat.Float{0.5, -0.2, 0.3, 0.8})
@@ -6,11 +6,15 @@ package nn
 
 import (
 	"bytes"
+	"fmt"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/utils/kvdb"
 	"log"
+	"math"
 	"sync"
+	"sync/atomic"
+	"unsafe"
 )
 
 // Param is the interface for a Model parameter.
@@ -64,6 +68,15 @@ type param struct {
 	hasGrad      bool
 	requiresGrad bool
 	storage      *kvdb.KeyValueDB // default nil
+	shared       int32            // 1 if the value is shared with processors in inference mode (atomic)
+	lazy         *lazyValue       // default nil; set for the params of the models loaded lazily
+	detached     int32            // greater than zero while WriteModel writes the value apart from the gob payload
+}
+
+// lazyValue loads the value of a param on first access.
+type lazyValue struct {
+	once sync.Once
+	load func() (mat.Matrix, error)
 }
 
 // ParamOption allows to configure a new Param with your specific needs.
@@ -125,14 +138,37 @@ func (r *param) Type() ParamsType {
 
 // Value returns the value of the delegate itself.
 func (r *param) Value() mat.Matrix {
+	r.materialize()
 	return r.value
 }
 
+// materialize loads the value of a param of a model loaded lazily, once.
+// It panics if the value cannot be loaded, e.g. because the model file has been corrupted.
+func (r *param) materialize() {
+	if r.lazy == nil {
+		return
+	}
+	r.lazy.once.Do(func() {
+		value, err := r.lazy.load()
+		if err != nil {
+			panic(fmt.Errorf("nn: failed to load the value of the param %#v: %w", r.name, err))
+		}
+		r.value = value
+		r.lazy.load = nil // release the model file
+	})
+}
+
 // ReplaceValue replaces the value of the parameter and clears the support structure.
 func (r *param) ReplaceValue(value mat.Matrix) {
+	if r.lazy != nil {
+		r.lazy.once.Do(func() {
+			r.lazy.load = nil // the value of the model file is no longer needed
+		})
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.value = value
+	atomic.StoreInt32(&r.shared, 0)
 	r.payload = nil
 	if r.storage != nil {
 		r.updateStorage()
@@ -143,7 +179,7 @@ func (r *param) ReplaceValue(value mat.Matrix) {
 // It panics if the value is not a scalar.
 // Note that it is not possible to start the backward step from a scalar value.
 func (r *param) ScalarValue() mat.Float {
-	return r.value.Scalar()
+	return r.Value().Scalar()
 }
 
 // Grad returns the gradients accumulated during the backward pass.
@@ -159,7 +195,7 @@ func (r *param) PropagateGrad(grad mat.Matrix) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if r.grad == nil {
-		r.grad = mat.GetEmptyDenseWorkspace(r.value.Dims()) // this could reduce the number of allocations
+		r.grad = mat.GetEmptyDenseWorkspace(r.Value().Dims()) // this could reduce the number of allocations
 	}
 	r.grad.AddInPlace(grad)
 	r.hasGrad = true
@@ -193,15 +229,69 @@ func (r *param) ZeroGrad() {
 }
 
 // ApplyDelta updates the value of the underlying storage applying the delta.
+// If the value is shared with processors in inference mode, it is not modified in place:
+// it is replaced with an updated copy (copy-on-write), so that the processors keep reading
+// a consistent value. The copy is not shared until the processors are refreshed, so the
+// following updates modify it in place.
 func (r *param) ApplyDelta(delta mat.Matrix) {
+	r.materialize()
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.Value().SubInPlace(delta)
+	if atomic.LoadInt32(&r.shared) == 1 {
+		r.value = r.value.Sub(delta)
+		atomic.StoreInt32(&r.shared, 0)
+	} else {
+		r.value.SubInPlace(delta)
+	}
 	if r.storage != nil {
 		r.updateStorage()
 	}
 }
 
+// ApplyDeltaAtomic updates the value of the param applying the delta with atomic operations on the
+// single elements, without locking the param, so that concurrent workers can update the same param
+// at the same time (Hogwild!). The workers may read values that are partially updated, which is
+// tolerated by the asynchronous optimization.
+// If the value is shared with processors in inference mode, or the param has a storage, the delta is
+// applied with ApplyDelta instead.
+func ApplyDeltaAtomic(p Param, delta mat.Matrix) {
+	var r *param
+	switch p := p.(type) {
+	case *param:
+		r = p
+	case *wrappedParam:
+		r = p.param
+	default:
+		p.ApplyDelta(delta)
+		return
+	}
+	r.materialize()
+	if r.storage != nil || atomic.LoadInt32(&r.shared) == 1 {
+		r.ApplyDelta(delta)
+		return
+	}
+	if !mat.SameDims(r.value, delta) {
+		panic("nn: incompatible dimensions of the delta")
+	}
+	data := r.value.Data()
+	for i, d := range delta.Data() {
+		if d != 0 {
+			atomicSubFloat(&data[i], d)
+		}
+	}
+}
+
+// atomicSubFloat subtracts the delta from the value at addr, atomically.
+func atomicSubFloat(addr *mat.Float, delta mat.Float) {
+	ptr := (*uint32)(unsafe.Pointer(addr))
+	for {
+		old := atomic.LoadUint32(ptr)
+		if atomic.CompareAndSwapUint32(ptr, old, math.Float32bits(math.Float32frombits(old)-delta)) {
+			return
+		}
+	}
+}
+
 // Payload returns the optimizer support structure (can be nil).
 func (r *param) Payload() *Payload {
 	r.mu.Lock()
@@ -270,12 +360,62 @@ func (r *param) wrappedParam(g *ag.Graph) *wrappedParam {
 	return &wrappedParam{param: r, Node: g.NewWrapNoGrad(r)}
 }
 
+// sharedParam returns a new read-only wrappedParam from the param itself, for the processors
+// in inference mode. The processor reads the current value of the param without copying it,
+// and never propagates gradients to it.
+func (r *param) sharedParam(g *ag.Graph) *wrappedParam {
+	view := &paramView{param: r}
+	view.refresh()
+	return &wrappedParam{param: r, Node: g.NewWrapNoGrad(view), view: view}
+}
+
+// share marks the value of the param as shared and returns it.
+func (r *param) share() mat.Matrix {
+	r.materialize()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	atomic.StoreInt32(&r.shared, 1)
+	return r.value
+}
+
+// paramView is a read-only view of the value of a param, as it was at the time of the last refresh.
+type paramView struct {
+	*param
+	value mat.Matrix
+}
+
+// refresh updates the view with the current value of the param.
+func (r *paramView) refresh() {
+	r.value = r.param.share()
+}
+
+// Value returns the value of the param at the time of the last refresh.
+func (r *paramView) Value() mat.Matrix {
+	return r.value
+}
+
+// ScalarValue returns the scalar value of the param at the time of the last refresh.
+func (r *paramView) ScalarValue() mat.Float {
+	return r.value.Scalar()
+}
+
 var _ Param = &wrappedParam{}
 
 // wrappedParam enriches a Param with a Node.
 type wrappedParam struct {
 	*param
 	Node ag.Node
+	view *paramView // not nil if the param is read-only (inference mode)
+}
+
+// Value dispatches the call to the Node.
+func (r *wrappedParam) Value() mat.Matrix {
+	return r.Node.Value()
+}
+
+// ScalarValue dispatches the call to the Node.
+func (r *wrappedParam) ScalarValue() mat.Float {
+	return r.Node.ScalarValue()
 }
 
 // ID dispatches the call to the Node.
@@ -312,3 +452,39 @@ func (r *wrappedParam) RequiresGrad() bool {
 func (r *wrappedParam) ZeroGrad() {
 	r.Node.ZeroGrad()
 }
+
+// SetRequiresGrad dispatches the call to the param, unless it is read-only.
+func (r *wrappedParam) SetRequiresGrad(value bool) {
+	r.assertWritable()
+	r.param.SetRequiresGrad(value)
+}
+
+// ReplaceValue dispatches the call to the param, unless it is read-only.
+func (r *wrappedParam) ReplaceValue(value mat.Matrix) {
+	r.assertWritable()
+	r.param.ReplaceValue(value)
+}
+
+// ApplyDelta dispatches the call to the param, unless it is read-only.
+func (r *wrappedParam) ApplyDelta(delta mat.Matrix) {
+	r.assertWritable()
+	r.param.ApplyDelta(delta)
+}
+
+// SetPayload dispatches the call to the param, unless it is read-only.
+func (r *wrappedParam) SetPayload(payload *Payload) {
+	r.assertWritable()
+	r.param.SetPayload(payload)
+}
+
+// ClearPayload dispatches the call to the param, unless it is read-only.
+func (r *wrappedParam) ClearPayload() {
+	r.assertWritable()
+	r.param.ClearPayload()
+}
+
+func (r *wrappedParam) assertWritable() {
+	if r.view != nil {
+		panic("nn: the params of a processor in inference mode are read-only")
+	}
+}
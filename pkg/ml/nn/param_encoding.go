@@ -13,6 +13,7 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"sync/atomic"
 )
 
 // init registers the param implementation with the gob subsystem - so that it knows how to encode and decode
@@ -25,7 +26,11 @@ func init() {
 func (r *param) MarshalBinary() ([]byte, error) {
 	buf := new(bytes.Buffer)
 
-	err := mat.MarshalBinaryMatrix(r.value, buf)
+	value := r.Value()
+	if atomic.LoadInt32(&r.detached) > 0 {
+		value = nil // the value is written in the tensors section of the model file
+	}
+	err := mat.MarshalBinaryMatrix(value, buf)
 	if err != nil {
 		return nil, err
 	}
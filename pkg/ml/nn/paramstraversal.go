@@ -9,21 +9,32 @@ import (
 	"github.com/nlpodyssey/spago/pkg/nlp/embeddings/syncmap"
 	"github.com/nlpodyssey/spago/pkg/utils"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 )
 
 // paramsTraversal allows the traversal of Model parameters.
-// The given callback is invoked for each parameter of the Model.
+// The given callback is invoked for each parameter of the Model, along with
+// its path (see ForEachParamWithPath).
 // If exploreSubModels is true, every nested Model and its parameters are
 // also visited.
 type paramsTraversal struct {
-	callback         func(param Param)
+	callback         func(path string, param Param)
 	exploreSubModels bool
+	// modelCallback, if not nil, is invoked for each nested Model, before visiting its parameters.
+	modelCallback func(path string, m Model)
 }
 
 // newParamsTraversal returns a new paramsTraversal.
 func newParamsTraversal(callback func(param Param), exploreSubModels bool) paramsTraversal {
+	return newPathParamsTraversal(func(_ string, param Param) {
+		callback(param)
+	}, exploreSubModels)
+}
+
+// newPathParamsTraversal returns a new paramsTraversal whose callback also receives the path of each parameter.
+func newPathParamsTraversal(callback func(path string, param Param), exploreSubModels bool) paramsTraversal {
 	return paramsTraversal{
 		callback:         callback,
 		exploreSubModels: exploreSubModels,
@@ -33,65 +44,82 @@ func newParamsTraversal(callback func(param Param), exploreSubModels bool) param
 // walk iterates through all the parameters of m.
 // TODO: don't loop the field every time, use a lazy initialized "params list" instead
 func (pt paramsTraversal) walk(m interface{}) {
+	pt.walkWithPath(m, "")
+}
+
+func (pt paramsTraversal) walkWithPath(m interface{}, basePath string) {
 	utils.ForEachField(m, func(field interface{}, name string, rTag reflect.StructTag) {
 		tag, err := parseModuleFieldTag(rTag.Get("spago"))
 		if err != nil {
 			panic(err)
 		}
+		path := joinPath(basePath, name)
 		v := reflect.ValueOf(field)
 		switch v.Kind() {
 		case reflect.Struct, reflect.Ptr, reflect.Interface:
-			pt.walkStructOrPtr(field, name, tag)
+			pt.walkStructOrPtr(field, name, path, tag)
 		case reflect.Slice:
-			pt.walkSlice(v, name, tag)
+			pt.walkSlice(v, name, path, tag)
 		case reflect.Map:
-			pt.walkMap(v, name, tag)
+			pt.walkMap(v, name, path, tag)
 		}
 	})
 }
 
-func (pt paramsTraversal) walkStructOrPtr(item interface{}, name string, tag moduleFieldTag) {
+// joinPath returns the path of the element with the given name, nested in basePath.
+func joinPath(basePath, name string) string {
+	if basePath == "" {
+		return name
+	}
+	return basePath + "." + name
+}
+
+func (pt paramsTraversal) walkStructOrPtr(item interface{}, name, path string, tag moduleFieldTag) {
 	v := reflect.ValueOf(item)
 	if v.Kind() == reflect.Ptr && v.Elem().Kind() != reflect.Struct {
 		return
 	}
 	switch itemT := item.(type) {
 	case *param:
-		pt.walkParam(itemT, name, tag)
+		pt.walkParam(itemT, name, path, tag)
 	case Model:
 		if pt.exploreSubModels {
-			pt.walk(item)
+			if pt.modelCallback != nil {
+				pt.modelCallback(path, itemT)
+			}
+			pt.walkWithPath(item, path)
 		}
 	case *sync.Map:
-		pt.walkSyncMap(itemT, name, tag)
+		pt.walkSyncMap(itemT, name, path, tag)
 	case *syncmap.Map:
-		pt.walkSyncMap(itemT.Map, name, tag)
+		pt.walkSyncMap(itemT.Map, name, path, tag)
 	default:
 		if tag.Type == paramsModuleFieldType {
-			pt.walk(item)
+			pt.walkWithPath(item, path)
 		}
 	}
 }
 
-func (pt paramsTraversal) walkSyncMap(i *sync.Map, name string, tag moduleFieldTag) {
+func (pt paramsTraversal) walkSyncMap(i *sync.Map, name, path string, tag moduleFieldTag) {
 	if tag.Type != paramsModuleFieldType {
 		return
 	}
 
 	i.Range(func(key, value interface{}) bool {
+		var keyName string
 		switch k := key.(type) {
 		case string:
-			key = k
+			keyName = k
 		case int:
-			key = fmt.Sprintf("%d", k)
+			keyName = strconv.Itoa(k)
 		default:
 			return false // skip map if the key is not a string or an int
 		}
 
-		name := strings.ToLower(fmt.Sprintf("%s.%s", name, key))
+		name := strings.ToLower(fmt.Sprintf("%s.%s", name, keyName))
 		switch reflect.ValueOf(value).Kind() {
 		case reflect.Struct, reflect.Ptr, reflect.Interface:
-			pt.walkStructOrPtr(value, name, tag)
+			pt.walkStructOrPtr(value, name, joinPath(path, keyName), tag)
 		default:
 			return false // skip
 		}
@@ -99,20 +127,20 @@ func (pt paramsTraversal) walkSyncMap(i *sync.Map, name string, tag moduleFieldT
 	})
 }
 
-func (pt paramsTraversal) walkSlice(v reflect.Value, name string, tag moduleFieldTag) {
+func (pt paramsTraversal) walkSlice(v reflect.Value, name, path string, tag moduleFieldTag) {
 	length := v.Len()
 	for i := 0; i < length; i++ {
 		p := v.Index(i)
 		switch p.Kind() {
 		case reflect.Struct, reflect.Ptr, reflect.Interface:
-			pt.walkStructOrPtr(p.Interface(), name, tag)
+			pt.walkStructOrPtr(p.Interface(), name, joinPath(path, strconv.Itoa(i)), tag)
 		default:
 			return // skip
 		}
 	}
 }
 
-func (pt paramsTraversal) walkMap(v reflect.Value, name string, tag moduleFieldTag) {
+func (pt paramsTraversal) walkMap(v reflect.Value, name, path string, tag moduleFieldTag) {
 	mapRange := v.MapRange()
 	for mapRange.Next() {
 		key := ""
@@ -128,17 +156,17 @@ func (pt paramsTraversal) walkMap(v reflect.Value, name string, tag moduleFieldT
 		name := strings.ToLower(fmt.Sprintf("%s.%s", name, key))
 		switch mapRange.Value().Kind() {
 		case reflect.Struct, reflect.Ptr, reflect.Interface:
-			pt.walkStructOrPtr(mapRange.Value().Interface(), name, tag)
+			pt.walkStructOrPtr(mapRange.Value().Interface(), name, joinPath(path, key), tag)
 		default:
 			return // skip
 		}
 	}
 }
 
-func (pt paramsTraversal) walkParam(item *param, name string, tag moduleFieldTag) {
+func (pt paramsTraversal) walkParam(item *param, name, path string, tag moduleFieldTag) {
 	if item.Name() == "" {
 		item.SetName(strings.ToLower(name))
 	}
 	item.SetType(tag.paramType())
-	pt.callback(item)
+	pt.callback(path, item)
 }
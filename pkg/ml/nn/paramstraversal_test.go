@@ -356,6 +356,28 @@ func TestParamsTraversal(t *testing.T) {
 		assertEqual(t, tt.CollectedParams, expected)
 	})
 
+	t.Run("it visits Param items in params-annotated sync.Map fields with int keys", func(t *testing.T) {
+		t.Parallel()
+
+		type TestModel struct {
+			ParamsTraversalBaseModel
+			MS *sync.Map `spago:"type:params"`
+		}
+
+		m := &TestModel{
+			MS: &sync.Map{},
+		}
+		m.MS.Store(7, NewParam(mat.NewScalar(3)))
+
+		var paths []string
+		pt := newPathParamsTraversal(func(path string, _ Param) {
+			paths = append(paths, path)
+		}, false)
+		pt.walk(m)
+
+		assertEqual(t, paths, []string{"MS.7"})
+	})
+
 	t.Run("it visits Param items in params-annotated embeddings.syncmap.Map fields", func(t *testing.T) {
 		t.Parallel()
 
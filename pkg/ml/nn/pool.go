@@ -0,0 +1,199 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// ProcessorPool is a pool of processors (i.e. reified models) of the same model, each one operating on
+// its own graph. It allows steady-state computations (e.g. the inference of a server) to reuse the
+// processors, instead of paying the cost of a new reification on every call.
+//
+// When a processor is put back into the pool, its graph is truncated to the nodes created during the
+// reification, and the processors are restored to their initial state: the fields with "processor"
+// scope and the unexported fields are zeroed, and InitProcessor is called again. The params of the
+// processors in inference mode are refreshed with the latest values of the params of the model.
+type ProcessorPool struct {
+	model     Model
+	mode      ProcessingMode
+	graphOpts []ag.GraphOption
+	pool      sync.Pool
+}
+
+// PooledProcessor is a processor borrowed from a ProcessorPool. It must be given back with
+// ProcessorPool.Put when it is no longer used.
+type PooledProcessor struct {
+	// Processor is the reified model.
+	Processor Model
+	// mark is the ID of the last node created during the reification.
+	mark int
+	// reset restores the processors to their initial state.
+	reset []func()
+}
+
+// NewProcessorPool returns a new ProcessorPool for the model.
+// The graphs of the processors are created with the given options.
+func NewProcessorPool(model Model, mode ProcessingMode, graphOpts ...ag.GraphOption) *ProcessorPool {
+	return &ProcessorPool{
+		model:     model,
+		mode:      mode,
+		graphOpts: graphOpts,
+	}
+}
+
+// Get returns a processor from the pool, reifying a new one if the pool is empty.
+func (p *ProcessorPool) Get() *PooledProcessor {
+	if pp, ok := p.pool.Get().(*PooledProcessor); ok {
+		return pp
+	}
+	g := ag.NewGraph(p.graphOpts...)
+	proc := Reify(Context{Graph: g, Mode: p.mode}, p.model)
+	return &PooledProcessor{
+		Processor: proc,
+		mark:      g.MaxID(),
+		reset:     newProcessorResetter().plan(proc),
+	}
+}
+
+// Put resets the processor and puts it back into the pool.
+// The values of the nodes created by the processor are released, so they must not be used afterwards.
+func (p *ProcessorPool) Put(pp *PooledProcessor) {
+	pp.Processor.Graph().Truncate(pp.mark)
+	for _, reset := range pp.reset {
+		reset()
+	}
+	p.pool.Put(pp)
+}
+
+// processorResetter builds the list of actions that restore a processor (including its sub-processors)
+// to the state it had after the reification. It visits the processor as the reifier does.
+type processorResetter struct {
+	actions []func()
+}
+
+func newProcessorResetter() *processorResetter {
+	return &processorResetter{}
+}
+
+func (r *processorResetter) plan(proc Model) []func() {
+	r.visitModel(proc)
+	return r.actions
+}
+
+func (r *processorResetter) visitModel(m Model) {
+	if isNil(m) {
+		return
+	}
+	r.visitStruct(reflect.ValueOf(m))
+	r.actions = append(r.actions, m.InitProcessor)
+}
+
+func (r *processorResetter) visitStruct(v reflect.Value) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || !v.CanAddr() {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		tag, err := parseModuleFieldTag(t.Field(i).Tag.Get("spago"))
+		if err != nil {
+			panic(err)
+		}
+		if tag.Scope == processorModuleFieldScope || !field.CanInterface() {
+			r.actions = append(r.actions, zeroer(field))
+			continue
+		}
+		if tag.Scope == modelModuleFieldScope {
+			continue
+		}
+		r.visitField(field, tag)
+	}
+}
+
+func (r *processorResetter) visitField(field reflect.Value, tag moduleFieldTag) {
+	switch fieldT := field.Interface().(type) {
+	case Param:
+		r.visitParam(fieldT)
+	case []Param:
+		for _, p := range fieldT {
+			r.visitParam(p)
+		}
+	case Context:
+		return
+	case BaseModel, *BaseModel:
+		return
+	case Model:
+		r.visitModel(fieldT)
+	case []Model:
+		for _, m := range fieldT {
+			r.visitModel(m)
+		}
+	default:
+		switch field.Kind() {
+		case reflect.Slice:
+			// the reifier copies the slice only if all the items are models or params
+			items := make([]reflect.Value, field.Len())
+			for i := range items {
+				items[i] = field.Index(i)
+				kind := items[i].Kind()
+				if kind != reflect.Struct && kind != reflect.Ptr && kind != reflect.Interface {
+					return
+				}
+				if _, isModel := items[i].Interface().(Model); !isModel && tag.Type != paramsModuleFieldType {
+					return
+				}
+			}
+			for _, item := range items {
+				if item.Kind() == reflect.Interface {
+					item = item.Elem()
+				}
+				r.visitStruct(item)
+			}
+		case reflect.Map:
+			if tag.Type != paramsModuleFieldType {
+				return
+			}
+			iter := field.MapRange()
+			for iter.Next() {
+				if p, isParam := iter.Value().Interface().(Param); isParam {
+					r.visitParam(p)
+					continue
+				}
+				r.visitStruct(iter.Value())
+			}
+		case reflect.Struct, reflect.Ptr:
+			if tag.Type == paramsModuleFieldType {
+				r.visitStruct(field)
+			}
+		}
+	}
+}
+
+// visitParam refreshes the read-only params of the processors in inference mode, so that
+// they read the latest value of the params of the model.
+func (r *processorResetter) visitParam(p Param) {
+	if wp, ok := p.(*wrappedParam); ok && wp.view != nil {
+		r.actions = append(r.actions, wp.view.refresh)
+	}
+}
+
+// zeroer returns a function that sets the field to its zero value, even if it is unexported.
+func zeroer(field reflect.Value) func() {
+	settable := reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+	zero := reflect.Zero(field.Type())
+	return func() {
+		settable.Set(zero)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type poolTestLayer struct {
+	BaseModel
+	W      Param
+	States []ag.Node `spago:"scope:processor"`
+	bias   ag.Node
+}
+
+func (m *poolTestLayer) InitProcessor() {
+	m.bias = m.Graph().NewScalar(1.0)
+}
+
+func (m *poolTestLayer) Forward(x ag.Node) ag.Node {
+	g := m.Graph()
+	var prev ag.Node = g.NewScalar(0.0)
+	if len(m.States) > 0 {
+		prev = m.States[len(m.States)-1]
+	}
+	y := g.Add(g.Add(g.Prod(m.W, x), m.bias), prev)
+	m.States = append(m.States, y)
+	return y
+}
+
+type poolTestModel struct {
+	BaseModel
+	Layers []*poolTestLayer
+}
+
+func TestProcessorPool(t *testing.T) {
+	model := &poolTestModel{
+		Layers: []*poolTestLayer{
+			{W: NewParam(mat.NewScalar(2.0))},
+			{W: NewParam(mat.NewScalar(3.0))},
+		},
+	}
+	// Layers is not a []Model, so its items are reified without calling InitProcessor:
+	// the test layer is also used as a model on its own.
+	layerPool := NewProcessorPool(model.Layers[0], Inference)
+
+	run := func(pp *PooledProcessor) mat.Float {
+		proc := pp.Processor.(*poolTestLayer)
+		g := proc.Graph()
+		proc.Forward(g.NewScalar(1.0))
+		return proc.Forward(g.NewScalar(2.0)).ScalarValue()
+	}
+
+	pp := layerPool.Get()
+	assert.Equal(t, mat.Float(8.0), run(pp)) // (2*1 + 1) + (2*2 + 1)
+	g := pp.Processor.Graph()
+	mark := pp.mark
+	assert.Greater(t, g.MaxID(), mark)
+	layerPool.Put(pp)
+
+	assert.Nil(t, pp.Processor.(*poolTestLayer).States)
+	assert.NotNil(t, pp.Processor.(*poolTestLayer).bias)
+
+	pp2 := layerPool.Get()
+	if pp2 == pp { // sync.Pool may drop the items (e.g. with the race detector)
+		assert.Same(t, g, pp2.Processor.Graph())
+	}
+	assert.Equal(t, mat.Float(8.0), run(pp2))
+	layerPool.Put(pp2)
+
+	modelPool := NewProcessorPool(model, Inference)
+	pp3 := modelPool.Get()
+	layer := pp3.Processor.(*poolTestModel).Layers[1]
+	layer.InitProcessor()
+	layer.Forward(layer.Graph().NewScalar(1.0))
+	assert.Len(t, layer.States, 1)
+	modelPool.Put(pp3)
+	assert.Nil(t, layer.States)
+	assert.Nil(t, layer.bias)
+	assert.Nil(t, model.Layers[1].States)
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pooling
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.StandardModel = &AttentionPooling{}
+)
+
+// AttentionPooling reduces a sequence of token states (e.g. the outputs of a BiRNN or a transformer
+// encoder) to a single vector, as a weighted sum of the states whose weights are computed by learned
+// queries. It is an alternative to the mean or last-state pooling for classification heads.
+//
+// With more than one query (hops), each hop attends to a different aspect of the sequence and the
+// results are concatenated, as in the self-attentive sentence encoder described in "A Structured
+// Self-attentive Sentence Embedding" by Lin et al., 2017 (https://arxiv.org/pdf/1703.03130.pdf).
+type AttentionPooling struct {
+	nn.BaseModel
+	W       nn.Param `spago:"type:weights"`
+	B       nn.Param `spago:"type:biases"`
+	Queries nn.Param `spago:"type:weights"`
+}
+
+func init() {
+	gob.Register(&AttentionPooling{})
+}
+
+// NewAttention returns a new AttentionPooling model, for inputs of the given size, with a hidden
+// projection of the given size, and the given number of hops. The output size is in * hops.
+// The parameters are initialized to zeros.
+func NewAttention(in, hidden, hops int) *AttentionPooling {
+	return &AttentionPooling{
+		W:       nn.NewParam(mat.NewEmptyDense(hidden, in)),
+		B:       nn.NewParam(mat.NewEmptyVecDense(hidden)),
+		Queries: nn.NewParam(mat.NewEmptyDense(hops, hidden)),
+	}
+}
+
+// Forward returns a single vector pooling all the input nodes (see Pool).
+func (m *AttentionPooling) Forward(xs ...ag.Node) []ag.Node {
+	y, _ := m.Pool(xs...)
+	return []ag.Node{y}
+}
+
+// Pool returns the pooled vector, and the attention weights as a matrix with one row for each hop,
+// and one column for each input. It computes:
+// u_t = tanh(w (dot) x_t + b)
+// a_h = softmax(u (dot) q_h)
+// y = concat(x (dot) a_1, ..., x (dot) a_hops)
+func (m *AttentionPooling) Pool(xs ...ag.Node) (y ag.Node, weights ag.Node) {
+	g := m.Graph()
+	us := make([]ag.Node, len(xs))
+	for t, x := range xs {
+		us[t] = g.Tanh(nn.Affine(g, m.B, m.W, x))
+	}
+	states := g.T(g.Stack(xs...))
+	keys := g.Stack(us...)
+	hops := m.Queries.Value().Rows()
+	pooled := make([]ag.Node, hops)
+	attention := make([]ag.Node, hops)
+	for h := range pooled {
+		attention[h] = g.Softmax(g.Mul(keys, g.T(g.RowView(m.Queries, h))))
+		pooled[h] = g.Mul(states, attention[h])
+	}
+	if hops == 1 {
+		return pooled[0], g.T(attention[0])
+	}
+	return g.Concat(pooled...), g.Stack(attention...)
+}
+
+// Penalty returns the penalization term that encourages the hops to attend to different inputs,
+// computed on the attention weights returned by Pool:
+// p = ||A (dot) A^T - I||^2 (squared Frobenius norm)
+// It can be added to the loss, scaled by a coefficient, when there are two or more hops.
+func (m *AttentionPooling) Penalty(weights ag.Node) ag.Node {
+	g := m.Graph()
+	eye := g.NewVariable(mat.I(weights.Value().Rows()), false)
+	return g.ReduceSum(g.Square(g.Sub(g.Mul(weights, g.T(weights)), eye)))
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pooling
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/gradcheck"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAttentionPooling_Forward(t *testing.T) {
+	// with zero parameters, the attention is uniform and the pooling is the mean of the inputs
+	g := ag.NewGraph()
+	model := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, NewAttention(3, 2, 1)).(*AttentionPooling)
+	ys := model.Forward(newTestSequence(g)...)
+
+	assert.Len(t, ys, 1)
+	assert.InDeltaSlice(t, []mat.Float{0.1, 0.2, 0.1}, ys[0].Value().Data(), 1.0e-6)
+}
+
+func TestAttentionPooling_Pool(t *testing.T) {
+	model := newTestAttentionPooling(2)
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*AttentionPooling)
+	xs := newTestSequence(g)
+	y, weights := proc.Pool(xs...)
+
+	assert.Equal(t, 6, y.Value().Size())
+	assert.Equal(t, 2, weights.Value().Rows())
+	assert.Equal(t, 3, weights.Value().Columns())
+
+	// reference computation, without the graph
+	w, b, q := model.W.Value(), model.B.Value(), model.Queries.Value()
+	var expected []mat.Float
+	for h := 0; h < 2; h++ {
+		scores := make([]mat.Float, len(xs))
+		var sum mat.Float
+		for i, x := range xs {
+			for j := 0; j < w.Rows(); j++ {
+				u := b.AtVec(j)
+				for k := 0; k < w.Columns(); k++ {
+					u += w.At(j, k) * x.Value().AtVec(k)
+				}
+				scores[i] += q.At(h, j) * mat.Tanh(u)
+			}
+			scores[i] = mat.Exp(scores[i])
+			sum += scores[i]
+		}
+		pooled := make([]mat.Float, 3)
+		for i, x := range xs {
+			assert.InDelta(t, scores[i]/sum, weights.Value().At(h, i), 1.0e-6)
+			for k := range pooled {
+				pooled[k] += scores[i] / sum * x.Value().AtVec(k)
+			}
+		}
+		expected = append(expected, pooled...)
+	}
+	assert.InDeltaSlice(t, expected, y.Value().Data(), 1.0e-6)
+}
+
+func TestAttentionPooling_Penalty(t *testing.T) {
+	g := ag.NewGraph()
+	model := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, NewAttention(3, 2, 2)).(*AttentionPooling)
+	weights := g.NewVariable(mat.NewDense(2, 3, []mat.Float{
+		1.0, 0.0, 0.0,
+		0.5, 0.5, 0.0,
+	}), true)
+	// A (dot) A^T - I = [[0.0, 0.5], [0.5, -0.5]]
+	assert.InDelta(t, 0.75, model.Penalty(weights).ScalarValue(), 1.0e-6)
+}
+
+func TestAttentionPooling_Gradients(t *testing.T) {
+	model := newTestAttentionPooling(2)
+	f := func(g *ag.Graph, proc nn.Model) ag.Node {
+		y, weights := proc.(*AttentionPooling).Pool(newTestSequence(g)...)
+		return g.Add(g.ReduceSum(g.Square(y)), proc.(*AttentionPooling).Penalty(weights))
+	}
+	assert.NoError(t, gradcheck.CheckModel(model, f, gradcheck.DefaultConfig))
+}
+
+func newTestAttentionPooling(hops int) *AttentionPooling {
+	model := NewAttention(3, 4, hops)
+	r := rand.NewLockedRand(42)
+	initializers.XavierUniform(model.W.Value(), 1.0, r)
+	initializers.Uniform(model.B.Value(), -0.5, 0.5, r)
+	initializers.XavierUniform(model.Queries.Value(), 1.0, r)
+	return model
+}
+
+func newTestSequence(g *ag.Graph) []ag.Node {
+	return []ag.Node{
+		g.NewVariable(mat.NewVecDense([]mat.Float{0.1, 0.5, -0.4}), false),
+		g.NewVariable(mat.NewVecDense([]mat.Float{0.3, -0.2, 0.6}), false),
+		g.NewVariable(mat.NewVecDense([]mat.Float{-0.1, 0.3, 0.1}), false),
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pooling
+
+import (
+	"encoding/gob"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.StandardModel = &MaxPool2D{}
+	_ nn.StandardModel = &AvgPool2D{}
+	_ nn.StandardModel = &GlobalMaxPooling{}
+	_ nn.StandardModel = &GlobalAvgPooling{}
+)
+
+// Config provides configuration settings for the two-dimensional pooling models.
+// Zero values of strides are interpreted as the kernel size (i.e. non-overlapping windows).
+type Config struct {
+	KernelSizeX int
+	KernelSizeY int
+	XStride     int
+	YStride     int
+	XPadding    int
+	YPadding    int
+}
+
+func (c Config) fnConfig() fn.Pool2DConfig {
+	config := fn.Pool2DConfig{
+		KernelRows:  c.KernelSizeX,
+		KernelCols:  c.KernelSizeY,
+		StrideRows:  c.XStride,
+		StrideCols:  c.YStride,
+		PaddingRows: c.XPadding,
+		PaddingCols: c.YPadding,
+	}
+	if config.StrideRows == 0 {
+		config.StrideRows = config.KernelRows
+	}
+	if config.StrideCols == 0 {
+		config.StrideCols = config.KernelCols
+	}
+	return config
+}
+
+// OutputDims returns the dimensions of each output, given the dimensions of the input.
+func (c Config) OutputDims(rows, cols int) (int, int) {
+	return c.fnConfig().OutputDims(rows, cols)
+}
+
+// MaxPool2D is a parameter-free model performing a two-dimensional max pooling.
+type MaxPool2D struct {
+	nn.BaseModel
+	Config Config
+}
+
+// AvgPool2D is a parameter-free model performing a two-dimensional average pooling.
+type AvgPool2D struct {
+	nn.BaseModel
+	Config Config
+}
+
+// GlobalMaxPooling is a parameter-free model that reduces each input channel to its maximum value.
+type GlobalMaxPooling struct {
+	nn.BaseModel
+}
+
+// GlobalAvgPooling is a parameter-free model that reduces each input channel to its average value.
+type GlobalAvgPooling struct {
+	nn.BaseModel
+}
+
+func init() {
+	gob.Register(&MaxPool2D{})
+	gob.Register(&AvgPool2D{})
+	gob.Register(&GlobalMaxPooling{})
+	gob.Register(&GlobalAvgPooling{})
+}
+
+// NewMax2D returns a new MaxPool2D model.
+func NewMax2D(config Config) *MaxPool2D {
+	return &MaxPool2D{Config: config}
+}
+
+// NewAvg2D returns a new AvgPool2D model.
+func NewAvg2D(config Config) *AvgPool2D {
+	return &AvgPool2D{Config: config}
+}
+
+// NewGlobalMax returns a new GlobalMaxPooling model.
+func NewGlobalMax() *GlobalMaxPooling {
+	return &GlobalMaxPooling{}
+}
+
+// NewGlobalAvg returns a new GlobalAvgPooling model.
+func NewGlobalAvg() *GlobalAvgPooling {
+	return &GlobalAvgPooling{}
+}
+
+// Forward performs the forward step for each input node and returns the result.
+// The max pooling is applied independently to each input channel.
+func (m *MaxPool2D) Forward(xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	config := m.Config.fnConfig()
+	pooled := func(x ag.Node) ag.Node {
+		return g.MaxPool2D(x, config)
+	}
+	return ag.Map(pooled, xs)
+}
+
+// Forward performs the forward step for each input node and returns the result.
+// The average pooling is applied independently to each input channel.
+func (m *AvgPool2D) Forward(xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	config := m.Config.fnConfig()
+	pooled := func(x ag.Node) ag.Node {
+		return g.AvgPool2D(x, config)
+	}
+	return ag.Map(pooled, xs)
+}
+
+// Forward returns a single vector containing the maximum value of each input channel.
+func (m *GlobalMaxPooling) Forward(xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	pooled := func(x ag.Node) ag.Node {
+		return g.MaxPool2D(x, globalPoolingConfig(x))
+	}
+	return []ag.Node{g.Concat(ag.Map(pooled, xs)...)}
+}
+
+// Forward returns a single vector containing the average value of each input channel.
+func (m *GlobalAvgPooling) Forward(xs ...ag.Node) []ag.Node {
+	g := m.Graph()
+	pooled := func(x ag.Node) ag.Node {
+		return g.AvgPool2D(x, globalPoolingConfig(x))
+	}
+	return []ag.Node{g.Concat(ag.Map(pooled, xs)...)}
+}
+
+// globalPoolingConfig returns the configuration of a pooling whose kernel covers the whole input.
+func globalPoolingConfig(x ag.Node) fn.Pool2DConfig {
+	rows, cols := x.Value().Dims()
+	return fn.Pool2DConfig{
+		KernelRows: rows,
+		KernelCols: cols,
+		StrideRows: rows,
+		StrideCols: cols,
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pooling
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMaxPool2D_Forward(t *testing.T) {
+	g := ag.NewGraph()
+	model := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, NewMax2D(Config{
+		KernelSizeX: 2,
+		KernelSizeY: 2,
+	})).(*MaxPool2D)
+
+	x1, x2 := newTestChannels(g)
+	ys := model.Forward(x1, x2)
+
+	assert.Len(t, ys, 2)
+	assert.InDeltaSlice(t, []mat.Float{0.4, 0.7}, ys[0].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.6, 0.9}, ys[1].Value().Data(), 1.0e-6)
+	assert.Equal(t, 1, ys[0].Value().Rows())
+	assert.Equal(t, 2, ys[0].Value().Columns())
+}
+
+func TestAvgPool2D_Forward(t *testing.T) {
+	g := ag.NewGraph()
+	model := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, NewAvg2D(Config{
+		KernelSizeX: 2,
+		KernelSizeY: 2,
+		YStride:     1,
+	})).(*AvgPool2D)
+
+	x1, _ := newTestChannels(g)
+	ys := model.Forward(x1)
+
+	assert.Len(t, ys, 1)
+	assert.InDeltaSlice(t, []mat.Float{0.1, 0.05, -0.25}, ys[0].Value().Data(), 1.0e-6)
+}
+
+func TestGlobalPooling_Forward(t *testing.T) {
+	g := ag.NewGraph()
+	ctx := nn.Context{Graph: g, Mode: nn.Training}
+	maxPooling := nn.Reify(ctx, NewGlobalMax()).(*GlobalMaxPooling)
+	avgPooling := nn.Reify(ctx, NewGlobalAvg()).(*GlobalAvgPooling)
+
+	x1, x2 := newTestChannels(g)
+	maxPooled := maxPooling.Forward(x1, x2)
+	avgPooled := avgPooling.Forward(x1, x2)
+
+	assert.Len(t, maxPooled, 1)
+	assert.Len(t, avgPooled, 1)
+	assert.InDeltaSlice(t, []mat.Float{0.7, 0.9}, maxPooled[0].Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{-0.075, 0.375}, avgPooled[0].Value().Data(), 1.0e-6)
+
+	maxPooled[0].PropagateGrad(mat.NewVecDense([]mat.Float{1, 2}))
+	avgPooled[0].PropagateGrad(mat.NewVecDense([]mat.Float{0.8, 0.0}))
+	g.BackwardAll()
+
+	assert.InDeltaSlice(t, []mat.Float{0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 1.1, 0.1}, x1.Grad().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0, 0, 0, 0, 0, 0, 0, 2}, x2.Grad().Data(), 1.0e-6)
+}
+
+func newTestChannels(g *ag.Graph) (ag.Node, ag.Node) {
+	x1 := g.NewVariable(mat.NewDense(2, 4, []mat.Float{
+		0.4, 0.1, -0.9, -0.5,
+		-0.4, 0.3, 0.7, -0.3,
+	}), true)
+	x2 := g.NewVariable(mat.NewDense(2, 4, []mat.Float{
+		0.1, 0.2, 0.3, 0.4,
+		0.5, 0.6, 0.0, 0.9,
+	}), true)
+	return x1, x2
+}
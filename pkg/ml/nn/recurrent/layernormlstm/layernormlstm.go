@@ -0,0 +1,157 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package layernormlstm implements the LSTM with Layer Normalization, which normalizes the input and
+// the recurrent contributions to the gates, and the cell before the output activation.
+//
+// Reference: "Layer normalization" by Jimmy Lei Ba, Jamie Ryan Kiros, and Geoffrey E Hinton (2016).
+// (https://arxiv.org/pdf/1607.06450.pdf)
+package layernormlstm
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"log"
+)
+
+var (
+	_ nn.Model    = &Model{}
+	_ nn.Stateful = &Model{}
+)
+
+// Model contains the serializable parameters.
+// The weights of the four gates are stacked, in the order: input, output, forget and candidate.
+// The layer normalizations are computed over all the gates together, as in the original paper;
+// their biases also act as the biases of the gates.
+type Model struct {
+	nn.BaseModel
+	W        nn.Param `spago:"type:weights"`
+	WRec     nn.Param `spago:"type:weights"`
+	GainIn   nn.Param `spago:"type:weights"`
+	BiasIn   nn.Param `spago:"type:biases"`
+	GainRec  nn.Param `spago:"type:weights"`
+	BiasRec  nn.Param `spago:"type:biases"`
+	GainCell nn.Param `spago:"type:weights"`
+	BiasCell nn.Param `spago:"type:biases"`
+	States   []*State `spago:"scope:processor"`
+}
+
+// State represent a state of the LayerNorm LSTM recurrent network.
+type State struct {
+	InG  ag.Node
+	OutG ag.Node
+	ForG ag.Node
+	Cand ag.Node
+	Cell ag.Node
+	Y    ag.Node
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new model with the gains of the layer normalizations initialized to ones,
+// and the other parameters initialized to zeros.
+func New(in, out int) *Model {
+	return &Model{
+		W:        nn.NewParam(mat.NewEmptyDense(4*out, in)),
+		WRec:     nn.NewParam(mat.NewEmptyDense(4*out, out)),
+		GainIn:   nn.NewParam(mat.NewInitVecDense(4*out, 1.0)),
+		BiasIn:   nn.NewParam(mat.NewEmptyVecDense(4 * out)),
+		GainRec:  nn.NewParam(mat.NewInitVecDense(4*out, 1.0)),
+		BiasRec:  nn.NewParam(mat.NewEmptyVecDense(4 * out)),
+		GainCell: nn.NewParam(mat.NewInitVecDense(out, 1.0)),
+		BiasCell: nn.NewParam(mat.NewEmptyVecDense(out)),
+	}
+}
+
+// SetInitialState sets the initial state of the recurrent network.
+// It panics if one or more states are already present.
+func (m *Model) SetInitialState(state *State) {
+	if len(m.States) > 0 {
+		log.Fatal("layernormlstm: the initial state must be set before any input")
+	}
+	m.States = append(m.States, state)
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		s := m.forward(x)
+		m.States = append(m.States, s)
+		ys[i] = s.Y
+	}
+	return ys
+}
+
+// LastState returns the last state of the recurrent network.
+// It returns nil if there are no states.
+func (m *Model) LastState() *State {
+	n := len(m.States)
+	if n == 0 {
+		return nil
+	}
+	return m.States[n-1]
+}
+
+// State returns the output and the cell of the last state (see nn.Stateful).
+// It returns nil if there are no states.
+func (m *Model) State() []ag.Node {
+	s := m.LastState()
+	if s == nil {
+		return nil
+	}
+	return []ag.Node{s.Y, s.Cell}
+}
+
+// SetState discards the states and sets the initial state with the given output and cell,
+// as returned by State (see nn.Stateful). An empty state resets the model.
+func (m *Model) SetState(state []ag.Node) {
+	m.States = nil
+	if len(state) > 0 {
+		m.SetInitialState(&State{Y: state[0], Cell: state[1]})
+	}
+}
+
+// forward computes the results with the following equations:
+// gates = ln(w (dot) x; gainIn, biasIn) + ln(wRec (dot) yPrev; gainRec, biasRec)
+// inG = sigmoid(gates[0])
+// outG = sigmoid(gates[1])
+// forG = sigmoid(gates[2])
+// cand = f(gates[3])
+// cell = inG * cand + forG * cellPrev
+// y = outG * f(ln(cell; gainCell, biasCell))
+func (m *Model) forward(x ag.Node) (s *State) {
+	g := m.Graph()
+	s = new(State)
+	yPrev, cellPrev := m.prev()
+	gates := g.LayerNorm(g.Mul(m.W, x), m.GainIn, m.BiasIn, 1e-5)
+	if yPrev != nil {
+		gates = g.Add(gates, g.LayerNorm(g.Mul(m.WRec, yPrev), m.GainRec, m.BiasRec, 1e-5))
+	}
+	size := m.GainCell.Value().Size()
+	s.InG = g.Sigmoid(g.View(gates, 0, 0, size, 1))
+	s.OutG = g.Sigmoid(g.View(gates, size, 0, size, 1))
+	s.ForG = g.Sigmoid(g.View(gates, 2*size, 0, size, 1))
+	s.Cand = g.Tanh(g.View(gates, 3*size, 0, size, 1))
+	if cellPrev != nil {
+		s.Cell = g.Add(g.Prod(s.InG, s.Cand), g.Prod(s.ForG, cellPrev))
+	} else {
+		s.Cell = g.Prod(s.InG, s.Cand)
+	}
+	s.Y = g.Prod(s.OutG, g.Tanh(g.LayerNorm(s.Cell, m.GainCell, m.BiasCell, 1e-5)))
+	return
+}
+
+func (m *Model) prev() (yPrev, cellPrev ag.Node) {
+	s := m.LastState()
+	if s != nil {
+		yPrev = s.Y
+		cellPrev = s.Cell
+	}
+	return
+}
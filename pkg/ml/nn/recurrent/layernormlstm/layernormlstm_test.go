@@ -0,0 +1,174 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package layernormlstm
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/gradcheck"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testInputs = [][]mat.Float{
+	{-0.8, -0.9, -0.9, 1.0},
+	{0.8, -0.3, 0.5, 0.3},
+	{-0.2, 0.7, 0.2, 0.4},
+}
+
+func TestModel_Forward(t *testing.T) {
+	model := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+	ys := proc.Forward(newInputs(g)...)
+
+	expected := referenceForward(model, testInputs)
+	for i, y := range ys {
+		assert.InDeltaSlice(t, expected[i], y.Value().Data(), 1.0e-05, "step %d", i)
+	}
+}
+
+func TestModel_Gradients(t *testing.T) {
+	model := newTestModel()
+	f := func(g *ag.Graph, proc nn.Model) ag.Node {
+		ys := proc.(*Model).Forward(newInputs(g)...)
+		return g.Concat(ys...)
+	}
+	assert.NoError(t, gradcheck.CheckModel(model, f, gradcheck.DefaultConfig))
+}
+
+func TestModel_Session(t *testing.T) {
+	model := newTestModel()
+	expected := referenceForward(model, testInputs)
+
+	session := nn.NewSession(model, nn.Inference)
+	defer session.Close()
+	for i, input := range testInputs {
+		session.Step(func(proc nn.Stateful) {
+			g := proc.Graph()
+			y := proc.(*Model).Forward(g.NewVariable(mat.NewVecDense(input), false))[0]
+			assert.InDeltaSlice(t, expected[i], y.Value().Data(), 1.0e-05)
+		})
+	}
+
+	session.Reset()
+	assert.Nil(t, session.Processor().State())
+}
+
+func TestNew(t *testing.T) {
+	model := New(4, 5)
+	assert.Equal(t, 20, model.W.Value().Rows())
+	assert.Equal(t, 4, model.W.Value().Columns())
+	assert.Equal(t, 20, model.WRec.Value().Rows())
+	assert.Equal(t, 5, model.WRec.Value().Columns())
+	assert.Equal(t, mat.NewInitVecDense(20, 1.0).Data(), model.GainIn.Value().Data())
+	assert.Equal(t, mat.NewInitVecDense(5, 1.0).Data(), model.GainCell.Value().Data())
+}
+
+func newTestModel() *Model {
+	model := New(4, 5)
+	r := rand.NewLockedRand(42)
+	initializers.XavierUniform(model.W.Value(), 1.0, r)
+	initializers.XavierUniform(model.WRec.Value(), 1.0, r)
+	for _, gain := range []nn.Param{model.GainIn, model.GainRec, model.GainCell} {
+		initializers.Uniform(gain.Value(), 0.5, 1.5, r)
+	}
+	for _, bias := range []nn.Param{model.BiasIn, model.BiasRec, model.BiasCell} {
+		initializers.Uniform(bias.Value(), -0.5, 0.5, r)
+	}
+	return model
+}
+
+func newInputs(g *ag.Graph) []ag.Node {
+	xs := make([]ag.Node, len(testInputs))
+	for i, input := range testInputs {
+		xs[i] = g.NewVariable(mat.NewVecDense(input), false)
+	}
+	return xs
+}
+
+// referenceForward computes the outputs of the model for the given sequence, without the graph.
+func referenceForward(m *Model, inputs [][]mat.Float) [][]mat.Float {
+	var yPrev, cellPrev []mat.Float
+	size := m.GainCell.Value().Size()
+	ys := make([][]mat.Float, len(inputs))
+	for t, x := range inputs {
+		gates := layerNorm(mulVec(m.W.Value(), x), m.GainIn, m.BiasIn)
+		if yPrev != nil {
+			gates = add(gates, layerNorm(mulVec(m.WRec.Value(), yPrev), m.GainRec, m.BiasRec))
+		}
+		inG := apply(gates[:size], sigmoid)
+		outG := apply(gates[size:2*size], sigmoid)
+		forG := apply(gates[2*size:3*size], sigmoid)
+		cand := apply(gates[3*size:], mat.Tanh)
+		cell := prod(inG, cand)
+		if cellPrev != nil {
+			cell = add(cell, prod(forG, cellPrev))
+		}
+		ys[t] = prod(outG, apply(layerNorm(cell, m.GainCell, m.BiasCell), mat.Tanh))
+		yPrev, cellPrev = ys[t], cell
+	}
+	return ys
+}
+
+func layerNorm(x []mat.Float, gain, bias nn.Param) []mat.Float {
+	n := mat.Float(len(x))
+	var mean, variance mat.Float
+	for _, v := range x {
+		mean += v
+	}
+	mean /= n
+	for _, v := range x {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= n
+	stdDev := mat.Sqrt(variance + 1e-5)
+	out := make([]mat.Float, len(x))
+	for i, v := range x {
+		out[i] = (v-mean)/stdDev*gain.Value().Data()[i] + bias.Value().Data()[i]
+	}
+	return out
+}
+
+func mulVec(m mat.Matrix, v []mat.Float) []mat.Float {
+	out := make([]mat.Float, m.Rows())
+	for i := range out {
+		for j, x := range v {
+			out[i] += m.At(i, j) * x
+		}
+	}
+	return out
+}
+
+func add(a, b []mat.Float) []mat.Float {
+	out := make([]mat.Float, len(a))
+	for i := range out {
+		out[i] = a[i] + b[i]
+	}
+	return out
+}
+
+func prod(a, b []mat.Float) []mat.Float {
+	out := make([]mat.Float, len(a))
+	for i := range out {
+		out[i] = a[i] * b[i]
+	}
+	return out
+}
+
+func apply(a []mat.Float, f func(mat.Float) mat.Float) []mat.Float {
+	out := make([]mat.Float, len(a))
+	for i := range out {
+		out[i] = f(a[i])
+	}
+	return out
+}
+
+func sigmoid(x mat.Float) mat.Float {
+	return 1 / (1 + mat.Exp(-x))
+}
@@ -13,7 +13,8 @@ import (
 )
 
 var (
-	_ nn.Model = &Model{}
+	_ nn.Model    = &Model{}
+	_ nn.Stateful = &Model{}
 )
 
 // Model contains the serializable parameters.
@@ -114,6 +115,25 @@ func (m *Model) LastState() *State {
 	return m.States[n-1]
 }
 
+// State returns the output and the cell of the last state (see nn.Stateful).
+// It returns nil if there are no states.
+func (m *Model) State() []ag.Node {
+	s := m.LastState()
+	if s == nil {
+		return nil
+	}
+	return []ag.Node{s.Y, s.Cell}
+}
+
+// SetState discards the states and sets the initial state with the given output and cell,
+// as returned by State (see nn.Stateful). An empty state resets the model.
+func (m *Model) SetState(state []ag.Node) {
+	m.States = nil
+	if len(state) > 0 {
+		m.SetInitialState(&State{Y: state[0], Cell: state[1]})
+	}
+}
+
 // forward computes the results with the following equations:
 // inG = sigmoid(wIn (dot) x + bIn + wInRec (dot) yPrev)
 // outG = sigmoid(wOut (dot) x + bOut + wOutRec (dot) yPrev)
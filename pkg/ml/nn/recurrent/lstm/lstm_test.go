@@ -407,3 +407,38 @@ func newTestModel2() *Model {
 	model.BCand.Value().SetData([]mat.Float{0.4, 0.3})
 	return model
 }
+
+func TestModel_Session(t *testing.T) {
+	model := newTestModel()
+	inputs := [][]mat.Float{
+		{-0.8, -0.9, -0.9, 1.0},
+		{0.8, -0.3, 0.5, 0.3},
+		{-0.2, 0.7, 0.2, 0.4},
+	}
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	xs := make([]ag.Node, len(inputs))
+	for i, input := range inputs {
+		xs[i] = g.NewVariable(mat.NewVecDense(input), false)
+	}
+	expected := proc.Forward(xs...)
+
+	session := nn.NewSession(model, nn.Inference)
+	defer session.Close()
+	graphSize := -1
+	for i, input := range inputs {
+		session.Step(func(proc nn.Stateful) {
+			g := proc.Graph()
+			y := proc.(*Model).Forward(g.NewVariable(mat.NewVecDense(input), false))[0]
+			assert.InDeltaSlice(t, expected[i].Value().Data(), y.Value().Data(), 1.0e-06)
+		})
+		if graphSize == -1 {
+			graphSize = session.Processor().Graph().MaxID()
+		}
+		assert.Equal(t, graphSize, session.Processor().Graph().MaxID()) // the graph doesn't grow
+	}
+
+	session.Reset()
+	assert.Nil(t, session.Processor().State())
+}
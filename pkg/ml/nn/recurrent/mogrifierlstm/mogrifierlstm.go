@@ -0,0 +1,189 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mogrifierlstm implements the Mogrifier LSTM, in which the input and the previous output
+// mutually gate each other for a number of rounds, before being used by a standard LSTM.
+//
+// Reference: "Mogrifier LSTM" by Gábor Melis, Tomáš Kočiský and Phil Blunsom (2019).
+// (https://arxiv.org/pdf/1909.01792.pdf)
+package mogrifierlstm
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"log"
+)
+
+var (
+	_ nn.Model    = &Model{}
+	_ nn.Stateful = &Model{}
+)
+
+// DefaultRounds is the number of mogrification rounds suggested by the authors.
+const DefaultRounds = 5
+
+// Model contains the serializable parameters.
+type Model struct {
+	nn.BaseModel
+	WIn      nn.Param   `spago:"type:weights"`
+	WInRec   nn.Param   `spago:"type:weights"`
+	BIn      nn.Param   `spago:"type:biases"`
+	WOut     nn.Param   `spago:"type:weights"`
+	WOutRec  nn.Param   `spago:"type:weights"`
+	BOut     nn.Param   `spago:"type:biases"`
+	WFor     nn.Param   `spago:"type:weights"`
+	WForRec  nn.Param   `spago:"type:weights"`
+	BFor     nn.Param   `spago:"type:biases"`
+	WCand    nn.Param   `spago:"type:weights"`
+	WCandRec nn.Param   `spago:"type:weights"`
+	BCand    nn.Param   `spago:"type:biases"`
+	Q        []nn.Param `spago:"type:weights"` // gates of the input, used by the odd rounds
+	R        []nn.Param `spago:"type:weights"` // gates of the previous output, used by the even rounds
+	States   []*State   `spago:"scope:processor"`
+}
+
+// State represent a state of the Mogrifier LSTM recurrent network.
+type State struct {
+	InG  ag.Node
+	OutG ag.Node
+	ForG ag.Node
+	Cand ag.Node
+	Cell ag.Node
+	Y    ag.Node
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new model with parameters initialized to zeros.
+// The number of rounds can be zero, making the model equivalent to a standard LSTM.
+func New(in, out, rounds int) *Model {
+	m := &Model{}
+	m.WIn, m.WInRec, m.BIn = newGateParams(in, out)
+	m.WOut, m.WOutRec, m.BOut = newGateParams(in, out)
+	m.WFor, m.WForRec, m.BFor = newGateParams(in, out)
+	m.WCand, m.WCandRec, m.BCand = newGateParams(in, out)
+	for i := 1; i <= rounds; i++ {
+		if i%2 == 1 {
+			m.Q = append(m.Q, nn.NewParam(mat.NewEmptyDense(in, out)))
+		} else {
+			m.R = append(m.R, nn.NewParam(mat.NewEmptyDense(out, in)))
+		}
+	}
+	return m
+}
+
+func newGateParams(in, out int) (w, wRec, b nn.Param) {
+	w = nn.NewParam(mat.NewEmptyDense(out, in))
+	wRec = nn.NewParam(mat.NewEmptyDense(out, out))
+	b = nn.NewParam(mat.NewEmptyVecDense(out))
+	return
+}
+
+// SetInitialState sets the initial state of the recurrent network.
+// It panics if one or more states are already present.
+func (m *Model) SetInitialState(state *State) {
+	if len(m.States) > 0 {
+		log.Fatal("mogrifierlstm: the initial state must be set before any input")
+	}
+	m.States = append(m.States, state)
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		s := m.forward(x)
+		m.States = append(m.States, s)
+		ys[i] = s.Y
+	}
+	return ys
+}
+
+// LastState returns the last state of the recurrent network.
+// It returns nil if there are no states.
+func (m *Model) LastState() *State {
+	n := len(m.States)
+	if n == 0 {
+		return nil
+	}
+	return m.States[n-1]
+}
+
+// State returns the output and the cell of the last state (see nn.Stateful).
+// It returns nil if there are no states.
+func (m *Model) State() []ag.Node {
+	s := m.LastState()
+	if s == nil {
+		return nil
+	}
+	return []ag.Node{s.Y, s.Cell}
+}
+
+// SetState discards the states and sets the initial state with the given output and cell,
+// as returned by State (see nn.Stateful). An empty state resets the model.
+func (m *Model) SetState(state []ag.Node) {
+	m.States = nil
+	if len(state) > 0 {
+		m.SetInitialState(&State{Y: state[0], Cell: state[1]})
+	}
+}
+
+// forward computes the results with the following equations:
+// x, yPrev = mogrify(x, yPrev)
+// inG = sigmoid(wIn (dot) x + bIn + wInRec (dot) yPrev)
+// outG = sigmoid(wOut (dot) x + bOut + wOutRec (dot) yPrev)
+// forG = sigmoid(wFor (dot) x + bFor + wForRec (dot) yPrev)
+// cand = f(wCand (dot) x + bC + wCandRec (dot) yPrev)
+// cell = inG * cand + forG * cellPrev
+// y = outG * f(cell)
+func (m *Model) forward(x ag.Node) (s *State) {
+	g := m.Graph()
+	s = new(State)
+	yPrev, cellPrev := m.prev()
+	x, yPrev = m.mogrify(x, yPrev)
+	s.InG = g.Sigmoid(nn.Affine(g, m.BIn, m.WIn, x, m.WInRec, yPrev))
+	s.OutG = g.Sigmoid(nn.Affine(g, m.BOut, m.WOut, x, m.WOutRec, yPrev))
+	s.ForG = g.Sigmoid(nn.Affine(g, m.BFor, m.WFor, x, m.WForRec, yPrev))
+	s.Cand = g.Tanh(nn.Affine(g, m.BCand, m.WCand, x, m.WCandRec, yPrev))
+	if cellPrev != nil {
+		s.Cell = g.Add(g.Prod(s.InG, s.Cand), g.Prod(s.ForG, cellPrev))
+	} else {
+		s.Cell = g.Prod(s.InG, s.Cand)
+	}
+	s.Y = g.Prod(s.OutG, g.Tanh(s.Cell))
+	return
+}
+
+// mogrify gates the input and the previous output with each other, alternately, with the equations:
+// x = 2 * sigmoid(q (dot) yPrev) * x    (odd rounds)
+// yPrev = 2 * sigmoid(r (dot) x) * yPrev    (even rounds)
+// Without a previous output (i.e. a zero vector), the input is left unchanged.
+func (m *Model) mogrify(x, yPrev ag.Node) (ag.Node, ag.Node) {
+	if yPrev == nil {
+		return x, yPrev
+	}
+	g := m.Graph()
+	two := g.Constant(2.0)
+	for i := 0; i < len(m.Q)+len(m.R); i++ {
+		if i%2 == 0 {
+			x = g.Prod(g.ProdScalar(g.Sigmoid(g.Mul(m.Q[i/2], yPrev)), two), x)
+		} else {
+			yPrev = g.Prod(g.ProdScalar(g.Sigmoid(g.Mul(m.R[i/2], x)), two), yPrev)
+		}
+	}
+	return x, yPrev
+}
+
+func (m *Model) prev() (yPrev, cellPrev ag.Node) {
+	s := m.LastState()
+	if s != nil {
+		yPrev = s.Y
+		cellPrev = s.Cell
+	}
+	return
+}
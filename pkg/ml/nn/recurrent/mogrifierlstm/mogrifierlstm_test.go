@@ -0,0 +1,175 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mogrifierlstm
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/gradcheck"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testInputs = [][]mat.Float{
+	{-0.8, -0.9, -0.9, 1.0},
+	{0.8, -0.3, 0.5, 0.3},
+	{-0.2, 0.7, 0.2, 0.4},
+}
+
+func TestModel_Forward(t *testing.T) {
+	for _, rounds := range []int{0, 1, DefaultRounds} {
+		model := newTestModel(rounds)
+		g := ag.NewGraph()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+		ys := proc.Forward(newInputs(g)...)
+
+		expected := referenceForward(model, testInputs)
+		for i, y := range ys {
+			assert.InDeltaSlice(t, expected[i], y.Value().Data(), 1.0e-05, "rounds %d, step %d", rounds, i)
+		}
+	}
+}
+
+func TestModel_Gradients(t *testing.T) {
+	model := newTestModel(DefaultRounds)
+	f := func(g *ag.Graph, proc nn.Model) ag.Node {
+		ys := proc.(*Model).Forward(newInputs(g)...)
+		return g.Concat(ys...)
+	}
+	assert.NoError(t, gradcheck.CheckModel(model, f, gradcheck.DefaultConfig))
+}
+
+func TestModel_Session(t *testing.T) {
+	model := newTestModel(DefaultRounds)
+	expected := referenceForward(model, testInputs)
+
+	session := nn.NewSession(model, nn.Inference)
+	defer session.Close()
+	for i, input := range testInputs {
+		session.Step(func(proc nn.Stateful) {
+			g := proc.Graph()
+			y := proc.(*Model).Forward(g.NewVariable(mat.NewVecDense(input), false))[0]
+			assert.InDeltaSlice(t, expected[i], y.Value().Data(), 1.0e-05)
+		})
+	}
+
+	session.Reset()
+	assert.Nil(t, session.Processor().State())
+}
+
+func TestNew(t *testing.T) {
+	model := New(4, 5, 3)
+	assert.Len(t, model.Q, 2)
+	assert.Len(t, model.R, 1)
+	assert.Equal(t, 4, model.Q[0].Value().Rows())
+	assert.Equal(t, 5, model.Q[0].Value().Columns())
+	assert.Equal(t, 5, model.R[0].Value().Rows())
+	assert.Equal(t, 4, model.R[0].Value().Columns())
+}
+
+func newTestModel(rounds int) *Model {
+	model := New(4, 5, rounds)
+	r := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, r)
+		} else {
+			initializers.Uniform(param.Value(), -0.5, 0.5, r)
+		}
+	})
+	return model
+}
+
+func newInputs(g *ag.Graph) []ag.Node {
+	xs := make([]ag.Node, len(testInputs))
+	for i, input := range testInputs {
+		xs[i] = g.NewVariable(mat.NewVecDense(input), false)
+	}
+	return xs
+}
+
+// referenceForward computes the outputs of the model for the given sequence, without the graph.
+func referenceForward(m *Model, inputs [][]mat.Float) [][]mat.Float {
+	var yPrev, cellPrev []mat.Float
+	ys := make([][]mat.Float, len(inputs))
+	for t, x := range inputs {
+		if yPrev != nil {
+			for i := 0; i < len(m.Q)+len(m.R); i++ {
+				if i%2 == 0 {
+					x = prod(scale(apply(mulVec(m.Q[i/2].Value(), yPrev), sigmoid), 2), x)
+				} else {
+					yPrev = prod(scale(apply(mulVec(m.R[i/2].Value(), x), sigmoid), 2), yPrev)
+				}
+			}
+		}
+		gate := func(w, wRec, b nn.Param, f func(mat.Float) mat.Float) []mat.Float {
+			v := add(mulVec(w.Value(), x), b.Value().Data())
+			if yPrev != nil {
+				v = add(v, mulVec(wRec.Value(), yPrev))
+			}
+			return apply(v, f)
+		}
+		inG := gate(m.WIn, m.WInRec, m.BIn, sigmoid)
+		outG := gate(m.WOut, m.WOutRec, m.BOut, sigmoid)
+		forG := gate(m.WFor, m.WForRec, m.BFor, sigmoid)
+		cand := gate(m.WCand, m.WCandRec, m.BCand, mat.Tanh)
+		cell := prod(inG, cand)
+		if cellPrev != nil {
+			cell = add(cell, prod(forG, cellPrev))
+		}
+		ys[t] = prod(outG, apply(cell, mat.Tanh))
+		yPrev, cellPrev = ys[t], cell
+	}
+	return ys
+}
+
+func mulVec(m mat.Matrix, v []mat.Float) []mat.Float {
+	out := make([]mat.Float, m.Rows())
+	for i := range out {
+		for j, x := range v {
+			out[i] += m.At(i, j) * x
+		}
+	}
+	return out
+}
+
+func add(a, b []mat.Float) []mat.Float {
+	out := make([]mat.Float, len(a))
+	for i := range out {
+		out[i] = a[i] + b[i]
+	}
+	return out
+}
+
+func prod(a, b []mat.Float) []mat.Float {
+	out := make([]mat.Float, len(a))
+	for i := range out {
+		out[i] = a[i] * b[i]
+	}
+	return out
+}
+
+func scale(a []mat.Float, k mat.Float) []mat.Float {
+	out := make([]mat.Float, len(a))
+	for i := range out {
+		out[i] = a[i] * k
+	}
+	return out
+}
+
+func apply(a []mat.Float, f func(mat.Float) mat.Float) []mat.Float {
+	out := make([]mat.Float, len(a))
+	for i := range out {
+		out[i] = f(a[i])
+	}
+	return out
+}
+
+func sigmoid(x mat.Float) mat.Float {
+	return 1 / (1 + mat.Exp(-x))
+}
@@ -65,21 +65,26 @@ func (m *BiModel) Forward(xs ...ag.Node) []ag.Node {
 	g := m.Graph()
 	n := len(xs)
 	ys := make([]ag.Node, n)
-	b := m.transformInputConcurrent(xs)
+	b := m.transformInputs(xs)
 
 	var hfwd []ag.Node
 	var hbwd []ag.Node
-	var wg sync.WaitGroup
-	wg.Add(2)
-	go func() {
-		defer wg.Done()
+	if g.ConcurrentComputations() > 1 {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			hfwd = m.forwardHidden(b)
+		}()
+		go func() {
+			defer wg.Done()
+			hbwd = m.forwardHidden(reversed(b))
+		}()
+		wg.Wait()
+	} else {
 		hfwd = m.forwardHidden(b)
-	}()
-	go func() {
-		defer wg.Done()
 		hbwd = m.forwardHidden(reversed(b))
-	}()
-	wg.Wait()
+	}
 
 	for i := 0; i < n; i++ {
 		concat := g.Concat(hfwd[i], hbwd[n-1-i])
@@ -110,6 +115,21 @@ func (m *BiModel) transformInput(x ag.Node) ag.Node {
 	return b
 }
 
+func (m *BiModel) transformInputs(xs []ag.Node) []ag.Node {
+	if len(xs) > 1 && m.Graph().ConcurrentComputations() > 1 {
+		return m.transformInputConcurrent(xs)
+	}
+	return m.transformInputSerial(xs)
+}
+
+func (m *BiModel) transformInputSerial(xs []ag.Node) []ag.Node {
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		ys[i] = m.transformInput(x)
+	}
+	return ys
+}
+
 func (m *BiModel) transformInputConcurrent(xs []ag.Node) []ag.Node {
 	var wg sync.WaitGroup
 	n := len(xs)
@@ -77,7 +77,7 @@ func New(config Config) *Model {
 // Forward performs the forward step for each input node and returns the result.
 func (m *Model) Forward(xs ...ag.Node) []ag.Node {
 	ys := make([]ag.Node, len(xs))
-	b := m.transformInputConcurrent(xs)
+	b := m.transformInputs(xs)
 	h, _ := m.getPrevHY()
 	for i := range xs {
 		h, y := m.forward(h, b[i])
@@ -116,6 +116,21 @@ func (m *Model) transformInput(x ag.Node) ag.Node {
 	return b
 }
 
+func (m *Model) transformInputs(xs []ag.Node) []ag.Node {
+	if len(xs) > 1 && m.Graph().ConcurrentComputations() > 1 {
+		return m.transformInputConcurrent(xs)
+	}
+	return m.transformInputSerial(xs)
+}
+
+func (m *Model) transformInputSerial(xs []ag.Node) []ag.Node {
+	ys := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		ys[i] = m.transformInput(x)
+	}
+	return ys
+}
+
 func (m *Model) transformInputConcurrent(xs []ag.Node) []ag.Node {
 	var wg sync.WaitGroup
 	n := len(xs)
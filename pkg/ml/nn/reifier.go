@@ -111,6 +111,12 @@ func (r reifier) reifyModelSlice(sourceField []Model) []Model {
 }
 
 func (r reifier) reifyParam(sourceField *param) Param {
+	if r.ctx.Mode == Inference {
+		return sourceField.sharedParam(r.ctx.Graph)
+	}
+	if r.ctx.Gradients != nil && sourceField.requiresGrad {
+		return &wrappedParam{param: sourceField, Node: r.ctx.Graph.NewWrap(r.ctx.Gradients.get(sourceField))}
+	}
 	return sourceField.wrappedParam(r.ctx.Graph)
 }
 
@@ -350,3 +350,52 @@ func TestModelContextualizer(t *testing.T) {
 		})
 	})
 }
+
+func TestReify_InferenceSharesParams(t *testing.T) {
+	type TestModel struct {
+		BaseModel
+		W Param
+	}
+	model := &TestModel{W: NewParam(mat.NewVecDense([]mat.Float{1, 2}))}
+	original := model.W.Value()
+
+	g := ag.NewGraph()
+	proc := Reify(Context{Graph: g, Mode: Inference}, model).(*TestModel)
+	assert.Same(t, original, proc.W.Value())
+	assert.False(t, proc.W.RequiresGrad())
+	assert.Panics(t, func() { proc.W.ApplyDelta(mat.NewVecDense([]mat.Float{1, 1})) })
+	assert.Panics(t, func() { proc.W.ReplaceValue(mat.NewVecDense([]mat.Float{0, 0})) })
+	assert.Panics(t, func() { proc.W.SetRequiresGrad(true) })
+
+	g.Backward(g.ReduceSum(proc.W))
+	assert.Nil(t, model.W.Grad())
+
+	// the training update doesn't modify the value read by the processor
+	model.W.ApplyDelta(mat.NewVecDense([]mat.Float{1, 1}))
+	assert.Equal(t, []mat.Float{1, 2}, proc.W.Value().Data())
+	assert.Equal(t, []mat.Float{0, 1}, model.W.Value().Data())
+	assert.NotSame(t, original, model.W.Value())
+
+	// the copy is no longer shared, so the following updates modify it in place
+	updated := model.W.Value()
+	model.W.ApplyDelta(mat.NewVecDense([]mat.Float{1, 1}))
+	assert.Same(t, updated, model.W.Value())
+	assert.Equal(t, []mat.Float{-1, 0}, model.W.Value().Data())
+	assert.Equal(t, []mat.Float{1, 2}, proc.W.Value().Data())
+
+	// the pooled processors read the latest value after being reset
+	pool := NewProcessorPool(model, Inference)
+	pp := pool.Get()
+	model.W.ApplyDelta(mat.NewVecDense([]mat.Float{1, 1}))
+	assert.Equal(t, []mat.Float{-1, 0}, pp.Processor.(*TestModel).W.Value().Data())
+	pool.Put(pp)
+	assert.Equal(t, []mat.Float{-2, -1}, pp.Processor.(*TestModel).W.Value().Data())
+
+	// in training mode the params are still modified in place
+	trainable := &TestModel{W: NewParam(mat.NewVecDense([]mat.Float{1, 2}))}
+	value := trainable.W.Value()
+	trainProc := Reify(Context{Graph: ag.NewGraph(), Mode: Training}, trainable).(*TestModel)
+	trainProc.W.ApplyDelta(mat.NewVecDense([]mat.Float{1, 1}))
+	assert.Same(t, value, trainable.W.Value())
+	assert.Equal(t, []mat.Float{0, 1}, value.Data())
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+)
+
+// Stateful is implemented by the processors that carry a state across consecutive inputs of a stream,
+// such as the hidden state of a recurrent network, or the keys and values cached by an attention layer.
+type Stateful interface {
+	Model
+	// State returns the nodes holding the current state (empty if there is no state yet).
+	State() []ag.Node
+	// SetState replaces the current state with the given nodes, in the same format returned by State,
+	// discarding any other node kept by the processor. An empty state resets the processor.
+	SetState(state []ag.Node)
+}
+
+// Session processes a stream of inputs incrementally, with a processor that keeps its state across
+// the calls (see Stateful), instead of processing full sequences only.
+// At the end of each step, the history of the graph is detached, so that the size of the graph doesn't
+// grow with the length of the stream.
+type Session struct {
+	proc Stateful
+	// mark is the ID of the last node created during the reification.
+	mark int
+}
+
+// NewSession returns a new Session for the model, whose processor operates on a new graph created
+// with the given options. It panics if the processor does not implement Stateful.
+func NewSession(m Model, mode ProcessingMode, graphOpts ...ag.GraphOption) *Session {
+	g := ag.NewGraph(graphOpts...)
+	proc, ok := Reify(Context{Graph: g, Mode: mode}, m).(Stateful)
+	if !ok {
+		panic("nn: the processor of a session must implement Stateful")
+	}
+	return &Session{
+		proc: proc,
+		mark: g.MaxID(),
+	}
+}
+
+// Processor returns the processor of the session.
+func (s *Session) Processor() Stateful {
+	return s.proc
+}
+
+// Step calls the given function to process the next input of the stream with the processor, then
+// detaches the history: all the nodes created in the step are removed from the graph, except for
+// the state of the processor. Therefore, the values of the outputs must be copied within the function
+// (e.g. with Graph.GetCopiedValue).
+func (s *Session) Step(f func(proc Stateful)) {
+	f(s.proc)
+	g := s.proc.Graph()
+	s.proc.SetState(g.DetachHistory(s.mark, s.proc.State()...))
+}
+
+// Reset discards the state of the processor, so that the session can process a new stream.
+func (s *Session) Reset() {
+	s.proc.Graph().Truncate(s.mark)
+	s.proc.SetState(nil)
+}
+
+// Close releases the graph of the session, which can no longer be used.
+func (s *Session) Close() {
+	s.proc.Graph().Clear()
+}
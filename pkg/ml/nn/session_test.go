@@ -0,0 +1,60 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// sessionTestModel sums all the inputs of a stream.
+type sessionTestModel struct {
+	BaseModel
+	Sum ag.Node `spago:"scope:processor"`
+}
+
+func (m *sessionTestModel) Forward(x ag.Node) ag.Node {
+	m.Sum = m.Graph().Add(m.Sum, x)
+	return m.Sum
+}
+
+func (m *sessionTestModel) State() []ag.Node {
+	if m.Sum == nil {
+		return nil
+	}
+	return []ag.Node{m.Sum}
+}
+
+func (m *sessionTestModel) SetState(state []ag.Node) {
+	m.Sum = nil
+	if len(state) > 0 {
+		m.Sum = state[0]
+	}
+}
+
+func TestSession(t *testing.T) {
+	session := NewSession(&sessionTestModel{}, Inference)
+	defer session.Close()
+
+	var sums []mat.Float
+	for _, x := range []mat.Float{1, 2, 3} {
+		session.Step(func(proc Stateful) {
+			sum := proc.(*sessionTestModel).Forward(proc.Graph().NewScalar(x))
+			sums = append(sums, sum.ScalarValue())
+		})
+		assert.Equal(t, 0, session.Processor().Graph().MaxID())
+	}
+	assert.Equal(t, []mat.Float{1, 3, 6}, sums)
+
+	session.Reset()
+	assert.Nil(t, session.Processor().State())
+	assert.Equal(t, -1, session.Processor().Graph().MaxID())
+}
+
+func TestNewSession_NotStateful(t *testing.T) {
+	assert.Panics(t, func() { NewSession(&BaseModel{}, Inference) })
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package softprompt implements the prompt tuning: a few trainable vectors (a soft prompt) are
+// prepended to the input vectors of a model, usually a pre-trained transformer, whose parameters
+// are frozen. Only the prompt is trained, so that a large model can be adapted to many tasks
+// storing a small prompt for each of them, serialized separately from the model:
+//
+//	nn.Freeze(backbone, nil)
+//	prompt := softprompt.New(softprompt.Config{Length: 20, Size: 768})
+//	prompt.InitFromVectors(vectors...) // e.g. the embeddings of some words of the vocabulary
+//	// ... train the prompt, then
+//	err := nn.SaveModel("task.prompt", prompt)
+//
+// Reference: "The Power of Scale for Parameter-Efficient Prompt Tuning" by Lester et al., 2021
+// (https://arxiv.org/abs/2104.08691).
+package softprompt
+
+import (
+	"encoding/gob"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+var (
+	_ nn.Model = &Model{}
+)
+
+// Config provides configuration settings for a soft prompt Model.
+type Config struct {
+	// Length is the number of vectors of the prompt.
+	Length int
+	// Size is the size of the vectors, the same of the input vectors of the model.
+	Size int
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	nn.BaseModel
+	Config  Config
+	Vectors []nn.Param `spago:"type:weights;scope:model"`
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new soft prompt Model with vectors initialized to zeros.
+func New(config Config) *Model {
+	vectors := make([]nn.Param, config.Length)
+	for i := range vectors {
+		vectors[i] = nn.NewParam(mat.NewEmptyVecDense(config.Size))
+	}
+	return &Model{
+		Config:  config,
+		Vectors: vectors,
+	}
+}
+
+// Load reads a soft prompt Model from file, as written by nn.SaveModel.
+func Load(filename string) (*Model, error) {
+	m := &Model{}
+	if _, err := nn.LoadModel(filename, m); err != nil {
+		return nil, fmt.Errorf("softprompt: %w", err)
+	}
+	return m, nil
+}
+
+// InitRandom initializes the vectors with values sampled uniformly in [-scale, scale].
+func (m *Model) InitRandom(scale mat.Float, generator *rand.LockedRand) {
+	for _, v := range m.Vectors {
+		initializers.Uniform(v.Value(), -scale, scale, generator)
+	}
+}
+
+// InitFromVectors initializes the vectors of the prompt with copies of the given ones, in order,
+// cycling over them if they are fewer. Initializing the prompt from the embeddings of some words
+// of the vocabulary (e.g. the labels of a classification task) usually speeds up its training.
+func (m *Model) InitFromVectors(vectors ...mat.Matrix) {
+	if len(vectors) == 0 {
+		panic("softprompt: no vectors to initialize the prompt")
+	}
+	for i, v := range m.Vectors {
+		source := vectors[i%len(vectors)]
+		if source.Size() != m.Config.Size {
+			panic(fmt.Sprintf("softprompt: vector of size %d, expected %d", source.Size(), m.Config.Size))
+		}
+		v.Value().SetData(source.Data())
+	}
+}
+
+// Prompt returns the vectors of the prompt, as nodes inserted in the graph.
+func (m *Model) Prompt() []ag.Node {
+	out := make([]ag.Node, len(m.Vectors))
+	for i, v := range m.Vectors {
+		out[i] = m.WrapParam(v)
+	}
+	return out
+}
+
+// Forward returns the vectors of the prompt followed by the inputs.
+func (m *Model) Forward(xs ...ag.Node) []ag.Node {
+	return append(m.Prompt(), xs...)
+}
+
+// Strip removes the outputs of the prompt positions from the outputs of a model, leaving the ones
+// of the inputs.
+func (m *Model) Strip(ys []ag.Node) []ag.Node {
+	return ys[len(m.Vectors):]
+}
+
+// Encode prepends the prompt to the inputs, processes them with the encoder (e.g. the layers of a
+// transformer), and returns the outputs of the inputs only.
+func (m *Model) Encode(encoder func(xs ...ag.Node) []ag.Node, xs []ag.Node) []ag.Node {
+	return m.Strip(encoder(m.Forward(xs...)...))
+}
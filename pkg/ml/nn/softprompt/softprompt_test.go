@@ -0,0 +1,130 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package softprompt
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModel_Forward(t *testing.T) {
+	m := New(Config{Length: 2, Size: 3})
+	m.InitFromVectors(mat.NewVecDense([]mat.Float{1, 2, 3}))
+	assert.Equal(t, []mat.Float{1, 2, 3}, m.Vectors[1].Value().Data())
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*Model)
+	x := g.NewVariable(mat.NewVecDense([]mat.Float{4, 5, 6}), false)
+	ys := proc.Forward(x)
+	assert.Len(t, ys, 3)
+	assert.Equal(t, []mat.Float{1, 2, 3}, ys[0].Value().Data())
+	assert.Equal(t, x, ys[2])
+	assert.Equal(t, []ag.Node{x}, proc.Strip(ys))
+
+	// the encoder sees the prompt, but only the outputs of the inputs are returned
+	encoded := proc.Encode(func(xs ...ag.Node) []ag.Node {
+		assert.Len(t, xs, 3)
+		return []ag.Node{g.Sum(xs...), g.Sum(xs...), g.Sum(xs...)}
+	}, []ag.Node{x})
+	require.Len(t, encoded, 1)
+	assert.Equal(t, []mat.Float{6, 9, 12}, encoded[0].Value().Data())
+
+	assert.Panics(t, func() { m.InitFromVectors() })
+	assert.Panics(t, func() { m.InitFromVectors(mat.NewVecDense([]mat.Float{1, 2})) })
+}
+
+func TestModel_InitRandom(t *testing.T) {
+	m := New(Config{Length: 3, Size: 4})
+	m.InitRandom(0.5, rand.NewLockedRand(42))
+	for _, v := range m.Vectors {
+		for _, x := range v.Value().Data() {
+			assert.True(t, x >= -0.5 && x <= 0.5)
+		}
+	}
+	assert.NotEqual(t, m.Vectors[0].Value().Data(), m.Vectors[1].Value().Data())
+}
+
+// testTask is a frozen backbone with a soft prompt, whose output is the projection of the mean of
+// the inputs, prompt included.
+type testTask struct {
+	nn.BaseModel
+	Backbone *linear.Model
+	Prompt   *Model
+}
+
+func (m *testTask) forward(xs ...ag.Node) ag.Node {
+	g := m.Graph()
+	encoded := m.Prompt.Encode(func(xs ...ag.Node) []ag.Node {
+		y := nn.ToNode(m.Backbone.Forward(g.DivScalar(g.Sum(xs...), g.Constant(mat.Float(len(xs))))))
+		ys := make([]ag.Node, len(xs))
+		for i := range ys {
+			ys[i] = y
+		}
+		return ys
+	}, xs)
+	return encoded[0]
+}
+
+func TestPromptTuning(t *testing.T) {
+	backbone := linear.New(2, 1)
+	backbone.W.Value().SetData([]mat.Float{1, -1})
+	backbone.B.Value().SetData([]mat.Float{0.5})
+	nn.Freeze(backbone, nil)
+	task := &testTask{
+		Backbone: backbone,
+		Prompt:   New(Config{Length: 2, Size: 2}),
+	}
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(0.1, 0, false)), nn.NewDefaultParamsIterator(task))
+
+	input := mat.NewVecDense([]mat.Float{1, 1})
+	const target = 2.0
+	loss := func() mat.Float {
+		g := ag.NewGraph()
+		defer g.Clear()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, task).(*testTask)
+		diff := g.SubScalar(proc.forward(g.NewVariable(input, false)), g.Constant(target))
+		loss := g.Square(diff)
+		g.Backward(loss)
+		optimizer.Optimize()
+		return loss.ScalarValue()
+	}
+	first := loss()
+	var last mat.Float
+	for i := 0; i < 100; i++ {
+		last = loss()
+	}
+	assert.Less(t, float64(last), float64(first)/100)
+	// the backbone is not modified
+	assert.Equal(t, []mat.Float{1, -1}, backbone.W.Value().Data())
+	assert.Equal(t, []mat.Float{0.5}, backbone.B.Value().Data())
+
+	// the prompt is serialized separately from the backbone
+	dir, err := ioutil.TempDir("", "spago-softprompt-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "task.prompt")
+	require.NoError(t, nn.SaveModel(filename, task.Prompt))
+	loaded, err := Load(filename)
+	require.NoError(t, err)
+	assert.Equal(t, task.Prompt.Config, loaded.Config)
+	require.Len(t, loaded.Vectors, 2)
+	for i, v := range loaded.Vectors {
+		assert.Equal(t, task.Prompt.Vectors[i].Value().Data(), v.Value().Data())
+	}
+
+	_, err = Load(filepath.Join(dir, "missing.prompt"))
+	assert.Error(t, err)
+}
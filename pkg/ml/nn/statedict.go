@@ -0,0 +1,129 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"sort"
+	"strings"
+)
+
+// StateDict maps the paths of the parameters of a model (see ForEachParamWithPath)
+// to their values.
+type StateDict map[string]mat.Matrix
+
+// Paths returns the paths of the state, in lexicographic order.
+func (s StateDict) Paths() []string {
+	paths := make([]string, 0, len(s))
+	for path := range s {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// NamedParams returns the parameters of the model (including sub-models), indexed by path.
+func NamedParams(m Model) map[string]Param {
+	params := make(map[string]Param)
+	ForEachParamWithPath(m, func(path string, param Param) {
+		params[path] = param
+	})
+	return params
+}
+
+// GetParam returns the parameter of the model with the given path, if it exists.
+func GetParam(m Model, path string) (Param, bool) {
+	var found Param
+	ForEachParamWithPath(m, func(p string, param Param) {
+		if found == nil && p == path {
+			found = param
+		}
+	})
+	return found, found != nil
+}
+
+// SetParam copies the given value into the parameter of the model with the given path.
+// It returns an error if the parameter doesn't exist or has different dimensions.
+func SetParam(m Model, path string, value mat.Matrix) error {
+	param, ok := GetParam(m, path)
+	if !ok {
+		return fmt.Errorf("nn: parameter %#v not found", path)
+	}
+	if err := checkDims(path, param, value); err != nil {
+		return err
+	}
+	param.Value().SetData(value.Data())
+	return nil
+}
+
+// checkDims returns an error if the value has not the same dimensions of the parameter.
+func checkDims(path string, param Param, value mat.Matrix) error {
+	if !mat.SameDims(param.Value(), value) {
+		rows, cols := param.Value().Dims()
+		return fmt.Errorf("nn: parameter %#v has dimensions %dx%d, got %dx%d",
+			path, rows, cols, value.Rows(), value.Columns())
+	}
+	return nil
+}
+
+// GetStateDict returns a copy of the values of all the parameters of the model.
+func GetStateDict(m Model) StateDict {
+	state := make(StateDict)
+	ForEachParamWithPath(m, func(path string, param Param) {
+		state[path] = param.Value().Clone()
+	})
+	return state
+}
+
+// LoadStateResult reports the differences between a model and a state loaded into it.
+type LoadStateResult struct {
+	// MissingPaths are the paths of the parameters of the model not found in the state.
+	MissingPaths []string
+	// UnexpectedPaths are the paths of the state not found among the parameters of the model.
+	UnexpectedPaths []string
+}
+
+// LoadStateDict copies the values of the given state into the parameters of the model with the same paths.
+//
+// In strict mode, the paths of the state must exactly match the ones of the model, otherwise an error
+// is returned and the model is not modified. In non-strict mode, only the matching parameters are loaded,
+// and the missing and unexpected paths are reported in the result; this allows the loading of partial
+// states, e.g. a pre-trained encoder into a larger model.
+// In both modes, an error is returned, before loading any value, if the dimensions of a matching
+// parameter differ.
+func LoadStateDict(m Model, state StateDict, strict bool) (LoadStateResult, error) {
+	var result LoadStateResult
+	params := NamedParams(m)
+	for path, param := range params {
+		value, ok := state[path]
+		if !ok {
+			result.MissingPaths = append(result.MissingPaths, path)
+			continue
+		}
+		if err := checkDims(path, param, value); err != nil {
+			return result, err
+		}
+	}
+	for path := range state {
+		if _, ok := params[path]; !ok {
+			result.UnexpectedPaths = append(result.UnexpectedPaths, path)
+		}
+	}
+	sort.Strings(result.MissingPaths)
+	sort.Strings(result.UnexpectedPaths)
+
+	if strict && (len(result.MissingPaths) > 0 || len(result.UnexpectedPaths) > 0) {
+		return result, fmt.Errorf("nn: state mismatch in strict mode: missing [%s], unexpected [%s]",
+			strings.Join(result.MissingPaths, ", "), strings.Join(result.UnexpectedPaths, ", "))
+	}
+
+	for path, param := range params {
+		if value, ok := state[path]; ok {
+			param.Value().SetData(value.Data())
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNamedParams(t *testing.T) {
+	m := newFreezeTestModel()
+	params := NamedParams(m)
+
+	assert.Len(t, params, 7)
+	assert.Same(t, m.Layers[1].W, params["Layers.1.W"])
+	assert.Same(t, m.Embeddings, params["Embeddings"])
+}
+
+func TestGetParamAndSetParam(t *testing.T) {
+	m := newFreezeTestModel()
+
+	param, ok := GetParam(m, "Head.B")
+	assert.True(t, ok)
+	assert.Same(t, m.Head.B, param)
+	_, ok = GetParam(m, "Head.C")
+	assert.False(t, ok)
+
+	require.NoError(t, SetParam(m, "Head.B", mat.NewVecDense([]mat.Float{1, 2})))
+	assert.Equal(t, []mat.Float{1, 2}, m.Head.B.Value().Data())
+
+	assert.Error(t, SetParam(m, "Head.B", mat.NewVecDense([]mat.Float{1, 2, 3})))
+	assert.Error(t, SetParam(m, "Head.C", mat.NewVecDense([]mat.Float{1, 2})))
+}
+
+func TestGetStateDict(t *testing.T) {
+	m := newFreezeTestModel()
+	m.Head.B.Value().SetData([]mat.Float{1, 2})
+
+	state := GetStateDict(m)
+
+	assert.Equal(t, []string{
+		"Embeddings", "Head.B", "Head.W", "Layers.0.B", "Layers.0.W", "Layers.1.B", "Layers.1.W",
+	}, state.Paths())
+	assert.Equal(t, []mat.Float{1, 2}, state["Head.B"].Data())
+
+	// the state is a copy
+	state["Head.B"].SetData([]mat.Float{3, 4})
+	assert.Equal(t, []mat.Float{1, 2}, m.Head.B.Value().Data())
+}
+
+func TestLoadStateDict(t *testing.T) {
+	source := newFreezeTestModel()
+	source.Head.B.Value().SetData([]mat.Float{1, 2})
+	source.Layers[0].B.Value().SetData([]mat.Float{3, 4})
+
+	t.Run("strict", func(t *testing.T) {
+		m := newFreezeTestModel()
+		result, err := LoadStateDict(m, GetStateDict(source), true)
+		require.NoError(t, err)
+		assert.Empty(t, result.MissingPaths)
+		assert.Empty(t, result.UnexpectedPaths)
+		assert.Equal(t, []mat.Float{1, 2}, m.Head.B.Value().Data())
+		assert.Equal(t, []mat.Float{3, 4}, m.Layers[0].B.Value().Data())
+	})
+
+	t.Run("strict with mismatching paths", func(t *testing.T) {
+		m := newFreezeTestModel()
+		state := GetStateDict(source)
+		delete(state, "Embeddings")
+		state["Foo"] = mat.NewScalar(1)
+
+		result, err := LoadStateDict(m, state, true)
+		assert.Error(t, err)
+		assert.Equal(t, []string{"Embeddings"}, result.MissingPaths)
+		assert.Equal(t, []string{"Foo"}, result.UnexpectedPaths)
+		assert.Equal(t, []mat.Float{0, 0}, m.Head.B.Value().Data())
+	})
+
+	t.Run("non-strict", func(t *testing.T) {
+		m := newFreezeTestModel()
+		state := StateDict{
+			"Head.B": mat.NewVecDense([]mat.Float{5, 6}),
+			"Foo":    mat.NewScalar(1),
+		}
+		result, err := LoadStateDict(m, state, false)
+		require.NoError(t, err)
+		assert.Len(t, result.MissingPaths, 6)
+		assert.Equal(t, []string{"Foo"}, result.UnexpectedPaths)
+		assert.Equal(t, []mat.Float{5, 6}, m.Head.B.Value().Data())
+	})
+
+	t.Run("dimensions mismatch", func(t *testing.T) {
+		m := newFreezeTestModel()
+		state := StateDict{
+			"Head.B": mat.NewVecDense([]mat.Float{5, 6}),
+			"Head.W": mat.NewVecDense([]mat.Float{5, 6}),
+		}
+		_, err := LoadStateDict(m, state, false)
+		assert.Error(t, err)
+		assert.Equal(t, []mat.Float{0, 0}, m.Head.B.Value().Data())
+	})
+}
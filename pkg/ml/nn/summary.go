@@ -0,0 +1,155 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"strings"
+	"text/tabwriter"
+	"unsafe"
+)
+
+// floatSize is the size in bytes of a mat.Float.
+const floatSize = int(unsafe.Sizeof(mat.Float(0)))
+
+// ModelSummary describes the structure of a model: its sub-models (layers) and parameters.
+type ModelSummary struct {
+	// Layers contains the model itself, followed by all its sub-models, in traversal order.
+	Layers []LayerSummary
+	// Params contains all the parameters of the model, in traversal order.
+	Params []ParamSummary
+	// NumOfParams is the total number of scalar values of the parameters.
+	NumOfParams int
+	// NumOfTrainableParams is the number of scalar values of the trainable parameters.
+	NumOfTrainableParams int
+	// Bytes is the estimated memory occupied by the values of the parameters, in bytes.
+	// It doesn't include gradients and optimizer support structures.
+	Bytes int
+}
+
+// LayerSummary describes a model, or one of its sub-models.
+type LayerSummary struct {
+	// Path of the layer in the model tree (empty for the root model). See ForEachParamWithPath.
+	Path string
+	// Type is the name of the Go type of the layer (e.g. "linear.Model").
+	Type string
+	// NumOfParams is the number of scalar values of the parameters belonging directly to
+	// the layer, excluding the ones of its sub-models.
+	NumOfParams int
+	// NumOfTrainableParams is the number of scalar values of the trainable parameters
+	// belonging directly to the layer.
+	NumOfTrainableParams int
+}
+
+// ParamSummary describes a parameter.
+type ParamSummary struct {
+	// Path of the parameter in the model tree. See ForEachParamWithPath.
+	Path string
+	// Type of the parameter (weights, biases, undefined).
+	Type ParamsType
+	// Rows and Columns are the dimensions of the parameter's value.
+	Rows, Columns int
+	// Trainable reports whether the parameter requires gradients.
+	Trainable bool
+	// Bytes is the estimated memory occupied by the value of the parameter, in bytes.
+	Bytes int
+}
+
+// Size returns the number of scalar values of the parameter.
+func (p ParamSummary) Size() int {
+	return p.Rows * p.Columns
+}
+
+// Summary walks the model tree and returns the description of its layers and parameters.
+func Summary(m Model) *ModelSummary {
+	s := &ModelSummary{
+		Layers: []LayerSummary{{Path: "", Type: utils.Name(m)}},
+	}
+	pt := newPathParamsTraversal(func(path string, param Param) {
+		rows, cols := param.Value().Dims()
+		p := ParamSummary{
+			Path:      path,
+			Type:      param.Type(),
+			Rows:      rows,
+			Columns:   cols,
+			Trainable: param.RequiresGrad(),
+			Bytes:     rows * cols * floatSize,
+		}
+		s.Params = append(s.Params, p)
+		s.NumOfParams += p.Size()
+		s.Bytes += p.Bytes
+		layer := s.owner(path)
+		layer.NumOfParams += p.Size()
+		if p.Trainable {
+			s.NumOfTrainableParams += p.Size()
+			layer.NumOfTrainableParams += p.Size()
+		}
+	}, true)
+	pt.modelCallback = func(path string, sub Model) {
+		s.Layers = append(s.Layers, LayerSummary{Path: path, Type: utils.Name(sub)})
+	}
+	pt.walk(m)
+	return s
+}
+
+// owner returns the innermost layer containing the parameter with the given path.
+func (s *ModelSummary) owner(path string) *LayerSummary {
+	owner := &s.Layers[0]
+	for i := range s.Layers[1:] {
+		layer := &s.Layers[i+1]
+		if len(layer.Path) > len(owner.Path) && strings.HasPrefix(path, layer.Path+".") {
+			owner = layer
+		}
+	}
+	return owner
+}
+
+// String returns a human-readable table of the layers of the model, with the total number
+// of parameters and the estimated memory, similar to Keras' model.summary().
+func (s *ModelSummary) String() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Layer\tType\tParams\tTrainable")
+	for _, layer := range s.Layers {
+		path := layer.Path
+		if path == "" {
+			path = "(root)"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", path, layer.Type, layer.NumOfParams, layer.NumOfTrainableParams)
+	}
+	_ = w.Flush()
+	_, _ = fmt.Fprintf(&sb, "Total params: %d\n", s.NumOfParams)
+	_, _ = fmt.Fprintf(&sb, "Trainable params: %d\n", s.NumOfTrainableParams)
+	_, _ = fmt.Fprintf(&sb, "Non-trainable params: %d\n", s.NumOfParams-s.NumOfTrainableParams)
+	_, _ = fmt.Fprintf(&sb, "Estimated params memory: %s\n", formatBytes(s.Bytes))
+	return sb.String()
+}
+
+// ParamsString returns a human-readable table of the parameters of the model.
+func (s *ModelSummary) ParamsString() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Param\tType\tShape\tTrainable\tBytes")
+	for _, p := range s.Params {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%dx%d\t%t\t%d\n", p.Path, p.Type, p.Rows, p.Columns, p.Trainable, p.Bytes)
+	}
+	_ = w.Flush()
+	return sb.String()
+}
+
+func formatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
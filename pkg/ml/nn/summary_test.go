@@ -0,0 +1,54 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	m := newFreezeTestModel()
+	Freeze(m, SelectParams().PathPrefix("Embeddings", "Layers.0"))
+
+	s := Summary(m)
+
+	assert.Equal(t, []LayerSummary{
+		{Path: "", Type: "nn.freezeTestModel", NumOfParams: 6, NumOfTrainableParams: 0},
+		{Path: "Layers.0", Type: "nn.freezeTestLayer", NumOfParams: 6, NumOfTrainableParams: 0},
+		{Path: "Layers.1", Type: "nn.freezeTestLayer", NumOfParams: 6, NumOfTrainableParams: 6},
+		{Path: "Head", Type: "nn.freezeTestLayer", NumOfParams: 6, NumOfTrainableParams: 6},
+	}, s.Layers)
+	assert.Len(t, s.Params, 7)
+	assert.Equal(t, ParamSummary{
+		Path:      "Embeddings",
+		Type:      Weights,
+		Rows:      3,
+		Columns:   2,
+		Trainable: false,
+		Bytes:     24,
+	}, s.Params[0])
+	assert.Equal(t, "Head.B", s.Params[6].Path)
+	assert.Equal(t, Biases, s.Params[6].Type)
+	assert.Equal(t, 2, s.Params[6].Size())
+	assert.Equal(t, 24, s.NumOfParams)
+	assert.Equal(t, 12, s.NumOfTrainableParams)
+	assert.Equal(t, 96, s.Bytes)
+
+	str := s.String()
+	assert.True(t, strings.HasPrefix(str, "Layer "))
+	assert.Contains(t, str, "(root)")
+	assert.Contains(t, str, "Total params: 24\n")
+	assert.Contains(t, str, "Non-trainable params: 12\n")
+	assert.Contains(t, str, "Estimated params memory: 96 B\n")
+	assert.Contains(t, s.ParamsString(), "Layers.1.W")
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 MiB", formatBytes(2*1024*1024))
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformer
+
+import (
+	"encoding/gob"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/groupedqueryattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
+)
+
+var (
+	_ nn.Model = &Decoder{}
+	_ nn.Model = &DecoderLayer{}
+)
+
+// Decoder is a Transformer decoder, composed of a list of DecoderLayer.
+type Decoder struct {
+	nn.BaseModel
+	Config    Config
+	Layers    []*DecoderLayer
+	LayerNorm *layernorm.Model
+}
+
+// DecoderLayer is a Transformer decoder layer, with causal self-attention
+// and cross-attention over the encoder hidden states.
+type DecoderLayer struct {
+	nn.BaseModel
+	Config                  Config
+	SelfAttention           *groupedqueryattention.Model
+	SelfAttentionLayerNorm  *layernorm.Model
+	CrossAttention          *groupedqueryattention.Model
+	CrossAttentionLayerNorm *layernorm.Model
+	FFN                     nn.StandardModel
+	FFNLayerNorm            *layernorm.Model
+}
+
+// KeysValuesPairs contains the keys and values used by the self-attention and cross-attention
+// blocks of a DecoderLayer.
+type KeysValuesPairs struct {
+	// SelfAttKeyValues contains the keys and values used by self-attention.
+	SelfAttKeyValues groupedqueryattention.KeysValuesPairs
+	// CrossAttKeyValues contains the keys and values used by cross-attention.
+	CrossAttKeyValues groupedqueryattention.KeysValuesPairs
+}
+
+func init() {
+	gob.Register(&Decoder{})
+	gob.Register(&DecoderLayer{})
+}
+
+// NewDecoder returns a new Transformer Decoder.
+func NewDecoder(config Config) *Decoder {
+	layers := make([]*DecoderLayer, config.NumOfLayers)
+	for i := range layers {
+		layers[i] = NewDecoderLayer(config)
+	}
+	return &Decoder{
+		Config:    config,
+		Layers:    layers,
+		LayerNorm: layernorm.New(config.Size),
+	}
+}
+
+// NewDecoderLayer returns a new Transformer DecoderLayer.
+func NewDecoderLayer(config Config) *DecoderLayer {
+	return &DecoderLayer{
+		Config:                  config,
+		SelfAttention:           config.newAttention(true),
+		SelfAttentionLayerNorm:  layernorm.New(config.Size),
+		CrossAttention:          config.newAttention(false),
+		CrossAttentionLayerNorm: layernorm.New(config.Size),
+		FFN:                     config.newFFN(),
+		FFNLayerNorm:            layernorm.New(config.Size),
+	}
+}
+
+// Decode performs the forward step for each input and returns the result, together with the
+// keys and values of each layer, that can be used as past keys and values in the next step.
+// The past keys and values can be nil.
+func (m *Decoder) Decode(
+	xs []ag.Node,
+	encoderHiddenStates []ag.Node,
+	pastKeysValuesPairs []KeysValuesPairs,
+) ([]ag.Node, []KeysValuesPairs) {
+	nextCache := make([]KeysValuesPairs, len(m.Layers))
+	for i, l := range m.Layers {
+		var past KeysValuesPairs
+		if pastKeysValuesPairs != nil {
+			past = pastKeysValuesPairs[i]
+		}
+		xs, nextCache[i] = l.Forward(xs, encoderHiddenStates, past)
+	}
+	if m.Config.FinalLayerNorm {
+		xs = m.LayerNorm.Forward(xs...)
+	}
+	return xs, nextCache
+}
+
+// Forward performs the forward step for each input and returns the result.
+func (m *DecoderLayer) Forward(
+	xs []ag.Node,
+	encoderHiddenStates []ag.Node,
+	pastKeysValues KeysValuesPairs,
+) ([]ag.Node, KeysValuesPairs) {
+	g := m.Graph()
+	normalizeBefore := m.Config.NormalizeBefore
+	var kvp KeysValuesPairs
+
+	xs = residualBlock(g, normalizeBefore, m.SelfAttentionLayerNorm.Forward, xs, func(xs []ag.Node) []ag.Node {
+		att := m.SelfAttention.ForwardWithPastKeysValues(attention.ToQKV(xs), pastKeysValues.SelfAttKeyValues)
+		kvp.SelfAttKeyValues = att.ProjKeysValues
+		return att.AttOutput
+	})
+
+	xs = residualBlock(g, normalizeBefore, m.CrossAttentionLayerNorm.Forward, xs, func(xs []ag.Node) []ag.Node {
+		qkv := attention.QKV{Queries: xs}
+		// use the past key-values if they are available otherwise use the encoder hidden states
+		if pastKeysValues.CrossAttKeyValues == nil {
+			qkv.Keys = encoderHiddenStates
+			qkv.Values = encoderHiddenStates
+		}
+		att := m.CrossAttention.ForwardWithPastKeysValues(qkv, pastKeysValues.CrossAttKeyValues)
+		kvp.CrossAttKeyValues = att.ProjKeysValues
+		return att.AttOutput
+	})
+
+	xs = residualBlock(g, normalizeBefore, m.FFNLayerNorm.Forward, xs, func(xs []ag.Node) []ag.Node {
+		return m.FFN.Forward(xs...)
+	})
+	return xs, kvp
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformer
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/groupedqueryattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+)
+
+var (
+	_ nn.StandardModel = &Encoder{}
+	_ nn.StandardModel = &EncoderLayer{}
+)
+
+// Encoder is a Transformer encoder, composed of a stack of EncoderLayer.
+type Encoder struct {
+	nn.BaseModel
+	Config    Config
+	Layers    *stack.Model
+	LayerNorm *layernorm.Model
+}
+
+// EncoderLayer is a Transformer encoder layer.
+type EncoderLayer struct {
+	nn.BaseModel
+	Config                 Config
+	SelfAttention          *groupedqueryattention.Model
+	SelfAttentionLayerNorm *layernorm.Model
+	FFN                    nn.StandardModel
+	FFNLayerNorm           *layernorm.Model
+}
+
+func init() {
+	gob.Register(&Encoder{})
+	gob.Register(&EncoderLayer{})
+}
+
+// NewEncoder returns a new Transformer Encoder.
+func NewEncoder(config Config) *Encoder {
+	return &Encoder{
+		Config: config,
+		Layers: stack.Make(config.NumOfLayers, func(_ int) nn.StandardModel {
+			return NewEncoderLayer(config)
+		}),
+		LayerNorm: layernorm.New(config.Size),
+	}
+}
+
+// NewEncoderLayer returns a new Transformer EncoderLayer.
+func NewEncoderLayer(config Config) *EncoderLayer {
+	return &EncoderLayer{
+		Config:                 config,
+		SelfAttention:          config.newAttention(false),
+		SelfAttentionLayerNorm: layernorm.New(config.Size),
+		FFN:                    config.newFFN(),
+		FFNLayerNorm:           layernorm.New(config.Size),
+	}
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *Encoder) Forward(xs ...ag.Node) []ag.Node {
+	ys := m.Layers.Forward(xs...)
+	if m.Config.FinalLayerNorm {
+		ys = m.LayerNorm.Forward(ys...)
+	}
+	return ys
+}
+
+// Forward performs the forward step for each input node and returns the result.
+func (m *EncoderLayer) Forward(xs ...ag.Node) []ag.Node {
+	ys, _ := m.ForwardWithAttention(xs...)
+	return ys
+}
+
+// ForwardWithAttention performs the forward step for each input node and returns the result,
+// together with the self-attention weights of each head, for each input node (query).
+// The weights are nil if the flash attention is enabled.
+func (m *EncoderLayer) ForwardWithAttention(xs ...ag.Node) ([]ag.Node, [][]mat.Matrix) {
+	g := m.Graph()
+	normalizeBefore := m.Config.NormalizeBefore
+	var weights [][]mat.Matrix
+	xs = residualBlock(g, normalizeBefore, m.SelfAttentionLayerNorm.Forward, xs, func(xs []ag.Node) []ag.Node {
+		att := m.SelfAttention.Forward(attention.ToQKV(xs))
+		weights = att.AttWeights
+		return att.AttOutput
+	})
+	ys := residualBlock(g, normalizeBefore, m.FFNLayerNorm.Forward, xs, func(xs []ag.Node) []ag.Node {
+		return m.FFN.Forward(xs...)
+	})
+	return ys, weights
+}
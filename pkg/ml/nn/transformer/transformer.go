@@ -0,0 +1,114 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package transformer provides reusable Transformer encoder and decoder modules,
+// as described in "Attention Is All You Need" (Vaswani et al., 2017 -
+// http://papers.nips.cc/paper/7181-attention-is-all-you-need.pdf).
+//
+// The layers can be configured to apply the normalization before (pre-norm)
+// or after (post-norm) each residual block, to use any activation function
+// in the feed-forward blocks, optionally gated (GLU variants), and to use
+// multi-head, grouped-query or multi-query attention.
+package transformer
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/groupedqueryattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/glu"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+)
+
+// Config provides configuration settings for the Transformer encoder and decoder.
+type Config struct {
+	// Size is the dimension of the input and output vectors.
+	Size int
+	// NumOfLayers is the number of layers.
+	NumOfLayers int
+	// NumOfHeads is the number of attention (query) heads.
+	NumOfHeads int
+	// NumOfKeyValueHeads is the number of key/value heads. If it is zero, it defaults to
+	// NumOfHeads (standard multi-head attention); set it to a divisor of NumOfHeads to
+	// use the grouped-query attention, or to 1 to use the multi-query attention.
+	NumOfKeyValueHeads int
+	// FFNSize is the dimension of the hidden layer of the feed-forward blocks.
+	FFNSize int
+	// Activation is the activation function of the feed-forward blocks.
+	Activation ag.OpName
+	// GatedFFN reports whether the feed-forward blocks are gated linear units without biases
+	// (see package glu), where Activation is applied to the gate, e.g. ag.OpSiLU for SwiGLU
+	// and ag.OpGELU for GeGLU.
+	GatedFFN bool
+	// NormalizeBefore reports whether the layer normalization is applied before
+	// each residual block (pre-norm) instead of after it (post-norm).
+	NormalizeBefore bool
+	// FinalLayerNorm reports whether a layer normalization is applied to the
+	// output of the last layer. It is usually enabled together with NormalizeBefore.
+	FinalLayerNorm bool
+	// FlashAttentionBlockSize, if greater than zero, enables the memory-efficient attention.FlashAttention.
+	FlashAttentionBlockSize int
+}
+
+func (c Config) numOfKeyValueHeads() int {
+	if c.NumOfKeyValueHeads == 0 {
+		return c.NumOfHeads
+	}
+	return c.NumOfKeyValueHeads
+}
+
+func (c Config) newAttention(useCausalMask bool) *groupedqueryattention.Model {
+	return groupedqueryattention.New(groupedqueryattention.Config{
+		Size:                    c.Size,
+		NumOfHeads:              c.NumOfHeads,
+		NumOfKeyValueHeads:      c.numOfKeyValueHeads(),
+		UseCausalMask:           useCausalMask,
+		FlashAttentionBlockSize: c.FlashAttentionBlockSize,
+	})
+}
+
+func (c Config) newFFN() nn.StandardModel {
+	if c.GatedFFN {
+		return glu.New(glu.Config{
+			InputSize:  c.Size,
+			HiddenSize: c.FFNSize,
+			OutputSize: c.Size,
+			Activation: c.Activation,
+		})
+	}
+	return stack.New(
+		linear.New(c.Size, c.FFNSize),
+		activation.New(c.Activation),
+		linear.New(c.FFNSize, c.Size),
+	)
+}
+
+// residualBlock applies the given function to the input, adding the residual connection
+// and the normalization before or after the block, according to the configuration.
+func residualBlock(
+	g *ag.Graph,
+	normalizeBefore bool,
+	norm func(xs ...ag.Node) []ag.Node,
+	xs []ag.Node,
+	block func(xs []ag.Node) []ag.Node,
+) []ag.Node {
+	residual := xs
+	if normalizeBefore {
+		xs = norm(xs...)
+	}
+	xs = add(g, residual, block(xs))
+	if !normalizeBefore {
+		xs = norm(xs...)
+	}
+	return xs
+}
+
+func add(g *ag.Graph, a []ag.Node, b []ag.Node) []ag.Node {
+	c := make([]ag.Node, len(a))
+	for i := 0; i < len(a); i++ {
+		c[i] = g.Add(a[i], b[i])
+	}
+	return c
+}
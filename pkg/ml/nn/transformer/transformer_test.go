@@ -0,0 +1,116 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package transformer
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/glu"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEncoder_Forward(t *testing.T) {
+	for _, normalizeBefore := range []bool{false, true} {
+		config := newTestConfig(normalizeBefore)
+		model := NewEncoder(config)
+		initRandom(model)
+
+		g := ag.NewGraph()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Encoder)
+		ys := proc.Forward(newTestInput(g, 3)...)
+
+		assert.Len(t, ys, 3)
+		for _, y := range ys {
+			assert.Equal(t, config.Size, y.Value().Size())
+		}
+		assert.Len(t, model.Layers.Layers, config.NumOfLayers)
+		assert.Len(t, model.Layers.Layers[0].(*EncoderLayer).SelfAttention.Key, config.NumOfKeyValueHeads)
+	}
+}
+
+func TestDecoder_DecodeWithPastKeysValues(t *testing.T) {
+	model := NewDecoder(newTestConfig(true))
+	initRandom(model)
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Decoder)
+	encoded := newTestInput(g, 4)
+	xs := newTestInput(g, 3)
+
+	// decode the whole sequence at once
+	expected, _ := proc.Decode(xs, encoded, nil)
+
+	// decode one element at a time, reusing the past keys and values
+	actual := make([]ag.Node, len(xs))
+	var cache []KeysValuesPairs
+	for i, x := range xs {
+		var ys []ag.Node
+		ys, cache = proc.Decode([]ag.Node{x}, encoded, cache)
+		actual[i] = ys[0]
+	}
+
+	for i := range expected {
+		assert.InDeltaSlice(t, expected[i].Value().Data(), actual[i].Value().Data(), 1.0e-5)
+	}
+	assert.Len(t, cache[0].SelfAttKeyValues, 2)
+	assert.Len(t, cache[0].SelfAttKeyValues[0].Keys, 3)
+	assert.Len(t, cache[0].CrossAttKeyValues[0].Keys, 4)
+}
+
+func TestEncoder_GatedFFN(t *testing.T) {
+	config := newTestConfig(true)
+	config.GatedFFN = true
+	config.Activation = ag.OpSiLU
+	model := NewEncoder(config)
+	initRandom(model)
+
+	layer := model.Layers.Layers[0].(*EncoderLayer)
+	assert.IsType(t, &glu.Model{}, layer.FFN)
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Encoder)
+	ys := proc.Forward(newTestInput(g, 2)...)
+	assert.Len(t, ys, 2)
+	assert.Equal(t, config.Size, ys[0].Value().Size())
+}
+
+func newTestConfig(normalizeBefore bool) Config {
+	return Config{
+		Size:               8,
+		NumOfLayers:        2,
+		NumOfHeads:         4,
+		NumOfKeyValueHeads: 2,
+		FFNSize:            16,
+		Activation:         ag.OpGELU,
+		NormalizeBefore:    normalizeBefore,
+		FinalLayerNorm:     normalizeBefore,
+	}
+}
+
+func newTestInput(g *ag.Graph, n int) []ag.Node {
+	rndGen := rand.NewLockedRand(1)
+	xs := make([]ag.Node, n)
+	for i := range xs {
+		x := mat.NewEmptyVecDense(8)
+		initializers.Uniform(x, -1, 1, rndGen)
+		xs[i] = g.NewVariable(x, false)
+	}
+	return xs
+}
+
+func initRandom(model nn.Model) {
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, rndGen)
+		} else {
+			initializers.Uniform(param.Value(), -0.1, 0.1, rndGen)
+		}
+	})
+}
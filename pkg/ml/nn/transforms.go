@@ -7,6 +7,7 @@ package nn
 import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/fn"
 )
 
 // Affine performs an affine transformation over an arbitrary (odd) number of nodes held in the input.
@@ -44,28 +45,28 @@ func BiAffine(g *ag.Graph, w, u, v, b, x1, x2 ag.Node) ag.Node {
 	return g.Add(g.Add(g.Add(BiLinear(g, w, x1, x2), g.Mul(g.T(u), x1)), g.Mul(g.T(v), x2)), b)
 }
 
-// Conv2D performs a 2D convolution.
+// Conv2D performs a single-channel 2D convolution, without padding.
+// It uses the im2col-based ag.Graph.Conv2D operator; see the convolution/conv2d package
+// for a full-featured convolution module.
 func Conv2D(g *ag.Graph, w, x ag.Node, xStride, yStride int) ag.Node {
-	var dimx, dimy int
 	if (x.Value().Rows()-w.Value().Rows())%xStride != 0 {
 		panic("Incompatible stride value for rows")
 	}
 	if (x.Value().Columns()-w.Value().Columns())%yStride != 0 {
 		panic("Incompatible stride value for columns")
 	}
-	dimx = (x.Value().Rows()-w.Value().Rows())/xStride + 1
-	dimy = (x.Value().Columns()-w.Value().Columns())/yStride + 1
-
-	var outList []ag.Node
-	for i := 0; i < dimx; i++ {
-		for j := 0; j < dimy; j++ {
-			var view = g.View(x, i*xStride, j*yStride, w.Value().Rows(), w.Value().Columns())
-			var dotProduct = g.Dot(view, w)
-			outList = append(outList, dotProduct)
-		}
+	rows, cols := w.Value().Dims()
+	config := fn.Conv2DConfig{
+		KernelRows:   rows,
+		KernelCols:   cols,
+		StrideRows:   xStride,
+		StrideCols:   yStride,
+		DilationRows: 1,
+		DilationCols: 1,
 	}
-
-	return g.Reshape(g.Concat(outList...), dimx, dimy)
+	dimx, dimy := config.OutputDims(x.Value().Dims())
+	y := g.Conv2D(g.Reshape(w, 1, rows*cols), nil, []ag.Node{x}, config)
+	return g.Reshape(y, dimx, dimy)
 }
 
 // Separate returns a matrix of Node(s) represented as a slice of slice containing the elements extracted from the input.
@@ -19,6 +19,9 @@ type Config struct {
 	Beta1    mat.Float
 	Beta2    mat.Float
 	Epsilon  mat.Float
+	// WeightDecay is the decoupled weight decay of AdamW (Loshchilov and Hutter, 2019): the params
+	// are decayed by StepSize*WeightDecay at each update, apart from the adaptive step.
+	WeightDecay mat.Float
 }
 
 // NewConfig returns a new Adam Config.
@@ -37,6 +40,16 @@ func NewConfig(stepSize, beta1, beta2, epsilon mat.Float) Config {
 	}
 }
 
+// NewAdamWConfig returns a new AdamW Config, that is an Adam Config with decoupled weight decay.
+func NewAdamWConfig(stepSize, beta1, beta2, epsilon, weightDecay mat.Float) Config {
+	if weightDecay < 0.0 {
+		panic("adam: `weightDecay` must be non-negative")
+	}
+	config := NewConfig(stepSize, beta1, beta2, epsilon)
+	config.WeightDecay = weightDecay
+	return config
+}
+
 // NewDefaultConfig returns a new Config with generically reasonable default values.
 func NewDefaultConfig() Config {
 	return Config{
@@ -48,6 +61,7 @@ func NewDefaultConfig() Config {
 }
 
 var _ gd.Method = &Adam{}
+var _ gd.StatefulMethod = &Adam{}
 
 // Adam implements the Adam gradient descent optimization method.
 type Adam struct {
@@ -74,18 +88,18 @@ func (o *Adam) Label() int {
 const (
 	v    int = 0
 	m    int = 1
-	buf1 int = 2 // contains 'grads.ProdScalar(1.0 - beta1)'
-	buf2 int = 3 // contains 'grads.Prod(grads).ProdScalar(1.0 - beta2)'
-	buf3 int = 4
+	buf1 int = 2 // no longer used by the fused update; nil in the new support structures
+	buf2 int = 3 // no longer used by the fused update; nil in the new support structures
+	buf3 int = 4 // contains the delta
 )
 
 // NewSupport returns a new support structure with the given dimensions.
+// The layout of the support structures of the previous versions is kept, so that their states can be
+// restored, but the buffers that are no longer used are not allocated.
 func (o *Adam) NewSupport(r, c int) *nn.Payload {
 	supp := make([]mat.Matrix, 5)
 	supp[v] = mat.NewEmptyDense(r, c)
 	supp[m] = mat.NewEmptyDense(r, c)
-	supp[buf1] = mat.NewEmptyDense(r, c)
-	supp[buf2] = mat.NewEmptyDense(r, c)
 	supp[buf3] = mat.NewEmptyDense(r, c)
 	return &nn.Payload{
 		Label: o.Label(),
@@ -103,37 +117,48 @@ func (o *Adam) updateAlpha() {
 	o.Alpha = o.StepSize * mat.Sqrt(1.0-mat.Pow(o.Beta2, mat.Float(o.TimeStep))) / (1.0 - mat.Pow(o.Beta1, mat.Float(o.TimeStep)))
 }
 
-// Delta returns the difference between the current params and where the method wants it to be.
-func (o *Adam) Delta(param nn.Param) mat.Matrix {
-	return o.calcDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data)
+// MethodState returns the time step of the optimizer.
+func (o *Adam) MethodState() map[string]mat.Float {
+	return map[string]mat.Float{"TimeStep": mat.Float(o.TimeStep)}
 }
 
-// v = v*beta1 + grads*(1.0-beta1)
-// m = m*beta2 + (grads*grads)*(1.0-beta2)
-// d = (v / (sqrt(m) + eps)) * alpha
-func (o *Adam) calcDelta(grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
-	updateV(grads, supp, o.Beta1)
-	updateM(grads, supp, o.Beta2)
-	buf := supp[m].Sqrt().AddScalarInPlace(o.Epsilon)
-	defer mat.ReleaseMatrix(buf)
-	suppDiv := supp[v].Div(buf)
-	defer mat.ReleaseMatrix(suppDiv)
-	supp[buf3].ProdMatrixScalarInPlace(suppDiv, o.Alpha)
-	return supp[buf3]
+// SetMethodState restores the time step of the optimizer.
+func (o *Adam) SetMethodState(state map[string]mat.Float) {
+	o.TimeStep = int(state["TimeStep"])
+	o.updateAlpha()
 }
 
-// v = v*beta1 + grads*(1.0-beta1)
-func updateV(grads mat.Matrix, supp []mat.Matrix, beta1 mat.Float) {
-	supp[v].ProdScalarInPlace(beta1)
-	supp[buf1].ProdMatrixScalarInPlace(grads, 1.0-beta1)
-	supp[v].AddInPlace(supp[buf1])
+// Delta returns the difference between the current params and where the method wants it to be.
+func (o *Adam) Delta(param nn.Param) mat.Matrix {
+	return o.calcDelta(param.Value(), param.Grad(), gd.GetOrSetPayload(param, o).Data)
 }
 
+// calcDelta updates the moments and returns the delta in a single pass over the elements, without
+// intermediate matrices:
+//
+// v = v*beta1 + grads*(1.0-beta1)
 // m = m*beta2 + (grads*grads)*(1.0-beta2)
-func updateM(grads mat.Matrix, supp []mat.Matrix, beta2 mat.Float) {
-	supp[m].ProdScalarInPlace(beta2)
-	sqGrad := grads.Prod(grads)
-	defer mat.ReleaseMatrix(sqGrad)
-	supp[buf2].ProdMatrixScalarInPlace(sqGrad, 1.0-beta2)
-	supp[m].AddInPlace(supp[buf2])
+// d = (v / (sqrt(m) + eps)) * alpha + params*stepSize*weightDecay
+func (o *Adam) calcDelta(params, grads mat.Matrix, supp []mat.Matrix) mat.Matrix {
+	gs := grads.Data()
+	vs := supp[v].Data()
+	ms := supp[m].Data()
+	ds := supp[buf3].Data()
+	beta1, beta2 := o.Beta1, o.Beta2
+	if o.WeightDecay == 0.0 {
+		for i, g := range gs {
+			vs[i] = vs[i]*beta1 + g*(1.0-beta1)
+			ms[i] = ms[i]*beta2 + g*g*(1.0-beta2)
+			ds[i] = vs[i] / (mat.Sqrt(ms[i]) + o.Epsilon) * o.Alpha
+		}
+		return supp[buf3]
+	}
+	ps := params.Data()
+	decay := o.StepSize * o.WeightDecay
+	for i, g := range gs {
+		vs[i] = vs[i]*beta1 + g*(1.0-beta1)
+		ms[i] = ms[i]*beta2 + g*g*(1.0-beta2)
+		ds[i] = vs[i]/(mat.Sqrt(ms[i])+o.Epsilon)*o.Alpha + ps[i]*decay
+	}
+	return supp[buf3]
 }
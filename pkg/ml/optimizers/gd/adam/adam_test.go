@@ -6,7 +6,15 @@ package adam
 
 import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -36,7 +44,7 @@ func Test_Update(t *testing.T) {
 	supp[v].SetData([]mat.Float{0.7, 0.8, 0.5, 0.3, 0.2})
 	supp[m].SetData([]mat.Float{1.0, 0.4, 0.7, 0.0, 0.2})
 
-	params.SubInPlace(updater.calcDelta(grads, supp))
+	params.SubInPlace(updater.calcDelta(params, grads, supp))
 
 	assert.InDeltaSlice(t, []mat.Float{0.399772, 0.399605, 0.4998147, 0.995625, 0.799865}, params.Data(), 1.0e-6)
 }
@@ -65,7 +73,7 @@ func Test_Update2(t *testing.T) {
 
 	// === First iteration
 
-	params.SubInPlace(updater.calcDelta(grads, supp))
+	params.SubInPlace(updater.calcDelta(params, grads, supp))
 
 	assert.InDeltaSlice(t, []mat.Float{
 		0.05, 0.03, -0.01,
@@ -95,7 +103,7 @@ func Test_Update2(t *testing.T) {
 		0.44, 1.44, 2.44,
 	})
 
-	params.SubInPlace(updater.calcDelta(grads2, supp))
+	params.SubInPlace(updater.calcDelta(params, grads2, supp))
 
 	assert.InDeltaSlice(t, []mat.Float{
 		0.115, 0.071, -0.075,
@@ -115,3 +123,83 @@ func Test_Update2(t *testing.T) {
 		0.698005353030075, -0.399429341903112, 0.198229065305535,
 	}, params.Data(), 1.0e-5)
 }
+
+func Test_UpdateAdamW(t *testing.T) {
+	adam := New(NewConfig(0.001, 0.9, 0.999, 1.0e-8))
+	adamW := New(NewAdamWConfig(0.001, 0.9, 0.999, 1.0e-8, 0.01))
+	assert.Panics(t, func() { NewAdamWConfig(0.001, 0.9, 0.999, 1.0e-8, -1) })
+
+	params := mat.NewVecDense([]mat.Float{0.4, -0.4, 0.5, 1.0, 0.0})
+	grads := mat.NewVecDense([]mat.Float{0.9, 0.7, 0.4, 0.8, 0.1})
+	supp := adam.NewSupport(params.Dims()).Data
+	suppW := adamW.NewSupport(params.Dims()).Data
+	assert.Nil(t, supp[buf1])
+	assert.Nil(t, supp[buf2])
+
+	delta := adam.calcDelta(params, grads, supp).Data()
+	deltaW := adamW.calcDelta(params, grads, suppW).Data()
+	// the weight decay is decoupled from the adaptive step
+	for i, p := range params.Data() {
+		assert.InDelta(t, delta[i]+p*0.001*0.01, deltaW[i], 1.0e-9)
+	}
+	assert.Equal(t, supp[v].Data(), suppW[v].Data())
+	assert.Equal(t, supp[m].Data(), suppW[m].Data())
+}
+
+func BenchmarkAdam_Delta(b *testing.B) {
+	param := nn.NewParam(mat.NewEmptyDense(768, 768))
+	grads := mat.NewEmptyDense(768, 768)
+	for i := range grads.Data() {
+		grads.Data()[i] = mat.Float(i%7) - 3
+	}
+	updater := New(NewAdamWConfig(0.001, 0.9, 0.999, 1.0e-8, 0.01))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		param.PropagateGrad(grads)
+		updater.Delta(param)
+		param.ZeroGrad()
+	}
+}
+
+func TestOptimizerState(t *testing.T) {
+	model := linear.New(2, 1)
+	optimizer := gd.NewOptimizer(New(NewDefaultConfig()), nn.NewDefaultParamsIterator(model))
+	optimizationStep(optimizer, model)
+	optimizationStep(optimizer, model)
+
+	state := optimizer.GetState(model)
+	assert.Equal(t, gd.Adam, state.Label)
+	assert.Equal(t, mat.Float(3), state.Method["TimeStep"])
+	assert.Equal(t, []string{"B", "W"}, state.Paths())
+
+	dir, err := ioutil.TempDir("", "spago-adam-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "optimizer.bin")
+	require.NoError(t, gd.SaveState(filename, state))
+	state, err = gd.LoadStateFromFile(filename)
+	require.NoError(t, err)
+
+	// resume the training with a copy of the model
+	resumedModel := linear.New(2, 1)
+	_, err = nn.LoadStateDict(resumedModel, nn.GetStateDict(model), true)
+	require.NoError(t, err)
+	resumed := gd.NewOptimizer(New(NewDefaultConfig()), nn.NewDefaultParamsIterator(resumedModel))
+	require.NoError(t, resumed.LoadState(resumedModel, state))
+
+	optimizationStep(optimizer, model)
+	optimizationStep(resumed, resumedModel)
+	assert.InDeltaSlice(t, model.W.Value().Data(), resumedModel.W.Value().Data(), 1.0e-6)
+	assert.InDeltaSlice(t, model.B.Value().Data(), resumedModel.B.Value().Data(), 1.0e-6)
+
+	other := gd.NewOptimizer(sgd.New(sgd.NewConfig(0.1, 0.0, false)), nn.NewDefaultParamsIterator(resumedModel))
+	assert.Error(t, other.LoadState(resumedModel, state))
+}
+
+func optimizationStep(optimizer *gd.GradientDescent, model *linear.Model) {
+	model.W.PropagateGrad(mat.NewDense(1, 2, []mat.Float{0.5, -0.3}))
+	model.B.PropagateGrad(mat.NewVecDense([]mat.Float{0.2}))
+	optimizer.Optimize()
+	optimizer.IncExample()
+}
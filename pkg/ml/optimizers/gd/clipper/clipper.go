@@ -14,6 +14,15 @@ type GradClipper interface {
 	Clip(gs []mat.Matrix)
 }
 
+// ParamsGradClipper is a GradClipper whose clipping depends on the values of the
+// params too, besides their gradients.
+type ParamsGradClipper interface {
+	GradClipper
+	// ClipWithParams clips in place the gradients gs of the params whose values
+	// are ws, in the same order.
+	ClipWithParams(ws, gs []mat.Matrix)
+}
+
 // ClipValue is a GradClipper which clips the values of a matrix between
 // -Value and +Value.
 type ClipValue struct {
@@ -60,3 +69,66 @@ func (c *ClipNorm) Clip(gs []mat.Matrix) {
 		}
 	}
 }
+
+var _ ParamsGradClipper = &ClipAdaptive{}
+
+// ClipAdaptive is a ParamsGradClipper implementing the unit-wise Adaptive Gradient
+// Clipping (AGC) of Brock et al., 2021 (https://arxiv.org/abs/2102.06171).
+//
+// The gradients of each unit, that is each row of a matrix, or each element of a
+// vector (e.g. a bias), are rescaled whenever their norm exceeds Clipping times the
+// norm of the weights of the same unit. The norm of the weights is at least Epsilon, so that the units
+// initialized to zero can still be updated.
+type ClipAdaptive struct {
+	Clipping, Epsilon mat.Float
+}
+
+// Clip panics, since the adaptive clipping requires the values of the params.
+// Use ClipWithParams instead.
+func (c *ClipAdaptive) Clip(_ []mat.Matrix) {
+	panic("gd: the adaptive gradient clipping requires the values of the params")
+}
+
+// ClipWithParams clips in place the gradients gs, unit-wise, according to the
+// values ws of the corresponding params.
+func (c *ClipAdaptive) ClipWithParams(ws, gs []mat.Matrix) {
+	if len(ws) != len(gs) {
+		panic("gd: the number of params and gradients must be the same")
+	}
+	for i, g := range gs {
+		w := ws[i]
+		if !mat.SameDims(w, g) {
+			panic("gd: the params and the gradients must have the same dimensions")
+		}
+		unitSize := g.Columns()
+		if g.IsVector() {
+			unitSize = 1
+		}
+		wData, gData := w.Data(), g.Data()
+		for start := 0; start < len(gData); start += unitSize {
+			c.clipUnit(wData[start:start+unitSize], gData[start:start+unitSize])
+		}
+	}
+}
+
+// clipUnit rescales the gradients g of a single unit, whose weights are w.
+func (c *ClipAdaptive) clipUnit(w, g []mat.Float) {
+	maxNorm := c.Clipping * mat.Max(norm2(w), c.Epsilon)
+	gNorm := norm2(g)
+	if gNorm <= maxNorm {
+		return
+	}
+	scale := maxNorm / gNorm
+	for i := range g {
+		g[i] *= scale
+	}
+}
+
+// norm2 returns the Euclidean norm of the values.
+func norm2(xs []mat.Float) mat.Float {
+	var sum mat.Float
+	for _, x := range xs {
+		sum += x * x
+	}
+	return mat.Sqrt(sum)
+}
@@ -61,3 +61,33 @@ func buildTestGrads() []mat.Matrix {
 		mat.NewVecDense([]mat.Float{0.9, 0.7, 0.4, 0.8, 0.1}),
 	}
 }
+
+func TestClipAdaptive(t *testing.T) {
+	gs := buildTestGrads()
+	ws := []mat.Matrix{
+		mat.NewDense(4, 5, []mat.Float{
+			3.0, 4.0, 0.0, 0.0, 0.0,
+			0.0, 0.0, 0.0, 0.0, 0.0,
+			10.0, 10.0, 10.0, 10.0, 10.0,
+			1.0, 0.0, 0.0, 0.0, 0.0,
+		}),
+		mat.NewVecDense([]mat.Float{1.0, 0.0, 10.0, 0.0, 2.0}),
+	}
+	(&ClipAdaptive{Clipping: 0.1, Epsilon: 1.0e-3}).ClipWithParams(ws, gs)
+
+	// row 0: |w| = 5, |g| = 1.449138 > 0.5
+	// row 1: |w| = 0 < epsilon, |g| = 1.337909 > 0.0001
+	// row 2: |w| = 22.36068, |g| = 1.337909 <= 2.236068, unchanged
+	// row 3: |w| = 1, |g| = 1.516575 > 0.1
+	assert.InDeltaSlice(t, []mat.Float{
+		0.172516, 0.207020, -0.276026, -0.207020, 0.241523,
+		-0.0000299, 0.0000075, -0.0000598, 0.0000523, -0.0000523,
+		0.3, 0.5, 0.8, -0.9, 0.0,
+		-0.006594, 0.026375, 0.065938, -0.046157, 0.052750,
+	}, gs[0].Data(), 1.0e-06)
+	// element-wise: 0.9 > 0.1, 0.7 > 0.0001, 0.4 <= 1.0, 0.8 > 0.0001, 0.1 <= 0.2
+	assert.InDeltaSlice(t, []mat.Float{0.1, 0.0001, 0.4, 0.0001, 0.1}, gs[1].Data(), 1.0e-06)
+
+	assert.Panics(t, func() { (&ClipAdaptive{Clipping: 0.1}).Clip(buildTestGrads()) })
+	assert.Panics(t, func() { (&ClipAdaptive{Clipping: 0.1}).ClipWithParams(ws[:1], buildTestGrads()) })
+}
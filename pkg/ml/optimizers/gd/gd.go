@@ -6,17 +6,23 @@ package gd
 
 import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/clipper"
+	"github.com/nlpodyssey/spago/pkg/utils"
 	"github.com/nlpodyssey/spago/pkg/utils/processingqueue"
 	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 // GradientDescent implements Gradients Descent (GD) optimization.
 type GradientDescent struct {
 	method           Method // optimization method (SGD, AdaGrad, Adam, ...)
 	gradClipper      clipper.GradClipper
+	gradNoise        *gradNoise
 	paramsGetter     nn.ParamsGetter
 	paramsToOptimize []nn.Param
 	// processingQueue allows proper handling for computationally heavy operations
@@ -49,6 +55,39 @@ func ClipGradByNorm(max, normType mat.Float) Option {
 	}
 }
 
+// ClipGradAdaptive is an option to clip the gradients during the training with the unit-wise
+// Adaptive Gradient Clipping (AGC), which bounds the ratio between the norm of the gradients of
+// each unit and the norm of its weights to clipping (see clipper.ClipAdaptive).
+// Typical values of clipping range from 0.01 to 0.16; epsilon is usually 1e-3.
+func ClipGradAdaptive(clipping, epsilon mat.Float) Option {
+	if clipping <= 0 || epsilon < 0 {
+		panic("gd: the adaptive clipping requires clipping > 0 and epsilon >= 0")
+	}
+	return func(f *GradientDescent) {
+		f.gradClipper = &clipper.ClipAdaptive{
+			Clipping: clipping,
+			Epsilon:  epsilon,
+		}
+	}
+}
+
+// GradNoise is an option to add annealed Gaussian noise to the gradients before the clipping
+// (Neelakantan et al., 2015, https://arxiv.org/abs/1511.06807). At the optimization step t
+// (starting from 0) the noise has zero mean and variance eta / (1 + t)^gamma.
+// The paper suggests eta in {0.01, 0.3, 1.0} and gamma = 0.55.
+func GradNoise(eta, gamma mat.Float, seed uint64) Option {
+	if eta <= 0 || gamma < 0 {
+		panic("gd: the gradient noise requires eta > 0 and gamma >= 0")
+	}
+	return func(f *GradientDescent) {
+		f.gradNoise = &gradNoise{
+			eta:     eta,
+			gamma:   gamma,
+			randGen: rand.NewLockedRand(seed),
+		}
+	}
+}
+
 // ConcurrentComputations sets the maximum number of concurrent computations handled by the GradientDescent
 // for heavy tasks such as the params update steps.
 // The value 1 corresponds to sequential execution.
@@ -75,47 +114,91 @@ func NewOptimizer(method Method, paramsIterator nn.ParamsGetter, opts ...Option)
 	return optimizer
 }
 
-// Optimize optimize the params, applying the optional gradient clipping.
+// Optimize optimize the params, applying the optional gradient noise and clipping.
 // After the optimization the params have zero gradients.
 func (o *GradientDescent) Optimize() {
 	o.paramsToOptimize = o.paramsGetter.Params()
 	if o.paramsToOptimize == nil {
 		return
 	}
+	o.addGradNoise()
 	o.clipGrads()
 	o.updateParams()
 	o.paramsToOptimize = nil
+	if method, ok := o.method.(StepScheduler); ok {
+		method.IncStep()
+	}
 }
 
-// updateParamsSerial applies the optimization method to all the observed parameters.
-func (o *GradientDescent) updateParamsSerial() {
-	for _, param := range o.paramsToOptimize {
-		if param.HasGrad() {
-			delta := o.method.Delta(param) // important: don't release delta here
-			param.ApplyDelta(delta)
-			param.ZeroGrad()
-		}
-	}
+// Params returns the parameters observed by the optimizer.
+func (o *GradientDescent) Params() []nn.Param {
+	return o.paramsGetter.Params()
 }
 
-// updateParams applies the optimization method to all the observed parameters concurrently.
+// updateParams applies the optimization method to all the observed parameters with gradients.
+//
+// The updates of distinct parameters are independent, so they run concurrently on as many goroutines
+// as the size of the processing queue, each one taking the next parameter to update, from the
+// largest to the smallest so that the goroutines end together. Each parameter is updated by a single
+// goroutine, hence the result is the same of the serial update, which is used anyway when the
+// processing queue has size 1 or the deterministic mode is enabled (see ag.SetDeterministic).
 func (o *GradientDescent) updateParams() {
+	params := o.paramsWithGrad()
+	workers := utils.MinInt(o.processingQueue.Size(), len(params))
+	if workers <= 1 || ag.DeterministicEnabled() {
+		for _, param := range params {
+			o.updateParam(param)
+		}
+		return
+	}
+	sort.SliceStable(params, func(i, j int) bool {
+		return params[i].Value().Size() > params[j].Value().Size()
+	})
+	next := int64(-1)
 	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		o.processingQueue.Go(func() {
+			defer wg.Done()
+			for j := int(atomic.AddInt64(&next, 1)); j < len(params); j = int(atomic.AddInt64(&next, 1)) {
+				o.updateParam(params[j])
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// paramsWithGrad returns the observed parameters with gradients, once each, even if they are shared
+// by several modules (e.g. tied embeddings).
+func (o *GradientDescent) paramsWithGrad() []nn.Param {
+	params := make([]nn.Param, 0, len(o.paramsToOptimize))
+	seen := make(map[nn.Param]struct{}, len(o.paramsToOptimize))
 	for _, param := range o.paramsToOptimize {
-		if !param.HasGrad() {
+		if _, ok := seen[param]; ok || !param.HasGrad() {
 			continue
 		}
-		wg.Add(1)
-		go func(param nn.Param) {
-			defer wg.Done()
-			o.processingQueue.Run(func() {
-				delta := o.method.Delta(param)
-				param.ApplyDelta(delta)
-			})
-			param.ZeroGrad()
-		}(param)
+		seen[param] = struct{}{}
+		params = append(params, param)
 	}
-	wg.Wait()
+	return params
+}
+
+// updateParam applies the optimization method to the parameter, and clears its gradients.
+func (o *GradientDescent) updateParam(param nn.Param) {
+	delta := o.method.Delta(param) // important: don't release delta here
+	param.ApplyDelta(delta)
+	param.ZeroGrad()
+}
+
+// addGradNoise adds the optional Gaussian noise to the gradients of all the observed parameters.
+func (o *GradientDescent) addGradNoise() {
+	if o.gradNoise == nil {
+		return
+	}
+	for _, param := range o.paramsWithGrad() {
+		o.gradNoise.add(param.Grad())
+	}
+	o.gradNoise.step++
 }
 
 // clipGrad applies the gradient clipping to all the observed parameters.
@@ -123,15 +206,37 @@ func (o *GradientDescent) clipGrads() {
 	if o.gradClipper == nil {
 		return
 	}
-	var gs []mat.Matrix
-	for _, param := range o.paramsToOptimize {
-		if param.HasGrad() { // don't consider grad at zero
-			gs = append(gs, param.Grad())
-		}
+	params := o.paramsWithGrad() // don't consider grad at zero, nor the shared params twice
+	ws := make([]mat.Matrix, len(params))
+	gs := make([]mat.Matrix, len(params))
+	for i, param := range params {
+		ws[i] = param.Value()
+		gs[i] = param.Grad()
+	}
+	if c, ok := o.gradClipper.(clipper.ParamsGradClipper); ok {
+		c.ClipWithParams(ws, gs)
+		return
 	}
 	o.gradClipper.Clip(gs)
 }
 
+// gradNoise adds to the gradients a Gaussian noise with zero mean, whose variance decays with
+// the optimization steps. See GradNoise.
+type gradNoise struct {
+	eta, gamma mat.Float
+	step       int
+	randGen    *rand.LockedRand
+}
+
+// add adds the noise of the current step to the gradients in place.
+func (n *gradNoise) add(grad mat.Matrix) {
+	stdDev := mat.Sqrt(n.eta / mat.Pow(1+mat.Float(n.step), n.gamma))
+	data := grad.Data()
+	for i := range data {
+		data[i] += mat.Float(n.randGen.NormFloat32()) * stdDev
+	}
+}
+
 // IncExample beats the occurrence of a new example.
 func (o *GradientDescent) IncExample() {
 	if method, ok := o.method.(ExampleScheduler); ok {
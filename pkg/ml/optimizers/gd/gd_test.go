@@ -0,0 +1,138 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd_test
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testModel struct {
+	nn.BaseModel
+	Params []nn.Param `spago:"type:weights"`
+	// Tied is the same param of Params[0].
+	Tied nn.Param `spago:"type:weights"`
+}
+
+// newTestModel returns a model with params of different sizes, with random values and gradients.
+func newTestModel(n int) *testModel {
+	randGen := rand.NewLockedRand(42)
+	m := &testModel{Params: make([]nn.Param, n)}
+	for i := range m.Params {
+		m.Params[i] = nn.NewParam(mat.NewEmptyDense(i+1, 3))
+		initializers.Uniform(m.Params[i].Value(), -1, 1, randGen)
+	}
+	m.Tied = m.Params[0]
+	return m
+}
+
+func setTestGrads(m *testModel, step int) {
+	randGen := rand.NewLockedRand(uint64(step))
+	for _, p := range m.Params {
+		grad := mat.NewEmptyDense(p.Value().Dims())
+		initializers.Uniform(grad, -1, 1, randGen)
+		p.PropagateGrad(grad)
+	}
+}
+
+func TestGradientDescent_Optimize(t *testing.T) {
+	serial := newTestModel(20)
+	concurrent := newTestModel(20)
+	serialOptimizer := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(serial),
+		gd.ConcurrentComputations(1))
+	concurrentOptimizer := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(concurrent),
+		gd.ConcurrentComputations(4))
+
+	for step := 0; step < 5; step++ {
+		setTestGrads(serial, step)
+		setTestGrads(concurrent, step)
+		serialOptimizer.IncExample()
+		concurrentOptimizer.IncExample()
+		serialOptimizer.Optimize()
+		concurrentOptimizer.Optimize()
+	}
+	for i, p := range concurrent.Params {
+		assert.False(t, p.HasGrad())
+		assert.Equal(t, serial.Params[i].Value().Data(), p.Value().Data(), i)
+	}
+}
+
+func TestClipGradByNorm_TiedParams(t *testing.T) {
+	m := &testModel{Params: []nn.Param{nn.NewParam(mat.NewEmptyVecDense(2))}}
+	m.Tied = m.Params[0]
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(1, 0, false)), nn.NewDefaultParamsIterator(m),
+		gd.ClipGradByNorm(1, 2))
+	m.Params[0].PropagateGrad(mat.NewVecDense([]mat.Float{3, 4}))
+	optimizer.Optimize()
+	// the norm of the gradients is 5, not counting the tied param twice
+	assert.InDeltaSlice(t, []mat.Float{-0.6, -0.8}, m.Params[0].Value().Data(), 1.0e-06)
+}
+
+func TestGradNoise(t *testing.T) {
+	newModel := func() *testModel {
+		return &testModel{Params: []nn.Param{nn.NewParam(mat.NewEmptyDense(100, 100))}}
+	}
+	noisy, other := newModel(), newModel()
+	noisyOptimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(1, 0, false)), nn.NewDefaultParamsIterator(noisy),
+		gd.GradNoise(1, 0.55, 42))
+	otherOptimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(1, 0, false)), nn.NewDefaultParamsIterator(other),
+		gd.GradNoise(1, 0.55, 42))
+
+	// with zero gradients, the updates are the opposite of the noise
+	variance := func(m *testModel) mat.Float {
+		p := m.Params[0]
+		v := p.Value().Pow(2).Sum() / mat.Float(p.Value().Size())
+		p.Value().Zeros()
+		return v
+	}
+	for step := 0; step < 100; step++ {
+		for _, m := range []*testModel{noisy, other} {
+			m.Params[0].PropagateGrad(mat.NewEmptyDense(100, 100))
+		}
+		noisyOptimizer.Optimize()
+		otherOptimizer.Optimize()
+		assert.Equal(t, noisy.Params[0].Value().Data(), other.Params[0].Value().Data())
+		expected := 1 / mat.Pow(1+mat.Float(step), 0.55)
+		assert.InDelta(t, expected, variance(noisy), float64(expected)*0.1, step)
+		variance(other)
+	}
+}
+
+func TestClipGradAdaptive(t *testing.T) {
+	m := &testModel{Params: []nn.Param{nn.NewParam(mat.NewDense(2, 2, []mat.Float{3, 4, 0, 0}))}}
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(1, 0, false)), nn.NewDefaultParamsIterator(m),
+		gd.ClipGradAdaptive(0.1, 0.001))
+	m.Params[0].PropagateGrad(mat.NewDense(2, 2, []mat.Float{0.3, 0.4, 0.6, 0.8}))
+	optimizer.Optimize()
+	assert.InDeltaSlice(t, []mat.Float{2.7, 3.6, -0.00006, -0.00008}, m.Params[0].Value().Data(), 1.0e-06)
+
+	assert.Panics(t, func() { gd.ClipGradAdaptive(0, 0.001) })
+	assert.Panics(t, func() { gd.GradNoise(0, 0.55, 42) })
+}
+
+func BenchmarkGradientDescent_Optimize(b *testing.B) {
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			m := newTestModel(100)
+			optimizer := gd.NewOptimizer(adam.New(adam.NewDefaultConfig()), nn.NewDefaultParamsIterator(m),
+				gd.ConcurrentComputations(workers))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				setTestGrads(m, i)
+				b.StartTimer()
+				optimizer.Optimize()
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lookahead implements the Lookahead optimizer (Zhang et al., 2019,
+// https://arxiv.org/abs/1907.08610), which wraps any other gradient descent method.
+//
+// The wrapped method updates the "fast" weights as usual; every K optimization steps the "slow"
+// weights move towards them by a fraction Alpha of their distance, and the fast weights restart
+// from the new slow weights.
+package lookahead
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"sync"
+)
+
+// Config provides configuration settings for Lookahead.
+type Config struct {
+	// K is the number of optimization steps of the wrapped method between two synchronizations.
+	K int
+	// Alpha is the step size of the slow weights towards the fast weights.
+	Alpha mat.Float
+}
+
+// NewConfig returns a new Lookahead Config.
+func NewConfig(k int, alpha mat.Float) Config {
+	if k < 1 {
+		panic("lookahead: `k` must be greater than zero")
+	}
+	if !(alpha > 0.0 && alpha <= 1.0) {
+		panic("lookahead: `alpha` must be in the range (0.0, 1.0]")
+	}
+	return Config{
+		K:     k,
+		Alpha: alpha,
+	}
+}
+
+// NewDefaultConfig returns a new Config with the default values suggested in the paper.
+func NewDefaultConfig() Config {
+	return Config{
+		K:     5,
+		Alpha: 0.5,
+	}
+}
+
+var _ gd.Method = &Lookahead{}
+var _ gd.StatefulMethod = &Lookahead{}
+var _ gd.StepScheduler = &Lookahead{}
+
+// Lookahead implements the Lookahead gradient descent optimization method around another method.
+//
+// The slow weights are kept after the support structure of the wrapped method, which has the
+// same label, so that the state of the optimizer can be saved and loaded as usual.
+//
+// The synchronization involves all the params updated at least once, including those without
+// gradients in the synchronization step.
+type Lookahead struct {
+	Config
+	method gd.Method
+	// Step is the number of optimization steps done so far.
+	Step int
+	// slow is the index of the slow weights in the support structures.
+	slow int
+	// params are the params with slow weights, in order of their first update.
+	params []nn.Param
+	// tracked is the set of params, guarded by mu, since Delta can be called concurrently.
+	tracked map[nn.Param]struct{}
+	mu      sync.Mutex
+}
+
+// New returns a new Lookahead optimizer around the given method.
+func New(method gd.Method, c Config) *Lookahead {
+	return &Lookahead{
+		Config:  c,
+		method:  method,
+		slow:    len(method.NewSupport(1, 1).Data),
+		tracked: make(map[nn.Param]struct{}),
+	}
+}
+
+// Method returns the wrapped optimization method.
+func (o *Lookahead) Method() gd.Method {
+	return o.method
+}
+
+// Label returns the enumeration-like value which identifies the wrapped gradient descent method.
+func (o *Lookahead) Label() int {
+	return o.method.Label()
+}
+
+// NewSupport returns a new support structure with the given dimensions, made of the support
+// structure of the wrapped method followed by the slow weights.
+func (o *Lookahead) NewSupport(r, c int) *nn.Payload {
+	supp := o.method.NewSupport(r, c)
+	supp.Data = append(supp.Data, nil) // the slow weights are initialized on the first update
+	return supp
+}
+
+// Delta returns the difference between the current params and where the method wants it to be,
+// that is the delta of the wrapped method. The params are moved to the updated slow weights at
+// the end of the synchronization steps (see IncStep).
+func (o *Lookahead) Delta(param nn.Param) mat.Matrix {
+	o.track(param)
+	return o.method.Delta(param)
+}
+
+// track initializes the slow weights of the param on its first update, and adds it to the params
+// to synchronize.
+func (o *Lookahead) track(param nn.Param) {
+	o.slowWeights(param)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.tracked[param]; !ok {
+		o.tracked[param] = struct{}{}
+		o.params = append(o.params, param)
+	}
+}
+
+// synchronize moves the slow weights of all the tracked params towards their fast weights, by a
+// fraction Alpha of their distance, and sets the params to the new slow weights.
+func (o *Lookahead) synchronize() {
+	for _, param := range o.params {
+		slow := o.slowWeights(param)
+		value := param.Value()
+		delta := mat.NewEmptyDense(value.Dims())
+		ps, ss, ds := value.Data(), slow.Data(), delta.Data()
+		for i, p := range ps {
+			ss[i] += o.Alpha * (p - ss[i])
+			ds[i] = p - ss[i]
+		}
+		param.ApplyDelta(delta)
+		mat.ReleaseDense(delta)
+	}
+}
+
+// slowWeights returns the slow weights of the param, initializing them to the current value of
+// the param if needed.
+func (o *Lookahead) slowWeights(param nn.Param) mat.Matrix {
+	supp := gd.GetOrSetPayload(param, o)
+	if len(supp.Data) == o.slow { // e.g. created by the wrapped method alone
+		supp.Data = append(supp.Data, nil)
+	}
+	if supp.Data[o.slow] == nil {
+		supp.Data[o.slow] = param.Value().Clone()
+	}
+	return supp.Data[o.slow]
+}
+
+// IncStep beats the occurrence of a new optimization step, synchronizing the params every K steps.
+func (o *Lookahead) IncStep() {
+	o.Step++
+	if method, ok := o.method.(gd.StepScheduler); ok {
+		method.IncStep()
+	}
+	if o.Step%o.K == 0 {
+		o.synchronize()
+	}
+}
+
+// IncExample beats the occurrence of a new example.
+func (o *Lookahead) IncExample() {
+	if method, ok := o.method.(gd.ExampleScheduler); ok {
+		method.IncExample()
+	}
+}
+
+// IncBatch beats the occurrence of a new batch.
+func (o *Lookahead) IncBatch() {
+	if method, ok := o.method.(gd.BatchScheduler); ok {
+		method.IncBatch()
+	}
+}
+
+// IncEpoch beats the occurrence of a new epoch.
+func (o *Lookahead) IncEpoch() {
+	if method, ok := o.method.(gd.EpochScheduler); ok {
+		method.IncEpoch()
+	}
+}
+
+// stepKey is the name of the step in the state of the method.
+const stepKey = "LookaheadStep"
+
+// MethodState returns the state of the wrapped method, if any, with the step of Lookahead.
+func (o *Lookahead) MethodState() map[string]mat.Float {
+	state := make(map[string]mat.Float)
+	if method, ok := o.method.(gd.StatefulMethod); ok {
+		for name, value := range method.MethodState() {
+			state[name] = value
+		}
+	}
+	state[stepKey] = mat.Float(o.Step)
+	return state
+}
+
+// SetMethodState restores a state returned by MethodState.
+func (o *Lookahead) SetMethodState(state map[string]mat.Float) {
+	methodState := make(map[string]mat.Float, len(state))
+	for name, value := range state {
+		if name == stepKey {
+			o.Step = int(value)
+			continue
+		}
+		methodState[name] = value
+	}
+	if method, ok := o.method.(gd.StatefulMethod); ok {
+		method.SetMethodState(methodState)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lookahead
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testModel struct {
+	nn.BaseModel
+	W nn.Param `spago:"type:weights"`
+}
+
+func TestLookahead_Delta(t *testing.T) {
+	m := &testModel{W: nn.NewParam(mat.NewVecDense([]mat.Float{1.0, 2.0}))}
+	method := New(sgd.New(sgd.NewConfig(0.1, 0.0, false)), NewConfig(2, 0.5))
+	optimizer := gd.NewOptimizer(method, nn.NewDefaultParamsIterator(m))
+	assert.Equal(t, gd.SGD, method.Label())
+
+	expected := [][]mat.Float{
+		{0.9, 1.9}, // fast
+		{0.9, 1.9}, // slow: 1 + 0.5 * (0.8 - 1)
+		{0.8, 1.8}, // fast
+		{0.8, 1.8}, // slow: 0.9 + 0.5 * (0.7 - 0.9)
+		{0.7, 1.7}, // fast
+	}
+	for step, values := range expected {
+		m.W.PropagateGrad(mat.NewVecDense([]mat.Float{1.0, 1.0}))
+		optimizer.Optimize()
+		assert.InDeltaSlice(t, values, m.W.Value().Data(), 1.0e-06, step)
+	}
+	assert.Equal(t, 5, method.Step)
+	assert.InDeltaSlice(t, []mat.Float{0.8, 1.8}, m.W.Payload().Data[1].Data(), 1.0e-06)
+}
+
+func TestLookahead_SynchronizeWithoutGrad(t *testing.T) {
+	m := &struct {
+		nn.BaseModel
+		W nn.Param `spago:"type:weights"`
+		V nn.Param `spago:"type:weights"`
+	}{
+		W: nn.NewParam(mat.NewVecDense([]mat.Float{1.0})),
+		V: nn.NewParam(mat.NewVecDense([]mat.Float{1.0})),
+	}
+	method := New(sgd.New(sgd.NewConfig(0.1, 0.0, false)), NewConfig(2, 0.5))
+	optimizer := gd.NewOptimizer(method, nn.NewDefaultParamsIterator(m))
+
+	m.W.PropagateGrad(mat.NewVecDense([]mat.Float{1.0}))
+	m.V.PropagateGrad(mat.NewVecDense([]mat.Float{1.0}))
+	optimizer.Optimize()
+	assert.InDeltaSlice(t, []mat.Float{0.9}, m.V.Value().Data(), 1.0e-06)
+
+	// V has no gradients in the synchronization step, but it is synchronized anyway
+	m.W.PropagateGrad(mat.NewVecDense([]mat.Float{1.0}))
+	optimizer.Optimize()
+	assert.InDeltaSlice(t, []mat.Float{0.9}, m.W.Value().Data(), 1.0e-06)  // slow: 1 + 0.5 * (0.8 - 1)
+	assert.InDeltaSlice(t, []mat.Float{0.95}, m.V.Value().Data(), 1.0e-06) // slow: 1 + 0.5 * (0.9 - 1)
+}
+
+func TestLookahead_State(t *testing.T) {
+	newOptimizer := func(m *testModel) (*gd.GradientDescent, *Lookahead) {
+		method := New(adam.New(adam.NewDefaultConfig()), NewDefaultConfig())
+		return gd.NewOptimizer(method, nn.NewDefaultParamsIterator(m)), method
+	}
+	m := &testModel{W: nn.NewParam(mat.NewVecDense([]mat.Float{1.0, 2.0}))}
+	optimizer, method := newOptimizer(m)
+	for step := 0; step < 3; step++ {
+		optimizer.IncExample()
+		m.W.PropagateGrad(mat.NewVecDense([]mat.Float{0.5, -0.5}))
+		optimizer.Optimize()
+	}
+	state := optimizer.GetState(m)
+	assert.Equal(t, gd.Adam, state.Label)
+	assert.Equal(t, map[string]mat.Float{"TimeStep": 4, "LookaheadStep": 3}, state.Method)
+
+	other := &testModel{W: nn.NewParam(m.W.Value().Clone())}
+	otherOptimizer, otherMethod := newOptimizer(other)
+	assert.NoError(t, otherOptimizer.LoadState(other, state))
+	assert.Equal(t, 3, otherMethod.Step)
+	assert.Equal(t, 4, otherMethod.Method().(*adam.Adam).TimeStep)
+
+	// the two optimizations go on the same way, synchronizing at the fifth step
+	for step := 3; step < 6; step++ {
+		for _, o := range []*gd.GradientDescent{optimizer, otherOptimizer} {
+			o.IncExample()
+		}
+		m.W.PropagateGrad(mat.NewVecDense([]mat.Float{0.5, -0.5}))
+		other.W.PropagateGrad(mat.NewVecDense([]mat.Float{0.5, -0.5}))
+		optimizer.Optimize()
+		otherOptimizer.Optimize()
+		assert.Equal(t, m.W.Value().Data(), other.W.Value().Data(), step)
+	}
+	assert.Equal(t, 6, method.Step)
+}
+
+func TestLookahead_WrappedPayload(t *testing.T) {
+	// a support structure created by the wrapped method alone gets the slow weights
+	m := &testModel{W: nn.NewParam(mat.NewVecDense([]mat.Float{1.0, 2.0}))}
+	inner := sgd.New(sgd.NewConfig(0.1, 0.0, false))
+	gd.GetOrSetPayload(m.W, inner)
+	method := New(inner, NewConfig(1, 0.5))
+	m.W.PropagateGrad(mat.NewVecDense([]mat.Float{1.0, 1.0}))
+	gd.NewOptimizer(method, nn.NewDefaultParamsIterator(m)).Optimize()
+	assert.InDeltaSlice(t, []mat.Float{0.95, 1.95}, m.W.Value().Data(), 1.0e-06)
+	assert.Len(t, m.W.Payload().Data, 2)
+}
+
+func TestNewConfig(t *testing.T) {
+	assert.Panics(t, func() { NewConfig(0, 0.5) })
+	assert.Panics(t, func() { NewConfig(5, 0.0) })
+	assert.Panics(t, func() { NewConfig(5, 1.5) })
+	assert.Equal(t, NewDefaultConfig(), NewConfig(5, 0.5))
+}
@@ -49,6 +49,7 @@ func NewDefaultConfig() Config {
 }
 
 var _ gd.Method = &RAdam{}
+var _ gd.StatefulMethod = &RAdam{}
 
 // RAdam implements the RAdam gradient descent optimization method.
 type RAdam struct {
@@ -99,6 +100,16 @@ func (o *RAdam) IncBatch() {
 	o.TimeStep++
 }
 
+// MethodState returns the time step of the optimizer.
+func (o *RAdam) MethodState() map[string]mat.Float {
+	return map[string]mat.Float{"TimeStep": mat.Float(o.TimeStep)}
+}
+
+// SetMethodState restores the time step of the optimizer.
+func (o *RAdam) SetMethodState(state map[string]mat.Float) {
+	o.TimeStep = int(state["TimeStep"])
+}
+
 // Delta returns the difference between the current params and where the method wants it to be.
 func (o *RAdam) Delta(param nn.Param) mat.Matrix {
 	return o.calcDelta(param.Grad(), gd.GetOrSetPayload(param, o).Data)
@@ -0,0 +1,107 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sam implements the Sharpness-Aware Minimization (Foret et al., 2020,
+// https://arxiv.org/abs/2010.01412) around a gradient descent optimizer.
+//
+// SAM looks for params whose whole neighbourhood has a low loss, and it requires two forward and
+// backward steps per update: the first one computes the gradients at the current params, which
+// are then moved to the point of the highest loss of their neighbourhood (Perturb); the second one
+// computes the gradients at that point, which the wrapped optimizer applies to the original
+// params (Optimize):
+//
+//	g.Backward(loss(model))   // first forward and backward steps
+//	s.Perturb()
+//	g.Clear()
+//	g.Backward(loss(model))   // second forward and backward steps
+//	s.Optimize()
+package sam
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+)
+
+// SAM wraps a GradientDescent optimizer with the Sharpness-Aware Minimization.
+type SAM struct {
+	optimizer *gd.GradientDescent
+	// Rho is the radius of the neighbourhood of the params.
+	Rho mat.Float
+	// perturbations are the params moved by Perturb, with their original values.
+	perturbations []perturbation
+}
+
+type perturbation struct {
+	param    nn.Param
+	original mat.Matrix
+}
+
+// New returns a new SAM around the optimizer. The radius rho is usually 0.05.
+func New(optimizer *gd.GradientDescent, rho mat.Float) *SAM {
+	if rho <= 0 {
+		panic("sam: `rho` must be greater than zero")
+	}
+	return &SAM{
+		optimizer: optimizer,
+		Rho:       rho,
+	}
+}
+
+// Optimizer returns the wrapped optimizer.
+func (s *SAM) Optimizer() *gd.GradientDescent {
+	return s.optimizer
+}
+
+// Perturb moves the params with gradients by Rho along the direction of the gradients, which
+// approximates the point of the highest loss in their neighbourhood, and then it zeroes the
+// gradients, so that they can be computed again at the perturbed params.
+func (s *SAM) Perturb() {
+	if s.perturbations != nil {
+		panic("sam: the params are already perturbed")
+	}
+	params := paramsWithGrad(s.optimizer.Params())
+	var sum mat.Float
+	for _, param := range params {
+		for _, g := range param.Grad().Data() {
+			sum += g * g
+		}
+	}
+	scale := s.Rho / (mat.Sqrt(sum) + 1.0e-12)
+	s.perturbations = make([]perturbation, len(params))
+	for i, param := range params {
+		s.perturbations[i] = perturbation{param: param, original: param.Value().Clone()}
+		delta := param.Grad().ProdScalar(-scale)
+		param.ApplyDelta(delta)
+		mat.ReleaseMatrix(delta)
+		param.ZeroGrad()
+	}
+}
+
+// Optimize restores the original values of the params moved by Perturb, and then optimizes them
+// with the wrapped optimizer, using the gradients computed at the perturbed params.
+// Without a previous Perturb, it is the same as the Optimize of the wrapped optimizer.
+func (s *SAM) Optimize() {
+	for _, p := range s.perturbations {
+		payload := p.param.Payload() // ReplaceValue clears the support structure of the optimizer
+		p.param.ReplaceValue(p.original)
+		p.param.SetPayload(payload)
+	}
+	s.perturbations = nil
+	s.optimizer.Optimize()
+}
+
+// paramsWithGrad returns the distinct params with gradients, in order.
+func paramsWithGrad(params []nn.Param) []nn.Param {
+	result := make([]nn.Param, 0, len(params))
+	seen := make(map[nn.Param]struct{}, len(params))
+	for _, param := range params {
+		if _, ok := seen[param]; ok || !param.HasGrad() {
+			continue
+		}
+		seen[param] = struct{}{}
+		result = append(result, param)
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testModel struct {
+	nn.BaseModel
+	W nn.Param `spago:"type:weights"`
+	B nn.Param `spago:"type:biases"`
+	// Tied is the same param of W.
+	Tied nn.Param `spago:"type:weights"`
+}
+
+// backward propagates the gradients of the loss (|W|^2 + |B|^2) / 2, which are the values of the params.
+func backward(m *testModel) {
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*testModel)
+	loss := g.Add(g.ReduceSum(g.Square(proc.W)), g.ReduceSum(g.Square(proc.B)))
+	g.Backward(g.ProdScalar(loss, g.Constant(0.5)))
+}
+
+func TestSAM(t *testing.T) {
+	m := &testModel{
+		W: nn.NewParam(mat.NewVecDense([]mat.Float{3.0, 0.0})),
+		B: nn.NewParam(mat.NewVecDense([]mat.Float{4.0})),
+	}
+	m.Tied = m.W
+	s := New(gd.NewOptimizer(sgd.New(sgd.NewConfig(0.1, 0.0, false)), nn.NewDefaultParamsIterator(m)), 1.0)
+
+	backward(m)
+	s.Perturb()
+	// the params move by rho along the gradients, whose norm is 5
+	assert.InDeltaSlice(t, []mat.Float{3.6, 0.0}, m.W.Value().Data(), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{4.8}, m.B.Value().Data(), 1.0e-06)
+	assert.False(t, m.W.HasGrad())
+	assert.Panics(t, func() { s.Perturb() })
+
+	backward(m)
+	s.Optimize()
+	// the original params are updated with the gradients at the perturbed params
+	assert.InDeltaSlice(t, []mat.Float{2.64, 0.0}, m.W.Value().Data(), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{3.52}, m.B.Value().Data(), 1.0e-06)
+	assert.False(t, m.W.HasGrad())
+
+	// without Perturb, it is a plain optimization step
+	backward(m)
+	s.Optimize()
+	assert.InDeltaSlice(t, []mat.Float{2.376, 0.0}, m.W.Value().Data(), 1.0e-06)
+	assert.InDeltaSlice(t, []mat.Float{3.168}, m.B.Value().Data(), 1.0e-06)
+}
+
+func TestSAM_RestoresOriginalValues(t *testing.T) {
+	original := []mat.Float{3.0, 1.0e-7}
+	m := &testModel{
+		W: nn.NewParam(mat.NewVecDense(original)),
+		B: nn.NewParam(mat.NewVecDense([]mat.Float{4.0})),
+	}
+	m.Tied = m.W
+	s := New(gd.NewOptimizer(sgd.New(sgd.NewConfig(0.0, 0.0, false)), nn.NewDefaultParamsIterator(m)), 100.0)
+
+	backward(m)
+	s.Perturb()
+	backward(m)
+	s.Optimize()
+	// with a zero learning rate, the params are exactly the original ones
+	assert.Equal(t, original, m.W.Value().Data())
+	assert.NotNil(t, m.W.Payload())
+}
+
+func TestNew(t *testing.T) {
+	m := &testModel{W: nn.NewParam(mat.NewVecDense([]mat.Float{1.0}))}
+	optimizer := gd.NewOptimizer(sgd.New(sgd.NewConfig(0.1, 0.0, false)), nn.NewDefaultParamsIterator(m))
+	assert.Same(t, optimizer, New(optimizer, 0.05).Optimizer())
+	assert.Panics(t, func() { New(optimizer, 0.0) })
+}
@@ -21,3 +21,9 @@ type ExampleScheduler interface {
 	// IncExample beats the occurrence of a new example.
 	IncExample()
 }
+
+// StepScheduler is implemented by any value that has the IncStep method.
+type StepScheduler interface {
+	// IncStep beats the occurrence of a new optimization step, after the update of the params.
+	IncStep()
+}
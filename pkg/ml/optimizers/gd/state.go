@@ -0,0 +1,121 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gd
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"sort"
+)
+
+// StatefulMethod is implemented by the optimization methods whose state is not limited to the
+// support structures of the params, e.g. the time step of Adam.
+type StatefulMethod interface {
+	// MethodState returns the state of the method, by name.
+	MethodState() map[string]mat.Float
+	// SetMethodState restores a state returned by MethodState.
+	SetMethodState(state map[string]mat.Float)
+}
+
+// State is the state of a gradient descent optimization, which allows to pause and resume a
+// training, or to inspect the support structures of the optimization method (e.g. the moment
+// buffers of Adam).
+type State struct {
+	// Label identifies the optimization method (e.g. Adam).
+	Label int
+	// Method is the state of the method, if it is a StatefulMethod.
+	Method map[string]mat.Float
+	// Payloads maps the paths of the parameters of the model (see nn.ForEachParamWithPath) to
+	// their support structures.
+	Payloads map[string]*nn.Payload
+}
+
+// Paths returns the paths of the parameters with a support structure, in lexicographic order.
+func (s State) Paths() []string {
+	paths := make([]string, 0, len(s.Payloads))
+	for path := range s.Payloads {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// GetState returns a copy of the state of the optimizer, with the support structures of the
+// parameters of the model. The parameters without a support structure are omitted.
+func (o *GradientDescent) GetState(m nn.Model) State {
+	state := State{
+		Label:    o.method.Label(),
+		Payloads: make(map[string]*nn.Payload),
+	}
+	if method, ok := o.method.(StatefulMethod); ok {
+		state.Method = method.MethodState()
+	}
+	nn.ForEachParamWithPath(m, func(path string, param nn.Param) {
+		payload := param.Payload()
+		if payload == nil || payload.Label != state.Label {
+			return
+		}
+		state.Payloads[path] = clonePayload(payload)
+	})
+	return state
+}
+
+// LoadState restores a state returned by GetState, setting a copy of the support structures into
+// the parameters of the model with the same paths.
+// It returns an error, before modifying the optimizer or the model, if the state belongs to a
+// different optimization method, or a support structure does not match its parameter.
+func (o *GradientDescent) LoadState(m nn.Model, state State) error {
+	if state.Label != o.method.Label() {
+		return fmt.Errorf("gd: the state belongs to the optimization method %d, not %d", state.Label, o.method.Label())
+	}
+	params := nn.NamedParams(m)
+	for path, payload := range state.Payloads {
+		param, ok := params[path]
+		if !ok {
+			return fmt.Errorf("gd: parameter %#v not found", path)
+		}
+		for _, data := range payload.Data {
+			if data != nil && !mat.SameDims(param.Value(), data) {
+				rows, cols := param.Value().Dims()
+				return fmt.Errorf("gd: parameter %#v has dimensions %dx%d, the support structure has %dx%d",
+					path, rows, cols, data.Rows(), data.Columns())
+			}
+		}
+	}
+	if method, ok := o.method.(StatefulMethod); ok && state.Method != nil {
+		method.SetMethodState(state.Method)
+	}
+	for path, payload := range state.Payloads {
+		params[path].SetPayload(clonePayload(payload))
+	}
+	return nil
+}
+
+// SaveState writes the state of an optimizer to file.
+func SaveState(filename string, state State) error {
+	return utils.SerializeToFile(filename, state)
+}
+
+// LoadStateFromFile reads the state of an optimizer from file.
+func LoadStateFromFile(filename string) (State, error) {
+	var state State
+	err := utils.DeserializeFromFile(filename, &state)
+	return state, err
+}
+
+func clonePayload(payload *nn.Payload) *nn.Payload {
+	clone := &nn.Payload{
+		Label: payload.Label,
+		Data:  make([]mat.Matrix, len(payload.Data)),
+	}
+	for i, data := range payload.Data {
+		if data != nil {
+			clone.Data[i] = data.Clone()
+		}
+	}
+	return clone
+}
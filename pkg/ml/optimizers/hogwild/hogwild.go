@@ -0,0 +1,134 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hogwild implements the asynchronous stochastic gradient descent without locks, Hogwild!
+// (Niu et al., 2011, https://arxiv.org/abs/1106.5730).
+//
+// Several workers train the same model at the same time, each on its own examples: a worker
+// accumulates the gradients of its mini-batch in its own nn.GradientBuffer, and then updates the
+// params in place with atomic operations (see nn.ApplyDeltaAtomic), while the other workers go on
+// reading them. No locks are taken on the params, neither during the backward step nor during the
+// updates, so the training scales with the cores; since the updates of the sparse problems seldom
+// overlap, the convergence is the one of the serial SGD.
+package hogwild
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// LossFunc returns the loss of an example, computed by the model reified for the training.
+type LossFunc func(model nn.Model, example int) ag.Node
+
+// Config provides configuration settings for a Hogwild! Trainer.
+type Config struct {
+	// Workers is the number of concurrent workers; runtime.NumCPU() if 0.
+	Workers int
+	// BatchSize is the number of examples of each update of a worker; 1 if 0.
+	BatchSize    int
+	LearningRate mat.Float
+	Seed         uint64
+}
+
+// Trainer trains a model with Hogwild!.
+type Trainer struct {
+	Config
+	model   nn.Model
+	loss    LossFunc
+	randGen *rand.LockedRand
+	updates int64
+}
+
+// NewTrainer returns a new Hogwild! Trainer of the model.
+func NewTrainer(model nn.Model, loss LossFunc, config Config) *Trainer {
+	if config.Workers < 0 || config.BatchSize < 0 {
+		panic("hogwild: the number of workers and the batch size cannot be negative")
+	}
+	if config.LearningRate <= 0 {
+		panic(fmt.Sprintf("hogwild: invalid learning rate %v", config.LearningRate))
+	}
+	if config.Workers == 0 {
+		config.Workers = runtime.NumCPU()
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = 1
+	}
+	return &Trainer{
+		Config:  config,
+		model:   model,
+		loss:    loss,
+		randGen: rand.NewLockedRand(config.Seed),
+	}
+}
+
+// Epoch trains the model on the examples from 0 to n-1, in random order, and returns their average
+// loss. The examples are distributed to the workers, which update the model concurrently.
+func (t *Trainer) Epoch(n int) mat.Float {
+	indices := rand.ShuffleInPlace(utils.MakeIndices(n), t.randGen)
+	examples := make(chan []int, t.Workers)
+	go func() {
+		defer close(examples)
+		for start := 0; start < n; start += t.BatchSize {
+			examples <- indices[start:utils.MinInt(start+t.BatchSize, n)]
+		}
+	}()
+
+	losses := make([]mat.Float, t.Workers)
+	var wg sync.WaitGroup
+	for i := 0; i < t.Workers; i++ {
+		wg.Add(1)
+		go func(i int, randGen *rand.LockedRand) {
+			defer wg.Done()
+			buf := nn.NewGradientBuffer()
+			for batch := range examples {
+				losses[i] += t.update(batch, buf, randGen)
+			}
+		}(i, t.randGen.Spawn())
+	}
+	wg.Wait()
+
+	if n == 0 {
+		return 0
+	}
+	var sum mat.Float
+	for _, loss := range losses {
+		sum += loss
+	}
+	return sum / mat.Float(n)
+}
+
+// update performs the forward and the backward steps of a mini-batch, and applies the gradients
+// to the params of the model. It returns the sum of the losses of the examples.
+func (t *Trainer) update(batch []int, buf *nn.GradientBuffer, randGen *rand.LockedRand) mat.Float {
+	// the concurrency comes from the workers, so the graph of each one is serial
+	g := ag.NewGraph(ag.Rand(randGen), ag.ConcurrentComputations(1))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training, Gradients: buf}, t.model)
+	losses := make([]ag.Node, len(batch))
+	for i, example := range batch {
+		losses[i] = t.loss(proc, example)
+	}
+	loss := g.Sum(losses...)
+	g.Backward(g.DivScalar(loss, g.Constant(mat.Float(len(batch)))))
+	buf.ForEach(func(param nn.Param, grad mat.Matrix) {
+		delta := grad.ProdScalar(t.LearningRate)
+		nn.ApplyDeltaAtomic(param, delta)
+		mat.ReleaseMatrix(delta)
+	})
+	buf.ZeroGrad()
+	atomic.AddInt64(&t.updates, 1)
+	return loss.ScalarValue()
+}
+
+// Updates returns the number of updates applied to the model so far.
+func (t *Trainer) Updates() int {
+	return int(atomic.LoadInt64(&t.updates))
+}
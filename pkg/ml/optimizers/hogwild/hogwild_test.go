@@ -0,0 +1,84 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hogwild
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/embedding"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// newTestRegression returns a linear model and the loss of the examples of y = 2*x1 - 3*x2 + 1.
+func newTestRegression(n int) (*linear.Model, LossFunc) {
+	randGen := rand.NewLockedRand(1)
+	inputs := make([][]mat.Float, n)
+	for i := range inputs {
+		inputs[i] = []mat.Float{randGen.Float()*2 - 1, randGen.Float()*2 - 1}
+	}
+	loss := func(model nn.Model, i int) ag.Node {
+		m := model.(*linear.Model)
+		g := m.Graph()
+		x := g.NewVariable(mat.NewVecDense(inputs[i]), false)
+		target := 2*inputs[i][0] - 3*inputs[i][1] + 1
+		return g.Square(g.SubScalar(nn.ToNode(m.Forward(x)), g.Constant(target)))
+	}
+	return linear.New(2, 1), loss
+}
+
+func TestTrainer_Epoch(t *testing.T) {
+	model, loss := newTestRegression(200)
+	trainer := NewTrainer(model, loss, Config{Workers: 4, BatchSize: 3, LearningRate: 0.05, Seed: 42})
+	first := trainer.Epoch(200)
+	var last mat.Float
+	for i := 0; i < 20; i++ {
+		last = trainer.Epoch(200)
+	}
+	assert.Less(t, float64(last), float64(first)/100)
+	assert.InDeltaSlice(t, []mat.Float{2, -3}, model.W.Value().Data(), 0.01)
+	assert.InDeltaSlice(t, []mat.Float{1}, model.B.Value().Data(), 0.01)
+	// the gradients are never accumulated into the params
+	assert.False(t, model.W.HasGrad())
+	assert.Equal(t, 21*67, trainer.Updates())
+
+	assert.Equal(t, mat.Float(0), trainer.Epoch(0))
+}
+
+// TestTrainer_Embedding trains a lookup table, whose vectors are not reified with the model
+// (scope "model"), to move each vector i to (i, -i).
+func TestTrainer_Embedding(t *testing.T) {
+	model := embedding.New(embedding.Config{Size: 2, NumOfEmbeddings: 4, Trainable: true})
+	for _, v := range model.Vectors {
+		v.Value().SetData([]mat.Float{1, 1})
+	}
+	loss := func(model nn.Model, i int) ag.Node {
+		m := model.(*embedding.Model)
+		g := m.Graph()
+		target := g.NewVariable(mat.NewVecDense([]mat.Float{mat.Float(i), -mat.Float(i)}), false)
+		return g.ReduceSum(g.Square(g.Sub(m.Encode([]int{i})[0], target)))
+	}
+	trainer := NewTrainer(model, loss, Config{Workers: 2, LearningRate: 0.1, Seed: 42})
+	for i := 0; i < 30; i++ {
+		trainer.Epoch(4)
+	}
+	for i, v := range model.Vectors {
+		assert.InDeltaSlice(t, []mat.Float{mat.Float(i), -mat.Float(i)}, v.Value().Data(), 0.01, i)
+		// the gradients are accumulated in the buffers of the workers, not in the shared params
+		assert.False(t, v.HasGrad(), i)
+	}
+}
+
+func TestNewTrainer(t *testing.T) {
+	model, loss := newTestRegression(1)
+	trainer := NewTrainer(model, loss, Config{LearningRate: 0.1})
+	assert.True(t, trainer.Workers > 0)
+	assert.Equal(t, 1, trainer.BatchSize)
+	assert.Panics(t, func() { NewTrainer(model, loss, Config{}) })
+	assert.Panics(t, func() { NewTrainer(model, loss, Config{Workers: -1, LearningRate: 0.1}) })
+}
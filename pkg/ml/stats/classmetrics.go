@@ -6,6 +6,7 @@ package stats
 
 import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"math"
 )
 
 // ClassMetrics provides methods to calculate Precision, Recall, F1Score, Accuracy
@@ -86,9 +87,17 @@ func (c *ClassMetrics) Accuracy() mat.Float {
 	return zeroIfNaN(numerator / (numerator + mat.Float(c.FalseNeg+c.FalsePos)))
 }
 
+// MatthewsCorrelation returns the Matthews correlation coefficient (MCC) in [-1, 1], calculated as
+// (TP * TN - FP * FN) / sqrt((TP + FP) * (TP + FN) * (TN + FP) * (TN + FN)).
+// It is a balanced measure, which can be used even if the classes are of very different sizes.
+func (c *ClassMetrics) MatthewsCorrelation() mat.Float {
+	tp, tn, fp, fn := mat.Float(c.TruePos), mat.Float(c.TrueNeg), mat.Float(c.FalsePos), mat.Float(c.FalseNeg)
+	return zeroIfNaN((tp*tn - fp*fn) / mat.Sqrt((tp+fp)*(tp+fn)*(tn+fp)*(tn+fn)))
+}
+
 // zeroIfNaN returns zero if the value is NaN otherwise the value.
 func zeroIfNaN(value mat.Float) mat.Float {
-	if value == mat.NaN() {
+	if math.IsNaN(float64(value)) {
 		return 0.0
 	}
 	return value
@@ -0,0 +1,67 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"sort"
+)
+
+// Pearson returns the Pearson correlation coefficient in [-1, 1] of the values x and y,
+// i.e. the measure of their linear correlation. It returns 0 if either is constant.
+func Pearson(x, y []mat.Float) mat.Float {
+	if len(x) != len(y) {
+		panic("stats: the values must have the same length")
+	}
+	if len(x) == 0 {
+		return 0
+	}
+	var meanX, meanY mat.Float
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= mat.Float(len(x))
+	meanY /= mat.Float(len(y))
+	var cov, varX, varY mat.Float
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	return zeroIfNaN(cov / mat.Sqrt(varX*varY))
+}
+
+// Spearman returns the Spearman rank correlation coefficient in [-1, 1] of the values x and y,
+// i.e. the Pearson correlation of their ranks, which measures how well their relationship can be
+// described by a monotonic function. The tied values get the average of their ranks.
+func Spearman(x, y []mat.Float) mat.Float {
+	return Pearson(ranks(x), ranks(y))
+}
+
+// ranks returns the (1-based) ranks of the values, averaging the ranks of the tied values.
+func ranks(values []mat.Float) []mat.Float {
+	indices := make([]int, len(values))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		return values[indices[a]] < values[indices[b]]
+	})
+	ranks := make([]mat.Float, len(values))
+	for start := 0; start < len(indices); {
+		end := start + 1
+		for end < len(indices) && values[indices[end]] == values[indices[start]] {
+			end++
+		}
+		rank := mat.Float(start+end+1) / 2 // the average of the ranks start+1 ... end
+		for _, i := range indices[start:end] {
+			ranks[i] = rank
+		}
+		start = end
+	}
+	return ranks
+}
@@ -0,0 +1,30 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPearson(t *testing.T) {
+	assert.InDelta(t, 1.0, Pearson([]mat.Float{1, 2, 3}, []mat.Float{2, 4, 6}), 1.0e-6)
+	assert.InDelta(t, -1.0, Pearson([]mat.Float{1, 2, 3}, []mat.Float{3, 2, 1}), 1.0e-6)
+	assert.InDelta(t, 0.866025, Pearson([]mat.Float{1, 2, 3}, []mat.Float{1, 3, 3}), 1.0e-6)
+	assert.Equal(t, mat.Float(0), Pearson([]mat.Float{1, 1, 1}, []mat.Float{1, 2, 3}))
+}
+
+func TestSpearman(t *testing.T) {
+	assert.InDelta(t, 1.0, Spearman([]mat.Float{1, 2, 3, 4}, []mat.Float{1, 10, 100, 1000}), 1.0e-6)
+	assert.Equal(t, []mat.Float{1, 2.5, 2.5, 4}, ranks([]mat.Float{0, 5, 5, 7}))
+}
+
+func TestMatthewsCorrelation(t *testing.T) {
+	c := &ClassMetrics{TruePos: 6, TrueNeg: 3, FalsePos: 1, FalseNeg: 2}
+	assert.InDelta(t, 0.478091, c.MatthewsCorrelation(), 1.0e-6)
+	assert.Equal(t, mat.Float(0), (&ClassMetrics{TruePos: 4}).MatthewsCorrelation())
+	assert.Equal(t, mat.Float(0), (&ClassMetrics{TrueNeg: 4}).F1Score())
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabular
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"hash/fnv"
+	"sort"
+)
+
+var (
+	_ Feature = &OneHot{}
+	_ Feature = &Hashed{}
+)
+
+// OneHot is a Feature converting a categorical column into a one-hot vector, with a value for
+// each known category. A missing value is all zeros.
+type OneHot struct {
+	Column string
+	// Categories are the known categories, in the order of the values. If empty before Fit, they
+	// are the categories of the training records, from the most frequent one.
+	Categories []string
+	// MinCount and MaxCategories, if not zero, limit the categories learned by Fit to the ones
+	// occurring at least MinCount times, and to the MaxCategories most frequent ones.
+	MinCount      int
+	MaxCategories int
+	// Other adds a last value for the unknown categories, which are all zeros otherwise.
+	Other bool
+	// Fixed reports whether the categories were given, rather than learned by Fit.
+	Fixed bool
+}
+
+// NewOneHot returns a new OneHot feature of the column. The categories are learned by Fit if
+// none is given.
+func NewOneHot(column string, categories ...string) *OneHot {
+	return &OneHot{
+		Column:     column,
+		Categories: categories,
+		Fixed:      len(categories) > 0,
+	}
+}
+
+// Fit learns the categories of the column, unless they were given.
+func (f *OneHot) Fit(records []Record) error {
+	if f.Fixed {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, record := range records {
+		if value, ok := lookup(record, f.Column); ok {
+			counts[value]++
+		}
+	}
+	categories := make([]string, 0, len(counts))
+	for category, count := range counts {
+		if count >= f.MinCount {
+			categories = append(categories, category)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if counts[categories[i]] != counts[categories[j]] {
+			return counts[categories[i]] > counts[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+	if f.MaxCategories > 0 && len(categories) > f.MaxCategories {
+		categories = categories[:f.MaxCategories]
+	}
+	f.Categories = categories
+	return nil
+}
+
+// Size returns the number of categories, plus one with Other.
+func (f *OneHot) Size() int {
+	if f.Other {
+		return len(f.Categories) + 1
+	}
+	return len(f.Categories)
+}
+
+// Names returns "<column>=<category>" for each category, followed by "<column>=<other>" with Other.
+func (f *OneHot) Names() []string {
+	names := make([]string, 0, f.Size())
+	for _, category := range f.Categories {
+		names = append(names, f.Column+"="+category)
+	}
+	if f.Other {
+		names = append(names, f.Column+"=<other>")
+	}
+	return names
+}
+
+// Transform writes the one-hot vector of the category of the column.
+func (f *OneHot) Transform(record Record, dst []mat.Float) error {
+	for i := range dst {
+		dst[i] = 0
+	}
+	value, ok := lookup(record, f.Column)
+	if !ok {
+		return nil
+	}
+	for i, category := range f.Categories {
+		if category == value {
+			dst[i] = 1
+			return nil
+		}
+	}
+	if f.Other {
+		dst[len(f.Categories)] = 1
+	}
+	return nil
+}
+
+// Hashed is a Feature converting a categorical column into a fixed number of buckets by the
+// hashing trick, for the columns with too many or unknown categories to be one-hot encoded.
+// A missing value is all zeros.
+//
+// Reference: "Feature Hashing for Large Scale Multitask Learning" by Weinberger et al., 2009
+// (https://arxiv.org/abs/0902.2206).
+type Hashed struct {
+	Column  string
+	Buckets int
+	// Signed sets the value of the bucket to -1 or 1 by the highest bit of the hash, so that the
+	// collisions cancel out on average rather than add up.
+	Signed bool
+}
+
+// NewHashed returns a new Hashed feature of the column, with the given number of buckets.
+func NewHashed(column string, buckets int) *Hashed {
+	if buckets < 1 {
+		panic("tabular: the number of buckets must be positive")
+	}
+	return &Hashed{
+		Column:  column,
+		Buckets: buckets,
+	}
+}
+
+// Fit does nothing, since the buckets don't depend on the training records.
+func (f *Hashed) Fit([]Record) error {
+	return nil
+}
+
+// Size returns the number of buckets.
+func (f *Hashed) Size() int {
+	return f.Buckets
+}
+
+// Names returns "<column>#<bucket>" for each bucket.
+func (f *Hashed) Names() []string {
+	names := make([]string, f.Buckets)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s#%d", f.Column, i)
+	}
+	return names
+}
+
+// Transform writes the buckets of the category of the column, which are zeros except the one of
+// the hash of the category.
+func (f *Hashed) Transform(record Record, dst []mat.Float) error {
+	for i := range dst {
+		dst[i] = 0
+	}
+	value, ok := lookup(record, f.Column)
+	if !ok {
+		return nil
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(f.Column + "=" + value))
+	sum := h.Sum64()
+	sign := mat.Float(1)
+	if f.Signed && sum>>63 == 1 {
+		sign = -1
+	}
+	dst[int(sum%uint64(f.Buckets))] = sign
+	return nil
+}
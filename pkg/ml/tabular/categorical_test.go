@@ -0,0 +1,93 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabular
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testColors = []Record{{"c": "red"}, {"c": "blue"}, {"c": "red"}, {"c": "green"}, {"c": "red"}, {"c": "blue"}, {}}
+
+func TestOneHot_Fit(t *testing.T) {
+	f := NewOneHot("c")
+	assert.NoError(t, f.Fit(testColors))
+	assert.Equal(t, []string{"red", "blue", "green"}, f.Categories)
+
+	f.MinCount = 2
+	assert.NoError(t, f.Fit(testColors))
+	assert.Equal(t, []string{"red", "blue"}, f.Categories)
+
+	f.MinCount, f.MaxCategories = 0, 1
+	assert.NoError(t, f.Fit(testColors))
+	assert.Equal(t, []string{"red"}, f.Categories)
+
+	fixed := NewOneHot("c", "green", "red")
+	assert.NoError(t, fixed.Fit(testColors))
+	assert.Equal(t, []string{"green", "red"}, fixed.Categories)
+}
+
+func TestOneHot_Transform(t *testing.T) {
+	f := NewOneHot("c", "red", "blue")
+	f.Other = true
+	assert.Equal(t, []string{"c=red", "c=blue", "c=<other>"}, f.Names())
+	tests := map[string][]mat.Float{
+		"blue":   {0, 1, 0},
+		" red ":  {1, 0, 0},
+		"yellow": {0, 0, 1},
+		"":       {0, 0, 0},
+	}
+	for value, expected := range tests {
+		dst := []mat.Float{9, 9, 9}
+		assert.NoError(t, f.Transform(Record{"c": value}, dst))
+		assert.Equal(t, expected, dst, value)
+	}
+
+	f.Other = false
+	dst := make([]mat.Float, f.Size())
+	assert.NoError(t, f.Transform(Record{"c": "yellow"}, dst))
+	assert.Equal(t, []mat.Float{0, 0}, dst)
+}
+
+func TestHashed(t *testing.T) {
+	f := NewHashed("c", 8)
+	assert.NoError(t, f.Fit(testColors))
+	assert.Equal(t, 8, f.Size())
+	assert.Equal(t, "c#7", f.Names()[7])
+
+	dst := make([]mat.Float, f.Size())
+	assert.NoError(t, f.Transform(Record{"c": "red"}, dst))
+	assert.Equal(t, mat.Float(1), sum(dst))
+	again := make([]mat.Float, f.Size())
+	assert.NoError(t, f.Transform(Record{"c": " red"}, again))
+	assert.Equal(t, dst, again)
+
+	assert.NoError(t, f.Transform(Record{}, dst))
+	assert.Equal(t, mat.Float(0), sum(dst))
+
+	f.Signed = true
+	for _, record := range testColors[:4] {
+		assert.NoError(t, f.Transform(record, dst))
+		nonZero := 0
+		for _, v := range dst {
+			if v != 0 {
+				assert.Equal(t, mat.Float(1), mat.Abs(v))
+				nonZero++
+			}
+		}
+		assert.Equal(t, 1, nonZero)
+	}
+
+	assert.Panics(t, func() { NewHashed("c", 0) })
+}
+
+func sum(xs []mat.Float) mat.Float {
+	var s mat.Float
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
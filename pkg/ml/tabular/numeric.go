@@ -0,0 +1,182 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabular
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"sort"
+	"strconv"
+)
+
+// Scaling is the transformation of the numeric values.
+type Scaling int
+
+const (
+	// NoScaling leaves the values unchanged.
+	NoScaling Scaling = iota
+	// Standardize subtracts the mean and divides by the standard deviation.
+	Standardize
+	// MinMax maps the range of the training values to [0, 1].
+	MinMax
+)
+
+// Imputation is the replacement of the missing numeric values.
+type Imputation int
+
+const (
+	// ImputeMean replaces the missing values with the mean of the training values.
+	ImputeMean Imputation = iota
+	// ImputeMedian replaces the missing values with the median of the training values.
+	ImputeMedian
+	// ImputeConstant replaces the missing values with Numeric.FillValue.
+	ImputeConstant
+)
+
+var _ Feature = &Numeric{}
+
+// Numeric is a Feature converting a numeric column into a scaled value, optionally followed by
+// an indicator of the missing values.
+type Numeric struct {
+	Column     string
+	Scaling    Scaling
+	Imputation Imputation
+	// FillValue replaces the missing values with the ImputeConstant imputation, before the scaling.
+	FillValue mat.Float
+	// MissingIndicator adds a value which is 1 if the value is missing and 0 otherwise, so that the
+	// model can tell the imputed values apart.
+	MissingIndicator bool
+	// Mean, Std, Min, Max and Median are the statistics of the training values, set by Fit.
+	Mean   mat.Float
+	Std    mat.Float
+	Min    mat.Float
+	Max    mat.Float
+	Median mat.Float
+}
+
+// NewNumeric returns a new Numeric feature of the column.
+func NewNumeric(column string, scaling Scaling, imputation Imputation) *Numeric {
+	return &Numeric{
+		Column:     column,
+		Scaling:    scaling,
+		Imputation: imputation,
+	}
+}
+
+// Fit computes the statistics of the non-missing values of the column. It returns an error if a
+// value is not a number.
+func (f *Numeric) Fit(records []Record) error {
+	var values []mat.Float
+	for i, record := range records {
+		v, ok, err := f.parse(record)
+		if err != nil {
+			return fmt.Errorf("%w (record %d)", err, i)
+		}
+		if ok {
+			values = append(values, v)
+		}
+	}
+	f.Mean, f.Std, f.Min, f.Max, f.Median = 0, 0, 0, 0, 0
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	f.Min, f.Max = values[0], values[len(values)-1]
+	if n := len(values); n%2 == 1 {
+		f.Median = values[n/2]
+	} else {
+		f.Median = (values[n/2-1] + values[n/2]) / 2
+	}
+	for _, v := range values {
+		f.Mean += v
+	}
+	f.Mean /= mat.Float(len(values))
+	for _, v := range values {
+		f.Std += (v - f.Mean) * (v - f.Mean)
+	}
+	f.Std = mat.Sqrt(f.Std / mat.Float(len(values)))
+	return nil
+}
+
+// Size returns 1, or 2 with the MissingIndicator.
+func (f *Numeric) Size() int {
+	if f.MissingIndicator {
+		return 2
+	}
+	return 1
+}
+
+// Names returns the name of the column, followed by "<column>_missing" with the MissingIndicator.
+func (f *Numeric) Names() []string {
+	if f.MissingIndicator {
+		return []string{f.Column, f.Column + "_missing"}
+	}
+	return []string{f.Column}
+}
+
+// Transform writes the scaled value of the column, imputed if it is missing.
+func (f *Numeric) Transform(record Record, dst []mat.Float) error {
+	v, ok, err := f.parse(record)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		v = f.impute()
+	}
+	dst[0] = f.scale(v)
+	if f.MissingIndicator {
+		dst[1] = 0
+		if !ok {
+			dst[1] = 1
+		}
+	}
+	return nil
+}
+
+// parse returns the value of the column, and false if it is missing.
+func (f *Numeric) parse(record Record) (mat.Float, bool, error) {
+	s, ok := lookup(record, f.Column)
+	if !ok {
+		return 0, false, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("tabular: column %q: invalid number %q", f.Column, s)
+	}
+	return mat.Float(v), true, nil
+}
+
+func (f *Numeric) impute() mat.Float {
+	switch f.Imputation {
+	case ImputeMean:
+		return f.Mean
+	case ImputeMedian:
+		return f.Median
+	case ImputeConstant:
+		return f.FillValue
+	default:
+		panic("tabular: invalid imputation")
+	}
+}
+
+// scale applies the Scaling; a constant column is only shifted.
+func (f *Numeric) scale(v mat.Float) mat.Float {
+	switch f.Scaling {
+	case NoScaling:
+		return v
+	case Standardize:
+		if f.Std == 0 {
+			return v - f.Mean
+		}
+		return (v - f.Mean) / f.Std
+	case MinMax:
+		if f.Max == f.Min {
+			return v - f.Min
+		}
+		return (v - f.Min) / (f.Max - f.Min)
+	default:
+		panic("tabular: invalid scaling")
+	}
+}
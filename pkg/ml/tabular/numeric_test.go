@@ -0,0 +1,75 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabular
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testNumbers = []Record{{"x": "1"}, {"x": "2"}, {"x": "6"}, {"x": ""}, {}}
+
+func TestNumeric_Fit(t *testing.T) {
+	f := NewNumeric("x", NoScaling, ImputeMean)
+	assert.NoError(t, f.Fit(testNumbers))
+	assert.InDelta(t, 3, f.Mean, 1.0e-6)
+	assert.InDelta(t, mat.Sqrt(14.0/3), f.Std, 1.0e-6)
+	assert.Equal(t, mat.Float(1), f.Min)
+	assert.Equal(t, mat.Float(6), f.Max)
+	assert.Equal(t, mat.Float(2), f.Median)
+
+	assert.NoError(t, f.Fit(append(testNumbers, Record{"x": "7"})))
+	assert.Equal(t, mat.Float(4), f.Median)
+
+	assert.Error(t, f.Fit([]Record{{"x": "one"}}))
+}
+
+func TestNumeric_Transform(t *testing.T) {
+	tests := []struct {
+		scaling    Scaling
+		imputation Imputation
+		value      string
+		expected   mat.Float
+	}{
+		{NoScaling, ImputeMean, "4", 4},
+		{NoScaling, ImputeMean, "", 3},
+		{NoScaling, ImputeMedian, "NA", 2},
+		{NoScaling, ImputeConstant, "", -1},
+		{Standardize, ImputeMean, "", 0},
+		{Standardize, ImputeMean, "5", 2 / mat.Sqrt(14.0/3)},
+		{MinMax, ImputeMean, "6", 1},
+		{MinMax, ImputeMedian, "", 0.2},
+	}
+	for _, test := range tests {
+		f := NewNumeric("x", test.scaling, test.imputation)
+		f.FillValue = -1
+		assert.NoError(t, f.Fit(testNumbers))
+		dst := make([]mat.Float, f.Size())
+		assert.NoError(t, f.Transform(Record{"x": test.value}, dst))
+		assert.InDelta(t, test.expected, dst[0], 1.0e-6)
+	}
+}
+
+func TestNumeric_ConstantColumn(t *testing.T) {
+	for _, scaling := range []Scaling{Standardize, MinMax} {
+		f := NewNumeric("x", scaling, ImputeMean)
+		assert.NoError(t, f.Fit([]Record{{"x": "3"}, {"x": "3"}}))
+		dst := make([]mat.Float, 1)
+		assert.NoError(t, f.Transform(Record{"x": "3"}, dst))
+		assert.Equal(t, mat.Float(0), dst[0])
+	}
+}
+
+func TestNumeric_MissingIndicator(t *testing.T) {
+	f := NewNumeric("x", NoScaling, ImputeConstant)
+	f.MissingIndicator = true
+	assert.Equal(t, []string{"x", "x_missing"}, f.Names())
+	dst := make([]mat.Float, f.Size())
+	assert.NoError(t, f.Transform(Record{}, dst))
+	assert.Equal(t, []mat.Float{0, 1}, dst)
+	assert.NoError(t, f.Transform(Record{"x": "2.5"}, dst))
+	assert.Equal(t, []mat.Float{2.5, 0}, dst)
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tabular converts tabular records, such as the rows of a CSV file or the fields of a
+// request, into the Dense vectors taken as input by the feed-forward layers (e.g. linear, bls
+// and stack), so that the tabular models can be trained and served inside Go services.
+//
+// Each column is converted by a Feature: Numeric for the numbers, which are scaled and whose
+// missing values are imputed, OneHot for the categories with a known and small vocabulary, and
+// Hashed for the categories with a large or open one. A Pipeline learns the statistics of its
+// features from the training records (Fit), and concatenates their values into a vector
+// (Transform); the fitted pipeline is serializable with gob (e.g. utils.SerializeToFile), so
+// that the same conversion is applied at inference time.
+package tabular
+
+import (
+	"encoding/csv"
+	"encoding/gob"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"io"
+	"strings"
+)
+
+// Record is a row of a table, mapping the name of each column to its value. A column that is not
+// in the record, or whose value is missing (see IsMissing), is handled as a missing value.
+type Record map[string]string
+
+// Feature converts a column of the records into one or more values of the input vectors.
+type Feature interface {
+	// Fit learns the statistics of the feature from the training records.
+	Fit(records []Record) error
+	// Size returns the number of values of the feature; it can depend on Fit.
+	Size() int
+	// Names returns a name for each value of the feature, to interpret the inputs.
+	Names() []string
+	// Transform writes the values of the feature for the record into dst, whose length is Size().
+	Transform(record Record, dst []mat.Float) error
+}
+
+// Pipeline converts the records into vectors, concatenating the values of its features.
+type Pipeline struct {
+	Features []Feature
+}
+
+func init() {
+	gob.Register(&Numeric{})
+	gob.Register(&OneHot{})
+	gob.Register(&Hashed{})
+}
+
+// NewPipeline returns a new Pipeline of the given features.
+func NewPipeline(features ...Feature) *Pipeline {
+	return &Pipeline{Features: features}
+}
+
+// Fit learns the statistics of all the features from the training records.
+func (p *Pipeline) Fit(records []Record) error {
+	for _, feature := range p.Features {
+		if err := feature.Fit(records); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Size returns the size of the vectors, that is the sum of the sizes of the features.
+func (p *Pipeline) Size() int {
+	size := 0
+	for _, feature := range p.Features {
+		size += feature.Size()
+	}
+	return size
+}
+
+// Names returns the names of the values of the vectors (see Feature.Names).
+func (p *Pipeline) Names() []string {
+	names := make([]string, 0, p.Size())
+	for _, feature := range p.Features {
+		names = append(names, feature.Names()...)
+	}
+	return names
+}
+
+// Transform returns the vector of the record.
+func (p *Pipeline) Transform(record Record) (*mat.Dense, error) {
+	data := make([]mat.Float, p.Size())
+	offset := 0
+	for _, feature := range p.Features {
+		size := feature.Size()
+		if err := feature.Transform(record, data[offset:offset+size]); err != nil {
+			return nil, err
+		}
+		offset += size
+	}
+	return mat.NewVecDense(data), nil
+}
+
+// TransformAll returns the vectors of the records.
+func (p *Pipeline) TransformAll(records []Record) ([]*mat.Dense, error) {
+	out := make([]*mat.Dense, len(records))
+	for i, record := range records {
+		x, err := p.Transform(record)
+		if err != nil {
+			return nil, fmt.Errorf("%w (record %d)", err, i)
+		}
+		out[i] = x
+	}
+	return out, nil
+}
+
+// missingValues are the values, compared case-insensitively, that denote a missing value.
+var missingValues = map[string]bool{"": true, "na": true, "n/a": true, "nan": true, "null": true, "none": true}
+
+// IsMissing reports whether the value denotes a missing value: the empty string, "NA", "N/A",
+// "NaN", "null" or "None", case-insensitively and regardless of the surrounding spaces.
+func IsMissing(value string) bool {
+	return missingValues[strings.ToLower(strings.TrimSpace(value))]
+}
+
+// lookup returns the value of the column of the record, without the surrounding spaces, and false
+// if it is missing.
+func lookup(record Record, column string) (string, bool) {
+	value, ok := record[column]
+	if !ok || IsMissing(value) {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
+
+// ReadCSV reads the records of a CSV stream, whose first row contains the names of the columns.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("tabular: reading the CSV header: %w", err)
+	}
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tabular: %w", err)
+		}
+		record := make(Record, len(header))
+		for i, column := range header {
+			record[column] = row[i]
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabular
+
+import (
+	"bytes"
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const testCSV = `age,city,income
+30,Rome,1000
+50,Milan,
+,Rome,3000
+40, Turin ,NA
+`
+
+func TestReadCSV(t *testing.T) {
+	records, err := ReadCSV(strings.NewReader(testCSV))
+	assert.NoError(t, err)
+	assert.Len(t, records, 4)
+	assert.Equal(t, Record{"age": "30", "city": "Rome", "income": "1000"}, records[0])
+
+	_, err = ReadCSV(strings.NewReader(""))
+	assert.Error(t, err)
+	_, err = ReadCSV(strings.NewReader("a,b\n1,2,3\n"))
+	assert.Error(t, err)
+}
+
+func TestIsMissing(t *testing.T) {
+	for _, value := range []string{"", " ", "NA", "n/a", "NaN", "null", "None"} {
+		assert.True(t, IsMissing(value), value)
+	}
+	for _, value := range []string{"0", "nope", "Rome"} {
+		assert.False(t, IsMissing(value), value)
+	}
+}
+
+func newTestPipeline() *Pipeline {
+	income := NewNumeric("income", Standardize, ImputeMedian)
+	income.MissingIndicator = true
+	return NewPipeline(
+		NewNumeric("age", MinMax, ImputeMean),
+		NewOneHot("city"),
+		income,
+	)
+}
+
+func TestPipeline(t *testing.T) {
+	records, err := ReadCSV(strings.NewReader(testCSV))
+	assert.NoError(t, err)
+	p := newTestPipeline()
+	assert.NoError(t, p.Fit(records))
+
+	assert.Equal(t, 6, p.Size())
+	assert.Equal(t, []string{"age", "city=Rome", "city=Milan", "city=Turin", "income", "income_missing"}, p.Names())
+
+	xs, err := p.TransformAll(records)
+	assert.NoError(t, err)
+	assert.Len(t, xs, 4)
+	assert.InDeltaSlice(t, []mat.Float{0, 1, 0, 0, -1, 0}, xs[0].Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{1, 0, 1, 0, 0, 1}, xs[1].Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.5, 1, 0, 0, 1, 0}, xs[2].Data(), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{0.5, 0, 0, 1, 0, 1}, xs[3].Data(), 1.0e-6)
+
+	_, err = p.TransformAll([]Record{{"age": "old"}})
+	assert.EqualError(t, err, `tabular: column "age": invalid number "old" (record 0)`)
+}
+
+func TestPipeline_Gob(t *testing.T) {
+	records, err := ReadCSV(strings.NewReader(testCSV))
+	assert.NoError(t, err)
+	p := newTestPipeline()
+	assert.NoError(t, p.Fit(records))
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(p))
+	var decoded Pipeline
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	for _, record := range records {
+		expected, err := p.Transform(record)
+		assert.NoError(t, err)
+		actual, err := decoded.Transform(record)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Data(), actual.Data())
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tuning
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/utils/data"
+)
+
+// CVResult is the result of a k-fold cross-validation.
+type CVResult struct {
+	// Scores are the scores of the folds.
+	Scores []mat.Float
+	Mean   mat.Float
+	// StdDev is the standard deviation of the scores.
+	StdDev mat.Float
+}
+
+// CrossValidate runs a k-fold cross-validation over a dataset of the given size, calling evaluate
+// to train a new model on the training set of each fold and score it on the validation set.
+// If class is not nil, the folds are stratified (see data.KFold).
+// It stops at the first error of evaluate.
+func CrossValidate(size, k int, seed uint64, class func(i int) string, evaluate func(fold data.Fold) (mat.Float, error)) (CVResult, error) {
+	folds := data.KFold(size, k, seed, class)
+	scores := make([]mat.Float, len(folds))
+	for i, fold := range folds {
+		score, err := evaluate(fold)
+		if err != nil {
+			return CVResult{}, err
+		}
+		scores[i] = score
+	}
+	mean, stdDev := meanStdDev(scores)
+	return CVResult{Scores: scores, Mean: mean, StdDev: stdDev}, nil
+}
+
+// meanStdDev returns the mean and the (population) standard deviation of the values.
+func meanStdDev(values []mat.Float) (mean, stdDev mat.Float) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= mat.Float(len(values))
+	for _, v := range values {
+		stdDev += (v - mean) * (v - mean)
+	}
+	return mean, mat.Sqrt(stdDev / mat.Float(len(values)))
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tuning provides the model selection utilities: a k-fold cross-validation driver and a
+// grid or random search over the hyperparameters, running the trials in parallel.
+//
+// The objective of a search trains and evaluates a model with the given hyperparameters, e.g.
+// building the gd.MethodConfig of a Trainer from them, and possibly cross-validating it:
+//
+//	space := tuning.Space{
+//	    "lr":         tuning.LogUniform(1.0e-5, 1.0e-3),
+//	    "batch_size": tuning.Choice(16, 32),
+//	}
+//	result, err := tuning.RandomSearch(space, 20, func(p tuning.Params) (mat.Float, error) {
+//	    return train(p.Float("lr"), p.Int("batch_size"))
+//	}, tuning.Config{Workers: 4, Seed: 42})
+package tuning
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"sort"
+	"sync"
+)
+
+// Objective trains and evaluates a model with the given hyperparameters, returning its score.
+// It must be safe to call concurrently when the trials run in parallel.
+type Objective func(params Params) (mat.Float, error)
+
+// Config provides the configuration of a search.
+type Config struct {
+	// Workers is the number of trials run in parallel (at least 1).
+	Workers int
+	// Minimize is true if the lower scores are the better ones (e.g. a loss), false otherwise.
+	Minimize bool
+	// Seed is the seed of the random search.
+	Seed uint64
+}
+
+// Trial is the evaluation of an assignment of the hyperparameters.
+type Trial struct {
+	ID     int
+	Params Params
+	Score  mat.Float
+	// Err is the error of the objective; the failed trials are never the best ones.
+	Err error
+}
+
+// Result is the result of a search.
+type Result struct {
+	// Trials are sorted from the best to the worst, the failed ones at the end.
+	Trials []Trial
+	// Best is the best trial, or nil if all the trials failed.
+	Best *Trial
+}
+
+// GridSearch evaluates all the combinations of the values of the dimensions of the space.
+// It fails if a dimension is continuous.
+func GridSearch(space Space, objective Objective, config Config) (Result, error) {
+	grid, err := space.grid()
+	if err != nil {
+		return Result{}, err
+	}
+	return run(grid, objective, config), nil
+}
+
+// RandomSearch evaluates n random assignments of the hyperparameters, sampled from the space.
+func RandomSearch(space Space, n int, objective Objective, config Config) (Result, error) {
+	if n < 1 {
+		return Result{}, fmt.Errorf("tuning: the number of trials must be positive")
+	}
+	rndGen := rand.NewLockedRand(config.Seed)
+	samples := make([]Params, n)
+	for i := range samples {
+		samples[i] = space.sample(rndGen)
+	}
+	return run(samples, objective, config), nil
+}
+
+// run evaluates the assignments of the hyperparameters in parallel and aggregates the results.
+func run(assignments []Params, objective Objective, config Config) Result {
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	trials := make([]Trial, len(assignments))
+	ids := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				score, err := objective(assignments[id])
+				trials[id] = Trial{ID: id, Params: assignments[id], Score: score, Err: err}
+			}
+		}()
+	}
+	for id := range assignments {
+		ids <- id
+	}
+	close(ids)
+	wg.Wait()
+
+	sort.SliceStable(trials, func(i, j int) bool {
+		a, b := trials[i], trials[j]
+		if (a.Err == nil) != (b.Err == nil) {
+			return a.Err == nil
+		}
+		if config.Minimize {
+			return a.Score < b.Score
+		}
+		return a.Score > b.Score
+	})
+	result := Result{Trials: trials}
+	if len(trials) > 0 && trials[0].Err == nil {
+		result.Best = &result.Trials[0]
+	}
+	return result
+}
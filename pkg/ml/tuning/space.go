@@ -0,0 +1,176 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tuning
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"sort"
+)
+
+// Dimension is the range of the values of a hyperparameter.
+type Dimension interface {
+	// Sample returns a random value of the dimension.
+	Sample(rndGen *rand.LockedRand) interface{}
+	// Values returns all the values of the dimension, or nil if it is continuous.
+	Values() []interface{}
+}
+
+var (
+	_ Dimension = choice{}
+	_ Dimension = uniform{}
+	_ Dimension = logUniform{}
+	_ Dimension = intUniform{}
+)
+
+// Space is the search space of the hyperparameters, by name.
+type Space map[string]Dimension
+
+// Params is an assignment of the hyperparameters, by name.
+type Params map[string]interface{}
+
+// Float returns the value of the hyperparameter as a mat.Float.
+// It panics if the value is not a number.
+func (p Params) Float(name string) mat.Float {
+	switch v := p[name].(type) {
+	case mat.Float:
+		return v
+	case float64:
+		return mat.Float(v)
+	case int:
+		return mat.Float(v)
+	default:
+		panic(fmt.Sprintf("tuning: the hyperparameter %q is not a number", name))
+	}
+}
+
+// Int returns the value of the hyperparameter as an int.
+// It panics if the value is not an int.
+func (p Params) Int(name string) int {
+	v, ok := p[name].(int)
+	if !ok {
+		panic(fmt.Sprintf("tuning: the hyperparameter %q is not an int", name))
+	}
+	return v
+}
+
+// String returns the value of the hyperparameter as a string.
+// It panics if the value is not a string.
+func (p Params) String(name string) string {
+	v, ok := p[name].(string)
+	if !ok {
+		panic(fmt.Sprintf("tuning: the hyperparameter %q is not a string", name))
+	}
+	return v
+}
+
+// Choice returns a Dimension with the given values.
+func Choice(values ...interface{}) Dimension {
+	if len(values) == 0 {
+		panic("tuning: a choice must have at least one value")
+	}
+	return choice(values)
+}
+
+type choice []interface{}
+
+func (d choice) Sample(rndGen *rand.LockedRand) interface{} { return d[rndGen.Intn(len(d))] }
+func (d choice) Values() []interface{}                      { return d }
+
+// Uniform returns a continuous Dimension of the mat.Float values uniformly distributed in [min, max).
+func Uniform(min, max mat.Float) Dimension {
+	return uniform{min: min, max: max}
+}
+
+type uniform struct{ min, max mat.Float }
+
+func (d uniform) Sample(rndGen *rand.LockedRand) interface{} {
+	return d.min + (d.max-d.min)*mat.Float(rndGen.Float())
+}
+func (d uniform) Values() []interface{} { return nil }
+
+// LogUniform returns a continuous Dimension of the mat.Float values in [min, max) whose logarithm
+// is uniformly distributed, suitable for the scale-free hyperparameters such as the learning rate.
+// min must be positive.
+func LogUniform(min, max mat.Float) Dimension {
+	if min <= 0 {
+		panic("tuning: the minimum of a log-uniform dimension must be positive")
+	}
+	return logUniform{min: min, max: max}
+}
+
+type logUniform struct{ min, max mat.Float }
+
+func (d logUniform) Sample(rndGen *rand.LockedRand) interface{} {
+	logMin, logMax := mat.Log(d.min), mat.Log(d.max)
+	return mat.Exp(logMin + (logMax-logMin)*mat.Float(rndGen.Float()))
+}
+func (d logUniform) Values() []interface{} { return nil }
+
+// IntRange returns a Dimension of the int values in [min, max].
+func IntRange(min, max int) Dimension {
+	if max < min {
+		panic("tuning: the maximum of an int range must not be less than the minimum")
+	}
+	return intUniform{min: min, max: max}
+}
+
+type intUniform struct{ min, max int }
+
+func (d intUniform) Sample(rndGen *rand.LockedRand) interface{} {
+	return d.min + rndGen.Intn(d.max-d.min+1)
+}
+
+func (d intUniform) Values() []interface{} {
+	values := make([]interface{}, 0, d.max-d.min+1)
+	for v := d.min; v <= d.max; v++ {
+		values = append(values, v)
+	}
+	return values
+}
+
+// names returns the names of the dimensions of the space, sorted, so that the iteration is deterministic.
+func (s Space) names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// grid returns all the combinations of the values of the dimensions, or an error if a dimension is continuous.
+func (s Space) grid() ([]Params, error) {
+	grid := []Params{{}}
+	for _, name := range s.names() {
+		values := s[name].Values()
+		if values == nil {
+			return nil, fmt.Errorf("tuning: the dimension %q is continuous", name)
+		}
+		next := make([]Params, 0, len(grid)*len(values))
+		for _, params := range grid {
+			for _, v := range values {
+				p := make(Params, len(params)+1)
+				for k, pv := range params {
+					p[k] = pv
+				}
+				p[name] = v
+				next = append(next, p)
+			}
+		}
+		grid = next
+	}
+	return grid, nil
+}
+
+// sample returns a random assignment of the hyperparameters.
+func (s Space) sample(rndGen *rand.LockedRand) Params {
+	params := make(Params, len(s))
+	for _, name := range s.names() {
+		params[name] = s[name].Sample(rndGen)
+	}
+	return params
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tuning
+
+import (
+	"errors"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/utils/data"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGridSearch(t *testing.T) {
+	space := Space{
+		"x":    IntRange(-2, 2),
+		"sign": Choice("+", "-"),
+	}
+	objective := func(p Params) (mat.Float, error) {
+		x := p.Float("x")
+		if p.String("sign") == "-" {
+			return -x * x, nil
+		}
+		return x * x, nil
+	}
+	result, err := GridSearch(space, objective, Config{Workers: 3})
+	assert.NoError(t, err)
+	assert.Len(t, result.Trials, 10)
+	assert.Equal(t, mat.Float(4), result.Best.Score)
+	assert.Equal(t, "+", result.Best.Params.String("sign"))
+	assert.Equal(t, 4, result.Best.Params.Int("x")*result.Best.Params.Int("x"))
+
+	result, err = GridSearch(space, objective, Config{Workers: 3, Minimize: true})
+	assert.NoError(t, err)
+	assert.Equal(t, mat.Float(-4), result.Best.Score)
+
+	_, err = GridSearch(Space{"lr": Uniform(0, 1)}, objective, Config{})
+	assert.Error(t, err)
+}
+
+func TestRandomSearch(t *testing.T) {
+	space := Space{
+		"lr":    LogUniform(1.0e-4, 1.0e-1),
+		"decay": Uniform(0.5, 1),
+	}
+	failure := errors.New("diverged")
+	objective := func(p Params) (mat.Float, error) {
+		if p.Float("lr") > 0.05 {
+			return 0, failure
+		}
+		return -mat.Abs(mat.Log(p.Float("lr")) - mat.Log(1.0e-3)), nil
+	}
+	result, err := RandomSearch(space, 30, objective, Config{Workers: 4, Seed: 42})
+	assert.NoError(t, err)
+	assert.Len(t, result.Trials, 30)
+	for i, trial := range result.Trials {
+		lr, decay := trial.Params.Float("lr"), trial.Params.Float("decay")
+		assert.True(t, lr >= 1.0e-4 && lr < 1.0e-1)
+		assert.True(t, decay >= 0.5 && decay < 1)
+		if i > 0 && trial.Err == nil {
+			assert.True(t, trial.Score <= result.Trials[i-1].Score)
+		}
+	}
+	assert.Equal(t, failure, result.Trials[len(result.Trials)-1].Err)
+	assert.InDelta(t, 1.0e-3, result.Best.Params.Float("lr"), 1.0e-3)
+
+	again, _ := RandomSearch(space, 30, objective, Config{Workers: 1, Seed: 42})
+	assert.Equal(t, result.Best.Params, again.Best.Params)
+
+	_, err = RandomSearch(space, 0, objective, Config{})
+	assert.Error(t, err)
+}
+
+func TestRandomSearchAllFailed(t *testing.T) {
+	result, err := RandomSearch(Space{"x": Choice(1)}, 2, func(Params) (mat.Float, error) {
+		return 0, errors.New("failed")
+	}, Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, result.Best)
+}
+
+func TestCrossValidate(t *testing.T) {
+	var validated []int
+	result, err := CrossValidate(9, 3, 42, nil, func(fold data.Fold) (mat.Float, error) {
+		validated = append(validated, fold.Validation...)
+		return mat.Float(len(validated)), nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, validated, 9)
+	assert.Equal(t, []mat.Float{3, 6, 9}, result.Scores)
+	assert.InDelta(t, 6.0, result.Mean, 1.0e-6)
+	assert.InDelta(t, 2.449490, result.StdDev, 1.0e-6)
+
+	_, err = CrossValidate(9, 3, 42, nil, func(data.Fold) (mat.Float, error) {
+		return 0, errors.New("failed")
+	})
+	assert.Error(t, err)
+}
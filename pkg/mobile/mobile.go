@@ -0,0 +1,184 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mobile provides a gomobile-friendly facade to run spaGO models on Android and iOS
+// (see "gomobile bind"), for text classification with BERT and named entities recognition
+// with the sequence labeler.
+//
+// The API only uses the types supported by gomobile: strings, numbers, errors and pointers to
+// structs. The results are flat structs, whose elements are accessed by index.
+package mobile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nlpodyssey/spago/pkg/nlp/sequencelabeler"
+	slgrpcapi "github.com/nlpodyssey/spago/pkg/nlp/sequencelabeler/grpcapi"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
+	bertgrpcapi "github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
+)
+
+// Classifier classifies texts with a converted BERT model.
+type Classifier struct {
+	server *bert.Server
+}
+
+// NewClassifier loads the BERT model from the given directory, which must have a classifier.
+func NewClassifier(modelPath string) (*Classifier, error) {
+	model, err := bert.LoadModel(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	if model.Classifier == nil || len(model.Classifier.Config.Labels) == 0 {
+		return nil, fmt.Errorf("mobile: the BERT model has no classifier")
+	}
+	return &Classifier{server: bert.NewServer(model)}, nil
+}
+
+// Classify returns the classification of the text.
+func (c *Classifier) Classify(text string) (*Classification, error) {
+	return c.classify(&bertgrpcapi.ClassifyRequest{Text: text})
+}
+
+// ClassifyPair returns the classification of a pair of texts, e.g. a premise and a hypothesis.
+func (c *Classifier) ClassifyPair(text, text2 string) (*Classification, error) {
+	return c.classify(&bertgrpcapi.ClassifyRequest{HasText2: true, Text: text, Text2: text2})
+}
+
+func (c *Classifier) classify(req *bertgrpcapi.ClassifyRequest) (*Classification, error) {
+	reply, err := c.server.Classify(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+	classification := &Classification{
+		Class:       reply.Class,
+		Confidence:  reply.Confidence,
+		Took:        reply.Took,
+		classes:     make([]string, len(reply.Distribution)),
+		confidences: make([]float64, len(reply.Distribution)),
+	}
+	for i, pair := range reply.Distribution {
+		classification.classes[i] = pair.Class
+		classification.confidences[i] = pair.Confidence
+	}
+	return classification, nil
+}
+
+// Classification is the result of a text classification. The distribution of the classes is
+// sorted by descending confidence.
+type Classification struct {
+	Class      string
+	Confidence float64
+	// Took is the number of milliseconds it took to classify the text.
+	Took        int64
+	classes     []string
+	confidences []float64
+}
+
+// Size returns the number of classes of the distribution.
+func (c *Classification) Size() int {
+	return len(c.classes)
+}
+
+// ClassAt returns the i-th class of the distribution.
+func (c *Classification) ClassAt(i int) string {
+	return c.classes[i]
+}
+
+// ConfidenceAt returns the confidence of the i-th class of the distribution.
+func (c *Classification) ConfidenceAt(i int) float64 {
+	return c.confidences[i]
+}
+
+// Tagger recognizes named entities with a converted sequence labeling model (see cmd/ner).
+type Tagger struct {
+	server *sequencelabeler.Server
+}
+
+// NewTagger loads the sequence labeling model from the given directory, which contains its
+// "config.json", parameters and embeddings.
+func NewTagger(modelPath string) (_ *Tagger, err error) {
+	config, err := loadTaggerConfig(filepath.Join(modelPath, "config.json"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(modelPath, config.ModelFilename)); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("mobile: %v", r)
+		}
+	}()
+	model := sequencelabeler.NewDefaultModel(config, modelPath, true, false)
+	model.Load(modelPath)
+	model.LoadEmbeddings(config, modelPath, true, false)
+	return &Tagger{server: sequencelabeler.NewServer(model)}, nil
+}
+
+func loadTaggerConfig(file string) (sequencelabeler.Config, error) {
+	var config sequencelabeler.Config
+	configFile, err := os.Open(file)
+	if err != nil {
+		return config, err
+	}
+	defer configFile.Close()
+	err = json.NewDecoder(configFile).Decode(&config)
+	return config, err
+}
+
+// Tag returns the labeled tokens of the text. If onlyEntities is true, the tokens of the same
+// entity are merged, and the tokens that are not part of an entity are discarded.
+func (t *Tagger) Tag(text string, onlyEntities bool) (*Tokens, error) {
+	reply, err := t.server.Analyze(context.Background(), &slgrpcapi.AnalyzeRequest{
+		Text:              text,
+		MergeEntities:     onlyEntities,
+		FilterNotEntities: onlyEntities,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tokens := &Tokens{
+		Took:   reply.Took,
+		tokens: make([]*Token, len(reply.Tokens)),
+	}
+	for i, token := range reply.Tokens {
+		tokens.tokens[i] = &Token{
+			Text:  token.Text,
+			Start: int(token.Start),
+			End:   int(token.End),
+			Label: token.Label,
+		}
+	}
+	return tokens, nil
+}
+
+// Tokens is the result of a named entities recognition.
+type Tokens struct {
+	// Took is the number of milliseconds it took to label the text.
+	Took   int64
+	tokens []*Token
+}
+
+// Size returns the number of tokens.
+func (t *Tokens) Size() int {
+	return len(t.tokens)
+}
+
+// Get returns the i-th token.
+func (t *Tokens) Get(i int) *Token {
+	return t.tokens[i]
+}
+
+// Token is a labeled token, with its offsets in runes.
+type Token struct {
+	Text  string
+	Start int
+	End   int
+	Label string
+}
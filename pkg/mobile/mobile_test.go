@@ -0,0 +1,52 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mobile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClassifier_MissingModel(t *testing.T) {
+	_, err := NewClassifier(filepath.Join(os.TempDir(), "spago-mobile-missing"))
+	assert.Error(t, err)
+}
+
+func TestNewTagger_MissingModel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spago-mobile-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = NewTagger(dir)
+	assert.Error(t, err)
+
+	config := []byte(`{"model_filename": "model.bin"}`)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.json"), config, 0644))
+	_, err = NewTagger(dir)
+	assert.Error(t, err)
+}
+
+func TestClassification(t *testing.T) {
+	c := &Classification{
+		Class:       "positive",
+		Confidence:  0.8,
+		classes:     []string{"positive", "negative"},
+		confidences: []float64{0.8, 0.2},
+	}
+	assert.Equal(t, 2, c.Size())
+	assert.Equal(t, "negative", c.ClassAt(1))
+	assert.Equal(t, 0.2, c.ConfidenceAt(1))
+}
+
+func TestTokens(t *testing.T) {
+	tokens := &Tokens{tokens: []*Token{{Text: "Rome", Start: 0, End: 4, Label: "LOC"}}}
+	assert.Equal(t, 1, tokens.Size())
+	assert.Equal(t, &Token{Text: "Rome", Start: 0, End: 4, Label: "LOC"}, tokens.Get(0))
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package augmentation implements token-level data augmentations for the training of NLP models
+// on small datasets: random deletion and swap, synonym replacement by the nearest neighbours in
+// an embedding space, and mask-and-fill with the masked language model of BERT.
+//
+// The augmentations implement the Augmenter interface, so that they can be composed, and applied
+// either to a whole dataset beforehand (see Expand), or to each example as it is fed to the model.
+package augmentation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+)
+
+// Augmenter returns a perturbed copy of a sequence of tokens, without modifying its input.
+type Augmenter interface {
+	Augment(tokens []string) []string
+}
+
+// Func is an adapter to use an ordinary function as an Augmenter.
+type Func func(tokens []string) []string
+
+// Augment calls f(tokens).
+func (f Func) Augment(tokens []string) []string {
+	return f(tokens)
+}
+
+// Compose returns an Augmenter applying the augmenters in sequence.
+func Compose(augmenters ...Augmenter) Augmenter {
+	return Func(func(tokens []string) []string {
+		for _, a := range augmenters {
+			tokens = a.Augment(tokens)
+		}
+		return tokens
+	})
+}
+
+// Sometimes returns an Augmenter applying the augmenter with probability p, and otherwise
+// returning a copy of the tokens.
+func Sometimes(a Augmenter, p mat.Float, rndGen *rand.LockedRand) Augmenter {
+	return Func(func(tokens []string) []string {
+		if mat.Float(rndGen.Float()) < p {
+			return a.Augment(tokens)
+		}
+		return append([]string(nil), tokens...)
+	})
+}
+
+// Expand returns the examples followed by n augmented copies of each of them, in the same order,
+// so that the i-th copy of the j-th example is at (i+1)*len(examples)+j.
+func Expand(examples [][]string, n int, a Augmenter) [][]string {
+	out := make([][]string, 0, len(examples)*(n+1))
+	out = append(out, examples...)
+	for i := 0; i < n; i++ {
+		for _, example := range examples {
+			out = append(out, a.Augment(example))
+		}
+	}
+	return out
+}
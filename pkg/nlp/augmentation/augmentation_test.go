@@ -0,0 +1,80 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package augmentation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var tokens = []string{"the", "cat", "sat", "on", "the", "mat"}
+
+func TestRandomDeletion(t *testing.T) {
+	rndGen := rand.NewLockedRand(42)
+	out := RandomDeletion(0.5, rndGen).Augment(tokens)
+	assert.True(t, len(out) < len(tokens))
+	assert.Equal(t, []string{"the", "cat", "sat", "on", "the", "mat"}, tokens) // unchanged
+
+	assert.Len(t, RandomDeletion(1, rndGen).Augment(tokens), 1)
+	assert.Equal(t, tokens, RandomDeletion(0, rndGen).Augment(tokens))
+	assert.Empty(t, RandomDeletion(1, rndGen).Augment(nil))
+}
+
+func TestRandomSwap(t *testing.T) {
+	out := RandomSwap(3, rand.NewLockedRand(42)).Augment(tokens)
+	assert.NotEqual(t, tokens, out)
+	assert.ElementsMatch(t, tokens, out)
+	assert.Equal(t, []string{"cat"}, RandomSwap(3, rand.NewLockedRand(42)).Augment([]string{"cat"}))
+}
+
+func TestSynonymReplacement(t *testing.T) {
+	synonyms := NewEmbeddingSynonyms(
+		[]string{"cat", "kitty", "mat", "rug", "car"},
+		[]mat.Matrix{
+			mat.NewVecDense([]mat.Float{1, 0.1, 0}),
+			mat.NewVecDense([]mat.Float{1, 0.2, 0}),
+			mat.NewVecDense([]mat.Float{0, 1, 0.1}),
+			mat.NewVecDense([]mat.Float{0, 1, 0.2}),
+			mat.NewVecDense([]mat.Float{0, 0, 1}),
+		},
+		1,
+		0.9,
+	)
+	assert.Equal(t, []string{"kitty"}, synonyms.Synonyms("cat"))
+	assert.Empty(t, synonyms.Synonyms("car"))
+	assert.Nil(t, synonyms.Synonyms("unknown"))
+
+	out := SynonymReplacement(synonyms, 1, rand.NewLockedRand(42)).Augment(tokens)
+	assert.Equal(t, []string{"the", "kitty", "sat", "on", "the", "rug"}, out)
+}
+
+func TestComposeAndSometimes(t *testing.T) {
+	exclaim := Func(func(tokens []string) []string {
+		return append(append([]string(nil), tokens...), "!")
+	})
+	assert.Equal(t, []string{"a", "!", "!"}, Compose(exclaim, exclaim).Augment([]string{"a"}))
+	assert.Equal(t, []string{"a"}, Sometimes(exclaim, 0, rand.NewLockedRand(42)).Augment([]string{"a"}))
+	assert.Equal(t, []string{"a", "!"}, Sometimes(exclaim, 1, rand.NewLockedRand(42)).Augment([]string{"a"}))
+}
+
+func TestExpand(t *testing.T) {
+	examples := [][]string{{"a"}, {"b"}}
+	suffix := Func(func(tokens []string) []string {
+		return append(append([]string(nil), tokens...), "+")
+	})
+	assert.Equal(t, [][]string{{"a"}, {"b"}, {"a", "+"}, {"b", "+"}, {"a", "+"}, {"b", "+"}}, Expand(examples, 2, suffix))
+}
+
+func TestBestWord(t *testing.T) {
+	vocab := vocabulary.New([]string{"[CLS]", "##ing", "cat", "dog", "[SEP]"})
+	word, ok := bestWord(vocab, []mat.Float{10, 9, 1, 2, 10})
+	assert.True(t, ok)
+	assert.Equal(t, "dog", word)
+	_, ok = bestWord(vocab, []mat.Float{10, 9})
+	assert.False(t, ok)
+}
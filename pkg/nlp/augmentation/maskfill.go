@@ -0,0 +1,83 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package augmentation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+	"strings"
+)
+
+// MaskAndFill returns an Augmenter masking each token with probability p (at least one token),
+// and replacing it with the word predicted by the masked language model of BERT in its context.
+// The tokens are words, which are split into word pieces for the model; a masked token is left
+// unchanged if no whole word is predicted.
+func MaskAndFill(model *bert.Model, p mat.Float, rndGen *rand.LockedRand) Augmenter {
+	tokenizer := wordpiecetokenizer.New(model.Vocabulary)
+	return Func(func(tokens []string) []string {
+		out := append([]string(nil), tokens...)
+		if len(out) == 0 {
+			return out
+		}
+		masked := make(map[int]bool)
+		for i := range out {
+			if mat.Float(rndGen.Float()) < p {
+				masked[i] = true
+			}
+		}
+		if len(masked) == 0 {
+			masked[rndGen.Intn(len(out))] = true
+		}
+
+		pieces := []string{wordpiecetokenizer.DefaultClassToken}
+		maskedPositions := make([]int, 0, len(masked))
+		tokenAt := make(map[int]int) // the index of the token masked at each position of the pieces
+		for i, token := range out {
+			if masked[i] {
+				tokenAt[len(pieces)] = i
+				maskedPositions = append(maskedPositions, len(pieces))
+				pieces = append(pieces, wordpiecetokenizer.DefaultMaskToken)
+				continue
+			}
+			pieces = append(pieces, tokenizers.GetStrings(tokenizer.Tokenize(token))...)
+		}
+		pieces = append(pieces, wordpiecetokenizer.DefaultSequenceSeparator)
+
+		g := ag.NewGraph()
+		defer g.Clear()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*bert.Model)
+		for position, prediction := range proc.PredictMasked(proc.Encode(pieces), maskedPositions) {
+			if word, ok := bestWord(model.Vocabulary, prediction.Value().Data()); ok {
+				out[tokenAt[position]] = word
+			}
+		}
+		return out
+	})
+}
+
+// bestWord returns the term of the vocabulary with the highest score, among the whole words
+// (i.e. neither the sub-words nor the special tokens).
+func bestWord(vocab *vocabulary.Vocabulary, scores []mat.Float) (string, bool) {
+	best, found := "", false
+	var max mat.Float
+	for id, score := range scores {
+		if found && score <= max {
+			continue
+		}
+		term, ok := vocab.Term(id)
+		if !ok || strings.HasPrefix(term, wordpiecetokenizer.DefaultSplitPrefix) ||
+			(strings.HasPrefix(term, "[") && strings.HasSuffix(term, "]")) {
+			continue
+		}
+		best, max, found = term, score, true
+	}
+	return best, found
+}
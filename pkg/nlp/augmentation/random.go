@@ -0,0 +1,45 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package augmentation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+)
+
+// RandomDeletion returns an Augmenter deleting each token with probability p.
+// At least one token is always kept.
+func RandomDeletion(p mat.Float, rndGen *rand.LockedRand) Augmenter {
+	return Func(func(tokens []string) []string {
+		if len(tokens) == 0 {
+			return []string{}
+		}
+		out := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			if mat.Float(rndGen.Float()) >= p {
+				out = append(out, token)
+			}
+		}
+		if len(out) == 0 {
+			out = append(out, tokens[rndGen.Intn(len(tokens))])
+		}
+		return out
+	})
+}
+
+// RandomSwap returns an Augmenter swapping two random tokens, n times.
+func RandomSwap(n int, rndGen *rand.LockedRand) Augmenter {
+	return Func(func(tokens []string) []string {
+		out := append([]string(nil), tokens...)
+		if len(out) < 2 {
+			return out
+		}
+		for k := 0; k < n; k++ {
+			i, j := rndGen.Intn(len(out)), rndGen.Intn(len(out))
+			out[i], out[j] = out[j], out[i]
+		}
+		return out
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package augmentation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"sort"
+	"sync"
+)
+
+// Synonyms provides the synonyms of the words.
+type Synonyms interface {
+	// Synonyms returns the synonyms of the word, possibly none.
+	Synonyms(word string) []string
+}
+
+// SynonymReplacement returns an Augmenter replacing each token which has synonyms with one of
+// them, chosen at random, with probability p.
+func SynonymReplacement(synonyms Synonyms, p mat.Float, rndGen *rand.LockedRand) Augmenter {
+	return Func(func(tokens []string) []string {
+		out := append([]string(nil), tokens...)
+		for i, token := range out {
+			if mat.Float(rndGen.Float()) >= p {
+				continue
+			}
+			if candidates := synonyms.Synonyms(token); len(candidates) > 0 {
+				out[i] = candidates[rndGen.Intn(len(candidates))]
+			}
+		}
+		return out
+	})
+}
+
+var _ Synonyms = &EmbeddingSynonyms{}
+
+// EmbeddingSynonyms finds the synonyms of the words as their nearest neighbours in an embedding
+// space, by cosine similarity. The search is exhaustive, so the vocabulary should be limited
+// (e.g. to the most frequent words); the synonyms of each word are cached.
+// It is safe for concurrent use.
+type EmbeddingSynonyms struct {
+	words         []string
+	vectors       []mat.Matrix
+	index         map[string]int
+	k             int
+	minSimilarity mat.Float
+	mu            sync.Mutex
+	cache         map[string][]string
+}
+
+// NewEmbeddingSynonyms returns a new EmbeddingSynonyms over the words and their vectors, finding
+// up to k synonyms for each word, whose similarity is at least minSimilarity.
+func NewEmbeddingSynonyms(words []string, vectors []mat.Matrix, k int, minSimilarity mat.Float) *EmbeddingSynonyms {
+	if len(words) != len(vectors) {
+		panic("augmentation: the words and the vectors must have the same length")
+	}
+	index := make(map[string]int, len(words))
+	for i, w := range words {
+		index[w] = i
+	}
+	return &EmbeddingSynonyms{
+		words:         words,
+		vectors:       vectors,
+		index:         index,
+		k:             k,
+		minSimilarity: minSimilarity,
+		cache:         make(map[string][]string),
+	}
+}
+
+// Synonyms returns the nearest neighbours of the word, by decreasing similarity, or none if the
+// word is not in the vocabulary.
+func (s *EmbeddingSynonyms) Synonyms(word string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if synonyms, ok := s.cache[word]; ok {
+		return synonyms
+	}
+	i, ok := s.index[word]
+	if !ok {
+		return nil
+	}
+	type neighbour struct {
+		word       string
+		similarity mat.Float
+	}
+	neighbours := make([]neighbour, 0)
+	for j, v := range s.vectors {
+		if j == i {
+			continue
+		}
+		if similarity := mat.Cosine(s.vectors[i], v); similarity >= s.minSimilarity {
+			neighbours = append(neighbours, neighbour{word: s.words[j], similarity: similarity})
+		}
+	}
+	sort.SliceStable(neighbours, func(a, b int) bool {
+		return neighbours[a].similarity > neighbours[b].similarity
+	})
+	if len(neighbours) > s.k {
+		neighbours = neighbours[:s.k]
+	}
+	synonyms := make([]string, len(neighbours))
+	for j, n := range neighbours {
+		synonyms[j] = n.word
+	}
+	s.cache[word] = synonyms
+	return synonyms
+}
@@ -21,7 +21,8 @@ import (
 )
 
 var (
-	_ nn.Model = &Model{}
+	_ nn.Model    = &Model{}
+	_ nn.Stateful = &Model{}
 )
 
 const (
@@ -104,7 +105,7 @@ func (m *Model) Initialize(rndGen *rand.LockedRand) {
 // InitProcessor initializes embeddings needed by the Forward().
 func (m *Model) InitProcessor() {
 	m.UsedEmbeddings = make(map[int]ag.Node)
-	m.UnknownEmbedding = m.Graph().NewWrap(m.Embeddings[m.Vocabulary.MustID(m.UnknownToken)])
+	m.UnknownEmbedding = m.WrapParam(m.Embeddings[m.Vocabulary.MustID(m.UnknownToken)])
 }
 
 // Forward performs the forward step for each input and returns the result.
@@ -121,6 +122,18 @@ func (m *Model) Forward(in interface{}) interface{} {
 	return ys
 }
 
+// State returns the state of the recurrent network (see nn.Stateful).
+func (m *Model) State() []ag.Node {
+	return m.RNN.State()
+}
+
+// SetState sets the state of the recurrent network, discarding the embeddings used
+// so far (see nn.Stateful).
+func (m *Model) SetState(state []ag.Node) {
+	m.UsedEmbeddings = make(map[int]ag.Node)
+	m.RNN.SetState(state)
+}
+
 // UseProjection performs a linear projection with Processor.Projection model,
 // if available, otherwise returns xs unmodified.
 func (m *Model) UseProjection(xs []ag.Node) []ag.Node {
@@ -144,7 +157,7 @@ func (m *Model) GetEmbeddings(xs []string) []ag.Node {
 			ys[i] = embedding
 			continue
 		}
-		ys[i] = m.Graph().NewWrap(m.Embeddings[id])
+		ys[i] = m.WrapParam(m.Embeddings[id])
 		m.UsedEmbeddings[id] = ys[i]
 	}
 	return ys
@@ -16,7 +16,6 @@ import (
 	"github.com/nlpodyssey/spago/pkg/ml/nn/recurrent/lstm"
 	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
 	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
-	"github.com/nlpodyssey/spago/pkg/utils"
 	"github.com/nlpodyssey/spago/pkg/utils/gopickleutils"
 	"io"
 	"io/ioutil"
@@ -115,7 +114,7 @@ func Convert(modelPath string, flairModelName, configFileName, modelFileName str
 
 	output := path.Join(modelPath, modelFileName)
 	log.Printf("Serializing full model to \"%s\"... ", output)
-	err := utils.SerializeToFile(output, lm)
+	err := nn.SaveModel(output, lm)
 	if err != nil {
 		panic("error during model serialization.")
 	}
@@ -64,7 +64,7 @@ func (m *Generator) GenerateText(prefix string) (text string, logProb mat.Float)
 func (m *Generator) generateNext(proc *Model, xs ...string) (next string, prob mat.Float) {
 	lastIndex := len(xs) - 1
 	prediction := proc.Forward(xs).([]ag.Node)[lastIndex].Value().Data() // keep the last prediction only
-	index := sample(prediction, m.Temperature)
+	index := sample(prediction, m.Temperature, proc.Graph().Rand())
 	next = m.model.Vocabulary.MustTerm(index)
 	prob = prediction[index]
 	return
@@ -28,6 +28,11 @@ type TrainingConfig struct {
 	SerializationInterval int
 	UpdateMethod          gd.MethodConfig
 	ModelPath             string
+
+	// TBPTT enables the truncated back-propagation through time with a bounded graph: after each batch,
+	// the history of the recurrent states is detached from the graph, while the last hidden state is
+	// carried over to the next batch. It allows training on very long sequences. BackStep is ignored.
+	TBPTT bool
 }
 
 // Trainer implements the training process for a Character-level Language Model.
@@ -63,7 +68,7 @@ func (t *Trainer) Train() {
 		// TODO: save the model only if it is better against a validation criterion (yet to be defined)
 		if i > 0 && i%t.SerializationInterval == 0 {
 			fmt.Println("=== MODEL SERIALIZATION")
-			err := utils.SerializeToFile(t.ModelPath, t.model)
+			err := nn.SaveModel(t.ModelPath, t.model)
 			if err != nil {
 				panic("charlm: error during model serialization.")
 			}
@@ -80,6 +85,7 @@ func (t *Trainer) trainPassage(index int, text string) {
 	)
 	defer g.Clear()
 	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, t.model).(*Model)
+	mark := g.MaxID() // the nodes created during the reification
 
 	// Split the text into runes and append the sequence separator
 	sequence := utils.SplitByRune(text)
@@ -103,6 +109,9 @@ func (t *Trainer) trainPassage(index int, text string) {
 		t.optimizer.Optimize()
 		t.lastBatchLoss = loss
 		t.curPerplexity = mat.Exp(loss)
+		if t.TBPTT {
+			detachHistory(proc, mark)
+		}
 	}
 	if g.TimeStep() != cnt {
 		panic(fmt.Sprintf("charlm: time-step `%d` different than processed items `%d`. Something goes wrong.",
@@ -126,6 +135,16 @@ func (t *Trainer) trainBatch(proc *Model, batch []string) mat.Float {
 	targets := targetsIds(batch, t.model.Vocabulary, t.model.UnknownToken)
 	loss := losses.CrossEntropySeq(g, predicted[:len(targets)], targets, true)
 	g.Forward(ag.Range(prevTimeStep+1, -1))
-	g.Backward(loss, ag.Truncate(t.BackStep))
+	if t.TBPTT {
+		g.Backward(loss)
+	} else {
+		g.Backward(loss, ag.Truncate(t.BackStep))
+	}
 	return loss.ScalarValue()
 }
+
+// detachHistory removes from the graph the nodes created after the given ID, carrying over the last
+// state of the recurrent network as the initial state for the next prediction.
+func detachHistory(proc *Model, maxID int) {
+	proc.SetState(proc.Graph().DetachHistory(maxID, proc.State()...))
+}
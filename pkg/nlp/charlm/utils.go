@@ -26,12 +26,12 @@ func targetsIds(sequence []string, vocab *vocabulary.Vocabulary, unknownToken st
 
 // sample extracts the next character from the probability multinomial distribution.
 // Note that the softmax must NOT have been applied to the prediction values.
-func sample(prediction []mat.Float, temperature mat.Float) int {
+func sample(prediction []mat.Float, temperature mat.Float, generator *rand.LockedRand) int {
 	for i := range prediction {
 		prediction[i] *= 1.0 / temperature
 	}
 	prediction = floatutils.SoftMax(prediction)
-	p := rand.Float() // TODO: use a local random generator?
+	p := generator.Float()
 	for i, x := range prediction {
 		p -= x
 		if p < 0 {
@@ -76,18 +76,22 @@ func (m *Model) Encode(words []string) []ag.Node {
 
 	var hiddenStates []ag.Node
 	var reverseHiddenStates []ag.Node
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	go func() {
-		defer wg.Done()
+	if m.Graph().ConcurrentComputations() > 1 {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			hiddenStates = process(m.LeftToRight, padding(sequence, m.StartMarker, m.EndMarker))
+		}()
+		go func() {
+			defer wg.Done()
+			reverseHiddenStates = process(m.RightToLeft, padding(reversed(sequence), m.StartMarker, m.EndMarker))
+		}()
+		wg.Wait()
+	} else {
 		hiddenStates = process(m.LeftToRight, padding(sequence, m.StartMarker, m.EndMarker))
-	}()
-	go func() {
-		defer wg.Done()
 		reverseHiddenStates = process(m.RightToLeft, padding(reversed(sequence), m.StartMarker, m.EndMarker))
-	}()
-	wg.Wait()
+	}
 
 	out := make([]ag.Node, len(words))
 	for i, boundary := range boundaries {
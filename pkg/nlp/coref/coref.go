@@ -0,0 +1,242 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package coref implements a span-ranking coreference resolution model, which operates on the contextual
+// encodings of the tokens of a text (e.g. the output of a BERT encoder).
+//
+// All the spans up to a maximum width are represented by the encodings of their boundaries and an
+// attention-weighted sum of their tokens. A mention scorer prunes the spans, keeping the most likely
+// mentions; then, each mention is linked to the best of the preceding mentions (its antecedent), or to
+// none of them, by summing the mention scores of both spans and a biaffine antecedent score.
+//
+// Reference: "End-to-end Neural Coreference Resolution" by Lee et al., 2017
+// (https://arxiv.org/pdf/1707.07045.pdf).
+package coref
+
+import (
+	"encoding/gob"
+	"sort"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+)
+
+var (
+	_ nn.Model = &Model{}
+)
+
+// Span is a contiguous sequence of tokens, from Start to End (both inclusive).
+type Span struct {
+	Start int
+	End   int
+}
+
+// Config provides configuration settings for a coreference Model.
+type Config struct {
+	// InputSize is the size of the encodings of the tokens.
+	InputSize int
+	// HiddenSize is the size of the hidden layer of the mention scorer.
+	HiddenSize int
+	// ProjectionSize is the size of the projections of the spans used by the antecedent scorer.
+	ProjectionSize int
+	// MaxSpanWidth is the maximum number of tokens of a mention.
+	MaxSpanWidth int
+	// MentionRatio is the number of mentions kept after the pruning, per token of the text.
+	MentionRatio mat.Float
+	// MaxAntecedents is the maximum number of preceding mentions considered as antecedents.
+	MaxAntecedents int
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	nn.BaseModel
+	Config        Config
+	HeadAttention *linear.Model
+	MentionHidden *linear.Model
+	MentionOutput *linear.Model
+	Projection    *linear.Model
+	W             nn.Param `spago:"type:weights"`
+	U             nn.Param `spago:"type:weights"`
+	V             nn.Param `spago:"type:weights"`
+	B             nn.Param `spago:"type:biases"`
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new model with parameters initialized to zeros.
+func New(config Config) *Model {
+	spanSize := 3 * config.InputSize
+	return &Model{
+		Config:        config,
+		HeadAttention: linear.New(config.InputSize, 1),
+		MentionHidden: linear.New(spanSize, config.HiddenSize),
+		MentionOutput: linear.New(config.HiddenSize, 1),
+		Projection:    linear.New(spanSize, config.ProjectionSize),
+		W:             nn.NewParam(mat.NewEmptyDense(config.ProjectionSize, config.ProjectionSize)),
+		U:             nn.NewParam(mat.NewEmptyVecDense(config.ProjectionSize)),
+		V:             nn.NewParam(mat.NewEmptyVecDense(config.ProjectionSize)),
+		B:             nn.NewParam(mat.NewEmptyVecDense(1)),
+	}
+}
+
+// Resolve returns the clusters of coreferent mentions found in the text, given the encodings of its tokens.
+// The clusters, and the mentions of each cluster, are sorted by position.
+func (m *Model) Resolve(xs []ag.Node) [][]Span {
+	s := m.score(xs)
+	cluster := make(map[Span]int)
+	var clusters [][]Span
+	for a, scores := range s.antecedents {
+		best := mat.Float(0.0) // the score of the dummy antecedent
+		antecedent := -1
+		for i, score := range scores {
+			if v := score.ScalarValue(); v > best {
+				best, antecedent = v, s.candidates[a][i]
+			}
+		}
+		if antecedent < 0 {
+			continue
+		}
+		mention, prev := s.mentions[a], s.mentions[antecedent]
+		id, ok := cluster[prev]
+		if !ok {
+			id = len(clusters)
+			cluster[prev] = id
+			clusters = append(clusters, []Span{prev})
+		}
+		cluster[mention] = id
+		clusters[id] = append(clusters[id], mention)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return less(clusters[i][0], clusters[j][0])
+	})
+	return clusters
+}
+
+// Loss returns the negative marginal log-likelihood of the gold antecedents of the mentions kept by the
+// pruning, given the encodings of the tokens and the gold clusters. The gold antecedents of a mention
+// are the preceding mentions of the same cluster; the mentions that don't belong to any cluster, or that
+// are the first of their cluster, have the dummy antecedent only.
+func (m *Model) Loss(xs []ag.Node, clusters [][]Span) ag.Node {
+	g := m.Graph()
+	gold := make(map[Span]int)
+	for id, cluster := range clusters {
+		for _, span := range cluster {
+			gold[span] = id + 1 // zero means no cluster
+		}
+	}
+	s := m.score(xs)
+	dummy := g.NewScalar(0.0)
+	var loss ag.Node
+	for a, scores := range s.antecedents {
+		all := []ag.Node{dummy}
+		var positives []ag.Node
+		for i, score := range scores {
+			all = append(all, score)
+			if id := gold[s.mentions[a]]; id > 0 && gold[s.mentions[s.candidates[a][i]]] == id {
+				positives = append(positives, score)
+			}
+		}
+		if len(positives) == 0 {
+			positives = []ag.Node{dummy}
+		}
+		loss = g.Add(loss, g.Sub(g.LogSumExp(g.Concat(all...)), g.LogSumExp(g.Concat(positives...))))
+	}
+	return loss
+}
+
+// scored contains the mentions kept by the pruning, sorted by position, and the scores of their antecedents.
+type scored struct {
+	mentions []Span
+	// candidates are the indices of the candidate antecedents of each mention.
+	candidates [][]int
+	// antecedents are the scores of the candidate antecedents of each mention.
+	antecedents [][]ag.Node
+}
+
+// score computes the scores of the candidate antecedents of the mentions kept by the pruning:
+// s(i, j) = mention(i) + mention(j) + biaffine(proj(i), proj(j))
+func (m *Model) score(xs []ag.Node) *scored {
+	g := m.Graph()
+	spans := m.spans(len(xs))
+	attention := m.HeadAttention.Forward(xs...)
+	reps := make([]ag.Node, len(spans))
+	for i, span := range spans {
+		reps[i] = m.represent(xs, attention, span)
+	}
+	mentionScores := m.MentionOutput.Forward(ag.Map(g.ReLU, m.MentionHidden.Forward(reps...))...)
+	kept := m.prune(spans, mentionScores, len(xs))
+
+	s := &scored{
+		mentions:    make([]Span, len(kept)),
+		candidates:  make([][]int, len(kept)),
+		antecedents: make([][]ag.Node, len(kept)),
+	}
+	projections := make([]ag.Node, len(kept))
+	for a, i := range kept {
+		s.mentions[a] = spans[i]
+		projections[a] = m.Projection.Forward(reps[i])[0]
+	}
+	for a := range kept {
+		first := 0
+		if m.Config.MaxAntecedents > 0 && a > m.Config.MaxAntecedents {
+			first = a - m.Config.MaxAntecedents
+		}
+		for b := first; b < a; b++ {
+			pair := nn.BiAffine(g, m.W, m.U, m.V, m.B, projections[a], projections[b])
+			score := g.Add(g.Add(mentionScores[kept[a]], mentionScores[kept[b]]), pair)
+			s.candidates[a] = append(s.candidates[a], b)
+			s.antecedents[a] = append(s.antecedents[a], score)
+		}
+	}
+	return s
+}
+
+// spans returns all the spans up to the maximum width, sorted by position.
+func (m *Model) spans(length int) []Span {
+	var spans []Span
+	for start := 0; start < length; start++ {
+		for end := start; end < length && end-start < m.Config.MaxSpanWidth; end++ {
+			spans = append(spans, Span{Start: start, End: end})
+		}
+	}
+	return spans
+}
+
+// represent returns the representation of the span: the concatenation of the encodings of the first
+// and the last token, and of the sum of the encodings of all its tokens, weighted by the attention.
+func (m *Model) represent(xs, attention []ag.Node, span Span) ag.Node {
+	g := m.Graph()
+	weights := g.Softmax(g.Concat(attention[span.Start : span.End+1]...))
+	head := g.Mul(g.T(g.Stack(xs[span.Start:span.End+1]...)), weights)
+	return g.Concat(xs[span.Start], xs[span.End], head)
+}
+
+// prune returns the indices of the spans with the highest mention scores, sorted by position.
+func (m *Model) prune(spans []Span, scores []ag.Node, length int) []int {
+	k := int(mat.Ceil(m.Config.MentionRatio * mat.Float(length)))
+	if k < 1 {
+		k = 1
+	}
+	if k > len(spans) {
+		k = len(spans)
+	}
+	indices := make([]int, len(spans))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return scores[indices[i]].ScalarValue() > scores[indices[j]].ScalarValue()
+	})
+	kept := indices[:k]
+	sort.Ints(kept) // the spans are already sorted by position
+	return kept
+}
+
+func less(a, b Span) bool {
+	return a.Start < b.Start || (a.Start == b.Start && a.End < b.End)
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package coref
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/gradcheck"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testInputs = [][]mat.Float{
+	{0.9, -0.2, 0.1, 0.4},
+	{-0.5, 0.3, 0.8, -0.1},
+	{0.2, 0.7, -0.6, 0.3},
+	{0.8, -0.1, 0.2, 0.5},
+	{-0.3, -0.8, 0.4, 0.1},
+}
+
+// the first and the fourth tokens refer to the same entity
+var testClusters = [][]Span{{{Start: 0, End: 0}, {Start: 3, End: 3}}}
+
+func TestModel_Spans(t *testing.T) {
+	model := New(Config{InputSize: 4, HiddenSize: 3, ProjectionSize: 2, MaxSpanWidth: 2, MentionRatio: 0.5})
+	assert.Equal(t, []Span{{0, 0}, {0, 1}, {1, 1}, {1, 2}, {2, 2}}, model.spans(3))
+}
+
+func TestModel_Prune(t *testing.T) {
+	model := newTestModel(Config{InputSize: 4, HiddenSize: 3, ProjectionSize: 2, MaxSpanWidth: 2, MentionRatio: 0.4})
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	s := proc.score(newInputs(g))
+	assert.Len(t, s.mentions, 2) // ceil(0.4 * 5)
+	assert.True(t, less(s.mentions[0], s.mentions[1]))
+	assert.Empty(t, s.antecedents[0])
+	assert.Equal(t, []int{0}, s.candidates[1])
+}
+
+func TestModel_MaxAntecedents(t *testing.T) {
+	model := newTestModel(Config{InputSize: 4, HiddenSize: 3, ProjectionSize: 2, MaxSpanWidth: 1, MentionRatio: 1.0, MaxAntecedents: 2})
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	s := proc.score(newInputs(g))
+	assert.Len(t, s.mentions, 5)
+	assert.Equal(t, []int{0, 1}, s.candidates[2])
+	assert.Equal(t, []int{2, 3}, s.candidates[4])
+}
+
+func TestModel_Gradients(t *testing.T) {
+	model := newTestModel(Config{InputSize: 4, HiddenSize: 3, ProjectionSize: 2, MaxSpanWidth: 1, MentionRatio: 1.0})
+	f := func(g *ag.Graph, proc nn.Model) ag.Node {
+		return proc.(*Model).Loss(newInputs(g), testClusters)
+	}
+	assert.NoError(t, gradcheck.CheckModel(model, f, gradcheck.DefaultConfig))
+}
+
+func TestModel_Training(t *testing.T) {
+	model := newTestModel(Config{InputSize: 4, HiddenSize: 8, ProjectionSize: 4, MaxSpanWidth: 2, MentionRatio: 1.0})
+	optimizer := gd.NewOptimizer(adam.New(adam.NewConfig(0.01, 0.9, 0.999, 1.0e-8)), nn.NewDefaultParamsIterator(model))
+
+	var first, last mat.Float
+	for epoch := 0; epoch < 200; epoch++ {
+		g := ag.NewGraph()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+		loss := proc.Loss(newInputs(g), testClusters)
+		g.Backward(loss)
+		optimizer.Optimize()
+		if epoch == 0 {
+			first = loss.ScalarValue()
+		}
+		last = loss.ScalarValue()
+	}
+	assert.Less(t, last, first)
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	assert.Equal(t, testClusters, proc.Resolve(newInputs(g)))
+}
+
+func TestModel_Resolve(t *testing.T) {
+	model := New(Config{InputSize: 4, HiddenSize: 3, ProjectionSize: 2, MaxSpanWidth: 1, MentionRatio: 1.0})
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	assert.Empty(t, proc.Resolve(newInputs(g))) // all the scores are zero, as the dummy antecedent
+}
+
+func newTestModel(config Config) *Model {
+	model := New(config)
+	r := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, r)
+		} else {
+			initializers.Uniform(param.Value(), -0.5, 0.5, r)
+		}
+	})
+	return model
+}
+
+func newInputs(g *ag.Graph) []ag.Node {
+	xs := make([]ag.Node, len(testInputs))
+	for i, input := range testInputs {
+		xs[i] = g.NewVariable(mat.NewVecDense(input), false)
+	}
+	return xs
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package document provides a pipeline to process documents of arbitrary length with models that
+// operate on shorter texts: the document is split into sentences, which are grouped into chunks of
+// limited length, and the results of the chunks are mapped back to the offsets of the document.
+package document
+
+import (
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/sentencesplitter"
+	"sync"
+	"unicode/utf8"
+)
+
+// Config provides configuration settings for a Pipeline.
+type Config struct {
+	// MaxSentences is the maximum number of sentences of a chunk (zero means no limit).
+	MaxSentences int
+	// MaxLength is the maximum length of a chunk, as measured by Length (zero means no limit).
+	// A sentence longer than MaxLength makes a chunk on its own.
+	MaxLength int
+	// Length measures the length of a text (e.g. the number of tokens of a model). If it is nil,
+	// the length is the number of runes.
+	Length func(text string) int
+	// Workers is the maximum number of chunks processed concurrently by Process (1 if zero).
+	Workers int
+}
+
+// Chunk is a group of consecutive sentences of a document.
+type Chunk struct {
+	// Text is the portion of the document spanning the sentences.
+	Text string
+	// Offsets are the position of Text in the document, in runes.
+	Offsets tokenizers.OffsetsType
+	// Sentences are the sentences of the chunk, with their offsets in the document.
+	Sentences []tokenizers.StringOffsetsPair
+}
+
+// Pipeline splits documents into chunks of sentences.
+type Pipeline struct {
+	splitter tokenizers.Tokenizer
+	config   Config
+}
+
+// NewPipeline returns a new Pipeline splitting the sentences with the given tokenizer.
+// If the tokenizer is nil, a default sentencesplitter.SentenceSplitter is used.
+func NewPipeline(splitter tokenizers.Tokenizer, config Config) *Pipeline {
+	if splitter == nil {
+		splitter = sentencesplitter.New()
+	}
+	if config.Length == nil {
+		config.Length = utf8.RuneCountInString
+	}
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	return &Pipeline{
+		splitter: splitter,
+		config:   config,
+	}
+}
+
+// Split splits the text into chunks, filling each chunk with as many sentences as the limits allow.
+func (p *Pipeline) Split(text string) []Chunk {
+	runes := []rune(text)
+	chunks := make([]Chunk, 0)
+	var sentences []tokenizers.StringOffsetsPair
+	for _, sentence := range p.splitter.Tokenize(text) {
+		if len(sentences) > 0 && !p.fits(runes, sentences[0], sentence, len(sentences)+1) {
+			chunks = append(chunks, newChunk(runes, sentences))
+			sentences = nil
+		}
+		sentences = append(sentences, sentence)
+	}
+	if len(sentences) > 0 {
+		chunks = append(chunks, newChunk(runes, sentences))
+	}
+	return chunks
+}
+
+// fits reports whether a chunk from the first to the last sentence respects the limits.
+func (p *Pipeline) fits(runes []rune, first, last tokenizers.StringOffsetsPair, numSentences int) bool {
+	if p.config.MaxSentences > 0 && numSentences > p.config.MaxSentences {
+		return false
+	}
+	if p.config.MaxLength > 0 {
+		text := string(runes[first.Offsets.Start:last.Offsets.End])
+		return p.config.Length(text) <= p.config.MaxLength
+	}
+	return true
+}
+
+func newChunk(runes []rune, sentences []tokenizers.StringOffsetsPair) Chunk {
+	offsets := tokenizers.OffsetsType{
+		Start: sentences[0].Offsets.Start,
+		End:   sentences[len(sentences)-1].Offsets.End,
+	}
+	return Chunk{
+		Text:      string(runes[offsets.Start:offsets.End]),
+		Offsets:   offsets,
+		Sentences: sentences,
+	}
+}
+
+// Process calls f for each chunk, with its index, concurrently on at most Config.Workers goroutines.
+// It returns when all the chunks have been processed. The results are usually stored by f at the
+// index of the chunk, so that they can be recombined in the order of the document.
+func (p *Pipeline) Process(chunks []Chunk, f func(i int, chunk Chunk)) {
+	var wg sync.WaitGroup
+	indices := make(chan int)
+	workers := p.config.Workers
+	if workers > len(chunks) {
+		workers = len(chunks)
+	}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				f(i, chunks[i])
+			}
+		}()
+	}
+	for i := range chunks {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+// Align converts the offsets of the tokens, relative to the given text (a sentence or a chunk), to
+// offsets relative to the document.
+func Align(tokens []tokenizers.StringOffsetsPair, text tokenizers.OffsetsType) []tokenizers.StringOffsetsPair {
+	aligned := make([]tokenizers.StringOffsetsPair, len(tokens))
+	for i, token := range tokens {
+		aligned[i] = tokenizers.StringOffsetsPair{
+			String: token.String,
+			Offsets: tokenizers.OffsetsType{
+				Start: token.Offsets.Start + text.Start,
+				End:   token.Offsets.End + text.Start,
+			},
+		}
+	}
+	return aligned
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package document
+
+import (
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/basetokenizer"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testText = "  The first sentence. The second one is longer than the others! Third. Fourth and last.  "
+
+func TestPipeline_Split(t *testing.T) {
+	t.Run("no limits", func(t *testing.T) {
+		chunks := NewPipeline(nil, Config{}).Split(testText)
+		assert.Len(t, chunks, 1)
+		assert.Equal(t, strings.TrimSpace(testText), chunks[0].Text)
+		assert.Equal(t, tokenizers.OffsetsType{Start: 2, End: 87}, chunks[0].Offsets)
+		assert.Len(t, chunks[0].Sentences, 4)
+	})
+
+	t.Run("max sentences", func(t *testing.T) {
+		chunks := NewPipeline(nil, Config{MaxSentences: 3}).Split(testText)
+		assert.Equal(t, []string{
+			"The first sentence. The second one is longer than the others! Third.",
+			"Fourth and last.",
+		}, chunkTexts(chunks))
+	})
+
+	t.Run("max length", func(t *testing.T) {
+		words := func(text string) int {
+			return len(strings.Fields(text))
+		}
+		chunks := NewPipeline(nil, Config{MaxLength: 5, Length: words}).Split(testText)
+		assert.Equal(t, []string{
+			"The first sentence.",
+			"The second one is longer than the others!", // a sentence longer than the limit on its own
+			"Third. Fourth and last.",
+		}, chunkTexts(chunks))
+		runes := []rune(testText)
+		for _, chunk := range chunks {
+			assert.Equal(t, chunk.Text, string(runes[chunk.Offsets.Start:chunk.Offsets.End]))
+		}
+	})
+
+	t.Run("empty text", func(t *testing.T) {
+		assert.Empty(t, NewPipeline(nil, Config{}).Split(" \n "))
+	})
+}
+
+func TestPipeline_Process(t *testing.T) {
+	pipeline := NewPipeline(nil, Config{MaxSentences: 1, Workers: 3})
+	chunks := pipeline.Split(testText)
+	results := make([][]tokenizers.StringOffsetsPair, len(chunks))
+	var calls int32
+	pipeline.Process(chunks, func(i int, chunk Chunk) {
+		atomic.AddInt32(&calls, 1)
+		results[i] = Align(basetokenizer.New().Tokenize(chunk.Text), chunk.Offsets)
+	})
+	assert.Equal(t, int32(4), calls)
+
+	// the recombined tokens are the ones of the whole text
+	var tokens []tokenizers.StringOffsetsPair
+	for _, result := range results {
+		tokens = append(tokens, result...)
+	}
+	assert.Equal(t, basetokenizer.New().Tokenize(testText), tokens)
+
+	pipeline.Process(nil, func(i int, chunk Chunk) {
+		t.Error("unexpected call")
+	})
+}
+
+func chunkTexts(chunks []Chunk) []string {
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+	return texts
+}
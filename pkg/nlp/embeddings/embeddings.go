@@ -225,7 +225,9 @@ func (m *Model) getEmbedding(word string) ag.Node {
 			return m.ZeroEmbedding
 		}
 		return nil
+	case m.Mode() == nn.Inference:
+		return m.Graph().NewWrapNoGrad(param)
 	default:
-		return m.Graph().NewWrap(param)
+		return m.WrapParam(param)
 	}
 }
@@ -0,0 +1,205 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package glue evaluates the sequence classification models on the tasks of the GLUE benchmark
+// (https://gluebenchmark.com), loading their development sets and measuring the metrics of each
+// task, so that the converted models can be validated against the published results.
+package glue
+
+import (
+	"bufio"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/stats"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Example is an example of a task.
+type Example struct {
+	Text1 string
+	// Text2 is empty for the single-sentence tasks.
+	Text2 string
+	// Label is the gold label of a classification task.
+	Label string
+	// Score is the gold score of a regression task.
+	Score mat.Float
+}
+
+// Prediction is the prediction of a model for an example.
+type Prediction struct {
+	// Label is the predicted label of a classification task.
+	Label string
+	// Score is the predicted score of a regression task.
+	Score mat.Float
+}
+
+// Model is a sequence classification (or regression) model evaluated on the tasks.
+type Model interface {
+	// Predict returns the prediction for the texts (text2 is empty for the single-sentence tasks).
+	Predict(text1, text2 string) (Prediction, error)
+}
+
+// ModelFunc is an adapter to allow the use of ordinary functions as a Model.
+type ModelFunc func(text1, text2 string) (Prediction, error)
+
+// Predict calls f(text1, text2).
+func (f ModelFunc) Predict(text1, text2 string) (Prediction, error) {
+	return f(text1, text2)
+}
+
+// Result is the result of the evaluation of a model on a task.
+type Result struct {
+	Task     string             `json:"task"`
+	Examples int                `json:"examples"`
+	Metrics  map[Metric]float64 `json:"metrics"`
+}
+
+// LoadDev loads the development set of the task from the directory of the GLUE data.
+func LoadDev(task Task, dir string) ([]Example, error) {
+	f, err := os.Open(filepath.Join(dir, task.DevFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(task, f)
+}
+
+// Load loads the examples of the task from a tab-separated file.
+func Load(task Task, r io.Reader) ([]Example, error) {
+	var examples []Example
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if line == 1 && task.Header {
+			continue
+		}
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		example, err := parseExample(task, strings.Split(scanner.Text(), "\t"))
+		if err != nil {
+			return nil, fmt.Errorf("glue: %s line %d: %w", task.Name, line, err)
+		}
+		examples = append(examples, example)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return examples, nil
+}
+
+func parseExample(task Task, fields []string) (Example, error) {
+	labelColumn := task.LabelColumn
+	if labelColumn < 0 {
+		labelColumn += len(fields)
+	}
+	if labelColumn < 0 || task.Text1Column >= len(fields) || task.Text2Column >= len(fields) || labelColumn >= len(fields) {
+		return Example{}, fmt.Errorf("unexpected number of columns %d", len(fields))
+	}
+	example := Example{Text1: fields[task.Text1Column]}
+	if task.Text2Column >= 0 {
+		example.Text2 = fields[task.Text2Column]
+	}
+	label := strings.TrimSpace(fields[labelColumn])
+	if task.IsRegression() {
+		score, err := strconv.ParseFloat(label, 32)
+		if err != nil {
+			return Example{}, err
+		}
+		example.Score = mat.Float(score)
+		return example, nil
+	}
+	if !contains(task.Labels, label) {
+		return Example{}, fmt.Errorf("unknown label %q", label)
+	}
+	example.Label = label
+	return example, nil
+}
+
+// Evaluate returns the metrics of the predictions of the model on the examples of the task.
+// It stops at the first error of the model.
+func Evaluate(task Task, examples []Example, model Model) (Result, error) {
+	predictions := make([]Prediction, len(examples))
+	for i, example := range examples {
+		prediction, err := model.Predict(example.Text1, example.Text2)
+		if err != nil {
+			return Result{}, err
+		}
+		predictions[i] = prediction
+	}
+	return Result{
+		Task:     task.Name,
+		Examples: len(examples),
+		Metrics:  Score(task, examples, predictions),
+	}, nil
+}
+
+// Score returns the metrics of the task, given the predictions of the examples.
+func Score(task Task, examples []Example, predictions []Prediction) map[Metric]float64 {
+	if len(examples) != len(predictions) {
+		panic("glue: the examples and the predictions must have the same length")
+	}
+	metrics := make(map[Metric]float64, len(task.Metrics))
+	if task.IsRegression() {
+		gold := make([]mat.Float, len(examples))
+		predicted := make([]mat.Float, len(examples))
+		for i := range examples {
+			gold[i], predicted[i] = examples[i].Score, predictions[i].Score
+		}
+		for _, metric := range task.Metrics {
+			switch metric {
+			case Pearson:
+				metrics[metric] = float64(stats.Pearson(gold, predicted))
+			case Spearman:
+				metrics[metric] = float64(stats.Spearman(gold, predicted))
+			}
+		}
+		return metrics
+	}
+
+	correct := 0
+	positive := stats.NewMetricCounter()
+	for i, example := range examples {
+		gold, predicted := example.Label, predictions[i].Label
+		if gold == predicted {
+			correct++
+		}
+		switch {
+		case gold == task.PositiveLabel && predicted == task.PositiveLabel:
+			positive.IncTruePos()
+		case gold == task.PositiveLabel:
+			positive.IncFalseNeg()
+		case predicted == task.PositiveLabel:
+			positive.IncFalsePos()
+		default:
+			positive.IncTrueNeg()
+		}
+	}
+	for _, metric := range task.Metrics {
+		switch metric {
+		case Accuracy:
+			if len(examples) > 0 {
+				metrics[metric] = float64(correct) / float64(len(examples))
+			}
+		case F1:
+			metrics[metric] = float64(positive.F1Score())
+		case MCC:
+			metrics[metric] = float64(positive.MatthewsCorrelation())
+		}
+	}
+	return metrics
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glue
+
+import (
+	"encoding/json"
+	"errors"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const mrpcDev = "Quality\t#1 ID\t#2 ID\t#1 String\t#2 String\n" +
+	"1\t1\t2\tThe cat sat.\tA cat was sitting.\n" +
+	"0\t3\t4\tIt rains.\tThe sun shines.\n" +
+	"1\t5\t6\tHe left.\tHe went away.\n" +
+	"0\t7\t8\tRed.\tBlue.\n"
+
+func TestLoad(t *testing.T) {
+	examples, err := Load(Tasks["mrpc"], strings.NewReader(mrpcDev))
+	assert.NoError(t, err)
+	assert.Len(t, examples, 4)
+	assert.Equal(t, Example{Text1: "The cat sat.", Text2: "A cat was sitting.", Label: "1"}, examples[0])
+
+	stsb := "index\tgenre\tfilename\tyear\told_index\tsource1\tsource2\tsentence1\tsentence2\tscore\n" +
+		"0\tmain\tf\t2012\t1\tnone\tnone\tA man plays.\tA man is playing.\t4.750\n"
+	examples, err = Load(Tasks["sts-b"], strings.NewReader(stsb))
+	assert.NoError(t, err)
+	assert.Equal(t, []Example{{Text1: "A man plays.", Text2: "A man is playing.", Score: 4.75}}, examples)
+
+	_, err = Load(Tasks["sst-2"], strings.NewReader("sentence\tlabel\ngood\t2\n"))
+	assert.Error(t, err)
+	_, err = Load(Tasks["mrpc"], strings.NewReader("header\n1\tonly\n"))
+	assert.Error(t, err)
+}
+
+func TestEvaluateClassification(t *testing.T) {
+	examples, _ := Load(Tasks["mrpc"], strings.NewReader(mrpcDev))
+	predicted := map[string]string{"The cat sat.": "1", "It rains.": "1", "He left.": "0", "Red.": "0"}
+	model := ModelFunc(func(text1, _ string) (Prediction, error) {
+		return Prediction{Label: predicted[text1]}, nil
+	})
+	result, err := Evaluate(Tasks["mrpc"], examples, model)
+	assert.NoError(t, err)
+	assert.Equal(t, "MRPC", result.Task)
+	assert.Equal(t, 4, result.Examples)
+	assert.InDelta(t, 0.5, result.Metrics[Accuracy], 1.0e-6)
+	assert.InDelta(t, 0.5, result.Metrics[F1], 1.0e-6)
+
+	_, err = Evaluate(Tasks["mrpc"], examples, ModelFunc(func(string, string) (Prediction, error) {
+		return Prediction{}, errors.New("failed")
+	}))
+	assert.Error(t, err)
+}
+
+func TestScoreMCCAndCorrelation(t *testing.T) {
+	examples := []Example{{Label: "1"}, {Label: "1"}, {Label: "0"}, {Label: "0"}}
+	predictions := []Prediction{{Label: "1"}, {Label: "1"}, {Label: "0"}, {Label: "0"}}
+	assert.InDelta(t, 1.0, Score(Tasks["cola"], examples, predictions)[MCC], 1.0e-6)
+
+	examples = []Example{{Score: 1}, {Score: 2}, {Score: 3}}
+	predictions = []Prediction{{Score: 0.1}, {Score: 0.3}, {Score: 0.2}}
+	metrics := Score(Tasks["sts-b"], examples, predictions)
+	assert.InDelta(t, 0.5, metrics[Pearson], 1.0e-6)
+	assert.InDelta(t, 0.5, metrics[Spearman], 1.0e-6)
+}
+
+func TestHTTPModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text  string `json:"text"`
+			Text2 string `json:"text2"`
+			Debug bool   `json:"debug"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		response := map[string]interface{}{"class": "LABEL_1"}
+		if body.Debug {
+			response["logits"] = []mat.Float{3.5}
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	model := &HTTPModel{URL: server.URL, LabelMap: map[string]string{"LABEL_1": "1"}}
+	prediction, err := model.Predict("a", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, Prediction{Label: "1"}, prediction)
+
+	model = &HTTPModel{URL: server.URL, Regression: true}
+	prediction, err = model.Predict("a", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, Prediction{Score: 3.5}, prediction)
+}
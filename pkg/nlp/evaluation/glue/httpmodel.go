@@ -0,0 +1,70 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"net/http"
+)
+
+var _ Model = &HTTPModel{}
+
+// HTTPModel is a Model served over HTTP by the "classify" endpoint of the BERT server, which
+// classifies the pairs of texts as well.
+type HTTPModel struct {
+	// URL is the URL of the classification endpoint.
+	URL string
+	// LabelMap maps the labels of the model to the labels of the task (e.g. "LABEL_0" to "0");
+	// the labels which are not in the map are left unchanged.
+	LabelMap map[string]string
+	// Regression is true if the model has a single output, whose logit is the predicted score.
+	Regression bool
+	// Client is the HTTP client of the requests (http.DefaultClient if nil).
+	Client *http.Client
+}
+
+// Predict returns the predicted class (or score) of the texts.
+func (m *HTTPModel) Predict(text1, text2 string) (Prediction, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"text":  text1,
+		"text2": text2,
+		"debug": m.Regression,
+	})
+	if err != nil {
+		return Prediction{}, err
+	}
+	client := m.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(m.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return Prediction{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Prediction{}, fmt.Errorf("glue: %s: status code %d", m.URL, resp.StatusCode)
+	}
+	var response struct {
+		Class  string      `json:"class"`
+		Logits []mat.Float `json:"logits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return Prediction{}, err
+	}
+	if m.Regression {
+		if len(response.Logits) != 1 {
+			return Prediction{}, fmt.Errorf("glue: expected a single logit, found %d", len(response.Logits))
+		}
+		return Prediction{Score: response.Logits[0]}, nil
+	}
+	if label, ok := m.LabelMap[response.Class]; ok {
+		return Prediction{Label: label}, nil
+	}
+	return Prediction{Label: response.Class}, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glue
+
+// Metric is the name of a metric of a task.
+type Metric string
+
+const (
+	// Accuracy is the fraction of the examples whose label is predicted correctly.
+	Accuracy Metric = "accuracy"
+	// F1 is the F1 score of the positive label.
+	F1 Metric = "f1"
+	// MCC is the Matthews correlation coefficient of the positive label.
+	MCC Metric = "mcc"
+	// Pearson is the Pearson correlation of the predicted scores.
+	Pearson Metric = "pearson"
+	// Spearman is the Spearman rank correlation of the predicted scores.
+	Spearman Metric = "spearman"
+)
+
+// Task describes a task of the GLUE benchmark, and the format of its tab-separated files.
+type Task struct {
+	Name string
+	// DevFile is the name of the development set file, within the directory of the task.
+	DevFile string
+	// Header is true if the first line of the files is a header.
+	Header bool
+	// Text1Column and Text2Column are the columns of the texts; Text2Column is -1 for the
+	// single-sentence tasks.
+	Text1Column int
+	Text2Column int
+	// LabelColumn is the column of the label (or of the score); a negative value counts from the end.
+	LabelColumn int
+	// Labels are the labels of a classification task, or nil for a regression task.
+	Labels []string
+	// PositiveLabel is the label whose F1 and MCC are measured.
+	PositiveLabel string
+	Metrics       []Metric
+}
+
+// IsRegression reports whether the task is a regression task (i.e. STS-B).
+func (t Task) IsRegression() bool {
+	return t.Labels == nil
+}
+
+// Tasks are the tasks of the GLUE benchmark, by name, as distributed by the GLUE download script.
+var Tasks = map[string]Task{
+	"cola": {
+		Name: "CoLA", DevFile: "CoLA/dev.tsv", Header: false,
+		Text1Column: 3, Text2Column: -1, LabelColumn: 1,
+		Labels: []string{"0", "1"}, PositiveLabel: "1",
+		Metrics: []Metric{MCC},
+	},
+	"sst-2": {
+		Name: "SST-2", DevFile: "SST-2/dev.tsv", Header: true,
+		Text1Column: 0, Text2Column: -1, LabelColumn: 1,
+		Labels: []string{"0", "1"}, PositiveLabel: "1",
+		Metrics: []Metric{Accuracy},
+	},
+	"mrpc": {
+		Name: "MRPC", DevFile: "MRPC/dev.tsv", Header: true,
+		Text1Column: 3, Text2Column: 4, LabelColumn: 0,
+		Labels: []string{"0", "1"}, PositiveLabel: "1",
+		Metrics: []Metric{Accuracy, F1},
+	},
+	"sts-b": {
+		Name: "STS-B", DevFile: "STS-B/dev.tsv", Header: true,
+		Text1Column: 7, Text2Column: 8, LabelColumn: -1,
+		Metrics: []Metric{Pearson, Spearman},
+	},
+	"qqp": {
+		Name: "QQP", DevFile: "QQP/dev.tsv", Header: true,
+		Text1Column: 3, Text2Column: 4, LabelColumn: 5,
+		Labels: []string{"0", "1"}, PositiveLabel: "1",
+		Metrics: []Metric{Accuracy, F1},
+	},
+	"mnli": {
+		Name: "MNLI", DevFile: "MNLI/dev_matched.tsv", Header: true,
+		Text1Column: 8, Text2Column: 9, LabelColumn: -1,
+		Labels:  []string{"contradiction", "entailment", "neutral"},
+		Metrics: []Metric{Accuracy},
+	},
+	"mnli-mm": {
+		Name: "MNLI-MM", DevFile: "MNLI/dev_mismatched.tsv", Header: true,
+		Text1Column: 8, Text2Column: 9, LabelColumn: -1,
+		Labels:  []string{"contradiction", "entailment", "neutral"},
+		Metrics: []Metric{Accuracy},
+	},
+	"qnli": {
+		Name: "QNLI", DevFile: "QNLI/dev.tsv", Header: true,
+		Text1Column: 1, Text2Column: 2, LabelColumn: 3,
+		Labels:  []string{"entailment", "not_entailment"},
+		Metrics: []Metric{Accuracy},
+	},
+	"rte": {
+		Name: "RTE", DevFile: "RTE/dev.tsv", Header: true,
+		Text1Column: 1, Text2Column: 2, LabelColumn: 3,
+		Labels:  []string{"entailment", "not_entailment"},
+		Metrics: []Metric{Accuracy},
+	},
+	"wnli": {
+		Name: "WNLI", DevFile: "WNLI/dev.tsv", Header: true,
+		Text1Column: 1, Text2Column: 2, LabelColumn: 3,
+		Labels:  []string{"0", "1"},
+		Metrics: []Metric{Accuracy},
+	},
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package squad
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
+	"net/http"
+)
+
+var (
+	_ Model = &HTTPModel{}
+	_ Model = &BERTModel{}
+)
+
+// HTTPModel is a Model served over HTTP by the "answer" endpoint of the BERT server.
+type HTTPModel struct {
+	// URL is the URL of the question-answering endpoint.
+	URL string
+	// MinConfidence is the confidence below which the best answer is discarded, so that the
+	// question is considered impossible (SQuAD v2.0).
+	MinConfidence float64
+	// Client is the HTTP client of the requests (http.DefaultClient if nil).
+	Client *http.Client
+}
+
+// Answer returns the best answer of the server, or an empty string if there is none.
+func (m *HTTPModel) Answer(question, passage string) (string, error) {
+	body, err := json.Marshal(bert.QABody{Question: question, Passage: passage})
+	if err != nil {
+		return "", err
+	}
+	client := m.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(m.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("squad: %s: status code %d", m.URL, resp.StatusCode)
+	}
+	var response bert.QuestionAnsweringResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", err
+	}
+	if len(response.Answers) == 0 || float64(response.Answers[0].Confidence) < m.MinConfidence {
+		return "", nil
+	}
+	return response.Answers[0].Text, nil
+}
+
+// BERTModel is a Model answering the questions in-process, with the question-answering of a
+// BERT server which is not necessarily started.
+type BERTModel struct {
+	Server *bert.Server
+	// MinConfidence is the confidence below which the best answer is discarded, so that the
+	// question is considered impossible (SQuAD v2.0).
+	MinConfidence float64
+}
+
+// Answer returns the best answer of the model, or an empty string if there is none.
+func (m *BERTModel) Answer(question, passage string) (string, error) {
+	reply, err := m.Server.Answer(context.Background(), &grpcapi.AnswerRequest{Question: question, Passage: passage})
+	if err != nil {
+		return "", err
+	}
+	if len(reply.Answers) == 0 || reply.Answers[0].Confidence < m.MinConfidence {
+		return "", nil
+	}
+	return reply.Answers[0].Text, nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package squad evaluates the extractive question-answering models on the SQuAD v1.1 and v2.0
+// datasets (https://rajpurkar.github.io/SQuAD-explorer), computing the exact match and F1 scores
+// as the official evaluation script, so that the converted models can be regression tested.
+package squad
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Question is a question of the dataset, with its context and its gold answers.
+type Question struct {
+	ID       string
+	Question string
+	Context  string
+	// Answers are the gold answers; they are empty if the question is impossible (SQuAD v2.0).
+	Answers []string
+}
+
+// IsImpossible reports whether the question has no answer in its context.
+func (q Question) IsImpossible() bool {
+	return len(q.Answers) == 0
+}
+
+// Model is a question-answering model evaluated on the dataset.
+type Model interface {
+	// Answer returns the answer to the question found in the passage (its context), or an empty
+	// string if there is no answer.
+	Answer(question, passage string) (string, error)
+}
+
+// ModelFunc is an adapter to allow the use of ordinary functions as a Model.
+type ModelFunc func(question, passage string) (string, error)
+
+// Answer calls f(question, passage).
+func (f ModelFunc) Answer(question, passage string) (string, error) {
+	return f(question, passage)
+}
+
+// Result contains the metrics of the evaluation, as percentages, with the same names of the
+// official evaluation script. The HasAns and NoAns metrics are only measured on SQuAD v2.0.
+type Result struct {
+	Exact       float64 `json:"exact"`
+	F1          float64 `json:"f1"`
+	Total       int     `json:"total"`
+	HasAnsExact float64 `json:"HasAns_exact,omitempty"`
+	HasAnsF1    float64 `json:"HasAns_f1,omitempty"`
+	HasAnsTotal int     `json:"HasAns_total,omitempty"`
+	NoAnsExact  float64 `json:"NoAns_exact,omitempty"`
+	NoAnsF1     float64 `json:"NoAns_f1,omitempty"`
+	NoAnsTotal  int     `json:"NoAns_total,omitempty"`
+}
+
+type dataset struct {
+	Data []struct {
+		Paragraphs []struct {
+			Context string `json:"context"`
+			QAs     []struct {
+				ID       string `json:"id"`
+				Question string `json:"question"`
+				Answers  []struct {
+					Text string `json:"text"`
+				} `json:"answers"`
+				IsImpossible bool `json:"is_impossible"`
+			} `json:"qas"`
+		} `json:"paragraphs"`
+	} `json:"data"`
+}
+
+// LoadFile loads the questions of a SQuAD dataset from a JSON file.
+func LoadFile(filename string) ([]Question, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Load loads the questions of a SQuAD dataset in JSON format.
+func Load(r io.Reader) ([]Question, error) {
+	var ds dataset
+	if err := json.NewDecoder(r).Decode(&ds); err != nil {
+		return nil, err
+	}
+	var questions []Question
+	for _, article := range ds.Data {
+		for _, paragraph := range article.Paragraphs {
+			for _, qa := range paragraph.QAs {
+				q := Question{ID: qa.ID, Question: qa.Question, Context: paragraph.Context}
+				if !qa.IsImpossible {
+					for _, answer := range qa.Answers {
+						q.Answers = append(q.Answers, answer.Text)
+					}
+				}
+				questions = append(questions, q)
+			}
+		}
+	}
+	return questions, nil
+}
+
+// Evaluate returns the metrics of the answers of the model to the questions, and the answers by
+// question ID. It stops at the first error of the model.
+func Evaluate(questions []Question, model Model) (Result, map[string]string, error) {
+	predictions := make(map[string]string, len(questions))
+	for _, q := range questions {
+		answer, err := model.Answer(q.Question, q.Context)
+		if err != nil {
+			return Result{}, nil, err
+		}
+		predictions[q.ID] = answer
+	}
+	return Score(questions, predictions), predictions, nil
+}
+
+// Score returns the metrics of the predicted answers, by question ID. The missing predictions
+// are considered empty answers.
+func Score(questions []Question, predictions map[string]string) Result {
+	var result Result
+	impossible := false
+	for _, q := range questions {
+		prediction := predictions[q.ID]
+		golds := q.Answers
+		if q.IsImpossible() {
+			impossible = true
+			golds = []string{""}
+		}
+		var exact, f1 float64
+		for _, gold := range golds {
+			exact = max(exact, ExactMatch(prediction, gold))
+			f1 = max(f1, F1Score(prediction, gold))
+		}
+		result.Total++
+		result.Exact += exact
+		result.F1 += f1
+		if q.IsImpossible() {
+			result.NoAnsTotal++
+			result.NoAnsExact += exact
+			result.NoAnsF1 += f1
+		} else {
+			result.HasAnsTotal++
+			result.HasAnsExact += exact
+			result.HasAnsF1 += f1
+		}
+	}
+	result.Exact, result.F1 = percentage(result.Exact, result.Total), percentage(result.F1, result.Total)
+	result.HasAnsExact, result.HasAnsF1 = percentage(result.HasAnsExact, result.HasAnsTotal), percentage(result.HasAnsF1, result.HasAnsTotal)
+	result.NoAnsExact, result.NoAnsF1 = percentage(result.NoAnsExact, result.NoAnsTotal), percentage(result.NoAnsF1, result.NoAnsTotal)
+	if !impossible { // SQuAD v1.1
+		result = Result{Exact: result.Exact, F1: result.F1, Total: result.Total}
+	}
+	return result
+}
+
+// ExactMatch returns 1 if the normalized prediction is equal to the normalized gold answer, 0 otherwise.
+func ExactMatch(prediction, gold string) float64 {
+	if Normalize(prediction) == Normalize(gold) {
+		return 1
+	}
+	return 0
+}
+
+// F1Score returns the F1 score of the tokens of the normalized prediction and gold answer.
+func F1Score(prediction, gold string) float64 {
+	predictionTokens := strings.Fields(Normalize(prediction))
+	goldTokens := strings.Fields(Normalize(gold))
+	if len(predictionTokens) == 0 || len(goldTokens) == 0 {
+		if len(predictionTokens) == len(goldTokens) {
+			return 1
+		}
+		return 0
+	}
+	counts := make(map[string]int, len(goldTokens))
+	for _, token := range goldTokens {
+		counts[token]++
+	}
+	common := 0
+	for _, token := range predictionTokens {
+		if counts[token] > 0 {
+			counts[token]--
+			common++
+		}
+	}
+	if common == 0 {
+		return 0
+	}
+	precision := float64(common) / float64(len(predictionTokens))
+	recall := float64(common) / float64(len(goldTokens))
+	return 2 * precision * recall / (precision + recall)
+}
+
+var articles = regexp.MustCompile(`\b(a|an|the)\b`)
+
+// Normalize lower-cases the text, and removes the punctuation, the articles and the extra
+// white spaces, as the official evaluation script.
+func Normalize(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if r <= unicode.MaxASCII && (unicode.IsPunct(r) || unicode.IsSymbol(r)) {
+			return -1
+		}
+		return r
+	}, strings.ToLower(text))
+	text = articles.ReplaceAllString(text, " ")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func percentage(sum float64, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * sum / float64(total)
+}
+
+func max(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package squad
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const squadV2 = `{
+  "version": "v2.0",
+  "data": [{
+    "title": "Cats",
+    "paragraphs": [{
+      "context": "The cat sat on the mat in the kitchen.",
+      "qas": [
+        {"id": "q1", "question": "Where did the cat sit?", "answers": [{"text": "on the mat", "answer_start": 12}, {"text": "the mat", "answer_start": 15}], "is_impossible": false},
+        {"id": "q2", "question": "In which room?", "answers": [{"text": "the kitchen", "answer_start": 27}], "is_impossible": false},
+        {"id": "q3", "question": "What is the name of the cat?", "answers": [], "is_impossible": true}
+      ]
+    }]
+  }]
+}`
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "cat sat on mat", Normalize("The  Cat sat, on a mat!"))
+	assert.Equal(t, "theater", Normalize("the theater"))
+}
+
+func TestF1Score(t *testing.T) {
+	assert.Equal(t, 1.0, F1Score("The mat", "mat"))
+	assert.InDelta(t, 0.666667, F1Score("on the mat", "mat"), 1.0e-6)
+	assert.Equal(t, 0.0, F1Score("kitchen", "mat"))
+	assert.Equal(t, 1.0, F1Score("", ""))
+	assert.Equal(t, 0.0, F1Score("mat", ""))
+	assert.Equal(t, 1.0, ExactMatch("the Mat.", "mat"))
+}
+
+func TestEvaluate(t *testing.T) {
+	questions, err := Load(strings.NewReader(squadV2))
+	assert.NoError(t, err)
+	assert.Len(t, questions, 3)
+	assert.Equal(t, []string{"on the mat", "the mat"}, questions[0].Answers)
+	assert.True(t, questions[2].IsImpossible())
+
+	answers := map[string]string{
+		"Where did the cat sit?":       "mat",
+		"In which room?":               "in the kitchen room",
+		"What is the name of the cat?": "Tom",
+	}
+	result, predictions, err := Evaluate(questions, ModelFunc(func(question, _ string) (string, error) {
+		return answers[question], nil
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "mat", predictions["q1"])
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, 2, result.HasAnsTotal)
+	assert.Equal(t, 1, result.NoAnsTotal)
+	assert.InDelta(t, 100.0/3, result.Exact, 1.0e-6)
+	assert.InDelta(t, 50.0, result.HasAnsExact, 1.0e-6)
+	assert.InDelta(t, 100*(1+0.5)/3, result.F1, 1.0e-6) // F1("in the kitchen room", "the kitchen") = 0.5
+	assert.Equal(t, 0.0, result.NoAnsF1)
+
+	_, _, err = Evaluate(questions, ModelFunc(func(string, string) (string, error) {
+		return "", errors.New("failed")
+	}))
+	assert.Error(t, err)
+}
+
+func TestScoreV1(t *testing.T) {
+	questions := []Question{{ID: "q1", Answers: []string{"mat"}}, {ID: "q2", Answers: []string{"kitchen"}}}
+	result := Score(questions, map[string]string{"q1": "the mat"})
+	assert.Equal(t, Result{Exact: 50, F1: 50, Total: 2}, result)
+}
+
+func TestHTTPModel(t *testing.T) {
+	confidence := 0.9
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"answers": []map[string]interface{}{{"text": "the mat", "confidence": confidence}},
+		})
+	}))
+	defer server.Close()
+
+	model := &HTTPModel{URL: server.URL, MinConfidence: 0.5}
+	answer, err := model.Answer("Where?", "On the mat.")
+	assert.NoError(t, err)
+	assert.Equal(t, "the mat", answer)
+
+	confidence = 0.2
+	answer, err = model.Answer("Where?", "On the mat.")
+	assert.NoError(t, err)
+	assert.Equal(t, "", answer)
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keyphrases
+
+import (
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/basetokenizer"
+	"strings"
+	"unicode"
+)
+
+// CandidateExtractor is implemented by any value that extracts the candidate phrases of a text.
+type CandidateExtractor interface {
+	// Candidates returns the candidate phrases, with their offsets in the text (in runes).
+	Candidates(text string) []tokenizers.StringOffsetsPair
+}
+
+// DefaultStopwords is a short list of English stopwords, used by default by StopwordsCandidates.
+var DefaultStopwords = []string{
+	"a", "about", "above", "after", "again", "against", "all", "also", "am", "an", "and", "any", "are", "as",
+	"at", "be", "because", "been", "before", "being", "below", "between", "both", "but", "by", "can", "could",
+	"did", "do", "does", "doing", "down", "during", "each", "few", "for", "from", "further", "had", "has",
+	"have", "having", "he", "her", "here", "hers", "herself", "him", "himself", "his", "how", "i", "if", "in",
+	"into", "is", "it", "its", "itself", "just", "may", "me", "might", "more", "most", "must", "my", "myself",
+	"no", "nor", "not", "now", "of", "off", "on", "once", "only", "or", "other", "our", "ours", "ourselves",
+	"out", "over", "own", "same", "shall", "she", "should", "so", "some", "such", "than", "that", "the",
+	"their", "theirs", "them", "themselves", "then", "there", "these", "they", "this", "those", "through",
+	"to", "too", "under", "until", "up", "very", "was", "we", "were", "what", "when", "where", "which",
+	"while", "who", "whom", "why", "will", "with", "would", "you", "your", "yours", "yourself", "yourselves",
+}
+
+// StopwordsCandidates extracts as candidates the sequences of words delimited by stopwords and
+// punctuation (as in RAKE, "Automatic keyword extraction from individual documents" by Rose et al., 2010).
+type StopwordsCandidates struct {
+	stopwords map[string]bool
+	maxWords  int
+}
+
+var _ CandidateExtractor = &StopwordsCandidates{}
+
+// NewStopwordsCandidates returns a new StopwordsCandidates, with the given stopwords (DefaultStopwords
+// if none), discarding the sequences longer than maxWords (zero means no limit).
+func NewStopwordsCandidates(maxWords int, stopwords ...string) *StopwordsCandidates {
+	if len(stopwords) == 0 {
+		stopwords = DefaultStopwords
+	}
+	c := &StopwordsCandidates{
+		stopwords: make(map[string]bool, len(stopwords)),
+		maxWords:  maxWords,
+	}
+	for _, word := range stopwords {
+		c.stopwords[strings.ToLower(word)] = true
+	}
+	return c
+}
+
+// Candidates returns the candidate phrases of the text.
+func (c *StopwordsCandidates) Candidates(text string) []tokenizers.StringOffsetsPair {
+	runes := []rune(text)
+	tokens := basetokenizer.New().Tokenize(text)
+	candidates := make([]tokenizers.StringOffsetsPair, 0)
+	var phrase []tokenizers.StringOffsetsPair
+	flush := func() {
+		if len(phrase) > 0 && (c.maxWords == 0 || len(phrase) <= c.maxWords) {
+			candidates = append(candidates, span(runes, phrase[0].Offsets.Start, phrase[len(phrase)-1].Offsets.End))
+		}
+		phrase = nil
+	}
+	for _, token := range tokens {
+		if c.isDelimiter(token.String) || (len(phrase) > 0 && !isSpace(runes[phrase[len(phrase)-1].Offsets.End:token.Offsets.Start])) {
+			flush()
+		}
+		if !c.isDelimiter(token.String) {
+			phrase = append(phrase, token)
+		}
+	}
+	flush()
+	return candidates
+}
+
+// isDelimiter reports whether the token is a stopword, a punctuation sign, or a number.
+func (c *StopwordsCandidates) isDelimiter(token string) bool {
+	if c.stopwords[strings.ToLower(token)] {
+		return true
+	}
+	for _, r := range token {
+		if unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// TaggedToken is a token labeled by a tagger, such as a part-of-speech tagger or a named entity recognizer.
+type TaggedToken struct {
+	tokenizers.StringOffsetsPair
+	Label string
+}
+
+// TaggedCandidates extracts as candidates the sequences of consecutive tokens whose labels are accepted,
+// e.g. the adjectives and nouns for a part-of-speech tagger, or the entities for a named entity recognizer.
+type TaggedCandidates struct {
+	tag    func(text string) []TaggedToken
+	accept func(label string) bool
+}
+
+var _ CandidateExtractor = &TaggedCandidates{}
+
+// NewTaggedCandidates returns a new TaggedCandidates, labeling the texts with the tag function.
+func NewTaggedCandidates(tag func(text string) []TaggedToken, accept func(label string) bool) *TaggedCandidates {
+	return &TaggedCandidates{
+		tag:    tag,
+		accept: accept,
+	}
+}
+
+// AcceptLabels returns a function accepting the given labels only (e.g. "NOUN", "PROPN" and "ADJ").
+func AcceptLabels(labels ...string) func(label string) bool {
+	accepted := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		accepted[label] = true
+	}
+	return func(label string) bool {
+		return accepted[label]
+	}
+}
+
+// AcceptEntities accepts any label of an entity, in the IOB or IOBES schemes (i.e. everything but "O").
+func AcceptEntities(label string) bool {
+	return label != "O" && label != ""
+}
+
+// Candidates returns the candidate phrases of the text.
+func (c *TaggedCandidates) Candidates(text string) []tokenizers.StringOffsetsPair {
+	runes := []rune(text)
+	candidates := make([]tokenizers.StringOffsetsPair, 0)
+	start, end := -1, -1
+	for _, token := range c.tag(text) {
+		if !c.accept(token.Label) || isBeginning(token.Label) {
+			if start >= 0 {
+				candidates = append(candidates, span(runes, start, end))
+			}
+			start = -1
+			if !c.accept(token.Label) {
+				continue
+			}
+		}
+		if start < 0 {
+			start = token.Offsets.Start
+		}
+		end = token.Offsets.End
+	}
+	if start >= 0 {
+		candidates = append(candidates, span(runes, start, end))
+	}
+	return candidates
+}
+
+// isBeginning reports whether the label starts a new entity, in the IOB or IOBES schemes.
+func isBeginning(label string) bool {
+	return strings.HasPrefix(label, "B-") || strings.HasPrefix(label, "S-")
+}
+
+func span(runes []rune, start, end int) tokenizers.StringOffsetsPair {
+	return tokenizers.StringOffsetsPair{
+		String:  string(runes[start:end]),
+		Offsets: tokenizers.OffsetsType{Start: start, End: end},
+	}
+}
+
+func isSpace(runes []rune) bool {
+	for _, r := range runes {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package keyphrases implements an unsupervised keyphrase extraction pipeline: the candidate phrases are
+// extracted from the text (see CandidateExtractor), and ranked by the similarity of their embeddings with
+// the embedding of the whole text, optionally diversifying the results with the Maximal Marginal Relevance.
+//
+// Reference: "Simple Unsupervised Keyphrase Extraction using Sentence Embeddings" by Bennani-Smires et al.,
+// 2018 (https://arxiv.org/pdf/1801.04470.pdf).
+package keyphrases
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"strings"
+)
+
+// Encoder returns the embeddings of the given texts, in the same order.
+// All the embeddings must have the same size.
+type Encoder func(texts []string) []mat.Matrix
+
+// Config provides configuration settings for an Extractor.
+type Config struct {
+	// TopK is the maximum number of phrases returned (zero means all).
+	TopK int
+	// Diversity is the trade-off between the relevance of the phrases and their diversity, from 0.0 (the most
+	// similar phrases to the text are returned) to 1.0 (the phrases are chosen to be different from each other).
+	Diversity mat.Float
+}
+
+// Phrase is a keyphrase extracted from a text.
+type Phrase struct {
+	// Text is the phrase as it first occurs in the text.
+	Text string
+	// Occurrences are the offsets of all the occurrences of the phrase (case-insensitive).
+	Occurrences []tokenizers.OffsetsType
+	// Score is the cosine similarity between the embeddings of the phrase and of the text.
+	Score mat.Float
+}
+
+// Extractor extracts the keyphrases of the texts.
+type Extractor struct {
+	candidates []CandidateExtractor
+	encode     Encoder
+}
+
+// NewExtractor returns a new Extractor, using the candidates of all the given extractors,
+// and the encoder to compute the embeddings of the text and of the phrases.
+func NewExtractor(encode Encoder, candidates ...CandidateExtractor) *Extractor {
+	return &Extractor{
+		candidates: candidates,
+		encode:     encode,
+	}
+}
+
+// Extract returns the keyphrases of the text, from the best to the worst.
+func (e *Extractor) Extract(text string, config Config) []Phrase {
+	phrases := e.collect(text)
+	if len(phrases) == 0 {
+		return phrases
+	}
+	texts := make([]string, len(phrases)+1)
+	texts[0] = text
+	for i, phrase := range phrases {
+		texts[i+1] = phrase.Text
+	}
+	embeddings := e.encode(texts)
+	for i := range phrases {
+		phrases[i].Score = mat.Cosine(embeddings[0], embeddings[i+1])
+	}
+	return selectPhrases(phrases, embeddings[1:], config)
+}
+
+// collect returns the distinct candidate phrases of the text (case-insensitive), in order of occurrence.
+func (e *Extractor) collect(text string) []Phrase {
+	phrases := make([]Phrase, 0)
+	index := make(map[string]int)
+	for _, extractor := range e.candidates {
+		for _, candidate := range extractor.Candidates(text) {
+			key := strings.ToLower(candidate.String)
+			i, ok := index[key]
+			if !ok {
+				i = len(phrases)
+				index[key] = i
+				phrases = append(phrases, Phrase{Text: candidate.String})
+			}
+			if !containsOffsets(phrases[i].Occurrences, candidate.Offsets) {
+				phrases[i].Occurrences = append(phrases[i].Occurrences, candidate.Offsets)
+			}
+		}
+	}
+	return phrases
+}
+
+func containsOffsets(list []tokenizers.OffsetsType, offsets tokenizers.OffsetsType) bool {
+	for _, item := range list {
+		if item == offsets {
+			return true
+		}
+	}
+	return false
+}
+
+// selectPhrases returns the best phrases, with the Maximal Marginal Relevance:
+// next = argmax_c (1 - diversity) * score(c) - diversity * max_s cosine(c, s)
+// where s are the phrases already selected, except for the first one, which is the best by score.
+// With zero diversity, it is the ranking by score.
+func selectPhrases(phrases []Phrase, embeddings []mat.Matrix, config Config) []Phrase {
+	k := config.TopK
+	if k <= 0 || k > len(phrases) {
+		k = len(phrases)
+	}
+	selected := make([]Phrase, 0, k)
+	used := make([]bool, len(phrases))
+	redundancy := make([]mat.Float, len(phrases)) // the max similarity with the selected phrases
+	for len(selected) < k {
+		best := -1
+		var bestValue mat.Float
+		for i, phrase := range phrases {
+			if used[i] {
+				continue
+			}
+			value := phrase.Score // the first phrase is always the most relevant
+			if len(selected) > 0 {
+				value = (1.0-config.Diversity)*phrase.Score - config.Diversity*redundancy[i]
+			}
+			if best < 0 || value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		used[best] = true
+		selected = append(selected, phrases[best])
+		for i := range phrases {
+			if sim := mat.Cosine(embeddings[i], embeddings[best]); !used[i] && (len(selected) == 1 || sim > redundancy[i]) {
+				redundancy[i] = sim
+			}
+		}
+	}
+	return selected
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package keyphrases
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStopwordsCandidates_Candidates(t *testing.T) {
+	text := "The neural networks of spaGO are fast, and the state-of-the-art neural networks are in 2021 very accurate."
+	candidates := NewStopwordsCandidates(3).Candidates(text)
+	assert.Equal(t, []string{
+		"neural networks", "spaGO", "fast", "state-of-the-art neural networks", "accurate",
+	}, tokenizers.GetStrings(candidates))
+	runes := []rune(text)
+	for _, c := range candidates {
+		assert.Equal(t, c.String, string(runes[c.Offsets.Start:c.Offsets.End]))
+	}
+
+	candidates = NewStopwordsCandidates(2).Candidates(text) // the longer sequences are discarded
+	assert.Equal(t, []string{"neural networks", "spaGO", "fast", "accurate"}, tokenizers.GetStrings(candidates))
+
+	candidates = NewStopwordsCandidates(0, "of", "and").Candidates("Bread and butter of the kingdom")
+	assert.Equal(t, []string{"Bread", "butter", "the kingdom"}, tokenizers.GetStrings(candidates))
+}
+
+func TestTaggedCandidates_Candidates(t *testing.T) {
+	text := "Mark Knopfler played in Glasgow Scotland"
+	tag := func(string) []TaggedToken {
+		return []TaggedToken{
+			newTaggedToken("Mark", 0, "B-PER"),
+			newTaggedToken("Knopfler", 5, "E-PER"),
+			newTaggedToken("played", 14, "O"),
+			newTaggedToken("in", 21, "O"),
+			newTaggedToken("Glasgow", 24, "S-LOC"),
+			newTaggedToken("Scotland", 32, "S-LOC"),
+		}
+	}
+	candidates := NewTaggedCandidates(tag, AcceptEntities).Candidates(text)
+	assert.Equal(t, []string{"Mark Knopfler", "Glasgow", "Scotland"}, tokenizers.GetStrings(candidates))
+	assert.Equal(t, tokenizers.OffsetsType{Start: 0, End: 13}, candidates[0].Offsets)
+
+	candidates = NewTaggedCandidates(tag, AcceptLabels("O")).Candidates(text)
+	assert.Equal(t, []string{"played in"}, tokenizers.GetStrings(candidates))
+}
+
+func TestExtractor_Extract(t *testing.T) {
+	text := "Cats and dogs. Dogs and kittens. Cars."
+	vectors := map[string][]mat.Float{
+		text:      {1.0, 0.1, 0.0},
+		"Cats":    {0.9, 0.2, 0.1},
+		"dogs":    {1.0, 0.0, 0.1},
+		"kittens": {0.9, 0.3, 0.0},
+		"Cars":    {0.0, 0.0, 1.0},
+	}
+	encode := func(texts []string) []mat.Matrix {
+		embeddings := make([]mat.Matrix, len(texts))
+		for i, text := range texts {
+			embeddings[i] = mat.NewVecDense(vectors[text])
+		}
+		return embeddings
+	}
+	extractor := NewExtractor(encode, NewStopwordsCandidates(3))
+
+	phrases := extractor.Extract(text, Config{})
+	assert.Equal(t, []string{"dogs", "Cats", "kittens", "Cars"}, phraseTexts(phrases))
+	assert.Equal(t, []tokenizers.OffsetsType{{Start: 9, End: 13}, {Start: 15, End: 19}}, phrases[0].Occurrences)
+	assert.InDelta(t, mat.Cosine(mat.NewVecDense(vectors[text]), mat.NewVecDense(vectors["dogs"])), phrases[0].Score, 1.0e-6)
+	for i := 1; i < len(phrases); i++ {
+		assert.True(t, phrases[i-1].Score >= phrases[i].Score)
+	}
+
+	phrases = extractor.Extract(text, Config{TopK: 2})
+	assert.Equal(t, []string{"dogs", "Cats"}, phraseTexts(phrases))
+
+	// with the maximal diversity, the second phrase is the most different from the first one
+	phrases = extractor.Extract(text, Config{TopK: 2, Diversity: 1.0})
+	assert.Equal(t, []string{"dogs", "Cars"}, phraseTexts(phrases))
+
+	assert.Empty(t, extractor.Extract("and the", Config{}))
+}
+
+func newTaggedToken(text string, start int, label string) TaggedToken {
+	return TaggedToken{
+		StringOffsetsPair: tokenizers.StringOffsetsPair{
+			String:  text,
+			Offsets: tokenizers.OffsetsType{Start: start, End: start + len(text)},
+		},
+		Label: label,
+	}
+}
+
+func phraseTexts(phrases []Phrase) []string {
+	texts := make([]string, len(phrases))
+	for i, phrase := range phrases {
+		texts[i] = phrase.Text
+	}
+	return texts
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package perturbation
+
+import (
+	"errors"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/sentencesplitter"
+	"sort"
+)
+
+// ErrSingleSentence is returned when the text of a counterfactual analysis has less than two sentences.
+var ErrSingleSentence = errors.New("perturbation: the text must have at least two sentences")
+
+// CounterfactualConfig provides configuration settings for a SentenceCounterfactuals analysis.
+type CounterfactualConfig struct {
+	// Splitter splits the text into sentences (a sentencesplitter.SentenceSplitter if nil).
+	Splitter tokenizers.Tokenizer
+	// Workers is the number of perturbed texts classified concurrently (1 if not positive).
+	Workers int
+}
+
+// Sentence is a sentence of the analyzed text.
+type Sentence struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	// Deltas are the decreases of the confidence of each class when the sentence is removed.
+	Deltas map[string]mat.Float `json:"deltas"`
+}
+
+// Counterfactual reports how the confidence of a class changes when the sentence with the highest
+// impact on it is removed.
+type Counterfactual struct {
+	Class string `json:"class"`
+	// Confidence is the confidence of the class for the whole text, and CounterfactualConfidence
+	// the one without the sentence.
+	Confidence               mat.Float `json:"confidence"`
+	CounterfactualConfidence mat.Float `json:"counterfactual_confidence"`
+	// Sentence is the index of the removed sentence, the one whose removal changes the confidence
+	// of the class the most (in absolute value).
+	Sentence int `json:"sentence"`
+	// Delta is the decrease of the confidence: positive if the sentence supports the class,
+	// negative if it opposes it.
+	Delta mat.Float `json:"delta"`
+}
+
+// CounterfactualAnalysis is the result of a SentenceCounterfactuals analysis.
+type CounterfactualAnalysis struct {
+	Sentences []Sentence `json:"sentences"`
+	// Counterfactuals are the counterfactuals of the classes, by decreasing confidence.
+	Counterfactuals []Counterfactual `json:"counterfactuals"`
+}
+
+// SentenceCounterfactuals analyzes the classification of a multi-sentence text, e.g. a long
+// document, by classifying it again without each of its sentences. For each class, it reports
+// how the confidence changes when the sentence with the highest impact on the class is removed.
+// The offsets of the sentences are in runes.
+// The classifier is called once for the text, and once for each sentence.
+func SentenceCounterfactuals(text string, classify Classifier, config CounterfactualConfig) (*CounterfactualAnalysis, error) {
+	splitter := config.Splitter
+	if splitter == nil {
+		splitter = sentencesplitter.New()
+	}
+	sentences := splitter.Tokenize(text)
+	if len(sentences) < 2 {
+		return nil, ErrSingleSentence
+	}
+
+	scores, err := classify(text)
+	if err != nil {
+		return nil, err
+	}
+	perturbed, err := perturb([]rune(text), tokenizers.GetOffsets(sentences), "", config.Workers, classify)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &CounterfactualAnalysis{
+		Sentences:       make([]Sentence, len(sentences)),
+		Counterfactuals: make([]Counterfactual, 0, len(scores)),
+	}
+	for i, sentence := range sentences {
+		analysis.Sentences[i] = Sentence{
+			Text:   sentence.String,
+			Start:  sentence.Offsets.Start,
+			End:    sentence.Offsets.End,
+			Deltas: make(map[string]mat.Float, len(scores)),
+		}
+		for class, confidence := range scores {
+			analysis.Sentences[i].Deltas[class] = confidence - perturbed[i][class]
+		}
+	}
+	for class, confidence := range scores {
+		best := 0
+		for i, sentence := range analysis.Sentences {
+			if mat.Abs(sentence.Deltas[class]) > mat.Abs(analysis.Sentences[best].Deltas[class]) {
+				best = i
+			}
+		}
+		analysis.Counterfactuals = append(analysis.Counterfactuals, Counterfactual{
+			Class:                    class,
+			Confidence:               confidence,
+			CounterfactualConfidence: perturbed[best][class],
+			Sentence:                 best,
+			Delta:                    analysis.Sentences[best].Deltas[class],
+		})
+	}
+	sort.Slice(analysis.Counterfactuals, func(i, j int) bool {
+		a, b := analysis.Counterfactuals[i], analysis.Counterfactuals[j]
+		if a.Confidence == b.Confidence {
+			return a.Class < b.Class
+		}
+		return a.Confidence > b.Confidence
+	})
+	return analysis, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package perturbation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSentenceCounterfactuals(t *testing.T) {
+	// "sport" grows with the mentions of "goal", "politics" with the ones of "vote"
+	classify := func(text string) (map[string]mat.Float, error) {
+		sport := 0.1 + 0.4*mat.Float(strings.Count(text, "goal"))
+		politics := 0.1 + 0.4*mat.Float(strings.Count(text, "vote"))
+		return map[string]mat.Float{"sport": sport, "politics": politics}, nil
+	}
+	text := "A goal in the match. The weather was fine. A goal and a vote."
+	analysis, err := SentenceCounterfactuals(text, classify, CounterfactualConfig{Workers: 2})
+	assert.NoError(t, err)
+
+	assert.Len(t, analysis.Sentences, 3)
+	assert.Equal(t, "The weather was fine.", analysis.Sentences[1].Text)
+	assert.Equal(t, 21, analysis.Sentences[1].Start)
+	assert.Equal(t, 42, analysis.Sentences[1].End)
+	assert.InDelta(t, 0.4, analysis.Sentences[0].Deltas["sport"], 1.0e-6)
+	assert.InDelta(t, 0, analysis.Sentences[0].Deltas["politics"], 1.0e-6)
+	assert.InDelta(t, 0, analysis.Sentences[1].Deltas["sport"], 1.0e-6)
+
+	assert.Len(t, analysis.Counterfactuals, 2)
+	sport, politics := analysis.Counterfactuals[0], analysis.Counterfactuals[1]
+	assert.Equal(t, "sport", sport.Class)
+	assert.InDelta(t, 0.9, sport.Confidence, 1.0e-6)
+	assert.InDelta(t, 0.5, sport.CounterfactualConfidence, 1.0e-6)
+	assert.Equal(t, 0, sport.Sentence) // the first sentence with the most impact
+	assert.InDelta(t, 0.4, sport.Delta, 1.0e-6)
+	assert.Equal(t, "politics", politics.Class)
+	assert.Equal(t, 2, politics.Sentence)
+	assert.InDelta(t, 0.1, politics.CounterfactualConfidence, 1.0e-6)
+}
+
+func TestSentenceCounterfactualsSingleSentence(t *testing.T) {
+	_, err := SentenceCounterfactuals("A goal in the match.", keywordClassifier, CounterfactualConfig{})
+	assert.Equal(t, ErrSingleSentence, err)
+}
@@ -0,0 +1,201 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package perturbation explains the decisions of any text classifier by re-classifying perturbed
+// versions of the input, without access to the model internals (e.g. the gradients). It works
+// with any model, including the zero-shot classifiers, at the cost of one classification per
+// perturbation.
+package perturbation
+
+import (
+	"errors"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/basetokenizer"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrUnknownClass is returned when the class to explain is not one of the classes of the classifier.
+var ErrUnknownClass = errors.New("perturbation: unknown class")
+
+// Classifier returns the confidence of each class for the text.
+type Classifier func(text string) (map[string]mat.Float, error)
+
+// Config provides configuration settings for an Occlusion explanation.
+type Config struct {
+	// Class is the class to explain; the predicted one if empty.
+	Class string
+	// Tokenizer splits the text into the occluded tokens (a basetokenizer.BaseTokenizer if nil).
+	Tokenizer tokenizers.Tokenizer
+	// Window is the number of consecutive tokens occluded together (1 if not positive).
+	Window int
+	// Mask, if not empty, replaces the occluded tokens (e.g. the mask token of the model);
+	// otherwise, they are removed from the text.
+	Mask string
+	// Workers is the number of perturbed texts classified concurrently (1 if not positive).
+	Workers int
+}
+
+// Token is a token of the explained text, with its importance for the class.
+type Token struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	// Delta is the decrease of the confidence of the class when the token is occluded, averaged
+	// over the windows which include it: positive values support the class, negative ones oppose it.
+	Delta mat.Float `json:"delta"`
+}
+
+// Explanation is the explanation of the classification of a text.
+type Explanation struct {
+	// Class is the explained class, and Confidence its confidence for the original text.
+	Class      string    `json:"class"`
+	Confidence mat.Float `json:"confidence"`
+	Tokens     []Token   `json:"tokens"`
+}
+
+// Occlusion explains the classification of the text by occluding its tokens, a window at a time,
+// and measuring how much the confidence of the class decreases (Zeiler and Fergus, 2014,
+// https://arxiv.org/abs/1311.2901). The offsets of the tokens are in runes.
+// The classifier is called once for the text, and once for each window.
+func Occlusion(text string, classify Classifier, config Config) (*Explanation, error) {
+	tokenizer := config.Tokenizer
+	if tokenizer == nil {
+		tokenizer = basetokenizer.New()
+	}
+	window := config.Window
+	if window < 1 {
+		window = 1
+	}
+
+	scores, err := classify(text)
+	if err != nil {
+		return nil, err
+	}
+	class, err := explainedClass(scores, config.Class)
+	if err != nil {
+		return nil, err
+	}
+	confidence := scores[class]
+
+	tokens := tokenizer.Tokenize(text)
+	if window > len(tokens) {
+		window = len(tokens)
+	}
+	numWindows := 0
+	if len(tokens) > 0 {
+		numWindows = len(tokens) - window + 1
+	}
+	runes := []rune(text)
+	spans := make([]tokenizers.OffsetsType, numWindows)
+	for i := range spans {
+		spans[i] = tokenizers.OffsetsType{Start: tokens[i].Offsets.Start, End: tokens[i+window-1].Offsets.End}
+	}
+	perturbed, err := perturb(runes, spans, config.Mask, config.Workers, classify)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &Explanation{
+		Class:      class,
+		Confidence: confidence,
+		Tokens:     make([]Token, len(tokens)),
+	}
+	for i, token := range tokens {
+		explanation.Tokens[i] = Token{
+			Text:  token.String,
+			Start: token.Offsets.Start,
+			End:   token.Offsets.End,
+		}
+		var sum mat.Float
+		n := 0
+		for w := i - window + 1; w <= i; w++ {
+			if w >= 0 && w < numWindows {
+				sum += confidence - perturbed[w][class]
+				n++
+			}
+		}
+		if n > 0 {
+			explanation.Tokens[i].Delta = sum / mat.Float(n)
+		}
+	}
+	return explanation, nil
+}
+
+// explainedClass returns the given class, if not empty, or the one with the highest confidence.
+func explainedClass(scores map[string]mat.Float, class string) (string, error) {
+	if class != "" {
+		if _, ok := scores[class]; !ok {
+			return "", fmt.Errorf("%w %q", ErrUnknownClass, class)
+		}
+		return class, nil
+	}
+	if len(scores) == 0 {
+		return "", fmt.Errorf("perturbation: no class to explain")
+	}
+	first := true
+	for c, score := range scores {
+		// the ties are broken by the name, so that the choice does not depend on the map order
+		if first || score > scores[class] || (score == scores[class] && c < class) {
+			class, first = c, false
+		}
+	}
+	return class, nil
+}
+
+// perturb classifies the text without each span (or with the span replaced by the mask),
+// concurrently on at most the given number of workers, and returns the scores in the order of
+// the spans. It returns the first error encountered, if any.
+func perturb(runes []rune, spans []tokenizers.OffsetsType, mask string, workers int, classify Classifier) ([]map[string]mat.Float, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(spans) {
+		workers = len(spans)
+	}
+	results := make([]map[string]mat.Float, len(spans))
+	errs := make([]error, len(spans))
+	var wg sync.WaitGroup
+	indices := make(chan int)
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i], errs[i] = classify(remove(runes, spans[i], mask))
+			}
+		}()
+	}
+	for i := range spans {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// remove returns the text without the span, or with the span replaced by the mask if not empty.
+// The white-spaces left around a removed span are collapsed.
+func remove(runes []rune, span tokenizers.OffsetsType, mask string) string {
+	before, after := string(runes[:span.Start]), string(runes[span.End:])
+	if mask != "" {
+		return before + mask + after
+	}
+	if after == "" {
+		return strings.TrimRightFunc(before, unicode.IsSpace)
+	}
+	if before == "" || strings.TrimRightFunc(before, unicode.IsSpace) != before {
+		after = strings.TrimLeftFunc(after, unicode.IsSpace)
+	}
+	return before + after
+}
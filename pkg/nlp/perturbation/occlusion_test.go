@@ -0,0 +1,83 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package perturbation
+
+import (
+	"errors"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// keywordClassifier is "positive" with a confidence growing with the occurrences of "good",
+// "negative" otherwise.
+func keywordClassifier(text string) (map[string]mat.Float, error) {
+	p := mat.Float(0.2 + 0.3*mat.Float(strings.Count(text, "good")))
+	if p > 1 {
+		p = 1
+	}
+	return map[string]mat.Float{"positive": p, "negative": 1 - p}, nil
+}
+
+func TestOcclusion(t *testing.T) {
+	explanation, err := Occlusion("a good and good film", keywordClassifier, Config{Workers: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, "positive", explanation.Class)
+	assert.InDelta(t, 0.8, explanation.Confidence, 1.0e-6)
+	assert.Len(t, explanation.Tokens, 5)
+	assert.Equal(t, Token{Text: "good", Start: 2, End: 6, Delta: explanation.Tokens[1].Delta}, explanation.Tokens[1])
+	for i, expected := range []mat.Float{0, 0.3, 0, 0.3, 0} {
+		assert.InDelta(t, expected, explanation.Tokens[i].Delta, 1.0e-6)
+	}
+
+	explanation, err = Occlusion("a good and good film", keywordClassifier, Config{Class: "negative", Window: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "negative", explanation.Class)
+	// the windows are "a good", "good and", "and good", "good film"
+	for i, expected := range []mat.Float{-0.3, -0.3, -0.3, -0.3, -0.3} {
+		assert.InDelta(t, expected, explanation.Tokens[i].Delta, 1.0e-6)
+	}
+}
+
+func TestOcclusionMask(t *testing.T) {
+	var texts []string
+	classify := func(text string) (map[string]mat.Float, error) {
+		texts = append(texts, text)
+		return keywordClassifier(text)
+	}
+	_, err := Occlusion("good film", classify, Config{Mask: "<mask>"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"good film", "<mask> film", "good <mask>"}, texts)
+}
+
+func TestOcclusionErrors(t *testing.T) {
+	_, err := Occlusion("good film", keywordClassifier, Config{Class: "neutral"})
+	assert.True(t, errors.Is(err, ErrUnknownClass))
+
+	failing := errors.New("failing")
+	calls := 0
+	_, err = Occlusion("good film", func(text string) (map[string]mat.Float, error) {
+		calls++
+		if calls > 1 {
+			return nil, failing
+		}
+		return keywordClassifier(text)
+	}, Config{})
+	assert.Equal(t, failing, err)
+
+	explanation, err := Occlusion("", keywordClassifier, Config{Window: 3})
+	assert.NoError(t, err)
+	assert.Empty(t, explanation.Tokens)
+}
+
+func TestRemove(t *testing.T) {
+	runes := []rune("the cat sat")
+	assert.Equal(t, "cat sat", remove(runes, tokenizers.OffsetsType{Start: 0, End: 3}, ""))
+	assert.Equal(t, "the sat", remove(runes, tokenizers.OffsetsType{Start: 4, End: 7}, ""))
+	assert.Equal(t, "the cat", remove(runes, tokenizers.OffsetsType{Start: 8, End: 11}, ""))
+	assert.Equal(t, "the [MASK] sat", remove(runes, tokenizers.OffsetsType{Start: 4, End: 7}, "[MASK]"))
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package relations implements a relation extraction model, which classifies the relation between each
+// ordered pair of entities of a text (e.g. the entities found by a named entity recognizer), given the
+// contextual encodings of its tokens (e.g. the output of a BERT encoder).
+//
+// An entity is represented by the average of the encodings of its tokens and by an embedding of its type.
+// The representation of a pair is the concatenation of the representations of the two entities and of the
+// element-wise product of their encodings, which a feed-forward network maps into the relation labels.
+package relations
+
+import (
+	"encoding/gob"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+)
+
+var (
+	_ nn.Model = &Model{}
+)
+
+// Entity is a typed sequence of tokens, from Start to End (both inclusive).
+type Entity struct {
+	Start int
+	End   int
+	Label string
+}
+
+// Relation is a typed relation between two entities, from the Head to the Tail.
+type Relation struct {
+	// Head is the index of the first entity of the relation.
+	Head int
+	// Tail is the index of the second entity of the relation.
+	Tail int
+	// Label is the type of the relation.
+	Label string
+	// Confidence is the probability of the label.
+	Confidence mat.Float
+}
+
+// Config provides configuration settings for a relation extraction Model.
+type Config struct {
+	// InputSize is the size of the encodings of the tokens.
+	InputSize int
+	// HiddenSize is the size of the hidden layer of the classifier.
+	HiddenSize int
+	// Labels are the types of the relations. The first label is assigned to the pairs
+	// of entities without any relation (e.g. "no_relation").
+	Labels []string
+	// EntityLabels are the types of the entities; the unknown types share the same embedding.
+	EntityLabels []string
+	// TypeSize is the size of the embeddings of the types of the entities (zero means no embeddings).
+	TypeSize int
+}
+
+// Model contains the serializable parameters.
+type Model struct {
+	nn.BaseModel
+	Config Config
+	// Types are the embeddings of the entity labels, plus the embedding of the unknown types.
+	Types  []nn.Param `spago:"type:weights"`
+	Hidden *linear.Model
+	Output *linear.Model
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new model with parameters initialized to zeros.
+func New(config Config) *Model {
+	types := make([]nn.Param, 0)
+	if config.TypeSize > 0 {
+		for i := 0; i <= len(config.EntityLabels); i++ {
+			types = append(types, nn.NewParam(mat.NewEmptyVecDense(config.TypeSize)))
+		}
+	}
+	return &Model{
+		Config: config,
+		Types:  types,
+		Hidden: linear.New(3*config.InputSize+2*config.TypeSize, config.HiddenSize),
+		Output: linear.New(config.HiddenSize, len(config.Labels)),
+	}
+}
+
+// Forward returns the logits of the relation labels for the given pair of entities.
+func (m *Model) Forward(xs []ag.Node, head, tail Entity) ag.Node {
+	g := m.Graph()
+	h, t := m.pool(xs, head), m.pool(xs, tail)
+	features := []ag.Node{h, t, g.Prod(h, t)}
+	if m.Config.TypeSize > 0 {
+		features = append(features, m.typeOf(head.Label), m.typeOf(tail.Label))
+	}
+	return nn.ToNode(m.Output.Forward(g.ReLU(nn.ToNode(m.Hidden.Forward(g.Concat(features...))))))
+}
+
+// Classify returns the relations between the ordered pairs of distinct entities, except for the pairs
+// whose best label is the first one (no relation), sorted by head and tail.
+func (m *Model) Classify(xs []ag.Node, entities []Entity) []Relation {
+	relations := make([]Relation, 0)
+	for i, head := range entities {
+		for j, tail := range entities {
+			if i == j {
+				continue
+			}
+			probs := floatutils.SoftMax(m.Forward(xs, head, tail).Value().Data())
+			best := floatutils.ArgMax(probs)
+			if best == 0 {
+				continue
+			}
+			relations = append(relations, Relation{
+				Head:       i,
+				Tail:       j,
+				Label:      m.Config.Labels[best],
+				Confidence: probs[best],
+			})
+		}
+	}
+	return relations
+}
+
+// Loss returns the sum of the cross-entropy losses of all the ordered pairs of distinct entities, given
+// the gold relations; the pairs not included in the gold relations have the first label (no relation).
+// It panics if a gold relation has an unknown label.
+func (m *Model) Loss(xs []ag.Node, entities []Entity, gold []Relation) ag.Node {
+	g := m.Graph()
+	labels := make(map[string]int, len(m.Config.Labels))
+	for i, label := range m.Config.Labels {
+		labels[label] = i
+	}
+	targets := make(map[[2]int]int, len(gold))
+	for _, relation := range gold {
+		target, ok := labels[relation.Label]
+		if !ok {
+			panic("relations: unknown relation label " + relation.Label)
+		}
+		targets[[2]int{relation.Head, relation.Tail}] = target
+	}
+	var loss ag.Node
+	for i, head := range entities {
+		for j, tail := range entities {
+			if i == j {
+				continue
+			}
+			logits := m.Forward(xs, head, tail)
+			loss = g.Add(loss, losses.CrossEntropy(g, logits, targets[[2]int{i, j}]))
+		}
+	}
+	return loss
+}
+
+// pool returns the average of the encodings of the tokens of the entity.
+func (m *Model) pool(xs []ag.Node, entity Entity) ag.Node {
+	g := m.Graph()
+	return g.Mean(xs[entity.Start : entity.End+1])
+}
+
+// typeOf returns the embedding of the entity label.
+func (m *Model) typeOf(label string) ag.Node {
+	for i, entityLabel := range m.Config.EntityLabels {
+		if entityLabel == label {
+			return m.Types[i]
+		}
+	}
+	return m.Types[len(m.Config.EntityLabels)]
+}
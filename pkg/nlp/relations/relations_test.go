@@ -0,0 +1,144 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relations
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/ag/gradcheck"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testInputs = [][]mat.Float{
+	{0.9, -0.2, 0.1, 0.4},
+	{-0.5, 0.3, 0.8, -0.1},
+	{0.2, 0.7, -0.6, 0.3},
+	{0.8, -0.1, 0.2, 0.5},
+	{-0.3, -0.8, 0.4, 0.1},
+}
+
+// e.g. "Mark Knopfler born Glasgow Scotland"
+var testEntities = []Entity{
+	{Start: 0, End: 1, Label: "PER"},
+	{Start: 3, End: 3, Label: "LOC"},
+	{Start: 4, End: 4, Label: "LOC"},
+}
+
+var testRelations = []Relation{
+	{Head: 0, Tail: 1, Label: "born_in"},
+	{Head: 1, Tail: 2, Label: "located_in"},
+}
+
+var testConfig = Config{
+	InputSize:    4,
+	HiddenSize:   8,
+	Labels:       []string{"no_relation", "born_in", "located_in"},
+	EntityLabels: []string{"PER", "LOC"},
+	TypeSize:     2,
+}
+
+func TestNew(t *testing.T) {
+	model := New(testConfig)
+	assert.Len(t, model.Types, 3) // plus the unknown type
+	assert.Equal(t, 16, model.Hidden.W.Value().Columns())
+	assert.Equal(t, 3, model.Output.W.Value().Rows())
+
+	model = New(Config{InputSize: 4, HiddenSize: 8, Labels: testConfig.Labels})
+	assert.Empty(t, model.Types)
+	assert.Equal(t, 12, model.Hidden.W.Value().Columns())
+}
+
+func TestModel_Forward(t *testing.T) {
+	model := newTestModel(testConfig)
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	xs := newInputs(g)
+
+	y := proc.Forward(xs, testEntities[0], testEntities[1])
+	assert.Equal(t, 3, y.Value().Size())
+
+	// the unknown types share the same embedding
+	a := proc.Forward(xs, Entity{Start: 2, End: 2, Label: "ORG"}, testEntities[1])
+	b := proc.Forward(xs, Entity{Start: 2, End: 2, Label: "MISC"}, testEntities[1])
+	assert.InDeltaSlice(t, a.Value().Data(), b.Value().Data(), 1.0e-6)
+}
+
+func TestModel_Gradients(t *testing.T) {
+	model := newTestModel(testConfig)
+	f := func(g *ag.Graph, proc nn.Model) ag.Node {
+		return proc.(*Model).Loss(newInputs(g), testEntities, testRelations)
+	}
+	assert.NoError(t, gradcheck.CheckModel(model, f, gradcheck.DefaultConfig))
+}
+
+func TestModel_Training(t *testing.T) {
+	model := newTestModel(testConfig)
+	optimizer := gd.NewOptimizer(adam.New(adam.NewConfig(0.01, 0.9, 0.999, 1.0e-8)), nn.NewDefaultParamsIterator(model))
+
+	var first, last mat.Float
+	for epoch := 0; epoch < 200; epoch++ {
+		g := ag.NewGraph()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+		loss := proc.Loss(newInputs(g), testEntities, testRelations)
+		g.Backward(loss)
+		optimizer.Optimize()
+		if epoch == 0 {
+			first = loss.ScalarValue()
+		}
+		last = loss.ScalarValue()
+	}
+	assert.Less(t, last, first)
+
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	relations := proc.Classify(newInputs(g), testEntities)
+	assert.Len(t, relations, 2)
+	for i, relation := range relations {
+		assert.Equal(t, testRelations[i].Head, relation.Head)
+		assert.Equal(t, testRelations[i].Tail, relation.Tail)
+		assert.Equal(t, testRelations[i].Label, relation.Label)
+		assert.Greater(t, relation.Confidence, mat.Float(0.5))
+	}
+}
+
+func TestModel_Loss(t *testing.T) {
+	model := New(testConfig)
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, model).(*Model)
+	// with zero parameters, the loss of each of the 6 pairs is log(3)
+	loss := proc.Loss(newInputs(g), testEntities, testRelations)
+	assert.InDelta(t, 6*mat.Log(3.0), loss.ScalarValue(), 1.0e-5)
+
+	assert.Panics(t, func() {
+		proc.Loss(newInputs(g), testEntities, []Relation{{Head: 0, Tail: 1, Label: "unknown"}})
+	})
+}
+
+func newTestModel(config Config) *Model {
+	model := New(config)
+	r := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, r)
+		} else {
+			initializers.Uniform(param.Value(), -0.5, 0.5, r)
+		}
+	})
+	return model
+}
+
+func newInputs(g *ag.Graph) []ag.Node {
+	xs := make([]ag.Node, len(testInputs))
+	for i, input := range testInputs {
+		xs[i] = g.NewVariable(mat.NewVecDense(input), false)
+	}
+	return xs
+}
@@ -21,7 +21,6 @@ import (
 	"github.com/nlpodyssey/spago/pkg/nlp/contextualstringembeddings"
 	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
 	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
-	"github.com/nlpodyssey/spago/pkg/utils"
 	"github.com/nlpodyssey/spago/pkg/utils/gopickleutils"
 	"io"
 	"io/ioutil"
@@ -146,7 +145,7 @@ func Convert(modelPath string, flairModelName string) {
 
 	output := path.Join(modelPath, config.ModelFilename)
 	log.Printf("Serializing full model to \"%s\"... ", output)
-	err := utils.SerializeToFile(output, model)
+	err := nn.SaveModel(output, model)
 	if err != nil {
 		panic("error during model serialization.")
 	}
@@ -21,12 +21,12 @@ import (
 	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
 	"github.com/nlpodyssey/spago/pkg/nlp/stackedembeddings"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
-	"github.com/nlpodyssey/spago/pkg/utils"
 	"path/filepath"
 )
 
 var (
-	_ nn.Model = &Model{}
+	_ nn.Model    = &Model{}
+	_ nn.Stateful = &Model{}
 )
 
 // Model implements a sequence labeling model.
@@ -111,7 +111,7 @@ func (m *Model) LoadEmbeddings(config Config, path string, readOnlyEmbeddings bo
 func (m *Model) Load(path string) {
 	file := filepath.Join(path, m.Config.ModelFilename)
 	fmt.Printf("Loading model parameters from `%s`... ", file)
-	err := utils.DeserializeFromFile(file, m)
+	_, err := nn.LoadModel(file, m)
 	if err != nil {
 		panic("error during model deserialization.")
 	}
@@ -139,6 +139,27 @@ func (m *Model) Forward(tokens []tokenizers.StringOffsetsPair) []TokenLabel {
 	return result
 }
 
+// State returns the state of the left-to-right recurrent network of the tagger, which carries the
+// context of a stream from a chunk of tokens to the next, e.g. with an nn.Session (see nn.Stateful).
+func (m *Model) State() []ag.Node {
+	return m.TaggerLayer.BiRNN.Positive.(nn.Stateful).State()
+}
+
+// SetState sets the state of the left-to-right recurrent network of the tagger (see nn.Stateful).
+// All the other recurrent networks are reset, since they only see the current chunk of tokens: the
+// right-to-left network of the tagger, and the character-level language models of the contextual
+// string embeddings.
+func (m *Model) SetState(state []ag.Node) {
+	m.TaggerLayer.BiRNN.Positive.(nn.Stateful).SetState(state)
+	m.TaggerLayer.BiRNN.Negative.(nn.Stateful).SetState(nil)
+	for _, encoder := range m.EmbeddingsLayer.WordsEncoders {
+		if cse, ok := encoder.(*contextualstringembeddings.Model); ok {
+			cse.LeftToRight.SetState(nil)
+			cse.RightToLeft.SetState(nil)
+		}
+	}
+}
+
 // NegativeLogLoss computes the negative log loss with respect to the targets.
 // TODO: it could be more consistent if the targets were the string labels
 func (m *Model) NegativeLogLoss(emissionScores []ag.Node, targets []int) ag.Node {
@@ -0,0 +1,139 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sequencelabeler
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/birnn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/birnncrf"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/crf"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/recurrent/lstm"
+	"github.com/nlpodyssey/spago/pkg/nlp/charlm"
+	"github.com/nlpodyssey/spago/pkg/nlp/contextualstringembeddings"
+	"github.com/nlpodyssey/spago/pkg/nlp/stackedembeddings"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestModel_Session(t *testing.T) {
+	model := newTestModel()
+	chunks := [][]tokenizers.StringOffsetsPair{
+		newTestTokens("ab", "c"),
+		newTestTokens("ca", "b", "cc"),
+		newTestTokens("b"),
+	}
+
+	session := nn.NewSession(model, nn.Inference)
+	defer session.Close()
+
+	var state []mat.Matrix // the state carried to the next chunk
+	var maxID int
+	for i, chunk := range chunks {
+		expectedLabels, expectedState := forwardWithState(model, chunk, state)
+
+		var labels []TokenLabel
+		session.Step(func(proc nn.Stateful) {
+			labels = proc.(*Model).Forward(chunk)
+		})
+		state = copiedValues(session.Processor().State())
+
+		assert.Equal(t, expectedLabels, labels, i)
+		require.Len(t, state, 2)
+		for j := range state {
+			assert.InDeltaSlice(t, expectedState[j].Data(), state[j].Data(), 1.0e-6, i)
+		}
+		if i == 0 {
+			maxID = session.Processor().Graph().MaxID()
+		}
+		assert.Equal(t, maxID, session.Processor().Graph().MaxID(), i) // the graph doesn't grow
+	}
+
+	session.Reset()
+	assert.Nil(t, session.Processor().State())
+	expectedLabels, _ := forwardWithState(model, chunks[0], nil)
+	session.Step(func(proc nn.Stateful) {
+		assert.Equal(t, expectedLabels, proc.(*Model).Forward(chunks[0]))
+	})
+}
+
+// forwardWithState labels the tokens with a new processor, whose left-to-right recurrent network of
+// the tagger starts from the given state, and returns the labels with the final state.
+func forwardWithState(model *Model, tokens []tokenizers.StringOffsetsPair, state []mat.Matrix) ([]TokenLabel, []mat.Matrix) {
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Model)
+	if state != nil {
+		proc.TaggerLayer.BiRNN.Positive.(*lstm.Model).SetState([]ag.Node{
+			g.NewVariable(state[0], false),
+			g.NewVariable(state[1], false),
+		})
+	}
+	labels := proc.Forward(tokens)
+	return labels, copiedValues(proc.State())
+}
+
+func copiedValues(nodes []ag.Node) []mat.Matrix {
+	values := make([]mat.Matrix, len(nodes))
+	for i, n := range nodes {
+		values[i] = n.Value().Clone()
+	}
+	return values
+}
+
+func newTestModel() *Model {
+	terms := []string{"a", "b", "c", " ", "\n", charlm.DefaultUnknownToken}
+	vocab := vocabulary.New(terms)
+	newCharLM := func() *charlm.Model {
+		m := charlm.New(charlm.Config{
+			VocabularySize: len(terms),
+			EmbeddingSize:  4,
+			HiddenSize:     5,
+		})
+		m.Vocabulary = vocab
+		return m
+	}
+	labels := []string{"O", "B-PER", "I-PER"}
+	model := &Model{
+		Config: Config{Labels: labels},
+		EmbeddingsLayer: &stackedembeddings.Model{
+			WordsEncoders: []stackedembeddings.WordsEncoderProcessor{
+				contextualstringembeddings.New(newCharLM(), newCharLM(), contextualstringembeddings.Concat, '\n', ' '),
+			},
+			ProjectionLayer: linear.New(10, 6),
+		},
+		TaggerLayer: birnncrf.New(
+			birnn.New(lstm.New(6, 4), lstm.New(6, 4), birnn.Concat),
+			linear.New(8, len(labels)),
+			crf.New(len(labels)),
+		),
+		Labels: labels,
+	}
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		initializers.Uniform(param.Value(), -1, 1, rndGen)
+	})
+	return model
+}
+
+func newTestTokens(words ...string) []tokenizers.StringOffsetsPair {
+	tokens := make([]tokenizers.StringOffsetsPair, len(words))
+	start := 0
+	for i, word := range words {
+		tokens[i] = tokenizers.StringOffsetsPair{
+			String:  word,
+			Offsets: tokenizers.OffsetsType{Start: start, End: start + len(word)},
+		}
+		start += len(word) + 1
+	}
+	return tokens
+}
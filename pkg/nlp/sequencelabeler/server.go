@@ -6,17 +6,22 @@ package sequencelabeler
 
 import (
 	"net/http"
+	"runtime"
 
+	"github.com/nlpodyssey/spago/pkg/nlp/document"
 	"github.com/nlpodyssey/spago/pkg/nlp/sequencelabeler/grpcapi"
 	"github.com/nlpodyssey/spago/pkg/utils/grpcutils"
 	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"github.com/nlpodyssey/spago/pkg/webui/console"
 	"github.com/nlpodyssey/spago/pkg/webui/ner"
 )
 
 // Server is the spaGO built-in implementation of HTTP and gRPC server for
 // sequence labeling.
 type Server struct {
-	model           *Model
+	model *Model
+	// pipeline splits the texts into sentences, which are labeled independently.
+	pipeline        *document.Pipeline
 	TimeoutSeconds  int
 	MaxRequestBytes int
 
@@ -24,10 +29,17 @@ type Server struct {
 	grpcapi.UnimplementedSequenceLabelerServer
 }
 
+// maxSentencesPerChunk is the number of sentences labeled on the same graph.
+const maxSentencesPerChunk = 16
+
 // NewServer returns a new Server.
 func NewServer(model *Model) *Server {
 	return &Server{
 		model: model,
+		pipeline: document.NewPipeline(nil, document.Config{
+			MaxSentences: maxSentencesPerChunk,
+			Workers:      runtime.NumCPU(),
+		}),
 	}
 }
 
@@ -35,7 +47,9 @@ func NewServer(model *Model) *Server {
 func (s *Server) Start(address, grpcAddress, tlsCert, tlsKey string, tlsDisable bool) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ner-ui", ner.Handler)
+	mux.HandleFunc("/console-ui", console.NewHandler(map[string]string{"ner": "../analyze"}))
 	mux.HandleFunc("/analyze", s.analyze)
+	mux.HandleFunc("/keyphrases", s.extractKeyphrases)
 
 	go httputils.RunHTTPServer(httputils.HTTPServerConfig{
 		Address:         address,
@@ -14,6 +14,7 @@ import (
 
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/document"
 	"github.com/nlpodyssey/spago/pkg/nlp/sequencelabeler/grpcapi"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/basetokenizer"
 )
@@ -91,17 +92,29 @@ func tokensFrom(resp *Response) []*grpcapi.Token {
 	return result
 }
 
+// process labels the text sentence by sentence, so that texts of any length can be processed.
 func (s *Server) process(text string, merge bool) ([]TokenLabel, time.Duration) {
 	start := time.Now()
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
-	tokenized := basetokenizer.New().Tokenize(text)
-	predicted := proc.Forward(tokenized)
-	if merge {
-		predicted = mergeEntities(predicted)
+	chunks := s.pipeline.Split(text)
+	results := make([][]TokenLabel, len(chunks))
+	s.pipeline.Process(chunks, func(i int, chunk document.Chunk) {
+		g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
+		defer g.Clear()
+		for _, sentence := range chunk.Sentences {
+			proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
+			tokenized := document.Align(basetokenizer.New().Tokenize(sentence.String), sentence.Offsets)
+			predicted := proc.Forward(tokenized)
+			if merge {
+				predicted = mergeEntities(predicted)
+			}
+			results[i] = append(results[i], predicted...)
+		}
+	})
+	analysis := make([]TokenLabel, 0)
+	for _, result := range results {
+		analysis = append(analysis, result...)
 	}
-	return predicted, time.Since(start)
+	return analysis, time.Since(start)
 }
 
 func prepareResponse(tokens []TokenLabel, took time.Duration) *Response {
@@ -135,13 +148,18 @@ type Token struct {
 
 // Dump serializes the Response to JSON.
 func (r *Response) Dump(pretty bool) ([]byte, error) {
+	return dump(r, pretty)
+}
+
+// dump serializes the given value to JSON.
+func dump(value interface{}, pretty bool) ([]byte, error) {
 	buf := bytes.NewBufferString("")
 	enc := json.NewEncoder(buf)
 	if pretty {
 		enc.SetIndent("", "    ")
 	}
 	enc.SetEscapeHTML(true)
-	err := enc.Encode(r)
+	err := enc.Encode(value)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,143 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sequencelabeler
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/keyphrases"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/basetokenizer"
+)
+
+// defaultMaxWords is the default maximum number of words of the candidates delimited by stopwords.
+const defaultMaxWords = 3
+
+// KeyphrasesOptionsType provides JSON-serializable options for the keyphrase extraction requests.
+type KeyphrasesOptionsType struct {
+	// TopK is the maximum number of phrases returned (default all).
+	TopK int `json:"topK"`
+	// Diversity, from 0.0 to 1.0, favours the phrases different from each other (default 0.0).
+	Diversity mat.Float `json:"diversity"`
+	// MaxWords is the maximum number of words of the candidates delimited by stopwords (default 3).
+	MaxWords int `json:"maxWords"`
+	// Labels are the labels of the model whose tokens are candidates (default all the entities).
+	// For a part-of-speech model, they are usually the nouns and the adjectives.
+	Labels []string `json:"labels"`
+}
+
+// KeyphrasesBody provides JSON-serializable parameters for keyphrase extraction requests.
+type KeyphrasesBody struct {
+	Options KeyphrasesOptionsType `json:"options"`
+	Text    string                `json:"text"`
+}
+
+// KeyphrasesResponse provides JSON-serializable parameters for keyphrase extraction responses.
+type KeyphrasesResponse struct {
+	Phrases []Keyphrase `json:"phrases"`
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// Keyphrase provides JSON-serializable parameters for a single phrase of the keyphrase extraction responses.
+type Keyphrase struct {
+	Text        string     `json:"text"`
+	Score       mat.Float  `json:"score"`
+	Occurrences []Position `json:"occurrences"`
+}
+
+// Position is the position of a phrase in the text.
+type Position struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func (s *Server) extractKeyphrases(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	var body KeyphrasesBody
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := s.keyphrases(body.Text, body.Options)
+	_, pretty := req.URL.Query()["pretty"]
+	response, err := dump(result, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// keyphrases extracts the keyphrases of the text. The candidates are the phrases labeled by the model,
+// and the phrases delimited by stopwords; they are ranked with the embeddings of the model.
+func (s *Server) keyphrases(text string, options KeyphrasesOptionsType) *KeyphrasesResponse {
+	start := time.Now()
+	accept := keyphrases.AcceptEntities
+	if len(options.Labels) > 0 {
+		accept = keyphrases.AcceptLabels(options.Labels...)
+	}
+	maxWords := options.MaxWords
+	if maxWords == 0 {
+		maxWords = defaultMaxWords
+	}
+	extractor := keyphrases.NewExtractor(
+		s.encodeTexts,
+		keyphrases.NewTaggedCandidates(s.tag, accept),
+		keyphrases.NewStopwordsCandidates(maxWords),
+	)
+	phrases := extractor.Extract(text, keyphrases.Config{
+		TopK:      options.TopK,
+		Diversity: options.Diversity,
+	})
+
+	result := make([]Keyphrase, len(phrases))
+	for i, phrase := range phrases {
+		occurrences := make([]Position, len(phrase.Occurrences))
+		for j, offsets := range phrase.Occurrences {
+			occurrences[j] = Position{Start: offsets.Start, End: offsets.End}
+		}
+		result[i] = Keyphrase{Text: phrase.Text, Score: phrase.Score, Occurrences: occurrences}
+	}
+	return &KeyphrasesResponse{Phrases: result, Took: time.Since(start).Milliseconds()}
+}
+
+// tag labels the text with the model.
+func (s *Server) tag(text string) []keyphrases.TaggedToken {
+	analysis, _ := s.process(text, false)
+	tokens := make([]keyphrases.TaggedToken, len(analysis))
+	for i, token := range analysis {
+		tokens[i] = keyphrases.TaggedToken{StringOffsetsPair: token.StringOffsetsPair, Label: token.Label}
+	}
+	return tokens
+}
+
+// encodeTexts returns the embeddings of the texts, as the average of the embeddings of their words.
+func (s *Server) encodeTexts(texts []string) []mat.Matrix {
+	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	embeddings := make([]mat.Matrix, len(texts))
+	for i, text := range texts {
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
+		words := tokenizers.GetStrings(basetokenizer.New().Tokenize(text))
+		embeddings[i] = g.GetCopiedValue(g.Mean(proc.EmbeddingsLayer.Encode(words)))
+	}
+	return embeddings
+}
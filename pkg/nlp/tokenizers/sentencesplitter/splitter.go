@@ -0,0 +1,192 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sentencesplitter provides a sentence segmenter, which splits a text into sentences.
+//
+// The candidate boundaries are the sentence-final punctuation marks (possibly followed by closing
+// quotes or brackets) followed by a white-space, and the paragraph breaks (blank lines).
+// By default, the candidates are accepted with simple rules, that reject the periods following a
+// known abbreviation or an initial, and those followed by a lowercase word. The decision can be
+// delegated to a model instead, with the WithBoundaryClassifier option.
+package sentencesplitter
+
+import (
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"strings"
+	"unicode"
+)
+
+var _ tokenizers.Tokenizer = &SentenceSplitter{}
+
+// DefaultAbbreviations are the abbreviations registered by default (lowercase, without the final period).
+var DefaultAbbreviations = []string{
+	"mr", "mrs", "ms", "dr", "prof", "sr", "jr", "st", "mt", "vs", "cf", "al", "e.g", "i.e",
+	"inc", "ltd", "co", "corp", "dept", "univ", "no", "nos", "vol", "fig", "figs", "eq", "approx",
+	"jan", "feb", "mar", "apr", "jun", "jul", "aug", "sep", "sept", "oct", "nov", "dec",
+}
+
+// BoundaryClassifier is implemented by the models that decide whether a candidate boundary
+// actually ends a sentence.
+type BoundaryClassifier interface {
+	// IsBoundary reports whether the sentence ends at the position end (exclusive) of the text.
+	IsBoundary(text []rune, end int) bool
+}
+
+// SentenceSplitter splits a text into sentences.
+type SentenceSplitter struct {
+	abbreviations map[string]bool
+	classifier    BoundaryClassifier
+}
+
+// Option allows to configure a new SentenceSplitter with your specific needs.
+type Option func(*SentenceSplitter)
+
+// RegisterAbbreviations is an option to register further abbreviations, after which a period
+// does not end a sentence. They are case-insensitive, and must not include the final period.
+func RegisterAbbreviations(abbreviations ...string) Option {
+	return func(s *SentenceSplitter) {
+		for _, abbr := range abbreviations {
+			s.abbreviations[strings.ToLower(abbr)] = true
+		}
+	}
+}
+
+// WithBoundaryClassifier is an option to decide the candidate boundaries with the given classifier,
+// instead of the rules. The paragraph breaks are always boundaries.
+func WithBoundaryClassifier(classifier BoundaryClassifier) Option {
+	return func(s *SentenceSplitter) {
+		s.classifier = classifier
+	}
+}
+
+// New returns a new sentence splitter ready to use.
+func New(opts ...Option) *SentenceSplitter {
+	s := &SentenceSplitter{
+		abbreviations: make(map[string]bool),
+	}
+	RegisterAbbreviations(DefaultAbbreviations...)(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Tokenize splits the text into sentences, without the surrounding white-spaces.
+// The offsets are the positions of the sentences in the original text, in runes.
+func (s *SentenceSplitter) Tokenize(text string) []tokenizers.StringOffsetsPair {
+	runes := []rune(text)
+	sentences := make([]tokenizers.StringOffsetsPair, 0)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		end, ok := s.boundary(runes, i)
+		if !ok {
+			continue
+		}
+		sentences = appendSentence(sentences, runes, start, end)
+		start = end
+		i = end - 1
+	}
+	return appendSentence(sentences, runes, start, len(runes))
+}
+
+// boundary returns the end of the sentence, if a boundary is found at the position i.
+func (s *SentenceSplitter) boundary(runes []rune, i int) (int, bool) {
+	if runes[i] == '\n' {
+		j := i + 1
+		for j < len(runes) && runes[j] != '\n' && unicode.IsSpace(runes[j]) {
+			j++
+		}
+		return i + 1, j < len(runes) && runes[j] == '\n'
+	}
+	if !isTerminator(runes[i]) {
+		return 0, false
+	}
+	end := i + 1
+	for end < len(runes) && isTerminator(runes[end]) {
+		end++
+	}
+	for end < len(runes) && isCloser(runes[end]) {
+		end++
+	}
+	if end < len(runes) && !unicode.IsSpace(runes[end]) && !isFullWidth(runes[i]) {
+		return 0, false
+	}
+	if s.classifier != nil {
+		return end, s.classifier.IsBoundary(runes, end)
+	}
+	if runes[i] == '.' && end == i+1 && (s.isAbbreviation(runes, i) || isFollowedByLowercase(runes, end)) {
+		return 0, false
+	}
+	return end, true
+}
+
+// isAbbreviation reports whether the period at the position i follows an abbreviation or an initial.
+func (s *SentenceSplitter) isAbbreviation(runes []rune, i int) bool {
+	start := i
+	for start > 0 && !unicode.IsSpace(runes[start-1]) && !isOpener(runes[start-1]) {
+		start--
+	}
+	word := runes[start:i]
+	if len(word) == 1 && unicode.IsUpper(word[0]) {
+		return true
+	}
+	return s.abbreviations[strings.ToLower(string(word))]
+}
+
+func isFollowedByLowercase(runes []rune, end int) bool {
+	for _, r := range runes[end:] {
+		if unicode.IsSpace(r) || isOpener(r) {
+			continue
+		}
+		return unicode.IsLower(r)
+	}
+	return false
+}
+
+// appendSentence appends the span [start, end) of the runes to the sentences, trimming the white-spaces.
+// Empty spans are discarded.
+func appendSentence(sentences []tokenizers.StringOffsetsPair, runes []rune, start, end int) []tokenizers.StringOffsetsPair {
+	for start < end && unicode.IsSpace(runes[start]) {
+		start++
+	}
+	for end > start && unicode.IsSpace(runes[end-1]) {
+		end--
+	}
+	if start == end {
+		return sentences
+	}
+	return append(sentences, tokenizers.StringOffsetsPair{
+		String:  string(runes[start:end]),
+		Offsets: tokenizers.OffsetsType{Start: start, End: end},
+	})
+}
+
+func isTerminator(r rune) bool {
+	switch r {
+	case '.', '!', '?', '…', '。', '！', '？':
+		return true
+	}
+	return false
+}
+
+// isFullWidth reports whether the terminator is used in languages without white-spaces between sentences.
+func isFullWidth(r rune) bool {
+	return r == '。' || r == '！' || r == '？'
+}
+
+func isCloser(r rune) bool {
+	switch r {
+	case '"', '\'', ')', ']', '}', '”', '’', '»':
+		return true
+	}
+	return false
+}
+
+func isOpener(r rune) bool {
+	switch r {
+	case '"', '\'', '(', '[', '{', '“', '‘', '«':
+		return true
+	}
+	return false
+}
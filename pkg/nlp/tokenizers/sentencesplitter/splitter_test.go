@@ -0,0 +1,57 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sentencesplitter
+
+import (
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSentenceSplitter_Tokenize(t *testing.T) {
+	run := func(text string, expected []string, opts ...Option) {
+		t.Run(text, func(t *testing.T) {
+			actual := New(opts...).Tokenize(text)
+			assert.Equal(t, expected, tokenizers.GetStrings(actual))
+			runes := []rune(text)
+			for _, sentence := range actual {
+				assert.Equal(t, sentence.String, string(runes[sentence.Offsets.Start:sentence.Offsets.End]))
+			}
+		})
+	}
+
+	run("", []string{})
+	run("  \n ", []string{})
+	run("Hello world", []string{"Hello world"})
+	run("Hello world. How are you?! I'm fine... Thanks.", []string{
+		"Hello world.", "How are you?!", "I'm fine...", "Thanks.",
+	})
+	run("He said \"Stop.\" Then he left.", []string{"He said \"Stop.\"", "Then he left."})
+	run("Mr. Smith met Dr. Jones at 3.30 p.m. yesterday. J. R. R. Tolkien wrote it.", []string{
+		"Mr. Smith met Dr. Jones at 3.30 p.m. yesterday.", "J. R. R. Tolkien wrote it.",
+	})
+	run("Use tools, e.g. hammers. Or not.", []string{"Use tools, e.g. hammers.", "Or not."})
+	run("A title\n\nThe first paragraph\n \nThe second one", []string{
+		"A title", "The first paragraph", "The second one",
+	})
+	run("A line\nthat continues.", []string{"A line\nthat continues."})
+	run("Ciao à tutti. Età dell'oro.", []string{"Ciao à tutti.", "Età dell'oro."})
+	run("今日は晴れです。明日は雨です。", []string{"今日は晴れです。", "明日は雨です。"})
+	run("Call Acme Ltd. Ask for Bob.", []string{"Call Acme Ltd. Ask for Bob."})
+	run("Call Acme Gmbh. Ask for Bob.", []string{"Call Acme Gmbh. Ask for Bob."}, RegisterAbbreviations("GmbH"))
+}
+
+type testClassifier struct{}
+
+// IsBoundary accepts the candidates followed by a digit only.
+func (testClassifier) IsBoundary(text []rune, end int) bool {
+	return end+1 < len(text) && text[end+1] >= '0' && text[end+1] <= '9'
+}
+
+func TestWithBoundaryClassifier(t *testing.T) {
+	splitter := New(WithBoundaryClassifier(testClassifier{}))
+	actual := splitter.Tokenize("One. Two. 3 four! 5 six\n\nseven")
+	assert.Equal(t, []string{"One. Two.", "3 four!", "5 six", "seven"}, tokenizers.GetStrings(actual))
+}
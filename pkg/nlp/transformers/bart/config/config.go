@@ -55,6 +55,7 @@ type Config struct {
 	NormalizeBefore            bool              `json:"normalize_before"`
 	NormalizeEmbedding         bool              `json:"normalize_embedding"`
 	NumHiddenLayers            int               `json:"num_hidden_layers"`
+	NumKeyValueHeads           int               `json:"num_key_value_heads"` // only to refuse the GQA checkpoints
 	OutputPast                 bool              `json:"output_past"`
 	PadTokenID                 int               `json:"pad_token_id"`
 	ScaleEmbedding             bool              `json:"scale_embedding"`
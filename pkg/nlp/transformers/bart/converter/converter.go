@@ -20,7 +20,6 @@ import (
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/head/conditionalgeneration"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/head/sequenceclassification"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/positionalencoder/learnedpositionalencoder"
-	"github.com/nlpodyssey/spago/pkg/utils"
 	"github.com/nlpodyssey/spago/pkg/utils/gopickleutils"
 	"log"
 	"os"
@@ -48,6 +47,10 @@ func ConvertHuggingFacePreTrained(modelPath string) error {
 	if err != nil {
 		return err
 	}
+	if kv := config.NumKeyValueHeads; kv != 0 && (kv != config.EncoderAttentionHeads || kv != config.DecoderAttentionHeads) {
+		// see groupedqueryattention.Model.LoadHuggingFaceParams
+		return fmt.Errorf("bart: the grouped-query attention (%d key/value heads) is not supported by BART", kv)
+	}
 
 	// Enable training mode, so that we have writing permissions
 	// (for example, for embeddings storage files).
@@ -195,7 +198,7 @@ func (c *huggingFacePreTrainedConverter) serializeModel() error {
 		}
 	}
 
-	err := utils.SerializeToFile(c.modelFilename, model)
+	err := nn.SaveModel(c.modelFilename, model)
 	if err != nil {
 		return fmt.Errorf("bert: error during model serialization: %w", err)
 	}
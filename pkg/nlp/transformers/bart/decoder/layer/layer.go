@@ -58,7 +58,7 @@ func NewLayer(config config.Config) *Layer {
 		EncoderAttentionLayerNorm: layernorm.New(config.DModel),
 		FFN: stack.New(
 			linear.New(config.DModel, config.DecoderFFNDim),
-			activation.New(mustGetOpName(config.ActivationFunction)),
+			activation.MustNewFromName(config.ActivationFunction),
 			// dropout.New(config.ActivationDropout)
 			linear.New(config.DecoderFFNDim, config.DModel),
 			// dropout.New(config.Dropout)
@@ -67,14 +67,6 @@ func NewLayer(config config.Config) *Layer {
 	}
 }
 
-func mustGetOpName(str string) ag.OpName {
-	value, err := ag.GetOpName(str)
-	if err != nil {
-		panic(err)
-	}
-	return value
-}
-
 // KeysValuesPairs contains the keys and values used by the self-attention and cross-attention blocks.
 type KeysValuesPairs struct {
 	// SelfAttKeyValues contains the keys and values used by self-attention.
@@ -6,6 +6,7 @@ package layer
 
 import (
 	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
@@ -43,7 +44,7 @@ func NewLayer(config config.Config) *Layer {
 		SelfAttentionLayerNorm: layernorm.New(config.DModel),
 		FFN: stack.New(
 			linear.New(config.DModel, config.EncoderFFNDim),
-			activation.New(mustGetOpName(config.ActivationFunction)),
+			activation.MustNewFromName(config.ActivationFunction),
 			// dropout.New(config.ActivationDropout)
 			linear.New(config.EncoderFFNDim, config.DModel),
 			// dropout.New(config.Dropout)
@@ -52,34 +53,34 @@ func NewLayer(config config.Config) *Layer {
 	}
 }
 
-func mustGetOpName(str string) ag.OpName {
-	value, err := ag.GetOpName(str)
-	if err != nil {
-		panic(err)
-	}
-	return value
-}
-
 // Forward performs the forward step for each input node and returns the result.
 func (m *Layer) Forward(xs ...ag.Node) []ag.Node {
-	selfAtt := m.selfAttentionBlock(xs)
+	out, _ := m.ForwardWithAttention(xs...)
+	return out
+}
+
+// ForwardWithAttention performs the forward step for each input node and returns the result,
+// together with the self-attention weights of each head, for each input node (query).
+func (m *Layer) ForwardWithAttention(xs ...ag.Node) ([]ag.Node, [][]mat.Matrix) {
+	selfAtt, weights := m.selfAttentionBlock(xs)
 	out := m.fullyConnectedBlock(selfAtt)
 	// TODO: limit output values if any Inf or NaN
-	return out
+	return out, weights
 }
 
-func (m *Layer) selfAttentionBlock(xs []ag.Node) []ag.Node {
+func (m *Layer) selfAttentionBlock(xs []ag.Node) ([]ag.Node, [][]mat.Matrix) {
 	residual := m.copy(xs)
 	if m.Config.NormalizeBefore {
 		xs = m.SelfAttentionLayerNorm.Forward(xs...)
 	}
-	xs = m.SelfAttention.Forward(attention.ToQKV(xs)).AttOutput // TODO: key_padding_mask
+	selfAtt := m.SelfAttention.Forward(attention.ToQKV(xs)) // TODO: key_padding_mask
+	xs = selfAtt.AttOutput
 	// TODO: xs = m.Dropout(xs) // config.Dropout
 	xs = add(m.Graph(), residual, xs)
 	if !m.Config.NormalizeBefore {
 		xs = m.SelfAttentionLayerNorm.Forward(xs...)
 	}
-	return xs
+	return xs, selfAtt.AttWeights
 }
 
 func (m *Layer) fullyConnectedBlock(xs []ag.Node) []ag.Node {
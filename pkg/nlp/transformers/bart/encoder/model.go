@@ -6,6 +6,7 @@ package encoder
 
 import (
 	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
@@ -66,19 +67,45 @@ func New(config config.Config) *Model {
 	}
 }
 
+// Output contains the hidden states and the attention weights of all the layers of the encoder.
+type Output struct {
+	// HiddenStates are the input of the first layer (i.e. the embeddings, with the positional encoding),
+	// followed by the output of each layer. With the final layer normalization, the last hidden states
+	// are normalized.
+	HiddenStates [][]ag.Node
+	// Attentions are the self-attention weights of each layer, for each head and each position (query).
+	Attentions [][][]mat.Matrix
+}
+
 // Encode performs the forward step for each input node and returns the result.
 func (m *Model) Encode(xs []ag.Node) []ag.Node {
+	out := m.EncodeWithOutputs(xs)
+	return out.HiddenStates[len(out.HiddenStates)-1]
+}
+
+// EncodeWithOutputs performs the forward step for each input node, and returns the hidden states
+// and the attention weights of all the layers. The last hidden states are the result of Encode.
+func (m *Model) EncodeWithOutputs(xs []ag.Node) Output {
 	embedPos := m.PositionalEncoder.Encode(utils.MakeIndices(len(xs)))
 	ys := add(m.Graph(), xs, embedPos)
 	if m.Config.NormalizeEmbedding {
 		ys = m.EmbeddingLayerNorm.Forward(ys...)
 		// TODO: ys = m.Dropout(ys)
 	}
-	ys = m.Layers.Forward(ys...)
+	out := Output{
+		HiddenStates: [][]ag.Node{ys},
+		Attentions:   make([][][]mat.Matrix, 0, len(m.Layers.Layers)),
+	}
+	for _, l := range m.Layers.Layers {
+		var weights [][]mat.Matrix
+		ys, weights = l.(*layer.Layer).ForwardWithAttention(ys...)
+		out.HiddenStates = append(out.HiddenStates, ys)
+		out.Attentions = append(out.Attentions, weights)
+	}
 	if m.Config.FinalLayerNorm {
-		ys = m.LayerNorm.Forward(ys...)
+		out.HiddenStates[len(out.HiddenStates)-1] = m.LayerNorm.Forward(ys...)
 	}
-	return ys // TODO: return all hidden states?
+	return out
 }
 
 func add(g *ag.Graph, a []ag.Node, b []ag.Node) []ag.Node {
@@ -5,6 +5,7 @@
 package conditionalgeneration
 
 import (
+	"context"
 	"encoding/gob"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
@@ -17,8 +18,9 @@ import (
 )
 
 var (
-	_ nn.Model                  = &Model{}
-	_ generation.EncoderDecoder = &Model{}
+	_ nn.Model                   = &Model{}
+	_ generation.EncoderDecoder  = &Model{}
+	_ generation.SequenceDecoder = &Model{}
 )
 
 // Model is a model for conditional generation tasks
@@ -59,6 +61,36 @@ func (m *Model) PredictNext(
 
 // Generate generates sequences using generation-search decoding.
 func (m *Model) Generate(inputIDs []int) []int {
+	ids, _ := m.GenerateContext(context.Background(), inputIDs)
+	return ids
+}
+
+// GenerateContext is like Generate, but it stops the decoding as soon as the context
+// is done, returning the context error.
+func (m *Model) GenerateContext(ctx context.Context, inputIDs []int) ([]int, error) {
+	return m.GenerateWithConfig(ctx, inputIDs, m.GeneratorConfig())
+}
+
+// GenerateWithConfig is like GenerateContext, but it uses the given generator configuration,
+// e.g. the GeneratorConfig of the model with additional constraints.
+func (m *Model) GenerateWithConfig(ctx context.Context, inputIDs []int, config generation.GeneratorConfig) ([]int, error) {
+	return generation.NewGenerator(config, m).GenerateContext(ctx, inputIDs)
+}
+
+// GenerateSpeculative is like GenerateContext, but it uses the speculative decoding with the draft
+// model, usually a much smaller BART sharing the same vocabulary.
+func (m *Model) GenerateSpeculative(
+	ctx context.Context,
+	inputIDs []int,
+	draft *Model,
+	config generation.SpeculativeConfig,
+) ([]int, error) {
+	return generation.NewSpeculativeGenerator(m.GeneratorConfig(), config, m, draft).GenerateContext(ctx, inputIDs)
+}
+
+// GeneratorConfig returns the configuration of the generator used by GenerateContext, from the
+// BART configuration.
+func (m *Model) GeneratorConfig() generation.GeneratorConfig {
 	incrementalForward := m.Graph().IncrementalForwardEnabled()
 
 	maxConcurrentComputations := runtime.NumCPU()
@@ -66,7 +98,7 @@ func (m *Model) Generate(inputIDs []int) []int {
 		maxConcurrentComputations = runtime.NumCPU() / 2
 	}
 
-	generator := generation.NewGenerator(generation.GeneratorConfig{
+	return generation.GeneratorConfig{
 		NumBeams:                  m.BART.Config.NumBeams,
 		MinLength:                 0,
 		MaxLength:                 m.BART.Config.MaxLength,
@@ -81,9 +113,7 @@ func (m *Model) Generate(inputIDs []int) []int {
 		BadWordsIDs:               m.BART.Config.BadWordsIDs,
 		MaxConcurrentComputations: maxConcurrentComputations,
 		IncrementalForward:        incrementalForward,
-	}, m)
-
-	return generator.Generate(inputIDs)
+	}
 }
 
 // Encode satisfies pkg/nlp/transformers/generation/Encoder.
@@ -101,3 +131,10 @@ func (m *Model) Decode(encodedInput []ag.Node, inputIDs []int, pastCache generat
 	logits, nextCache := m.PredictNext(encodedInput, inputIDs, pastKeysValues)
 	return logits, nextCache
 }
+
+// DecodeSequence satisfies pkg/nlp/transformers/generation/SequenceDecoder.
+func (m *Model) DecodeSequence(encodedInput []ag.Node, inputIDs []int, pastCache generation.Cache) ([]ag.Node, generation.Cache) {
+	pastKeysValues, _ := pastCache.(decoder.KeysValuesPairs)
+	decoded, nextCache := m.BART.Decode(inputIDs, encodedInput, pastKeysValues)
+	return m.Projection.Forward(decoded...), nextCache
+}
@@ -11,13 +11,12 @@ import (
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/config"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/head/conditionalgeneration"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/head/sequenceclassification"
-	"github.com/nlpodyssey/spago/pkg/utils"
 	"log"
 	"path"
 )
 
 // Load loads a Model model from file.
-func Load(modelPath string) (nn.Model, error) {
+func Load(modelPath string, opts ...nn.LoadOption) (nn.Model, error) {
 	configFilename := path.Join(modelPath, config.DefaultConfigurationFile)
 	embeddingsPath := path.Join(modelPath, config.DefaultEmbeddingsStorage)
 	modelFilename := path.Join(modelPath, config.DefaultModelFile)
@@ -45,7 +44,7 @@ func Load(modelPath string) (nn.Model, error) {
 	}
 
 	fmt.Printf("[2/2] Loading model weights... ")
-	err = utils.DeserializeFromFile(modelFilename, model)
+	_, err = nn.LoadModel(modelFilename, model, opts...)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("bert: error during model deserialization (%s)", err.Error()))
 	}
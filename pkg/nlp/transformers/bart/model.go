@@ -12,6 +12,7 @@ import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/softprompt"
 	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/config"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/decoder"
@@ -68,6 +69,19 @@ func (m *Model) Encode(inputIDs []int) []ag.Node {
 	return m.Encoder.Encode(m.useScaledEmbeddings(m.Embeddings.Encode(intToStringSlice(inputIDs))))
 }
 
+// EncodeWithPrompt is like Encode, but it prepends the soft prompt (reified in the same graph) to
+// the embeddings of the inputs. The encoding of the prompt is kept, so that the decoder can attend
+// to it.
+func (m *Model) EncodeWithPrompt(inputIDs []int, prompt *softprompt.Model) []ag.Node {
+	return m.Encoder.Encode(prompt.Forward(m.useScaledEmbeddings(m.Embeddings.Encode(intToStringSlice(inputIDs)))...))
+}
+
+// EncodeWithOutputs performs the BART encoding, returning the hidden states and the attention
+// weights of all the layers of the encoder (see encoder.Output).
+func (m *Model) EncodeWithOutputs(inputIDs []int) encoder.Output {
+	return m.Encoder.EncodeWithOutputs(m.useScaledEmbeddings(m.Embeddings.Encode(intToStringSlice(inputIDs))))
+}
+
 // Decode performs the BART decoding.
 func (m *Model) Decode(
 	inputIDs []int,
@@ -50,10 +50,9 @@ func New(config Config) *LearnedPositionalEncoder {
 
 // Encode performs the forward step for each input and returns the result.
 func (m *LearnedPositionalEncoder) Encode(positions []int) []ag.Node {
-	g := m.Graph()
 	embeddings := make([]ag.Node, len(positions))
 	for i, pos := range positions {
-		embeddings[i] = g.NewWrap(m.Vectors[pos+m.Config.Offset])
+		embeddings[i] = m.WrapParam(m.Vectors[pos+m.Config.Offset])
 	}
 	return embeddings
 }
@@ -8,17 +8,25 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/calibration"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/bpetokenizer"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/sentencepiece"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/head/conditionalgeneration"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/head/sequenceclassification"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/server/grpcapi"
+	"github.com/nlpodyssey/spago/pkg/plugins"
+	"github.com/nlpodyssey/spago/pkg/utils/asyncjobs"
 	"github.com/nlpodyssey/spago/pkg/utils/grpcutils"
 	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"github.com/nlpodyssey/spago/pkg/utils/lrucache"
 	"github.com/nlpodyssey/spago/pkg/webui/bartnli"
+	"github.com/nlpodyssey/spago/pkg/webui/console"
 	"net/http"
+	"time"
 )
 
 // Server contains everything needed to run a BART server.
@@ -28,6 +36,24 @@ type Server struct {
 	spTokenizer     *sentencepiece.Tokenizer
 	TimeoutSeconds  int
 	MaxRequestBytes int
+	// Routes are the additional HTTP routes of the plugins (see the plugins package).
+	Routes []plugins.Route
+	// Cache, if not nil, holds the responses of the classification requests, so that the identical
+	// requests are not computed again.
+	Cache *lrucache.Cache
+	// Jobs, if not nil, runs the asynchronous jobs submitted to the /jobs/ endpoints.
+	Jobs *asyncjobs.Manager
+	// AdminAddress, if not empty, is the address of a separate listener serving the debug
+	// endpoints under /debug/ (see httputils.RunAdminServer).
+	AdminAddress string
+	// NLILabels maps the classes of the model to the NLI roles used by the zero-shot classification.
+	// The empty fields take the default labels (see NLILabels).
+	NLILabels NLILabels
+	// Calibrator, if not nil, calibrates the probabilities of the sequence classification
+	// (see the calibration package); the zero-shot classification is not affected.
+	Calibrator calibration.Calibrator
+	// nliProcessors is the pool of processors used for the zero-shot classification.
+	nliProcessors *nn.ProcessorPool
 
 	// UnimplementedBARTServer must be embedded to have forward compatible implementations for gRPC.
 	grpcapi.UnimplementedBARTServer
@@ -39,15 +65,19 @@ func NewServer(
 	bpeTokenizer *bpetokenizer.BPETokenizer,
 	spTokenizer *sentencepiece.Tokenizer,
 ) *Server {
+	var nliProcessors *nn.ProcessorPool
 	switch model.(type) {
-	case *sequenceclassification.Model, *conditionalgeneration.Model: // ok
+	case *sequenceclassification.Model:
+		nliProcessors = newNLIProcessorPool(model)
+	case *conditionalgeneration.Model: // ok
 	default:
 		panic("bart: invalid model type")
 	}
 	return &Server{
-		model:        model,
-		bpeTokenizer: bpeTokenizer,
-		spTokenizer:  spTokenizer,
+		model:         model,
+		bpeTokenizer:  bpeTokenizer,
+		spTokenizer:   spTokenizer,
+		nliProcessors: nliProcessors,
 	}
 }
 
@@ -69,16 +99,34 @@ func (s *Server) StartDefaultServer(grpcAddress, tlsCert, tlsKey string, tlsDisa
 // HTTP router using the public handler functions
 func (s *Server) StartDefaultHTTPServer(address, tlsCert, tlsKey string, tlsDisable bool) {
 	mux := http.NewServeMux()
+	mux.HandleFunc("/console-ui", console.Handler)
 	switch s.model.(type) {
 	case *sequenceclassification.Model:
 		mux.HandleFunc("/classify-nli-ui", bartnli.Handler)
 		mux.HandleFunc("/classify", s.ClassifyHandler)
 		mux.HandleFunc("/classify-nli", s.ClassifyNLIHandler)
+		mux.HandleFunc("/explain", s.ExplainHandler)
+		mux.HandleFunc("/explain-nli", s.ExplainNLIHandler)
+		if s.Jobs != nil {
+			mux.HandleFunc("/jobs/classify-nli", s.ClassifyNLIJobHandler)
+		}
 	case *conditionalgeneration.Model:
 		mux.HandleFunc("/generate", s.GenerateHandler)
+		if s.Jobs != nil {
+			mux.HandleFunc("/jobs/generate", s.GenerateJobHandler)
+		}
 	default:
 		panic("bart: invalid model type")
 	}
+	if s.Jobs != nil {
+		mux.HandleFunc("/jobs/", s.Jobs.StatusHandler("/jobs/"))
+	}
+	for _, route := range s.Routes {
+		mux.HandleFunc(route.Pattern, route.Handler)
+	}
+	if s.AdminAddress != "" {
+		go httputils.RunAdminServer(s.AdminAddress)
+	}
 
 	go httputils.RunHTTPServer(httputils.HTTPServerConfig{
 		Address:         address,
@@ -91,18 +139,26 @@ func (s *Server) StartDefaultHTTPServer(address, tlsCert, tlsKey string, tlsDisa
 }
 
 // Classify handles a classification request over gRPC.
-func (s *Server) Classify(_ context.Context, req *grpcapi.ClassifyRequest) (*grpcapi.ClassifyReply, error) {
-	result := s.classify(req.GetText(), req.GetText2())
+func (s *Server) Classify(ctx context.Context, req *grpcapi.ClassifyRequest) (*grpcapi.ClassifyReply, error) {
+	result, err := s.cachedClassify(ctx, req.GetText(), req.GetText2(), false)
+	if err != nil {
+		return nil, err
+	}
 	return classificationFrom(result), nil
 }
 
 // ClassifyNLI handles a zero-shot classification request over gRPC.
-func (s *Server) ClassifyNLI(_ context.Context, req *grpcapi.ClassifyNLIRequest) (*grpcapi.ClassifyReply, error) {
-	result, err := s.classifyNLI(
+func (s *Server) ClassifyNLI(ctx context.Context, req *grpcapi.ClassifyNLIRequest) (*grpcapi.ClassifyReply, error) {
+	result, err := s.cachedClassifyNLI(
+		ctx,
 		req.GetText(),
-		req.GetHypothesisTemplate(),
+		hypothesisTemplates(req.GetHypothesisTemplate(), nil),
 		req.GetPossibleLabels(),
+		nil,
 		req.MultiClass,
+		0, // the deadline of a gRPC request is carried by its context
+		false,
+		s.NLILabels,
 	)
 	if err != nil {
 		return nil, err
@@ -111,8 +167,20 @@ func (s *Server) ClassifyNLI(_ context.Context, req *grpcapi.ClassifyNLIRequest)
 }
 
 // Generate handles a conditional generation request over gRPC.
-func (s *Server) Generate(_ context.Context, req *grpcapi.GenerateRequest) (*grpcapi.GenerateReply, error) {
-	result, err := s.generate(req.GetText())
+func (s *Server) Generate(ctx context.Context, req *grpcapi.GenerateRequest) (*grpcapi.GenerateReply, error) {
+	options := generateOptions{
+		StopSequences:     req.GetStopSequences(),
+		BannedWords:       req.GetBannedWords(),
+		RepetitionPenalty: mat.Float(req.GetRepetitionPenalty()),
+		NoRepeatNGramSize: int(req.GetNoRepeatNgramSize()),
+	}
+	for _, id := range req.GetBannedTokenIds() {
+		options.BannedTokenIDs = append(options.BannedTokenIDs, int(id))
+	}
+	if err := s.validateGenerateOptions(options); err != nil {
+		return nil, err
+	}
+	result, err := s.generate(ctx, req.GetText(), options)
 	if err != nil {
 		return nil, err
 	}
@@ -122,6 +190,28 @@ func (s *Server) Generate(_ context.Context, req *grpcapi.GenerateRequest) (*grp
 	}, nil
 }
 
+// cached returns the cached response of the request identified by the key parts, or the one
+// computed by the given function, which is then cached unless it fails or it is partial.
+// The cache is used only if enabled. The responses are shared by the requests, so they must
+// not be modified.
+func (s *Server) cached(compute func() (*ClassifyResponse, error), key ...interface{}) (*ClassifyResponse, error) {
+	if s.Cache == nil {
+		return compute()
+	}
+	k, err := lrucache.Key(key...)
+	if err != nil {
+		return compute()
+	}
+	if response, ok := s.Cache.Get(k); ok {
+		return response.(*ClassifyResponse), nil
+	}
+	response, err := compute()
+	if err == nil && !response.Partial {
+		s.Cache.Add(k, response)
+	}
+	return response, err
+}
+
 type body struct {
 	Text  string `json:"text"`
 	Text2 string `json:"text2"`
@@ -129,6 +219,130 @@ type body struct {
 	HypothesisTemplate string   `json:"hypothesis_template"`
 	PossibleLabels     []string `json:"possible_labels"`
 	MultiClass         bool     `json:"multi_class"`
+	// HypothesisTemplates are more templates (besides HypothesisTemplate), whose scores are averaged.
+	HypothesisTemplates []string `json:"hypothesis_templates"`
+	// Callback is used by the /jobs/ endpoints: the completed job is POSTed to this URL, if not empty.
+	Callback string `json:"callback"`
+	// Timeout is the maximum number of milliseconds for processing the candidate labels (0 for no limit).
+	// Once expired, the response only scores the labels processed so far.
+	Timeout int64 `json:"timeout"`
+	// Debug returns the raw logits of the classes, or of the NLI classes of each candidate label.
+	Debug bool `json:"debug"`
+	// Threshold, Thresholds and TopK are used by the multi-class ClassifyNLI: the response lists the
+	// labels whose confidence reaches their threshold in Thresholds, or Threshold for the labels
+	// without one (0.5 if not positive), at most TopK if positive.
+	Threshold  mat.Float            `json:"threshold"`
+	Thresholds map[string]mat.Float `json:"thresholds"`
+	TopK       int                  `json:"top_k"`
+	// NLILabels, if not nil, overrides the NLI labels of the server for a ClassifyNLI request.
+	NLILabels *NLILabels `json:"nli_labels"`
+	// LabelDescriptions are used by ClassifyNLI in place of the possible labels they describe, to
+	// form the hypotheses (or the label embeddings). The response still reports the labels.
+	LabelDescriptions map[string]string `json:"label_descriptions"`
+	// Method is the zero-shot classification method of ClassifyNLI: "nli" (the default) or
+	// "embeddings", a cheap fallback for the models which cannot perform NLI.
+	Method string `json:"method"`
+	// Target, Window and Mask are used by the explanations: the class to explain (the predicted one
+	// if empty), the number of consecutive tokens occluded together, and the text replacing them
+	// (the tokens are removed if empty). See perturbation.Config.
+	Target string `json:"target"`
+	Window int    `json:"window"`
+	Mask   string `json:"mask"`
+	// Sentences turns the explanations into the counterfactual analysis of the sentences of the
+	// text (see perturbation.SentenceCounterfactuals).
+	Sentences bool `json:"sentences"`
+	// StopSequences, BannedWords, BannedTokenIDs, RepetitionPenalty and NoRepeatNGramSize are
+	// used by Generate (see generateOptions).
+	StopSequences     []string  `json:"stop_sequences"`
+	BannedWords       []string  `json:"banned_words"`
+	BannedTokenIDs    []int     `json:"banned_token_ids"`
+	RepetitionPenalty mat.Float `json:"repetition_penalty"`
+	NoRepeatNGramSize int       `json:"no_repeat_ngram_size"`
+}
+
+// generateOptions returns the options of a Generate request.
+func (b body) generateOptions() generateOptions {
+	return generateOptions{
+		StopSequences:     b.StopSequences,
+		BannedWords:       b.BannedWords,
+		BannedTokenIDs:    b.BannedTokenIDs,
+		RepetitionPenalty: b.RepetitionPenalty,
+		NoRepeatNGramSize: b.NoRepeatNGramSize,
+	}
+}
+
+const (
+	nliMethod        = "nli"
+	embeddingsMethod = "embeddings"
+)
+
+// hypothesisTemplates returns the single hypothesis template, if not empty, followed by the others.
+func hypothesisTemplates(template string, others []string) []string {
+	if template == "" {
+		return others
+	}
+	return append([]string{template}, others...)
+}
+
+// validateZeroShot returns an error if the ClassifyNLI request is invalid.
+func (b body) validateZeroShot() error {
+	switch b.Method {
+	case "", nliMethod:
+		if err := validateHypothesisTemplates(hypothesisTemplates(b.HypothesisTemplate, b.HypothesisTemplates)); err != nil {
+			return err
+		}
+	case embeddingsMethod:
+	default:
+		return fmt.Errorf("server: invalid zero-shot classification method %#v", b.Method)
+	}
+	if b.TopK < 0 {
+		return fmt.Errorf("server: invalid top_k %d", b.TopK)
+	}
+	return nil
+}
+
+// classifyZeroShot performs the zero-shot classification of a validated ClassifyNLI request with
+// the requested method. The labels of a multi-class classification are selected by the thresholds
+// and the limit of the request.
+func (s *Server) classifyZeroShot(ctx context.Context, content body) (*ClassifyResponse, error) {
+	var result *ClassifyResponse
+	var err error
+	if content.Method == embeddingsMethod {
+		result, err = s.cachedClassifyEmbeddings(
+			ctx,
+			content.Text,
+			content.PossibleLabels,
+			content.LabelDescriptions,
+			content.MultiClass,
+		)
+	} else {
+		result, err = s.cachedClassifyNLI(
+			ctx,
+			content.Text,
+			hypothesisTemplates(content.HypothesisTemplate, content.HypothesisTemplates),
+			content.PossibleLabels,
+			content.LabelDescriptions,
+			content.MultiClass,
+			time.Duration(content.Timeout)*time.Millisecond,
+			content.Debug,
+			s.nliLabels(content.NLILabels),
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return content.selectLabels(result), nil
+}
+
+// selectLabels returns the response of a multi-class ClassifyNLI with the labels selected by the
+// thresholds and the limit of the request. The response is not modified, since it can be cached.
+func (b body) selectLabels(resp *ClassifyResponse) *ClassifyResponse {
+	if !b.MultiClass && len(b.PossibleLabels) > 1 {
+		return resp
+	}
+	selected := *resp
+	selected.Labels = selectLabels(resp.Distribution, b.Threshold, b.Thresholds, b.TopK)
+	return &selected
 }
 
 // ClassifyHandler handles a classify request over HTTP.
@@ -143,7 +357,11 @@ func (s *Server) ClassifyHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	result := s.classify(content.Text, content.Text2)
+	result, err := s.cachedClassify(req.Context(), content.Text, content.Text2, content.Debug)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
 	if err != nil {
@@ -169,13 +387,12 @@ func (s *Server) ClassifyNLIHandler(w http.ResponseWriter, req *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if err := content.validateZeroShot(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	result, err := s.classifyNLI(
-		content.Text,
-		content.HypothesisTemplate,
-		content.PossibleLabels,
-		content.MultiClass,
-	)
+	result, err := s.classifyZeroShot(req.Context(), content)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -195,6 +412,81 @@ func (s *Server) ClassifyNLIHandler(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// ClassifyNLIJobHandler submits a zero-shot classification job over HTTP.
+// It responds with the status of the job, which can be polled at /jobs/<id>.
+func (s *Server) ClassifyNLIJobHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	var content body
+	err := json.NewDecoder(req.Body).Decode(&content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := content.validateZeroShot(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := s.Jobs.Submit(content.Callback, func(ctx context.Context) (interface{}, error) {
+		return s.classifyZeroShot(ctx, content)
+	})
+	s.writeJob(w, req, job, err)
+}
+
+// GenerateJobHandler submits a conditional generation job over HTTP, e.g. for the summarization
+// of a long document. It responds with the status of the job, which can be polled at /jobs/<id>.
+func (s *Server) GenerateJobHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	var content body
+	err := json.NewDecoder(req.Body).Decode(&content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options := content.generateOptions()
+	if err := s.validateGenerateOptions(options); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := s.Jobs.Submit(content.Callback, func(ctx context.Context) (interface{}, error) {
+		return s.generate(ctx, content.Text, options)
+	})
+	s.writeJob(w, req, job, err)
+}
+
+// writeJob writes the status of a submitted job, or the error of the submission.
+func (s *Server) writeJob(w http.ResponseWriter, req *http.Request, job asyncjobs.Job, err error) {
+	if errors.Is(err, asyncjobs.ErrShutdown) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, pretty := req.URL.Query()["pretty"]
+	response, err := Dump(job, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
 // GenerateHandler handles a conditional generation request over HTTP.
 func (s *Server) GenerateHandler(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
@@ -207,7 +499,13 @@ func (s *Server) GenerateHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	result, err := s.generate(content.Text)
+	options := content.generateOptions()
+	if err := s.validateGenerateOptions(options); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.generate(req.Context(), content.Text, options)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -231,6 +529,10 @@ func (s *Server) GenerateHandler(w http.ResponseWriter, req *http.Request) {
 type ClassConfidencePair struct {
 	Class      string    `json:"class"`
 	Confidence mat.Float `json:"confidence"`
+	// Logits are the raw logits of the NLI classes (e.g. entailment, neutral and contradiction)
+	// for the hypothesis of a candidate label (averaged over the templates), returned on debug
+	// zero-shot requests.
+	Logits []mat.Float `json:"logits,omitempty"`
 }
 
 // ClassifyResponse is a JSON-serializable structure which holds server
@@ -239,6 +541,15 @@ type ClassifyResponse struct {
 	Class        string                `json:"class"`
 	Confidence   mat.Float             `json:"confidence"`
 	Distribution []ClassConfidencePair `json:"distribution"`
+	// Labels are the labels of a multi-class zero-shot classification which reach their threshold,
+	// by decreasing confidence.
+	Labels []ClassConfidencePair `json:"labels,omitempty"`
+	// Logits are the raw logits of the classes, in the order of their IDs, returned on debug
+	// classification requests. They are omitted for the long texts classified in chunks.
+	Logits []mat.Float `json:"logits,omitempty"`
+	// Partial reports whether the timeout of the request expired, so that only some of
+	// the candidate labels have been scored.
+	Partial bool `json:"partial,omitempty"`
 	// Took is the number of milliseconds it took the server to execute the request.
 	Took int64 `json:"took"`
 }
@@ -5,9 +5,12 @@
 package server
 
 import (
+	"context"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/document"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/head/sequenceclassification"
 	"runtime"
 	"sort"
@@ -15,17 +18,32 @@ import (
 	"time"
 )
 
-func (s *Server) classify(text string, text2 string) *ClassifyResponse {
+// cachedClassify is like classify, but it uses the response cache, if enabled.
+func (s *Server) cachedClassify(ctx context.Context, text string, text2 string, debug bool) (*ClassifyResponse, error) {
+	return s.cached(func() (*ClassifyResponse, error) {
+		return s.classify(ctx, text, text2, debug)
+	}, "classify", text, text2, debug)
+}
+
+func (s *Server) classify(ctx context.Context, text string, text2 string, debug bool) (*ClassifyResponse, error) {
 	start := time.Now()
 
-	g := ag.NewGraph(ag.IncrementalForward(false), ag.ConcurrentComputations(runtime.NumCPU()))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*sequenceclassification.Model)
+	var probs, logits []mat.Float
+	var err error
 	inputIds := getInputIDs(s.bpeTokenizer, text, text2)
-	logits := proc.Classify(inputIds)
-	g.Forward()
+	maxLength := s.model.(*sequenceclassification.Model).BART.Config.MaxPositionEmbeddings
+	if text2 == "" && maxLength > 0 && len(inputIds) > maxLength {
+		probs, err = s.classifyDocument(ctx, text, maxLength)
+	} else {
+		logits, err = s.classifyInputIDs(ctx, inputIds)
+		if err == nil {
+			probs = s.probabilities(logits)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	probs := floatutils.SoftMax(g.GetCopiedValue(logits).Data())
 	best := floatutils.ArgMax(probs)
 	classes := s.model.(*sequenceclassification.Model).BART.Config.ID2Label
 	class := classes[strconv.Itoa(best)]
@@ -42,10 +60,71 @@ func (s *Server) classify(text string, text2 string) *ClassifyResponse {
 		return distribution[i].Confidence > distribution[j].Confidence
 	})
 
-	return &ClassifyResponse{
+	response := &ClassifyResponse{
 		Class:        class,
 		Confidence:   probs[best],
 		Distribution: distribution,
 		Took:         time.Since(start).Milliseconds(),
 	}
+	if debug {
+		response.Logits = logits
+	}
+	return response, nil
+}
+
+// classifyInputIDs returns the logits of the classes for the given input.
+// The computation stops as soon as the context is done.
+func (s *Server) classifyInputIDs(ctx context.Context, inputIds []int) ([]mat.Float, error) {
+	g := ag.NewGraph(ag.IncrementalForward(false), ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*sequenceclassification.Model)
+	logits := proc.Classify(inputIds)
+	if err := g.ForwardContext(ctx); err != nil {
+		return nil, err
+	}
+	return g.GetCopiedValue(logits).Data(), nil
+}
+
+// classifyDocument classifies a text longer than the maximum length of the model, splitting it into
+// chunks of sentences. It returns the average of the probabilities of the chunks.
+// The chunks made of a single sentence that still exceeds the maximum length are truncated.
+func (s *Server) classifyDocument(ctx context.Context, text string, maxLength int) ([]mat.Float, error) {
+	pipeline := document.NewPipeline(nil, document.Config{
+		MaxLength: maxLength,
+		Length: func(text string) int {
+			return len(getInputIDs(s.bpeTokenizer, text, ""))
+		},
+	})
+	chunks := pipeline.Split(text)
+	var sum []mat.Float
+	for _, chunk := range chunks {
+		inputIds := getInputIDs(s.bpeTokenizer, chunk.Text, "")
+		if len(inputIds) > maxLength {
+			inputIds = append(inputIds[:maxLength-1], defaultEndSequenceTokenID)
+		}
+		logits, err := s.classifyInputIDs(ctx, inputIds)
+		if err != nil {
+			return nil, err
+		}
+		probs := s.probabilities(logits)
+		if sum == nil {
+			sum = make([]mat.Float, len(probs))
+		}
+		for i, p := range probs {
+			sum[i] += p
+		}
+	}
+	for i := range sum {
+		sum[i] /= mat.Float(len(chunks))
+	}
+	return sum, nil
+}
+
+// probabilities returns the probabilities of the classes given their logits, calibrated by the
+// Calibrator of the server, if any.
+func (s *Server) probabilities(logits []mat.Float) []mat.Float {
+	if s.Calibrator != nil {
+		return s.Calibrator.Calibrate(logits)
+	}
+	return floatutils.SoftMax(logits)
 }
@@ -0,0 +1,112 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/head/sequenceclassification"
+	"sort"
+	"time"
+)
+
+// embeddingsSimilarityScale scales the cosine similarities before the softmax, since their range
+// [-1, 1] would otherwise give an almost uniform distribution.
+const embeddingsSimilarityScale mat.Float = 10
+
+// cachedClassifyEmbeddings is like classifyEmbeddings, but it uses the response cache, if enabled.
+func (s *Server) cachedClassifyEmbeddings(
+	ctx context.Context,
+	text string,
+	candidateLabels []string,
+	labelDescriptions map[string]string,
+	multiClass bool,
+) (*ClassifyResponse, error) {
+	return s.cached(func() (*ClassifyResponse, error) {
+		return s.classifyEmbeddings(ctx, text, candidateLabels, labelDescriptions, multiClass)
+	}, "classify-embeddings", text, candidateLabels, labelDescriptions, multiClass)
+}
+
+// classifyEmbeddings performs a zero-shot classification of the text by the cosine similarity of
+// its sentence embedding with the ones of the candidate labels (or of their descriptions).
+// It is a cheap fallback for the models which cannot perform NLI, requiring a single encoding
+// per label instead of an NLI inference. The sentence embeddings are the average of the hidden
+// states of the encoder.
+// The confidences are the softmax of the scaled similarities, or the similarities rescaled to
+// [0, 1] in multi-class mode, so that the labels are scored independently.
+func (s *Server) classifyEmbeddings(
+	ctx context.Context,
+	text string,
+	candidateLabels []string,
+	labelDescriptions map[string]string,
+	multiClass bool,
+) (*ClassifyResponse, error) {
+	start := time.Now()
+
+	textEmbedding, err := s.sentenceEmbedding(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	similarities := make([]mat.Float, len(candidateLabels))
+	for i, label := range candidateLabels {
+		labelEmbedding, err := s.sentenceEmbedding(ctx, describeLabel(label, labelDescriptions))
+		if err != nil {
+			return nil, err
+		}
+		similarities[i] = mat.Cosine(textEmbedding, labelEmbedding)
+	}
+
+	scores := make([]mat.Float, len(similarities))
+	if multiClass || len(candidateLabels) == 1 {
+		for i, similarity := range similarities {
+			scores[i] = (similarity + 1) / 2
+		}
+	} else {
+		for i, similarity := range similarities {
+			scores[i] = similarity * embeddingsSimilarityScale
+		}
+		scores = floatutils.SoftMax(scores)
+	}
+
+	best := floatutils.ArgMax(scores)
+	distribution := make([]ClassConfidencePair, len(scores))
+	for i, label := range candidateLabels {
+		distribution[i] = ClassConfidencePair{
+			Class:      label,
+			Confidence: scores[i],
+		}
+	}
+	sort.Slice(distribution, func(i, j int) bool {
+		return distribution[i].Confidence > distribution[j].Confidence
+	})
+
+	return &ClassifyResponse{
+		Class:        candidateLabels[best],
+		Confidence:   scores[best],
+		Distribution: distribution,
+		Took:         time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// sentenceEmbedding returns the average of the hidden states of the encoder for the text, which is
+// truncated to the maximum length of the model.
+func (s *Server) sentenceEmbedding(ctx context.Context, text string) (mat.Matrix, error) {
+	pp := s.nliProcessors.Get()
+	defer s.nliProcessors.Put(pp)
+	proc := pp.Processor.(*sequenceclassification.Model)
+	g := proc.Graph()
+
+	inputIds := getInputIDs(s.bpeTokenizer, text, "")
+	maxLength := proc.BART.Config.MaxPositionEmbeddings
+	if maxLength > 0 && len(inputIds) > maxLength {
+		inputIds = append(inputIds[:maxLength-1], defaultEndSequenceTokenID)
+	}
+	embedding := g.Mean(proc.BART.Encode(inputIds))
+	if err := g.ForwardContext(ctx); err != nil {
+		return nil, err
+	}
+	return g.GetCopiedValue(embedding), nil
+}
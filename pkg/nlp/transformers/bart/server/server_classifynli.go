@@ -5,6 +5,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
@@ -16,7 +17,6 @@ import (
 	"runtime"
 	"sort"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -28,65 +28,204 @@ type premiseHypothesisPair struct {
 
 const defaultHypothesisTemplate = "This text is about {}."
 
+// NLILabels maps the classes of an NLI model to the roles used by the zero-shot classification,
+// so that the models whose classes are not named "entailment" and "contradiction" can be used.
+// The labels are matched with the classes of the model case-insensitively.
+type NLILabels struct {
+	// Entailment is the label of the entailment class ("entailment" if empty).
+	Entailment string `json:"entailment"`
+	// Contradiction is the label of the contradiction class ("contradiction" if empty).
+	Contradiction string `json:"contradiction"`
+	// Neutral, if not empty, is the label of the neutral class. The multi-class scores then
+	// softmax the entailment against both the contradiction and the neutral class, instead of
+	// the contradiction only.
+	Neutral string `json:"neutral"`
+}
+
+const (
+	defaultEntailmentLabel    = "entailment"
+	defaultContradictionLabel = "contradiction"
+)
+
+// nliLabels returns the NLI labels of the server, overridden by the non-empty ones of the request.
+func (s *Server) nliLabels(override *NLILabels) NLILabels {
+	labels := s.NLILabels
+	if override == nil {
+		return labels
+	}
+	if override.Entailment != "" {
+		labels.Entailment = override.Entailment
+	}
+	if override.Contradiction != "" {
+		labels.Contradiction = override.Contradiction
+	}
+	if override.Neutral != "" {
+		labels.Neutral = override.Neutral
+	}
+	return labels
+}
+
+// defaultMultiClassThreshold is the default minimum confidence of the labels selected by a
+// multi-class classification.
+const defaultMultiClassThreshold mat.Float = 0.5
+
+// cachedClassifyNLI is like classifyNLI, but it uses the response cache, if enabled.
+func (s *Server) cachedClassifyNLI(
+	ctx context.Context,
+	text string,
+	hypothesisTemplates []string,
+	candidateLabels []string,
+	labelDescriptions map[string]string,
+	multiClass bool,
+	timeout time.Duration,
+	debug bool,
+	nliLabels NLILabels,
+) (*ClassifyResponse, error) {
+	return s.cached(func() (*ClassifyResponse, error) {
+		return s.classifyNLI(
+			ctx, text, hypothesisTemplates, candidateLabels, labelDescriptions, multiClass, timeout, debug, nliLabels)
+	}, "classify-nli", text, hypothesisTemplates, candidateLabels, labelDescriptions, multiClass, debug, nliLabels)
+}
+
+// validateHypothesisTemplates returns an error if a template lacks the "{}" placeholder of the label.
+func validateHypothesisTemplates(templates []string) error {
+	for _, template := range templates {
+		if !strings.Contains(template, "{}") {
+			return fmt.Errorf("server: the hypothesis template %#v has no {} placeholder", template)
+		}
+	}
+	return nil
+}
+
+// classifyNLI performs a zero-shot classification of the text, entailing a hypothesis for each
+// candidate label and hypothesis template (defaultHypothesisTemplate if none). With more templates,
+// the scores of a label are the average of its scores with each template. The labels which have a
+// description are replaced by it in the hypotheses.
+// If the timeout is positive and expires before all the hypotheses have been processed, the
+// response only scores the labels processed so far, and it is flagged as partial.
+// If debug is true, each label of the distribution has the NLI logits of its hypotheses, averaged
+// over the templates.
+// The classes of the model are mapped to the NLI roles by the given labels.
 func (s *Server) classifyNLI(
+	ctx context.Context,
 	text string,
-	hypothesisTemplate string,
+	hypothesisTemplates []string,
 	candidateLabels []string,
+	labelDescriptions map[string]string,
 	multiClass bool,
+	timeout time.Duration,
+	debug bool,
+	nliLabels NLILabels,
 ) (*ClassifyResponse, error) {
 	start := time.Now()
 
-	if hypothesisTemplate == "" {
-		hypothesisTemplate = defaultHypothesisTemplate
+	if len(hypothesisTemplates) == 0 {
+		hypothesisTemplates = []string{defaultHypothesisTemplate}
+	}
+	if err := validateHypothesisTemplates(hypothesisTemplates); err != nil {
+		return nil, err
 	}
 
-	entailmentID, contradictionID, err := s.getEntailmentAndContradictionIDs()
+	entailmentID, contradictionID, neutralID, err := s.getNLIClassIDs(nliLabels)
 	if err != nil {
 		return nil, err
 	}
 
 	numOfCandidateLabels := len(candidateLabels)
-	logits := make([]mat.Matrix, numOfCandidateLabels)
+	numOfTemplates := len(hypothesisTemplates)
+	// the logits of the hypothesis of the i-th label with the t-th template are at i*numOfTemplates+t
+	logits := make([]mat.Matrix, numOfCandidateLabels*numOfTemplates)
 
 	numWorkers := runtime.NumCPU() / 2 // leave some space for other concurrent computations
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
 	wp := workerpool.New(numWorkers)
 	workers := s.newWorkers(numWorkers)
-	wg := sync.WaitGroup{}
-	go wp.Run(func(workerID int, jobData interface{}) {
+	wp.Start(func(ctx context.Context, workerID int, jobData interface{}) error {
 		data := jobData.(premiseHypothesisPair)
-		logits[data.index] = workers[workerID].process(data)
-		wg.Done()
+		var err error
+		logits[data.index], err = workers[workerID].process(ctx, data)
+		return err
 	})
 
+	jobsCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		jobsCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	jobs := make([]*workerpool.Job, len(logits))
 	for i, label := range candidateLabels {
-		wg.Add(1)
-		wp.PublishJobData(premiseHypothesisPair{
-			index:      i,
-			premise:    text,
-			hypothesis: strings.Replace(hypothesisTemplate, "{}", label, -1),
-		})
+		for t, template := range hypothesisTemplates {
+			index := i*numOfTemplates + t
+			jobs[index] = wp.Submit(jobsCtx, premiseHypothesisPair{
+				index:      index,
+				premise:    text,
+				hypothesis: strings.Replace(template, "{}", describeLabel(label, labelDescriptions), -1),
+			})
+		}
+	}
+	if err := wp.Shutdown(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// a label is processed if the hypotheses of all the templates are
+	processed := make([]int, 0, numOfCandidateLabels)
+	for i := 0; i < numOfCandidateLabels; i++ {
+		expired := false
+		for _, job := range jobs[i*numOfTemplates : (i+1)*numOfTemplates] {
+			err := job.Err()
+			if err == context.DeadlineExceeded && timeout > 0 && ctx.Err() == nil {
+				expired = true // the deadline of the request has expired: the label is left out
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !expired {
+			processed = append(processed, i)
+		}
+	}
+	if len(processed) == 0 {
+		return nil, fmt.Errorf("server: no candidate label processed within the timeout of %s", timeout)
 	}
-	wg.Wait()
+	partial := len(processed) < numOfCandidateLabels
 
 	if numOfCandidateLabels == 1 {
 		multiClass = true
 	}
 
-	scores := func() []mat.Float {
-		if multiClass {
-			return getMultiClassScores(logits, entailmentID, contradictionID)
+	scores := make([]mat.Float, len(processed))
+	for t := 0; t < numOfTemplates; t++ {
+		templateLogits := make([]mat.Matrix, len(processed))
+		for j, i := range processed {
+			templateLogits[j] = logits[i*numOfTemplates+t]
 		}
-		return getScores(logits, entailmentID)
-	}()
+		templateScores := func() []mat.Float {
+			if multiClass {
+				return getMultiClassScores(templateLogits, entailmentID, contradictionID, neutralID)
+			}
+			return getScores(templateLogits, entailmentID)
+		}()
+		for j, score := range templateScores {
+			scores[j] += score / mat.Float(numOfTemplates)
+		}
+	}
 
 	best := floatutils.ArgMax(scores)
-	class := candidateLabels[best]
+	class := candidateLabels[processed[best]]
 
 	distribution := make([]ClassConfidencePair, len(scores))
-	for i := 0; i < len(scores); i++ {
-		distribution[i] = ClassConfidencePair{
+	for j, i := range processed {
+		distribution[j] = ClassConfidencePair{
 			Class:      candidateLabels[i],
-			Confidence: scores[i],
+			Confidence: scores[j],
+		}
+		if debug {
+			distribution[j].Logits = averageLogits(logits[i*numOfTemplates : (i+1)*numOfTemplates])
 		}
 	}
 
@@ -98,16 +237,66 @@ func (s *Server) classifyNLI(
 		Class:        class,
 		Confidence:   scores[best],
 		Distribution: distribution,
+		Partial:      partial,
 		Took:         time.Since(start).Milliseconds(),
 	}, nil
 }
 
-// getMultiClassScores softmax over the entailment vs. contradiction for each label independently
-func getMultiClassScores(logits []mat.Matrix, entailmentID, contradictionID int) []mat.Float {
+// describeLabel returns the description of the label, or the label itself if it has none.
+func describeLabel(label string, descriptions map[string]string) string {
+	if description, ok := descriptions[label]; ok && description != "" {
+		return description
+	}
+	return label
+}
+
+// averageLogits returns the element-wise average of the logits.
+func averageLogits(logits []mat.Matrix) []mat.Float {
+	sum := logits[0].Clone()
+	for _, l := range logits[1:] {
+		sum.AddInPlace(l)
+	}
+	return sum.ProdScalarInPlace(1 / mat.Float(len(logits))).Data()
+}
+
+// selectLabels returns the labels of the distribution, sorted by decreasing confidence, whose
+// confidence reaches their threshold, at most topK if positive. The labels without a threshold
+// of their own use the default one, or defaultMultiClassThreshold if it is not positive.
+func selectLabels(
+	distribution []ClassConfidencePair,
+	defaultThreshold mat.Float,
+	thresholds map[string]mat.Float,
+	topK int,
+) []ClassConfidencePair {
+	if defaultThreshold <= 0 {
+		defaultThreshold = defaultMultiClassThreshold
+	}
+	labels := make([]ClassConfidencePair, 0)
+	for _, pair := range distribution {
+		if topK > 0 && len(labels) == topK {
+			break
+		}
+		threshold, ok := thresholds[pair.Class]
+		if !ok {
+			threshold = defaultThreshold
+		}
+		if pair.Confidence >= threshold {
+			labels = append(labels, pair)
+		}
+	}
+	return labels
+}
+
+// getMultiClassScores softmax over the entailment vs. contradiction for each label independently.
+// If neutralID is not negative, the neutral class is softmaxed along with the contradiction.
+func getMultiClassScores(logits []mat.Matrix, entailmentID, contradictionID, neutralID int) []mat.Float {
 	scores := make([]mat.Float, len(logits))
 	for i, v := range logits {
-		prob := floatutils.SoftMax([]mat.Float{v.AtVec(entailmentID), v.AtVec(contradictionID)})
-		scores[i] = prob[0]
+		classes := []mat.Float{v.AtVec(entailmentID), v.AtVec(contradictionID)}
+		if neutralID >= 0 {
+			classes = append(classes, v.AtVec(neutralID))
+		}
+		scores[i] = floatutils.SoftMax(classes)[0]
 	}
 	return scores
 }
@@ -121,43 +310,96 @@ func getScores(logits []mat.Matrix, entailmentID int) []mat.Float {
 	return floatutils.SoftMax(scores)
 }
 
-func (s *Server) getEntailmentAndContradictionIDs() (
-	entailmentID, contradictionID int, err error,
+// getNLIClassIDs returns the IDs of the entailment, contradiction and neutral classes of the model,
+// mapped by the given labels. The neutral ID is -1 if the neutral label is empty.
+func (s *Server) getNLIClassIDs(labels NLILabels) (
+	entailmentID, contradictionID, neutralID int, err error,
 ) {
+	if labels.Entailment == "" {
+		labels.Entailment = defaultEntailmentLabel
+	}
+	if labels.Contradiction == "" {
+		labels.Contradiction = defaultContradictionLabel
+	}
 	labels2id := s.model.(*sequenceclassification.Model).BART.Config.Label2ID
-	entailmentID, ok := labels2id["entailment"]
+	entailmentID, ok := lookupLabel(labels2id, labels.Entailment)
 	if !ok {
-		return -1, -1, fmt.Errorf("server: `entailment` label not found")
+		return -1, -1, -1, fmt.Errorf("server: `%s` label not found", labels.Entailment)
 	}
-	contradictionID, ok = labels2id["contradiction"]
+	contradictionID, ok = lookupLabel(labels2id, labels.Contradiction)
 	if !ok {
-		return -1, -1, fmt.Errorf("server: `contradiction` label not found")
+		return -1, -1, -1, fmt.Errorf("server: `%s` label not found", labels.Contradiction)
+	}
+	neutralID = -1
+	if labels.Neutral != "" {
+		neutralID, ok = lookupLabel(labels2id, labels.Neutral)
+		if !ok {
+			return -1, -1, -1, fmt.Errorf("server: `%s` label not found", labels.Neutral)
+		}
 	}
 	return
 }
 
+// lookupLabel returns the ID of the label, matched case-insensitively.
+func lookupLabel(labels2id map[string]int, label string) (int, bool) {
+	if id, ok := labels2id[label]; ok {
+		return id, true
+	}
+	for l, id := range labels2id {
+		if strings.EqualFold(l, label) {
+			return id, true
+		}
+	}
+	return -1, false
+}
+
 func (s *Server) newWorkers(workersSize int) []*worker {
 	workers := make([]*worker, workersSize)
 	for i := range workers {
 		workers[i] = &worker{
-			tokenizer: s.bpeTokenizer,
-			model:     s.model.(*sequenceclassification.Model),
+			tokenizer:  s.bpeTokenizer,
+			processors: s.nliProcessors,
 		}
 	}
 	return workers
 }
 
+// newNLIProcessorPool returns a pool of processors of the sequence classification model,
+// so that the processors and the nodes of their graphs are reused across the requests.
+// The graphs share the global thread budget, or one of their own, so that the concurrent
+// processors do not oversubscribe the CPUs.
+func newNLIProcessorPool(model nn.Model) *nn.ProcessorPool {
+	budget := ag.GlobalThreadBudget()
+	if budget == nil {
+		budget = ag.NewThreadBudget(runtime.NumCPU())
+	}
+	return nn.NewProcessorPool(model, nn.Inference,
+		ag.ConcurrentComputations(runtime.NumCPU()),
+		ag.SharedThreadBudget(budget),
+		ag.IncrementalForward(false),
+		ag.Arena(true),
+	)
+}
+
 type worker struct {
-	tokenizer *bpetokenizer.BPETokenizer
-	model     *sequenceclassification.Model
+	tokenizer  *bpetokenizer.BPETokenizer
+	processors *nn.ProcessorPool
 }
 
-func (w *worker) process(input premiseHypothesisPair) mat.Matrix {
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()), ag.IncrementalForward(false))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, w.model).(*sequenceclassification.Model)
+// process returns the logits of the given premise-hypothesis pair, or the context error if
+// the context is done before the computation is completed.
+func (w *worker) process(ctx context.Context, input premiseHypothesisPair) (mat.Matrix, error) {
 	inputIds := getInputIDs(w.tokenizer, input.premise, input.hypothesis)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	pp := w.processors.Get()
+	defer w.processors.Put(pp)
+	proc := pp.Processor.(*sequenceclassification.Model)
+	g := proc.Graph()
 	logits := proc.Classify(inputIds)
-	g.Forward()
-	return g.GetCopiedValue(logits)
+	if err := g.ForwardContext(ctx); err != nil {
+		return nil, err
+	}
+	return g.GetCopiedValue(logits), nil
 }
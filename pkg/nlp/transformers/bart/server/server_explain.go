@@ -0,0 +1,152 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/nlp/perturbation"
+	"net/http"
+	"time"
+)
+
+// ExplainResponse is a JSON-serializable server response for the "explain" requests: the
+// importance of each token of the text for the class, by occlusion (see perturbation.Occlusion).
+type ExplainResponse struct {
+	*perturbation.Explanation
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// CounterfactualResponse is a JSON-serializable server response for the "explain" requests of the
+// sentences: how the confidence of each class changes when the sentence with the highest impact
+// on it is removed (see perturbation.SentenceCounterfactuals).
+type CounterfactualResponse struct {
+	*perturbation.CounterfactualAnalysis
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// ExplainHandler handles an explain request of the sequence classification over HTTP.
+func (s *Server) ExplainHandler(w http.ResponseWriter, req *http.Request) {
+	s.handleExplain(w, req, false)
+}
+
+// ExplainNLIHandler handles an explain request of the zero-shot classification over HTTP. The
+// request has the fields of the classify-nli requests, and its classes are the candidate labels.
+func (s *Server) ExplainNLIHandler(w http.ResponseWriter, req *http.Request) {
+	s.handleExplain(w, req, true)
+}
+
+func (s *Server) handleExplain(w http.ResponseWriter, req *http.Request, zeroShot bool) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	var content body
+	err := json.NewDecoder(req.Body).Decode(&content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if zeroShot {
+		if err := content.validateZeroShot(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var result interface{}
+	if content.Sentences {
+		result, err = s.explainSentences(req.Context(), content, zeroShot)
+	} else {
+		result, err = s.explain(req.Context(), content, zeroShot)
+	}
+	if errors.Is(err, perturbation.ErrUnknownClass) || errors.Is(err, perturbation.ErrSingleSentence) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, pretty := req.URL.Query()["pretty"]
+	response, err := Dump(result, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// explain returns the explanation of the sequence classification, or of the zero-shot
+// classification, of the text of the request.
+func (s *Server) explain(ctx context.Context, content body, zeroShot bool) (*ExplainResponse, error) {
+	start := time.Now()
+	explanation, err := perturbation.Occlusion(content.Text, s.explainedClassifier(ctx, content, zeroShot), perturbation.Config{
+		Class:  content.Target,
+		Window: content.Window,
+		Mask:   content.Mask,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ExplainResponse{
+		Explanation: explanation,
+		Took:        time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// explainSentences returns the counterfactual analysis of the sentences of the text of the request,
+// for the sequence classification or the zero-shot classification.
+func (s *Server) explainSentences(ctx context.Context, content body, zeroShot bool) (*CounterfactualResponse, error) {
+	start := time.Now()
+	analysis, err := perturbation.SentenceCounterfactuals(
+		content.Text, s.explainedClassifier(ctx, content, zeroShot), perturbation.CounterfactualConfig{})
+	if err != nil {
+		return nil, err
+	}
+	return &CounterfactualResponse{
+		CounterfactualAnalysis: analysis,
+		Took:                   time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// explainedClassifier returns the classifier of the perturbed texts of the request, by sequence
+// classification or by zero-shot classification with the settings of the request.
+func (s *Server) explainedClassifier(ctx context.Context, content body, zeroShot bool) perturbation.Classifier {
+	content.Timeout = 0 // the partial responses would not score all the classes
+	return func(text string) (map[string]mat.Float, error) {
+		var result *ClassifyResponse
+		var err error
+		if zeroShot {
+			perturbed := content
+			perturbed.Text = text
+			result, err = s.classifyZeroShot(ctx, perturbed)
+		} else {
+			result, err = s.classify(ctx, text, content.Text2, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return distributionMap(result.Distribution), nil
+	}
+}
+
+// distributionMap returns the confidence of each class of the distribution.
+func distributionMap(distribution []ClassConfidencePair) map[string]mat.Float {
+	scores := make(map[string]mat.Float, len(distribution))
+	for _, pair := range distribution {
+		scores[pair.Class] = pair.Confidence
+	}
+	return scores
+}
@@ -5,14 +5,60 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/config"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/head/conditionalgeneration"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+	"strings"
 	"time"
 )
 
-func (s *Server) generate(text string) (*GenerateResponse, error) {
+// generateOptions are the options of a generation request, applied by the processors of the
+// scores of the generator (see generation.GeneratorConfig).
+type generateOptions struct {
+	// StopSequences end the generation as soon as the text ends with one of them, which is then
+	// removed from the response.
+	StopSequences []string
+	// BannedWords are the words which must not be generated, in addition to the bad words of the
+	// model configuration; BannedTokenIDs are the tokens which must not be generated.
+	BannedWords    []string
+	BannedTokenIDs []int
+	// RepetitionPenalty penalizes the tokens already generated (1 or 0 for no penalty).
+	RepetitionPenalty mat.Float
+	// NoRepeatNGramSize, if positive, prevents the n-grams of this size from occurring twice.
+	NoRepeatNGramSize int
+}
+
+// validateGenerateOptions returns an error if the options of a generation request are invalid.
+func (s *Server) validateGenerateOptions(options generateOptions) error {
+	model, ok := s.model.(*conditionalgeneration.Model)
+	if !ok {
+		return fmt.Errorf("bart: the model can't generate")
+	}
+	if options.RepetitionPenalty < 0 {
+		return fmt.Errorf("bart: the repetition penalty must be non-negative, got %g", options.RepetitionPenalty)
+	}
+	if options.NoRepeatNGramSize < 0 {
+		return fmt.Errorf("bart: the no-repeat n-gram size must be non-negative, got %d", options.NoRepeatNGramSize)
+	}
+	for _, id := range options.BannedTokenIDs {
+		if id < 0 || id >= model.BART.Config.VocabSize {
+			return fmt.Errorf("bart: banned token ID %d out of range", id)
+		}
+	}
+	for _, seq := range options.StopSequences {
+		if strings.TrimSpace(seq) == "" {
+			return fmt.Errorf("bart: empty stop sequence")
+		}
+	}
+	return nil
+}
+
+func (s *Server) generate(ctx context.Context, text string, options generateOptions) (*GenerateResponse, error) {
 	start := time.Now()
 
 	g := ag.NewGraph(ag.IncrementalForward(false))
@@ -26,8 +72,13 @@ func (s *Server) generate(text string) (*GenerateResponse, error) {
 
 	tokenIDs = append(tokenIDs, bartConfig.EosTokenID)
 
-	rawGeneratedIDs := proc.Generate(tokenIDs)
+	generatorConfig := s.generatorConfig(proc, options)
+	rawGeneratedIDs, err := proc.GenerateWithConfig(ctx, tokenIDs, generatorConfig)
+	if err != nil {
+		return nil, err
+	}
 	generatedIDs := s.stripBadTokens(rawGeneratedIDs, bartConfig)
+	generatedIDs = trimStopSequence(generatedIDs, generatorConfig.StopSequences)
 
 	generatedTokens := s.spTokenizer.IDsToTokens(generatedIDs)
 	generatedText := s.spTokenizer.Detokenize(generatedTokens)
@@ -38,6 +89,68 @@ func (s *Server) generate(text string) (*GenerateResponse, error) {
 	}, nil
 }
 
+// generatorConfig returns the configuration of the generator of the model with the options.
+func (s *Server) generatorConfig(proc *conditionalgeneration.Model, options generateOptions) generation.GeneratorConfig {
+	cfg := proc.GeneratorConfig()
+	cfg.RepetitionPenalty = options.RepetitionPenalty
+	cfg.NoRepeatNGramSize = options.NoRepeatNGramSize
+	if len(options.BannedWords) > 0 || len(options.BannedTokenIDs) > 0 {
+		badWordsIDs := append([][]int{}, cfg.BadWordsIDs...)
+		for _, word := range options.BannedWords {
+			if ids := s.spTokenizer.TokensToIDs(s.spTokenizer.Tokenize(word)); len(ids) > 0 {
+				badWordsIDs = append(badWordsIDs, ids)
+			}
+		}
+		for _, id := range options.BannedTokenIDs {
+			badWordsIDs = append(badWordsIDs, []int{id})
+		}
+		cfg.BadWordsIDs = badWordsIDs
+	}
+	for _, seq := range options.StopSequences {
+		cfg.StopSequences = append(cfg.StopSequences, s.stopSequenceIDs(seq)...)
+	}
+	return cfg
+}
+
+// stopSequenceIDs returns the token IDs of the stop sequence, both as a separate word and, if it
+// doesn't start with a space, attached to the previous word (e.g. a punctuation mark).
+func (s *Server) stopSequenceIDs(seq string) [][]int {
+	tokens := s.spTokenizer.Tokenize(seq)
+	if len(tokens) == 0 {
+		return nil
+	}
+	variants := [][]int{s.spTokenizer.TokensToIDs(tokens)}
+	if strings.HasPrefix(seq, " ") {
+		return variants
+	}
+	attached := append([]string{}, tokens...)
+	attached[0] = strings.TrimPrefix(attached[0], sentencePieceSeparator)
+	if attached[0] == "" {
+		attached = attached[1:]
+	}
+	if len(attached) == 0 {
+		return variants
+	}
+	ids := s.spTokenizer.TokensToIDs(attached)
+	// the attached token may be missing from the vocabulary
+	if s.spTokenizer.IDsToTokens(ids[:1])[0] == attached[0] {
+		variants = append(variants, ids)
+	}
+	return variants
+}
+
+// sentencePieceSeparator is the prefix of the word-initial tokens of the sentencepiece tokenizer.
+const sentencePieceSeparator = "▁"
+
+// trimStopSequence removes the stop sequence the generated tokens end with, if any.
+func trimStopSequence(ids []int, stopSequences [][]int) []int {
+	stop := &generation.StopSequences{Sequences: stopSequences}
+	if i := stop.Match(ids); i >= 0 {
+		return ids[:len(ids)-len(stopSequences[i])]
+	}
+	return ids
+}
+
 func (s *Server) stripBadTokens(ids []int, bartConfig config.Config) []int {
 	result := make([]int, 0, len(ids))
 	for _, id := range ids {
@@ -10,9 +10,13 @@ import (
 	"fmt"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/softprompt"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/transformer"
+	"github.com/nlpodyssey/spago/pkg/nlp/relations"
 	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
-	"github.com/nlpodyssey/spago/pkg/utils"
 	"log"
 	"os"
 	"path"
@@ -30,6 +34,9 @@ const (
 	DefaultEmbeddingsStorage = "embeddings_storage"
 )
 
+// defaultRelationTypeSize is the size of the embeddings of the entity types used by the relation classifier.
+const defaultRelationTypeSize = 32
+
 var (
 	_ nn.Model = &Model{}
 )
@@ -41,11 +48,13 @@ type Config struct {
 	IntermediateSize      int               `json:"intermediate_size"`
 	MaxPositionEmbeddings int               `json:"max_position_embeddings"`
 	NumAttentionHeads     int               `json:"num_attention_heads"`
+	NumKeyValueHeads      int               `json:"num_key_value_heads"` // only to refuse the GQA checkpoints
 	NumHiddenLayers       int               `json:"num_hidden_layers"`
 	TypeVocabSize         int               `json:"type_vocab_size"`
 	VocabSize             int               `json:"vocab_size"`
 	ID2Label              map[string]string `json:"id2label"`
-	Training              bool              `json:"training"` // Custom for spaGO
+	ID2Relation           map[string]string `json:"id2relation"` // Custom for spaGO
+	Training              bool              `json:"training"`    // Custom for spaGO
 }
 
 func init() {
@@ -67,6 +76,15 @@ func LoadConfig(file string) (Config, error) {
 	return config, nil
 }
 
+// hiddenActivation returns a new activation Model resolved from HiddenAct, with the default values
+// of its parameters, if any (e.g. the alpha of "elu"). It defaults to GELU if no activation is specified.
+func (c Config) hiddenActivation() *activation.Model {
+	if c.HiddenAct == "" {
+		return activation.New(ag.OpGELU)
+	}
+	return activation.MustNewFromName(c.HiddenAct)
+}
+
 // Model implements a BERT model.
 type Model struct {
 	nn.BaseModel
@@ -80,11 +98,15 @@ type Model struct {
 	SeqRelationship *linear.Model
 	SpanClassifier  *SpanClassifier
 	Classifier      *Classifier
+	// RelationClassifier classifies the relations between the entities recognized by the Classifier.
+	// It is nil if the configuration doesn't define any relation label (see Config.ID2Relation).
+	RelationClassifier *relations.Model
 }
 
 // NewDefaultBERT returns a new model based on the original BERT architecture.
 func NewDefaultBERT(config Config, embeddingsStoragePath string) *Model {
-	return &Model{
+	hiddenActivation := config.hiddenActivation().Activation
+	m := &Model{
 		Config:     config,
 		Vocabulary: nil,
 		Embeddings: NewEmbeddings(EmbeddingsConfig{
@@ -100,20 +122,20 @@ func NewDefaultBERT(config Config, embeddingsStoragePath string) *Model {
 			Size:                   config.HiddenSize,
 			NumOfAttentionHeads:    config.NumAttentionHeads,
 			IntermediateSize:       config.IntermediateSize,
-			IntermediateActivation: ag.OpGELU,
+			IntermediateActivation: hiddenActivation,
 			NumOfLayers:            config.NumHiddenLayers,
 		}),
 		Predictor: NewPredictor(PredictorConfig{
 			InputSize:        config.HiddenSize,
 			HiddenSize:       config.HiddenSize,
 			OutputSize:       config.VocabSize,
-			HiddenActivation: ag.OpGELU,
+			HiddenActivation: hiddenActivation,
 			OutputActivation: ag.OpIdentity, // implicit Softmax (trained with CrossEntropyLoss)
 		}),
 		Discriminator: NewDiscriminator(DiscriminatorConfig{
 			InputSize:        config.HiddenSize,
 			HiddenSize:       config.HiddenSize,
-			HiddenActivation: ag.OpGELU,
+			HiddenActivation: hiddenActivation,
 			OutputActivation: ag.OpIdentity, // implicit Sigmoid (trained with BCEWithLogitsLoss)
 		}),
 		Pooler: NewPooler(PoolerConfig{
@@ -130,22 +152,69 @@ func NewDefaultBERT(config Config, embeddingsStoragePath string) *Model {
 				if len(x) == 0 {
 					return []string{"LABEL_0", "LABEL_1"} // assume binary classification by default
 				}
-				y := make([]string, len(x))
-				for k, v := range x {
-					i, err := strconv.Atoi(k)
-					if err != nil {
-						log.Fatal(err)
-					}
-					y[i] = v
-				}
-				return y
+				return idToLabels(x)
 			}(config.ID2Label),
 		}),
+		RelationClassifier: newRelationClassifier(config),
+	}
+	m.setHiddenActivations()
+	return m
+}
+
+// setHiddenActivations replaces the hidden activations of the encoder layers, the predictor and the
+// discriminator, which are made of the operator alone, with the ones resolved from HiddenAct.
+func (m *Model) setHiddenActivations() {
+	for _, layer := range m.Encoder.Layers {
+		layer.(*transformer.EncoderLayer).FFN.(*stack.Model).Layers[1] = m.Config.hiddenActivation()
+	}
+	m.Predictor.Layers[1] = m.Config.hiddenActivation()
+	m.Discriminator.Layers[1] = m.Config.hiddenActivation()
+}
+
+// newRelationClassifier returns a new relation classifier on top of the entities recognized by the token
+// classifier, or nil if the configuration doesn't define any relation label.
+func newRelationClassifier(config Config) *relations.Model {
+	if len(config.ID2Relation) == 0 {
+		return nil
 	}
+	return relations.New(relations.Config{
+		InputSize:    config.HiddenSize,
+		HiddenSize:   config.HiddenSize,
+		Labels:       idToLabels(config.ID2Relation),
+		EntityLabels: entityLabels(idToLabels(config.ID2Label)),
+		TypeSize:     defaultRelationTypeSize,
+	})
 }
 
-// LoadModel loads a BERT Model from file.
-func LoadModel(modelPath string) (*Model, error) {
+// idToLabels returns the labels sorted by their (string) IDs, from "0" to len(x)-1.
+func idToLabels(x map[string]string) []string {
+	y := make([]string, len(x))
+	for k, v := range x {
+		i, err := strconv.Atoi(k)
+		if err != nil {
+			log.Fatal(err)
+		}
+		y[i] = v
+	}
+	return y
+}
+
+// entityLabels returns the distinct types of the entities of the IOB or IOBES labels (e.g. "PER" for "B-PER").
+func entityLabels(labels []string) []string {
+	seen := make(map[string]bool)
+	types := make([]string, 0)
+	for _, label := range labels {
+		if len(label) < 3 || label[1] != '-' || seen[label[2:]] {
+			continue
+		}
+		seen[label[2:]] = true
+		types = append(types, label[2:])
+	}
+	return types
+}
+
+// LoadModel loads a BERT Model from file. The options configure the loading of the model weights.
+func LoadModel(modelPath string, opts ...nn.LoadOption) (*Model, error) {
 	configFilename := path.Join(modelPath, DefaultConfigurationFile)
 	vocabFilename := path.Join(modelPath, DefaultVocabularyFile)
 	embeddingsFilename := path.Join(modelPath, DefaultEmbeddingsStorage)
@@ -169,10 +238,11 @@ func LoadModel(modelPath string) (*Model, error) {
 	model.Vocabulary = vocab
 
 	fmt.Printf("[3/3] Loading model weights... ")
-	err = utils.DeserializeFromFile(modelFilename, model)
+	_, err = nn.LoadModel(modelFilename, model, opts...)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("bert: error during model deserialization (%s)", err.Error()))
 	}
+	model.Encoder.upgradeLegacyLayers()
 	fmt.Println("ok")
 
 	return model, nil
@@ -184,6 +254,19 @@ func (m *Model) Encode(tokens []string) []ag.Node {
 	return m.Encoder.Forward(tokensEncoding...)
 }
 
+// EncodeWithPrompt is like Encode, but it prepends the soft prompt (reified in the same graph) to
+// the embeddings of the tokens. Only the encoding of the tokens is returned, so that the one of
+// [CLS] is the first as usual.
+func (m *Model) EncodeWithPrompt(tokens []string, prompt *softprompt.Model) []ag.Node {
+	return prompt.Encode(m.Encoder.Forward, m.Embeddings.Encode(tokens))
+}
+
+// EncodeWithOutputs transforms a string sequence into an encoded representation, returning the hidden
+// states and the attention weights of all the layers of the encoder (see EncoderOutput).
+func (m *Model) EncodeWithOutputs(tokens []string) EncoderOutput {
+	return m.Encoder.ForwardWithOutputs(m.Embeddings.Encode(tokens)...)
+}
+
 // PredictMasked performs a masked prediction task. It returns the predictions
 // for indices associated to the masked nodes.
 func (m *Model) PredictMasked(transformed []ag.Node, masked []int) map[int]ag.Node {
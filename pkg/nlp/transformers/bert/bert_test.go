@@ -0,0 +1,59 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/transformer"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConfig_HiddenActivation(t *testing.T) {
+	assert.Equal(t, ag.OpGELU, Config{}.hiddenActivation().Activation)
+	assert.Equal(t, ag.OpSiLU, Config{HiddenAct: "swish"}.hiddenActivation().Activation)
+
+	elu := Config{HiddenAct: "elu"}.hiddenActivation()
+	assert.Equal(t, ag.OpELU, elu.Activation)
+	assert.Len(t, elu.Params, 1)
+
+	assert.Panics(t, func() { Config{HiddenAct: "foo"}.hiddenActivation() })
+}
+
+func TestModel_SetHiddenActivations(t *testing.T) {
+	config := Config{HiddenAct: "elu"}
+	encoderConfig := newTestEncoderConfig()
+	m := &Model{
+		Config:  config,
+		Encoder: NewBertEncoder(encoderConfig),
+		Predictor: NewPredictor(PredictorConfig{
+			InputSize:        8,
+			HiddenSize:       8,
+			OutputSize:       4,
+			HiddenActivation: ag.OpELU,
+			OutputActivation: ag.OpIdentity,
+		}),
+		Discriminator: NewDiscriminator(DiscriminatorConfig{
+			InputSize:        8,
+			HiddenSize:       8,
+			HiddenActivation: ag.OpELU,
+			OutputActivation: ag.OpIdentity,
+		}),
+	}
+	m.setHiddenActivations()
+
+	for _, layer := range m.Encoder.Layers {
+		ffn := layer.(*transformer.EncoderLayer).FFN.(*stack.Model)
+		assert.Len(t, ffn.Layers[1].(*activation.Model).Params, 1)
+	}
+	assert.Len(t, m.Predictor.Layers[1].(*activation.Model).Params, 1)
+	assert.Len(t, m.Discriminator.Layers[1].(*activation.Model).Params, 1)
+
+	initRandom(m.Encoder)
+	out := forwardWithOutputs(m.Encoder, 2)
+	assert.Len(t, out.HiddenStates, encoderConfig.NumOfLayers+1)
+}
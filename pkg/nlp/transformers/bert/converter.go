@@ -12,9 +12,10 @@ import (
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/transformer"
 	"github.com/nlpodyssey/spago/pkg/nlp/embeddings"
 	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
-	"github.com/nlpodyssey/spago/pkg/utils"
 	"github.com/nlpodyssey/spago/pkg/utils/gopickleutils"
 	"log"
 	"os"
@@ -46,6 +47,11 @@ func ConvertHuggingFacePreTrained(modelPath string) error {
 	if err != nil {
 		return err
 	}
+	if config.NumKeyValueHeads != 0 && config.NumKeyValueHeads != config.NumAttentionHeads {
+		// see groupedqueryattention.Model.LoadHuggingFaceParams
+		return fmt.Errorf("bert: the grouped-query attention (%d key/value heads) is not supported by BERT",
+			config.NumKeyValueHeads)
+	}
 	// Enable training mode, so that we have writing permissions
 	// (for example, for embeddings storage files).
 	config.Training = true
@@ -141,7 +147,7 @@ func (c *huggingFacePreTrainedConverter) convert() error {
 }
 
 func (c *huggingFacePreTrainedConverter) serializeModel() error {
-	err := utils.SerializeToFile(c.modelFilename, c.model)
+	err := nn.SaveModel(c.modelFilename, c.model)
 	if err != nil {
 		return fmt.Errorf("bert: error during model serialization: %w", err)
 	}
@@ -259,31 +265,32 @@ func dumpWordEmbeddings(source []mat.Float, dest *embeddings.Model, vocabulary *
 func mapBertEncoder(model *Encoder) map[string]mat.Matrix {
 	paramsMap := make(map[string]mat.Matrix)
 	for i := 0; i < model.NumOfLayers; i++ {
-		layer := model.Layers[i].(*EncoderLayer)
+		layer := model.Layers[i].(*transformer.EncoderLayer)
 		prefixBase := fmt.Sprintf("bert.encoder.layer.%d", i)
 		// Sublayer 1
 		for j := 0; j < model.EncoderConfig.NumOfAttentionHeads; j++ {
-			attention := layer.MultiHeadAttention.Attention[j]
+			attention := layer.SelfAttention
 			prefix := fmt.Sprintf("%s.%d.attention.self", prefixBase, j)
-			paramsMap[fmt.Sprintf("%s.query.weight", prefix)] = attention.Query.W.Value()
-			paramsMap[fmt.Sprintf("%s.query.bias", prefix)] = attention.Query.B.Value()
-			paramsMap[fmt.Sprintf("%s.key.weight", prefix)] = attention.Key.W.Value()
-			paramsMap[fmt.Sprintf("%s.key.bias", prefix)] = attention.Key.B.Value()
-			paramsMap[fmt.Sprintf("%s.value.weight", prefix)] = attention.Value.W.Value()
-			paramsMap[fmt.Sprintf("%s.value.bias", prefix)] = attention.Value.B.Value()
+			paramsMap[fmt.Sprintf("%s.query.weight", prefix)] = attention.Query[j].W.Value()
+			paramsMap[fmt.Sprintf("%s.query.bias", prefix)] = attention.Query[j].B.Value()
+			paramsMap[fmt.Sprintf("%s.key.weight", prefix)] = attention.Key[j].W.Value()
+			paramsMap[fmt.Sprintf("%s.key.bias", prefix)] = attention.Key[j].B.Value()
+			paramsMap[fmt.Sprintf("%s.value.weight", prefix)] = attention.Value[j].W.Value()
+			paramsMap[fmt.Sprintf("%s.value.bias", prefix)] = attention.Value[j].B.Value()
 		}
 		prefix := fmt.Sprintf("bert.encoder.layer.%d.attention", i)
-		paramsMap[fmt.Sprintf("%s.output.dense.weight", prefix)] = layer.MultiHeadAttention.OutputMerge.W.Value()
-		paramsMap[fmt.Sprintf("%s.output.dense.bias", prefix)] = layer.MultiHeadAttention.OutputMerge.B.Value()
-		paramsMap[fmt.Sprintf("%s.output.LayerNorm.weight", prefix)] = layer.NormAttention.W.Value()
-		paramsMap[fmt.Sprintf("%s.output.LayerNorm.bias", prefix)] = layer.NormAttention.B.Value()
+		paramsMap[fmt.Sprintf("%s.output.dense.weight", prefix)] = layer.SelfAttention.OutputMerge.W.Value()
+		paramsMap[fmt.Sprintf("%s.output.dense.bias", prefix)] = layer.SelfAttention.OutputMerge.B.Value()
+		paramsMap[fmt.Sprintf("%s.output.LayerNorm.weight", prefix)] = layer.SelfAttentionLayerNorm.W.Value()
+		paramsMap[fmt.Sprintf("%s.output.LayerNorm.bias", prefix)] = layer.SelfAttentionLayerNorm.B.Value()
 		// Sublayer 2
-		paramsMap[fmt.Sprintf("%s.intermediate.dense.weight", prefixBase)] = layer.FFN.Layers[0].(*linear.Model).W.Value()
-		paramsMap[fmt.Sprintf("%s.intermediate.dense.bias", prefixBase)] = layer.FFN.Layers[0].(*linear.Model).B.Value()
-		paramsMap[fmt.Sprintf("%s.output.dense.weight", prefixBase)] = layer.FFN.Layers[2].(*linear.Model).W.Value()
-		paramsMap[fmt.Sprintf("%s.output.dense.bias", prefixBase)] = layer.FFN.Layers[2].(*linear.Model).B.Value()
-		paramsMap[fmt.Sprintf("%s.output.LayerNorm.weight", prefixBase)] = layer.NormFFN.W.Value()
-		paramsMap[fmt.Sprintf("%s.output.LayerNorm.bias", prefixBase)] = layer.NormFFN.B.Value()
+		ffn := layer.FFN.(*stack.Model)
+		paramsMap[fmt.Sprintf("%s.intermediate.dense.weight", prefixBase)] = ffn.Layers[0].(*linear.Model).W.Value()
+		paramsMap[fmt.Sprintf("%s.intermediate.dense.bias", prefixBase)] = ffn.Layers[0].(*linear.Model).B.Value()
+		paramsMap[fmt.Sprintf("%s.output.dense.weight", prefixBase)] = ffn.Layers[2].(*linear.Model).W.Value()
+		paramsMap[fmt.Sprintf("%s.output.dense.bias", prefixBase)] = ffn.Layers[2].(*linear.Model).B.Value()
+		paramsMap[fmt.Sprintf("%s.output.LayerNorm.weight", prefixBase)] = layer.FFNLayerNorm.W.Value()
+		paramsMap[fmt.Sprintf("%s.output.LayerNorm.bias", prefixBase)] = layer.FFNLayerNorm.B.Value()
 	}
 	return paramsMap
 }
@@ -93,8 +93,13 @@ func newProjector(in, out int) *linear.Model {
 
 // Encode transforms a string sequence into an encoded representation.
 func (m *Embeddings) Encode(words []string) []ag.Node {
+	return m.encodeWordEmbeddings(words, m.getWordEmbeddings(words))
+}
+
+// encodeWordEmbeddings is like Encode, but it takes the word embeddings of the words as input,
+// e.g. to compute the gradients with respect to them.
+func (m *Embeddings) encodeWordEmbeddings(words []string, wordEmbeddings []ag.Node) []ag.Node {
 	encoded := make([]ag.Node, len(words))
-	wordEmbeddings := m.getWordEmbeddings(words)
 	sequenceIndex := 0
 	for i := 0; i < len(words); i++ {
 		encoded[i] = wordEmbeddings[i]
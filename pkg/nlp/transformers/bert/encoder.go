@@ -14,13 +14,11 @@ package bert
 
 import (
 	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/multiheadattention"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
-	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/transformer"
 )
 
 var (
@@ -37,7 +35,18 @@ type EncoderConfig struct {
 	NumOfLayers            int
 }
 
-// Encoder is a BERT Encoder model.
+// transformerConfig returns the configuration of the post-norm transformer.EncoderLayer of BERT.
+func (c EncoderConfig) transformerConfig() transformer.Config {
+	return transformer.Config{
+		Size:        c.Size,
+		NumOfLayers: c.NumOfLayers,
+		NumOfHeads:  c.NumOfAttentionHeads,
+		FFNSize:     c.IntermediateSize,
+		Activation:  c.IntermediateActivation,
+	}
+}
+
+// Encoder is a BERT Encoder model, made of a stack of transformer.EncoderLayer.
 type Encoder struct {
 	EncoderConfig
 	*stack.Model
@@ -51,22 +60,8 @@ func init() {
 func NewBertEncoder(config EncoderConfig) *Encoder {
 	return &Encoder{
 		EncoderConfig: config,
-		Model: stack.Make(config.NumOfLayers, func(i int) nn.StandardModel {
-			return &EncoderLayer{
-				MultiHeadAttention: multiheadattention.New(
-					config.Size,
-					config.NumOfAttentionHeads,
-					false, // don't use causal mask
-				),
-				NormAttention: layernorm.New(config.Size),
-				FFN: stack.New(
-					linear.New(config.Size, config.IntermediateSize),
-					activation.New(config.IntermediateActivation),
-					linear.New(config.IntermediateSize, config.Size),
-				),
-				NormFFN: layernorm.New(config.Size),
-				Index:   i,
-			}
+		Model: stack.Make(config.NumOfLayers, func(_ int) nn.StandardModel {
+			return transformer.NewEncoderLayer(config.transformerConfig())
 		}),
 	}
 }
@@ -74,20 +69,7 @@ func NewBertEncoder(config EncoderConfig) *Encoder {
 // NewAlbertEncoder returns a new variant of the BERT encoder model.
 // In this variant the stack of N identical BERT encoder layers share the same parameters.
 func NewAlbertEncoder(config EncoderConfig) *Encoder {
-	sharedLayer := &EncoderLayer{
-		MultiHeadAttention: multiheadattention.New(
-			config.Size,
-			config.NumOfAttentionHeads,
-			false, // don't use causal mask
-		),
-		NormAttention: layernorm.New(config.Size),
-		FFN: stack.New(
-			linear.New(config.Size, config.IntermediateSize),
-			activation.New(config.IntermediateActivation),
-			linear.New(config.IntermediateSize, config.Size),
-		),
-		NormFFN: layernorm.New(config.Size),
-	}
+	sharedLayer := transformer.NewEncoderLayer(config.transformerConfig())
 	return &Encoder{
 		EncoderConfig: config,
 		Model: stack.Make(config.NumOfLayers, func(_ int) nn.StandardModel {
@@ -95,3 +77,49 @@ func NewAlbertEncoder(config EncoderConfig) *Encoder {
 		}),
 	}
 }
+
+// upgradeLegacyLayers replaces the legacy EncoderLayer of the models serialized by the older
+// versions of spaGO with equivalent transformer.EncoderLayer, sharing the same parameters.
+func (m *Encoder) upgradeLegacyLayers() {
+	upgraded := make(map[*EncoderLayer]*transformer.EncoderLayer)
+	for i, layer := range m.Layers {
+		legacy, ok := layer.(*EncoderLayer)
+		if !ok {
+			continue
+		}
+		if _, ok := upgraded[legacy]; !ok { // the layers of ALBERT are shared
+			upgraded[legacy] = legacy.toTransformer(m.EncoderConfig)
+		}
+		m.Layers[i] = upgraded[legacy]
+	}
+}
+
+// EncoderOutput contains the hidden states and the attention weights of all the layers of the Encoder.
+type EncoderOutput struct {
+	// HiddenStates are the input of the Encoder (i.e. the embeddings), followed by the output of each layer.
+	HiddenStates [][]ag.Node
+	// Attentions are the self-attention weights of each layer, for each head and each position (query).
+	Attentions [][][]mat.Matrix
+}
+
+// attentionLayer is implemented by the layers of the Encoder, both transformer.EncoderLayer and
+// the legacy EncoderLayer.
+type attentionLayer interface {
+	ForwardWithAttention(xs ...ag.Node) ([]ag.Node, [][]mat.Matrix)
+}
+
+// ForwardWithOutputs performs the forward step for each input node, and returns the hidden states
+// and the attention weights of all the layers. The last hidden states are the result of Forward.
+func (m *Encoder) ForwardWithOutputs(xs ...ag.Node) EncoderOutput {
+	out := EncoderOutput{
+		HiddenStates: [][]ag.Node{xs},
+		Attentions:   make([][][]mat.Matrix, 0, len(m.Layers)),
+	}
+	for _, layer := range m.Layers {
+		ys, weights := layer.(attentionLayer).ForwardWithAttention(xs...)
+		out.HiddenStates = append(out.HiddenStates, ys)
+		out.Attentions = append(out.Attentions, weights)
+		xs = ys
+	}
+	return out
+}
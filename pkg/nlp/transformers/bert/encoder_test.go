@@ -0,0 +1,136 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"bytes"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/multiheadattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/transformer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEncoder_UpgradeLegacyLayers(t *testing.T) {
+	for _, shared := range []bool{false, true} {
+		config := newTestEncoderConfig()
+		model := newLegacyEncoder(config, shared)
+		initRandom(model)
+
+		expected := forwardWithOutputs(model, 3)
+		model.upgradeLegacyLayers()
+		actual := forwardWithOutputs(model, 3)
+
+		for _, layer := range model.Layers {
+			assert.IsType(t, &transformer.EncoderLayer{}, layer)
+		}
+		assert.Equal(t, shared, model.Layers[0] == model.Layers[1])
+		assertEqualOutputs(t, expected, actual)
+	}
+}
+
+func TestEncoder_UpgradeLegacyLayersFromModelFile(t *testing.T) {
+	config := newTestEncoderConfig()
+	legacy := newLegacyEncoder(config, false)
+	initRandom(legacy)
+	expected := forwardWithOutputs(legacy, 3)
+
+	var buf bytes.Buffer
+	require.NoError(t, nn.WriteModel(&buf, legacy))
+	model := NewBertEncoder(config)
+	_, err := nn.ReadModel(bytes.NewReader(buf.Bytes()), int64(buf.Len()), model)
+	require.NoError(t, err)
+	assert.IsType(t, &EncoderLayer{}, model.Layers[0])
+
+	model.upgradeLegacyLayers()
+	assertEqualOutputs(t, expected, forwardWithOutputs(model, 3))
+}
+
+func newTestEncoderConfig() EncoderConfig {
+	return EncoderConfig{
+		Size:                   8,
+		NumOfAttentionHeads:    2,
+		IntermediateSize:       16,
+		IntermediateActivation: ag.OpGELU,
+		NumOfLayers:            2,
+	}
+}
+
+// newLegacyEncoder returns an Encoder made of legacy EncoderLayer, as the older versions of spaGO.
+func newLegacyEncoder(config EncoderConfig, shared bool) *Encoder {
+	newLayer := func(i int) nn.StandardModel {
+		return &EncoderLayer{
+			MultiHeadAttention: multiheadattention.New(config.Size, config.NumOfAttentionHeads, false),
+			NormAttention:      layernorm.New(config.Size),
+			FFN: stack.New(
+				linear.New(config.Size, config.IntermediateSize),
+				activation.New(config.IntermediateActivation),
+				linear.New(config.IntermediateSize, config.Size),
+			),
+			NormFFN: layernorm.New(config.Size),
+			Index:   i,
+		}
+	}
+	sharedLayer := newLayer(0)
+	return &Encoder{
+		EncoderConfig: config,
+		Model: stack.Make(config.NumOfLayers, func(i int) nn.StandardModel {
+			if shared {
+				return sharedLayer
+			}
+			return newLayer(i)
+		}),
+	}
+}
+
+func forwardWithOutputs(model *Encoder, n int) EncoderOutput {
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, model).(*Encoder)
+	rndGen := rand.NewLockedRand(1)
+	xs := make([]ag.Node, n)
+	for i := range xs {
+		x := mat.NewEmptyVecDense(model.Size)
+		initializers.Uniform(x, -1, 1, rndGen)
+		xs[i] = g.NewVariable(x, false)
+	}
+	return proc.ForwardWithOutputs(xs...)
+}
+
+func assertEqualOutputs(t *testing.T, expected, actual EncoderOutput) {
+	require.Len(t, actual.HiddenStates, len(expected.HiddenStates))
+	for i, states := range expected.HiddenStates {
+		for j, state := range states {
+			assert.InDeltaSlice(t, state.Value().Data(), actual.HiddenStates[i][j].Value().Data(), 1.0e-5)
+		}
+	}
+	require.Len(t, actual.Attentions, len(expected.Attentions))
+	for i, heads := range expected.Attentions {
+		for j, head := range heads {
+			for k, weights := range head {
+				assert.InDeltaSlice(t, weights.Data(), actual.Attentions[i][j][k].Data(), 1.0e-5)
+			}
+		}
+	}
+}
+
+func initRandom(model nn.Model) {
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		if param.Type() == nn.Weights {
+			initializers.XavierUniform(param.Value(), 1.0, rndGen)
+		} else {
+			initializers.Uniform(param.Value(), -0.1, 0.1, rndGen)
+		}
+	})
+}
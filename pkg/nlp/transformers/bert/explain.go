@@ -0,0 +1,121 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"runtime"
+)
+
+// IntegratedGradients returns the attribution of each token to the probability of the target class
+// of the sequence classification, by integrated gradients (Sundararajan et al., 2017,
+// https://arxiv.org/abs/1703.01365). The gradients of the probability with respect to the word
+// embeddings are averaged along the straight path from a baseline, where the word embeddings are
+// zero, to the input, approximated with the given number of steps; the attribution of a token is
+// the dot product of its average gradients with its word embedding.
+// The special tokens keep their embeddings in the baseline, so their attribution is zero.
+// The attributions sum up approximately to the difference of the probabilities of the target
+// class for the input and for the baseline.
+func (m *Model) IntegratedGradients(tokens []string, target, steps int) []mat.Float {
+	if steps < 1 {
+		steps = 1
+	}
+	attributions := make([]mat.Float, len(tokens))
+	for step := 1; step <= steps; step++ {
+		alpha := mat.Float(step) / mat.Float(steps)
+		for i, a := range m.scaledEmbeddingsAttributions(tokens, target, alpha) {
+			attributions[i] += a / mat.Float(steps)
+		}
+	}
+	return attributions
+}
+
+// scaledEmbeddingsAttributions computes the probability of the target class with the word
+// embeddings scaled by alpha, and returns, for each token, the dot product of its word embedding
+// with the gradients of the probability with respect to the scaled embedding.
+func (m *Model) scaledEmbeddingsAttributions(tokens []string, target int, alpha mat.Float) []mat.Float {
+	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, m).(*Model)
+
+	embeddings := make([]mat.Matrix, len(tokens))
+	inputs := make([]ag.Node, len(tokens))
+	for i, embedding := range proc.Embeddings.getWordEmbeddings(tokens) {
+		embeddings[i] = embedding.Value()
+		if wordpiecetokenizer.IsDefaultSpecial(tokens[i]) {
+			inputs[i] = embedding
+			continue
+		}
+		inputs[i] = g.NewVariable(embeddings[i].ProdScalar(alpha), true)
+	}
+	encoded := proc.Encoder.Forward(proc.Embeddings.encodeWordEmbeddings(tokens, inputs)...)
+	probs := g.Softmax(proc.SequenceClassification(encoded))
+	g.Backward(g.AtVec(probs, target))
+
+	attributions := make([]mat.Float, len(tokens))
+	for i, input := range inputs {
+		if wordpiecetokenizer.IsDefaultSpecial(tokens[i]) || !input.HasGrad() {
+			continue
+		}
+		attributions[i] = embeddings[i].DotUnitary(input.Grad())
+	}
+	return attributions
+}
+
+// AttentionRollout returns the importance of each token for the representation of the token at
+// the given position (e.g. 0 for the [CLS] token used by the sequence classification), by attention
+// rollout (Abnar and Zuidema, 2020, https://arxiv.org/abs/2005.00928): the attention weights of each
+// layer are averaged over the heads, mixed with the identity to account for the residual
+// connections, and multiplied across the layers. The importances sum up to 1.
+// The attentions are the ones of EncoderOutput.
+func AttentionRollout(attentions [][][]mat.Matrix, position int) []mat.Float {
+	if len(attentions) == 0 {
+		return nil
+	}
+	n := len(attentions[0][0])
+	rollout := identity(n)
+	for _, heads := range attentions {
+		layer := make([][]mat.Float, n)
+		for q := 0; q < n; q++ {
+			layer[q] = make([]mat.Float, n)
+			for _, head := range heads {
+				for k, w := range head[q].Data() {
+					layer[q][k] += 0.5 * w / mat.Float(len(heads))
+				}
+			}
+			layer[q][q] += 0.5 // the rows still sum up to 1
+		}
+		rollout = matMul(layer, rollout)
+	}
+	return rollout[position]
+}
+
+func identity(n int) [][]mat.Float {
+	m := make([][]mat.Float, n)
+	for i := range m {
+		m[i] = make([]mat.Float, n)
+		m[i][i] = 1
+	}
+	return m
+}
+
+func matMul(a, b [][]mat.Float) [][]mat.Float {
+	c := make([][]mat.Float, len(a))
+	for i := range a {
+		c[i] = make([]mat.Float, len(b[0]))
+		for k, aik := range a[i] {
+			if aik == 0 {
+				continue
+			}
+			for j, bkj := range b[k] {
+				c[i][j] += aik * bkj
+			}
+		}
+	}
+	return c
+}
@@ -6,19 +6,26 @@ package bert
 
 import (
 	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/attention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/groupedqueryattention"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/attention/multiheadattention"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/normalization/layernorm"
 	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/transformer"
 )
 
 var (
 	_ nn.Model = &EncoderLayer{}
 )
 
-// EncoderLayer is a BERT Encoder Layer model.
+// EncoderLayer is the BERT Encoder Layer model of the older versions of spaGO, which is kept to read
+// the models serialized with it. LoadModel replaces it with the equivalent transformer.EncoderLayer.
+//
+// Deprecated: the Encoder is made of transformer.EncoderLayer.
 type EncoderLayer struct {
 	nn.BaseModel
 	MultiHeadAttention *multiheadattention.Model
@@ -34,12 +41,20 @@ func init() {
 
 // Forward performs the forward step for each input node and returns the result.
 func (m *EncoderLayer) Forward(xs ...ag.Node) []ag.Node {
-	return m.fullyConnectedBlock(m.selfAttentionBlock(xs))
+	ys, _ := m.ForwardWithAttention(xs...)
+	return ys
 }
 
-func (m *EncoderLayer) selfAttentionBlock(xs []ag.Node) []ag.Node {
-	selfAtt := m.MultiHeadAttention.Forward(attention.ToQKV(xs)).AttOutput
-	return m.NormAttention.Forward(m.add(xs, selfAtt)...)
+// ForwardWithAttention performs the forward step for each input node and returns the result,
+// together with the self-attention weights of each head, for each input node (query).
+func (m *EncoderLayer) ForwardWithAttention(xs ...ag.Node) ([]ag.Node, [][]mat.Matrix) {
+	selfAtt, weights := m.selfAttentionBlock(xs)
+	return m.fullyConnectedBlock(selfAtt), weights
+}
+
+func (m *EncoderLayer) selfAttentionBlock(xs []ag.Node) ([]ag.Node, [][]mat.Matrix) {
+	selfAtt := m.MultiHeadAttention.Forward(attention.ToQKV(xs))
+	return m.NormAttention.Forward(m.add(xs, selfAtt.AttOutput)...), selfAtt.AttWeights
 }
 
 func (m *EncoderLayer) fullyConnectedBlock(xs []ag.Node) []ag.Node {
@@ -53,3 +68,30 @@ func (m *EncoderLayer) add(a, b []ag.Node) []ag.Node {
 	}
 	return c
 }
+
+// toTransformer returns the transformer.EncoderLayer equivalent to the layer, sharing the same
+// parameters: each attention head becomes a grouped-query attention head with its own key/value head.
+func (m *EncoderLayer) toTransformer(config EncoderConfig) *transformer.EncoderLayer {
+	mha := m.MultiHeadAttention
+	att := &groupedqueryattention.Model{
+		Config: groupedqueryattention.Config{
+			Size:               mha.Dm,
+			NumOfHeads:         mha.NumOfHeads,
+			NumOfKeyValueHeads: mha.NumOfHeads,
+		},
+		Query:       make([]*linear.Model, mha.NumOfHeads),
+		Key:         make([]*linear.Model, mha.NumOfHeads),
+		Value:       make([]*linear.Model, mha.NumOfHeads),
+		OutputMerge: mha.OutputMerge,
+	}
+	for h, head := range mha.Attention {
+		att.Query[h], att.Key[h], att.Value[h] = head.Query, head.Key, head.Value
+	}
+	return &transformer.EncoderLayer{
+		Config:                 config.transformerConfig(),
+		SelfAttention:          att,
+		SelfAttentionLayerNorm: m.NormAttention,
+		FFN:                    m.FFN,
+		FFNLayerNorm:           m.NormFFN,
+	}
+}
@@ -10,14 +10,20 @@ import (
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	matsort "github.com/nlpodyssey/spago/pkg/mat32/sort"
 	"github.com/nlpodyssey/spago/pkg/webui/bertclassification"
+	"github.com/nlpodyssey/spago/pkg/webui/console"
 	"net/http"
 	"sort"
+	"sync"
 
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/calibration"
+	"github.com/nlpodyssey/spago/pkg/ml/fewshot"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
+	"github.com/nlpodyssey/spago/pkg/plugins"
 	"github.com/nlpodyssey/spago/pkg/utils/grpcutils"
 	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"github.com/nlpodyssey/spago/pkg/utils/lrucache"
 	"github.com/nlpodyssey/spago/pkg/webui/bertqa"
 )
 
@@ -28,6 +34,23 @@ type Server struct {
 	model           *Model
 	TimeoutSeconds  int
 	MaxRequestBytes int
+	// Routes are the additional HTTP routes of the plugins (see the plugins package).
+	Routes []plugins.Route
+	// Cache, if not nil, holds the responses of the classify, answer and encode requests, so that
+	// the identical requests are not computed again.
+	Cache *lrucache.Cache
+	// AnswerTemperature calibrates the confidence of the answers (see FitAnswerTemperature).
+	// The default 0 is the same as 1, i.e. no calibration.
+	AnswerTemperature mat.Float
+	// Calibrator, if not nil, calibrates the probabilities of the sequence classification
+	// (see FitCalibrator and the calibration package).
+	Calibrator calibration.Calibrator
+	// AdminAddress, if not empty, is the address of a separate listener serving the debug
+	// endpoints under /debug/ (see httputils.RunAdminServer).
+	AdminAddress string
+	// fewShotSets are the few-shot classifiers by name (see FewShotExamplesHandler).
+	fewShotSets map[string]*fewshot.Classifier
+	fewShotMu   sync.Mutex
 
 	// UnimplementedBERTServer must be embedded to have forward compatible implementations for gRPC.
 	grpcapi.UnimplementedBERTServer
@@ -47,12 +70,24 @@ func (s *Server) StartDefaultServer(address, grpcAddress, tlsCert, tlsKey string
 	mux := http.NewServeMux()
 	mux.HandleFunc("/bert-qa-ui", bertqa.Handler)
 	mux.HandleFunc("/bert-classify-ui", bertclassification.Handler)
+	mux.HandleFunc("/console-ui", console.Handler)
 	mux.HandleFunc("/discriminate", s.DiscriminateHandler)
 	mux.HandleFunc("/predict", s.PredictHandler)
 	mux.HandleFunc("/answer", s.QaHandler)
 	mux.HandleFunc("/tag", s.LabelerHandler)
+	mux.HandleFunc("/relations", s.RelationsHandler)
 	mux.HandleFunc("/classify", s.ClassifyHandler)
+	mux.HandleFunc("/classify-pair", s.PairClassifyHandler)
 	mux.HandleFunc("/encode", s.SentenceEncoderHandler)
+	mux.HandleFunc("/explain", s.ExplainHandler)
+	mux.HandleFunc("/few-shot/examples", s.FewShotExamplesHandler)
+	mux.HandleFunc("/few-shot/classify", s.FewShotClassifyHandler)
+	for _, route := range s.Routes {
+		mux.HandleFunc(route.Pattern, route.Handler)
+	}
+	if s.AdminAddress != "" {
+		go httputils.RunAdminServer(s.AdminAddress)
+	}
 
 	go httputils.RunHTTPServer(httputils.HTTPServerConfig{
 		Address:         address,
@@ -74,17 +109,50 @@ func (s *Server) StartDefaultServer(address, grpcAddress, tlsCert, tlsKey string
 	grpcutils.RunGRPCServer(grpcAddress, grpcServer)
 }
 
+// cached returns the cached response of the request identified by the key parts, or the one
+// computed by the given function, which is then cached unless it fails. The cache is used only if enabled.
+// The responses are shared by the requests, so they must not be modified.
+func (s *Server) cached(compute func() (interface{}, error), key ...interface{}) (interface{}, error) {
+	if s.Cache == nil {
+		return compute()
+	}
+	k, err := lrucache.Key(key...)
+	if err != nil {
+		return compute()
+	}
+	if response, ok := s.Cache.Get(k); ok {
+		return response, nil
+	}
+	response, err := compute()
+	if err == nil {
+		s.Cache.Add(k, response)
+	}
+	return response, err
+}
+
 // Body is the JSON-serializable expected request body for various BERT server requests.
 type Body struct {
 	Text            string                                `json:"text"`
 	Text2           string                                `json:"text2"`
 	PoolingStrategy grpcapi.EncodeRequest_PoolingStrategy `json:"pooling_strategy"`
+	// Normalize is used by the "encode" requests to normalize the vector to unit length (L2 norm).
+	Normalize bool `json:"normalize"`
+	// OutputHiddenStates and OutputAttentions are used by the "encode" requests to return
+	// the hidden states and the attention weights of all the layers of the encoder.
+	OutputHiddenStates bool `json:"output_hidden_states"`
+	OutputAttentions   bool `json:"output_attentions"`
+	// Debug is used by the "classify" requests to return the raw logits.
+	Debug bool `json:"debug"`
 }
 
 // QABody is the JSON-serializable expected request body for BERT question-answering server requests.
 type QABody struct {
 	Question string `json:"question"`
 	Passage  string `json:"passage"`
+	// TopN, if positive, is the number of candidate answers to return, whatever their confidence.
+	TopN int `json:"top_n"`
+	// Debug returns the logits and the probabilities of the start and end positions of each token.
+	Debug bool `json:"debug"`
 }
 
 func pad(words []string) []string {
@@ -112,6 +180,10 @@ type Answer struct {
 	Start      int       `json:"start"`
 	End        int       `json:"end"`
 	Confidence mat.Float `json:"confidence"`
+	// StartProbability and EndProbability are the probabilities of the start and of the end
+	// positions of the answer, among the positions of the passage.
+	StartProbability mat.Float `json:"start_probability"`
+	EndProbability   mat.Float `json:"end_probability"`
 }
 
 // AnswerSlice is a slice of Answer elements, which implements the sort.Interface.
@@ -138,10 +210,25 @@ func (p AnswerSlice) Sort() {
 	sort.Sort(p)
 }
 
+// AnswerToken is a JSON-serializable word piece of the passage of a question-answering request,
+// with the logits and the probabilities of its start and end positions.
+type AnswerToken struct {
+	Text             string    `json:"text"`
+	Start            int       `json:"start"`
+	End              int       `json:"end"`
+	StartLogit       mat.Float `json:"start_logit"`
+	EndLogit         mat.Float `json:"end_logit"`
+	StartProbability mat.Float `json:"start_probability"`
+	EndProbability   mat.Float `json:"end_probability"`
+}
+
 // QuestionAnsweringResponse is the JSON-serializable structure for BERT
 // question-answering server response.
 type QuestionAnsweringResponse struct {
 	Answers AnswerSlice `json:"answers"`
+	// Tokens are the word pieces of the passage with the logits and the probabilities of their
+	// start and end positions, returned on debug requests.
+	Tokens []AnswerToken `json:"tokens,omitempty"`
 	// Took is the number of milliseconds it took the server to execute the request.
 	Took int64 `json:"took"`
 }
@@ -181,6 +268,10 @@ type Token struct {
 	Start int    `json:"start"`
 	End   int    `json:"end"`
 	Label string `json:"label"`
+	// Logits and Probabilities are the raw logits and the probabilities of the labels, in the order
+	// of the labels of the classifier, returned on debug requests.
+	Logits        []mat.Float `json:"logits,omitempty"`
+	Probabilities []mat.Float `json:"probabilities,omitempty"`
 }
 
 // TokenSlice is a slice of Token elements, which implements the sort.Interface.
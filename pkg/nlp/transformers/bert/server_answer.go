@@ -16,6 +16,7 @@ import (
 
 	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/calibration"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
@@ -34,7 +35,11 @@ func (s *Server) QaHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	result := s.answer(body.Question, body.Passage)
+	result, err := s.cachedAnswer(req.Context(), body.Question, body.Passage, body.TopN, body.Debug)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
 	if err != nil {
@@ -52,7 +57,10 @@ func (s *Server) QaHandler(w http.ResponseWriter, req *http.Request) {
 // Answer handles a question-answering request over gRPC.
 // TODO(evanmcclure@gmail.com) Reuse the gRPC message type for HTTP requests.
 func (s *Server) Answer(ctx context.Context, req *grpcapi.AnswerRequest) (*grpcapi.AnswerReply, error) {
-	result := s.answer(req.GetQuestion(), req.GetPassage())
+	result, err := s.cachedAnswer(ctx, req.GetQuestion(), req.GetPassage(), 0, false)
+	if err != nil {
+		return nil, err
+	}
 
 	return &grpcapi.AnswerReply{
 		Answers: answersFrom(result),
@@ -75,10 +83,80 @@ func answersFrom(resp *QuestionAnsweringResponse) []*grpcapi.Answer {
 	return result
 }
 
-// TODO: This method is too long; it needs to be refactored.
-func (s *Server) answer(question string, passage string) *QuestionAnsweringResponse {
+// cachedAnswer is like answer, but it uses the response cache, if enabled.
+func (s *Server) cachedAnswer(ctx context.Context, question string, passage string, topN int, debug bool) (*QuestionAnsweringResponse, error) {
+	response, err := s.cached(func() (interface{}, error) {
+		return s.answer(ctx, question, passage, topN, debug)
+	}, "answer", question, passage, topN, debug)
+	if err != nil {
+		return nil, err
+	}
+	return response.(*QuestionAnsweringResponse), nil
+}
+
+// answer returns the answers to the question found in the passage, sorted by confidence.
+// If topN is positive, it returns the best topN candidate answers, whatever their confidence;
+// otherwise, it returns up to defaultMaxAnswers answers with at least defaultMinConfidence.
+// The confidences are calibrated with the AnswerTemperature of the server.
+// If debug is true, the response includes the logits and the probabilities of the tokens of the passage.
+// The computation stops as soon as the context is done.
+func (s *Server) answer(ctx context.Context, question string, passage string, topN int, debug bool) (*QuestionAnsweringResponse, error) {
 	start := time.Now()
 
+	maxCandidateLogits := int(defaultMaxCandidateLogits)
+	if topN > maxCandidateLogits {
+		maxCandidateLogits = topN
+	}
+	candidateAnswers, scores, tokens, err := s.answerCandidates(ctx, question, passage, maxCandidateLogits)
+	if err != nil {
+		return nil, err
+	}
+	if !debug {
+		tokens = nil
+	}
+	if len(candidateAnswers) == 0 {
+		return &QuestionAnsweringResponse{
+			Answers: AnswerSlice{},
+			Tokens:  tokens,
+		}, nil
+	}
+
+	probs := calibration.SoftMaxWithTemperature(scores, s.AnswerTemperature)
+	answers := make(AnswerSlice, 0)
+	for i, candidate := range candidateAnswers {
+		if topN > 0 || probs[i] >= defaultMinConfidence {
+			candidate.Confidence = probs[i]
+			answers = append(answers, candidate)
+		}
+	}
+
+	maxAnswers := defaultMaxAnswers
+	if topN > 0 {
+		maxAnswers = topN
+	}
+	sort.Stable(sort.Reverse(answers))
+	if len(answers) > maxAnswers {
+		answers = answers[:maxAnswers]
+	}
+
+	return &QuestionAnsweringResponse{
+		Answers: answers,
+		Tokens:  tokens,
+		Took:    time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// answerCandidates returns the candidate answers to the question found in the passage, combining
+// the best maxCandidateLogits start and end positions, with their scores (the sum of the start and
+// end logits). The candidates have the start and end probabilities, but not the confidence.
+// It also returns the tokens of the passage, with their logits and probabilities.
+// The computation stops as soon as the context is done.
+func (s *Server) answerCandidates(
+	ctx context.Context,
+	question string,
+	passage string,
+	maxCandidateLogits int,
+) ([]Answer, []mat.Float, []AnswerToken, error) {
 	tokenizer := wordpiecetokenizer.New(s.model.Vocabulary)
 	origQuestionTokens := tokenizer.Tokenize(question)
 	origPassageTokens := tokenizer.Tokenize(passage)
@@ -88,18 +166,34 @@ func (s *Server) answer(question string, passage string) *QuestionAnsweringRespo
 	tokenized := append([]string{cls}, append(tokenizers.GetStrings(origQuestionTokens), sep)...)
 	tokenized = append(tokenized, append(tokenizers.GetStrings(origPassageTokens), sep)...)
 
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
+	g := ag.NewGraph(ag.IncrementalForward(false), ag.ConcurrentComputations(runtime.NumCPU()))
 	defer g.Clear()
-	ctx := nn.Context{Graph: g, Mode: nn.Inference}
-	proc := nn.Reify(ctx, s.model).(*Model)
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
 	encoded := proc.Encode(tokenized)
 
 	passageStartIndex := len(origQuestionTokens) + 2 // +2 because of [CLS] and [SEP]
 	passageEndIndex := passageStartIndex + len(origPassageTokens)
 	startLogits, endLogits := proc.SpanClassifier.Classify(encoded)
+	if err := g.ForwardContext(ctx); err != nil {
+		return nil, nil, nil, err
+	}
 	startLogits, endLogits = startLogits[passageStartIndex:passageEndIndex], endLogits[passageStartIndex:passageEndIndex] // cut invalid positions
-	startIndices := getBestIndices(extractScores(startLogits), defaultMaxCandidateLogits)
-	endIndices := getBestIndices(extractScores(endLogits), defaultMaxCandidateLogits)
+	startScores, endScores := extractScores(startLogits), extractScores(endLogits)
+	startProbs, endProbs := floatutils.SoftMax(startScores), floatutils.SoftMax(endScores)
+	tokens := make([]AnswerToken, len(origPassageTokens))
+	for i, token := range origPassageTokens {
+		tokens[i] = AnswerToken{
+			Text:             token.String,
+			Start:            token.Offsets.Start,
+			End:              token.Offsets.End,
+			StartLogit:       startScores[i],
+			EndLogit:         endScores[i],
+			StartProbability: startProbs[i],
+			EndProbability:   endProbs[i],
+		}
+	}
+	startIndices := getBestIndices(startScores, maxCandidateLogits)
+	endIndices := getBestIndices(endScores, maxCandidateLogits)
 
 	candidateAnswers := make([]Answer, 0)
 	scores := make([]mat.Float, 0) // the scores are aligned with the candidateAnswers
@@ -113,38 +207,44 @@ func (s *Server) answer(question string, passage string) *QuestionAnsweringRespo
 			default:
 				startOffset := origPassageTokens[startIndex].Offsets.Start
 				endOffset := origPassageTokens[endIndex].Offsets.End
-				scores = append(scores, startLogits[startIndex].ScalarValue()+endLogits[endIndex].ScalarValue())
+				scores = append(scores, startScores[startIndex]+endScores[endIndex])
 				candidateAnswers = append(candidateAnswers, Answer{
-					Text:  strings.Trim(string([]rune(passage)[startOffset:endOffset]), " "),
-					Start: startOffset,
-					End:   endOffset,
+					Text:             strings.Trim(string([]rune(passage)[startOffset:endOffset]), " "),
+					Start:            startOffset,
+					End:              endOffset,
+					StartProbability: startProbs[startIndex],
+					EndProbability:   endProbs[endIndex],
 				})
 			}
 		}
 	}
+	return candidateAnswers, scores, tokens, nil
+}
 
-	if len(candidateAnswers) == 0 {
-		return &QuestionAnsweringResponse{
-			Answers: AnswerSlice{},
-		}
-	}
+// QAExample is a question with its passage and the text of the correct answer, used to calibrate
+// the confidence of the answers (see FitAnswerTemperature).
+type QAExample struct {
+	Question string `json:"question"`
+	Passage  string `json:"passage"`
+	Answer   string `json:"answer"`
+}
 
-	probs := floatutils.SoftMax(scores)
-	answers := make(AnswerSlice, 0)
-	for i, candidate := range candidateAnswers {
-		if probs[i] >= defaultMinConfidence {
-			candidate.Confidence = probs[i]
-			answers = append(answers, candidate)
+// FitAnswerTemperature learns the temperature which calibrates the confidence of the answers on a
+// dev set, and returns it; the temperature can be set as the AnswerTemperature of the server.
+// The examples whose correct answer is not among the candidates are skipped.
+func (s *Server) FitAnswerTemperature(examples []QAExample) mat.Float {
+	var logits [][]mat.Float
+	var targets []int
+	for _, example := range examples {
+		// the background context is never done
+		candidates, scores, _, _ := s.answerCandidates(context.Background(), example.Question, example.Passage, defaultMaxCandidateLogits)
+		for i, candidate := range candidates {
+			if strings.EqualFold(candidate.Text, strings.TrimSpace(example.Answer)) {
+				logits = append(logits, scores)
+				targets = append(targets, i)
+				break
+			}
 		}
 	}
-
-	sort.Sort(sort.Reverse(answers))
-	if len(answers) > defaultMaxAnswers {
-		answers = answers[:defaultMaxAnswers]
-	}
-
-	return &QuestionAnsweringResponse{
-		Answers: answers,
-		Took:    time.Since(start).Milliseconds(),
-	}
+	return calibration.FitTemperature(logits, targets)
 }
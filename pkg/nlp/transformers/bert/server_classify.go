@@ -7,7 +7,9 @@ package bert
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/calibration"
 	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
 	"net/http"
 	"runtime"
@@ -17,6 +19,7 @@ import (
 	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/document"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
 )
@@ -33,7 +36,11 @@ func (s *Server) ClassifyHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	result := s.classify(body.Text, body.Text2)
+	result, err := s.cachedClassify(req.Context(), body.Text, body.Text2, body.Debug)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
 	if err != nil {
@@ -59,14 +66,20 @@ type ClassifyResponse struct {
 	Class        string                `json:"class"`
 	Confidence   mat.Float             `json:"confidence"`
 	Distribution []ClassConfidencePair `json:"distribution"`
+	// Logits are the raw logits of the classes, in the order of the labels of the classifier,
+	// returned on debug requests. They are omitted for the long texts classified in chunks.
+	Logits []mat.Float `json:"logits,omitempty"`
 	// Took is the number of milliseconds it took the server to execute the request.
 	Took int64 `json:"took"`
 }
 
 // Classify handles a classification request over gRPC.
 // TODO(evanmcclure@gmail.com) Reuse the gRPC message type for HTTP requests.
-func (s *Server) Classify(_ context.Context, req *grpcapi.ClassifyRequest) (*grpcapi.ClassifyReply, error) {
-	result := s.classify(req.GetText(), req.GetText2())
+func (s *Server) Classify(ctx context.Context, req *grpcapi.ClassifyRequest) (*grpcapi.ClassifyReply, error) {
+	result, err := s.cachedClassify(ctx, req.GetText(), req.GetText2(), false)
+	if err != nil {
+		return nil, err
+	}
 	return classificationFrom(result), nil
 }
 
@@ -98,20 +111,53 @@ func (s *Server) getTokenized(text, text2 string) []string {
 	return tokenized
 }
 
+// cachedClassify is like classify, but it uses the response cache, if enabled.
+func (s *Server) cachedClassify(ctx context.Context, text string, text2 string, debug bool) (*ClassifyResponse, error) {
+	response, err := s.cached(func() (interface{}, error) {
+		return s.classify(ctx, text, text2, debug)
+	}, "classify", text, text2, debug)
+	if err != nil {
+		return nil, err
+	}
+	return response.(*ClassifyResponse), nil
+}
+
 // TODO: This method is too long; it needs to be refactored.
 // For the textual inference task, text is the premise and text2 is the hypothesis.
-func (s *Server) classify(text string, text2 string) *ClassifyResponse {
+func (s *Server) classify(ctx context.Context, text string, text2 string, debug bool) (*ClassifyResponse, error) {
 	start := time.Now()
 
+	var probs, logits []mat.Float
+	var err error
 	tokenized := s.getTokenized(text, text2)
+	maxLength := s.model.Config.MaxPositionEmbeddings
+	if text2 == "" && maxLength > 0 && len(tokenized) > maxLength {
+		probs, err = s.classifyDocument(ctx, text, maxLength)
+	} else {
+		logits, err = s.sequenceLogits(ctx, tokenized)
+		if err == nil {
+			probs = s.probabilities(logits)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
-	encoded := proc.Encode(tokenized)
+	class, confidence, distribution := s.distribution(probs)
+	response := &ClassifyResponse{
+		Class:        class,
+		Confidence:   confidence,
+		Distribution: distribution,
+		Took:         time.Since(start).Milliseconds(),
+	}
+	if debug {
+		response.Logits = logits
+	}
+	return response, nil
+}
 
-	logits := proc.SequenceClassification(encoded)
-	probs := floatutils.SoftMax(logits.Value().Data())
+// distribution returns the best class with its confidence, and the classes sorted by confidence.
+func (s *Server) distribution(probs []mat.Float) (string, mat.Float, []ClassConfidencePair) {
 	best := floatutils.ArgMax(probs)
 	class := s.model.Classifier.Config.Labels[best]
 
@@ -126,11 +172,99 @@ func (s *Server) classify(text string, text2 string) *ClassifyResponse {
 	sort.Slice(distribution, func(i, j int) bool {
 		return distribution[i].Confidence > distribution[j].Confidence
 	})
+	return class, probs[best], distribution
+}
 
-	return &ClassifyResponse{
-		Class:        class,
-		Confidence:   probs[best],
-		Distribution: distribution,
-		Took:         time.Since(start).Milliseconds(),
+// probabilities returns the probabilities of the classes given their logits, calibrated by the
+// Calibrator of the server, if any.
+func (s *Server) probabilities(logits []mat.Float) []mat.Float {
+	if s.Calibrator != nil {
+		return s.Calibrator.Calibrate(logits)
+	}
+	return floatutils.SoftMax(logits)
+}
+
+// ClassificationExample is a text (or a pair of texts) with its correct label, used to calibrate
+// the confidence of the sequence classification (see FitCalibrator).
+type ClassificationExample struct {
+	Text  string `json:"text"`
+	Text2 string `json:"text2"`
+	Label string `json:"label"`
+}
+
+// FitCalibrator learns a calibrator of the given method (calibration.TemperatureMethod or
+// calibration.IsotonicMethod) of the sequence classification on a dev set, and returns it; the
+// calibrator can be set as the Calibrator of the server. The texts are not split into chunks.
+func (s *Server) FitCalibrator(examples []ClassificationExample, method string) (calibration.Calibrator, error) {
+	labels := s.model.Classifier.Config.Labels
+	logits := make([][]mat.Float, len(examples))
+	targets := make([]int, len(examples))
+	for i, example := range examples {
+		target := -1
+		for j, label := range labels {
+			if label == example.Label {
+				target = j
+				break
+			}
+		}
+		if target == -1 {
+			return nil, fmt.Errorf("bert: unknown label %q", example.Label)
+		}
+		var err error
+		logits[i], err = s.sequenceLogits(context.Background(), s.getTokenized(example.Text, example.Text2))
+		if err != nil {
+			return nil, err
+		}
+		targets[i] = target
+	}
+	return calibration.Fit(method, logits, targets)
+}
+
+// sequenceLogits returns the logits of the sequence classification for the given tokens.
+// The computation stops as soon as the context is done.
+func (s *Server) sequenceLogits(ctx context.Context, tokenized []string) ([]mat.Float, error) {
+	g := ag.NewGraph(ag.IncrementalForward(false), ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
+	encoded := proc.Encode(tokenized)
+	logits := proc.SequenceClassification(encoded)
+	if err := g.ForwardContext(ctx); err != nil {
+		return nil, err
+	}
+	return g.GetCopiedValue(logits).Data(), nil // the graph is cleared on return
+}
+
+// classifyDocument classifies a text longer than the maximum length of the model, splitting it into
+// chunks of sentences. It returns the average of the probabilities of the chunks.
+// The chunks made of a single sentence that still exceeds the maximum length are truncated.
+func (s *Server) classifyDocument(ctx context.Context, text string, maxLength int) ([]mat.Float, error) {
+	pipeline := document.NewPipeline(nil, document.Config{
+		MaxLength: maxLength,
+		Length: func(text string) int {
+			return len(s.getTokenized(text, ""))
+		},
+	})
+	chunks := pipeline.Split(text)
+	var sum []mat.Float
+	for _, chunk := range chunks {
+		tokenized := s.getTokenized(chunk.Text, "")
+		if len(tokenized) > maxLength {
+			tokenized = append(tokenized[:maxLength-1], wordpiecetokenizer.DefaultSequenceSeparator)
+		}
+		logits, err := s.sequenceLogits(ctx, tokenized)
+		if err != nil {
+			return nil, err
+		}
+		probs := s.probabilities(logits)
+		if sum == nil {
+			sum = make([]mat.Float, len(probs))
+		}
+		for i, p := range probs {
+			sum[i] += p
+		}
+	}
+	for i := range sum {
+		sum[i] /= mat.Float(len(chunks))
 	}
+	return sum, nil
 }
@@ -0,0 +1,128 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+)
+
+// TextPair is a JSON-serializable pair of texts, e.g. a premise and a hypothesis, or two sentences
+// to compare for similarity or paraphrase detection.
+type TextPair struct {
+	Text  string `json:"text"`
+	Text2 string `json:"text2"`
+}
+
+// PairClassifyBody is the JSON-serializable expected request body for BERT text-pair classification requests.
+type PairClassifyBody struct {
+	Pairs []TextPair `json:"pairs"`
+}
+
+// PairClassifyResponse is the JSON-serializable server response for BERT text-pair classification requests.
+type PairClassifyResponse struct {
+	// Results are the classifications of the pairs, in the same order as the request.
+	Results []PairClassification `json:"results"`
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// PairClassification is the JSON-serializable classification of a single pair of texts.
+// Models with a single label (e.g. STS-B) are regression models: their Score is the raw output
+// of the classifier, and the other fields are empty.
+type PairClassification struct {
+	Class        string                `json:"class,omitempty"`
+	Confidence   mat.Float             `json:"confidence,omitempty"`
+	Distribution []ClassConfidencePair `json:"distribution,omitempty"`
+	Score        *mat.Float            `json:"score,omitempty"`
+}
+
+// PairClassifyHandler handles a text-pair classification request over HTTP. The texts of each pair are
+// encoded together as a sentence pair (with distinct token types), and classified with the labels of
+// the model configuration.
+func (s *Server) PairClassifyHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	var body PairClassifyBody
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.classifyPairs(req.Context(), body.Pairs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, pretty := req.URL.Query()["pretty"]
+	response, err := Dump(result, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) classifyPairs(ctx context.Context, pairs []TextPair) (*PairClassifyResponse, error) {
+	start := time.Now()
+	results := make([]PairClassification, len(pairs))
+	for i, pair := range pairs {
+		logits, err := s.sequenceLogits(ctx, s.getPairTokenized(pair.Text, pair.Text2))
+		if err != nil {
+			return nil, err
+		}
+		if len(logits) == 1 {
+			score := logits[0]
+			results[i] = PairClassification{Score: &score}
+			continue
+		}
+		class, confidence, distribution := s.distribution(s.probabilities(logits))
+		results[i] = PairClassification{
+			Class:        class,
+			Confidence:   confidence,
+			Distribution: distribution,
+		}
+	}
+	return &PairClassifyResponse{
+		Results: results,
+		Took:    time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// getPairTokenized returns the tokens of the sentence pair "[CLS] text [SEP] text2 [SEP]". If it exceeds
+// the maximum length of the model, the longest of the two texts is truncated, one token at a time.
+func (s *Server) getPairTokenized(text, text2 string) []string {
+	tokenizer := wordpiecetokenizer.New(s.model.Vocabulary)
+	a := tokenizers.GetStrings(tokenizer.Tokenize(text))
+	b := tokenizers.GetStrings(tokenizer.Tokenize(text2))
+	if maxLength := s.model.Config.MaxPositionEmbeddings; maxLength > 0 {
+		for len(a)+len(b)+3 > maxLength && (len(a) > 0 || len(b) > 0) {
+			if len(a) >= len(b) {
+				a = a[:len(a)-1]
+			} else {
+				b = b[:len(b)-1]
+			}
+		}
+	}
+	cls := wordpiecetokenizer.DefaultClassToken
+	sep := wordpiecetokenizer.DefaultSequenceSeparator
+	tokenized := append([]string{cls}, a...)
+	tokenized = append(tokenized, sep)
+	tokenized = append(tokenized, b...)
+	return append(tokenized, sep)
+}
@@ -31,7 +31,21 @@ func (s *Server) SentenceEncoderHandler(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	result := s.encode(body.Text, body.PoolingStrategy)
+	if _, ok := grpcapi.EncodeRequest_PoolingStrategy_name[int32(body.PoolingStrategy)]; !ok {
+		http.Error(w, "bert: invalid pooling strategy", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.cachedEncode(req.Context(), body.Text, encodeOptions{
+		poolingStrategy: body.PoolingStrategy,
+		normalize:       body.Normalize,
+		hiddenStates:    body.OutputHiddenStates,
+		attentions:      body.OutputAttentions,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
 	if err != nil {
@@ -49,14 +63,27 @@ func (s *Server) SentenceEncoderHandler(w http.ResponseWriter, req *http.Request
 // EncodeResponse is a JSON-serializable server response for BERT "encode" requests.
 type EncodeResponse struct {
 	Data []mat.Float `json:"data"`
+	// Tokens are the word pieces of the text, including "[CLS]" and "[SEP]", returned with the
+	// hidden states or the attention weights.
+	Tokens []string `json:"tokens,omitempty"`
+	// HiddenStates are the embeddings followed by the output of each layer, for each token.
+	HiddenStates [][][]mat.Float `json:"hidden_states,omitempty"`
+	// Attentions are the attention weights of each layer, for each head, from each token to each token.
+	Attentions [][][][]mat.Float `json:"attentions,omitempty"`
 	// Took is the number of milliseconds it took the server to execute the request.
 	Took int64 `json:"took"`
 }
 
 // Encode handles an encoding request over gRPC.
 // TODO(evanmcclure@gmail.com) Reuse the gRPC message type for HTTP requests.
-func (s *Server) Encode(_ context.Context, req *grpcapi.EncodeRequest) (*grpcapi.EncodeReply, error) {
-	result := s.encode(req.GetText(), req.GetPoolingStrategy())
+func (s *Server) Encode(ctx context.Context, req *grpcapi.EncodeRequest) (*grpcapi.EncodeReply, error) {
+	result, err := s.cachedEncode(ctx, req.GetText(), encodeOptions{
+		poolingStrategy: req.GetPoolingStrategy(),
+		normalize:       req.GetNormalize(),
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	vector32 := make([]float32, len(result.Data))
 	for i, num := range result.Data {
@@ -69,35 +96,115 @@ func (s *Server) Encode(_ context.Context, req *grpcapi.EncodeRequest) (*grpcapi
 	}, nil
 }
 
-func (s *Server) encode(text string, poolingStrategy grpcapi.EncodeRequest_PoolingStrategy) *EncodeResponse {
+// encodeOptions are the options of the "encode" requests.
+type encodeOptions struct {
+	poolingStrategy grpcapi.EncodeRequest_PoolingStrategy
+	// normalize the pooled vector to unit length (L2 norm)
+	normalize bool
+	// hiddenStates and attentions return the hidden states and the attention weights of all the layers
+	hiddenStates bool
+	attentions   bool
+}
+
+// lastLayersToPool is the number of layers pooled by the REDUCE_MEAN_LAST_4_LAYERS strategy.
+const lastLayersToPool = 4
+
+// cachedEncode is like encode, but it uses the response cache, if enabled.
+func (s *Server) cachedEncode(ctx context.Context, text string, options encodeOptions) (*EncodeResponse, error) {
+	response, err := s.cached(func() (interface{}, error) {
+		return s.encode(ctx, text, options)
+	}, "encode", text, options.poolingStrategy, options.normalize, options.hiddenStates, options.attentions)
+	if err != nil {
+		return nil, err
+	}
+	return response.(*EncodeResponse), nil
+}
+
+// encode returns the pooled encoding of the text, optionally with the hidden states and the
+// attention weights of all the layers.
+// The computation stops as soon as the context is done; if the attention weights are requested,
+// it can only stop before the forward step, since they are read while the graph is built.
+func (s *Server) encode(ctx context.Context, text string, options encodeOptions) (*EncodeResponse, error) {
 	start := time.Now()
 	tokenizer := wordpiecetokenizer.New(s.model.Vocabulary)
 	origTokens := tokenizer.Tokenize(text)
 	tokenized := pad(tokenizers.GetStrings(origTokens))
 
-	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	incremental := options.attentions
+	g := ag.NewGraph(ag.IncrementalForward(incremental), ag.ConcurrentComputations(runtime.NumCPU()))
 	defer g.Clear()
 	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
-	encoded := proc.Encode(tokenized)
+	outputs := proc.EncodeWithOutputs(tokenized)
+	encoded := outputs.HiddenStates[len(outputs.HiddenStates)-1]
 
 	var pooled ag.Node
-	switch poolingStrategy {
+	switch options.poolingStrategy {
 	case grpcapi.EncodeRequest_REDUCE_MEAN:
 		pooled = g.Mean(encoded)
 	case grpcapi.EncodeRequest_REDUCE_MAX:
 		pooled = Max(g, encoded)
 	case grpcapi.EncodeRequest_REDUCE_MEAN_MAX:
 		pooled = g.Concat(g.Mean(encoded), Max(g, encoded))
+	case grpcapi.EncodeRequest_REDUCE_MEAN_LAST_4_LAYERS:
+		pooled = meanOfLastLayers(g, outputs.HiddenStates[1:], lastLayersToPool)
 	case grpcapi.EncodeRequest_CLS_TOKEN:
 		pooled = proc.Pool(encoded)
 	default:
 		panic("bert: invalid pooling strategy")
 	}
+	if !incremental {
+		if err := g.ForwardContext(ctx); err != nil {
+			return nil, err
+		}
+	}
 
-	return &EncodeResponse{
+	response := &EncodeResponse{
 		Data: pooled.Value().Data(),
-		Took: time.Since(start).Milliseconds(),
 	}
+	if options.normalize {
+		response.Data = pooled.Value().(*mat.Dense).Normalize2().Data()
+	}
+	if options.hiddenStates || options.attentions {
+		response.Tokens = tokenized
+	}
+	if options.hiddenStates {
+		response.HiddenStates = make([][][]mat.Float, len(outputs.HiddenStates))
+		for i, states := range outputs.HiddenStates {
+			response.HiddenStates[i] = make([][]mat.Float, len(states))
+			for j, state := range states {
+				response.HiddenStates[i][j] = g.GetCopiedValue(state).Data() // the graph is cleared on return
+			}
+		}
+	}
+	if options.attentions {
+		response.Attentions = make([][][][]mat.Float, len(outputs.Attentions))
+		for i, heads := range outputs.Attentions {
+			response.Attentions[i] = make([][][]mat.Float, len(heads))
+			for j, weights := range heads {
+				response.Attentions[i][j] = make([][]mat.Float, len(weights))
+				for k, w := range weights {
+					response.Attentions[i][j][k] = w.Clone().Data()
+				}
+			}
+		}
+	}
+	response.Took = time.Since(start).Milliseconds()
+	return response, nil
+}
+
+// meanOfLastLayers returns the average of the mean encodings of the last n layers (or all the layers if fewer).
+func meanOfLastLayers(g *ag.Graph, layers [][]ag.Node, n int) ag.Node {
+	if len(layers) > n {
+		layers = layers[len(layers)-n:]
+	}
+	means := make([]ag.Node, len(layers))
+	for i, layer := range layers {
+		means[i] = g.Mean(layer)
+	}
+	return g.Mean(means)
 }
 
 // Max returns the value that describes the maximum of the sample.
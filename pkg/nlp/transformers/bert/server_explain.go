@@ -0,0 +1,165 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"encoding/json"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+const (
+	// IntegratedGradientsMethod is the name of the integrated gradients explanation method.
+	IntegratedGradientsMethod = "integrated_gradients"
+	// AttentionRolloutMethod is the name of the attention rollout explanation method.
+	AttentionRolloutMethod = "attention_rollout"
+
+	defaultExplainSteps = 20
+	maxExplainSteps     = 300
+)
+
+// ExplainBody is the JSON body of the "explain" requests.
+type ExplainBody struct {
+	Text  string `json:"text"`
+	Text2 string `json:"text2"`
+	// Target is the class to explain; the predicted one if empty.
+	Target string `json:"target"`
+	// Methods are the explanation methods (IntegratedGradientsMethod and AttentionRolloutMethod);
+	// all of them if empty.
+	Methods []string `json:"methods"`
+	// Steps is the number of steps of the integrated gradients (20 by default).
+	Steps int `json:"steps"`
+}
+
+// ExplainResponse is a JSON-serializable server response for BERT "explain" requests.
+type ExplainResponse struct {
+	Class      string    `json:"class"`
+	Confidence mat.Float `json:"confidence"`
+	// Target is the explained class, and TargetConfidence its probability.
+	Target           string    `json:"target"`
+	TargetConfidence mat.Float `json:"target_confidence"`
+	// Tokens are the word pieces of the input, including the special tokens.
+	Tokens []string `json:"tokens"`
+	// IntegratedGradients are the attributions of the tokens to the probability of the target class
+	// (see Model.IntegratedGradients); positive values support the class, negative ones oppose it.
+	IntegratedGradients []mat.Float `json:"integrated_gradients,omitempty"`
+	// AttentionRollout are the importances of the tokens for the [CLS] representation used by the
+	// classification (see AttentionRollout); they don't depend on the target class.
+	AttentionRollout []mat.Float `json:"attention_rollout,omitempty"`
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// ExplainHandler handles an explain request over HTTP, returning the importance of each token for
+// the sequence classification.
+func (s *Server) ExplainHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	var body ExplainBody
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.explain(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, pretty := req.URL.Query()["pretty"]
+	response, err := Dump(result, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// explain returns the explanation of the classification of the texts. It fails if the request
+// is not valid.
+func (s *Server) explain(body ExplainBody) (*ExplainResponse, error) {
+	start := time.Now()
+
+	integratedGradients, attentionRollout := len(body.Methods) == 0, len(body.Methods) == 0
+	for _, method := range body.Methods {
+		switch method {
+		case IntegratedGradientsMethod:
+			integratedGradients = true
+		case AttentionRolloutMethod:
+			attentionRollout = true
+		default:
+			return nil, fmt.Errorf("bert: unknown explanation method %q", method)
+		}
+	}
+	steps := body.Steps
+	if steps == 0 {
+		steps = defaultExplainSteps
+	}
+	if steps < 0 || steps > maxExplainSteps {
+		return nil, fmt.Errorf("bert: the steps must be between 1 and %d", maxExplainSteps)
+	}
+	tokens := s.getTokenized(body.Text, body.Text2)
+	if maxLength := s.model.Config.MaxPositionEmbeddings; maxLength > 0 && len(tokens) > maxLength {
+		return nil, fmt.Errorf("bert: the text is too long to be explained (%d tokens, max %d)", len(tokens), maxLength)
+	}
+
+	logits, rollout := s.logitsWithAttentionRollout(tokens)
+	probs := s.probabilities(logits)
+	class, confidence, _ := s.distribution(probs)
+	target := class
+	if body.Target != "" {
+		target = body.Target
+	}
+	targetID := -1
+	for i, label := range s.model.Classifier.Config.Labels {
+		if label == target {
+			targetID = i
+			break
+		}
+	}
+	if targetID == -1 {
+		return nil, fmt.Errorf("bert: unknown target class %q", target)
+	}
+
+	response := &ExplainResponse{
+		Class:            class,
+		Confidence:       confidence,
+		Target:           target,
+		TargetConfidence: probs[targetID],
+		Tokens:           tokens,
+	}
+	if integratedGradients {
+		response.IntegratedGradients = s.model.IntegratedGradients(tokens, targetID, steps)
+	}
+	if attentionRollout {
+		response.AttentionRollout = rollout
+	}
+	response.Took = time.Since(start).Milliseconds()
+	return response, nil
+}
+
+// logitsWithAttentionRollout returns the logits of the sequence classification for the given
+// tokens, and the attention rollout of the [CLS] token.
+func (s *Server) logitsWithAttentionRollout(tokens []string) ([]mat.Float, []mat.Float) {
+	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
+	outputs := proc.EncodeWithOutputs(tokens)
+	logits := proc.SequenceClassification(outputs.HiddenStates[len(outputs.HiddenStates)-1])
+	// the graph is cleared on return
+	return g.GetCopiedValue(logits).Data(), AttentionRollout(outputs.Attentions, 0)
+}
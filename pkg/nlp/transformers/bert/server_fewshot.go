@@ -0,0 +1,243 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"context"
+	"encoding/json"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/ml/fewshot"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert/grpcapi"
+	"net/http"
+	"time"
+)
+
+// fewShotSimilarityScale scales the cosine similarities before the softmax, since their range
+// [-1, 1] would otherwise give an almost uniform distribution.
+const fewShotSimilarityScale mat.Float = 10
+
+// FewShotExample is a labeled example of a few-shot classification.
+type FewShotExample struct {
+	Text  string `json:"text"`
+	Label string `json:"label"`
+}
+
+// FewShotBody is the JSON-serializable body of the few-shot requests.
+type FewShotBody struct {
+	// Set is the name of the set of classes, so that independent few-shot classifiers can be
+	// served at the same time. The empty name is a valid set.
+	Set string `json:"set"`
+	// Examples are used by the "examples" requests.
+	Examples []FewShotExample `json:"examples"`
+	// Text is used by the "classify" requests.
+	Text string `json:"text"`
+}
+
+// FewShotClassesResponse is the JSON-serializable response of the few-shot "examples" requests.
+type FewShotClassesResponse struct {
+	Classes []fewshot.Class `json:"classes"`
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// FewShotClassifyResponse is the JSON-serializable response of the few-shot "classify" requests.
+type FewShotClassifyResponse struct {
+	Class        string                `json:"class"`
+	Confidence   mat.Float             `json:"confidence"`
+	Distribution []ClassConfidencePair `json:"distribution"`
+	// Similarities are the cosine similarities of the text with the centroids of the classes.
+	Similarities []fewshot.Prediction `json:"similarities"`
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// FewShotExamplesHandler handles the examples of a few-shot classification over HTTP.
+// A POST request adds the examples to their classes; a DELETE request removes the class given by
+// the "label" query parameter from the set given by the "set" parameter, or the whole set if there
+// is no label. Both respond with the classes left in the set.
+func (s *Server) FewShotExamplesHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	start := time.Now()
+	var classes []fewshot.Class
+	switch req.Method {
+	case http.MethodPost:
+		var body FewShotBody
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		classes, err = s.addFewShotExamples(req.Context(), body.Set, body.Examples)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case http.MethodDelete:
+		query := req.URL.Query()
+		classes = s.removeFewShotClass(query.Get("set"), query.Get("label"))
+	default:
+		http.Error(w, "bert: method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result := &FewShotClassesResponse{
+		Classes: classes,
+		Took:    time.Since(start).Milliseconds(),
+	}
+	_, pretty := req.URL.Query()["pretty"]
+	response, err := Dump(result, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// FewShotClassifyHandler handles a few-shot classification request over HTTP.
+func (s *Server) FewShotClassifyHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	var body FewShotBody
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.classifyFewShot(req.Context(), body.Set, body.Text)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if result == nil {
+		http.Error(w, "bert: no examples in the few-shot set", http.StatusNotFound)
+		return
+	}
+	_, pretty := req.URL.Query()["pretty"]
+	response, err := Dump(result, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// fewShotEmbedding returns the sentence embedding of the text used by the few-shot classification,
+// the normalized mean of its encoding.
+func (s *Server) fewShotEmbedding(ctx context.Context, text string) (mat.Matrix, error) {
+	encoded, err := s.cachedEncode(ctx, text, encodeOptions{
+		poolingStrategy: grpcapi.EncodeRequest_REDUCE_MEAN,
+		normalize:       true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mat.NewVecDense(encoded.Data), nil
+}
+
+// fewShotSet returns the few-shot classifier with the given name, creating it if create is true,
+// or nil if it does not exist.
+func (s *Server) fewShotSet(name string, create bool) *fewshot.Classifier {
+	s.fewShotMu.Lock()
+	defer s.fewShotMu.Unlock()
+	if c, ok := s.fewShotSets[name]; ok || !create {
+		return c
+	}
+	if s.fewShotSets == nil {
+		s.fewShotSets = make(map[string]*fewshot.Classifier)
+	}
+	c := fewshot.New()
+	s.fewShotSets[name] = c
+	return c
+}
+
+// addFewShotExamples adds the examples to the set, and returns its classes.
+// If the context is done before all the examples are encoded, none of them is added.
+func (s *Server) addFewShotExamples(ctx context.Context, set string, examples []FewShotExample) ([]fewshot.Class, error) {
+	embeddings := make([]mat.Matrix, len(examples))
+	for i, example := range examples {
+		embedding, err := s.fewShotEmbedding(ctx, example.Text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	c := s.fewShotSet(set, true)
+	for i, example := range examples {
+		c.Add(example.Label, embeddings[i])
+	}
+	return c.Classes(), nil
+}
+
+// removeFewShotClass removes the class with the given label from the set, or the whole set if the
+// label is empty, and returns the classes left in the set.
+func (s *Server) removeFewShotClass(set string, label string) []fewshot.Class {
+	if label == "" {
+		s.fewShotMu.Lock()
+		delete(s.fewShotSets, set)
+		s.fewShotMu.Unlock()
+		return []fewshot.Class{}
+	}
+	c := s.fewShotSet(set, false)
+	if c == nil {
+		return []fewshot.Class{}
+	}
+	c.Remove(label)
+	return c.Classes()
+}
+
+// classifyFewShot classifies the text among the classes of the set by the similarity of its
+// embedding with their centroids. The confidences are the softmax of the scaled similarities.
+// It returns nil if the set has no classes.
+func (s *Server) classifyFewShot(ctx context.Context, set string, text string) (*FewShotClassifyResponse, error) {
+	start := time.Now()
+	c := s.fewShotSet(set, false)
+	if c == nil {
+		return nil, nil
+	}
+	embedding, err := s.fewShotEmbedding(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	predictions := c.Predict(embedding)
+	if len(predictions) == 0 {
+		return nil, nil
+	}
+
+	scores := make([]mat.Float, len(predictions))
+	for i, p := range predictions {
+		scores[i] = p.Similarity * fewShotSimilarityScale
+	}
+	probs := floatutils.SoftMax(scores)
+	distribution := make([]ClassConfidencePair, len(predictions))
+	for i, p := range predictions {
+		distribution[i] = ClassConfidencePair{
+			Class:      p.Label,
+			Confidence: probs[i],
+		}
+	}
+	// the predictions are sorted by decreasing similarity, so the first one is the best
+	return &FewShotClassifyResponse{
+		Class:        predictions[0].Label,
+		Confidence:   probs[0],
+		Distribution: distribution,
+		Similarities: predictions,
+		Took:         time.Since(start).Milliseconds(),
+	}, nil
+}
@@ -5,6 +5,7 @@
 package bert
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
@@ -29,6 +30,8 @@ type LabelerOptionsType struct {
 type TokenClassifierBody struct {
 	Options LabelerOptionsType `json:"options"`
 	Text    string             `json:"text"`
+	// Debug returns the logits and the probabilities of the labels of each word.
+	Debug bool `json:"debug"`
 }
 
 // LabelerHandler handles a labeling request over HTTP.
@@ -43,7 +46,11 @@ func (s *Server) LabelerHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	result := s.label(body.Text, body.Options.MergeEntities, body.Options.FilterNotEntities)
+	result, err := s.label(req.Context(), body.Text, body.Options.MergeEntities, body.Options.FilterNotEntities, body.Debug)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	_, pretty := req.URL.Query()["pretty"]
 	response, err := Dump(result, pretty)
@@ -59,19 +66,39 @@ func (s *Server) LabelerHandler(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
-// TODO: This method is too long; it needs to be refactored.
-func (s *Server) label(text string, merge bool, filter bool) *Response {
+// label returns the labeled words of the text.
+// The computation stops as soon as the context is done.
+func (s *Server) label(ctx context.Context, text string, merge bool, filter bool, debug bool) (*Response, error) {
 	start := time.Now()
 
-	tokenizer := wordpiecetokenizer.New(s.model.Vocabulary)
+	g := ag.NewGraph(ag.IncrementalForward(false))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
+	words, encoded := encodeWords(proc, text)
+	logits := proc.TokenClassification(encoded)
+	if err := g.ForwardContext(ctx); err != nil {
+		return nil, err
+	}
+	retTokens := labelWords(proc, words, logits, debug)
+	if merge {
+		retTokens = mergeEntities(text, retTokens)
+	}
+	if filter {
+		retTokens = filterNotEntities(retTokens)
+	}
+	return &Response{Tokens: retTokens, Took: time.Since(start).Milliseconds()}, nil
+}
+
+// encodeWords returns the words of the text and their encodings, as the average
+// of the encodings of their pieces.
+func encodeWords(proc *Model, text string) ([]tokenizers.StringOffsetsPair, []ag.Node) {
+	g := proc.Graph()
+	tokenizer := wordpiecetokenizer.New(proc.Vocabulary)
 	origTokens := tokenizer.Tokenize(text)
 	tokensRange := wordpiecetokenizer.GroupPieces(origTokens)
 	groupedTokens := wordpiecetokenizer.MakeOffsetPairsFromGroups(text, origTokens, tokensRange)
 	tokenized := pad(tokenizers.GetStrings(origTokens))
 
-	g := ag.NewGraph()
-	defer g.Clear()
-	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
 	encoded := proc.Encode(tokenized)
 	encoded = encoded[1 : len(encoded)-1] // trim [CLS] and [SEP]
 
@@ -87,25 +114,29 @@ func (s *Server) label(text string, merge bool, filter bool) *Response {
 			avgEncoded[i] = g.DivScalar(avgEncoded[i], g.NewScalar(mat.Float(cnt)))
 		}
 	}
+	return groupedTokens, avgEncoded
+}
 
+// labelWords returns the words labeled with the logits of the token classifier, with the logits and
+// the probabilities of the labels if debug is true.
+func labelWords(proc *Model, words []tokenizers.StringOffsetsPair, logits []ag.Node, debug bool) []Token {
 	retTokens := make([]Token, 0)
-	for i, logits := range proc.TokenClassification(avgEncoded) {
-		probs := floatutils.SoftMax(logits.Value().Data())
+	for i, wordLogits := range logits {
+		probs := floatutils.SoftMax(wordLogits.Value().Data())
 		best := floatutils.ArgMax(probs)
-		retTokens = append(retTokens, Token{
-			Text:  groupedTokens[i].String,
-			Start: groupedTokens[i].Offsets.Start,
-			End:   groupedTokens[i].Offsets.End,
-			Label: s.model.Classifier.Config.Labels[best],
-		})
-	}
-	if merge {
-		retTokens = mergeEntities(text, retTokens)
-	}
-	if filter {
-		retTokens = filterNotEntities(retTokens)
+		token := Token{
+			Text:  words[i].String,
+			Start: words[i].Offsets.Start,
+			End:   words[i].Offsets.End,
+			Label: proc.Classifier.Config.Labels[best],
+		}
+		if debug {
+			token.Logits = wordLogits.Value().Clone().Data() // the graph is cleared on return
+			token.Probabilities = probs
+		}
+		retTokens = append(retTokens, token)
 	}
-	return &Response{Tokens: retTokens, Took: time.Since(start).Milliseconds()}
+	return retTokens
 }
 
 // TODO: make sure that the input label sequence is valid
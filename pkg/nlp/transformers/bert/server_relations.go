@@ -0,0 +1,148 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bert
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/relations"
+)
+
+// RelationsResponse is the JSON-serializable server response for BERT relation extraction requests.
+type RelationsResponse struct {
+	// Entities are the entities recognized by the token classifier, in order of occurrence.
+	Entities []Token `json:"entities"`
+	// Relations are the relations between the entities.
+	Relations []EntityRelation `json:"relations"`
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// EntityRelation is a JSON-serializable typed relation between two entities of a RelationsResponse.
+type EntityRelation struct {
+	// Head is the index of the first entity of the relation.
+	Head int `json:"head"`
+	// Tail is the index of the second entity of the relation.
+	Tail       int       `json:"tail"`
+	Label      string    `json:"label"`
+	Confidence mat.Float `json:"confidence"`
+}
+
+// RelationsHandler handles a relation extraction request over HTTP: the entities of the text are
+// recognized by the token classifier, then the relations between them by the relation classifier.
+func (s *Server) RelationsHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.model.RelationClassifier == nil {
+		http.Error(w, "bert: the model has no relation classifier", http.StatusNotImplemented)
+		return
+	}
+
+	var body Body
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := s.extractRelations(body.Text)
+
+	_, pretty := req.URL.Query()["pretty"]
+	response, err := Dump(result, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) extractRelations(text string) *RelationsResponse {
+	start := time.Now()
+
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
+	words, encoded := encodeWords(proc, text)
+	entities := groupEntities(labelWords(proc, words, proc.TokenClassification(encoded), false))
+
+	spans := make([]relations.Entity, len(entities))
+	retEntities := make([]Token, len(entities))
+	runes := []rune(text)
+	for i, entity := range entities {
+		spans[i] = entity.Entity
+		retEntities[i] = Token{
+			Text:  strings.Trim(string(runes[entity.start:entity.end]), " "),
+			Start: entity.start,
+			End:   entity.end,
+			Label: entity.Label,
+		}
+	}
+	retRelations := make([]EntityRelation, 0)
+	for _, relation := range proc.RelationClassifier.Classify(encoded, spans) {
+		retRelations = append(retRelations, EntityRelation{
+			Head:       relation.Head,
+			Tail:       relation.Tail,
+			Label:      relation.Label,
+			Confidence: relation.Confidence,
+		})
+	}
+	return &RelationsResponse{
+		Entities:  retEntities,
+		Relations: retRelations,
+		Took:      time.Since(start).Milliseconds(),
+	}
+}
+
+// wordEntity is an entity spanning the words from Start to End, and the runes from start to end (excluded).
+type wordEntity struct {
+	relations.Entity
+	start int
+	end   int
+}
+
+// groupEntities returns the entities of the labeled words, in the IOB or IOBES schemes.
+func groupEntities(tokens []Token) []wordEntity {
+	entities := make([]wordEntity, 0)
+	var current *wordEntity
+	flush := func() {
+		if current != nil {
+			entities = append(entities, *current)
+		}
+		current = nil
+	}
+	for i, token := range tokens {
+		if len(token.Label) < 3 || token.Label[1] != '-' { // e.g. "O"
+			flush()
+			continue
+		}
+		prefix, label := token.Label[0], token.Label[2:]
+		if current == nil || prefix == 'B' || prefix == 'S' || current.Label != label {
+			flush()
+			current = &wordEntity{
+				Entity: relations.Entity{Start: i, End: i, Label: label},
+				start:  token.Start,
+			}
+		}
+		current.End = i
+		current.end = token.End
+		if prefix == 'E' || prefix == 'S' {
+			flush()
+		}
+	}
+	flush()
+	return entities
+}
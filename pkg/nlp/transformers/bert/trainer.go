@@ -18,7 +18,6 @@ import (
 	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/gdmbuilder"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
 	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
-	"github.com/nlpodyssey/spago/pkg/utils"
 	"io"
 	"log"
 	"os"
@@ -70,7 +69,7 @@ func (t *Trainer) Train() {
 
 		if i > 0 && i%1000 == 0 {
 			fmt.Println("=== MODEL SERIALIZATION")
-			err := utils.SerializeToFile(t.ModelPath, t.model)
+			err := nn.SaveModel(t.ModelPath, t.model)
 			if err != nil {
 				panic("bert: error during model serialization.")
 			}
@@ -113,7 +112,7 @@ func (t *Trainer) trainPassage(text string) {
 		panic("bert: expected loss not to be nil")
 	}
 
-	g.Backward(loss)
+	g.Backward(loss, ag.EarlyRelease())
 	t.lastBatchLoss = loss.ScalarValue()
 	fmt.Printf("Cnt: %d Loss: %.6f\n", t.countLine, t.lastBatchLoss)
 }
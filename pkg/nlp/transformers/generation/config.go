@@ -39,6 +39,21 @@ type GeneratorConfig struct {
 	EarlyStopping bool
 	// BadWordsIDs is a list of token IDs that are not allowed to be generated.
 	BadWordsIDs [][]int
+	// StopSequences are sequences of token IDs which end the generation as soon as a sequence ends
+	// with one of them (which is kept).
+	StopSequences [][]int
+	// RepetitionPenalty is the penalty of the tokens already generated: their scores are multiplied
+	// by it (being log-probabilities). 1.0 or 0 means no penalty; values > 1.0 discourage
+	// repetitions.
+	RepetitionPenalty mat.Float
+	// NoRepeatNGramSize, if positive, prevents the n-grams of this size from occurring twice.
+	NoRepeatNGramSize int
+	// Constraints restrict the sequences that can be generated, e.g. forcing a prefix, requiring
+	// some phrases or matching a regular expression.
+	Constraints []Constraint
+	// Processors are additional processors of the scores of the next tokens, applied after the
+	// ones of the other options, in order.
+	Processors []ScoresProcessor
 	// MaxConcurrentComputations is the maximum number of concurrent computations
 	// handled by the generation search algorithm.
 	MaxConcurrentComputations int
@@ -0,0 +1,280 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generation
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"regexp/syntax"
+	"sort"
+)
+
+// Constraint restricts the sequences that can be generated.
+type Constraint interface {
+	// Allowed returns the tokens that can follow the generated ones, or nil if any token can.
+	// remaining is the number of tokens that can still be generated, the next one included,
+	// before the end of sequence is forced.
+	Allowed(generated []int, remaining int) []int
+	// Satisfied reports whether the generated tokens satisfy the constraint, so that the
+	// sequence can end.
+	Satisfied(generated []int) bool
+}
+
+var (
+	_ Constraint = &ForcedPrefix{}
+	_ Constraint = &RequiredPhrases{}
+	_ Constraint = &Regexp{}
+)
+
+// ForcedPrefix is a Constraint forcing the sequence to start with the given tokens.
+type ForcedPrefix struct {
+	TokenIDs []int
+}
+
+// Allowed satisfies the Constraint interface.
+func (c *ForcedPrefix) Allowed(generated []int, _ int) []int {
+	if len(generated) >= len(c.TokenIDs) {
+		return nil
+	}
+	return []int{c.TokenIDs[len(generated)]}
+}
+
+// Satisfied satisfies the Constraint interface.
+func (c *ForcedPrefix) Satisfied(generated []int) bool {
+	return len(generated) >= len(c.TokenIDs)
+}
+
+// RequiredPhrases is a Constraint requiring the sequence to contain each phrase, anywhere. The
+// sequence can't end before, and the tokens of the missing phrases are forced when there is no
+// more room to leave the choice to the model.
+type RequiredPhrases struct {
+	Phrases [][]int
+}
+
+// Allowed satisfies the Constraint interface.
+func (c *RequiredPhrases) Allowed(generated []int, remaining int) []int {
+	needed := 0
+	next, bestOverlap := -1, -1
+	for _, phrase := range c.Phrases {
+		if len(phrase) == 0 || containsPhrase(generated, phrase) {
+			continue
+		}
+		overlap := phraseOverlap(generated, phrase)
+		needed += len(phrase)
+		if overlap > bestOverlap {
+			next, bestOverlap = phrase[overlap], overlap
+		}
+	}
+	if next == -1 || needed-bestOverlap < remaining {
+		return nil
+	}
+	return []int{next}
+}
+
+// Satisfied satisfies the Constraint interface.
+func (c *RequiredPhrases) Satisfied(generated []int) bool {
+	for _, phrase := range c.Phrases {
+		if !containsPhrase(generated, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsPhrase reports whether the tokens contain the phrase.
+func containsPhrase(tokens, phrase []int) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		if utils.IntSliceEqual(tokens[i:i+len(phrase)], phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// phraseOverlap returns the length of the longest suffix of the tokens which is a proper prefix
+// of the phrase.
+func phraseOverlap(tokens, phrase []int) int {
+	for n := len(phrase) - 1; n > 0; n-- {
+		if n <= len(tokens) && utils.IntSliceEqual(tokens[len(tokens)-n:], phrase[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// Regexp is a Constraint requiring the text of the sequence to match a regular expression, as a
+// whole. At each step, it allows only the tokens whose text keeps the match possible, and the
+// end of sequence once the text matches. The tokens without text (e.g. the special tokens) are
+// always allowed, and the empty-width assertions of the expression (e.g. ^, $ and \b) are
+// ignored.
+type Regexp struct {
+	prog       *syntax.Prog
+	texts      []string
+	eosTokenID int
+}
+
+// NewRegexp returns a new Regexp constraint for the pattern (with the syntax of the regexp
+// package). texts are the texts of the tokens of the vocabulary, as they appear in the
+// detokenized sequence (e.g. with the leading space of the word-initial tokens).
+func NewRegexp(pattern string, texts []string, eosTokenID int) (*Regexp, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("generation: invalid pattern: %w", err)
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, fmt.Errorf("generation: invalid pattern: %w", err)
+	}
+	return &Regexp{prog: prog, texts: texts, eosTokenID: eosTokenID}, nil
+}
+
+// Allowed satisfies the Constraint interface.
+func (c *Regexp) Allowed(generated []int, _ int) []int {
+	states := c.run(generated)
+	matched := c.matched(states)
+	allowed := make([]int, 0)
+	for id, text := range c.texts {
+		switch {
+		case id == c.eosTokenID:
+			if matched {
+				allowed = append(allowed, id)
+			}
+		case text == "":
+			allowed = append(allowed, id)
+		default:
+			if len(c.advance(states, text)) > 0 {
+				allowed = append(allowed, id)
+			}
+		}
+	}
+	if c.eosTokenID >= len(c.texts) && matched {
+		allowed = append(allowed, c.eosTokenID)
+	}
+	return allowed
+}
+
+// Satisfied satisfies the Constraint interface.
+func (c *Regexp) Satisfied(generated []int) bool {
+	return c.matched(c.run(generated))
+}
+
+// run returns the states of the automaton after the text of the tokens.
+func (c *Regexp) run(tokenIDs []int) []uint32 {
+	states := c.closure(nil, uint32(c.prog.Start))
+	for _, id := range tokenIDs {
+		if id == c.eosTokenID || id < 0 || id >= len(c.texts) {
+			continue
+		}
+		states = c.advance(states, c.texts[id])
+	}
+	return states
+}
+
+// advance returns the states of the automaton after the text, starting from the given states.
+func (c *Regexp) advance(states []uint32, text string) []uint32 {
+	for _, r := range text {
+		if len(states) == 0 {
+			break
+		}
+		var next []uint32
+		for _, pc := range states {
+			inst := &c.prog.Inst[pc]
+			if isRuneInst(inst.Op) && inst.MatchRune(r) {
+				next = c.closure(next, inst.Out)
+			}
+		}
+		states = next
+	}
+	return states
+}
+
+// closure adds to the states the instruction pc and the ones reachable from it without
+// consuming any rune, keeping only the rune and match instructions.
+func (c *Regexp) closure(states []uint32, pc uint32) []uint32 {
+	stack := []uint32{pc}
+	visited := make(map[uint32]bool)
+	for len(stack) > 0 {
+		pc, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if visited[pc] {
+			continue
+		}
+		visited[pc] = true
+		inst := &c.prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			stack = append(stack, inst.Arg, inst.Out)
+		case syntax.InstCapture, syntax.InstEmptyWidth, syntax.InstNop:
+			stack = append(stack, inst.Out)
+		case syntax.InstFail:
+			// dead end
+		default:
+			states = insertState(states, pc)
+		}
+	}
+	return states
+}
+
+// matched reports whether the states include the match.
+func (c *Regexp) matched(states []uint32) bool {
+	for _, pc := range states {
+		if c.prog.Inst[pc].Op == syntax.InstMatch {
+			return true
+		}
+	}
+	return false
+}
+
+func isRuneInst(op syntax.InstOp) bool {
+	switch op {
+	case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+		return true
+	default:
+		return false
+	}
+}
+
+// insertState adds the state to the sorted states, if missing.
+func insertState(states []uint32, pc uint32) []uint32 {
+	i := sort.Search(len(states), func(i int) bool { return states[i] >= pc })
+	if i < len(states) && states[i] == pc {
+		return states
+	}
+	states = append(states, 0)
+	copy(states[i+1:], states[i:])
+	states[i] = pc
+	return states
+}
+
+// processConstraints sets to -Inf the scores of the tokens disallowed by the constraints, and
+// those of the end of sequence while a constraint is not satisfied.
+func (b *Generator) processConstraints(inputIDs [][]int, scores []Scores) []Scores {
+	for i, ids := range inputIDs {
+		generated := ids[1:] // without the decoder start token
+		remaining := b.config.MaxLength - 1 - len(ids)
+		for _, c := range b.config.Constraints {
+			if allowed := c.Allowed(generated, remaining); allowed != nil {
+				maskAllBut(scores[i], allowed)
+			}
+			if b.config.EOSTokenID >= 0 && !c.Satisfied(generated) {
+				scores[i].SetVec(b.config.EOSTokenID, mat.Inf(-1))
+			}
+		}
+	}
+	return scores
+}
+
+// maskAllBut sets to -Inf the scores of all the tokens but the allowed ones.
+func maskAllBut(scores Scores, allowed []int) {
+	keep := make(map[int]bool, len(allowed))
+	for _, id := range allowed {
+		keep[id] = true
+	}
+	for id := 0; id < scores.Size(); id++ {
+		if !keep[id] {
+			scores.SetVec(id, mat.Inf(-1))
+		}
+	}
+}
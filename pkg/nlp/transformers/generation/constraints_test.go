@@ -0,0 +1,129 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generation
+
+import (
+	"context"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	testStartID   = 0
+	testEOSID     = 1
+	testPadID     = 2
+	testVocabSize = 6
+)
+
+// testTexts are the texts of the tokens of testModel.
+var testTexts = []string{"", "", "", "a", "b", "c"}
+
+// testModel always prefers the token 3 ("a"), then 4 ("b"), then 5 ("c"), then the end of
+// sequence.
+type testModel struct {
+	nn.BaseModel
+}
+
+var _ EncoderDecoder = &testModel{}
+
+func (m *testModel) Encode(inputIDs []int) []ag.Node {
+	return []ag.Node{m.Graph().NewScalar(mat.Float(len(inputIDs)))}
+}
+
+func (m *testModel) Decode(_ []ag.Node, _ []int, _ Cache) (ag.Node, Cache) {
+	return m.Graph().NewVariable(mat.NewVecDense([]mat.Float{0, 1, 0, 4, 3, 2}), false), nil
+}
+
+func testGenerate(constraints ...Constraint) []int {
+	return testGenerateWith(func(config *GeneratorConfig) {
+		config.Constraints = constraints
+	})
+}
+
+// testGenerateWith generates a sequence from testModel, with the configuration modified by fn.
+func testGenerateWith(fn func(config *GeneratorConfig)) []int {
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, &testModel{}).(*testModel)
+	config := GeneratorConfig{
+		NumBeams:                  2,
+		MaxLength:                 6,
+		IsEncoderDecoder:          true,
+		EOSTokenID:                testEOSID,
+		PadTokenID:                testPadID,
+		VocabSize:                 testVocabSize,
+		DecoderStartTokenID:       testStartID,
+		LengthPenalty:             1.0,
+		MaxConcurrentComputations: 1,
+		IncrementalForward:        true,
+	}
+	fn(&config)
+	generator := NewGenerator(config, proc)
+	ids, err := generator.GenerateContext(context.Background(), []int{3})
+	if err != nil {
+		panic(err)
+	}
+	return ids
+}
+
+func TestForcedPrefix(t *testing.T) {
+	c := &ForcedPrefix{TokenIDs: []int{5, 4}}
+	assert.Equal(t, []int{5}, c.Allowed(nil, 10))
+	assert.Equal(t, []int{4}, c.Allowed([]int{5}, 10))
+	assert.Nil(t, c.Allowed([]int{5, 4}, 10))
+	assert.False(t, c.Satisfied([]int{5}))
+	assert.True(t, c.Satisfied([]int{5, 4, 3}))
+
+	assert.Equal(t, []int{testStartID, 5, 4, 3, 3, testEOSID}, testGenerate(c))
+}
+
+func TestRequiredPhrases(t *testing.T) {
+	c := &RequiredPhrases{Phrases: [][]int{{4, 5}, {3}}}
+	assert.False(t, c.Satisfied([]int{3, 4}))
+	assert.True(t, c.Satisfied([]int{4, 5, 3}))
+
+	// the model is free to choose while there is room
+	assert.Nil(t, c.Allowed([]int{3}, 3))
+	// then the missing phrase is forced, continuing a partial match
+	assert.Equal(t, []int{4}, c.Allowed([]int{3}, 2))
+	assert.Equal(t, []int{5}, c.Allowed([]int{3, 4}, 1))
+	assert.Nil(t, c.Allowed([]int{3, 4, 5}, 1))
+
+	ids := testGenerate(c)
+	assert.Equal(t, testEOSID, ids[len(ids)-1])
+	assert.True(t, c.Satisfied(ids[1:]))
+	// without the constraint, only the token 3 is generated
+	assert.Equal(t, []int{testStartID, 3, 3, 3, 3, testEOSID}, testGenerate())
+}
+
+func TestRegexp(t *testing.T) {
+	c, err := NewRegexp(`b+c?`, testTexts, testEOSID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2, 4}, c.Allowed(nil, 10))
+	assert.Equal(t, []int{0, testEOSID, 2, 4, 5}, c.Allowed([]int{4}, 10))
+	assert.Equal(t, []int{0, testEOSID, 2}, c.Allowed([]int{4, 5}, 10))
+	assert.False(t, c.Satisfied(nil))
+	assert.True(t, c.Satisfied([]int{4, 4}))
+	assert.False(t, c.Satisfied([]int{4, 3}))
+
+	ids := testGenerate(c)
+	assert.Equal(t, []int{testStartID, 4, 4, 4, 4, testEOSID}, ids)
+
+	// the tokens can span several characters
+	c, err = NewRegexp(`(ab)+|[0-9]{2}`, []string{"", "", "", "ab", "a", "b", "1", "234"}, testEOSID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2, 3, 4, 6}, c.Allowed(nil, 10))
+	assert.Equal(t, []int{0, 2, 5}, c.Allowed([]int{4}, 10))
+	assert.Equal(t, []int{0, testEOSID, 2, 3, 4}, c.Allowed([]int{4, 5}, 10))
+	assert.True(t, c.Satisfied([]int{3, 4, 5}))
+	assert.True(t, c.Satisfied([]int{6, 6}))
+	assert.False(t, c.Satisfied([]int{7}))
+
+	_, err = NewRegexp(`(a`, testTexts, testEOSID)
+	assert.Error(t, err)
+}
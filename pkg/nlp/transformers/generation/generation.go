@@ -8,6 +8,7 @@
 package generation
 
 import (
+	"context"
 	mat "github.com/nlpodyssey/spago/pkg/mat32"
 	"github.com/nlpodyssey/spago/pkg/ml/ag"
 	"github.com/nlpodyssey/spago/pkg/utils/processingqueue"
@@ -23,35 +24,47 @@ type Generator struct {
 	processingQueue processingqueue.ProcessingQueue
 	padMask         ag.Node
 	eosMask         ag.Node
+	processors      []ScoresProcessor
 }
 
 // NewGenerator creates a new Generator object.
 func NewGenerator(config GeneratorConfig, model EncoderDecoder) *Generator {
-	return &Generator{
+	b := &Generator{
 		config:          config,
 		model:           model,
 		processingQueue: processingqueue.New(config.MaxConcurrentComputations),
 		padMask:         makePadMask(model.Graph(), config.PadTokenID, config.VocabSize),
 		eosMask:         makeEosMask(model.Graph(), config.EOSTokenID, config.VocabSize),
 	}
+	b.processors = b.makeProcessors()
+	return b
 }
 
 // Generate generates sequences for models with a language modeling head, using
 // generation-search decoding.
 func (b *Generator) Generate(inputIDs []int) []int {
+	ids, _ := b.GenerateContext(context.Background(), inputIDs)
+	return ids
+}
+
+// GenerateContext is like Generate, but it stops the decoding as soon as the context
+// is done, returning the context error.
+func (b *Generator) GenerateContext(ctx context.Context, inputIDs []int) ([]int, error) {
 	if !b.config.IsEncoderDecoder {
 		panic("generator: unsupported architecture")
 	}
 
 	encodedInput := b.model.Encode(inputIDs)
 	if !b.config.IncrementalForward {
-		b.performForward()
+		if err := b.performForward(ctx); err != nil {
+			return nil, err
+		}
 	}
 
-	return b.beamSearch(NewScorer(b.config), encodedInput)
+	return b.beamSearch(ctx, NewScorer(b.config), encodedInput)
 }
 
-func (b *Generator) beamSearch(scorer *Scorer, encodedInput []ag.Node) []int {
+func (b *Generator) beamSearch(ctx context.Context, scorer *Scorer, encodedInput []ag.Node) ([]int, error) {
 	var (
 		numBeams         = b.config.NumBeams
 		beamScores       = b.makeInitBeamScores()
@@ -59,10 +72,17 @@ func (b *Generator) beamSearch(scorer *Scorer, encodedInput []ag.Node) []int {
 		scores           = make([]Scores, numBeams)
 		cache            = make([]Cache, numBeams)
 		curLen           = len(decodingInputIDs[0])
+		err              error
 	)
 
 	for curLen < b.config.MaxLength {
-		scores, cache = b.generateNext(encodedInput, decodingInputIDs, cache)
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		scores, cache, err = b.generateNext(ctx, encodedInput, decodingInputIDs, cache)
+		if err != nil {
+			return nil, err
+		}
 		nextTokenScores := b.inhibitInvalidTokens(decodingInputIDs, scores)
 		updateTokensScores(nextTokenScores, beamScores)
 		scoredTokens := b.getTopKScoredTokens(nextTokenScores)
@@ -77,14 +97,15 @@ func (b *Generator) beamSearch(scorer *Scorer, encodedInput []ag.Node) []int {
 		curLen++
 	}
 
-	return scorer.Finalize(decodingInputIDs, beamScores)
+	return scorer.Finalize(decodingInputIDs, beamScores), nil
 }
 
 func (b *Generator) generateNext(
+	ctx context.Context,
 	encodedInput []ag.Node,
 	decodingInputIDs [][]int,
 	pastCache []Cache,
-) ([]Scores, []Cache) {
+) ([]Scores, []Cache, error) {
 	numBeams := b.config.NumBeams
 	logProbs := make([]ag.Node, numBeams)
 	logits := make([]ag.Node, numBeams)
@@ -105,20 +126,25 @@ func (b *Generator) generateNext(
 	wg.Wait()
 
 	if !b.config.IncrementalForward {
-		b.performForward()
+		if err := b.performForward(ctx); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	for i := 0; i < numBeams; i++ {
 		scores[i] = b.model.Graph().GetCopiedValue(logProbs[i])
 	}
 
-	return scores, nextCache
+	return scores, nextCache, nil
 }
 
-func (b *Generator) performForward() {
+func (b *Generator) performForward(ctx context.Context) error {
 	g := b.model.Graph()
-	g.Forward(ag.Range(g.TimeStep(), -1))
+	if err := g.ForwardContext(ctx, ag.Range(g.TimeStep(), -1)); err != nil {
+		return err
+	}
 	g.IncTimeStep() // mark the next block to be computed from here on
+	return nil
 }
 
 func (b *Generator) getTopKScoredTokens(tokensScores []Scores) ScoredTokens {
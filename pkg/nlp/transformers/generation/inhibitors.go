@@ -12,11 +12,8 @@ import (
 )
 
 func (b *Generator) inhibitInvalidTokens(inputIDs [][]int, scores []Scores) []Scores {
-	if b.config.MinLength >= 0 && b.config.EOSTokenID >= 0 {
-		scores = b.processMinLengthScores(inputIDs, scores)
-	}
-	if len(b.config.BadWordsIDs) > 0 {
-		scores = b.processBadWordsScores(inputIDs, scores)
+	for _, p := range b.processors {
+		scores = p.Process(inputIDs, scores)
 	}
 	return scores
 }
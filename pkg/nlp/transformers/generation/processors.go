@@ -0,0 +1,137 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/utils"
+)
+
+// ScoresProcessor is implemented by any value that has the Process method.
+type ScoresProcessor interface {
+	// Process modifies the scores (log-probabilities) of the next tokens of each beam, given the
+	// input IDs of the beams, and returns them; e.g. it sets to -Inf the scores of the tokens
+	// which must not be generated.
+	Process(inputIDs [][]int, scores []Scores) []Scores
+}
+
+// ScoresProcessorFunc is an adapter to allow the use of ordinary functions as ScoresProcessor.
+type ScoresProcessorFunc func(inputIDs [][]int, scores []Scores) []Scores
+
+// Process calls f(inputIDs, scores).
+func (f ScoresProcessorFunc) Process(inputIDs [][]int, scores []Scores) []Scores {
+	return f(inputIDs, scores)
+}
+
+var (
+	_ ScoresProcessor = ScoresProcessorFunc(nil)
+	_ ScoresProcessor = &RepetitionPenalty{}
+	_ ScoresProcessor = &NoRepeatNGram{}
+	_ ScoresProcessor = &StopSequences{}
+)
+
+// makeProcessors returns the chain of the processors of the scores enabled by the configuration.
+func (b *Generator) makeProcessors() []ScoresProcessor {
+	var processors []ScoresProcessor
+	if b.config.MinLength >= 0 && b.config.EOSTokenID >= 0 {
+		processors = append(processors, ScoresProcessorFunc(b.processMinLengthScores))
+	}
+	if b.config.RepetitionPenalty != 0 && b.config.RepetitionPenalty != 1 {
+		processors = append(processors, &RepetitionPenalty{Penalty: b.config.RepetitionPenalty})
+	}
+	if b.config.NoRepeatNGramSize > 0 {
+		processors = append(processors, &NoRepeatNGram{Size: b.config.NoRepeatNGramSize})
+	}
+	if len(b.config.BadWordsIDs) > 0 {
+		processors = append(processors, ScoresProcessorFunc(b.processBadWordsScores))
+	}
+	if len(b.config.Constraints) > 0 {
+		processors = append(processors, ScoresProcessorFunc(b.processConstraints))
+	}
+	if len(b.config.StopSequences) > 0 && b.config.EOSTokenID >= 0 {
+		processors = append(processors, &StopSequences{
+			Sequences:  b.config.StopSequences,
+			EOSTokenID: b.config.EOSTokenID,
+		})
+	}
+	return append(processors, b.config.Processors...)
+}
+
+// RepetitionPenalty is a ScoresProcessor penalizing the tokens already generated, as in "CTRL: A
+// Conditional Transformer Language Model for Controllable Generation" by Keskar et al., 2019
+// (https://arxiv.org/abs/1909.05858). The negative scores are multiplied by Penalty, the positive
+// ones divided by it.
+type RepetitionPenalty struct {
+	Penalty mat.Float
+}
+
+// Process satisfies the ScoresProcessor interface.
+func (p *RepetitionPenalty) Process(inputIDs [][]int, scores []Scores) []Scores {
+	for i, ids := range inputIDs {
+		seen := make(map[int]bool, len(ids))
+		for _, id := range ids[1:] { // without the decoder start token
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if score := scores[i].AtVec(id); score < 0 {
+				scores[i].SetVec(id, score*p.Penalty)
+			} else {
+				scores[i].SetVec(id, score/p.Penalty)
+			}
+		}
+	}
+	return scores
+}
+
+// NoRepeatNGram is a ScoresProcessor preventing the n-grams of the given Size from occurring
+// twice in a sequence.
+type NoRepeatNGram struct {
+	Size int
+}
+
+// Process satisfies the ScoresProcessor interface.
+func (p *NoRepeatNGram) Process(inputIDs [][]int, scores []Scores) []Scores {
+	n := p.Size
+	for i, ids := range inputIDs {
+		if len(ids) < n {
+			continue
+		}
+		prefix := ids[len(ids)-n+1:]
+		for start := 0; start+n <= len(ids); start++ {
+			if utils.IntSliceEqual(ids[start:start+n-1], prefix) {
+				scores[i].SetVec(ids[start+n-1], mat.Inf(-1))
+			}
+		}
+	}
+	return scores
+}
+
+// StopSequences is a ScoresProcessor ending the sequences which end with one of the stop
+// Sequences, forcing the end of sequence token.
+type StopSequences struct {
+	Sequences  [][]int
+	EOSTokenID int
+}
+
+// Process satisfies the ScoresProcessor interface.
+func (p *StopSequences) Process(inputIDs [][]int, scores []Scores) []Scores {
+	for i, ids := range inputIDs {
+		if p.Match(ids[1:]) >= 0 { // without the decoder start token
+			maskAllBut(scores[i], []int{p.EOSTokenID})
+		}
+	}
+	return scores
+}
+
+// Match returns the index of the first stop sequence the tokens end with, or -1.
+func (p *StopSequences) Match(tokenIDs []int) int {
+	for i, seq := range p.Sequences {
+		if len(seq) > 0 && len(seq) <= len(tokenIDs) && utils.IntSliceEqual(tokenIDs[len(tokenIDs)-len(seq):], seq) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generation
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestScores(values ...[]mat.Float) []Scores {
+	scores := make([]Scores, len(values))
+	for i, v := range values {
+		scores[i] = mat.NewVecDense(v)
+	}
+	return scores
+}
+
+func TestRepetitionPenalty(t *testing.T) {
+	p := &RepetitionPenalty{Penalty: 2}
+	scores := p.Process(
+		[][]int{{testStartID, 3, 3, 4}, {testStartID}},
+		newTestScores([]mat.Float{-1, -1, -1, -1, 0.5, -1}, []mat.Float{-1, -1, -1, -1, -1, -1}),
+	)
+	assert.Equal(t, []mat.Float{-1, -1, -1, -2, 0.25, -1}, scores[0].Data())
+	// the decoder start token is not penalized
+	assert.Equal(t, []mat.Float{-1, -1, -1, -1, -1, -1}, scores[1].Data())
+}
+
+func TestNoRepeatNGram(t *testing.T) {
+	p := &NoRepeatNGram{Size: 2}
+	scores := p.Process(
+		[][]int{{testStartID, 3, 4, 3}, {testStartID, 3}},
+		newTestScores(make([]mat.Float, testVocabSize), make([]mat.Float, testVocabSize)),
+	)
+	// "3 4" already occurred
+	assert.Equal(t, mat.Inf(-1), scores[0].AtVec(4))
+	assert.Equal(t, mat.Float(0), scores[0].AtVec(3))
+	assert.Equal(t, mat.Float(0), scores[0].AtVec(5))
+	assert.Equal(t, make([]mat.Float, testVocabSize), scores[1].Data())
+}
+
+func TestStopSequences(t *testing.T) {
+	p := &StopSequences{Sequences: [][]int{{4, 5}, {3}}, EOSTokenID: testEOSID}
+	assert.Equal(t, 0, p.Match([]int{3, 4, 5}))
+	assert.Equal(t, 1, p.Match([]int{5, 3}))
+	assert.Equal(t, -1, p.Match([]int{5, 4}))
+	assert.Equal(t, -1, p.Match(nil))
+
+	scores := p.Process(
+		[][]int{{testStartID, 4, 5}, {testStartID, 4}},
+		newTestScores(make([]mat.Float, testVocabSize), make([]mat.Float, testVocabSize)),
+	)
+	inf := mat.Inf(-1)
+	assert.Equal(t, []mat.Float{inf, 0, inf, inf, inf, inf}, scores[0].Data())
+	assert.Equal(t, make([]mat.Float, testVocabSize), scores[1].Data())
+}
+
+func TestGenerator_Processors(t *testing.T) {
+	// the model always prefers "3"
+	assert.Equal(t, []int{testStartID, 3, 3, 3, 3, testEOSID}, testGenerate())
+
+	// with greedy decoding, as the beam search may prefer the longer hypotheses
+	assert.Equal(t, []int{testStartID, 3, testEOSID}, testGenerateWith(func(c *GeneratorConfig) {
+		c.NumBeams = 1
+		c.StopSequences = [][]int{{3}}
+	}))
+	assert.Equal(t, []int{testStartID, 3, 4, 5, testEOSID}, testGenerateWith(func(c *GeneratorConfig) {
+		c.NoRepeatNGramSize = 1
+	}))
+	assert.Equal(t, []int{testStartID, 4, 4, 4, 4, testEOSID}, testGenerateWith(func(c *GeneratorConfig) {
+		c.BadWordsIDs = [][]int{{3}}
+	}))
+
+	// the custom processors follow the others
+	var calls int
+	testGenerateWith(func(c *GeneratorConfig) {
+		c.Processors = []ScoresProcessor{ScoresProcessorFunc(func(inputIDs [][]int, scores []Scores) []Scores {
+			calls++
+			return scores
+		})}
+	})
+	assert.Equal(t, 5, calls)
+}
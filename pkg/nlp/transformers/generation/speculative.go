@@ -0,0 +1,261 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generation
+
+import (
+	"context"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+)
+
+// SpeculativeConfig provides configuration settings for the speculative decoding.
+type SpeculativeConfig struct {
+	// NumDraftTokens is the number of tokens proposed by the draft model at each step.
+	NumDraftTokens int
+	// Temperature is the temperature of the sampling of the tokens; 0 is the greedy decoding.
+	Temperature mat.Float
+	// Seed initializes the random generator of the sampling.
+	Seed uint64
+}
+
+// SpeculativeGenerator generates sequences by speculative decoding: a small draft model proposes
+// a few tokens, one at a time, then the model verifies all of them in a single step, keeping
+// those it agrees with and adding a token of its own. The sequences follow the distribution of
+// the model alone (they are the same with the greedy decoding), while most of its steps are
+// replaced by the much cheaper ones of the draft model.
+//
+// Reference: "Fast Inference from Transformers via Speculative Decoding" by Leviathan et al., 2022
+// (https://arxiv.org/abs/2211.17192); "Accelerating Large Language Model Decoding with Speculative
+// Sampling" by Chen et al., 2023 (https://arxiv.org/abs/2302.01318).
+type SpeculativeGenerator struct {
+	config  SpeculativeConfig
+	model   *Generator
+	draft   *Generator
+	randGen *rand.LockedRand
+}
+
+// NewSpeculativeGenerator returns a new SpeculativeGenerator of the model, with the draft model.
+// Both must be SequenceDecoders sharing the same vocabulary. The configuration of the generator
+// applies to both, but the NumBeams, as a single sequence is decoded.
+func NewSpeculativeGenerator(config GeneratorConfig, speculative SpeculativeConfig, model, draft EncoderDecoder) *SpeculativeGenerator {
+	if _, ok := model.(SequenceDecoder); !ok {
+		panic("generator: the model must be a SequenceDecoder")
+	}
+	if _, ok := draft.(SequenceDecoder); !ok {
+		panic("generator: the draft model must be a SequenceDecoder")
+	}
+	if speculative.NumDraftTokens < 1 {
+		panic("generator: the number of draft tokens must be positive")
+	}
+	config.NumBeams = 1
+	return &SpeculativeGenerator{
+		config:  speculative,
+		model:   NewGenerator(config, model),
+		draft:   NewGenerator(config, draft),
+		randGen: rand.NewLockedRand(speculative.Seed),
+	}
+}
+
+// Generate generates a sequence for the input, by speculative decoding.
+func (s *SpeculativeGenerator) Generate(inputIDs []int) []int {
+	ids, _ := s.GenerateContext(context.Background(), inputIDs)
+	return ids
+}
+
+// GenerateContext is like Generate, but it stops the decoding as soon as the context
+// is done, returning the context error.
+func (s *SpeculativeGenerator) GenerateContext(ctx context.Context, inputIDs []int) ([]int, error) {
+	config := s.model.config
+	if !config.IsEncoderDecoder {
+		panic("generator: unsupported architecture")
+	}
+
+	model := &decodingState{generator: s.model, encodedInput: s.model.model.Encode(inputIDs)}
+	draft := &decodingState{generator: s.draft, encodedInput: s.draft.model.Encode(inputIDs)}
+	if !config.IncrementalForward {
+		if err := s.model.performForward(ctx); err != nil {
+			return nil, err
+		}
+		if err := s.draft.performForward(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := []int{config.DecoderStartTokenID}
+	for len(ids) < config.MaxLength {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		next, err := s.step(ctx, ids, model, draft)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, next...)
+		if next[len(next)-1] == config.EOSTokenID {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// step returns the next tokens of the sequence: the ones proposed by the draft model accepted by
+// the model, followed by one of the model.
+func (s *SpeculativeGenerator) step(ctx context.Context, ids []int, model, draft *decodingState) ([]int, error) {
+	eosTokenID := s.model.config.EOSTokenID
+	numDraftTokens := s.config.NumDraftTokens
+	if remaining := s.model.config.MaxLength - len(ids); numDraftTokens > remaining {
+		numDraftTokens = remaining
+	}
+
+	// the draft model proposes the tokens, keeping its states to resume from the accepted ones
+	sequence := append(make([]int, 0, len(ids)+numDraftTokens), ids...)
+	draftProbs := make([][]mat.Float, 0, numDraftTokens)
+	draftStates := make([]decodingState, 0, numDraftTokens)
+	for len(draftProbs) < numDraftTokens {
+		scores, err := draft.decode(ctx, sequence)
+		if err != nil {
+			return nil, err
+		}
+		draftStates = append(draftStates, *draft)
+		probs := s.distribution(scores[len(scores)-1])
+		token := s.sample(probs)
+		sequence = append(sequence, token)
+		draftProbs = append(draftProbs, probs)
+		if token == eosTokenID {
+			break
+		}
+	}
+	proposed := sequence[len(ids):]
+
+	// the model computes the distributions of all the proposed tokens at once, and of the next
+	// one if they can be followed
+	scores, err := model.decode(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	accepted := *model
+	probs := [][]mat.Float{s.distribution(scores[len(scores)-1])}
+	verified := sequence[:len(sequence)-1]
+	if proposed[len(proposed)-1] != eosTokenID && len(sequence) < s.model.config.MaxLength {
+		verified = sequence
+	}
+	if len(verified) > len(ids) {
+		scores, err = model.decode(ctx, verified)
+		if err != nil {
+			return nil, err
+		}
+		for _, score := range scores {
+			probs = append(probs, s.distribution(score))
+		}
+	}
+
+	for i, token := range proposed {
+		if !s.accept(token, probs[i], draftProbs[i]) {
+			*model = accepted
+			*draft = draftStates[i]
+			return append(proposed[:i:i], s.resample(probs[i], draftProbs[i])), nil
+		}
+	}
+	*draft = draftStates[len(draftStates)-1]
+	if len(probs) > len(proposed) {
+		return append(proposed, s.sample(probs[len(proposed)])), nil
+	}
+	return proposed, nil
+}
+
+// distribution returns the probabilities of the next token from its scores, according to the
+// temperature; with the greedy decoding, the most likely token has probability 1.
+func (s *SpeculativeGenerator) distribution(scores Scores) []mat.Float {
+	data := scores.Data()
+	if s.config.Temperature == 0 {
+		probs := make([]mat.Float, len(data))
+		probs[floatutils.ArgMax(data)] = 1
+		return probs
+	}
+	scaled := make([]mat.Float, len(data))
+	for i, score := range data {
+		scaled[i] = score / s.config.Temperature
+	}
+	return floatutils.SoftMax(scaled)
+}
+
+// accept reports whether the token proposed by the draft model is accepted, which happens with
+// probability min(1, p(token) / q(token)), where p and q are the distributions of the model and
+// of the draft model.
+func (s *SpeculativeGenerator) accept(token int, p, q []mat.Float) bool {
+	if p[token] >= q[token] {
+		return true
+	}
+	return s.randGen.Float() < p[token]/q[token]
+}
+
+// resample samples the token replacing the rejected one from the residual distribution
+// max(0, p - q), normalized, so that the tokens follow the distribution p of the model.
+func (s *SpeculativeGenerator) resample(p, q []mat.Float) int {
+	residual := make([]mat.Float, len(p))
+	var sum mat.Float
+	for i := range p {
+		if d := p[i] - q[i]; d > 0 {
+			residual[i] = d
+			sum += d
+		}
+	}
+	if sum == 0 {
+		return s.sample(p)
+	}
+	for i := range residual {
+		residual[i] /= sum
+	}
+	return s.sample(residual)
+}
+
+// sample samples a token from the distribution.
+func (s *SpeculativeGenerator) sample(probs []mat.Float) int {
+	u := s.randGen.Float()
+	var cumulative mat.Float
+	for i, p := range probs {
+		cumulative += p
+		if u < cumulative {
+			return i
+		}
+	}
+	return floatutils.ArgMax(probs) // rounding errors
+}
+
+// decodingState is the state of the decoding of a sequence by a model of the SpeculativeGenerator.
+type decodingState struct {
+	generator    *Generator
+	encodedInput []ag.Node
+	// cache contains the first cached inputs of the sequence.
+	cache  Cache
+	cached int
+}
+
+// decode decodes the inputs of the sequence not in the cache yet, adding them, and returns the
+// scores of the next token after each of them, with the same adjustments and processors of the
+// beam search.
+func (d *decodingState) decode(ctx context.Context, sequence []int) ([]Scores, error) {
+	b := d.generator
+	g := b.model.Graph()
+	logits, cache := b.model.(SequenceDecoder).DecodeSequence(d.encodedInput, sequence[d.cached:], d.cache)
+	logProbs := make([]ag.Node, len(logits))
+	for i, x := range logits {
+		logProbs[i] = g.LogSoftmax(b.adjustLogitsDuringGeneration(x, d.cached+i+1))
+	}
+	if !b.config.IncrementalForward {
+		if err := b.performForward(ctx); err != nil {
+			return nil, err
+		}
+	}
+	scores := make([]Scores, len(logProbs))
+	for i, x := range logProbs {
+		prefix := sequence[:d.cached+i+1]
+		scores[i] = b.inhibitInvalidTokens([][]int{prefix}, []Scores{g.GetCopiedValue(x)})[0]
+	}
+	d.cache, d.cached = cache, len(sequence)
+	return scores, nil
+}
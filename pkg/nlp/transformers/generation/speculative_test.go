@@ -0,0 +1,204 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generation
+
+import (
+	"context"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// testSequenceModel is a SequenceDecoder whose logits depend on the whole decoded prefix, which
+// it keeps in the cache. It counts the calls of DecodeSequence.
+type testSequenceModel struct {
+	nn.BaseModel
+	Logits func(prefix []int) []mat.Float
+	Calls  *int
+}
+
+var (
+	_ EncoderDecoder  = &testSequenceModel{}
+	_ SequenceDecoder = &testSequenceModel{}
+)
+
+func newTestSequenceModel(g *ag.Graph, logits func(prefix []int) []mat.Float) *testSequenceModel {
+	m := &testSequenceModel{Logits: logits, Calls: new(int)}
+	return nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, m).(*testSequenceModel)
+}
+
+func (m *testSequenceModel) Encode(inputIDs []int) []ag.Node {
+	return []ag.Node{m.Graph().NewScalar(mat.Float(len(inputIDs)))}
+}
+
+func (m *testSequenceModel) Decode(encodedInput []ag.Node, inputIDs []int, pastCache Cache) (ag.Node, Cache) {
+	if pastCache != nil {
+		inputIDs = inputIDs[len(inputIDs)-1:]
+	}
+	logits, cache := m.DecodeSequence(encodedInput, inputIDs, pastCache)
+	return logits[len(logits)-1], cache
+}
+
+func (m *testSequenceModel) DecodeSequence(_ []ag.Node, inputIDs []int, pastCache Cache) ([]ag.Node, Cache) {
+	*m.Calls++
+	past, _ := pastCache.([]int)
+	prefix := append([]int{}, past...)
+	logits := make([]ag.Node, len(inputIDs))
+	for i, id := range inputIDs {
+		prefix = append(prefix, id)
+		logits[i] = m.Graph().NewVariable(mat.NewVecDense(m.Logits(prefix)), false)
+	}
+	return logits, prefix
+}
+
+// testTargetLogits are the logits of the model, varying with the length and the last token of
+// the prefix; the end of sequence is unlikely before the length 8.
+func testTargetLogits(prefix []int) []mat.Float {
+	logits := make([]mat.Float, testVocabSize)
+	for id := range logits {
+		logits[id] = mat.Float((prefix[len(prefix)-1]*7 + len(prefix)*3 + id*5) % 11)
+	}
+	if len(prefix) < 8 {
+		logits[testEOSID] = -1
+	}
+	return logits
+}
+
+// testDraftLogits are the logits of a draft model agreeing with the model on the even positions
+// only.
+func testDraftLogits(prefix []int) []mat.Float {
+	if len(prefix)%2 == 0 {
+		return testTargetLogits(prefix)
+	}
+	logits := make([]mat.Float, testVocabSize)
+	for id := range logits {
+		logits[id] = mat.Float((id + len(prefix)) % testVocabSize)
+	}
+	return logits
+}
+
+func testSpeculativeConfig() GeneratorConfig {
+	return GeneratorConfig{
+		MaxLength:                 12,
+		IsEncoderDecoder:          true,
+		EOSTokenID:                testEOSID,
+		PadTokenID:                testPadID,
+		VocabSize:                 testVocabSize,
+		DecoderStartTokenID:       testStartID,
+		MaxConcurrentComputations: 1,
+		IncrementalForward:        true,
+	}
+}
+
+// testGreedyDecoding returns the sequence decoded greedily from the logits.
+func testGreedyDecoding(logits func(prefix []int) []mat.Float, maxLength int) []int {
+	ids := []int{testStartID}
+	for len(ids) < maxLength {
+		scores := logits(ids)
+		scores[testPadID] = mat.Inf(-1)
+		next := floatutils.ArgMax(scores)
+		if len(ids) == maxLength-1 {
+			next = testEOSID
+		}
+		ids = append(ids, next)
+		if next == testEOSID {
+			break
+		}
+	}
+	return ids
+}
+
+func TestSpeculativeGenerator_Greedy(t *testing.T) {
+	config := testSpeculativeConfig()
+	expected := testGreedyDecoding(testTargetLogits, config.MaxLength)
+
+	draftLogits := map[string]func(prefix []int) []mat.Float{
+		"same":      testTargetLogits,
+		"partial":   testDraftLogits,
+		"different": func([]int) []mat.Float { return []mat.Float{0, 0, 0, 0, 0, 9} },
+	}
+	for name, logits := range draftLogits {
+		for _, numDraftTokens := range []int{1, 3, 20} {
+			g := ag.NewGraph()
+			model := newTestSequenceModel(g, testTargetLogits)
+			draft := newTestSequenceModel(g, logits)
+			generator := NewSpeculativeGenerator(config, SpeculativeConfig{NumDraftTokens: numDraftTokens}, model, draft)
+			ids, err := generator.GenerateContext(context.Background(), []int{3})
+			assert.NoError(t, err)
+			assert.Equal(t, expected, ids, "%s draft, %d tokens", name, numDraftTokens)
+			if name == "same" && numDraftTokens > 1 {
+				// the model verifies several tokens at each step
+				assert.Less(t, *model.Calls, len(ids)-1)
+			}
+			g.Clear()
+		}
+	}
+
+	// with the forward of the graph deferred
+	config.IncrementalForward = false
+	g := ag.NewGraph(ag.IncrementalForward(false))
+	defer g.Clear()
+	model := newTestSequenceModel(g, testTargetLogits)
+	draft := newTestSequenceModel(g, testDraftLogits)
+	generator := NewSpeculativeGenerator(config, SpeculativeConfig{NumDraftTokens: 3}, model, draft)
+	assert.Equal(t, expected, generator.Generate([]int{3}))
+}
+
+func TestSpeculativeGenerator_Processors(t *testing.T) {
+	config := testSpeculativeConfig()
+	config.NoRepeatNGramSize = 1
+	g := ag.NewGraph()
+	defer g.Clear()
+	model := newTestSequenceModel(g, testTargetLogits)
+	draft := newTestSequenceModel(g, testTargetLogits)
+	generator := NewSpeculativeGenerator(config, SpeculativeConfig{NumDraftTokens: 3}, model, draft)
+	ids := generator.Generate([]int{3})
+	seen := make(map[int]bool)
+	for _, id := range ids[1:] {
+		assert.False(t, seen[id], "repeated token %d in %v", id, ids)
+		seen[id] = true
+	}
+}
+
+func TestSpeculativeGenerator_Sampling(t *testing.T) {
+	config := testSpeculativeConfig()
+	config.MaxLength = 3 // a token, then the end of sequence
+	g := ag.NewGraph()
+	defer g.Clear()
+	model := newTestSequenceModel(g, testTargetLogits)
+	draft := newTestSequenceModel(g, testDraftLogits)
+	generator := NewSpeculativeGenerator(config, SpeculativeConfig{NumDraftTokens: 2, Temperature: 1, Seed: 42}, model, draft)
+
+	logits := testTargetLogits([]int{testStartID})
+	logits[testPadID] = mat.Inf(-1)
+	expected := floatutils.SoftMax(logits)
+
+	const n = 5000
+	counts := make([]int, testVocabSize)
+	for i := 0; i < n; i++ {
+		counts[generator.Generate([]int{3})[1]]++
+	}
+	for id, p := range expected {
+		assert.InDelta(t, p, mat.Float(counts[id])/n, 0.02, "token %d", id)
+	}
+}
+
+func TestNewSpeculativeGenerator(t *testing.T) {
+	g := ag.NewGraph()
+	defer g.Clear()
+	model := newTestSequenceModel(g, testTargetLogits)
+	notSequenceDecoder := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, &testModel{}).(*testModel)
+	config := testSpeculativeConfig()
+	assert.Panics(t, func() {
+		NewSpeculativeGenerator(config, SpeculativeConfig{NumDraftTokens: 2}, notSequenceDecoder, model)
+	})
+	assert.Panics(t, func() {
+		NewSpeculativeGenerator(config, SpeculativeConfig{NumDraftTokens: 2}, model, notSequenceDecoder)
+	})
+	assert.Panics(t, func() { NewSpeculativeGenerator(config, SpeculativeConfig{}, model, model) })
+}
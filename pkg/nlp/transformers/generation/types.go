@@ -30,6 +30,16 @@ type Decoder interface {
 	Decode(encodedInput []ag.Node, decodingInputIDs []int, pastCache Cache) (ag.Node, Cache)
 }
 
+// SequenceDecoder is a Decoder able to decode several inputs at once, as needed to verify in a
+// single step the tokens proposed by the draft model of the speculative decoding.
+type SequenceDecoder interface {
+	Decoder
+	// DecodeSequence returns the logits of the next element after each of the decodingInputIDs,
+	// which follow the ones already in the pastCache (nil at the start), and the cache including
+	// all of them.
+	DecodeSequence(encodedInput []ag.Node, decodingInputIDs []int, pastCache Cache) ([]ag.Node, Cache)
+}
+
 // Scores is just an alias of a Matrix
 type Scores = mat.Matrix
 
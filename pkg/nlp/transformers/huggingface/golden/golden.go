@@ -0,0 +1,213 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package golden provides a test harness to compare the outputs of spaGO models with reference
+// activations and logits exported from Hugging Face Transformers, so that regressions of the
+// converters can be detected (and located) layer by layer.
+//
+// The reference tensors can be exported in JSON, as an object mapping each name to its
+// "shape" and "data" (flattened in row-major order), or as a NumPy .npz archive, e.g.:
+//
+//	out = model(**inputs, output_hidden_states=True)
+//	np.savez("reference.npz", **{f"hidden_states.{i}": h[0].numpy() for i, h in enumerate(out.hidden_states)})
+//
+// Leading dimensions of size one (e.g. the batch) are ignored when comparing the tensors.
+package golden
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Tensor is a multi-dimensional array of reference values, stored in row-major order.
+type Tensor struct {
+	Shape []int       `json:"shape"`
+	Data  []mat.Float `json:"data"`
+}
+
+// Reference is a collection of named tensors exported from a reference implementation.
+type Reference map[string]*Tensor
+
+// Load reads the reference tensors from a JSON file or a NumPy .npz archive, according to
+// the extension of the file name.
+func Load(filename string) (Reference, error) {
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".json":
+		return LoadJSON(filename)
+	case ".npz":
+		return LoadNPZ(filename)
+	default:
+		return nil, fmt.Errorf("golden: unsupported file extension %q", ext)
+	}
+}
+
+// Matrix returns the tensor as a matrix, after the removal of the leading dimensions of size one.
+// A tensor with one dimension is returned as a column vector.
+func (t *Tensor) Matrix() (mat.Matrix, error) {
+	shape := squeeze(t.Shape)
+	switch len(shape) {
+	case 0:
+		return mat.NewScalar(t.Data[0]), nil
+	case 1:
+		return mat.NewVecDense(t.Data), nil
+	case 2:
+		return mat.NewDense(shape[0], shape[1], t.Data), nil
+	default:
+		return nil, fmt.Errorf("golden: tensor with shape %v can't be converted to a matrix", t.Shape)
+	}
+}
+
+func (t *Tensor) validate() error {
+	size := 1
+	for _, dim := range t.Shape {
+		size *= dim
+	}
+	if size != len(t.Data) {
+		return fmt.Errorf("golden: tensor with shape %v and %d elements", t.Shape, len(t.Data))
+	}
+	return nil
+}
+
+// squeeze removes the leading dimensions of size one.
+func squeeze(shape []int) []int {
+	for len(shape) > 0 && shape[0] == 1 {
+		shape = shape[1:]
+	}
+	return shape
+}
+
+// Output is a named output of a spaGO model, compared with the reference tensor with the same name.
+type Output struct {
+	Name  string
+	Value mat.Matrix
+}
+
+// NewOutput returns a new Output, whose value is the matrix made of the values of the nodes as rows
+// (e.g. the hidden states of all the tokens of a sequence). A single node is taken as it is.
+func NewOutput(name string, nodes ...ag.Node) Output {
+	if len(nodes) == 1 {
+		return Output{Name: name, Value: nodes[0].Value()}
+	}
+	rows := make([]mat.Matrix, len(nodes))
+	for i, n := range nodes {
+		rows[i] = n.Value()
+	}
+	return Output{Name: name, Value: mat.Stack(rows...)}
+}
+
+// Tolerance provides the maximum accepted difference between an actual value a and an expected value e,
+// as in NumPy's allclose: |a - e| <= Abs + Rel * |e|.
+type Tolerance struct {
+	Abs mat.Float
+	Rel mat.Float
+}
+
+// DefaultTolerance is suitable for the comparison of single-precision activations.
+var DefaultTolerance = Tolerance{Abs: 1e-4, Rel: 1e-3}
+
+// Result is the outcome of the comparison of an Output with its reference tensor.
+type Result struct {
+	Name string
+	// MaxAbsDiff is the maximum absolute difference between the actual and the expected values.
+	MaxAbsDiff mat.Float
+	// Mismatches is the number of values outside the tolerance.
+	Mismatches int
+	// FirstMismatch is the index of the first value outside the tolerance, or -1.
+	FirstMismatch int
+	// Err is set if the comparison couldn't be performed (e.g. missing tensor or different shapes).
+	Err error
+}
+
+// OK reports whether the output matches the reference.
+func (r Result) OK() bool {
+	return r.Err == nil && r.Mismatches == 0
+}
+
+// String returns a human-readable description of the result.
+func (r Result) String() string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("%s: %v", r.Name, r.Err)
+	case r.Mismatches > 0:
+		return fmt.Sprintf("%s: %d mismatches (first at index %d), max abs diff %g",
+			r.Name, r.Mismatches, r.FirstMismatch, r.MaxAbsDiff)
+	default:
+		return fmt.Sprintf("%s: ok, max abs diff %g", r.Name, r.MaxAbsDiff)
+	}
+}
+
+// Compare compares each output with the reference tensor with the same name, returning
+// a Result for each output, in the same order.
+func Compare(ref Reference, outputs []Output, tol Tolerance) []Result {
+	results := make([]Result, len(outputs))
+	for i, out := range outputs {
+		results[i] = compare(ref, out, tol)
+	}
+	return results
+}
+
+func compare(ref Reference, out Output, tol Tolerance) Result {
+	result := Result{Name: out.Name, FirstMismatch: -1}
+	t, ok := ref[out.Name]
+	if !ok {
+		result.Err = fmt.Errorf("missing reference tensor")
+		return result
+	}
+	expected, err := t.Matrix()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if !sameShape(expected, out.Value) {
+		result.Err = fmt.Errorf("expected shape %dx%d, actual %dx%d",
+			expected.Rows(), expected.Columns(), out.Value.Rows(), out.Value.Columns())
+		return result
+	}
+	actualData := out.Value.Data()
+	for i, e := range expected.Data() {
+		diff := mat.Abs(actualData[i] - e)
+		if diff > result.MaxAbsDiff || diff != diff {
+			result.MaxAbsDiff = diff
+		}
+		if !(diff <= tol.Abs+tol.Rel*mat.Abs(e)) {
+			if result.FirstMismatch == -1 {
+				result.FirstMismatch = i
+			}
+			result.Mismatches++
+		}
+	}
+	return result
+}
+
+// sameShape reports whether the matrices have the same dimensions, regardless of the
+// orientation of the vectors.
+func sameShape(a, b mat.Matrix) bool {
+	return mat.SameDims(a, b) || mat.VectorsOfSameSize(a, b)
+}
+
+// AssertMatch compares the outputs with the reference tensors, reporting the result of each output
+// and marking the test as failed if any of them doesn't match. The outputs are expected to be given
+// in the order of the computation (e.g. layer by layer), so that the first mismatching output
+// locates the origin of a regression.
+func AssertMatch(t testing.TB, ref Reference, outputs []Output, tol Tolerance) bool {
+	t.Helper()
+	ok := true
+	for _, result := range Compare(ref, outputs, tol) {
+		if result.OK() {
+			t.Log(result)
+			continue
+		}
+		if ok {
+			t.Errorf("golden: first mismatching output %s", result)
+			ok = false
+			continue
+		}
+		t.Error(result)
+	}
+	return ok
+}
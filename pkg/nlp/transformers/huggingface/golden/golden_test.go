@@ -0,0 +1,145 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golden
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadJSON(t *testing.T) {
+	ref, err := Load("testdata/reference.json")
+	require.NoError(t, err)
+	assert.Len(t, ref, 3)
+	assert.Equal(t, []int{1, 2, 3}, ref["hidden_states.0"].Shape)
+
+	m, err := ref["hidden_states.1"].Matrix()
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.Rows())
+	assert.Equal(t, 3, m.Columns())
+	assert.Equal(t, []mat.Float{1.0, -1.0, 0.5, -0.5, 2.0, 0.0}, m.Data())
+
+	m, err = ref["logits"].Matrix()
+	require.NoError(t, err)
+	assert.True(t, m.IsVector())
+}
+
+func TestLoadNPZ(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "reference.npz")
+	writeNPZ(t, filename, map[string][]byte{
+		"a.npy": npy("<f4", "(2, 2)", []float32{1, 2, 3, 4}),
+		"b.npy": npy("<f8", "(3,)", []float64{0.5, -0.5, 1.5}),
+		"c.npy": npy("<i8", "()", []int64{7}),
+	})
+
+	ref, err := Load(filename)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 2}, ref["a"].Shape)
+	assert.Equal(t, []mat.Float{1, 2, 3, 4}, ref["a"].Data)
+	assert.Equal(t, []int{3}, ref["b"].Shape)
+	assert.Equal(t, []mat.Float{0.5, -0.5, 1.5}, ref["b"].Data)
+	assert.Equal(t, []int{}, ref["c"].Shape)
+	assert.Equal(t, []mat.Float{7}, ref["c"].Data)
+}
+
+func TestReadNPY_Errors(t *testing.T) {
+	_, err := ReadNPY(bytes.NewReader([]byte("not a npy file")))
+	assert.Error(t, err)
+	_, err = ReadNPY(bytes.NewReader(npy("<c8", "(1,)", []float64{1})))
+	assert.Error(t, err)
+	_, err = ReadNPY(bytes.NewReader(npy("<f4", "(3,)", []float32{1, 2})))
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	ref, err := Load("testdata/reference.json")
+	require.NoError(t, err)
+
+	g := ag.NewGraph()
+	outputs := []Output{
+		NewOutput("hidden_states.0",
+			g.NewVariable(mat.NewVecDense([]mat.Float{0.1, 0.2, 0.3}), false),
+			g.NewVariable(mat.NewVecDense([]mat.Float{0.4, 0.5, 0.60001}), false),
+		),
+		NewOutput("hidden_states.1",
+			g.NewVariable(mat.NewVecDense([]mat.Float{1.0, -1.0, 0.5}), false),
+			g.NewVariable(mat.NewVecDense([]mat.Float{-0.5, 2.1, 0.1}), false),
+		),
+		NewOutput("logits", g.NewVariable(mat.NewVecDense([]mat.Float{0.75, -0.25}), false)),
+		NewOutput("missing", g.NewScalar(1.0)),
+		{Name: "logits", Value: mat.NewVecDense([]mat.Float{0.75})},
+	}
+
+	results := Compare(ref, outputs, DefaultTolerance)
+	require.Len(t, results, 5)
+
+	assert.True(t, results[0].OK())
+	assert.False(t, results[1].OK())
+	assert.Equal(t, 2, results[1].Mismatches)
+	assert.Equal(t, 4, results[1].FirstMismatch)
+	assert.InDelta(t, 0.1, results[1].MaxAbsDiff, 1e-6)
+	assert.True(t, results[2].OK())
+	assert.Error(t, results[3].Err)
+	assert.Error(t, results[4].Err)
+
+	rec := &recorder{TB: t}
+	assert.False(t, AssertMatch(rec, ref, outputs, DefaultTolerance))
+	require.Len(t, rec.errors, 3)
+	assert.Contains(t, rec.errors[0], "first mismatching output hidden_states.1")
+	assert.True(t, AssertMatch(rec, ref, outputs[:1], DefaultTolerance))
+}
+
+// recorder is a testing.TB collecting the reported errors, without failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper()                 {}
+func (r *recorder) Log(args ...interface{}) {}
+func (r *recorder) Error(args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// npy returns the content of a .npy file (version 1.0) with the given type, shape and data.
+func npy(descr, shape string, data interface{}) []byte {
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }", descr, shape)
+	for (10+len(header)+1)%64 != 0 {
+		header += " "
+	}
+	header += "\n"
+	buf := new(bytes.Buffer)
+	buf.Write([]byte("\x93NUMPY\x01\x00"))
+	_ = binary.Write(buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	_ = binary.Write(buf, binary.LittleEndian, data)
+	return buf.Bytes()
+}
+
+func writeNPZ(t *testing.T, filename string, files map[string][]byte) {
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+}
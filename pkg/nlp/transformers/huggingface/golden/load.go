@@ -0,0 +1,160 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golden
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LoadJSON reads the reference tensors from a JSON file, containing an object that maps
+// each name to an object with the "shape" and the "data" of the tensor.
+func LoadJSON(filename string) (Reference, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ref := Reference{}
+	if err := json.NewDecoder(f).Decode(&ref); err != nil {
+		return nil, fmt.Errorf("golden: error decoding %s: %w", filename, err)
+	}
+	for name, t := range ref {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("%w (%s)", err, name)
+		}
+	}
+	return ref, nil
+}
+
+// LoadNPZ reads the reference tensors from a NumPy .npz archive, as created by numpy.savez.
+// The supported data types are little-endian floats and integers, in C order.
+func LoadNPZ(filename string) (Reference, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	ref := Reference{}
+	for _, file := range r.File {
+		name := strings.TrimSuffix(file.Name, ".npy")
+		t, err := readNPYFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s)", err, name)
+		}
+		ref[name] = t
+	}
+	return ref, nil
+}
+
+func readNPYFile(file *zip.File) (*Tensor, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ReadNPY(rc)
+}
+
+var (
+	npyMagic      = []byte("\x93NUMPY")
+	npyDescr      = regexp.MustCompile(`'descr':\s*'([^']*)'`)
+	npyFortran    = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShape      = regexp.MustCompile(`'shape':\s*\(([^)]*)\)`)
+	npyDataReader = map[string]func(b []byte) float64{
+		"<f4": func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) },
+		"<f8": func(b []byte) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(b)) },
+		"<i4": func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) },
+		"<i8": func(b []byte) float64 { return float64(int64(binary.LittleEndian.Uint64(b))) },
+	}
+)
+
+// ReadNPY reads a tensor in the NumPy .npy format.
+func ReadNPY(r io.Reader) (*Tensor, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 10 || !bytes.Equal(data[:6], npyMagic) {
+		return nil, fmt.Errorf("golden: invalid npy data")
+	}
+	var header string
+	switch major := data[6]; major {
+	case 1:
+		n := int(binary.LittleEndian.Uint16(data[8:10]))
+		if len(data) < 10+n {
+			return nil, fmt.Errorf("golden: invalid npy header")
+		}
+		header, data = string(data[10:10+n]), data[10+n:]
+	case 2, 3:
+		if len(data) < 12 {
+			return nil, fmt.Errorf("golden: invalid npy header")
+		}
+		n := int(binary.LittleEndian.Uint32(data[8:12]))
+		if len(data) < 12+n {
+			return nil, fmt.Errorf("golden: invalid npy header")
+		}
+		header, data = string(data[12:12+n]), data[12+n:]
+	default:
+		return nil, fmt.Errorf("golden: unsupported npy version %d", major)
+	}
+
+	descr := npyDescr.FindStringSubmatch(header)
+	fortran := npyFortran.FindStringSubmatch(header)
+	shapeMatch := npyShape.FindStringSubmatch(header)
+	if descr == nil || fortran == nil || shapeMatch == nil {
+		return nil, fmt.Errorf("golden: invalid npy header %q", header)
+	}
+	read, ok := npyDataReader[descr[1]]
+	if !ok {
+		return nil, fmt.Errorf("golden: unsupported npy data type %q", descr[1])
+	}
+	if fortran[1] == "True" {
+		return nil, fmt.Errorf("golden: unsupported npy fortran order")
+	}
+	shape, err := parseShape(shapeMatch[1])
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tensor{Shape: shape}
+	itemSize, _ := strconv.Atoi(descr[1][2:])
+	t.Data = make([]mat.Float, len(data)/itemSize)
+	for i := range t.Data {
+		t.Data[i] = mat.Float(read(data[i*itemSize:]))
+	}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseShape parses the content of a Python tuple of integers, such as "2, 3" or "4,".
+func parseShape(s string) ([]int, error) {
+	shape := make([]int, 0)
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		dim, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("golden: invalid npy shape %q", s)
+		}
+		shape = append(shape, dim)
+	}
+	return shape, nil
+}
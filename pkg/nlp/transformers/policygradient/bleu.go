@@ -0,0 +1,49 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policygradient
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+// SentenceBLEU returns the BLEU score of the candidate tokens against the reference tokens, up to
+// the maxN-grams: the geometric mean of the precisions of the n-grams, clipped to the reference
+// counts, times the brevity penalty. The precisions of the n-grams longer than 1 are smoothed by
+// adding one to their counts, so that a single sentence with no matching 4-grams doesn't score 0.
+//
+// Reference: "BLEU: a Method for Automatic Evaluation of Machine Translation" by Papineni et al.,
+// 2002 (https://aclanthology.org/P02-1040); "ORANGE: a Method for Evaluating Automatic
+// Evaluation Metrics for Machine Translation" by Lin and Och, 2004
+// (https://aclanthology.org/C04-1072).
+func SentenceBLEU(candidate, reference []string, maxN int) mat.Float {
+	if len(candidate) == 0 || len(reference) == 0 {
+		return 0
+	}
+	var logPrecisions mat.Float
+	for n := 1; n <= maxN; n++ {
+		referenceNGrams := countNGrams(reference, n)
+		var matches, total int
+		for ngram, count := range countNGrams(candidate, n) {
+			total += count
+			if refCount := referenceNGrams[ngram]; refCount < count {
+				matches += refCount
+			} else {
+				matches += count
+			}
+		}
+		if n == 1 && matches == 0 {
+			return 0
+		}
+		if n > 1 {
+			matches, total = matches+1, total+1
+		}
+		logPrecisions += mat.Log(mat.Float(matches) / mat.Float(total))
+	}
+	brevityPenalty := mat.Float(1)
+	if len(candidate) < len(reference) {
+		brevityPenalty = mat.Exp(1 - mat.Float(len(reference))/mat.Float(len(candidate)))
+	}
+	return brevityPenalty * mat.Exp(logPrecisions/mat.Float(maxN))
+}
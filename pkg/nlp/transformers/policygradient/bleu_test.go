@@ -0,0 +1,32 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policygradient
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSentenceBLEU(t *testing.T) {
+	reference := strings.Fields("the cat is on the mat")
+	assert.InDelta(t, 1.0, SentenceBLEU(reference, reference, 4), 1.0e-6)
+
+	// unigrams 5/6, bigrams (3+1)/(5+1), same length
+	candidate := strings.Fields("the cat is on a mat")
+	expected := mat.Sqrt(5.0 / 6.0 * 4.0 / 6.0)
+	assert.InDelta(t, expected, SentenceBLEU(candidate, reference, 2), 1.0e-6)
+
+	// the brevity penalty of a shorter candidate: unigrams 3/3, bigrams (2+1)/(2+1)
+	short := strings.Fields("the cat is")
+	assert.InDelta(t, mat.Exp(1-6.0/3.0), SentenceBLEU(short, reference, 2), 1.0e-6)
+
+	// no matching 4-grams, yet a positive score
+	assert.Greater(t, SentenceBLEU(strings.Fields("the cat sat on the mat"), reference, 4), mat.Float(0))
+
+	assert.Equal(t, mat.Float(0), SentenceBLEU(strings.Fields("a dog"), reference, 4))
+	assert.Equal(t, mat.Float(0), SentenceBLEU(nil, reference, 4))
+}
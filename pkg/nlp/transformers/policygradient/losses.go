@@ -0,0 +1,125 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policygradient
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+)
+
+// Reinforce returns the REINFORCE loss of the samples, averaged over the samples: the negative
+// log-probability of each sequence, weighted by its advantage, that is how much its reward is
+// better than expected (see Advantages).
+func Reinforce(g *ag.Graph, samples []*Sample, advantages []mat.Float) ag.Node {
+	if len(samples) != len(advantages) {
+		panic("policygradient: the number of samples and advantages must be the same")
+	}
+	terms := make([]ag.Node, 0, len(samples))
+	for i, sample := range samples {
+		if len(sample.LogProbs) == 0 {
+			continue
+		}
+		logProb := g.Sum(sample.LogProbs...)
+		terms = append(terms, g.ProdScalar(logProb, g.Constant(advantages[i])))
+	}
+	if len(terms) == 0 {
+		return g.Constant(0)
+	}
+	return g.Neg(g.DivScalar(g.Sum(terms...), g.Constant(mat.Float(len(samples)))))
+}
+
+// PPO returns the clipped surrogate loss of Proximal Policy Optimization, averaged over the
+// tokens of the samples. The LogProbs of the samples are computed by the current policy (see
+// LogProbs), while oldLogProbs are the values of the log-probabilities of the policy that
+// generated the samples. The ratio of the probabilities of each token is clipped to
+// [1-epsilon, 1+epsilon], so that a step doesn't move the policy too far from the one that
+// generated the samples; the advantage of a sequence is shared by all its tokens.
+func PPO(g *ag.Graph, samples []*Sample, oldLogProbs [][]mat.Float, advantages []mat.Float, epsilon mat.Float) ag.Node {
+	if len(samples) != len(advantages) || len(samples) != len(oldLogProbs) {
+		panic("policygradient: the number of samples, old log-probabilities and advantages must be the same")
+	}
+	var terms []ag.Node
+	for i, sample := range samples {
+		advantage := g.Constant(advantages[i])
+		for t, logProb := range sample.LogProbs {
+			ratio := g.Exp(g.SubScalar(logProb, g.Constant(oldLogProbs[i][t])))
+			// min(ratio * A, clip(ratio, 1-epsilon, 1+epsilon) * A) only clips one side, given the
+			// sign of the advantage
+			var clipped ag.Node
+			if advantages[i] >= 0 {
+				clipped = g.Min(ratio, g.Constant(1+epsilon))
+			} else {
+				clipped = g.Max(ratio, g.Constant(1-epsilon))
+			}
+			terms = append(terms, g.ProdScalar(clipped, advantage))
+		}
+	}
+	if len(terms) == 0 {
+		return g.Constant(0)
+	}
+	return g.Neg(g.DivScalar(g.Sum(terms...), g.Constant(mat.Float(len(terms)))))
+}
+
+// Values returns the values of the log-probabilities of the samples, e.g. the oldLogProbs of PPO.
+func Values(samples []*Sample) [][]mat.Float {
+	out := make([][]mat.Float, len(samples))
+	for i, sample := range samples {
+		out[i] = make([]mat.Float, len(sample.LogProbs))
+		for t, logProb := range sample.LogProbs {
+			out[i][t] = logProb.ScalarValue()
+		}
+	}
+	return out
+}
+
+// Advantages returns the advantage of each sample: its reward minus the baseline, e.g. the
+// reward of the greedy decoding of the same input (self-critical) or a moving average of the
+// rewards (see MovingBaseline).
+func Advantages(samples []*Sample, baseline mat.Float) []mat.Float {
+	advantages := make([]mat.Float, len(samples))
+	for i, sample := range samples {
+		advantages[i] = sample.Reward - baseline
+	}
+	return advantages
+}
+
+// LeaveOneOutAdvantages returns the advantage of each sample, whose baseline is the mean reward
+// of the other samples of the same input, which needs no additional generation. It panics with
+// less than two samples.
+func LeaveOneOutAdvantages(samples []*Sample) []mat.Float {
+	if len(samples) < 2 {
+		panic("policygradient: the leave-one-out baseline needs at least two samples")
+	}
+	var sum mat.Float
+	for _, sample := range samples {
+		sum += sample.Reward
+	}
+	advantages := make([]mat.Float, len(samples))
+	for i, sample := range samples {
+		advantages[i] = sample.Reward - (sum-sample.Reward)/mat.Float(len(samples)-1)
+	}
+	return advantages
+}
+
+// MovingBaseline is the exponential moving average of the rewards.
+type MovingBaseline struct {
+	// Decay is the weight of the previous average, in [0, 1).
+	Decay mat.Float
+	// Value is the current average, initialized to the first reward.
+	Value mat.Float
+	// initialized reports whether Value has been set.
+	initialized bool
+}
+
+// Update adds the reward to the average, and returns the average before the update, which is
+// the baseline of the reward.
+func (b *MovingBaseline) Update(reward mat.Float) mat.Float {
+	if !b.initialized {
+		b.Value, b.initialized = reward, true
+	}
+	baseline := b.Value
+	b.Value = b.Decay*b.Value + (1-b.Decay)*reward
+	return baseline
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policygradient
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestSamples(g *ag.Graph, logProbs ...[]mat.Float) []*Sample {
+	samples := make([]*Sample, len(logProbs))
+	for i, values := range logProbs {
+		samples[i] = &Sample{}
+		for _, v := range values {
+			samples[i].LogProbs = append(samples[i].LogProbs, g.NewVariable(mat.NewScalar(v), true))
+		}
+	}
+	return samples
+}
+
+func TestReinforce(t *testing.T) {
+	g := ag.NewGraph()
+	samples := newTestSamples(g, []mat.Float{-1, -2}, []mat.Float{-0.5})
+	loss := Reinforce(g, samples, []mat.Float{2, -1})
+
+	// -((-3 * 2) + (-0.5 * -1)) / 2
+	assert.InDelta(t, 2.75, loss.ScalarValue(), 1.0e-6)
+
+	g.Backward(loss)
+	assert.InDelta(t, -1.0, samples[0].LogProbs[0].Grad().Scalar(), 1.0e-6)
+	assert.InDelta(t, -1.0, samples[0].LogProbs[1].Grad().Scalar(), 1.0e-6)
+	assert.InDelta(t, 0.5, samples[1].LogProbs[0].Grad().Scalar(), 1.0e-6)
+
+	assert.Equal(t, mat.Float(0), Reinforce(g, []*Sample{{}}, []mat.Float{1}).ScalarValue())
+	assert.Panics(t, func() { Reinforce(g, samples, []mat.Float{1}) })
+}
+
+func TestPPO(t *testing.T) {
+	g := ag.NewGraph()
+	samples := newTestSamples(g, []mat.Float{-1, -2}, []mat.Float{-1})
+	oldLogProbs := Values(samples)
+	assert.Equal(t, [][]mat.Float{{-1, -2}, {-1}}, oldLogProbs)
+
+	// the ratios are 1 for the policy that generated the samples: the loss is minus the mean
+	// advantage of the tokens
+	loss := PPO(g, samples, oldLogProbs, []mat.Float{1, -2}, 0.2)
+	assert.InDelta(t, 0.0, loss.ScalarValue(), 1.0e-6)
+	g.Backward(loss)
+	assert.InDelta(t, -1.0/3.0, samples[0].LogProbs[0].Grad().Scalar(), 1.0e-6)
+	assert.InDelta(t, 2.0/3.0, samples[1].LogProbs[0].Grad().Scalar(), 1.0e-6)
+}
+
+func TestPPOClipping(t *testing.T) {
+	g := ag.NewGraph()
+	// ratios e^0.5 > 1.2 and e^-0.5 < 0.8
+	samples := newTestSamples(g, []mat.Float{-0.5}, []mat.Float{-1.5})
+	oldLogProbs := [][]mat.Float{{-1}, {-1}}
+
+	// the ratios moved too far in the direction of the advantages: both are clipped, and
+	// there is no gradient
+	loss := PPO(g, samples, oldLogProbs, []mat.Float{1, -1}, 0.2)
+	assert.InDelta(t, -(1.2-0.8)/2, loss.ScalarValue(), 1.0e-6)
+	g.Backward(loss)
+	assert.Equal(t, mat.Float(0), samples[0].LogProbs[0].Grad().Scalar())
+	assert.Equal(t, mat.Float(0), samples[1].LogProbs[0].Grad().Scalar())
+
+	// the ratios moved in the opposite direction of the advantages: none is clipped
+	g.ZeroGrad()
+	loss = PPO(g, samples, oldLogProbs, []mat.Float{-1, 1}, 0.2)
+	g.Backward(loss)
+	assert.InDelta(t, mat.Exp(0.5)/2, samples[0].LogProbs[0].Grad().Scalar(), 1.0e-6)
+	assert.InDelta(t, -mat.Exp(-0.5)/2, samples[1].LogProbs[0].Grad().Scalar(), 1.0e-6)
+}
+
+func TestAdvantages(t *testing.T) {
+	samples := []*Sample{{Reward: 1}, {Reward: 2}, {Reward: 6}}
+	assert.Equal(t, []mat.Float{-1, 0, 4}, Advantages(samples, 2))
+	assert.Equal(t, []mat.Float{-3, -1.5, 4.5}, LeaveOneOutAdvantages(samples))
+	assert.Panics(t, func() { LeaveOneOutAdvantages(samples[:1]) })
+}
+
+func TestMovingBaseline(t *testing.T) {
+	b := MovingBaseline{Decay: 0.5}
+	assert.Equal(t, mat.Float(2), b.Update(2))
+	assert.Equal(t, mat.Float(2), b.Update(4))
+	assert.Equal(t, mat.Float(3), b.Update(1))
+	assert.Equal(t, mat.Float(2), b.Value)
+}
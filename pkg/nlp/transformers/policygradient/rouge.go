@@ -0,0 +1,78 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policygradient
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"strings"
+)
+
+// RougeN returns the ROUGE-N F1 score of the candidate tokens against the reference tokens: the
+// harmonic mean of the precision and the recall of their n-grams, counted with repetitions.
+//
+// Reference: "ROUGE: A Package for Automatic Evaluation of Summaries" by Lin, 2004
+// (https://aclanthology.org/W04-1013).
+func RougeN(candidate, reference []string, n int) mat.Float {
+	candidateNGrams := countNGrams(candidate, n)
+	referenceNGrams := countNGrams(reference, n)
+	var overlap, candidateTotal, referenceTotal int
+	for ngram, count := range candidateNGrams {
+		candidateTotal += count
+		if refCount := referenceNGrams[ngram]; refCount < count {
+			overlap += refCount
+		} else {
+			overlap += count
+		}
+	}
+	for _, count := range referenceNGrams {
+		referenceTotal += count
+	}
+	return f1(overlap, candidateTotal, referenceTotal)
+}
+
+// RougeL returns the ROUGE-L F1 score of the candidate tokens against the reference tokens,
+// based on their longest common subsequence.
+func RougeL(candidate, reference []string) mat.Float {
+	return f1(lcs(candidate, reference), len(candidate), len(reference))
+}
+
+// countNGrams returns the occurrences of each n-gram of the tokens.
+func countNGrams(tokens []string, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i+n <= len(tokens); i++ {
+		counts[strings.Join(tokens[i:i+n], "\x00")]++
+	}
+	return counts
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// f1 returns the F1 score of the overlap of a candidate and a reference of the given sizes.
+func f1(overlap, candidateSize, referenceSize int) mat.Float {
+	if overlap == 0 {
+		return 0
+	}
+	precision := mat.Float(overlap) / mat.Float(candidateSize)
+	recall := mat.Float(overlap) / mat.Float(referenceSize)
+	return 2 * precision * recall / (precision + recall)
+}
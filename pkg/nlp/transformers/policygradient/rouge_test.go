@@ -0,0 +1,41 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policygradient
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestRougeN(t *testing.T) {
+	candidate := strings.Fields("the cat was found under the bed")
+	reference := strings.Fields("the cat was under the bed")
+
+	// unigrams: 6 of 7 candidate tokens, 6 of 6 reference tokens
+	assert.InDelta(t, 2*(6.0/7.0)/(6.0/7.0+1), RougeN(candidate, reference, 1), 1.0e-6)
+	// bigrams: 4 of 6 candidate bigrams, 4 of 5 reference bigrams
+	assert.InDelta(t, 2*(4.0/6.0)*(4.0/5.0)/(4.0/6.0+4.0/5.0), RougeN(candidate, reference, 2), 1.0e-6)
+
+	// repeated n-grams are clipped to the reference counts
+	assert.InDelta(t, 2*(1.0/3.0)/(1.0/3.0+1), RougeN(strings.Fields("the the the"), []string{"the"}, 1), 1.0e-6)
+
+	assert.Equal(t, mat.Float(0), RougeN([]string{"a"}, []string{"b"}, 1))
+	assert.Equal(t, mat.Float(0), RougeN(nil, reference, 1))
+	assert.Equal(t, mat.Float(1), RougeN(reference, reference, 2))
+}
+
+func TestRougeL(t *testing.T) {
+	candidate := strings.Fields("police killed the gunman")
+	reference := strings.Fields("police kill the gunman")
+	assert.InDelta(t, 0.75, RougeL(candidate, reference), 1.0e-6)
+
+	// the subsequence needs not to be contiguous
+	assert.InDelta(t, 2*(3.0/5.0)*(3.0/3.0)/(3.0/5.0+1), RougeL(strings.Fields("a x b y c"), strings.Fields("a b c")), 1.0e-6)
+
+	assert.Equal(t, mat.Float(0), RougeL(nil, nil))
+	assert.Equal(t, 3, lcs(strings.Fields("a b c d e"), strings.Fields("b d x e a")))
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package policygradient fine-tunes the encoder-decoder generators (e.g. the BART conditional
+// generation model) by reinforcement learning, so that they maximize a reward of the whole
+// generated sequence, such as the ROUGE score of a summary or the feedback of the users, rather
+// than the likelihood of the reference tokens.
+//
+// The generator is the policy: the sequences are sampled token by token from its distribution,
+// keeping the log-probability of each sampled token in the graph (Sample), and scored by the
+// reward. The REINFORCE loss raises the log-probability of the sequences with a reward above a
+// baseline, and lowers the others; the PPO loss does the same for several optimization steps on
+// the same samples, clipping the change of the probabilities (see Reinforce and PPO). The
+// Trainer puts it all together.
+//
+// Reference: "Simple Statistical Gradient-Following Algorithms for Connectionist Reinforcement
+// Learning" by Williams, 1992; "Self-critical Sequence Training for Image Captioning" by Rennie
+// et al., 2017 (https://arxiv.org/abs/1612.00563); "Proximal Policy Optimization Algorithms" by
+// Schulman et al., 2017 (https://arxiv.org/abs/1707.06347).
+package policygradient
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+)
+
+// SamplingConfig provides configuration settings for the sampling of the sequences.
+type SamplingConfig struct {
+	// DecoderStartTokenID is the first input of the decoder, which is not part of the sequence.
+	DecoderStartTokenID int
+	// EOSTokenID ends the sequence; it is part of the sequence.
+	EOSTokenID int
+	// MaxLength is the maximum number of tokens of a sequence.
+	MaxLength int
+	// Temperature divides the logits before the sampling; zero selects the most likely token
+	// (greedy decoding).
+	Temperature mat.Float
+	// TopK, if not zero, samples among the TopK most likely tokens only.
+	TopK int
+}
+
+// Sample is a sequence generated by the policy.
+type Sample struct {
+	// TokenIDs are the generated tokens, without the decoder start token.
+	TokenIDs []int
+	// LogProbs are the log-probabilities of the tokens under the policy, in the graph of the
+	// policy (see LogProbs).
+	LogProbs []ag.Node
+	// Reward is the reward of the sequence.
+	Reward mat.Float
+}
+
+// SampleSequence generates a sequence from the decoder, given the encoded input, sampling each
+// token from the distribution of the decoder by the generator. The log-probabilities of the
+// tokens are those of the distribution of the decoder, not affected by the temperature and the
+// top-k filtering.
+func SampleSequence(
+	g *ag.Graph,
+	decoder generation.Decoder,
+	encoded []ag.Node,
+	config SamplingConfig,
+	generator *rand.LockedRand,
+) *Sample {
+	sample := &Sample{}
+	inputIDs := []int{config.DecoderStartTokenID}
+	var cache generation.Cache
+	for len(sample.TokenIDs) < config.MaxLength {
+		var logits ag.Node
+		logits, cache = decoder.Decode(encoded, inputIDs, cache)
+		logProbs := g.LogSoftmax(logits)
+		id := chooseToken(logits.Value().Data(), config, generator)
+		sample.TokenIDs = append(sample.TokenIDs, id)
+		sample.LogProbs = append(sample.LogProbs, g.AtVec(logProbs, id))
+		if id == config.EOSTokenID {
+			break
+		}
+		inputIDs = append(inputIDs, id)
+	}
+	return sample
+}
+
+// chooseToken samples a token from the logits, or selects the most likely one with a zero
+// temperature.
+func chooseToken(logits []mat.Float, config SamplingConfig, generator *rand.LockedRand) int {
+	if config.Temperature == 0 {
+		return floatutils.ArgMax(logits)
+	}
+	scaled := make([]mat.Float, len(logits))
+	for i, logit := range logits {
+		scaled[i] = logit / config.Temperature
+	}
+	if config.TopK > 0 && config.TopK < len(scaled) {
+		threshold := scaled[floatutils.ArgSort(scaled, true)[config.TopK-1]]
+		for i, v := range scaled {
+			if v < threshold {
+				scaled[i] = mat.Inf(-1)
+			}
+		}
+	}
+	probs := floatutils.SoftMax(scaled)
+	u := generator.Float()
+	var cumulative mat.Float
+	for i, p := range probs {
+		cumulative += p
+		if u < cumulative {
+			return i
+		}
+	}
+	return floatutils.ArgMax(probs) // rounding errors
+}
+
+// LogProbs returns the log-probabilities of the given tokens under the decoder, given the
+// encoded input, as the decoder generates them one at a time (teacher forcing). They are the
+// LogProbs of a Sample of the tokens, computed again, e.g. after an update of the policy.
+func LogProbs(g *ag.Graph, decoder generation.Decoder, encoded []ag.Node, decoderStartTokenID int, tokenIDs []int) []ag.Node {
+	out := make([]ag.Node, len(tokenIDs))
+	inputIDs := []int{decoderStartTokenID}
+	var cache generation.Cache
+	for i, id := range tokenIDs {
+		var logits ag.Node
+		logits, cache = decoder.Decode(encoded, inputIDs, cache)
+		out[i] = g.AtVec(g.LogSoftmax(logits), id)
+		inputIDs = append(inputIDs, id)
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policygradient
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	testStartID   = 0
+	testEOSID     = 1
+	testVocabSize = 4
+)
+
+// testModel is a bigram generator: the logits of the next token depend on the last one only.
+type testModel struct {
+	nn.BaseModel
+	W nn.Param `spago:"type:weights"`
+}
+
+var _ generation.EncoderDecoder = &testModel{}
+
+func newTestModel() *testModel {
+	m := &testModel{W: nn.NewParam(mat.NewEmptyDense(testVocabSize, testVocabSize))}
+	initializers.Uniform(m.W.Value(), -0.1, 0.1, rand.NewLockedRand(42))
+	return m
+}
+
+func (m *testModel) Encode(inputIDs []int) []ag.Node {
+	return []ag.Node{m.Graph().NewScalar(mat.Float(len(inputIDs)))}
+}
+
+func (m *testModel) Decode(_ []ag.Node, inputIDs []int, pastCache generation.Cache) (ag.Node, generation.Cache) {
+	steps, _ := pastCache.(int)
+	oneHot := mat.NewEmptyVecDense(testVocabSize)
+	oneHot.SetVec(inputIDs[len(inputIDs)-1], 1)
+	return m.Graph().Mul(m.W, m.Graph().NewVariable(oneHot, false)), steps + 1
+}
+
+var testSampling = SamplingConfig{
+	DecoderStartTokenID: testStartID,
+	EOSTokenID:          testEOSID,
+	MaxLength:           4,
+	Temperature:         1,
+}
+
+// testReward is the fraction of the tokens equal to 2.
+func testReward(tokenIDs []int) mat.Float {
+	var n mat.Float
+	for _, id := range tokenIDs {
+		if id == 2 {
+			n++
+		}
+	}
+	return n / mat.Float(len(tokenIDs))
+}
+
+func TestSampleSequence(t *testing.T) {
+	m := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*testModel)
+	generator := rand.NewLockedRand(1)
+	for i := 0; i < 10; i++ {
+		sample := SampleSequence(g, proc, proc.Encode([]int{5}), testSampling, generator)
+		assert.True(t, len(sample.TokenIDs) >= 1 && len(sample.TokenIDs) <= 4)
+		assert.Len(t, sample.LogProbs, len(sample.TokenIDs))
+		for j, id := range sample.TokenIDs {
+			assert.True(t, id < testVocabSize)
+			assert.True(t, id != testEOSID || j == len(sample.TokenIDs)-1)
+		}
+
+		// the same log-probabilities by teacher forcing
+		logProbs := LogProbs(g, proc, nil, testStartID, sample.TokenIDs)
+		for j, logProb := range logProbs {
+			assert.InDelta(t, sample.LogProbs[j].ScalarValue(), logProb.ScalarValue(), 1.0e-6)
+		}
+	}
+
+	// greedy decoding
+	m.W.Value().SetData(make([]mat.Float, testVocabSize*testVocabSize))
+	m.W.Value().Set(3, testStartID, 1)
+	m.W.Value().Set(testEOSID, 3, 1)
+	greedy := testSampling
+	greedy.Temperature = 0
+	assert.Equal(t, []int{3, testEOSID}, SampleSequence(g, proc, nil, greedy, nil).TokenIDs)
+
+	// top-1 sampling is greedy
+	top1 := testSampling
+	top1.TopK = 1
+	assert.Equal(t, []int{3, testEOSID}, SampleSequence(g, proc, nil, top1, generator).TokenIDs)
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policygradient
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/gdmbuilder"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+	"runtime"
+)
+
+// Method is the loss optimized by a Trainer.
+type Method int
+
+const (
+	// MethodReinforce optimizes the Reinforce loss, with a single step for each input.
+	MethodReinforce Method = iota
+	// MethodPPO optimizes the PPO loss, with TrainingConfig.PPOEpochs steps for each input.
+	MethodPPO
+)
+
+// Baseline is the baseline of the rewards of a Trainer (see Advantages).
+type Baseline int
+
+const (
+	// LeaveOneOut uses the mean reward of the other samples of the same input (see
+	// LeaveOneOutAdvantages); it needs at least two samples.
+	LeaveOneOut Baseline = iota
+	// SelfCritical uses the reward of the greedy decoding of the same input.
+	SelfCritical
+	// Moving uses the moving average of the rewards (see MovingBaseline).
+	Moving
+)
+
+// TrainingConfig provides configuration settings for a policy gradient Trainer.
+type TrainingConfig struct {
+	Seed uint64
+	// Sampling configures the generation of the samples.
+	Sampling SamplingConfig
+	// NumSamples is the number of sequences sampled for each input.
+	NumSamples int
+	Method     Method
+	Baseline   Baseline
+	// BaselineDecay is the decay of the Moving baseline.
+	BaselineDecay mat.Float
+	// PPOEpochs is the number of optimization steps on the samples of an input, and PPOEpsilon
+	// the clipping of the ratios of the probabilities, with MethodPPO.
+	PPOEpochs        int
+	PPOEpsilon       mat.Float
+	GradientClipping mat.Float
+	UpdateMethod     gd.MethodConfig
+}
+
+// Trainer fine-tunes an encoder-decoder generator by policy gradient.
+type Trainer struct {
+	TrainingConfig
+	randGen   *rand.LockedRand
+	optimizer *gd.GradientDescent
+	model     nn.Model
+	baseline  MovingBaseline
+}
+
+// NewTrainer returns a new Trainer of the model, which must be a generation.EncoderDecoder
+// (e.g. the BART conditional generation model).
+func NewTrainer(model nn.Model, config TrainingConfig) *Trainer {
+	if _, ok := model.(generation.EncoderDecoder); !ok {
+		panic("policygradient: the model must be an encoder-decoder")
+	}
+	if config.NumSamples < 1 || (config.Baseline == LeaveOneOut && config.NumSamples < 2) {
+		panic("policygradient: not enough samples for each input")
+	}
+	optimizer := gd.NewOptimizer(gdmbuilder.NewMethod(config.UpdateMethod), nn.NewDefaultParamsIterator(model))
+	if config.GradientClipping != 0.0 {
+		gd.ClipGradByNorm(config.GradientClipping, 2.0)(optimizer)
+	}
+	return &Trainer{
+		TrainingConfig: config,
+		randGen:        rand.NewLockedRand(config.Seed),
+		optimizer:      optimizer,
+		model:          model,
+		baseline:       MovingBaseline{Decay: config.BaselineDecay},
+	}
+}
+
+// Step samples the sequences of the input, scores them by the reward function, and optimizes
+// the policy. It returns the mean reward of the samples.
+func (t *Trainer) Step(inputIDs []int, reward func(tokenIDs []int) mat.Float) mat.Float {
+	g := t.newGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, t.model).(generation.EncoderDecoder)
+	encoded := proc.Encode(inputIDs)
+
+	samples := make([]*Sample, t.NumSamples)
+	var meanReward mat.Float
+	for i := range samples {
+		samples[i] = SampleSequence(g, proc, encoded, t.Sampling, t.randGen)
+		samples[i].Reward = reward(samples[i].TokenIDs)
+		meanReward += samples[i].Reward / mat.Float(len(samples))
+	}
+	advantages := t.advantages(inputIDs, samples, meanReward, reward)
+
+	switch t.Method {
+	case MethodReinforce:
+		t.optimize(g, Reinforce(g, samples, advantages))
+	case MethodPPO:
+		oldLogProbs := Values(samples)
+		t.optimize(g, PPO(g, samples, oldLogProbs, advantages, t.PPOEpsilon))
+		for epoch := 1; epoch < t.PPOEpochs; epoch++ {
+			t.ppoStep(inputIDs, samples, oldLogProbs, advantages)
+		}
+	default:
+		panic("policygradient: invalid method")
+	}
+	return meanReward
+}
+
+// advantages returns the advantages of the samples by the Baseline.
+func (t *Trainer) advantages(inputIDs []int, samples []*Sample, meanReward mat.Float, reward func([]int) mat.Float) []mat.Float {
+	switch t.Baseline {
+	case LeaveOneOut:
+		return LeaveOneOutAdvantages(samples)
+	case SelfCritical:
+		return Advantages(samples, reward(t.greedy(inputIDs)))
+	case Moving:
+		return Advantages(samples, t.baseline.Update(meanReward))
+	default:
+		panic("policygradient: invalid baseline")
+	}
+}
+
+// greedy returns the greedy decoding of the input, in Inference mode.
+func (t *Trainer) greedy(inputIDs []int) []int {
+	g := t.newGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, t.model).(generation.EncoderDecoder)
+	config := t.Sampling
+	config.Temperature = 0
+	return SampleSequence(g, proc, proc.Encode(inputIDs), config, nil).TokenIDs
+}
+
+// ppoStep performs a further optimization step on the samples, with their log-probabilities
+// under the updated policy.
+func (t *Trainer) ppoStep(inputIDs []int, samples []*Sample, oldLogProbs [][]mat.Float, advantages []mat.Float) {
+	g := t.newGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, t.model).(generation.EncoderDecoder)
+	encoded := proc.Encode(inputIDs)
+	updated := make([]*Sample, len(samples))
+	for i, sample := range samples {
+		updated[i] = &Sample{
+			TokenIDs: sample.TokenIDs,
+			LogProbs: LogProbs(g, proc, encoded, t.Sampling.DecoderStartTokenID, sample.TokenIDs),
+			Reward:   sample.Reward,
+		}
+	}
+	t.optimize(g, PPO(g, updated, oldLogProbs, advantages, t.PPOEpsilon))
+}
+
+func (t *Trainer) optimize(g *ag.Graph, loss ag.Node) {
+	g.Backward(loss)
+	t.optimizer.IncExample()
+	t.optimizer.Optimize()
+}
+
+func (t *Trainer) newGraph() *ag.Graph {
+	return ag.NewGraph(ag.Rand(t.randGen), ag.ConcurrentComputations(runtime.NumCPU()))
+}
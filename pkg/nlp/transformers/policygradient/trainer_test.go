@@ -0,0 +1,51 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package policygradient
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTrainer(t *testing.T) {
+	tests := []struct {
+		method   Method
+		baseline Baseline
+	}{
+		{MethodReinforce, LeaveOneOut},
+		{MethodReinforce, SelfCritical},
+		{MethodReinforce, Moving},
+		{MethodPPO, LeaveOneOut},
+	}
+	for _, test := range tests {
+		m := newTestModel()
+		trainer := NewTrainer(m, TrainingConfig{
+			Seed:          1,
+			Sampling:      testSampling,
+			NumSamples:    4,
+			Method:        test.method,
+			Baseline:      test.baseline,
+			BaselineDecay: 0.9,
+			PPOEpochs:     3,
+			PPOEpsilon:    0.2,
+			UpdateMethod:  sgd.NewConfig(0.5, 0, false),
+		})
+		var first, last mat.Float
+		for step := 0; step < 50; step++ {
+			reward := trainer.Step([]int{5}, testReward)
+			if step < 10 {
+				first += reward
+			}
+			if step >= 40 {
+				last += reward
+			}
+		}
+		assert.Greater(t, last, first, test)
+	}
+
+	assert.Panics(t, func() { NewTrainer(newTestModel(), TrainingConfig{NumSamples: 1, Baseline: LeaveOneOut}) })
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq2seq
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/policygradient"
+	"strconv"
+)
+
+// Metric scores a hypothesis against the reference, the higher the better, usually in [0, 1].
+type Metric func(hypothesis, reference []int) mat.Float
+
+// TokenMetric adapts a metric of string tokens, such as policygradient.SentenceBLEU or
+// policygradient.RougeL, to a Metric of the token IDs.
+func TokenMetric(metric func(candidate, reference []string) mat.Float) Metric {
+	return func(hypothesis, reference []int) mat.Float {
+		return metric(idsToStrings(hypothesis), idsToStrings(reference))
+	}
+}
+
+// BLEU returns the Metric of the sentence BLEU score of the token IDs, up to the 4-grams.
+func BLEU() Metric {
+	return TokenMetric(func(candidate, reference []string) mat.Float {
+		return policygradient.SentenceBLEU(candidate, reference, 4)
+	})
+}
+
+// RougeL returns the Metric of the ROUGE-L score of the token IDs.
+func RougeL() Metric {
+	return TokenMetric(policygradient.RougeL)
+}
+
+func idsToStrings(ids []int) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = strconv.Itoa(id)
+	}
+	return out
+}
+
+// MinimumRiskConfig provides configuration settings for the minimum risk training.
+type MinimumRiskConfig struct {
+	// Sampling configures the generation of the hypotheses.
+	Sampling policygradient.SamplingConfig
+	// NumSamples is the number of hypotheses sampled for each example; the duplicates are
+	// considered once.
+	NumSamples int
+	// Alpha is the sharpness of the distribution of the hypotheses, which scales their
+	// log-probabilities.
+	Alpha mat.Float
+	// IncludeReference adds the reference to the hypotheses.
+	IncludeReference bool
+	// Metric scores the hypotheses against the reference; the risk of a hypothesis is 1 - Metric.
+	Metric Metric
+	// Weight is the weight of the risk in the loss of a Trainer, the cross-entropy having weight
+	// 1 - Weight; 1 is the plain minimum risk training.
+	Weight mat.Float
+}
+
+// Validate returns an error if the configuration is not valid.
+func (c MinimumRiskConfig) Validate() error {
+	switch {
+	case c.NumSamples < 1:
+		return fmt.Errorf("seq2seq: the number of samples must be positive, got %d", c.NumSamples)
+	case c.Alpha <= 0:
+		return fmt.Errorf("seq2seq: alpha must be positive, got %g", c.Alpha)
+	case c.Metric == nil:
+		return fmt.Errorf("seq2seq: the metric is missing")
+	case c.Weight < 0 || c.Weight > 1:
+		return fmt.Errorf("seq2seq: the weight must be in [0, 1], got %g", c.Weight)
+	default:
+		return nil
+	}
+}
+
+// MinimumRisk returns the expected risk of the hypotheses of the example, sampled from the model:
+// the risk of each hypothesis (one minus its Metric score) weighted by its probability,
+// renormalized over the hypotheses and sharpened by Alpha. Minimizing it moves the probability
+// towards the hypotheses that score better at the sequence level, e.g. by BLEU or ROUGE, rather
+// than towards the reference tokens only.
+//
+// Reference: "Minimum Risk Training for Neural Machine Translation" by Shen et al., 2016
+// (https://arxiv.org/abs/1512.02433); "Classical Structured Prediction Losses for Sequence to
+// Sequence Learning" by Edunov et al., 2018 (https://arxiv.org/abs/1711.04956).
+func MinimumRisk(
+	g *ag.Graph,
+	model generation.EncoderDecoder,
+	example Example,
+	config MinimumRiskConfig,
+	generator *rand.LockedRand,
+) ag.Node {
+	encoded := model.Encode(example.InputIDs)
+	return minimumRisk(g, model, encoded, example.TargetIDs, config, generator)
+}
+
+func minimumRisk(
+	g *ag.Graph,
+	decoder generation.Decoder,
+	encoded []ag.Node,
+	targetIDs []int,
+	config MinimumRiskConfig,
+	generator *rand.LockedRand,
+) ag.Node {
+	seen := make(map[string]bool)
+	var scores []ag.Node
+	var risks []mat.Float
+	add := func(tokenIDs []int, logProbs []ag.Node) {
+		key := fmt.Sprint(tokenIDs)
+		if seen[key] || len(logProbs) == 0 {
+			return
+		}
+		seen[key] = true
+		scores = append(scores, g.ProdScalar(g.Sum(logProbs...), g.Constant(config.Alpha)))
+		risks = append(risks, 1-config.Metric(tokenIDs, targetIDs))
+	}
+	if config.IncludeReference {
+		add(targetIDs, policygradient.LogProbs(g, decoder, encoded, config.Sampling.DecoderStartTokenID, targetIDs))
+	}
+	for i := 0; i < config.NumSamples; i++ {
+		sample := policygradient.SampleSequence(g, decoder, encoded, config.Sampling, generator)
+		add(sample.TokenIDs, sample.LogProbs)
+	}
+	if len(scores) == 0 {
+		return g.Constant(0)
+	}
+	distribution := g.Softmax(g.Concat(scores...))
+	return g.Dot(distribution, g.NewVariable(mat.NewVecDense(risks), false))
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq2seq
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/policygradient"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func exactMatch(hypothesis, reference []int) mat.Float {
+	if len(hypothesis) != len(reference) {
+		return 0
+	}
+	for i, id := range hypothesis {
+		if id != reference[i] {
+			return 0
+		}
+	}
+	return 1
+}
+
+func TestMetrics(t *testing.T) {
+	assert.InDelta(t, 1.0, BLEU()([]int{4, 5, 6, 7}, []int{4, 5, 6, 7}), 1.0e-6)
+	assert.Equal(t, mat.Float(0), BLEU()([]int{1, 2}, []int{3, 4}))
+	assert.InDelta(t, 0.75, RougeL()([]int{1, 2, 3, 4}, []int{1, 5, 3, 4}), 1.0e-6)
+	// the IDs are not compared by their digits
+	assert.Equal(t, mat.Float(0), RougeL()([]int{12}, []int{1, 2}))
+}
+
+func TestMinimumRisk(t *testing.T) {
+	m := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*testModel)
+	example := Example{InputIDs: []int{2}, TargetIDs: []int{2, 1}}
+	config := MinimumRiskConfig{
+		Sampling: policygradient.SamplingConfig{
+			DecoderStartTokenID: testStartID,
+			EOSTokenID:          1,
+			MaxLength:           3,
+		},
+		NumSamples:       3,
+		Alpha:            0.5,
+		IncludeReference: true,
+		Metric:           exactMatch,
+		Weight:           1,
+	}
+	assert.NoError(t, config.Validate())
+
+	// the greedy hypothesis is sampled three times, but counted once
+	greedy := policygradient.SampleSequence(g, proc, nil, config.Sampling, nil)
+	assert.NotEqual(t, example.TargetIDs, greedy.TokenIDs)
+	logProbs := policygradient.LogProbs(g, proc, nil, testStartID, example.TargetIDs)
+	q := floatutils.SoftMax([]mat.Float{
+		config.Alpha * g.Sum(logProbs...).ScalarValue(),
+		config.Alpha * g.Sum(greedy.LogProbs...).ScalarValue(),
+	})
+	risk := MinimumRisk(g, proc, example, config, nil)
+	assert.InDelta(t, q[1], risk.ScalarValue(), 1.0e-6)
+
+	// the gradients raise the probability of the reference
+	g.Backward(risk)
+	assert.Less(t, m.W.Grad().At(2, testStartID), mat.Float(0))
+	assert.Less(t, m.W.Grad().At(1, 2), mat.Float(0))
+
+	config.IncludeReference = false
+	assert.InDelta(t, 1.0, MinimumRisk(g, proc, example, config, nil).ScalarValue(), 1.0e-6)
+}
+
+func TestMinimumRiskConfig_Validate(t *testing.T) {
+	valid := MinimumRiskConfig{NumSamples: 4, Alpha: 0.005, Metric: BLEU(), Weight: 0.5}
+	assert.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.NumSamples = 0
+	assert.Error(t, invalid.Validate())
+	invalid = valid
+	invalid.Alpha = 0
+	assert.Error(t, invalid.Validate())
+	invalid = valid
+	invalid.Metric = nil
+	assert.Error(t, invalid.Validate())
+	invalid = valid
+	invalid.Weight = 1.5
+	assert.Error(t, invalid.Validate())
+}
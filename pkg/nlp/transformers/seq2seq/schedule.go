@@ -0,0 +1,67 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq2seq
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+)
+
+// Schedule is implemented by any value that has the TeacherForcing method.
+type Schedule interface {
+	// TeacherForcing returns the probability of feeding the decoder with the reference token,
+	// rather than with the token predicted by the model, at the given training step (starting
+	// from 0).
+	TeacherForcing(step int) mat.Float
+}
+
+var (
+	_ Schedule = Constant(0)
+	_ Schedule = &Linear{}
+	_ Schedule = &Exponential{}
+	_ Schedule = &InverseSigmoid{}
+)
+
+// Constant is a Schedule with the same probability at each step; Constant(1) is the plain
+// teacher forcing.
+type Constant mat.Float
+
+// TeacherForcing satisfies the Schedule interface.
+func (c Constant) TeacherForcing(_ int) mat.Float {
+	return mat.Float(c)
+}
+
+// Linear is a Schedule decaying linearly from Start by Slope at each step, down to Min.
+type Linear struct {
+	Start mat.Float
+	Slope mat.Float
+	Min   mat.Float
+}
+
+// TeacherForcing satisfies the Schedule interface.
+func (l *Linear) TeacherForcing(step int) mat.Float {
+	return mat.Max(l.Min, l.Start-l.Slope*mat.Float(step))
+}
+
+// Exponential is a Schedule decaying as Decay^step, down to Min. Decay must be in (0, 1).
+type Exponential struct {
+	Decay mat.Float
+	Min   mat.Float
+}
+
+// TeacherForcing satisfies the Schedule interface.
+func (e *Exponential) TeacherForcing(step int) mat.Float {
+	return mat.Max(e.Min, mat.Pow(e.Decay, mat.Float(step)))
+}
+
+// InverseSigmoid is a Schedule decaying as K / (K + exp(step / K)): it stays close to 1 for the
+// first steps, then decays to 0; the greater K (>= 1), the slower the decay.
+type InverseSigmoid struct {
+	K mat.Float
+}
+
+// TeacherForcing satisfies the Schedule interface.
+func (s *InverseSigmoid) TeacherForcing(step int) mat.Float {
+	return s.K / (s.K + mat.Exp(mat.Float(step)/s.K))
+}
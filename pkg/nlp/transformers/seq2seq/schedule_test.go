@@ -0,0 +1,44 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq2seq
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConstant(t *testing.T) {
+	assert.Equal(t, mat.Float(0.5), Constant(0.5).TeacherForcing(0))
+	assert.Equal(t, mat.Float(0.5), Constant(0.5).TeacherForcing(1000))
+}
+
+func TestLinear(t *testing.T) {
+	s := &Linear{Start: 1, Slope: 0.1, Min: 0.25}
+	assert.InDelta(t, 1.0, s.TeacherForcing(0), 1.0e-6)
+	assert.InDelta(t, 0.7, s.TeacherForcing(3), 1.0e-6)
+	assert.InDelta(t, 0.25, s.TeacherForcing(8), 1.0e-6)
+	assert.InDelta(t, 0.25, s.TeacherForcing(100), 1.0e-6)
+}
+
+func TestExponential(t *testing.T) {
+	s := &Exponential{Decay: 0.5, Min: 0.1}
+	assert.InDelta(t, 1.0, s.TeacherForcing(0), 1.0e-6)
+	assert.InDelta(t, 0.25, s.TeacherForcing(2), 1.0e-6)
+	assert.InDelta(t, 0.1, s.TeacherForcing(10), 1.0e-6)
+}
+
+func TestInverseSigmoid(t *testing.T) {
+	s := &InverseSigmoid{K: 10}
+	assert.InDelta(t, 10.0/11.0, s.TeacherForcing(0), 1.0e-6)
+	assert.InDelta(t, 10.0/(10.0+mat.Exp(2)), s.TeacherForcing(20), 1.0e-6)
+	prev := s.TeacherForcing(0)
+	for step := 1; step < 100; step++ {
+		cur := s.TeacherForcing(step)
+		assert.Less(t, cur, prev)
+		prev = cur
+	}
+	assert.Less(t, prev, mat.Float(0.01))
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package seq2seq trains the encoder-decoder generators (e.g. the BART conditional generation
+// model) on pairs of input and target sequences, by the cross-entropy of the target tokens.
+//
+// With the plain teacher forcing, the decoder is always fed with the reference tokens during
+// the training, but with its own predictions during the generation: the errors it never saw
+// accumulate (exposure bias). Scheduled sampling feeds the decoder with its own predictions
+// with an increasing probability during the training, following a Schedule.
+//
+// Beyond the cross-entropy of the tokens, the minimum risk training optimizes a sequence-level
+// metric of the hypotheses sampled from the model, such as BLEU or ROUGE (see MinimumRisk).
+//
+// Reference: "Scheduled Sampling for Sequence Prediction with Recurrent Neural Networks" by
+// Bengio et al., 2015 (https://arxiv.org/abs/1506.03099).
+package seq2seq
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+)
+
+// Example is a training example of a Trainer.
+type Example struct {
+	// InputIDs are the tokens of the input of the encoder.
+	InputIDs []int
+	// TargetIDs are the tokens the decoder must generate, without the decoder start token and
+	// usually ending with the end of sequence token. They must not be empty.
+	TargetIDs []int
+}
+
+// DecodingConfig provides configuration settings for the decoding of the target sequences.
+type DecodingConfig struct {
+	// DecoderStartTokenID is the first input of the decoder.
+	DecoderStartTokenID int
+	// TeacherForcing is the probability of feeding the decoder with the previous reference token
+	// at each step, rather than with the previous token predicted by the model.
+	TeacherForcing mat.Float
+	// SamplePredictions samples the tokens predicted by the model from its distribution, rather
+	// than taking the most likely ones.
+	SamplePredictions bool
+}
+
+// Decode returns the logits of each target token, decoding them one at a time from the encoded
+// input. The input of each step is the previous reference token with probability
+// config.TeacherForcing, or the token predicted by the model at the previous step; the choices
+// are made by the generator, which can be nil when the probability is 0 or 1 and the
+// predictions are not sampled.
+func Decode(
+	decoder generation.Decoder,
+	encoded []ag.Node,
+	targetIDs []int,
+	config DecodingConfig,
+	generator *rand.LockedRand,
+) []ag.Node {
+	logits := make([]ag.Node, len(targetIDs))
+	inputIDs := []int{config.DecoderStartTokenID}
+	var cache generation.Cache
+	for i, targetID := range targetIDs {
+		logits[i], cache = decoder.Decode(encoded, inputIDs, cache)
+		if i == len(targetIDs)-1 {
+			break
+		}
+		next := targetID
+		if !teacherForcing(config.TeacherForcing, generator) {
+			next = predict(logits[i].Value().Data(), config.SamplePredictions, generator)
+		}
+		inputIDs = append(inputIDs, next)
+	}
+	return logits
+}
+
+// Loss returns the mean cross-entropy of the target tokens of the example (see Decode), with
+// label smoothing if epsilon is not zero.
+func Loss(
+	g *ag.Graph,
+	model generation.EncoderDecoder,
+	example Example,
+	config DecodingConfig,
+	epsilon mat.Float,
+	generator *rand.LockedRand,
+) ag.Node {
+	logits := Decode(model, model.Encode(example.InputIDs), example.TargetIDs, config, generator)
+	return crossEntropy(g, logits, example.TargetIDs, epsilon)
+}
+
+func crossEntropy(g *ag.Graph, logits []ag.Node, targetIDs []int, epsilon mat.Float) ag.Node {
+	if epsilon != 0 {
+		return losses.LabelSmoothingCrossEntropySeq(g, logits, targetIDs, epsilon, true)
+	}
+	return losses.CrossEntropySeq(g, logits, targetIDs, true)
+}
+
+// teacherForcing reports whether the next input is the reference token, with probability p.
+func teacherForcing(p mat.Float, generator *rand.LockedRand) bool {
+	switch {
+	case p >= 1:
+		return true
+	case p <= 0:
+		return false
+	default:
+		return generator.Float() < p
+	}
+}
+
+// predict returns the most likely token of the logits, or a token sampled from their
+// distribution.
+func predict(logits []mat.Float, sample bool, generator *rand.LockedRand) int {
+	if !sample {
+		return floatutils.ArgMax(logits)
+	}
+	probs := floatutils.SoftMax(logits)
+	u := generator.Float()
+	var cumulative mat.Float
+	for i, p := range probs {
+		cumulative += p
+		if u < cumulative {
+			return i
+		}
+	}
+	return floatutils.ArgMax(probs) // rounding errors
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq2seq
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	testStartID   = 0
+	testVocabSize = 4
+)
+
+// testModel is a bigram generator: the logits of the next token depend on the last one only.
+// It records the inputs of the decoder.
+type testModel struct {
+	nn.BaseModel
+	W      nn.Param `spago:"type:weights"`
+	inputs []int
+}
+
+var _ generation.EncoderDecoder = &testModel{}
+
+func newTestModel() *testModel {
+	m := &testModel{W: nn.NewParam(mat.NewEmptyDense(testVocabSize, testVocabSize))}
+	initializers.Uniform(m.W.Value(), -0.1, 0.1, rand.NewLockedRand(42))
+	return m
+}
+
+func (m *testModel) Encode(inputIDs []int) []ag.Node {
+	return []ag.Node{m.Graph().NewScalar(mat.Float(len(inputIDs)))}
+}
+
+func (m *testModel) Decode(_ []ag.Node, inputIDs []int, pastCache generation.Cache) (ag.Node, generation.Cache) {
+	steps, _ := pastCache.(int)
+	last := inputIDs[len(inputIDs)-1]
+	m.inputs = append(m.inputs, last)
+	oneHot := mat.NewEmptyVecDense(testVocabSize)
+	oneHot.SetVec(last, 1)
+	return m.Graph().Mul(m.W, m.Graph().NewVariable(oneHot, false)), steps + 1
+}
+
+func TestDecode(t *testing.T) {
+	m := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*testModel)
+
+	// the model always predicts 3
+	m.W.Value().SetData(make([]mat.Float, testVocabSize*testVocabSize))
+	for i := 0; i < testVocabSize; i++ {
+		m.W.Value().Set(3, i, 1)
+	}
+	targetIDs := []int{1, 2, 1}
+
+	logits := Decode(proc, nil, targetIDs, DecodingConfig{DecoderStartTokenID: testStartID, TeacherForcing: 1}, nil)
+	assert.Len(t, logits, 3)
+	assert.Equal(t, []int{testStartID, 1, 2}, proc.inputs)
+
+	proc.inputs = nil
+	Decode(proc, nil, targetIDs, DecodingConfig{DecoderStartTokenID: testStartID, TeacherForcing: 0}, nil)
+	assert.Equal(t, []int{testStartID, 3, 3}, proc.inputs)
+
+	// about half of the inputs are the predictions
+	proc.inputs = nil
+	longTarget := make([]int, 1000)
+	for i := range longTarget {
+		longTarget[i] = 1
+	}
+	config := DecodingConfig{DecoderStartTokenID: testStartID, TeacherForcing: 0.5}
+	Decode(proc, nil, longTarget, config, rand.NewLockedRand(1))
+	predicted := 0
+	for _, id := range proc.inputs[1:] {
+		if id == 3 {
+			predicted++
+		}
+	}
+	assert.InDelta(t, 500, predicted, 50)
+}
+
+func TestLoss(t *testing.T) {
+	m := newTestModel()
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, m).(*testModel)
+	example := Example{InputIDs: []int{2, 3}, TargetIDs: []int{2, 3, 1}}
+	config := DecodingConfig{DecoderStartTokenID: testStartID, TeacherForcing: 1}
+
+	logits := Decode(proc, nil, example.TargetIDs, config, nil)
+	expected := losses.CrossEntropySeq(g, logits, example.TargetIDs, true).ScalarValue()
+	assert.InDelta(t, expected, Loss(g, proc, example, config, 0, nil).ScalarValue(), 1.0e-6)
+
+	expected = losses.LabelSmoothingCrossEntropySeq(g, logits, example.TargetIDs, 0.1, true).ScalarValue()
+	assert.InDelta(t, expected, Loss(g, proc, example, config, 0.1, nil).ScalarValue(), 1.0e-6)
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq2seq
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/gdmbuilder"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/generation"
+	"runtime"
+)
+
+// TrainingConfig provides configuration settings for a seq2seq Trainer.
+type TrainingConfig struct {
+	Seed                uint64
+	BatchSize           int
+	Epochs              int
+	DecoderStartTokenID int
+	// Schedule is the probability of teacher forcing at each optimization step; nil is the plain
+	// teacher forcing.
+	Schedule Schedule
+	// SamplePredictions samples the tokens predicted by the model, fed to the decoder instead of
+	// the reference ones, rather than taking the most likely ones.
+	SamplePredictions bool
+	// LabelSmoothing is the epsilon of the label smoothing of the cross-entropy (0 disables it).
+	LabelSmoothing mat.Float
+	// MinimumRisk, if not nil, adds the minimum risk of the sampled hypotheses to the loss (see
+	// MinimumRisk and MinimumRiskConfig.Weight).
+	MinimumRisk      *MinimumRiskConfig
+	GradientClipping mat.Float
+	UpdateMethod     gd.MethodConfig
+}
+
+// Trainer implements the supervised training process of an encoder-decoder generator, with
+// scheduled sampling and, optionally, minimum risk training.
+type Trainer struct {
+	TrainingConfig
+	randGen   *rand.LockedRand
+	optimizer *gd.GradientDescent
+	model     nn.Model
+	step      int
+}
+
+// NewTrainer returns a new Trainer of the model, which must be a generation.EncoderDecoder
+// (e.g. the BART conditional generation model).
+func NewTrainer(model nn.Model, config TrainingConfig) *Trainer {
+	if _, ok := model.(generation.EncoderDecoder); !ok {
+		panic("seq2seq: the model must be an encoder-decoder")
+	}
+	if config.BatchSize < 1 {
+		panic("seq2seq: the batch size must be positive")
+	}
+	if config.MinimumRisk != nil {
+		if err := config.MinimumRisk.Validate(); err != nil {
+			panic(err)
+		}
+	}
+	optimizer := gd.NewOptimizer(gdmbuilder.NewMethod(config.UpdateMethod), nn.NewDefaultParamsIterator(model))
+	if config.GradientClipping != 0.0 {
+		gd.ClipGradByNorm(config.GradientClipping, 2.0)(optimizer)
+	}
+	return &Trainer{
+		TrainingConfig: config,
+		randGen:        rand.NewLockedRand(config.Seed),
+		optimizer:      optimizer,
+		model:          model,
+	}
+}
+
+// TeacherForcing returns the probability of teacher forcing of the next optimization step.
+func (t *Trainer) TeacherForcing() mat.Float {
+	if t.Schedule == nil {
+		return 1
+	}
+	return t.Schedule.TeacherForcing(t.step)
+}
+
+// Train executes the training process on the examples, shuffled at each epoch, and returns the
+// average loss of each epoch.
+func (t *Trainer) Train(examples []Example) []mat.Float {
+	indices := make([]int, len(examples))
+	for i := range indices {
+		indices[i] = i
+	}
+	epochLosses := make([]mat.Float, t.Epochs)
+	for epoch := range epochLosses {
+		rand.ShuffleInPlace(indices, t.randGen)
+		var sum mat.Float
+		var batches int
+		for start := 0; start < len(indices); start += t.BatchSize {
+			end := start + t.BatchSize
+			if end > len(indices) {
+				end = len(indices)
+			}
+			batch := make([]Example, 0, end-start)
+			for _, i := range indices[start:end] {
+				batch = append(batch, examples[i])
+			}
+			sum += t.TrainBatch(batch)
+			batches++
+		}
+		if batches > 0 {
+			epochLosses[epoch] = sum / mat.Float(batches)
+		}
+		t.optimizer.IncEpoch()
+	}
+	return epochLosses
+}
+
+// TrainBatch performs an optimization step on a batch of examples and returns its average
+// loss. The probability of teacher forcing follows the Schedule, one step per batch.
+func (t *Trainer) TrainBatch(batch []Example) mat.Float {
+	t.optimizer.IncBatch()
+	g := ag.NewGraph(ag.Rand(t.randGen), ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, t.model).(generation.EncoderDecoder)
+
+	config := DecodingConfig{
+		DecoderStartTokenID: t.DecoderStartTokenID,
+		TeacherForcing:      t.TeacherForcing(),
+		SamplePredictions:   t.SamplePredictions,
+	}
+	exampleLosses := make([]ag.Node, len(batch))
+	for i, example := range batch {
+		t.optimizer.IncExample()
+		exampleLosses[i] = t.loss(g, proc, example, config)
+	}
+	loss := g.DivScalar(g.Sum(exampleLosses...), g.Constant(mat.Float(len(batch))))
+	g.Backward(loss)
+	t.optimizer.Optimize()
+	t.step++
+	return loss.ScalarValue()
+}
+
+// loss returns the loss of the example: the cross-entropy, interpolated with the minimum risk if
+// enabled.
+func (t *Trainer) loss(g *ag.Graph, proc generation.EncoderDecoder, example Example, config DecodingConfig) ag.Node {
+	encoded := proc.Encode(example.InputIDs)
+	mrt := t.MinimumRisk
+	var ce ag.Node
+	if mrt == nil || mrt.Weight < 1 {
+		ce = crossEntropy(g, Decode(proc, encoded, example.TargetIDs, config, t.randGen), example.TargetIDs, t.LabelSmoothing)
+	}
+	if mrt == nil || mrt.Weight == 0 {
+		return ce
+	}
+	risk := minimumRisk(g, proc, encoded, example.TargetIDs, *mrt, t.randGen)
+	if mrt.Weight == 1 {
+		return risk
+	}
+	return g.Add(
+		g.ProdScalar(ce, g.Constant(1-mrt.Weight)),
+		g.ProdScalar(risk, g.Constant(mrt.Weight)),
+	)
+}
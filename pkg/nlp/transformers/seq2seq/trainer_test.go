@@ -0,0 +1,100 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq2seq
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/sgd"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/policygradient"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTrainer(t *testing.T) {
+	examples := []Example{
+		{InputIDs: []int{1}, TargetIDs: []int{2, 3, 1}},
+		{InputIDs: []int{2}, TargetIDs: []int{2, 3, 1}},
+		{InputIDs: []int{3}, TargetIDs: []int{2, 3, 1}},
+	}
+	schedules := []Schedule{nil, &Linear{Start: 1, Slope: 0.05, Min: 0.5}, &InverseSigmoid{K: 5}}
+	for _, schedule := range schedules {
+		trainer := NewTrainer(newTestModel(), TrainingConfig{
+			Seed:                1,
+			BatchSize:           2,
+			Epochs:              20,
+			DecoderStartTokenID: testStartID,
+			Schedule:            schedule,
+			UpdateMethod:        sgd.NewConfig(0.5, 0, false),
+		})
+		epochLosses := trainer.Train(examples)
+		assert.Len(t, epochLosses, 20)
+		assert.Less(t, epochLosses[19], epochLosses[0]/2, schedule)
+	}
+}
+
+func TestTrainer_MinimumRisk(t *testing.T) {
+	examples := []Example{
+		{InputIDs: []int{1}, TargetIDs: []int{2, 3, 1}},
+		{InputIDs: []int{2}, TargetIDs: []int{2, 3, 1}},
+	}
+	// the cross-entropy of the target, which has the best score
+	targetLoss := func(m *testModel) mat.Float {
+		g := ag.NewGraph()
+		proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, m).(*testModel)
+		config := DecodingConfig{DecoderStartTokenID: testStartID, TeacherForcing: 1}
+		return Loss(g, proc, examples[0], config, 0, nil).ScalarValue()
+	}
+	for _, weight := range []mat.Float{0.5, 1} {
+		m := newTestModel()
+		initialLoss := targetLoss(m)
+		trainer := NewTrainer(m, TrainingConfig{
+			Seed:                1,
+			BatchSize:           2,
+			Epochs:              50,
+			DecoderStartTokenID: testStartID,
+			MinimumRisk: &MinimumRiskConfig{
+				Sampling: policygradient.SamplingConfig{
+					DecoderStartTokenID: testStartID,
+					EOSTokenID:          1,
+					MaxLength:           4,
+					Temperature:         1,
+				},
+				NumSamples: 4,
+				Alpha:      1,
+				Metric:     RougeL(),
+				Weight:     weight,
+			},
+			UpdateMethod: sgd.NewConfig(0.5, 0, false),
+		})
+		trainer.Train(examples)
+		assert.Less(t, targetLoss(m), initialLoss, weight)
+	}
+}
+
+func TestTrainer_TeacherForcing(t *testing.T) {
+	trainer := NewTrainer(newTestModel(), TrainingConfig{
+		BatchSize:    1,
+		Schedule:     &Linear{Start: 1, Slope: 0.1},
+		UpdateMethod: sgd.NewConfig(0.1, 0, false),
+	})
+	assert.Equal(t, mat.Float(1), trainer.TeacherForcing())
+	trainer.TrainBatch([]Example{{InputIDs: []int{1}, TargetIDs: []int{2, 1}}})
+	trainer.TrainBatch([]Example{{InputIDs: []int{1}, TargetIDs: []int{2, 1}}})
+	assert.InDelta(t, 0.8, trainer.TeacherForcing(), 1.0e-6)
+
+	assert.Equal(t, mat.Float(1), NewTrainer(newTestModel(), TrainingConfig{
+		BatchSize:    1,
+		UpdateMethod: sgd.NewConfig(0.1, 0, false),
+	}).TeacherForcing())
+}
+
+func TestNewTrainer(t *testing.T) {
+	assert.Panics(t, func() { NewTrainer(newTestModel(), TrainingConfig{}) })
+	assert.Panics(t, func() {
+		NewTrainer(newTestModel(), TrainingConfig{BatchSize: 1, MinimumRisk: &MinimumRiskConfig{}})
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package plugins provides a registry of the plugins that extend the spaGO servers with custom
+// model heads and HTTP routes, so that a custom task does not require to fork the commands.
+//
+// A plugin registers itself in the init function of its package, calling Register. The plugin is
+// compiled into the server binary by importing its package for its side effects, e.g. in a custom
+// main that runs the application of cmd/bert, or it is built as a Go plugin (go build
+// -buildmode=plugin) and loaded at run time with Open.
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"plugin"
+	"sort"
+	"sync"
+
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+// ErrUnsupportedModel is returned by the factories of the plugins that do not support the model of
+// the server, e.g. a plugin for BERT models on a BART server. Such plugins are skipped.
+var ErrUnsupportedModel = errors.New("plugins: unsupported model")
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Context is the context in which a plugin is instantiated.
+type Context struct {
+	// Model is the model loaded by the server, e.g. a *bert.Model.
+	Model nn.Model
+	// ModelPath is the directory of the model, from which the plugin can load the parameters
+	// of its own heads.
+	ModelPath string
+}
+
+// Route is an HTTP route added to the server by a plugin. The patterns of the routes must not
+// conflict with the ones of the server.
+type Route struct {
+	Pattern string
+	Handler http.HandlerFunc
+}
+
+// Factory instantiates a plugin, returning its routes. It returns ErrUnsupportedModel if the
+// plugin does not support the model of the context.
+type Factory func(ctx Context) ([]Route, error)
+
+// Register makes a plugin available by the provided name.
+// If Register is called twice with the same name or if factory is nil, it panics.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if factory == nil {
+		panic("plugins: Register factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("plugins: Register called twice for plugin " + name)
+	}
+	factories[name] = factory
+}
+
+// Names returns a sorted list of the names of the registered plugins.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open loads the Go plugin at the given path, whose init functions register its plugins.
+// Go plugins are only supported on Linux, FreeBSD and macOS, with cgo enabled.
+func Open(path string) error {
+	if _, err := plugin.Open(path); err != nil {
+		return fmt.Errorf("plugins: %w", err)
+	}
+	return nil
+}
+
+// Routes instantiates all the registered plugins that support the model of the context, in order
+// of name, and returns their routes.
+func Routes(ctx Context) ([]Route, error) {
+	routes := make([]Route, 0)
+	for _, name := range Names() {
+		mu.RLock()
+		factory := factories[name]
+		mu.RUnlock()
+		pluginRoutes, err := factory(ctx)
+		if err == ErrUnsupportedModel {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("plugins: %s: %w", name, err)
+		}
+		routes = append(routes, pluginRoutes...)
+	}
+	return routes, nil
+}
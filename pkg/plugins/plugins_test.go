@@ -0,0 +1,79 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plugins
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	defer reset()
+	Register("foo", func(Context) ([]Route, error) { return nil, nil })
+	Register("bar", func(Context) ([]Route, error) { return nil, nil })
+	assert.Equal(t, []string{"bar", "foo"}, Names())
+
+	assert.Panics(t, func() {
+		Register("foo", func(Context) ([]Route, error) { return nil, nil })
+	})
+	assert.Panics(t, func() {
+		Register("baz", nil)
+	})
+}
+
+func TestRoutes(t *testing.T) {
+	defer reset()
+	handler := func(http.ResponseWriter, *http.Request) {}
+	Register("linear", func(ctx Context) ([]Route, error) {
+		if _, ok := ctx.Model.(*linear.Model); !ok {
+			return nil, ErrUnsupportedModel
+		}
+		return []Route{{Pattern: "/b", Handler: handler}, {Pattern: "/c", Handler: handler}}, nil
+	})
+	Register("any", func(ctx Context) ([]Route, error) {
+		assert.Equal(t, "path", ctx.ModelPath)
+		return []Route{{Pattern: "/a", Handler: handler}}, nil
+	})
+
+	routes, err := Routes(Context{Model: linear.New(1, 1), ModelPath: "path"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/a", "/b", "/c"}, patterns(routes))
+
+	routes, err = Routes(Context{Model: &nn.BaseModel{}, ModelPath: "path"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/a"}, patterns(routes))
+}
+
+func TestRoutes_Error(t *testing.T) {
+	defer reset()
+	Register("broken", func(Context) ([]Route, error) { return nil, errors.New("missing head") })
+
+	_, err := Routes(Context{})
+	assert.EqualError(t, err, "plugins: broken: missing head")
+}
+
+func TestOpen(t *testing.T) {
+	assert.Error(t, Open("missing.so"))
+}
+
+func patterns(routes []Route) []string {
+	result := make([]string, len(routes))
+	for i, route := range routes {
+		result[i] = route.Pattern
+	}
+	return result
+}
+
+func reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	factories = make(map[string]Factory)
+}
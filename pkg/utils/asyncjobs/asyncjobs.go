@@ -0,0 +1,397 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package asyncjobs runs long computations (e.g. the summarization of a long document) in the
+// background, beyond the timeouts of the HTTP requests. The clients submit a job, then poll its
+// status, or get it through a callback URL (webhook) once the job is completed.
+// The status of the jobs is persisted into a key-value database, so that it survives restarts,
+// until the completed jobs expire (see TTL).
+//
+// The callbacks are disabled by default: since the URLs come from the clients, the server would
+// otherwise POST to any host it can reach, including the internal ones. The operator enables them
+// for an allow-list of hosts (see CallbackHosts).
+package asyncjobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nlpodyssey/spago/pkg/utils/kvdb"
+	"github.com/nlpodyssey/spago/pkg/utils/workerpool"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status is the status of a job.
+type Status string
+
+const (
+	// Pending is the status of a job waiting for a worker.
+	Pending Status = "pending"
+	// Running is the status of a job being computed.
+	Running Status = "running"
+	// Done is the status of a job completed successfully.
+	Done Status = "done"
+	// Failed is the status of a job completed with an error.
+	Failed Status = "failed"
+)
+
+// ErrInterrupted is the error of the jobs that were pending or running when the process stopped.
+var ErrInterrupted = errors.New("asyncjobs: job interrupted by a restart")
+
+// ErrShutdown is the error of the jobs submitted to a Manager that has been shut down.
+var ErrShutdown = errors.New("asyncjobs: manager shut down")
+
+// ErrCallbacksDisabled is the error of the jobs submitted with a callback URL to a Manager with no
+// allowed callback hosts.
+var ErrCallbacksDisabled = errors.New("asyncjobs: callbacks are disabled")
+
+// DefaultCallbackTimeout is the default timeout of the requests to the callback URLs.
+const DefaultCallbackTimeout = 10 * time.Second
+
+// maxReapInterval is the maximum interval between the removals of the expired jobs.
+const maxReapInterval = time.Minute
+
+// Job is the JSON-serializable status of a job.
+type Job struct {
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+	// Result is the JSON encoding of the result of the job, once done.
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error is the error of the job, once failed.
+	Error string `json:"error,omitempty"`
+	// Callback is the URL which the job is POSTed to once completed, if not empty.
+	Callback string    `json:"callback,omitempty"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+}
+
+// Func is the computation of a job, which should stop as soon as the context is done.
+// Its result must be JSON-serializable.
+type Func func(ctx context.Context) (interface{}, error)
+
+// Manager runs the submitted jobs on a pool of workers, persisting their status.
+type Manager struct {
+	db     *kvdb.KeyValueDB
+	wp     *workerpool.WorkerPool
+	client *http.Client
+	// callbackHosts are the allowed hosts of the callback URLs, lowercase.
+	callbackHosts map[string]struct{}
+	// ttl is how long the completed jobs are kept (0 for ever).
+	ttl         time.Duration
+	stopReaper  chan struct{}
+	reaperDone  chan struct{}
+	reaperClose sync.Once
+}
+
+// Option allows to configure a new Manager with your specific needs.
+type Option func(*Manager)
+
+// CallbackHosts enables the callbacks to the URLs of the given hosts, which are compared with the
+// host of the URLs with its port (e.g. "hooks.example.com:8443"), or without it, case-insensitive.
+// The redirects of the callbacks are not followed, since they could lead to any other host.
+func CallbackHosts(hosts ...string) Option {
+	return func(m *Manager) {
+		for _, host := range hosts {
+			m.callbackHosts[strings.ToLower(host)] = struct{}{}
+		}
+	}
+}
+
+// TTL makes the Manager remove the jobs once the given time has passed since their completion
+// (the default 0 keeps them for ever). The expired jobs are removed in the background, at least
+// once a minute, and when the Manager is created.
+func TTL(ttl time.Duration) Option {
+	if ttl < 0 {
+		panic("asyncjobs: the TTL must be non-negative")
+	}
+	return func(m *Manager) {
+		m.ttl = ttl
+	}
+}
+
+type task struct {
+	job Job
+	f   Func
+}
+
+// NewManager returns a new Manager, running the jobs on the given number of workers, and
+// persisting their status into the database. The jobs that were pending or running when the
+// database was last used are marked as failed with ErrInterrupted.
+func NewManager(db *kvdb.KeyValueDB, workers int, opts ...Option) (*Manager, error) {
+	m := &Manager{
+		db: db,
+		wp: workerpool.New(workers),
+		client: &http.Client{
+			Timeout: DefaultCallbackTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		callbackHosts: make(map[string]struct{}),
+		stopReaper:    make(chan struct{}),
+		reaperDone:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if err := m.failInterrupted(); err != nil {
+		return nil, err
+	}
+	if err := m.reap(time.Now()); err != nil {
+		return nil, err
+	}
+	m.wp.Start(m.run)
+	go m.runReaper()
+	return m, nil
+}
+
+// Submit adds a job computing the given function, and returns its status. If the callback URL is
+// not empty, the job is POSTed to it once completed; the URL must be on one of the allowed hosts
+// (see CallbackHosts), otherwise the job is not submitted.
+// After Shutdown, the job is not submitted, and ErrShutdown is returned.
+func (m *Manager) Submit(callback string, f Func) (Job, error) {
+	if callback != "" {
+		if err := m.checkCallback(callback); err != nil {
+			return Job{}, err
+		}
+	}
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+	now := time.Now().UTC()
+	job := Job{
+		ID:       id,
+		Status:   Pending,
+		Callback: callback,
+		Created:  now,
+		Updated:  now,
+	}
+	if err := m.save(job); err != nil {
+		return Job{}, err
+	}
+	// the job is saved before being queued, so that its status is never overwritten by the worker
+	if err := m.wp.Submit(context.Background(), task{job: job, f: f}).Err(); errors.Is(err, workerpool.ErrClosed) {
+		if err := m.db.Delete([]byte(job.ID)); err != nil {
+			log.Printf("asyncjobs: removing job %s: %v", job.ID, err)
+		}
+		return Job{}, ErrShutdown
+	}
+	return job, nil
+}
+
+// Get returns the status of the job with the given ID, and whether it has been found.
+func (m *Manager) Get(id string) (Job, bool, error) {
+	data, ok, err := m.db.Get([]byte(id))
+	if err != nil || !ok {
+		return Job{}, ok, err
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return Job{}, false, err
+	}
+	return job, true, nil
+}
+
+// Shutdown stops accepting new jobs (see Submit), and waits for the submitted ones to be completed.
+// If the context is done first, the running jobs are canceled, and the jobs left pending or
+// running are marked as failed the next time the database is used by a Manager.
+// It also stops the removal of the expired jobs.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	err := m.wp.Shutdown(ctx)
+	m.reaperClose.Do(func() { close(m.stopReaper) })
+	<-m.reaperDone
+	return err
+}
+
+// StatusHandler returns an HTTP handler which serves the status of the job whose ID follows
+// the given prefix of the URL path (e.g. "/jobs/").
+func (m *Manager) StatusHandler(prefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		job, ok, err := m.Get(strings.TrimPrefix(req.URL.Path, prefix))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "asyncjobs: job not found", http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(job); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func (m *Manager) run(ctx context.Context, _ int, data interface{}) error {
+	t := data.(task)
+	job := t.job
+	m.update(&job, Running, nil, nil)
+
+	result, err := call(ctx, t.f)
+	if err == nil {
+		var raw []byte
+		raw, err = json.Marshal(result)
+		if err == nil {
+			m.update(&job, Done, raw, nil)
+		}
+	}
+	if err != nil {
+		m.update(&job, Failed, nil, err)
+	}
+	if job.Callback != "" {
+		m.notify(job)
+	}
+	return err
+}
+
+// call returns the result of the function, recovering from panics, so that the job is marked as failed.
+func call(ctx context.Context, f Func) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("asyncjobs: job panicked: %v", r)
+		}
+	}()
+	return f(ctx)
+}
+
+func (m *Manager) update(job *Job, status Status, result []byte, err error) {
+	job.Status = status
+	job.Result = result
+	if err != nil {
+		job.Error = err.Error()
+	}
+	job.Updated = time.Now().UTC()
+	if err := m.save(*job); err != nil {
+		log.Printf("asyncjobs: saving job %s: %v", job.ID, err)
+	}
+}
+
+// notify POSTs the completed job to its callback URL.
+func (m *Manager) notify(job Job) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		log.Printf("asyncjobs: job %s: %v", job.ID, err)
+		return
+	}
+	resp, err := m.client.Post(job.Callback, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Printf("asyncjobs: job %s callback: %v", job.ID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		log.Printf("asyncjobs: job %s callback: status code %d", job.ID, resp.StatusCode)
+	}
+}
+
+// checkCallback returns an error if the callback URL is invalid, or its host is not allowed.
+func (m *Manager) checkCallback(callback string) error {
+	if len(m.callbackHosts) == 0 {
+		return ErrCallbacksDisabled
+	}
+	u, err := url.Parse(callback)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("asyncjobs: invalid callback URL %#v", callback)
+	}
+	if _, ok := m.callbackHosts[strings.ToLower(u.Host)]; ok {
+		return nil
+	}
+	if _, ok := m.callbackHosts[strings.ToLower(u.Hostname())]; ok {
+		return nil
+	}
+	return fmt.Errorf("asyncjobs: callback host %#v not allowed", u.Host)
+}
+
+// runReaper removes the expired jobs periodically, until the Manager is shut down.
+func (m *Manager) runReaper() {
+	defer close(m.reaperDone)
+	if m.ttl == 0 {
+		<-m.stopReaper
+		return
+	}
+	interval := m.ttl
+	if interval > maxReapInterval {
+		interval = maxReapInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := m.reap(now); err != nil {
+				log.Printf("asyncjobs: removing the expired jobs: %v", err)
+			}
+		case <-m.stopReaper:
+			return
+		}
+	}
+}
+
+// reap removes the jobs completed more than the TTL before the given time, if the TTL is set.
+func (m *Manager) reap(now time.Time) error {
+	if m.ttl == 0 {
+		return nil
+	}
+	ids, err := m.db.Keys()
+	if err != nil {
+		return err
+	}
+	expiry := now.Add(-m.ttl)
+	for _, id := range ids {
+		job, ok, err := m.Get(id)
+		if err != nil {
+			return err
+		}
+		if ok && (job.Status == Done || job.Status == Failed) && job.Updated.Before(expiry) {
+			if err := m.db.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) save(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return m.db.Put([]byte(job.ID), data)
+}
+
+func (m *Manager) failInterrupted() error {
+	ids, err := m.db.Keys()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		job, ok, err := m.Get(id)
+		if err != nil {
+			return err
+		}
+		if ok && (job.Status == Pending || job.Status == Running) {
+			m.update(&job, Failed, nil, ErrInterrupted)
+		}
+	}
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package asyncjobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/nlpodyssey/spago/pkg/utils/kvdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestDB(t *testing.T) (*kvdb.KeyValueDB, string) {
+	dir, err := ioutil.TempDir("", "spago-asyncjobs-test-")
+	require.NoError(t, err)
+	return kvdb.NewDefaultKeyValueDB(kvdb.Config{Path: dir, ForceNew: true}), dir
+}
+
+func TestManager(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	m, err := NewManager(db, 2)
+	require.NoError(t, err)
+
+	ok, err := m.Submit("", func(context.Context) (interface{}, error) {
+		return map[string]string{"text": "summary"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Pending, ok.Status)
+	ko, err := m.Submit("", func(context.Context) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, ok.ID, ko.ID)
+	panicked, err := m.Submit("", func(context.Context) (interface{}, error) {
+		panic("boom")
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, m.Shutdown(context.Background()))
+
+	job, found, err := m.Get(ok.ID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, Done, job.Status)
+	assert.JSONEq(t, `{"text": "summary"}`, string(job.Result))
+
+	job, found, err = m.Get(ko.ID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, Failed, job.Status)
+	assert.Equal(t, "boom", job.Error)
+
+	job, _, err = m.Get(panicked.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Failed, job.Status)
+	assert.Equal(t, "asyncjobs: job panicked: boom", job.Error)
+
+	_, found, err = m.Get("unknown")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestManager_SubmitAfterShutdown(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	m, err := NewManager(db, 1)
+	require.NoError(t, err)
+	require.NoError(t, m.Shutdown(context.Background()))
+
+	_, err = m.Submit("", func(context.Context) (interface{}, error) {
+		return "late", nil
+	})
+	assert.Equal(t, ErrShutdown, err)
+	ids, err := db.Keys()
+	require.NoError(t, err)
+	assert.Empty(t, ids) // the job is not persisted
+}
+
+func TestManager_Callback(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	received := make(chan Job, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var job Job
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&job))
+		received <- job
+	}))
+	defer srv.Close()
+
+	srvURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	m, err := NewManager(db, 1, CallbackHosts("example.com", srvURL.Host))
+	require.NoError(t, err)
+	submitted, err := m.Submit(srv.URL, func(context.Context) (interface{}, error) {
+		return 42, nil
+	})
+	require.NoError(t, err)
+
+	_, err = m.Submit("ftp://example.com", nil)
+	assert.Error(t, err)
+	assert.NoError(t, m.checkCallback("http://EXAMPLE.com:8080/hook"))
+	_, err = m.Submit("http://internal.example.com/hook", nil)
+	assert.EqualError(t, err, `asyncjobs: callback host "internal.example.com" not allowed`)
+	_, err = m.Submit("http://"+srvURL.Hostname()+":1/hook", nil)
+	assert.Error(t, err)
+	require.NoError(t, m.Shutdown(context.Background()))
+
+	job := <-received
+	assert.Equal(t, submitted.ID, job.ID)
+	assert.Equal(t, Done, job.Status)
+	assert.Equal(t, "42", string(job.Result))
+}
+
+func TestManager_CallbacksDisabled(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	m, err := NewManager(db, 1)
+	require.NoError(t, err)
+	defer m.Shutdown(context.Background())
+	_, err = m.Submit("http://example.com/hook", nil)
+	assert.Equal(t, ErrCallbacksDisabled, err)
+}
+
+func TestManager_CallbackRedirect(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	redirected := make(chan struct{}, 1)
+	target := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		redirected <- struct{}{}
+	}))
+	defer target.Close()
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer srv.Close()
+
+	srvURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	m, err := NewManager(db, 1, CallbackHosts(srvURL.Host))
+	require.NoError(t, err)
+	_, err = m.Submit(srv.URL, func(context.Context) (interface{}, error) {
+		return 42, nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.Shutdown(context.Background()))
+	assert.Empty(t, redirected)
+}
+
+func TestManager_TTL(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	now := time.Now().UTC()
+	m := &Manager{db: db, ttl: time.Hour}
+	require.NoError(t, m.save(Job{ID: "expired", Status: Done, Updated: now.Add(-2 * time.Hour)}))
+	require.NoError(t, m.save(Job{ID: "failed", Status: Failed, Updated: now.Add(-2 * time.Hour)}))
+	require.NoError(t, m.save(Job{ID: "recent", Status: Done, Updated: now.Add(-time.Minute)}))
+
+	m, err := NewManager(db, 1, TTL(time.Hour))
+	require.NoError(t, err)
+
+	for _, id := range []string{"expired", "failed"} {
+		_, found, err := m.Get(id)
+		require.NoError(t, err)
+		assert.False(t, found, id)
+	}
+	_, found, err := m.Get("recent")
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	job, err := m.Submit("", func(context.Context) (interface{}, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.Shutdown(context.Background()))
+	require.NoError(t, m.reap(time.Now().Add(30*time.Minute)))
+	_, found, err = m.Get(job.ID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	require.NoError(t, m.reap(time.Now().Add(2*time.Hour)))
+	for _, id := range []string{job.ID, "recent"} {
+		_, found, err = m.Get(id)
+		require.NoError(t, err)
+		assert.False(t, found, id)
+	}
+
+	assert.Panics(t, func() { TTL(-time.Second) })
+}
+
+func TestNewManager_FailInterrupted(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	m := &Manager{db: db}
+	require.NoError(t, m.save(Job{ID: "pending", Status: Pending}))
+	require.NoError(t, m.save(Job{ID: "done", Status: Done}))
+
+	m, err := NewManager(db, 1)
+	require.NoError(t, err)
+	defer m.Shutdown(context.Background())
+
+	job, _, err := m.Get("pending")
+	require.NoError(t, err)
+	assert.Equal(t, Failed, job.Status)
+	assert.Equal(t, ErrInterrupted.Error(), job.Error)
+
+	job, _, err = m.Get("done")
+	require.NoError(t, err)
+	assert.Equal(t, Done, job.Status)
+}
+
+func TestManager_StatusHandler(t *testing.T) {
+	db, dir := newTestDB(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	m, err := NewManager(db, 1)
+	require.NoError(t, err)
+	job, err := m.Submit("", func(context.Context) (interface{}, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.Shutdown(context.Background()))
+
+	rec := httptest.NewRecorder()
+	m.StatusHandler("/jobs/")(rec, httptest.NewRequest(http.MethodGet, "/jobs/"+job.ID, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var got Job
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, Done, got.Status)
+
+	rec = httptest.NewRecorder()
+	m.StatusHandler("/jobs/")(rec, httptest.NewRequest(http.MethodGet, "/jobs/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -78,3 +78,49 @@ func SplitDataset(size int, splitRatio mat.Float, seed uint64, class func(i int)
 	}
 	return
 }
+
+// Fold is a split of the dataset of a k-fold cross-validation. Each part consists in a list of indices.
+type Fold struct {
+	Train      []int
+	Validation []int
+}
+
+// KFold splits the dataset into k folds of approximately the same size, for a k-fold cross-validation.
+// The i-th fold has the i-th part of the shuffled indices as validation set, and the rest as training set.
+// If class is not nil, the split is stratified, i.e. each part has approximately the same classes
+// distribution of the whole dataset.
+func KFold(size, k int, seed uint64, class func(i int) string) []Fold {
+	if k < 2 || k > size {
+		panic("data: the number of folds must be between 2 and the size of the dataset")
+	}
+	indices := rand.ShuffleInPlace(utils.MakeIndices(size), rand.NewLockedRand(seed))
+	if class != nil {
+		groups := make(map[string][]int)
+		var classes []string
+		for _, i := range indices {
+			c := class(i)
+			if _, ok := groups[c]; !ok {
+				classes = append(classes, c)
+			}
+			groups[c] = append(groups[c], i)
+		}
+		indices = indices[:0]
+		for _, c := range classes {
+			indices = append(indices, groups[c]...)
+		}
+	}
+	parts := make([][]int, k)
+	for j, i := range indices {
+		parts[j%k] = append(parts[j%k], i) // dealing the indices round-robin keeps the classes balanced
+	}
+	folds := make([]Fold, k)
+	for f := range folds {
+		folds[f].Validation = parts[f]
+		for p, part := range parts {
+			if p != f {
+				folds[f].Train = append(folds[f].Train, part...)
+			}
+		}
+	}
+	return folds
+}
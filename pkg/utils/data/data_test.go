@@ -0,0 +1,41 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+)
+
+func TestKFold(t *testing.T) {
+	folds := KFold(10, 3, 42, nil)
+	assert.Len(t, folds, 3)
+	var validation []int
+	for _, fold := range folds {
+		assert.Len(t, fold.Train, 10-len(fold.Validation))
+		assert.True(t, len(fold.Validation) == 3 || len(fold.Validation) == 4)
+		all := append(append([]int(nil), fold.Train...), fold.Validation...)
+		sort.Ints(all)
+		assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, all)
+		validation = append(validation, fold.Validation...)
+	}
+	sort.Ints(validation)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, validation)
+
+	assert.Panics(t, func() { KFold(10, 1, 42, nil) })
+	assert.Panics(t, func() { KFold(2, 3, 42, nil) })
+}
+
+func TestStratifiedKFold(t *testing.T) {
+	labels := []string{"a", "a", "a", "a", "a", "a", "b", "b", "b", "c", "c", "c"}
+	for _, fold := range KFold(len(labels), 3, 42, func(i int) string { return labels[i] }) {
+		counts := make(map[string]int)
+		for _, i := range fold.Validation {
+			counts[labels[i]]++
+		}
+		assert.Equal(t, map[string]int{"a": 2, "b": 1, "c": 1}, counts)
+	}
+}
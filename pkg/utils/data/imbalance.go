@@ -0,0 +1,152 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"sort"
+)
+
+var (
+	_ Sampler = &WeightedRandomSampler{}
+	_ Sampler = &OverSampler{}
+)
+
+// ClassCounts returns the number of examples of each of the numClasses classes, given the label
+// of each example.
+func ClassCounts(labels []int, numClasses int) []int {
+	counts := make([]int, numClasses)
+	for _, label := range labels {
+		counts[label]++
+	}
+	return counts
+}
+
+// ClassWeights returns the weight of each of the numClasses classes, inversely proportional to
+// its frequency, given the label of each example. The weights are scaled so that a balanced
+// dataset has all weights equal to 1 (n / (numClasses * count)); absent classes have weight 0.
+// They are meant to be used with the losses.WeightedCrossEntropy.
+func ClassWeights(labels []int, numClasses int) []mat.Float {
+	weights := make([]mat.Float, numClasses)
+	for c, count := range ClassCounts(labels, numClasses) {
+		if count > 0 {
+			weights[c] = mat.Float(len(labels)) / mat.Float(numClasses*count)
+		}
+	}
+	return weights
+}
+
+// BalancedWeights returns the sampling weight of each example, inversely proportional to the
+// frequency of its class, so that all the classes are drawn with the same probability by a
+// WeightedRandomSampler.
+func BalancedWeights(labels []int, numClasses int) []mat.Float {
+	counts := ClassCounts(labels, numClasses)
+	weights := make([]mat.Float, len(labels))
+	for i, label := range labels {
+		weights[i] = 1 / mat.Float(counts[label])
+	}
+	return weights
+}
+
+// WeightedRandomSampler draws numSamples examples at each epoch, with replacement, each with a
+// probability proportional to its weight. Combined with the BalancedWeights, it re-samples an
+// imbalanced dataset so that the classes are equally represented in the batches.
+type WeightedRandomSampler struct {
+	cumulative []mat.Float // the cumulative sum of the weights
+	numSamples int
+	batchSize  int
+	randGen    *rand.LockedRand
+}
+
+// NewWeightedRandomSampler returns a new WeightedRandomSampler of the examples with the given
+// (non-negative) weights.
+func NewWeightedRandomSampler(weights []mat.Float, numSamples, batchSize int, seed uint64) *WeightedRandomSampler {
+	if batchSize < 1 || numSamples < 0 {
+		panic("data: the batch size must be positive and the number of samples non-negative")
+	}
+	cumulative := make([]mat.Float, len(weights))
+	var sum mat.Float
+	for i, w := range weights {
+		if w < 0 {
+			panic("data: the weights must be non-negative")
+		}
+		sum += w
+		cumulative[i] = sum
+	}
+	if sum == 0 && numSamples > 0 {
+		panic("data: at least one weight must be positive")
+	}
+	return &WeightedRandomSampler{
+		cumulative: cumulative,
+		numSamples: numSamples,
+		batchSize:  batchSize,
+		randGen:    rand.NewLockedRand(seed),
+	}
+}
+
+// Batches returns the batches of an epoch.
+func (s *WeightedRandomSampler) Batches(_ int) [][]int {
+	indices := make([]int, s.numSamples)
+	for i := range indices {
+		indices[i] = s.draw()
+	}
+	return split(indices, s.batchSize)
+}
+
+// draw returns the index of an example, drawn according to the weights.
+func (s *WeightedRandomSampler) draw() int {
+	last := len(s.cumulative) - 1
+	r := mat.Float(s.randGen.Float()) * s.cumulative[last]
+	i := sort.Search(len(s.cumulative), func(i int) bool { return s.cumulative[i] > r })
+	return utils.MinInt(i, last)
+}
+
+// OverSampler balances the classes by repeating the examples of the minority classes until each
+// class has as many examples as the majority one. At each epoch, all the examples of each class
+// are taken at least once, the missing ones are drawn at random, and the result is shuffled.
+type OverSampler struct {
+	groups    [][]int // the indices of the examples of each class
+	maxCount  int
+	batchSize int
+	randGen   *rand.LockedRand
+}
+
+// NewOverSampler returns a new OverSampler of the examples with the given labels.
+func NewOverSampler(labels []int, numClasses, batchSize int, seed uint64) *OverSampler {
+	if batchSize < 1 {
+		panic("data: the batch size must be positive")
+	}
+	groups := make([][]int, numClasses)
+	maxCount := 0
+	for i, label := range labels {
+		groups[label] = append(groups[label], i)
+		if len(groups[label]) > maxCount {
+			maxCount = len(groups[label])
+		}
+	}
+	return &OverSampler{
+		groups:    groups,
+		maxCount:  maxCount,
+		batchSize: batchSize,
+		randGen:   rand.NewLockedRand(seed),
+	}
+}
+
+// Batches returns the batches of an epoch.
+func (s *OverSampler) Batches(_ int) [][]int {
+	indices := make([]int, 0, len(s.groups)*s.maxCount)
+	for _, group := range s.groups {
+		if len(group) == 0 {
+			continue
+		}
+		indices = append(indices, group...)
+		for k := len(group); k < s.maxCount; k++ {
+			indices = append(indices, group[s.randGen.Intn(len(group))])
+		}
+	}
+	return split(rand.ShuffleInPlace(indices, s.randGen), s.batchSize)
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var imbalancedLabels = []int{0, 0, 0, 0, 0, 0, 1, 1, 0, 2}
+
+func TestClassWeights(t *testing.T) {
+	assert.Equal(t, []int{7, 2, 1, 0}, ClassCounts(imbalancedLabels, 4))
+	assert.InDeltaSlice(t, []mat.Float{10.0 / 28, 10.0 / 8, 10.0 / 4, 0}, ClassWeights(imbalancedLabels, 4), 1.0e-6)
+	assert.InDeltaSlice(t, []mat.Float{1, 1}, ClassWeights([]int{0, 1, 1, 0}, 2), 1.0e-6)
+}
+
+func TestWeightedRandomSampler(t *testing.T) {
+	weights := BalancedWeights(imbalancedLabels, 3)
+	assert.InDelta(t, 1.0/7, weights[0], 1.0e-6)
+	assert.InDelta(t, 1.0, weights[9], 1.0e-6)
+
+	s := NewWeightedRandomSampler(weights, 3000, 32, 42)
+	batches := s.Batches(0)
+	assert.Len(t, batches, 94)
+	counts := make([]int, 3)
+	for _, batch := range batches {
+		for _, i := range batch {
+			counts[imbalancedLabels[i]]++
+		}
+	}
+	for _, count := range counts {
+		assert.InDelta(t, 1000, count, 100)
+	}
+
+	// zero weights are never drawn
+	s = NewWeightedRandomSampler([]mat.Float{0, 1, 0}, 10, 10, 42)
+	assert.Equal(t, [][]int{{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}}, s.Batches(0))
+
+	assert.Panics(t, func() { NewWeightedRandomSampler([]mat.Float{0, 0}, 1, 1, 42) })
+	assert.Panics(t, func() { NewWeightedRandomSampler([]mat.Float{-1, 1}, 1, 1, 42) })
+}
+
+func TestOverSampler(t *testing.T) {
+	s := NewOverSampler(imbalancedLabels, 4, 5, 42)
+	batches := s.Batches(0)
+	assert.Len(t, batches, 5)
+	counts := make([]int, 4)
+	seen := make(map[int]bool)
+	for _, batch := range batches {
+		for _, i := range batch {
+			counts[imbalancedLabels[i]]++
+			seen[i] = true
+		}
+	}
+	assert.Equal(t, []int{7, 7, 7, 0}, counts)
+	assert.Len(t, seen, len(imbalancedLabels)) // every example is taken at least once
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"sort"
+)
+
+// Sampler returns the batches of each epoch of the training, as lists of indices of the examples.
+type Sampler interface {
+	Batches(epoch int) [][]int
+}
+
+var (
+	_ Sampler = &BucketSampler{}
+	_ Sampler = &CurriculumSampler{}
+)
+
+// BucketSampler batches together the examples of similar length, so that the padding of the
+// batches is reduced. At each epoch, the examples are shuffled and split into pools of
+// poolFactor batches; the examples of each pool are sorted by length and batched, and the batches
+// are shuffled. The bigger the pools, the less padding, but the less random the batches.
+type BucketSampler struct {
+	lengths    []int
+	batchSize  int
+	poolFactor int
+	randGen    *rand.LockedRand
+}
+
+// NewBucketSampler returns a new BucketSampler of the examples with the given lengths.
+func NewBucketSampler(lengths []int, batchSize, poolFactor int, seed uint64) *BucketSampler {
+	if batchSize < 1 || poolFactor < 1 {
+		panic("data: the batch size and the pool factor must be positive")
+	}
+	return &BucketSampler{
+		lengths:    lengths,
+		batchSize:  batchSize,
+		poolFactor: poolFactor,
+		randGen:    rand.NewLockedRand(seed),
+	}
+}
+
+// Batches returns the batches of an epoch.
+func (s *BucketSampler) Batches(_ int) [][]int {
+	indices := rand.ShuffleInPlace(utils.MakeIndices(len(s.lengths)), s.randGen)
+	poolSize := s.batchSize * s.poolFactor
+	batches := make([][]int, 0, (len(indices)+s.batchSize-1)/s.batchSize)
+	for start := 0; start < len(indices); start += poolSize {
+		pool := indices[start:utils.MinInt(start+poolSize, len(indices))]
+		sort.SliceStable(pool, func(i, j int) bool {
+			return s.lengths[pool[i]] < s.lengths[pool[j]]
+		})
+		batches = append(batches, split(pool, s.batchSize)...)
+	}
+	s.randGen.Shuffle(len(batches), func(i, j int) {
+		batches[i], batches[j] = batches[j], batches[i]
+	})
+	return batches
+}
+
+// Schedule returns the competence of the model at each epoch of a curriculum, i.e. the fraction
+// (0, 1] of the easiest examples which are sampled.
+type Schedule func(epoch int) mat.Float
+
+// LinearSchedule returns a Schedule whose competence grows linearly from the initial one at
+// epoch 0 to 1 at the end of the warm-up epochs.
+func LinearSchedule(initial mat.Float, warmUpEpochs int) Schedule {
+	return func(epoch int) mat.Float {
+		if epoch >= warmUpEpochs {
+			return 1
+		}
+		return initial + (1-initial)*mat.Float(epoch)/mat.Float(warmUpEpochs)
+	}
+}
+
+// SqrtSchedule returns a Schedule whose competence grows as the square root of the epoch, from
+// the initial one at epoch 0 to 1 at the end of the warm-up epochs, so that the harder examples
+// are introduced quickly at first, then more slowly.
+// See "Competence-based Curriculum Learning for Neural Machine Translation" (Platanios et al., 2019).
+func SqrtSchedule(initial mat.Float, warmUpEpochs int) Schedule {
+	return func(epoch int) mat.Float {
+		if epoch >= warmUpEpochs {
+			return 1
+		}
+		t := mat.Float(epoch) / mat.Float(warmUpEpochs)
+		return mat.Sqrt(t*(1-initial*initial) + initial*initial)
+	}
+}
+
+// CurriculumSampler samples the examples from the easiest to the hardest: at each epoch, the
+// batches are made of the easiest examples within the competence given by the schedule, shuffled.
+// The difficulty can be any score, e.g. the length of the examples or the loss of a baseline model.
+type CurriculumSampler struct {
+	sorted    []int // the indices of the examples by increasing difficulty
+	batchSize int
+	schedule  Schedule
+	randGen   *rand.LockedRand
+}
+
+// NewCurriculumSampler returns a new CurriculumSampler of the examples with the given difficulties.
+func NewCurriculumSampler(difficulties []mat.Float, batchSize int, schedule Schedule, seed uint64) *CurriculumSampler {
+	if batchSize < 1 {
+		panic("data: the batch size must be positive")
+	}
+	sorted := utils.MakeIndices(len(difficulties))
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return difficulties[sorted[i]] < difficulties[sorted[j]]
+	})
+	return &CurriculumSampler{
+		sorted:    sorted,
+		batchSize: batchSize,
+		schedule:  schedule,
+		randGen:   rand.NewLockedRand(seed),
+	}
+}
+
+// Batches returns the batches of an epoch. At least one batch is returned, if there are examples.
+func (s *CurriculumSampler) Batches(epoch int) [][]int {
+	competence := s.schedule(epoch)
+	if competence > 1 {
+		competence = 1
+	}
+	n := int(mat.Ceil(competence * mat.Float(len(s.sorted))))
+	if n < s.batchSize {
+		n = s.batchSize
+	}
+	n = utils.MinInt(n, len(s.sorted))
+	indices := append([]int(nil), s.sorted[:n]...)
+	return split(rand.ShuffleInPlace(indices, s.randGen), s.batchSize)
+}
+
+// split splits the indices into batches of the given size (the last one may be smaller).
+func split(indices []int, batchSize int) [][]int {
+	batches := make([][]int, 0, (len(indices)+batchSize-1)/batchSize)
+	for start := 0; start < len(indices); start += batchSize {
+		batches = append(batches, indices[start:utils.MinInt(start+batchSize, len(indices))])
+	}
+	return batches
+}
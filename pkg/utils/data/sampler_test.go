@@ -0,0 +1,71 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package data
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+)
+
+func TestBucketSampler(t *testing.T) {
+	lengths := []int{5, 1, 9, 2, 8, 3, 7, 4}
+	s := NewBucketSampler(lengths, 2, 4, 42)
+	batches := s.Batches(0)
+	assert.Len(t, batches, 4)
+	var all []int
+	for _, batch := range batches {
+		assert.Len(t, batch, 2)
+		all = append(all, batch...)
+	}
+	sort.Ints(all)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, all)
+
+	// a single pool with all the examples: the batches are made of consecutive lengths
+	s = NewBucketSampler(lengths, 2, 100, 42)
+	var batchLengths [][]int
+	for _, batch := range s.Batches(0) {
+		batchLengths = append(batchLengths, []int{lengths[batch[0]], lengths[batch[1]]})
+	}
+	assert.ElementsMatch(t, [][]int{{1, 2}, {3, 4}, {5, 7}, {8, 9}}, batchLengths)
+
+	assert.Panics(t, func() { NewBucketSampler(lengths, 0, 1, 42) })
+}
+
+func TestSchedules(t *testing.T) {
+	linear := LinearSchedule(0.2, 4)
+	assert.InDelta(t, 0.2, linear(0), 1.0e-6)
+	assert.InDelta(t, 0.6, linear(2), 1.0e-6)
+	assert.Equal(t, mat.Float(1), linear(4))
+	assert.Equal(t, mat.Float(1), linear(10))
+
+	sqrt := SqrtSchedule(0.2, 4)
+	assert.InDelta(t, 0.2, sqrt(0), 1.0e-6)
+	assert.InDelta(t, 0.72111, sqrt(2), 1.0e-4)
+	assert.Equal(t, mat.Float(1), sqrt(4))
+}
+
+func TestCurriculumSampler(t *testing.T) {
+	difficulties := []mat.Float{0.9, 0.1, 0.5, 0.3, 0.7, 0.2, 0.8, 0.4, 0.6, 1.0}
+	s := NewCurriculumSampler(difficulties, 2, LinearSchedule(0.4, 3), 42)
+
+	indices := flatten(s.Batches(0))
+	sort.Ints(indices)
+	assert.Equal(t, []int{1, 3, 5, 7}, indices) // the 40% easiest
+
+	assert.Len(t, flatten(s.Batches(3)), 10)
+
+	s = NewCurriculumSampler(difficulties, 6, LinearSchedule(0.1, 3), 42)
+	assert.Len(t, flatten(s.Batches(0)), 6) // at least one full batch
+}
+
+func flatten(batches [][]int) []int {
+	var out []int
+	for _, batch := range batches {
+		out = append(out, batch...)
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httphandlers
+
+import (
+	"encoding/json"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/utils/kvdb"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+)
+
+// RegisterDebugHandlers adds the debug endpoints to the mux:
+//   - /debug/pprof/ serves the profiles of the net/http/pprof package;
+//   - /debug/goroutines dumps the stacks of all the goroutines;
+//   - /debug/memstats reports the memory usage of the runtime, the memory held by the arenas of
+//     the graphs, and the size and the cache metrics of the open key-value databases.
+//
+// The endpoints expose the internals of the process, so they are meant for administrators only.
+func RegisterDebugHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/goroutines", GoroutinesHandler)
+	mux.HandleFunc("/debug/memstats", MemStatsHandler)
+}
+
+// GoroutinesHandler writes the stacks of all the goroutines as plain text.
+func GoroutinesHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := rpprof.Lookup("goroutine").WriteTo(w, 2); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// MemStats is the JSON-serializable response of the MemStatsHandler.
+type MemStats struct {
+	Runtime     RuntimeMemStats `json:"runtime"`
+	Arenas      ag.ArenaStats   `json:"arenas"`
+	KeyValueDBs []kvdb.Stats    `json:"key_value_dbs"`
+}
+
+// RuntimeMemStats is a summary of the runtime.MemStats.
+type RuntimeMemStats struct {
+	Goroutines   int    `json:"goroutines"`
+	Alloc        uint64 `json:"alloc"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	Sys          uint64 `json:"sys"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"pause_total_ns"`
+}
+
+// MemStatsHandler writes the MemStats as JSON.
+func MemStatsHandler(w http.ResponseWriter, _ *http.Request) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	stats := MemStats{
+		Runtime: RuntimeMemStats{
+			Goroutines:   runtime.NumGoroutine(),
+			Alloc:        ms.Alloc,
+			TotalAlloc:   ms.TotalAlloc,
+			Sys:          ms.Sys,
+			HeapInuse:    ms.HeapInuse,
+			HeapObjects:  ms.HeapObjects,
+			NumGC:        ms.NumGC,
+			PauseTotalNs: ms.PauseTotalNs,
+		},
+		Arenas:      ag.GetArenaStats(),
+		KeyValueDBs: kvdb.AllStats(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
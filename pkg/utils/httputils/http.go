@@ -18,6 +18,10 @@ const DefaultTimeoutSeconds = 60
 // bytes the server will read parsing the request's body.
 const DefaultMaxRequestBytes = 1 << 20 // 1 MB
 
+// DefaultAdminAddress is the default address of the admin server (see RunAdminServer),
+// which only accepts local connections.
+const DefaultAdminAddress = "localhost:6060"
+
 // HTTPServerConfig provides server configuration parameters for running
 // an HTTP server (see RunHTTPServer).
 type HTTPServerConfig struct {
@@ -54,6 +58,17 @@ func RunHTTPServer(config HTTPServerConfig, h http.Handler) {
 	log.Fatal(err)
 }
 
+// RunAdminServer listens on the given address and serves the debug endpoints (see
+// httphandlers.RegisterDebugHandlers) using plain HTTP, and blocks until done.
+// The endpoints expose the internals of the process, so they are served apart from the public
+// endpoints, on an address which should not be reachable by the clients (e.g. DefaultAdminAddress).
+// There is no timeout, since the profiles and the traces take as long as requested.
+func RunAdminServer(address string) {
+	mux := http.NewServeMux()
+	httphandlers.RegisterDebugHandlers(mux)
+	log.Fatal(http.ListenAndServe(address, newRecoveryHandler(mux)))
+}
+
 type maxRequestBytesHandler struct {
 	h http.Handler
 	n int64
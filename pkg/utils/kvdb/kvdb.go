@@ -2,21 +2,15 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+// Package kvdb provides a key-value database which spaGO can use to efficiently store large data.
+// It is backed by Badger, except for WebAssembly (GOOS=js), where it is an in-memory map.
 package kvdb
 
 import (
-	"github.com/dgraph-io/badger/v3"
-	"log"
-	"os"
+	"sort"
+	"sync"
 )
 
-// KeyValueDB is a key-value database which spaGO can use to efficiently store
-// large data.
-type KeyValueDB struct {
-	Config
-	db *badger.DB
-}
-
 // Config provides configuration parameters for KeyValueDB.
 type Config struct {
 	Path     string
@@ -24,29 +18,6 @@ type Config struct {
 	ForceNew bool
 }
 
-// NewDefaultKeyValueDB returns a new KeyValueDB.
-func NewDefaultKeyValueDB(config Config) *KeyValueDB {
-	if config.ForceNew {
-		err := os.RemoveAll(config.Path)
-		if err != nil {
-			log.Println(err)
-		}
-	}
-	options := badger.DefaultOptions(config.Path).
-		WithReadOnly(config.ReadOnly).
-		WithSyncWrites(false).
-		WithLogger(nil)
-
-	db, err := badger.Open(options)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return &KeyValueDB{
-		Config: config,
-		db:     db,
-	}
-}
-
 // MarshalBinary prevents KeyValueDB to be encoded to binary representation.
 //
 // It never makes sense to encode/decode a KeyValueDB value, for example
@@ -65,71 +36,58 @@ func (*KeyValueDB) UnmarshalBinary([]byte) error {
 	return nil
 }
 
-// Close closes the underlying DB.
-// It's crucial to call it to ensure all the pending updates make their way to disk.
-func (m *KeyValueDB) Close() error {
-	return m.db.Close()
+// Stats reports the size and the cache metrics of an open KeyValueDB.
+type Stats struct {
+	Path string `json:"path"`
+	// LSMSize and ValueLogSize are the sizes in bytes of the LSM tree and of the value log.
+	// In WebAssembly, LSMSize is the size of the data kept in memory.
+	LSMSize      int64 `json:"lsm_size"`
+	ValueLogSize int64 `json:"value_log_size"`
+	// BlockCache and IndexCache are the metrics of the caches of the data blocks and of the table indices.
+	BlockCache CacheStats `json:"block_cache"`
+	IndexCache CacheStats `json:"index_cache"`
 }
 
-// DropAll would drop all the data stored.
-// Readings or writings performed during this operation may result in panics.
-func (m *KeyValueDB) DropAll() error {
-	return m.db.DropAll()
+// CacheStats reports the metrics of a cache.
+type CacheStats struct {
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
 }
 
-// Keys returns all the keys from the DB.
-func (m *KeyValueDB) Keys() ([]string, error) {
-	var keys []string
-	err := m.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = false
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		for it.Rewind(); it.Valid(); it.Next() {
-			keys = append(keys, string(it.Item().Key()))
-		}
-		return nil // end view
-	})
-	return keys, err
+// openDBs is the set of the open KeyValueDBs (see AllStats).
+var openDBs = struct {
+	sync.Mutex
+	dbs map[*KeyValueDB]struct{}
+}{dbs: map[*KeyValueDB]struct{}{}}
+
+func register(m *KeyValueDB) {
+	openDBs.Lock()
+	defer openDBs.Unlock()
+	openDBs.dbs[m] = struct{}{}
 }
 
-// Put sets a new key/value pair in the DB.
-func (m *KeyValueDB) Put(key []byte, value []byte) error {
-	return m.db.Update(func(txn *badger.Txn) error {
-		entry := badger.NewEntry(key, value)
-		err := txn.SetEntry(entry)
-		return err // end view
-	})
+func unregister(m *KeyValueDB) {
+	openDBs.Lock()
+	defer openDBs.Unlock()
+	delete(openDBs.dbs, m)
 }
 
-// Get returns the value associated to the given key, if it exists.
-func (m *KeyValueDB) Get(key []byte) (value []byte, ok bool, err error) {
-	err = m.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(key)
-		if err != nil {
-			return err
-		}
-		value, err = copyValue(item)
-		if err != nil {
-			return err
-		}
-		return nil // end view
-	})
-	switch {
-	case err == nil:
-		return value, true, nil
-	case err == badger.ErrKeyNotFound:
-		return nil, false, nil
-	default:
-		return nil, false, err
+// AllStats returns the stats of all the open KeyValueDBs, sorted by path.
+func AllStats() []Stats {
+	openDBs.Lock()
+	dbs := make([]*KeyValueDB, 0, len(openDBs.dbs))
+	for m := range openDBs.dbs {
+		dbs = append(dbs, m)
 	}
-}
+	openDBs.Unlock()
 
-func copyValue(item *badger.Item) ([]byte, error) {
-	var valCopy []byte
-	err := item.Value(func(val []byte) error {
-		valCopy = append([]byte{}, val...)
-		return nil
+	stats := make([]Stats, len(dbs))
+	for i, m := range dbs {
+		stats[i] = m.Stats()
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Path < stats[j].Path
 	})
-	return valCopy, err
+	return stats
 }
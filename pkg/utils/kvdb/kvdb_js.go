@@ -0,0 +1,108 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build js
+
+package kvdb
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrReadOnly is returned writing to a read-only KeyValueDB.
+var ErrReadOnly = errors.New("kvdb: the database is read-only")
+
+// KeyValueDB is a key-value database which spaGO can use to store large data.
+// In WebAssembly there is no file system, so the data is kept in memory and the Path is ignored.
+type KeyValueDB struct {
+	Config
+	mu   *sync.RWMutex
+	data map[string][]byte
+}
+
+// NewDefaultKeyValueDB returns a new KeyValueDB.
+func NewDefaultKeyValueDB(config Config) *KeyValueDB {
+	m := &KeyValueDB{
+		Config: config,
+		mu:     new(sync.RWMutex),
+		data:   make(map[string][]byte),
+	}
+	register(m)
+	return m
+}
+
+// Close does nothing, since the data is kept in memory.
+func (m *KeyValueDB) Close() error {
+	unregister(m)
+	return nil
+}
+
+// Stats returns the size of the data kept in memory. There are no caches.
+func (m *KeyValueDB) Stats() Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var size int64
+	for key, value := range m.data {
+		size += int64(len(key) + len(value))
+	}
+	return Stats{
+		Path:    m.Path,
+		LSMSize: size,
+	}
+}
+
+// DropAll would drop all the data stored.
+func (m *KeyValueDB) DropAll() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = make(map[string][]byte)
+	return nil
+}
+
+// Keys returns all the keys from the DB, sorted.
+func (m *KeyValueDB) Keys() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.data))
+	for key := range m.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Put sets a new key/value pair in the DB.
+func (m *KeyValueDB) Put(key []byte, value []byte) error {
+	if m.ReadOnly {
+		return ErrReadOnly
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+// Delete removes the key and its value from the DB, if it exists.
+func (m *KeyValueDB) Delete(key []byte) error {
+	if m.ReadOnly {
+		return ErrReadOnly
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, string(key))
+	return nil
+}
+
+// Get returns the value associated to the given key, if it exists.
+func (m *KeyValueDB) Get(key []byte) (value []byte, ok bool, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok = m.data[string(key)]
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]byte{}, value...), true, nil
+}
@@ -124,6 +124,54 @@ func TestKeyValueDB_PutAndGet(t *testing.T) {
 	assert.Nil(t, value)
 }
 
+func TestKeyValueDB_Delete(t *testing.T) {
+	t.Parallel()
+
+	dir := newTempDir(t, "spago-kvdb-test-")
+	defer os.RemoveAll(dir)
+
+	db := NewDefaultKeyValueDB(Config{Path: dir, ReadOnly: false, ForceNew: true})
+	defer db.Close()
+
+	require.Nil(t, db.Put([]byte{1}, []byte{2}))
+	require.Nil(t, db.Delete([]byte{1}))
+	_, ok, err := db.Get([]byte{1})
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	// Nonexistent key
+	require.Nil(t, db.Delete([]byte{9}))
+}
+
+func TestAllStats(t *testing.T) {
+	t.Parallel()
+
+	dir := newTempDir(t, "spago-kvdb-test-")
+	defer os.RemoveAll(dir)
+
+	db := NewDefaultKeyValueDB(Config{Path: dir, ReadOnly: false, ForceNew: true})
+	err := db.Put([]byte{1}, []byte{2})
+	require.Nil(t, err)
+
+	find := func() (Stats, bool) {
+		for _, stats := range AllStats() {
+			if stats.Path == dir {
+				return stats, true
+			}
+		}
+		return Stats{}, false
+	}
+
+	stats, ok := find()
+	assert.True(t, ok)
+	assert.Equal(t, db.Stats(), stats)
+
+	err = db.Close()
+	require.Nil(t, err)
+	_, ok = find()
+	assert.False(t, ok)
+}
+
 func TestKeyValueDB_Gob(t *testing.T) {
 	t.Parallel()
 
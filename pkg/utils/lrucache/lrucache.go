@@ -0,0 +1,133 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lrucache provides a cache with a maximum number of entries, which evicts the least
+// recently used ones, and optionally the ones older than a time-to-live.
+package lrucache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cache is a least recently used (LRU) cache, safe for concurrent use.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List // the most recently used entries come first
+	hits     uint64
+	misses   uint64
+	now      func() time.Time
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time // zero if there is no time-to-live
+}
+
+// New returns a new Cache holding at most capacity entries. If ttl is positive, the entries
+// expire after that duration since they have been added.
+// It panics if the capacity is not positive.
+func New(capacity int, ttl time.Duration) *Cache {
+	if capacity < 1 {
+		panic(fmt.Sprintf("lrucache: the capacity must be positive, found %d", capacity))
+	}
+	return &Cache{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns the value of the key, and whether it has been found (and not expired).
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if ok && c.expired(elem.Value.(*entry)) {
+		c.remove(elem)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*entry).value, true
+}
+
+// Add sets the value of the key, evicting the least recently used entry if the cache is full.
+func (c *Cache) Add(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = c.now().Add(c.ttl)
+	}
+	if elem, ok := c.items[key]; ok {
+		elem.Value = &entry{key: key, value: value, expires: expires}
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+	if c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// Len returns the number of entries in the cache, including the expired ones not evicted yet.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats reports the usage of a Cache.
+type Stats struct {
+	Len    int    `json:"len"`
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// Stats returns the number of entries and the number of hits and misses of the cache.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Len:    c.order.Len(),
+		Hits:   c.hits,
+		Misses: c.misses,
+	}
+}
+
+func (c *Cache) expired(e *entry) bool {
+	return !e.expires.IsZero() && c.now().After(e.expires)
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry).key)
+}
+
+// Key returns a key identifying the given parts, e.g. the name of an endpoint, its input
+// and its options. The parts are encoded as JSON, so they must be JSON-serializable.
+func Key(parts ...interface{}) (string, error) {
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lrucache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCache_LRU(t *testing.T) {
+	c := New(2, 0)
+	c.Add("a", 1)
+	c.Add("b", 2)
+
+	v, ok := c.Get("a") // "b" becomes the least recently used
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	c.Add("c", 3)
+	assert.Equal(t, 2, c.Len())
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	v, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	c.Add("a", 4) // replaces the value
+	v, _ = c.Get("a")
+	assert.Equal(t, 4, v)
+	assert.Equal(t, Stats{Len: 2, Hits: 3, Misses: 1}, c.Stats())
+}
+
+func TestCache_TTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := New(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Add("a", 1)
+	now = now.Add(time.Minute)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestNew_InvalidCapacity(t *testing.T) {
+	assert.Panics(t, func() { New(0, 0) })
+}
+
+func TestKey(t *testing.T) {
+	k1, err := Key("classify", "text", "")
+	require.NoError(t, err)
+	k2, err := Key("classify", "text", "")
+	require.NoError(t, err)
+	k3, err := Key("classify", "text2", "")
+	require.NoError(t, err)
+	assert.Equal(t, k1, k2)
+	assert.NotEqual(t, k1, k3)
+
+	_, err = Key(func() {})
+	assert.Error(t, err)
+}
@@ -5,78 +5,279 @@
 package workerpool
 
 import (
+	"container/heap"
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 )
 
+// ErrClosed is the error of the jobs submitted to a pool that has been shut down.
+var ErrClosed = errors.New("workerpool: pool closed")
+
 // WorkerPool is a structure to run a pool of worker goroutines, gracefully
 // handling interrupt and termination signals.
+//
+// The jobs are processed in order of priority, and in order of submission among
+// the jobs with the same priority. A job that panics fails with an error, without
+// affecting the other jobs.
 type WorkerPool struct {
-	size       int
-	ingestChan chan interface{}
-	jobsChan   chan interface{}
+	size    int
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   jobQueue
+	seq     uint64
+	closed  bool
+	ctx     context.Context // canceled when a shutdown is forced
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
 }
 
 // WorkerFunc is a function to perform a single worker job.
 type WorkerFunc func(workerID int, jobData interface{})
 
+// JobFunc is a function to perform a single worker job, which should stop as soon
+// as the context of the job is done.
+type JobFunc func(ctx context.Context, workerID int, jobData interface{}) error
+
 // New returns a new WorkerPool ready-to-use.
 func New(size int) *WorkerPool {
-	return &WorkerPool{
-		size:       size,
-		ingestChan: make(chan interface{}, 1),
-		jobsChan:   make(chan interface{}, size),
+	ctx, cancel := context.WithCancel(context.Background())
+	wp := &WorkerPool{
+		size:   size,
+		ctx:    ctx,
+		cancel: cancel,
 	}
+	wp.cond = sync.NewCond(&wp.mu)
+	return wp
 }
 
 // Run runs all workers and blocks until a signal is received.
+// Then it waits for the submitted jobs to be completed.
 func (wp *WorkerPool) Run(workerFunc WorkerFunc) {
-	ctx, ctxCancelFunc := context.WithCancel(context.Background())
-
-	wg := &sync.WaitGroup{}
-	wg.Add(wp.size)
-
-	go wp.runConsumer(ctx)
+	wp.Start(func(_ context.Context, workerID int, jobData interface{}) error {
+		workerFunc(workerID, jobData)
+		return nil
+	})
+	wp.blockUntilSignal()
+	_ = wp.Shutdown(context.Background())
+}
 
+// Start runs all workers in the background, until Shutdown is called.
+func (wp *WorkerPool) Start(jobFunc JobFunc) {
+	wp.workers.Add(wp.size)
 	for workerID := 0; workerID < wp.size; workerID++ {
-		go wp.runWorker(workerID, wg, workerFunc)
+		go wp.runWorker(workerID, jobFunc)
 	}
+}
 
-	wp.blockUntilSignal()
+// Shutdown stops accepting new jobs, and waits for the submitted jobs to be completed.
+// If the context is done first, the contexts of the running jobs are canceled, the
+// queued jobs fail, and the context error is returned.
+func (wp *WorkerPool) Shutdown(ctx context.Context) error {
+	wp.mu.Lock()
+	wp.closed = true
+	wp.cond.Broadcast()
+	wp.mu.Unlock()
 
-	ctxCancelFunc()
-	wg.Wait()
+	done := make(chan struct{})
+	go func() {
+		wp.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		wp.cancel()
+		wp.mu.Lock()
+		for wp.queue.Len() > 0 {
+			heap.Pop(&wp.queue).(*Job).finish(ctx.Err())
+		}
+		wp.mu.Unlock()
+		return ctx.Err()
+	}
 }
 
 // PublishJobData adds some data to be processed by the workers.
 func (wp *WorkerPool) PublishJobData(jobData interface{}) {
-	wp.ingestChan <- jobData
+	wp.Submit(context.Background(), jobData)
+}
+
+// JobOption allows to configure a job.
+type JobOption func(*Job)
+
+// Priority sets the priority of the job (default 0). The jobs with higher priority
+// are processed first.
+func Priority(value int) JobOption {
+	return func(j *Job) {
+		j.priority = value
+	}
 }
 
-func (wp *WorkerPool) runWorker(workerID int, wg *sync.WaitGroup, wFunc WorkerFunc) {
-	defer wg.Done()
-	for jobData := range wp.jobsChan {
-		wFunc(workerID, jobData)
+// Timeout sets the maximum duration of the job, including the time it is queued.
+func Timeout(d time.Duration) JobOption {
+	return func(j *Job) {
+		j.timeout = d
 	}
 }
 
-func (wp *WorkerPool) runConsumer(ctx context.Context) {
+// Submit adds a job to be processed by the workers, and returns it. The job fails,
+// without being processed, if its context is done before a worker is available.
+func (wp *WorkerPool) Submit(ctx context.Context, jobData interface{}, opts ...JobOption) *Job {
+	job := &Job{
+		Data: jobData,
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	if job.timeout > 0 {
+		job.ctx, job.cancel = context.WithTimeout(ctx, job.timeout)
+	} else {
+		job.ctx, job.cancel = context.WithCancel(ctx)
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if wp.closed {
+		job.finish(ErrClosed)
+		return job
+	}
+	wp.seq++
+	job.seq = wp.seq
+	heap.Push(&wp.queue, job)
+	wp.cond.Signal()
+	return job
+}
+
+func (wp *WorkerPool) runWorker(workerID int, jobFunc JobFunc) {
+	defer wp.workers.Done()
 	for {
-		select {
-		case jobData := <-wp.ingestChan:
-			wp.jobsChan <- jobData
-		case <-ctx.Done():
-			close(wp.jobsChan)
+		wp.mu.Lock()
+		for wp.queue.Len() == 0 && !wp.closed {
+			wp.cond.Wait()
+		}
+		if wp.queue.Len() == 0 {
+			wp.mu.Unlock()
 			return
 		}
+		job := heap.Pop(&wp.queue).(*Job)
+		wp.mu.Unlock()
+		job.run(wp.ctx, workerID, jobFunc)
 	}
 }
 
 func (wp *WorkerPool) blockUntilSignal() {
-	termChan := make(chan os.Signal)
+	termChan := make(chan os.Signal, 1)
 	signal.Notify(termChan, syscall.SIGINT, syscall.SIGTERM)
 	<-termChan
+	signal.Stop(termChan)
+}
+
+// Job is a job submitted to a WorkerPool.
+type Job struct {
+	// Data is the data to be processed by the workers.
+	Data     interface{}
+	priority int
+	timeout  time.Duration
+	seq      uint64
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+	err      error
+}
+
+// Done returns a channel that is closed when the job is completed.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Err returns the error of the completed job, or nil. The error of a job that
+// panicked reports the value of the panic.
+func (j *Job) Err() error {
+	select {
+	case <-j.done:
+		return j.err
+	default:
+		return nil
+	}
+}
+
+// Wait blocks until the job is completed, or its context is done, and returns its error.
+func (j *Job) Wait() error {
+	select {
+	case <-j.done:
+		return j.err
+	case <-j.ctx.Done():
+		select {
+		case <-j.done:
+			return j.err
+		default:
+			return j.ctx.Err()
+		}
+	}
+}
+
+// run processes the job, unless its context is already done, recovering from panics.
+func (j *Job) run(poolCtx context.Context, workerID int, jobFunc JobFunc) {
+	if err := j.ctx.Err(); err != nil {
+		j.finish(err)
+		return
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-poolCtx.Done():
+			j.cancel()
+		case <-stop:
+		}
+	}()
+
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("workerpool: job panicked: %v", r)
+			}
+		}()
+		err = jobFunc(j.ctx, workerID, j.Data)
+	}()
+	j.finish(err)
+}
+
+func (j *Job) finish(err error) {
+	j.err = err
+	j.cancel()
+	close(j.done)
+}
+
+// jobQueue is a priority queue of jobs (see container/heap).
+type jobQueue []*Job
+
+func (q jobQueue) Len() int { return len(q) }
+
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q jobQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *jobQueue) Push(x interface{}) { *q = append(*q, x.(*Job)) }
+
+func (q *jobQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	job := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return job
 }
@@ -5,12 +5,17 @@
 package workerpool
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 	"syscall"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWorkerPool(t *testing.T) {
@@ -208,3 +213,93 @@ func (ej ExecutedJobs) CountCompleted() int {
 	}
 	return n
 }
+
+func TestWorkerPool_Priority(t *testing.T) {
+	wp := New(1)
+	var processed []string
+	// the jobs are queued before the workers are started
+	wp.Submit(context.Background(), "first")
+	wp.Submit(context.Background(), "low", Priority(-1))
+	wp.Submit(context.Background(), "second")
+	wp.Submit(context.Background(), "high", Priority(1))
+	wp.Start(func(_ context.Context, _ int, jobData interface{}) error {
+		processed = append(processed, jobData.(string))
+		return nil
+	})
+	require.NoError(t, wp.Shutdown(context.Background()))
+	assert.Equal(t, []string{"high", "first", "second", "low"}, processed)
+}
+
+func TestWorkerPool_PanicRecovery(t *testing.T) {
+	wp := New(1)
+	wp.Start(func(_ context.Context, _ int, jobData interface{}) error {
+		if jobData == "panic" {
+			panic("boom")
+		}
+		if jobData == "error" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.EqualError(t, wp.Submit(context.Background(), "panic").Wait(), "workerpool: job panicked: boom")
+	assert.EqualError(t, wp.Submit(context.Background(), "error").Wait(), "failed")
+	assert.NoError(t, wp.Submit(context.Background(), "ok").Wait())
+	require.NoError(t, wp.Shutdown(context.Background()))
+}
+
+func TestWorkerPool_Timeout(t *testing.T) {
+	wp := New(1)
+	wp.Start(func(ctx context.Context, _ int, _ interface{}) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+	job := wp.Submit(context.Background(), "slow", Timeout(10*time.Millisecond))
+	assert.Equal(t, context.DeadlineExceeded, job.Wait())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	job = wp.Submit(ctx, "canceled")
+	assert.Equal(t, context.Canceled, job.Wait())
+	require.NoError(t, wp.Shutdown(context.Background()))
+}
+
+func TestWorkerPool_Shutdown(t *testing.T) {
+	wp := New(2)
+	wp.Start(func(_ context.Context, _ int, _ interface{}) error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+	jobs := make([]*Job, 6)
+	for i := range jobs {
+		jobs[i] = wp.Submit(context.Background(), i)
+	}
+	require.NoError(t, wp.Shutdown(context.Background()))
+	for _, job := range jobs {
+		assert.NoError(t, job.Err())
+		select {
+		case <-job.Done():
+		default:
+			t.Error("expected the job to be completed")
+		}
+	}
+	assert.Equal(t, ErrClosed, wp.Submit(context.Background(), "late").Wait())
+}
+
+func TestWorkerPool_ForcedShutdown(t *testing.T) {
+	wp := New(1)
+	wp.Start(func(ctx context.Context, _ int, _ interface{}) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	running := wp.Submit(context.Background(), "running")
+	queued := wp.Submit(context.Background(), "queued")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, wp.Shutdown(ctx))
+	assert.Equal(t, context.Canceled, running.Wait())
+	assert.Equal(t, context.DeadlineExceeded, queued.Wait())
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package clip implements a multimodal dual encoder in the style of CLIP, as described in
+// "Learning Transferable Visual Models From Natural Language Supervision" by Radford et al., 2021
+// (https://arxiv.org/abs/2103.00020).
+//
+// A BERT text encoder and a ResNet image encoder are projected into a shared embedding space,
+// where the cosine similarity of a text and an image measures how well the text describes the
+// image. The model is trained with a symmetric contrastive loss over batches of matching pairs,
+// in which the other texts and images of the batch are the negatives.
+//
+// The model holds the encoders themselves, so that they are fine-tuned with the projections, and
+// it is saved and loaded with nn.SaveModel and nn.LoadModel like any other model. The words
+// embeddings of BERT are kept in their own storage (see bert.NewDefaultBERT).
+package clip
+
+import (
+	"encoding/gob"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/losses"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/convolution/resnet"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
+)
+
+var (
+	_ nn.Model = &Model{}
+)
+
+// DefaultTemperature is the initial temperature of the similarities, as in the original CLIP.
+const DefaultTemperature mat.Float = 0.07
+
+// MaxLogitScale is the upper limit of the learned scale of the similarities, which keeps the
+// training stable (see ClampLogitScale).
+const MaxLogitScale mat.Float = 100
+
+// Config provides configuration settings for a CLIP Model.
+type Config struct {
+	// EmbeddingSize is the size of the shared embedding space.
+	EmbeddingSize int
+	// MeanPooling represents a text by the mean of the encoded tokens, instead of the pooled
+	// "[CLS]" token (see bert.Model.Pool).
+	MeanPooling bool
+}
+
+// Model contains the serializable parameters of a CLIP dual encoder.
+type Model struct {
+	nn.BaseModel
+	Config       Config
+	TextEncoder  *bert.Model
+	ImageEncoder *resnet.Model
+	// TextProjection and ImageProjection map the encodings into the shared embedding space.
+	TextProjection  *linear.Model
+	ImageProjection *linear.Model
+	// LogitScale is the logarithm of the scale of the similarities, that is of 1/temperature.
+	LogitScale nn.Param `spago:"type:weights"`
+}
+
+func init() {
+	gob.Register(&Model{})
+}
+
+// New returns a new CLIP Model pairing the given encoders. The projections are initialized to
+// zeros (see Init), the scale of the similarities to 1/DefaultTemperature.
+func New(config Config, textEncoder *bert.Model, imageEncoder *resnet.Model) *Model {
+	imageChannels := imageEncoder.Config.Channels
+	return &Model{
+		Config:          config,
+		TextEncoder:     textEncoder,
+		ImageEncoder:    imageEncoder,
+		TextProjection:  linear.New(textEncoder.Config.HiddenSize, config.EmbeddingSize, linear.BiasGrad(false)),
+		ImageProjection: linear.New(imageChannels[len(imageChannels)-1], config.EmbeddingSize, linear.BiasGrad(false)),
+		LogitScale:      nn.NewParam(mat.NewScalar(mat.Log(1 / DefaultTemperature))),
+	}
+}
+
+// Init initializes the projections by the Xavier uniform initialization, for the training from
+// scratch. The encoders are left untouched, since they are usually pre-trained.
+func Init(m *Model, generator *rand.LockedRand) {
+	initializers.XavierUniform(m.TextProjection.W.Value(), 1, generator)
+	initializers.XavierUniform(m.ImageProjection.W.Value(), 1, generator)
+}
+
+// Tokenize splits the text into the word pieces of the text encoder, between the "[CLS]" and
+// "[SEP]" tokens, truncated to the maximum length of the encoder.
+func (m *Model) Tokenize(text string) []string {
+	tokenizer := wordpiecetokenizer.New(m.TextEncoder.Vocabulary)
+	tokens := tokenizers.GetStrings(tokenizer.Tokenize(text))
+	if maxTokens := m.TextEncoder.Embeddings.MaxPositions - 2; len(tokens) > maxTokens {
+		tokens = tokens[:maxTokens]
+	}
+	tokens = append([]string{wordpiecetokenizer.DefaultClassToken}, tokens...)
+	return append(tokens, wordpiecetokenizer.DefaultSequenceSeparator)
+}
+
+// EncodeText returns the normalized embedding of the tokens of a text (see Tokenize).
+func (m *Model) EncodeText(tokens []string) ag.Node {
+	encoded := m.TextEncoder.Encode(tokens)
+	var pooled ag.Node
+	if m.Config.MeanPooling {
+		pooled = m.Graph().Mean(encoded)
+	} else {
+		pooled = m.TextEncoder.Pool(encoded)
+	}
+	return m.normalize(nn.ToNode(m.TextProjection.Forward(pooled)))
+}
+
+// EncodeImage returns the normalized embedding of the channels of an image (see
+// resnet.Model.Features).
+func (m *Model) EncodeImage(channels ...ag.Node) ag.Node {
+	features := m.ImageEncoder.Features(channels...)
+	return m.normalize(nn.ToNode(m.ImageProjection.Forward(features)))
+}
+
+// normalize returns the vector divided by its L2 norm.
+func (m *Model) normalize(x ag.Node) ag.Node {
+	g := m.Graph()
+	return g.DivScalar(x, g.Sqrt(g.ReduceSum(g.Square(x))))
+}
+
+// Similarity returns, for each of the given embeddings, the vector of its cosine similarities with
+// the candidates, scaled by the learned scale. The embeddings and the candidates are the output of
+// EncodeText and EncodeImage, in either order: the logits of the texts given an image are
+// Similarity(images, texts), and vice versa.
+func (m *Model) Similarity(xs, candidates []ag.Node) []ag.Node {
+	g := m.Graph()
+	stacked := g.Stack(candidates...)
+	scale := g.Exp(m.LogitScale)
+	logits := make([]ag.Node, len(xs))
+	for i, x := range xs {
+		logits[i] = g.ProdScalar(g.Mul(stacked, x), scale)
+	}
+	return logits
+}
+
+// Loss returns the symmetric contrastive loss of a batch of matching pairs: the i-th text
+// describes the i-th image. It is the average of the cross-entropy of the images given each
+// text and of the texts given each image, in which the other elements of the batch are the
+// negatives.
+func (m *Model) Loss(texts, images []ag.Node) ag.Node {
+	if len(texts) != len(images) {
+		panic("clip: the number of texts and images must be the same")
+	}
+	g := m.Graph()
+	var loss ag.Node
+	for i, logits := range m.Similarity(images, texts) {
+		loss = g.Add(loss, losses.CrossEntropy(g, logits, i))
+	}
+	for i, logits := range m.Similarity(texts, images) {
+		loss = g.Add(loss, losses.CrossEntropy(g, logits, i))
+	}
+	return g.DivScalar(loss, g.NewScalar(mat.Float(2*len(texts))))
+}
+
+// ClampLogitScale limits the learned scale of the similarities to MaxLogitScale. It is meant to
+// be called after each optimization step.
+func ClampLogitScale(m *Model) {
+	if max := mat.Log(MaxLogitScale); m.LogitScale.ScalarValue() > max {
+		m.LogitScale.Value().SetVec(0, max)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clip
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/convolution/resnet"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/adam"
+	"github.com/nlpodyssey/spago/pkg/nlp/tokenizers/wordpiecetokenizer"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
+	"github.com/nlpodyssey/spago/pkg/nlp/vocabulary"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testWords = []string{
+	wordpiecetokenizer.DefaultClassToken,
+	wordpiecetokenizer.DefaultSequenceSeparator,
+	wordpiecetokenizer.DefaultUnknownToken,
+	"red", "blue", "square",
+}
+
+// newTestModel returns a tiny CLIP model with random parameters, whose BERT words embeddings are
+// stored in a temporary directory.
+func newTestModel(t *testing.T) *Model {
+	dir, err := ioutil.TempDir("", "spago-clip-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	text := bert.NewDefaultBERT(bert.Config{
+		HiddenAct:             "gelu",
+		HiddenSize:            4,
+		IntermediateSize:      8,
+		MaxPositionEmbeddings: 8,
+		NumAttentionHeads:     2,
+		NumHiddenLayers:       1,
+		TypeVocabSize:         1,
+		VocabSize:             len(testWords),
+		Training:              true,
+	}, filepath.Join(dir, "embeddings"))
+	t.Cleanup(text.Embeddings.Words.Close)
+	text.Vocabulary = vocabulary.New(testWords)
+	image := resnet.New(resnet.Config{
+		InputChannels: 3,
+		Channels:      []int{2},
+		Blocks:        []int{1},
+	})
+	model := New(Config{EmbeddingSize: 3}, text, image)
+
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		initializers.Uniform(param.Value(), -0.5, 0.5, rndGen)
+	})
+	model.LogitScale.Value().SetVec(0, mat.Log(1/DefaultTemperature))
+	for _, word := range testWords {
+		vector := mat.NewEmptyVecDense(4)
+		initializers.Uniform(vector, -1, 1, rndGen)
+		text.Embeddings.Words.SetEmbedding(word, vector)
+	}
+	return model
+}
+
+// newTestImage returns the channels of a 4x4 image filled with the given color.
+func newTestImage(r, g, b mat.Float) []mat.Matrix {
+	channels := make([]mat.Matrix, 3)
+	for c, v := range []mat.Float{r, g, b} {
+		channels[c] = mat.NewInitDense(4, 4, v)
+	}
+	return channels
+}
+
+func encodePairs(model *Model, mode nn.ProcessingMode, pairs []Pair) (*Model, []ag.Node, []ag.Node) {
+	g := ag.NewGraph()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: mode}, model).(*Model)
+	texts := make([]ag.Node, len(pairs))
+	images := make([]ag.Node, len(pairs))
+	for i, pair := range pairs {
+		texts[i] = proc.EncodeText(model.Tokenize(pair.Text))
+		channels := make([]ag.Node, len(pair.Image))
+		for c, channel := range pair.Image {
+			channels[c] = g.NewVariable(channel, false)
+		}
+		images[i] = proc.EncodeImage(channels...)
+	}
+	return proc, texts, images
+}
+
+var testPairs = []Pair{
+	{Text: "red square", Image: newTestImage(1, 0, 0)},
+	{Text: "blue square", Image: newTestImage(0, 0, 1)},
+}
+
+func TestModel_Tokenize(t *testing.T) {
+	model := newTestModel(t)
+	assert.Equal(t, []string{"[CLS]", "red", "square", "[SEP]"}, model.Tokenize("red square"))
+	// truncated to the maximum positions of the text encoder
+	assert.Len(t, model.Tokenize("red red red red red red red red red"), 8)
+}
+
+func TestModel_Encode(t *testing.T) {
+	model := newTestModel(t)
+	for _, meanPooling := range []bool{false, true} {
+		model.Config.MeanPooling = meanPooling
+		_, texts, images := encodePairs(model, nn.Inference, testPairs)
+		for _, x := range append(texts, images...) {
+			assert.Equal(t, 3, x.Value().Size())
+			assert.InDelta(t, 1.0, x.Value().(*mat.Dense).Norm(2), 1.0e-5)
+		}
+	}
+}
+
+func TestModel_Similarity(t *testing.T) {
+	model := newTestModel(t)
+	proc, texts, images := encodePairs(model, nn.Inference, testPairs)
+	logits := proc.Similarity(images, texts)
+	assert.Len(t, logits, 2)
+	scale := mat.Exp(model.LogitScale.ScalarValue())
+	for i, image := range images {
+		for j, text := range texts {
+			expected := scale * image.Value().(*mat.Dense).DotUnitary(text.Value())
+			assert.InDelta(t, expected, logits[i].Value().AtVec(j), 1.0e-4)
+		}
+	}
+}
+
+func TestModel_Loss(t *testing.T) {
+	model := newTestModel(t)
+	proc, texts, images := encodePairs(model, nn.Inference, testPairs)
+	loss := proc.Loss(texts, images).ScalarValue()
+	assert.Greater(t, loss, mat.Float(0))
+
+	assert.Panics(t, func() { proc.Loss(texts, images[:1]) })
+}
+
+func TestTrainer_Train(t *testing.T) {
+	model := newTestModel(t)
+	trainer := NewTrainer(model, TrainingConfig{
+		Seed:         1,
+		BatchSize:    2,
+		Epochs:       30,
+		UpdateMethod: adam.NewConfig(0.01, 0.9, 0.999, 1.0e-8),
+	})
+	epochLosses := trainer.Train(testPairs)
+	assert.Len(t, epochLosses, 30)
+	assert.Less(t, epochLosses[29], epochLosses[0])
+	assert.LessOrEqual(t, model.LogitScale.ScalarValue(), mat.Log(MaxLogitScale))
+
+	proc, texts, images := encodePairs(model, nn.Inference, testPairs)
+	for i, logits := range proc.Similarity(images, texts) {
+		assert.Equal(t, i, floatutils.ArgMax(logits.Value().Data()))
+	}
+
+	assert.Panics(t, func() { NewTrainer(model, TrainingConfig{BatchSize: 1}) })
+}
+
+func TestClampLogitScale(t *testing.T) {
+	model := newTestModel(t)
+	model.LogitScale.Value().SetVec(0, 10)
+	ClampLogitScale(model)
+	assert.InDelta(t, mat.Log(MaxLogitScale), model.LogitScale.ScalarValue(), 1.0e-6)
+}
+
+func TestModel_SaveLoad(t *testing.T) {
+	model := newTestModel(t)
+	filename := filepath.Join(t.TempDir(), "clip.bin")
+	assert.NoError(t, nn.SaveModel(filename, model))
+
+	loaded := New(model.Config, model.TextEncoder, resnet.New(model.ImageEncoder.Config))
+	loaded.LogitScale.Value().SetVec(0, 0)
+	_, err := nn.LoadModel(filename, loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, model.LogitScale.ScalarValue(), loaded.LogitScale.ScalarValue())
+	assert.Equal(t, model.ImageProjection.W.Value().Data(), loaded.ImageProjection.W.Value().Data())
+	assert.Equal(t, model.ImageEncoder.Stem.W.Value().Data(), loaded.ImageEncoder.Stem.W.Value().Data())
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clip
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"github.com/nlpodyssey/spago/pkg/vision/imagetensor"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// Server contains everything needed to run a cross-modal similarity server.
+type Server struct {
+	model *Model
+	// Config converts the images into the input of the image encoder.
+	Config          imagetensor.Config
+	TimeoutSeconds  int
+	MaxRequestBytes int
+	// AdminAddress, if not empty, is the address of a separate listener serving the debug
+	// endpoints under /debug/ (see httputils.RunAdminServer).
+	AdminAddress string
+}
+
+// NewServer returns a new Server, converting the images by the given configuration, which must
+// match the input channels of the image encoder.
+func NewServer(model *Model, config imagetensor.Config) *Server {
+	if config.Channels() != model.ImageEncoder.Config.InputChannels {
+		panic("clip: the images and the model have a different number of channels")
+	}
+	return &Server{
+		model:  model,
+		Config: config,
+	}
+}
+
+// StartDefaultHTTPServer is used to start a basic HTTP server.
+// If you want more control of the HTTP server you can run your own
+// HTTP router using the public handler functions.
+func (s *Server) StartDefaultHTTPServer(address, tlsCert, tlsKey string, tlsDisable bool) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/similarity", s.SimilarityHandler)
+	if s.AdminAddress != "" {
+		go httputils.RunAdminServer(s.AdminAddress)
+	}
+
+	go httputils.RunHTTPServer(httputils.HTTPServerConfig{
+		Address:         address,
+		TLSDisable:      tlsDisable,
+		TLSCert:         tlsCert,
+		TLSKey:          tlsKey,
+		TimeoutSeconds:  s.TimeoutSeconds,
+		MaxRequestBytes: s.MaxRequestBytes,
+	}, mux)
+}
+
+// SimilarityBody is the JSON-serializable body of a "similarity" request.
+type SimilarityBody struct {
+	Texts []string `json:"texts"`
+	// Images are the encoded images (JPEG, PNG or GIF), in standard base64.
+	Images []string `json:"images"`
+}
+
+// SimilarityResponse is a JSON-serializable server response for the "similarity" requests.
+type SimilarityResponse struct {
+	// Similarities are the cosine similarities of each image with each text.
+	Similarities [][]mat.Float `json:"similarities"`
+	// Probabilities are, for each image, the probabilities of the texts to describe it: the
+	// softmax of the scaled similarities, as in the zero-shot classification of the images.
+	Probabilities [][]mat.Float `json:"probabilities"`
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// SimilarityHandler handles a similarity request over HTTP.
+func (s *Server) SimilarityHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	var body SimilarityBody
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body.Texts) == 0 || len(body.Images) == 0 {
+		http.Error(w, "clip: at least a text and an image are required", http.StatusBadRequest)
+		return
+	}
+	images, err := s.decodeImages(body.Images)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := s.Similarity(body.Texts, images)
+	_, pretty := req.URL.Query()["pretty"]
+	response, err := Dump(result, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// decodeImages converts the base64-encoded images into their channels.
+func (s *Server) decodeImages(encoded []string) ([][]mat.Matrix, error) {
+	images := make([][]mat.Matrix, len(encoded))
+	for i, image := range encoded {
+		data, err := base64.StdEncoding.DecodeString(image)
+		if err != nil {
+			return nil, fmt.Errorf("clip: image %d: %w", i, err)
+		}
+		images[i], err = imagetensor.Load(bytes.NewReader(data), s.Config)
+		if err != nil {
+			return nil, fmt.Errorf("clip: image %d: %w", i, err)
+		}
+	}
+	return images, nil
+}
+
+// Similarity returns the similarities of the images, given as their channels (see
+// imagetensor.FromImage), with the texts.
+func (s *Server) Similarity(texts []string, images [][]mat.Matrix) *SimilarityResponse {
+	start := time.Now()
+
+	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*Model)
+	textEmbeddings := make([]ag.Node, len(texts))
+	for i, text := range texts {
+		textEmbeddings[i] = proc.EncodeText(s.model.Tokenize(text))
+	}
+	imageEmbeddings := make([]ag.Node, len(images))
+	for i, image := range images {
+		channels := make([]ag.Node, len(image))
+		for c, channel := range image {
+			channels[c] = g.NewVariable(channel, false)
+		}
+		imageEmbeddings[i] = proc.EncodeImage(channels...)
+	}
+
+	scale := mat.Exp(s.model.LogitScale.ScalarValue())
+	response := &SimilarityResponse{
+		Similarities:  make([][]mat.Float, len(images)),
+		Probabilities: make([][]mat.Float, len(images)),
+	}
+	for i, logits := range proc.Similarity(imageEmbeddings, textEmbeddings) {
+		response.Probabilities[i] = floatutils.SoftMax(logits.Value().Data())
+		response.Similarities[i] = make([]mat.Float, len(texts))
+		for j, logit := range logits.Value().Data() {
+			response.Similarities[i][j] = logit / scale
+		}
+	}
+	response.Took = time.Since(start).Milliseconds()
+	return response
+}
+
+// Dump serializes the given value to JSON.
+func Dump(value interface{}, pretty bool) ([]byte, error) {
+	buf := bytes.NewBufferString("")
+	enc := json.NewEncoder(buf)
+	if pretty {
+		enc.SetIndent("", "    ")
+	}
+	enc.SetEscapeHTML(true)
+	err := enc.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clip
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/nlpodyssey/spago/pkg/vision/imagetensor"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestPNG(t *testing.T, c color.Color) string {
+	img := image.NewRGBA(image.Rect(0, 0, 6, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 6; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func newTestRequest(t *testing.T, body SimilarityBody) *http.Request {
+	data, err := json.Marshal(body)
+	assert.NoError(t, err)
+	return httptest.NewRequest(http.MethodPost, "/similarity", bytes.NewReader(data))
+}
+
+func TestServer_SimilarityHandler(t *testing.T) {
+	s := NewServer(newTestModel(t), imagetensor.Config{Width: 4, Height: 4})
+
+	rec := httptest.NewRecorder()
+	s.SimilarityHandler(rec, newTestRequest(t, SimilarityBody{
+		Texts:  []string{"red square", "blue square", "square"},
+		Images: []string{newTestPNG(t, color.RGBA{R: 255, A: 255}), newTestPNG(t, color.RGBA{B: 255, A: 255})},
+	}))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response SimilarityResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response.Similarities, 2)
+	assert.Len(t, response.Probabilities, 2)
+	for i := range response.Similarities {
+		assert.Len(t, response.Similarities[i], 3)
+		var sum float32
+		for j, p := range response.Probabilities[i] {
+			assert.True(t, response.Similarities[i][j] >= -1.0001 && response.Similarities[i][j] <= 1.0001)
+			sum += float32(p)
+		}
+		assert.InDelta(t, 1, sum, 1.0e-5)
+	}
+
+	for _, body := range []SimilarityBody{
+		{Texts: []string{"red square"}},
+		{Texts: []string{"red square"}, Images: []string{"not base64!"}},
+		{Texts: []string{"red square"}, Images: []string{base64.StdEncoding.EncodeToString([]byte("text"))}},
+	} {
+		rec = httptest.NewRecorder()
+		s.SimilarityHandler(rec, newTestRequest(t, body))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	model := newTestModel(t)
+	assert.Panics(t, func() { NewServer(model, imagetensor.Config{Grayscale: true}) })
+	assert.NotPanics(t, func() { NewServer(model, imagetensor.Config{}) })
+}
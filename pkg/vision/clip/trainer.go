@@ -0,0 +1,115 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clip
+
+import (
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd"
+	"github.com/nlpodyssey/spago/pkg/ml/optimizers/gd/gdmbuilder"
+	"runtime"
+)
+
+// Pair is a training example: a text describing an image, given as the channels of the image
+// (see imagetensor.FromImage).
+type Pair struct {
+	Text  string
+	Image []mat.Matrix
+}
+
+// TrainingConfig provides configuration settings for a CLIP Trainer.
+type TrainingConfig struct {
+	Seed uint64
+	// BatchSize is the number of pairs of a batch, which are the negatives of each other; it must
+	// be at least 2.
+	BatchSize        int
+	Epochs           int
+	GradientClipping mat.Float
+	UpdateMethod     gd.MethodConfig
+}
+
+// Trainer implements the contrastive training process for a CLIP Model.
+type Trainer struct {
+	TrainingConfig
+	randGen   *rand.LockedRand
+	optimizer *gd.GradientDescent
+	model     *Model
+}
+
+// NewTrainer returns a new CLIP Trainer.
+func NewTrainer(model *Model, config TrainingConfig) *Trainer {
+	if config.BatchSize < 2 {
+		panic("clip: the batch size must be at least 2")
+	}
+	optimizer := gd.NewOptimizer(gdmbuilder.NewMethod(config.UpdateMethod), nn.NewDefaultParamsIterator(model))
+	if config.GradientClipping != 0.0 {
+		gd.ClipGradByNorm(config.GradientClipping, 2.0)(optimizer)
+	}
+	return &Trainer{
+		TrainingConfig: config,
+		randGen:        rand.NewLockedRand(config.Seed),
+		optimizer:      optimizer,
+		model:          model,
+	}
+}
+
+// Train executes the training process on the pairs, shuffled at each epoch, and returns the
+// average loss of each epoch. A last batch smaller than 2 pairs is skipped.
+func (t *Trainer) Train(pairs []Pair) []mat.Float {
+	indices := make([]int, len(pairs))
+	for i := range indices {
+		indices[i] = i
+	}
+	epochLosses := make([]mat.Float, t.Epochs)
+	for epoch := range epochLosses {
+		rand.ShuffleInPlace(indices, t.randGen)
+		var sum mat.Float
+		var batches int
+		for start := 0; start+1 < len(indices); start += t.BatchSize {
+			end := start + t.BatchSize
+			if end > len(indices) {
+				end = len(indices)
+			}
+			batch := make([]Pair, 0, end-start)
+			for _, i := range indices[start:end] {
+				batch = append(batch, pairs[i])
+			}
+			sum += t.TrainBatch(batch)
+			batches++
+		}
+		if batches > 0 {
+			epochLosses[epoch] = sum / mat.Float(batches)
+		}
+		t.optimizer.IncEpoch()
+	}
+	return epochLosses
+}
+
+// TrainBatch performs an optimization step on a batch of pairs and returns its loss.
+func (t *Trainer) TrainBatch(batch []Pair) mat.Float {
+	t.optimizer.IncBatch()
+	g := ag.NewGraph(ag.Rand(t.randGen), ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Training}, t.model).(*Model)
+
+	texts := make([]ag.Node, len(batch))
+	images := make([]ag.Node, len(batch))
+	for i, pair := range batch {
+		t.optimizer.IncExample()
+		texts[i] = proc.EncodeText(t.model.Tokenize(pair.Text))
+		channels := make([]ag.Node, len(pair.Image))
+		for c, channel := range pair.Image {
+			channels[c] = g.NewVariable(channel, false)
+		}
+		images[i] = proc.EncodeImage(channels...)
+	}
+	loss := proc.Loss(texts, images)
+	g.Backward(loss)
+	t.optimizer.Optimize()
+	ClampLogitScale(t.model)
+	return loss.ScalarValue()
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package imageclassifier provides an HTTP server classifying images with a ResNet model, with
+// the same responses of the text classification servers.
+package imageclassifier
+
+import (
+	"bytes"
+	"encoding/json"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/convolution/resnet"
+	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+	"github.com/nlpodyssey/spago/pkg/vision/imagetensor"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Server contains everything needed to run an image classification server.
+type Server struct {
+	model *resnet.Model
+	// Config converts the images into the input of the model.
+	Config          imagetensor.Config
+	TimeoutSeconds  int
+	MaxRequestBytes int
+	// AdminAddress, if not empty, is the address of a separate listener serving the debug
+	// endpoints under /debug/ (see httputils.RunAdminServer).
+	AdminAddress string
+}
+
+// NewServer returns a new Server, converting the images by the given configuration, which must
+// match the input channels of the model.
+func NewServer(model *resnet.Model, config imagetensor.Config) *Server {
+	if config.Channels() != model.Config.InputChannels {
+		panic("imageclassifier: the images and the model have a different number of channels")
+	}
+	return &Server{
+		model:  model,
+		Config: config,
+	}
+}
+
+// StartDefaultHTTPServer is used to start a basic HTTP server.
+// If you want more control of the HTTP server you can run your own
+// HTTP router using the public handler functions.
+func (s *Server) StartDefaultHTTPServer(address, tlsCert, tlsKey string, tlsDisable bool) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/classify", s.ClassifyHandler)
+	if s.AdminAddress != "" {
+		go httputils.RunAdminServer(s.AdminAddress)
+	}
+
+	go httputils.RunHTTPServer(httputils.HTTPServerConfig{
+		Address:         address,
+		TLSDisable:      tlsDisable,
+		TLSCert:         tlsCert,
+		TLSKey:          tlsKey,
+		TimeoutSeconds:  s.TimeoutSeconds,
+		MaxRequestBytes: s.MaxRequestBytes,
+	}, mux)
+}
+
+// ClassConfidencePair associates a Confidence with a symbolic Class.
+type ClassConfidencePair struct {
+	Class      string    `json:"class"`
+	Confidence mat.Float `json:"confidence"`
+}
+
+// ClassifyResponse is a JSON-serializable server response for the "classify" requests.
+type ClassifyResponse struct {
+	Class        string                `json:"class"`
+	Confidence   mat.Float             `json:"confidence"`
+	Distribution []ClassConfidencePair `json:"distribution"`
+	// Took is the number of milliseconds it took the server to execute the request.
+	Took int64 `json:"took"`
+}
+
+// ClassifyHandler handles a classify request over HTTP. The image is the body of the request, or
+// the "image" file of a multipart form.
+func (s *Server) ClassifyHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+	w.Header().Set("Content-Type", "application/json")
+
+	var image io.Reader = req.Body
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := req.FormFile("image")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		image = file
+	}
+	channels, err := imagetensor.Load(image, s.Config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := s.Classify(channels)
+	_, pretty := req.URL.Query()["pretty"]
+	response, err := Dump(result, pretty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// Classify returns the classification of the channels of an image (see imagetensor.FromImage).
+func (s *Server) Classify(channels []mat.Matrix) *ClassifyResponse {
+	start := time.Now()
+
+	g := ag.NewGraph(ag.ConcurrentComputations(runtime.NumCPU()))
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, s.model).(*resnet.Model)
+	xs := make([]ag.Node, len(channels))
+	for i, channel := range channels {
+		xs[i] = g.NewVariable(channel, false)
+	}
+	logits := proc.Forward(xs...)[0]
+	probs := floatutils.SoftMax(logits.Value().Data())
+
+	labels := s.model.Config.Labels
+	best := floatutils.ArgMax(probs)
+	distribution := make([]ClassConfidencePair, len(probs))
+	for i, p := range probs {
+		distribution[i] = ClassConfidencePair{Class: labels[i], Confidence: p}
+	}
+	sort.SliceStable(distribution, func(i, j int) bool {
+		return distribution[i].Confidence > distribution[j].Confidence
+	})
+	return &ClassifyResponse{
+		Class:        labels[best],
+		Confidence:   probs[best],
+		Distribution: distribution,
+		Took:         time.Since(start).Milliseconds(),
+	}
+}
+
+// Dump serializes the given value to JSON.
+func Dump(value interface{}, pretty bool) ([]byte, error) {
+	buf := bytes.NewBufferString("")
+	enc := json.NewEncoder(buf)
+	if pretty {
+		enc.SetIndent("", "    ")
+	}
+	enc.SetEscapeHTML(true)
+	err := enc.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
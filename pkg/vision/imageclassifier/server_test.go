@@ -0,0 +1,92 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package imageclassifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/nlpodyssey/spago/pkg/mat32/rand"
+	"github.com/nlpodyssey/spago/pkg/ml/initializers"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/convolution/resnet"
+	"github.com/nlpodyssey/spago/pkg/vision/imagetensor"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestServer() *Server {
+	model := resnet.New(resnet.Config{
+		InputChannels: 3,
+		Channels:      []int{2},
+		Blocks:        []int{1},
+		Labels:        []string{"cat", "dog", "bird"},
+	})
+	rndGen := rand.NewLockedRand(42)
+	nn.ForEachParam(model, func(param nn.Param) {
+		initializers.Uniform(param.Value(), -0.5, 0.5, rndGen)
+	})
+	return NewServer(model, imagetensor.Config{Width: 4, Height: 4})
+}
+
+func newTestPNG(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 6, 6))
+	for i := 0; i < 6; i++ {
+		img.Set(i, i, color.RGBA{R: 200, G: 100, A: 255})
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestServer_ClassifyHandler(t *testing.T) {
+	s := newTestServer()
+
+	rec := httptest.NewRecorder()
+	s.ClassifyHandler(rec, httptest.NewRequest(http.MethodPost, "/classify", bytes.NewReader(newTestPNG(t))))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response ClassifyResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response.Distribution, 3)
+	assert.Equal(t, response.Class, response.Distribution[0].Class)
+	assert.Equal(t, response.Confidence, response.Distribution[0].Confidence)
+	var sum float32
+	for _, pair := range response.Distribution {
+		sum += float32(pair.Confidence)
+	}
+	assert.InDelta(t, 1, sum, 1.0e-5)
+
+	// the same image, as the file of a multipart form
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("image", "image.png")
+	assert.NoError(t, err)
+	_, err = part.Write(newTestPNG(t))
+	assert.NoError(t, err)
+	assert.NoError(t, form.Close())
+	req := httptest.NewRequest(http.MethodPost, "/classify", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec = httptest.NewRecorder()
+	s.ClassifyHandler(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var multipartResponse ClassifyResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &multipartResponse))
+	assert.Equal(t, response.Distribution, multipartResponse.Distribution)
+
+	rec = httptest.NewRecorder()
+	s.ClassifyHandler(rec, httptest.NewRequest(http.MethodPost, "/classify", bytes.NewReader([]byte("text"))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestNewServer(t *testing.T) {
+	model := resnet.New(resnet.Config{InputChannels: 1, Channels: []int{2}, Blocks: []int{1}, Labels: []string{"a"}})
+	assert.Panics(t, func() { NewServer(model, imagetensor.Config{}) })
+	assert.NotPanics(t, func() { NewServer(model, imagetensor.Config{Grayscale: true}) })
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package imagetensor converts images into the input of the two-dimensional convolution layers:
+// a matrix for each channel, with a row for each line of pixels, resized and normalized as the
+// model expects. The JPEG, PNG and GIF formats are supported.
+package imagetensor
+
+import (
+	"fmt"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/utils"
+	"image"
+	_ "image/gif"  // registers the GIF format
+	_ "image/jpeg" // registers the JPEG format
+	_ "image/png"  // registers the PNG format
+	"io"
+	"os"
+)
+
+// Config provides configuration settings for the conversion of the images.
+type Config struct {
+	// Width and Height are the size the images are resized to; the original size is kept if zero.
+	Width  int
+	Height int
+	// Grayscale converts the images to a single channel, instead of the red, green and blue ones.
+	Grayscale bool
+	// Mean and Std, if not empty, standardize each channel: the values in [0, 1] are shifted by
+	// the mean and divided by the standard deviation of the channel.
+	Mean []mat.Float
+	Std  []mat.Float
+}
+
+// ImageNetConfig returns the configuration of the models trained on ImageNet: RGB images of the
+// given size, standardized with the mean and the standard deviation of the ImageNet channels.
+func ImageNetConfig(size int) Config {
+	return Config{
+		Width:  size,
+		Height: size,
+		Mean:   []mat.Float{0.485, 0.456, 0.406},
+		Std:    []mat.Float{0.229, 0.224, 0.225},
+	}
+}
+
+// Channels returns the number of channels of the converted images.
+func (c Config) Channels() int {
+	if c.Grayscale {
+		return 1
+	}
+	return 3
+}
+
+// LoadFile reads the image at the given path and converts it (see FromImage).
+func LoadFile(filename string, config Config) ([]mat.Matrix, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f, config)
+}
+
+// Load decodes an image and converts it (see FromImage).
+func Load(r io.Reader, config Config) ([]mat.Matrix, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("imagetensor: %w", err)
+	}
+	return FromImage(img, config)
+}
+
+// FromImage converts the image into a matrix for each channel, resized and normalized by the
+// configuration. The values are in [0, 1] before the standardization.
+func FromImage(img image.Image, config Config) ([]mat.Matrix, error) {
+	numChannels := config.Channels()
+	if (len(config.Mean) > 0 && len(config.Mean) != numChannels) || len(config.Mean) != len(config.Std) {
+		return nil, fmt.Errorf("imagetensor: the mean and the std must have a value for each of the %d channels", numChannels)
+	}
+	if config.Width > 0 && config.Height > 0 {
+		img = Resize(img, config.Width, config.Height)
+	}
+	bounds := img.Bounds()
+	rows, cols := bounds.Dy(), bounds.Dx()
+	channels := make([]mat.Matrix, numChannels)
+	for c := range channels {
+		channels[c] = mat.NewEmptyDense(rows, cols)
+	}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			values := []mat.Float{mat.Float(r) / 0xffff, mat.Float(g) / 0xffff, mat.Float(b) / 0xffff}
+			if config.Grayscale {
+				// ITU-R BT.601 luma
+				values = []mat.Float{0.299*values[0] + 0.587*values[1] + 0.114*values[2]}
+			}
+			for c, v := range values {
+				if len(config.Mean) > 0 {
+					v = (v - config.Mean[c]) / config.Std[c]
+				}
+				channels[c].Set(y, x, v)
+			}
+		}
+	}
+	return channels, nil
+}
+
+// Resize returns the image resized to the given width and height by bilinear interpolation.
+func Resize(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	out := image.NewRGBA64(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		// the centers of the pixels are aligned
+		sy := clamp((float64(y)+0.5)*float64(srcH)/float64(height)-0.5, float64(srcH-1))
+		y0 := int(sy)
+		y1, fy := utils.MinInt(y0+1, srcH-1), sy-float64(y0)
+		for x := 0; x < width; x++ {
+			sx := clamp((float64(x)+0.5)*float64(srcW)/float64(width)-0.5, float64(srcW-1))
+			x0 := int(sx)
+			x1, fx := utils.MinInt(x0+1, srcW-1), sx-float64(x0)
+			var rgba [4]float64
+			for _, p := range []struct {
+				x, y int
+				w    float64
+			}{
+				{x0, y0, (1 - fx) * (1 - fy)},
+				{x1, y0, fx * (1 - fy)},
+				{x0, y1, (1 - fx) * fy},
+				{x1, y1, fx * fy},
+			} {
+				r, g, b, a := img.At(bounds.Min.X+p.x, bounds.Min.Y+p.y).RGBA()
+				rgba[0] += p.w * float64(r)
+				rgba[1] += p.w * float64(g)
+				rgba[2] += p.w * float64(b)
+				rgba[3] += p.w * float64(a)
+			}
+			i := out.PixOffset(x, y)
+			for c, v := range rgba {
+				u := uint16(v + 0.5)
+				out.Pix[i+2*c], out.Pix[i+2*c+1] = uint8(u>>8), uint8(u)
+			}
+		}
+	}
+	return out
+}
+
+// clamp returns v limited to [0, max].
+func clamp(v, max float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
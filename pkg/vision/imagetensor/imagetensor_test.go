@@ -0,0 +1,78 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package imagetensor
+
+import (
+	"bytes"
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// newTestImage returns a 4x2 image: the left half is red, the right half is white.
+func newTestImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 2 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestFromImage(t *testing.T) {
+	channels, err := FromImage(newTestImage(), Config{})
+	assert.NoError(t, err)
+	assert.Len(t, channels, 3)
+	rows, cols := channels[0].Dims()
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, 4, cols)
+	assert.Equal(t, []mat.Float{1, 1, 1, 1, 1, 1, 1, 1}, channels[0].Data())
+	assert.Equal(t, []mat.Float{0, 0, 1, 1, 0, 0, 1, 1}, channels[1].Data())
+
+	channels, err = FromImage(newTestImage(), Config{Grayscale: true, Mean: []mat.Float{0.5}, Std: []mat.Float{0.5}})
+	assert.NoError(t, err)
+	assert.Len(t, channels, 1)
+	assert.InDeltaSlice(t, []mat.Float{-0.402, -0.402, 1, 1, -0.402, -0.402, 1, 1}, channels[0].Data(), 1.0e-3)
+
+	_, err = FromImage(newTestImage(), Config{Mean: []mat.Float{0.5}, Std: []mat.Float{0.5}})
+	assert.Error(t, err)
+}
+
+func TestResize(t *testing.T) {
+	resized := Resize(newTestImage(), 2, 1)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), resized.Bounds())
+	_, g, _, _ := resized.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0), g)
+	_, g, _, _ = resized.At(1, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), g)
+
+	// the upscaling interpolates between the pixels
+	channels, err := FromImage(newTestImage(), Config{Width: 8, Height: 2})
+	assert.NoError(t, err)
+	row := channels[1].Data()[:8]
+	assert.Equal(t, mat.Float(0), row[0])
+	assert.Equal(t, mat.Float(1), row[7])
+	assert.True(t, row[3] > 0 && row[3] < 1)
+}
+
+func TestLoad(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, newTestImage()))
+	channels, err := Load(&buf, ImageNetConfig(2))
+	assert.NoError(t, err)
+	assert.Len(t, channels, 3)
+	assert.InDelta(t, (1-0.485)/0.229, channels[0].At(0, 0), 1.0e-5)
+
+	_, err = Load(bytes.NewReader([]byte("not an image")), Config{})
+	assert.Error(t, err)
+}
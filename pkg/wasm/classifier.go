@@ -0,0 +1,65 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasm
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+)
+
+// Classifier is a lightweight classifier of feature vectors, e.g. a small feed-forward network
+// distilled from a larger model, which can be exported with its labels to run in the browser.
+type Classifier struct {
+	// Labels are the classes predicted by the model, in the same order as its outputs.
+	Labels []string
+	// Model maps a feature vector to the logits of the labels, e.g. a stack.Model of
+	// linear and activation layers.
+	Model nn.StandardModel
+}
+
+// NewClassifier returns a new Classifier.
+func NewClassifier(labels []string, model nn.StandardModel) *Classifier {
+	return &Classifier{
+		Labels: labels,
+		Model:  model,
+	}
+}
+
+// Marshal returns the gob encoding of the classifier, which can be loaded in the browser.
+func (c *Classifier) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalClassifier decodes a Classifier from its gob encoding.
+func UnmarshalClassifier(data []byte) (*Classifier, error) {
+	c := new(Classifier)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(c); err != nil {
+		return nil, err
+	}
+	if c.Model == nil || len(c.Labels) == 0 {
+		return nil, fmt.Errorf("wasm: the classifier has no model or labels")
+	}
+	return c, nil
+}
+
+// Classify returns the probability distribution of the labels for the given features.
+func (c *Classifier) Classify(features []mat.Float) []mat.Float {
+	g := ag.NewGraph()
+	defer g.Clear()
+	proc := nn.Reify(nn.Context{Graph: g, Mode: nn.Inference}, c.Model).(nn.StandardModel)
+	x := g.NewVariable(mat.NewVecDense(features), false)
+	logits := proc.Forward(x)[0]
+	return floatutils.SoftMax(logits.Value().Data())
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasm
+
+import (
+	"testing"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/ml/ag"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/activation"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/stack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifier_Classify(t *testing.T) {
+	classifier := newTestClassifier()
+	probs := classifier.Classify([]mat.Float{1.0, 2.0})
+	assert.InDeltaSlice(t, []mat.Float{0.0900306, 0.2447285, 0.6652409}, probs, 1.0e-06)
+}
+
+func TestClassifier_Marshal(t *testing.T) {
+	data, err := newTestClassifier().Marshal()
+	require.NoError(t, err)
+
+	classifier, err := UnmarshalClassifier(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, classifier.Labels)
+	probs := classifier.Classify([]mat.Float{1.0, 2.0})
+	assert.InDeltaSlice(t, []mat.Float{0.0900306, 0.2447285, 0.6652409}, probs, 1.0e-06)
+}
+
+func TestUnmarshalClassifier(t *testing.T) {
+	data, err := NewClassifier(nil, nil).Marshal()
+	require.NoError(t, err)
+	_, err = UnmarshalClassifier(data)
+	assert.Error(t, err)
+
+	_, err = UnmarshalClassifier([]byte("foo"))
+	assert.Error(t, err)
+}
+
+func newTestClassifier() *Classifier {
+	layer := linear.New(2, 3)
+	layer.W.Value().SetData([]mat.Float{
+		1.0, 0.0,
+		0.0, 1.0,
+		1.0, 1.0,
+	})
+	layer.B.Value().SetData([]mat.Float{0.0, 0.0, 0.0})
+	return NewClassifier([]string{"a", "b", "c"}, stack.New(layer, activation.New(ag.OpIdentity)))
+}
@@ -0,0 +1,220 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build js,wasm
+
+// Package wasm exposes lightweight spaGO models to JavaScript, when compiled to WebAssembly
+// (GOOS=js GOARCH=wasm), so that they can run client-side in the browser.
+//
+// The models are loaded from the bytes of their files (e.g. fetched from the server), and
+// referenced from JavaScript with numeric handles. The functions never throw: in case of failure,
+// they return an object with an "error" message.
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"syscall/js"
+
+	mat "github.com/nlpodyssey/spago/pkg/mat32"
+	"github.com/nlpodyssey/spago/pkg/mat32/floatutils"
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/charlm"
+)
+
+const (
+	defaultMaxCharacters = 100
+	defaultTemperature   = 1.0
+)
+
+var (
+	mu     sync.Mutex
+	models = make(map[int]interface{})
+	nextID = 1
+)
+
+// Register sets a global JavaScript object with the given name, exposing these functions:
+//
+//	loadCharLM(bytes: Uint8Array) => {id}
+//	generateText(id, prefix: string, options?: {maxCharacters, temperature, stopAtEOS}) => {text, logProb}
+//	perplexity(id, text: string) => {perplexity}
+//	loadClassifier(bytes: Uint8Array) => {id, labels}
+//	classify(id, features: number[] | Float32Array) => {class, confidence, distribution}
+//	free(id)
+//
+// The program must not exit after the registration, e.g. by blocking with "select {}".
+func Register(name string) {
+	js.Global().Set(name, js.ValueOf(map[string]interface{}{
+		"loadCharLM":     js.FuncOf(loadCharLM),
+		"generateText":   js.FuncOf(generateText),
+		"perplexity":     js.FuncOf(perplexity),
+		"loadClassifier": js.FuncOf(loadClassifier),
+		"classify":       js.FuncOf(classify),
+		"free":           js.FuncOf(free),
+	}))
+}
+
+func loadCharLM(_ js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return failure(fmt.Errorf("wasm: missing model bytes"))
+	}
+	data, err := copyBytes(args[0])
+	if err != nil {
+		return failure(err)
+	}
+	model := new(charlm.Model)
+	if _, err := nn.ReadModel(bytes.NewReader(data), int64(len(data)), model); err != nil {
+		return failure(err)
+	}
+	return map[string]interface{}{"id": store(model)}
+}
+
+func generateText(_ js.Value, args []js.Value) interface{} {
+	model, err := charLM(args)
+	if err != nil {
+		return failure(err)
+	}
+	config := charlm.GeneratorConfig{
+		MaxCharacters: defaultMaxCharacters,
+		StopAtEOS:     true,
+		Temperature:   defaultTemperature,
+	}
+	prefix := ""
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		prefix = args[1].String()
+	}
+	if len(args) > 2 && args[2].Type() == js.TypeObject {
+		options := args[2]
+		if v := options.Get("maxCharacters"); v.Type() == js.TypeNumber {
+			config.MaxCharacters = v.Int()
+		}
+		if v := options.Get("temperature"); v.Type() == js.TypeNumber {
+			config.Temperature = mat.Float(v.Float())
+		}
+		if v := options.Get("stopAtEOS"); v.Type() == js.TypeBoolean {
+			config.StopAtEOS = v.Bool()
+		}
+	}
+	text, logProb := charlm.NewGenerator(model, config).GenerateText(prefix)
+	return map[string]interface{}{
+		"text":    text,
+		"logProb": float64(logProb),
+	}
+}
+
+func perplexity(_ js.Value, args []js.Value) interface{} {
+	model, err := charLM(args)
+	if err != nil {
+		return failure(err)
+	}
+	if len(args) < 2 || args[1].Type() != js.TypeString {
+		return failure(fmt.Errorf("wasm: missing text"))
+	}
+	return map[string]interface{}{
+		"perplexity": float64(charlm.CalculatePerplexity(model, args[1].String())),
+	}
+}
+
+func loadClassifier(_ js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return failure(fmt.Errorf("wasm: missing model bytes"))
+	}
+	data, err := copyBytes(args[0])
+	if err != nil {
+		return failure(err)
+	}
+	classifier, err := UnmarshalClassifier(data)
+	if err != nil {
+		return failure(err)
+	}
+	labels := make([]interface{}, len(classifier.Labels))
+	for i, label := range classifier.Labels {
+		labels[i] = label
+	}
+	return map[string]interface{}{
+		"id":     store(classifier),
+		"labels": labels,
+	}
+}
+
+func classify(_ js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return failure(fmt.Errorf("wasm: missing model id or features"))
+	}
+	classifier, ok := load(args[0]).(*Classifier)
+	if !ok {
+		return failure(fmt.Errorf("wasm: the id does not refer to a classifier"))
+	}
+	features := make([]mat.Float, args[1].Length())
+	for i := range features {
+		features[i] = mat.Float(args[1].Index(i).Float())
+	}
+	probs := classifier.Classify(features)
+	best := floatutils.ArgMax(probs)
+	distribution := make([]interface{}, len(probs))
+	for i, prob := range probs {
+		distribution[i] = map[string]interface{}{
+			"class":      classifier.Labels[i],
+			"confidence": float64(prob),
+		}
+	}
+	return map[string]interface{}{
+		"class":        classifier.Labels[best],
+		"confidence":   float64(probs[best]),
+		"distribution": distribution,
+	}
+}
+
+func free(_ js.Value, args []js.Value) interface{} {
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		mu.Lock()
+		delete(models, args[0].Int())
+		mu.Unlock()
+	}
+	return nil
+}
+
+func charLM(args []js.Value) (*charlm.Model, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("wasm: missing model id")
+	}
+	model, ok := load(args[0]).(*charlm.Model)
+	if !ok {
+		return nil, fmt.Errorf("wasm: the id does not refer to a character-level language model")
+	}
+	return model, nil
+}
+
+func store(model interface{}) int {
+	mu.Lock()
+	defer mu.Unlock()
+	id := nextID
+	nextID++
+	models[id] = model
+	return id
+}
+
+func load(id js.Value) interface{} {
+	if id.Type() != js.TypeNumber {
+		return nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return models[id.Int()]
+}
+
+// copyBytes copies the content of a JavaScript Uint8Array.
+func copyBytes(array js.Value) ([]byte, error) {
+	if !array.InstanceOf(js.Global().Get("Uint8Array")) {
+		return nil, fmt.Errorf("wasm: the model bytes must be a Uint8Array")
+	}
+	data := make([]byte, array.Length())
+	js.CopyBytesToGo(data, array)
+	return data, nil
+}
+
+func failure(err error) interface{} {
+	return map[string]interface{}{"error": err.Error()}
+}
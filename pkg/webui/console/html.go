@@ -0,0 +1,138 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package console provides a single web UI to try all the tasks of the spaGO servers: zero-shot
+// classification, question answering, named entity recognition, fill-mask and text generation.
+// Each task sends its requests to an editable endpoint, so that the console works with any loaded
+// model; the results are shown together with the latency and the raw JSON response.
+package console
+
+import (
+	"bytes"
+	"github.com/nlpodyssey/spago/pkg/webui"
+	"html/template"
+	"log"
+	"net/http"
+)
+
+const htmlTemplate = `
+<!doctype html>
+<html lang="">
+
+<head>
+	<meta charset="utf-8">
+	<title>spaGO</title>
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<style>
+		{{.CommonStyle}}
+		{{.CustomStyle}}
+	</style>
+</head>
+
+<body class="flex flex-col">
+	<header class="p-2 shadow z-10 flex">
+		<a
+			class="text-xl font-bold italic flex-grow"
+			href="https://github.com/nlpodyssey/spago"
+			target="_blank"
+		>
+			spa<em class="text-blue">GO</em>
+		</a>
+		<nav id="tasks" class="flex"></nav>
+	</header>
+
+	<main class="flex-grow overflow-hidden flex">
+		<form
+			class="bg-gray-200 flex-grow p-2 flex flex-col"
+			onsubmit="run(); return false;"
+		>
+			<div class="flex mb-2">
+				<label for="endpoint" class="p-2 text-gray">Endpoint</label>
+				<input
+					type="text"
+					id="endpoint"
+					class="flex-grow p-2 rounded shadow"
+				>
+			</div>
+			<textarea
+				id="text"
+				class="flex-grow max-h-96 resize-none bg-white p-2 rounded shadow overflow-auto"
+			></textarea>
+			<div id="params" class="mt-2 flex flex-col"></div>
+			<div class="mt-4 flex justify-end">
+				<input
+					type="submit"
+					id="submit"
+					value="Run"
+					class="rounded shadow cursor-pointer py-2 px-4 bg-blue hover:bg-light-blue"
+				>
+				<div id="loader" class="hidden"></div>
+			</div>
+		</form>
+
+		<aside id="output" class="bg-gray-300 shadow p-4 overflow-auto flex flex-col">
+			<div class="flex mb-2">
+				<span id="latency" class="flex-grow text-gray"></span>
+				<label class="text-sm text-gray">
+					<input type="checkbox" id="raw" onchange="toggleRaw()"> Raw JSON
+				</label>
+			</div>
+			<div id="result"></div>
+			<pre id="json" class="hidden text-sm overflow-auto"></pre>
+		</aside>
+	</main>
+	<script>
+		const endpoints = {{.Endpoints}};
+		{{.Script}}
+	</script>
+</body>
+
+</html>
+`
+
+// Handler is the server handler function for the multi-task console web UI, with the default endpoints.
+var Handler = NewHandler(nil)
+
+// NewHandler returns a server handler function for the multi-task console web UI, overriding the
+// default endpoints of the given tasks ("zero-shot", "qa", "ner", "fill-mask" and "generation").
+func NewHandler(endpoints map[string]string) http.HandlerFunc {
+	html := render(endpoints)
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*") // that's intended for testing purposes only
+		w.Header().Set("Content-Type", "text/html")
+		_, err := w.Write(html)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func render(endpoints map[string]string) []byte {
+	t, err := template.New("Console Web UI").Parse(htmlTemplate)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if endpoints == nil {
+		endpoints = map[string]string{}
+	}
+
+	data := struct {
+		CommonStyle template.CSS
+		CustomStyle template.CSS
+		Script      template.JS
+		Endpoints   map[string]string
+	}{
+		CommonStyle: webui.CommonStyle,
+		CustomStyle: style,
+		Script:      script,
+		Endpoints:   endpoints,
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	err = t.Execute(buf, data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return buf.Bytes()
+}
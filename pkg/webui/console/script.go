@@ -0,0 +1,256 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package console
+
+import "html/template"
+
+const script template.JS = `
+const tasks = {
+	'zero-shot': {
+		title: 'Zero-shot',
+		endpoint: '../classify-nli',
+		placeholder: 'Text to classify...',
+		params: [
+			{ id: 'labels', placeholder: 'Possible labels, comma separated...' },
+			{ id: 'template', placeholder: 'Hypothesis template (optional), e.g. "This example is {}."' },
+			{ id: 'multi-class', type: 'checkbox', label: 'Multi-class' },
+		],
+		body: (text, params) => ({
+			text,
+			possible_labels: params['labels'].split(',').map(l => l.trim()).filter(l => l.length > 0),
+			hypothesis_template: params['template'],
+			multi_class: params['multi-class'],
+		}),
+		render: (text, response) => renderDistribution(response.distribution),
+	},
+	'qa': {
+		title: 'QA',
+		endpoint: '../answer',
+		placeholder: 'Passage...',
+		params: [
+			{ id: 'question', placeholder: 'Question...' },
+		],
+		body: (passage, params) => ({ passage, question: params['question'] }),
+		render: (text, response) => renderAnswers(response.answers),
+	},
+	'ner': {
+		title: 'NER',
+		endpoint: '../tag',
+		placeholder: 'Text to analyze...',
+		params: [
+			{ id: 'merge-entities', type: 'checkbox', label: 'Merge entities', checked: true },
+		],
+		body: (text, params) => ({
+			text,
+			options: { mergeEntities: params['merge-entities'], filterNotEntities: false },
+		}),
+		render: (text, response) => renderTokens(text, response.tokens),
+	},
+	'fill-mask': {
+		title: 'Fill-mask',
+		endpoint: '../predict',
+		placeholder: 'Text with [MASK] tokens...',
+		params: [],
+		body: (text) => ({ text }),
+		render: (text, response) => renderTokens(text, response.tokens),
+	},
+	'generation': {
+		title: 'Generation',
+		endpoint: '../generate',
+		placeholder: 'Input text...',
+		params: [],
+		body: (text) => ({ text }),
+		render: (text, response) => renderText(response.text),
+	},
+};
+
+let currentTask = null;
+
+function init() {
+	const nav = document.getElementById('tasks');
+	Object.keys(tasks).forEach(name => {
+		const btn = document.createElement('button');
+		btn.id = 'task-' + name;
+		btn.innerText = tasks[name].title;
+		btn.onclick = selectTask.bind(this, name);
+		nav.appendChild(btn);
+	});
+	const name = window.location.hash.substring(1);
+	selectTask(tasks.hasOwnProperty(name) ? name : 'zero-shot');
+}
+
+function selectTask(name) {
+	if (currentTask !== null) {
+		document.getElementById('task-' + currentTask).classList.remove('active');
+	}
+	currentTask = name;
+	window.location.hash = name;
+	document.getElementById('task-' + name).classList.add('active');
+
+	const task = tasks[name];
+	document.getElementById('endpoint').value = endpoints[name] || task.endpoint;
+	document.getElementById('text').placeholder = task.placeholder;
+
+	const params = document.getElementById('params');
+	params.innerHTML = '';
+	task.params.forEach(param => {
+		const input = document.createElement('input');
+		input.id = 'param-' + param.id;
+		if (param.type === 'checkbox') {
+			input.type = 'checkbox';
+			input.checked = !!param.checked;
+			const label = document.createElement('label');
+			label.className = 'mt-2';
+			label.appendChild(input);
+			label.appendChild(document.createTextNode(' ' + param.label));
+			params.appendChild(label);
+		} else {
+			input.type = 'text';
+			input.className = 'mt-2';
+			input.placeholder = param.placeholder;
+			params.appendChild(input);
+		}
+	});
+	clearOutput();
+}
+
+function readParams(task) {
+	const values = {};
+	task.params.forEach(param => {
+		const input = document.getElementById('param-' + param.id);
+		values[param.id] = param.type === 'checkbox' ? input.checked : input.value;
+	});
+	return values;
+}
+
+async function run() {
+	const task = tasks[currentTask];
+	const text = document.getElementById('text').value;
+	try {
+		enableLoadingState();
+		clearOutput();
+
+		const start = performance.now();
+		const response = await fetch(document.getElementById('endpoint').value, {
+			method: 'POST',
+			headers: { 'Content-Type': 'application/json' },
+			body: JSON.stringify(task.body(text, readParams(task)))
+		});
+		const raw = await response.text();
+		const elapsed = Math.round(performance.now() - start);
+		document.getElementById('json').innerText = raw;
+		if (!response.ok) {
+			throw new Error(response.status + ' ' + response.statusText + '\n' + raw);
+		}
+		const json = JSON.parse(raw);
+		document.getElementById('json').innerText = JSON.stringify(json, null, 2);
+		document.getElementById('latency').innerText = 'took ' + json.took + ' ms (' + elapsed + ' ms round trip)';
+		task.render(text, json);
+	} catch (e) {
+		console.error(e);
+		showError(e);
+	}
+	disableLoadingState();
+}
+
+function renderDistribution(distribution) {
+	const result = document.getElementById('result');
+	distribution.forEach(item => {
+		const div = document.createElement('div');
+		div.className = 'item flex-col';
+		result.appendChild(div);
+
+		const row = document.createElement('div');
+		row.className = 'flex';
+		div.appendChild(row);
+		appendText(row, item.class, 'flex-grow');
+		appendText(row, item.confidence.toFixed(2), 'text-gray');
+
+		const bar = document.createElement('div');
+		bar.className = 'bar';
+		bar.style.width = (item.confidence * 100) + '%';
+		div.appendChild(bar);
+	});
+}
+
+function renderAnswers(answers) {
+	const result = document.getElementById('result');
+	if (answers.length === 0) {
+		appendText(result, 'No answers found.', 'text-gray');
+	}
+	answers.forEach(answer => {
+		const div = document.createElement('div');
+		div.className = 'item';
+		result.appendChild(div);
+		appendText(div, answer.text, 'flex-grow');
+		appendText(div, answer.confidence.toFixed(2), 'text-gray');
+	});
+}
+
+function renderTokens(text, tokens) {
+	const div = document.createElement('div');
+	div.className = 'text';
+	document.getElementById('result').appendChild(div);
+
+	const runes = Array.from(text); // the offsets are in runes
+	let lastPos = 0;
+	tokens.forEach(token => {
+		if (token.label === 'O') {
+			return;
+		}
+		div.appendChild(document.createTextNode(runes.slice(lastPos, token.start).join('')));
+		const span = document.createElement('span');
+		span.className = 'entity';
+		span.innerText = token.text;
+		div.appendChild(span);
+		appendText(span, token.label, 'label');
+		lastPos = token.end;
+	});
+	div.appendChild(document.createTextNode(runes.slice(lastPos).join('')));
+}
+
+function renderText(text) {
+	appendText(document.getElementById('result'), text, 'text item');
+}
+
+function appendText(parent, text, className) {
+	const span = document.createElement('span');
+	span.className = className;
+	span.innerText = text;
+	parent.appendChild(span);
+}
+
+function toggleRaw() {
+	const raw = document.getElementById('raw').checked;
+	document.getElementById('json').classList.toggle('hidden', !raw);
+	document.getElementById('result').classList.toggle('hidden', raw);
+}
+
+function clearOutput() {
+	document.getElementById('result').innerHTML = '';
+	document.getElementById('json').innerText = '';
+	document.getElementById('latency').innerText = '';
+}
+
+function enableLoadingState() {
+	document.getElementById('text').setAttribute('disabled', '');
+	document.getElementById('submit').classList.add('hidden');
+	document.getElementById('loader').classList.remove('hidden');
+}
+
+function disableLoadingState() {
+	document.getElementById('text').removeAttribute('disabled');
+	document.getElementById('submit').classList.remove('hidden');
+	document.getElementById('loader').classList.add('hidden');
+}
+
+function showError(e) {
+	const result = document.getElementById('result');
+	result.innerHTML = '';
+	appendText(result, e.name + '\n' + e.message, 'text-red text');
+}
+
+init();
+`
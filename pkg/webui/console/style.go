@@ -0,0 +1,63 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package console
+
+import "html/template"
+
+const style template.CSS = `
+#output {
+	width: 30rem;
+}
+
+#tasks button {
+	margin-left: .5rem;
+	padding: .25rem .5rem;
+	border-radius: 0.25rem;
+	background-color: transparent;
+	cursor: pointer;
+}
+
+#tasks button.active {
+	background-color: #8fe5fa;
+}
+
+#params input[type=text] {
+	padding: .5rem;
+	border-radius: 0.25rem;
+	box-shadow: 0 0 5px 0 rgba(0,0,0,0.1);
+}
+
+#result .item {
+	display: flex;
+	margin-bottom: .5rem;
+	padding: .5rem;
+	border-radius: 0.25rem;
+	background-color: #fff;
+	box-shadow: 0 0 5px 0 rgba(0,0,0,0.1);
+}
+
+#result .bar {
+	height: .25rem;
+	margin-top: .25rem;
+	background-color: #0aadd8;
+}
+
+#result .entity {
+	padding: 0 .15rem;
+	border-radius: 0.25rem;
+	background-color: #8fe5fa;
+}
+
+#result .entity .label {
+	margin-left: .25rem;
+	font-size: 0.75rem;
+	color: #7e8997;
+}
+
+#result .text {
+	white-space: pre-wrap;
+	line-height: 1.75rem;
+}
+`
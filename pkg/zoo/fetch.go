@@ -0,0 +1,128 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zoo
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nlpodyssey/spago/pkg/utils/httputils"
+)
+
+// fetchArchive downloads the compressed archive of the model, verifies its checksum, and extracts
+// it into the directory of the model.
+func fetchArchive(entry Entry, modelPath string) error {
+	u, err := url.Parse(entry.URL)
+	if err != nil {
+		return err
+	}
+	archiveName := path.Base(u.Path)
+	archivePath := filepath.Join(modelPath, archiveName)
+	if err := httputils.DownloadFile(archivePath, entry.URL); err != nil {
+		return err
+	}
+	defer os.Remove(archivePath)
+	if err := verifyChecksums(modelPath, map[string]string{archiveName: entry.Checksums[archiveName]}); err != nil {
+		return err
+	}
+	return extractTarGz(archivePath, modelPath)
+}
+
+// verifyChecksums verifies the SHA-256 checksums of the files in the given directory. The files
+// without an expected checksum are not verified; the files with a wrong checksum are removed.
+func verifyChecksums(dir string, checksums map[string]string) error {
+	for file, expected := range checksums {
+		if expected == "" {
+			continue
+		}
+		filename := filepath.Join(dir, file)
+		actual, err := sha256File(filename)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(actual, expected) {
+			os.Remove(filename)
+			return fmt.Errorf("zoo: checksum mismatch for `%s`: expected %s, found %s", file, expected, actual)
+		}
+	}
+	return nil
+}
+
+func sha256File(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// extractTarGz extracts the tar.gz archive into the given directory. The entries that would be
+// extracted outside of it are rejected.
+func extractTarGz(archivePath, dir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	uncompressed, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tarReader := tar.NewReader(uncompressed)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, header.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("zoo: invalid file path in archive: `%s`", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tarReader, target); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("zoo: unsupported type %d of `%s` in archive", header.Typeflag, header.Name)
+		}
+	}
+}
+
+func extractFile(r io.Reader, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
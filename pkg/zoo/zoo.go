@@ -0,0 +1,228 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zoo provides a registry of pre-trained models, so that a model can be loaded just by its
+// name, e.g. zoo.Load("valhalla/distilbart-mnli-12-3").
+//
+// The models are downloaded once into a local cache. A model registered with Register is fetched
+// from the URL of its compressed archive, or from the Hugging Face models hub, and its files are
+// verified against the expected checksums. Any other name is looked up on the Hugging Face models
+// hub, and the model is converted and loaded according to its architecture (see RegisterArchitecture).
+package zoo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bart/loader"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/bert"
+	"github.com/nlpodyssey/spago/pkg/nlp/transformers/huggingface"
+	"github.com/nlpodyssey/spago/pkg/utils/homedir"
+)
+
+const (
+	// DefaultModelFile is the file of the converted transformers, whose presence marks a model as cached.
+	DefaultModelFile = "spago_model.bin"
+	// fetchedMarker is the file created in the directory of a model once it has been fetched.
+	fetchedMarker = ".zoo-fetched"
+)
+
+// Loader loads a model from its local directory.
+type Loader func(modelPath string) (nn.Model, error)
+
+// Entry is a model of the zoo.
+type Entry struct {
+	// Name is the name of the model, e.g. "valhalla/distilbart-mnli-12-3".
+	Name string
+	// URL is the URL of the compressed archive (.tar.gz) of the converted model, whose files are at
+	// the root of the archive. If empty, the model is downloaded from the Hugging Face models hub,
+	// and converted.
+	URL string
+	// Checksums maps the files to their expected SHA-256 checksums, in hexadecimal: the name of the
+	// archive (the last element of the URL), or the files downloaded from the Hugging Face models hub.
+	Checksums map[string]string
+	// Loader loads the model. If nil, the model is loaded according to its architecture.
+	Loader Loader
+}
+
+var (
+	mu            sync.RWMutex
+	entries       = make(map[string]Entry)
+	architectures = map[string]Loader{
+		"bert":    loadBERT,
+		"electra": loadBERT,
+		"bart":    loadBART,
+		"marian":  loadBART,
+	}
+)
+
+// Register adds a model to the zoo.
+// If Register is called twice with the same name, it panics.
+func Register(entry Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := entries[entry.Name]; dup {
+		panic("zoo: Register called twice for model " + entry.Name)
+	}
+	entries[entry.Name] = entry
+}
+
+// RegisterArchitecture sets the loader of the models of the given type, which is the "model_type"
+// of their Hugging Face configuration (e.g. "bert" or "bart").
+func RegisterArchitecture(modelType string, loader Loader) {
+	mu.Lock()
+	defer mu.Unlock()
+	architectures[modelType] = loader
+}
+
+// Names returns a sorted list of the names of the registered models.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Option allows to configure Load and Fetch.
+type Option func(*options)
+
+type options struct {
+	cacheDir      string
+	forceDownload bool
+}
+
+// CacheDir sets the directory of the cache of the models (default "~/.spago").
+func CacheDir(dir string) Option {
+	return func(o *options) {
+		o.cacheDir = dir
+	}
+}
+
+// ForceDownload downloads the model again, even if it is in the cache.
+func ForceDownload() Option {
+	return func(o *options) {
+		o.forceDownload = true
+	}
+}
+
+// DefaultCacheDir returns the default directory of the cache of the models, "~/.spago".
+func DefaultCacheDir() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".spago"), nil
+}
+
+// Load returns the model with the given name, downloading it into the cache if needed.
+func Load(name string, opts ...Option) (nn.Model, error) {
+	modelPath, err := Fetch(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	mu.RLock()
+	load := entries[name].Loader
+	mu.RUnlock()
+	if load == nil {
+		if load, err = architectureLoader(modelPath); err != nil {
+			return nil, err
+		}
+	}
+	return load(modelPath)
+}
+
+// Fetch downloads the model with the given name into the cache, if needed, and returns its local
+// directory.
+func Fetch(name string, opts ...Option) (string, error) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.cacheDir == "" {
+		dir, err := DefaultCacheDir()
+		if err != nil {
+			return "", err
+		}
+		o.cacheDir = dir
+	}
+
+	modelPath := filepath.Join(o.cacheDir, name)
+	if isCached(modelPath) && !o.forceDownload {
+		return modelPath, nil
+	}
+	if err := os.MkdirAll(modelPath, 0755); err != nil {
+		return "", err
+	}
+	if err := fetch(o, name, modelPath); err != nil {
+		return "", err
+	}
+	return modelPath, ioutil.WriteFile(filepath.Join(modelPath, fetchedMarker), nil, 0644)
+}
+
+func isCached(modelPath string) bool {
+	for _, file := range []string{fetchedMarker, DefaultModelFile} {
+		if _, err := os.Stat(filepath.Join(modelPath, file)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func fetch(o options, name, modelPath string) error {
+	mu.RLock()
+	entry, registered := entries[name]
+	mu.RUnlock()
+	if registered && entry.URL != "" {
+		return fetchArchive(entry, modelPath)
+	}
+	err := huggingface.NewDownloader(o.cacheDir, name, o.forceDownload).Download()
+	if err != nil {
+		return err
+	}
+	if registered {
+		if err := verifyChecksums(modelPath, entry.Checksums); err != nil {
+			return err
+		}
+	}
+	return huggingface.NewConverter(o.cacheDir, name).Convert()
+}
+
+func architectureLoader(modelPath string) (Loader, error) {
+	config, err := huggingface.ReadCommonModelConfig(filepath.Join(modelPath, huggingface.ModelConfigFilename))
+	if err != nil {
+		return nil, err
+	}
+	modelType := config.ModelType
+	if modelType == "" {
+		modelType = "bert" // as the huggingface.Converter
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	load, ok := architectures[modelType]
+	if !ok {
+		return nil, fmt.Errorf("zoo: unsupported model type: `%s`", config.ModelType)
+	}
+	return load, nil
+}
+
+func loadBART(modelPath string) (nn.Model, error) {
+	return loader.Load(modelPath)
+}
+
+func loadBERT(modelPath string) (nn.Model, error) {
+	model, err := bert.LoadModel(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
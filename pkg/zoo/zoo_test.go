@@ -0,0 +1,164 @@
+// Copyright 2021 spaGO Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zoo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nlpodyssey/spago/pkg/ml/nn"
+	"github.com/nlpodyssey/spago/pkg/ml/nn/linear"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Archive(t *testing.T) {
+	archive := newTestArchive(t, map[string]string{"weights.txt": "foo", "sub/vocab.txt": "bar"})
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	cacheDir := newTestCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	Register(Entry{
+		Name:      "test/archive",
+		URL:       server.URL + "/models/archive.tar.gz",
+		Checksums: map[string]string{"archive.tar.gz": checksum(archive)},
+		Loader: func(modelPath string) (nn.Model, error) {
+			weights, err := ioutil.ReadFile(filepath.Join(modelPath, "weights.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, "foo", string(weights))
+			vocab, err := ioutil.ReadFile(filepath.Join(modelPath, "sub", "vocab.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, "bar", string(vocab))
+			return linear.New(1, 1), nil
+		},
+	})
+	assert.Contains(t, Names(), "test/archive")
+
+	for i := 0; i < 2; i++ {
+		model, err := Load("test/archive", CacheDir(cacheDir))
+		require.NoError(t, err)
+		assert.IsType(t, &linear.Model{}, model)
+	}
+	assert.Equal(t, 1, requests) // cached
+	assert.NoFileExists(t, filepath.Join(cacheDir, "test", "archive", "archive.tar.gz"))
+
+	_, err := Load("test/archive", CacheDir(cacheDir), ForceDownload())
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestLoad_ChecksumMismatch(t *testing.T) {
+	archive := newTestArchive(t, map[string]string{"weights.txt": "foo"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	cacheDir := newTestCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	Register(Entry{
+		Name:      "test/mismatch",
+		URL:       server.URL + "/archive.tar.gz",
+		Checksums: map[string]string{"archive.tar.gz": checksum([]byte("other"))},
+		Loader: func(string) (nn.Model, error) {
+			t.Fatal("unexpected load")
+			return nil, nil
+		},
+	})
+	_, err := Load("test/mismatch", CacheDir(cacheDir))
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(cacheDir, "test", "mismatch", "weights.txt"))
+}
+
+func TestLoad_InvalidArchivePath(t *testing.T) {
+	archive := newTestArchive(t, map[string]string{"../evil.txt": "foo"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	cacheDir := newTestCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	Register(Entry{Name: "test/evil", URL: server.URL + "/archive.tar.gz"})
+	_, err := Load("test/evil", CacheDir(cacheDir))
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(cacheDir, "test", "evil.txt"))
+}
+
+func TestLoad_Architecture(t *testing.T) {
+	cacheDir := newTestCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	modelPath := filepath.Join(cacheDir, "test", "cached")
+	require.NoError(t, os.MkdirAll(modelPath, 0755))
+	config := []byte(`{"model_type": "test-linear"}`)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(modelPath, "config.json"), config, 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(modelPath, DefaultModelFile), nil, 0644))
+
+	_, err := Load("test/cached", CacheDir(cacheDir))
+	assert.Error(t, err) // unsupported model type
+
+	RegisterArchitecture("test-linear", func(path string) (nn.Model, error) {
+		assert.Equal(t, modelPath, path)
+		return linear.New(1, 1), nil
+	})
+	model, err := Load("test/cached", CacheDir(cacheDir))
+	require.NoError(t, err)
+	assert.IsType(t, &linear.Model{}, model)
+}
+
+func TestRegister_Twice(t *testing.T) {
+	Register(Entry{Name: "test/twice"})
+	assert.Panics(t, func() {
+		Register(Entry{Name: "test/twice"})
+	})
+}
+
+func newTestCacheDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "spago-zoo-test-")
+	require.NoError(t, err)
+	return dir
+}
+
+func newTestArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}